@@ -0,0 +1,557 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/activitypub"
+	"github.com/doriancodes/linkleaf-cli/pkg/crosspost"
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+const (
+	// apKeySuffix and apFollowersSuffix name the actor's signing key and
+	// its followers, kept next to the feed.
+	apKeySuffix       = ".ap-key.pem"
+	apFollowersSuffix = ".followers.json"
+	// apPageSize is the number of activities per outbox page.
+	apPageSize = 20
+	// apMaxDeliver caps how many links of one change are delivered, so an
+	// import doesn't flood followers' timelines.
+	apMaxDeliver = 20
+	// maxInboxBody bounds an activity POSTed to the inbox.
+	maxInboxBody = 1 << 20
+)
+
+// apFollower is an actor following the feed and where to deliver to it.
+type apFollower struct {
+	Actor       string `json:"actor"`
+	Inbox       string `json:"inbox"`
+	SharedInbox string `json:"shared_inbox,omitempty"`
+}
+
+// apServer makes the served feed a followable ActivityPub actor: WebFinger,
+// the actor document, an outbox of Create activities (one Note per link),
+// and an inbox that accepts Follow and Undo. New links are delivered to
+// followers as the file changes, whoever changed it.
+type apServer struct {
+	cache *feedCache
+	base  string // public URL of the server, without trailing slash
+	user  string
+	host  string
+	key   activitypub.Key
+	path  string // followers file
+
+	mu         sync.Mutex
+	followers  []apFollower
+	deliveries sync.WaitGroup
+}
+
+// newAPServer loads (on first use, creates) the actor's key and followers.
+func newAPServer(cache *feedCache, base, user string) (*apServer, error) {
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("activitypub.url must be the server's public http(s) URL, got %q", base)
+	}
+	if user == "" {
+		user = "links"
+	}
+	path, err := feed.ExpandPath(cache.path)
+	if err != nil {
+		return nil, err
+	}
+	s := &apServer{cache: cache, base: strings.TrimSuffix(base, "/"), user: user, host: u.Host, path: path + apFollowersSuffix}
+	s.key.ID = s.actorID() + "#main-key"
+
+	keyPath := path + apKeySuffix
+	b, err := os.ReadFile(keyPath)
+	if errors.Is(err, os.ErrNotExist) {
+		if b, err = activitypub.GenerateKey(); err != nil {
+			return nil, err
+		}
+		if err := feed.WriteFileAtomic(keyPath, b, 0o600); err != nil {
+			return nil, err
+		}
+		msg.Infof("created ActivityPub key %s", keyPath)
+	} else if err != nil {
+		return nil, err
+	}
+	if s.key.Private, err = activitypub.ParseKey(b); err != nil {
+		return nil, fmt.Errorf("%s: %w", keyPath, err)
+	}
+
+	b, err = os.ReadFile(s.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(b, &s.followers); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.path, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *apServer) actorID() string         { return s.base + "/ap/actor" }
+func (s *apServer) noteID(id string) string { return s.base + "/ap/notes/" + url.PathEscape(id) }
+
+func (s *apServer) register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /.well-known/webfinger", s.webfinger)
+	mux.HandleFunc("GET /ap/actor", s.actor)
+	mux.HandleFunc("GET /ap/outbox", s.outbox)
+	mux.HandleFunc("GET /ap/followers", s.followersCollection)
+	mux.HandleFunc("GET /ap/notes/{id}", s.note)
+	mux.HandleFunc("GET /ap/notes/{id}/activity", s.note)
+	mux.HandleFunc("POST /ap/inbox", s.inbox)
+}
+
+// webfinger answers acct:user@host (and the actor URL) with the actor.
+func (s *apServer) webfinger(w http.ResponseWriter, r *http.Request) {
+	res := r.URL.Query().Get("resource")
+	if !strings.EqualFold(res, "acct:"+s.user+"@"+s.host) && res != s.actorID() {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/jrd+json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(map[string]any{
+		"subject": "acct:" + s.user + "@" + s.host,
+		"aliases": []string{s.actorID()},
+		"links": []map[string]string{
+			{"rel": "self", "type": activitypub.ContentType, "href": s.actorID()},
+			{"rel": "http://webfinger.net/rel/profile-page", "type": "text/html", "href": s.base + "/"},
+		},
+	})
+}
+
+// wantsActivity reports whether r asks for ActivityPub JSON rather than
+// a web page.
+func wantsActivity(r *http.Request) bool {
+	a := r.Header.Get("Accept")
+	return strings.Contains(a, activitypub.ContentType) || strings.Contains(a, "application/ld+json")
+}
+
+func writeActivity(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", activitypub.ContentType)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+func (s *apServer) actor(w http.ResponseWriter, r *http.Request) {
+	if !wantsActivity(r) {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	f, err := s.cache.get()
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	pub, err := activitypub.PublicKeyPEM(s.key.Private)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	si := siteInfo{}.withFeed(f)
+	a := activitypub.Actor{
+		Context:           []string{activitypub.ActivityStreams, activitypub.Security},
+		ID:                s.actorID(),
+		Type:              "Person",
+		PreferredUsername: s.user,
+		Name:              si.title(f),
+		URL:               s.base + "/",
+		Inbox:             s.base + "/ap/inbox",
+		Outbox:            s.base + "/ap/outbox",
+		Followers:         s.base + "/ap/followers",
+		PublicKey:         activitypub.PublicKey{ID: s.key.ID, Owner: s.actorID(), PublicKeyPem: pub},
+	}
+	if si.Description != "" {
+		a.Summary = "<p>" + html.EscapeString(si.Description) + "</p>"
+	}
+	writeActivity(w, a)
+}
+
+// outbox is an OrderedCollection of every link's Create, newest first,
+// paged with ?page=N.
+func (s *apServer) outbox(w http.ResponseWriter, r *http.Request) {
+	f, err := s.cache.get()
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	f = feed.Public(f, time.Now())
+	id := s.base + "/ap/outbox"
+	pages := max(1, (len(f.Links)+apPageSize-1)/apPageSize)
+	p := r.URL.Query().Get("page")
+	if p == "" {
+		writeActivity(w, map[string]any{
+			"@context":   activitypub.ActivityStreams,
+			"id":         id,
+			"type":       "OrderedCollection",
+			"totalItems": len(f.Links),
+			"first":      id + "?page=1",
+			"last":       id + "?page=" + strconv.Itoa(pages),
+		})
+		return
+	}
+	n, err := strconv.Atoi(p)
+	if err != nil || n < 1 || n > pages {
+		http.NotFound(w, r)
+		return
+	}
+	links := f.Links[(n-1)*apPageSize : min(n*apPageSize, len(f.Links))]
+	items := make([]any, len(links))
+	for i, l := range links {
+		items[i] = s.create(l)
+	}
+	page := map[string]any{
+		"@context":     activitypub.ActivityStreams,
+		"id":           id + "?page=" + p,
+		"type":         "OrderedCollectionPage",
+		"partOf":       id,
+		"orderedItems": items,
+	}
+	if n < pages {
+		page["next"] = id + "?page=" + strconv.Itoa(n+1)
+	}
+	if n > 1 {
+		page["prev"] = id + "?page=" + strconv.Itoa(n-1)
+	}
+	writeActivity(w, page)
+}
+
+// followersCollection only tells how many followers there are.
+func (s *apServer) followersCollection(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	n := len(s.followers)
+	s.mu.Unlock()
+	writeActivity(w, map[string]any{
+		"@context":   activitypub.ActivityStreams,
+		"id":         s.base + "/ap/followers",
+		"type":       "OrderedCollection",
+		"totalItems": n,
+	})
+}
+
+// note serves a link's Note, or with /activity its Create; browsers are
+// sent to the link itself.
+func (s *apServer) note(w http.ResponseWriter, r *http.Request) {
+	f, err := s.cache.get()
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	l := feed.Find(f, r.PathValue("id"))
+	if l == nil || !feed.Published(l, time.Now()) {
+		http.NotFound(w, r)
+		return
+	}
+	if !wantsActivity(r) {
+		http.Redirect(w, r, l.Url, http.StatusSeeOther)
+		return
+	}
+	v := s.create(l)
+	if !strings.HasSuffix(r.URL.Path, "/activity") {
+		v = v["object"].(map[string]any)
+	}
+	v["@context"] = activitypub.ActivityStreams
+	writeActivity(w, v)
+}
+
+// create is the Create activity of l's Note: the title linking to the
+// URL, the summary and the tags as hashtags, addressed to the public and
+// the followers. It has no @context, as an outbox item.
+func (s *apServer) create(l *v1.Link) map[string]any {
+	id := s.noteID(l.Id)
+	var content strings.Builder
+	fmt.Fprintf(&content, `<p><a href="%s">%s</a></p>`, html.EscapeString(l.Url), html.EscapeString(l.Title))
+	if l.Summary != "" {
+		fmt.Fprintf(&content, "<p>%s</p>", html.EscapeString(l.Summary))
+	}
+	var tags []map[string]string
+	var names []string
+	for _, t := range l.Tags {
+		if h := crosspost.Hashtag(t); h != "" {
+			tags = append(tags, map[string]string{"type": "Hashtag", "name": "#" + h})
+			names = append(names, "#"+h)
+		}
+	}
+	if len(names) > 0 {
+		fmt.Fprintf(&content, "<p>%s</p>", html.EscapeString(strings.Join(names, " ")))
+	}
+	to, cc := []string{activitypub.Public}, []string{s.base + "/ap/followers"}
+	note := map[string]any{
+		"id":           id,
+		"type":         "Note",
+		"attributedTo": s.actorID(),
+		"content":      content.String(),
+		"url":          l.Url,
+		"to":           to,
+		"cc":           cc,
+		"tag":          tags,
+	}
+	activity := map[string]any{
+		"id":     id + "/activity",
+		"type":   "Create",
+		"actor":  s.actorID(),
+		"to":     to,
+		"cc":     cc,
+		"object": note,
+	}
+	if t, ok := linkPublished(l); ok {
+		note["published"] = t.Format(time.RFC3339)
+		activity["published"] = note["published"]
+	}
+	return activity
+}
+
+// linkPublished is when l was added, else its date.
+func linkPublished(l *v1.Link) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, l.AddedAt); err == nil {
+		return t.UTC(), true
+	}
+	return linkTime(l)
+}
+
+// inbox handles Follow (accepted right away) and Undo of a Follow; other
+// activities are acknowledged and dropped. Both must be signed by the
+// actor they name.
+func (s *apServer) inbox(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxInboxBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var act struct {
+		ID     string          `json:"id"`
+		Type   string          `json:"type"`
+		Actor  string          `json:"actor"`
+		Object json.RawMessage `json:"object"`
+	}
+	if err := json.Unmarshal(body, &act); err != nil || act.Actor == "" {
+		http.Error(w, "not an activity", http.StatusBadRequest)
+		return
+	}
+	var undone struct {
+		Type   string `json:"type"`
+		Object string `json:"object"`
+	}
+	switch act.Type {
+	case "Follow":
+		var object string
+		if json.Unmarshal(act.Object, &object) != nil || object != s.actorID() {
+			http.Error(w, "can only follow "+s.actorID(), http.StatusBadRequest)
+			return
+		}
+	case "Undo":
+		if json.Unmarshal(act.Object, &undone) != nil || undone.Type != "Follow" {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+	default:
+		msg.Debugf("activitypub: ignored %s from %s", act.Type, act.Actor)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	actor, err := s.verify(r, body, act.Actor)
+	if err != nil {
+		msg.Debugf("activitypub: %s from %s: %v", act.Type, act.Actor, err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if act.Type == "Undo" {
+		if err := s.unfollow(actor.ID); err != nil {
+			serverError(w, err)
+			return
+		}
+		msg.Infof("activitypub: %s unfollowed", actor.ID)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if err := s.follow(apFollower{Actor: actor.ID, Inbox: actor.Inbox, SharedInbox: actor.SharedInbox()}); err != nil {
+		serverError(w, err)
+		return
+	}
+	msg.Infof("activitypub: %s followed", actor.ID)
+	accept := map[string]any{
+		"@context": activitypub.ActivityStreams,
+		"id":       s.actorID() + "#accept-" + randomHex(),
+		"type":     "Accept",
+		"actor":    s.actorID(),
+		"object":   json.RawMessage(body),
+	}
+	s.deliveries.Add(1)
+	go func() {
+		defer s.deliveries.Done()
+		if err := activitypub.Deliver(context.Background(), actor.Inbox, accept, s.key, activitypub.Options{}); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: activitypub: %v\n", err)
+		}
+	}()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verify checks r's HTTP signature with the key of the actor it claims
+// to come from, and returns that actor. The key must be fetched from the
+// actor's own document: keyId is the actor ID plus a fragment.
+func (s *apServer) verify(r *http.Request, body []byte, actorID string) (*activitypub.Actor, error) {
+	keyID, err := activitypub.KeyID(r)
+	if err != nil {
+		return nil, err
+	}
+	u, _, _ := strings.Cut(keyID, "#")
+	a, err := activitypub.FetchActor(r.Context(), u, s.key, activitypub.Options{})
+	if err != nil {
+		return nil, err
+	}
+	if u != a.ID || a.ID != actorID || a.PublicKey.ID != keyID || a.PublicKey.Owner != a.ID {
+		return nil, fmt.Errorf("key %s doesn't belong to %s", keyID, actorID)
+	}
+	pub, err := activitypub.ParsePublicKey(a.PublicKey.PublicKeyPem)
+	if err != nil {
+		return nil, err
+	}
+	if err := activitypub.Verify(r, body, pub); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *apServer) follow(f apFollower) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.followers = slices.DeleteFunc(s.followers, func(o apFollower) bool { return o.Actor == f.Actor })
+	s.followers = append(s.followers, f)
+	return s.saveFollowers()
+}
+
+func (s *apServer) unfollow(actor string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.followers = slices.DeleteFunc(s.followers, func(o apFollower) bool { return o.Actor == actor })
+	return s.saveFollowers()
+}
+
+// saveFollowers writes the followers file; s.mu must be held.
+func (s *apServer) saveFollowers() error {
+	b, err := json.MarshalIndent(s.followers, "", "  ")
+	if err != nil {
+		return err
+	}
+	return feed.WriteFileAtomic(s.path, append(b, '\n'), 0o644)
+}
+
+// watch delivers a Create for every link published in the feed (added,
+// released by "publish" or reaching its publish_at), and a Delete for
+// every link removed, until done is closed. It polls like WatchFeed.
+func (s *apServer) watch(done <-chan struct{}) {
+	raw, err := s.cache.get()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: activitypub: %v\n", err)
+		return
+	}
+	now := time.Now()
+	prev, due := feed.Public(raw, now), feed.NextPublish(raw, now)
+	tick := time.NewTicker(watchInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now = <-tick.C:
+		}
+		f, err := s.cache.get()
+		if err != nil {
+			msg.Debugf("activitypub: %v", err)
+			continue
+		}
+		if f == raw && (due.IsZero() || now.Before(due)) {
+			continue
+		}
+		raw, due = f, feed.NextPublish(f, now)
+		pub := feed.Public(f, now)
+		d := feed.Compare(prev, pub)
+		prev = pub
+		added := d.Added
+		if len(added) > apMaxDeliver {
+			msg.Infof("activitypub: %d links added; delivering the newest %d", len(added), apMaxDeliver)
+			added = added[:apMaxDeliver]
+		}
+		// Oldest first, so followers' timelines keep the feed's order.
+		for _, l := range slices.Backward(added) {
+			create := s.create(l)
+			create["@context"] = activitypub.ActivityStreams
+			s.deliver(create)
+		}
+		for _, l := range d.Removed {
+			s.deliver(map[string]any{
+				"@context": activitypub.ActivityStreams,
+				"id":       s.noteID(l.Id) + "#delete",
+				"type":     "Delete",
+				"actor":    s.actorID(),
+				"to":       []string{activitypub.Public},
+				"object":   map[string]string{"id": s.noteID(l.Id), "type": "Tombstone"},
+			})
+		}
+	}
+}
+
+// deliver sends activity to every follower, once per shared inbox.
+func (s *apServer) deliver(activity map[string]any) {
+	s.mu.Lock()
+	var inboxes []string
+	for _, f := range s.followers {
+		if inbox := cmp.Or(f.SharedInbox, f.Inbox); !slices.Contains(inboxes, inbox) {
+			inboxes = append(inboxes, inbox)
+		}
+	}
+	s.mu.Unlock()
+	for _, inbox := range inboxes {
+		s.deliveries.Add(1)
+		go func() {
+			defer s.deliveries.Done()
+			if err := activitypub.Deliver(context.Background(), inbox, activity, s.key, activitypub.Options{}); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: activitypub: %v\n", err)
+				return
+			}
+			msg.Debugf("activitypub: delivered %s to %s", activity["id"], inbox)
+		}()
+	}
+}
+
+// wait waits for deliveries in flight, at most until ctx is done.
+func (s *apServer) wait(ctx context.Context) {
+	finished := make(chan struct{})
+	go func() {
+		s.deliveries.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+	}
+}
+
+func randomHex() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// linkText is a link written as text for "add -" and "add -e": either a
+// JSON object or "key: value" lines, with the same keys.
+type linkText struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	URL     string   `json:"url"`
+	Date    string   `json:"date"`
+	Summary string   `json:"summary"`
+	Tags    tagsText `json:"tags"`
+	Via     string   `json:"via"`
+}
+
+// tagsText accepts tags as a JSON array or a comma-separated string.
+type tagsText []string
+
+func (t *tagsText) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = feed.SplitTags(s)
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+// parseLinkText reads a link from text. JSON is recognized by a leading
+// '{'; otherwise every non-blank line not starting with '#' must be
+// "key: value". Unknown keys are errors, so typos don't go unnoticed.
+func parseLinkText(text string) (*v1.Link, error) {
+	var lt linkText
+	if strings.HasPrefix(strings.TrimSpace(text), "{") {
+		d := json.NewDecoder(strings.NewReader(text))
+		d.DisallowUnknownFields()
+		if err := d.Decode(&lt); err != nil {
+			return nil, fmt.Errorf("parse link JSON: %w", err)
+		}
+	} else {
+		fields := map[string]*string{
+			"id": &lt.ID, "title": &lt.Title, "url": &lt.URL, "date": &lt.Date, "summary": &lt.Summary, "via": &lt.Via,
+		}
+		sc := bufio.NewScanner(strings.NewReader(text))
+		for n := 1; sc.Scan(); n++ {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" || line[0] == '#' {
+				continue
+			}
+			key, value, ok := strings.Cut(line, ":")
+			key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+			if !ok {
+				return nil, fmt.Errorf("line %d: want key: value", n)
+			}
+			if key == "tags" {
+				lt.Tags = feed.SplitTags(value)
+				continue
+			}
+			dst, ok := fields[key]
+			if !ok {
+				return nil, fmt.Errorf("line %d: unknown key %q", n, key)
+			}
+			*dst = value
+		}
+	}
+	l := &v1.Link{
+		Id:      strings.TrimSpace(lt.ID),
+		Title:   strings.TrimSpace(lt.Title),
+		Url:     strings.TrimSpace(lt.URL),
+		Date:    strings.TrimSpace(lt.Date),
+		Summary: strings.TrimSpace(lt.Summary),
+		Via:     strings.TrimSpace(lt.Via),
+		Tags:    lt.Tags,
+	}
+	if l.Date != "" {
+		d, err := feed.NormalizeDate(l.Date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("date %q: %w", l.Date, err)
+		}
+		l.Date = d
+	}
+	return l, nil
+}
+
+// readLinkText completes l, which holds the add flags, from text: stdin
+// (flags win over its fields) or, for -e, an editor template pre-filled
+// from l (what is saved wins). The date defaults to today.
+func readLinkText(l *v1.Link, stdin, normalize bool) error {
+	today := time.Now().Format(feed.DateLayout)
+	var text string
+	if stdin {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		text = string(b)
+	} else {
+		if l.Url == "" {
+			l.Url = clipboardURL()
+		}
+		if l.Date == "" {
+			l.Date = today
+		}
+		var err error
+		if text, err = editText(linkTemplate(l), "link-*.txt"); err != nil {
+			return err
+		}
+	}
+	in, err := parseLinkText(text)
+	if err != nil {
+		return err
+	}
+	if stdin {
+		fillLink(l, in)
+	} else {
+		fillLink(in, &v1.Link{Id: l.Id})
+		l.Id, l.Title, l.Url, l.Date, l.Summary, l.Via, l.Tags = in.Id, in.Title, in.Url, in.Date, in.Summary, in.Via, in.Tags
+	}
+	if l.Date == "" {
+		l.Date = today
+	}
+	if l.Title == "" || l.Url == "" {
+		return errors.New("the link needs a title and a URL; nothing written")
+	}
+	if l.Tags, err = validTags(l.Tags); err != nil {
+		return err
+	}
+	if normalize && l.Tags != nil {
+		l.Tags = feed.NormalizeTags(l.Tags)
+	}
+	l.Tags = withDefaultTags(l.Tags)
+	return nil
+}
+
+// linkTemplate renders l as the "key: value" text "add -e" opens.
+func linkTemplate(l *v1.Link) string {
+	return fmt.Sprintf(`# New link: fill in the fields, save and quit. Lines starting with # are
+# ignored; an empty title or URL aborts. Tags are comma-separated.
+title: %s
+url: %s
+date: %s
+tags: %s
+summary: %s
+via: %s
+`, l.Title, l.Url, l.Date, strings.Join(l.Tags, ", "), l.Summary, l.Via)
+}
+
+// fillLink copies src's non-empty fields into the empty fields of dst.
+func fillLink(dst, src *v1.Link) {
+	for _, f := range [][2]*string{
+		{&dst.Id, &src.Id}, {&dst.Title, &src.Title}, {&dst.Url, &src.Url},
+		{&dst.Date, &src.Date}, {&dst.Summary, &src.Summary}, {&dst.Via, &src.Via},
+	} {
+		if *f[0] == "" {
+			*f[0] = *f[1]
+		}
+	}
+	if len(dst.Tags) == 0 {
+		dst.Tags = src.Tags
+	}
+}
+
+// clipboardURL returns the clipboard's contents if they are an http(s)
+// URL, using whichever clipboard tool the platform has; "" otherwise.
+func clipboardURL() string {
+	var tools [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		tools = [][]string{{"pbpaste"}}
+	case "windows":
+		tools = [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}}
+	default:
+		tools = [][]string{{"wl-paste", "-n"}, {"xclip", "-o", "-selection", "clipboard"}, {"xsel", "-ob"}}
+	}
+	for _, argv := range tools {
+		if _, err := exec.LookPath(argv[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(argv[0], argv[1:]...).Output()
+		if err != nil {
+			continue
+		}
+		s := strings.TrimSpace(string(out))
+		if u, err := url.Parse(s); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && !strings.ContainsAny(s, " \n") {
+			return s
+		}
+		return ""
+	}
+	return ""
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// maxAPIBody bounds a POST or PATCH body.
+	maxAPIBody = 64 << 10
+	// apiPageSize is the page size when a list request gives no limit.
+	apiPageSize = 50
+)
+
+// apiServer is the JSON REST API of serve under /api/v1: the FeedService
+// calls (see feedService) over HTTP, with protojson bodies and answers.
+// Every request needs one of the config's serve.api_tokens.
+type apiServer struct {
+	svc     *feedService
+	tokens  []string
+	origins []string
+	// prefix replaces /api/v1 in the routes (the daemon's named feeds);
+	// the Pinboard API is only served without one.
+	prefix string
+}
+
+// apiHandler answers one API request with a status and a message, or an
+// error carrying a gRPC status code (see httpStatus).
+type apiHandler func(w http.ResponseWriter, r *http.Request) (int, proto.Message, error)
+
+func (a *apiServer) register(mux *http.ServeMux) {
+	p := a.root()
+	mux.HandleFunc("OPTIONS "+p+"/", func(w http.ResponseWriter, r *http.Request) {
+		a.cors(w, r)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET "+p+"/feed", a.handle(a.getFeed))
+	mux.HandleFunc("GET "+p+"/links", a.handle(a.listLinks))
+	mux.HandleFunc("POST "+p+"/links", a.handle(a.addLink))
+	mux.HandleFunc("GET "+p+"/links/{id}", a.handle(a.getLink))
+	mux.HandleFunc("PATCH "+p+"/links/{id}", a.handle(a.updateLink))
+	mux.HandleFunc("DELETE "+p+"/links/{id}", a.handle(a.deleteLink))
+	if a.prefix == "" {
+		a.registerPinboard(mux)
+	}
+}
+
+// root is the path the API's routes are under.
+func (a *apiServer) root() string { return cmp.Or(a.prefix, "/api/v1") }
+
+// cors lets browsers on the configured origins call the API.
+func (a *apiServer) cors(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" || !slices.Contains(a.origins, origin) && !slices.Contains(a.origins, "*") {
+		return
+	}
+	h := w.Header()
+	h.Set("Access-Control-Allow-Origin", origin)
+	h.Add("Vary", "Origin")
+	h.Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE")
+	h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+	h.Set("Access-Control-Expose-Headers", "Link, Location")
+}
+
+func (a *apiServer) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	match := 0
+	for _, t := range a.tokens {
+		match |= subtle.ConstantTimeCompare([]byte(token), []byte(t))
+	}
+	return match == 1
+}
+
+func (a *apiServer) handle(h apiHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.cors(w, r)
+		if !a.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="linkleaf"`)
+			apiError(w, http.StatusUnauthorized, "bad or missing token")
+			return
+		}
+		code, m, err := h(w, r)
+		if err != nil {
+			st := status.Convert(err)
+			code := httpStatus(st.Code())
+			if code == http.StatusInternalServerError {
+				fmt.Fprintln(os.Stderr, "error:", st.Message())
+			}
+			apiError(w, code, st.Message())
+			return
+		}
+		var buf bytes.Buffer
+		if err := writeJSONLine(&buf, m); err != nil {
+			serverError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		w.Write(buf.Bytes())
+	}
+}
+
+// httpStatus maps the status codes feedService returns to HTTP.
+func httpStatus(c codes.Code) int {
+	switch c {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists:
+		return http.StatusConflict
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.FailedPrecondition:
+		return http.StatusUnprocessableEntity
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func apiError(w http.ResponseWriter, code int, text string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{text})
+}
+
+// getFeed answers the feed's metadata: the Feed message without links.
+func (a *apiServer) getFeed(w http.ResponseWriter, r *http.Request) (int, proto.Message, error) {
+	f, err := a.svc.cache.get()
+	if err != nil {
+		return 0, nil, status.Error(codes.Unavailable, err.Error())
+	}
+	meta := &v1.Feed{Version: f.Version, Title: f.Title, Author: f.Author, GeneratedAt: f.GeneratedAt,
+		Description: f.Description, HomePageUrl: f.HomePageUrl, Icon: f.Icon, Lang: f.Lang}
+	return http.StatusOK, meta, nil
+}
+
+// listLinks takes the ListLinksRequest fields as query parameters (tag
+// repeatable, q for the query) and links the next page in a Link header.
+func (a *apiServer) listLinks(w http.ResponseWriter, r *http.Request) (int, proto.Message, error) {
+	q := r.URL.Query()
+	req := &v1.ListLinksRequest{
+		Tags:   q["tag"],
+		Domain: q.Get("domain"),
+		After:  q.Get("after"),
+		Before: q.Get("before"),
+		Query:  q.Get("q"),
+		Limit:  apiPageSize,
+	}
+	for name, dst := range map[string]*bool{"unread": &req.Unread, "starred": &req.Starred} {
+		if v := q.Get(name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return 0, nil, status.Errorf(codes.InvalidArgument, "%s: want true or false, got %q", name, v)
+			}
+			*dst = b
+		}
+	}
+	for name, dst := range map[string]*uint32{"offset": &req.Offset, "limit": &req.Limit} {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.ParseUint(v, 10, 31)
+			if err != nil {
+				return 0, nil, status.Errorf(codes.InvalidArgument, "%s: want a number, got %q", name, v)
+			}
+			*dst = uint32(n)
+		}
+	}
+	resp, err := a.svc.ListLinks(r.Context(), req)
+	if err != nil {
+		return 0, nil, err
+	}
+	if resp.More {
+		next := url.Values{}
+		for k, v := range q {
+			next[k] = v
+		}
+		next.Set("offset", strconv.Itoa(int(req.Offset)+len(resp.Links)))
+		next.Set("limit", strconv.Itoa(int(req.Limit)))
+		w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, next.Encode()))
+	}
+	return http.StatusOK, resp, nil
+}
+
+func (a *apiServer) getLink(w http.ResponseWriter, r *http.Request) (int, proto.Message, error) {
+	l, err := a.svc.cache.find(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if l == nil {
+		return 0, nil, status.Errorf(codes.NotFound, "no link with id %q", r.PathValue("id"))
+	}
+	return http.StatusOK, l, nil
+}
+
+// readLink decodes a protojson Link body; keys lists its top-level JSON
+// keys.
+func readLink(w http.ResponseWriter, r *http.Request) (l *v1.Link, keys []string, err error) {
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAPIBody))
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "read body: %v", err)
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "parse body: %v", err)
+	}
+	l = &v1.Link{}
+	if err := protojson.Unmarshal(b, l); err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "parse body: %v", err)
+	}
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	return l, keys, nil
+}
+
+func (a *apiServer) addLink(w http.ResponseWriter, r *http.Request) (int, proto.Message, error) {
+	l, _, err := readLink(w, r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if l, err = a.svc.AddLink(r.Context(), &v1.AddLinkRequest{Link: l}); err != nil {
+		return 0, nil, err
+	}
+	w.Header().Set("Location", a.root()+"/links/"+url.PathEscape(l.Id))
+	return http.StatusCreated, l, nil
+}
+
+// updateLink changes the fields present in the body (JSON merge patch:
+// null clears a field); the ID can't change.
+func (a *apiServer) updateLink(w http.ResponseWriter, r *http.Request) (int, proto.Message, error) {
+	id := r.PathValue("id")
+	l, keys, err := readLink(w, r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if l.Id != "" && l.Id != id {
+		return 0, nil, status.Error(codes.InvalidArgument, "the id can't be changed")
+	}
+	l.Id = id
+	fields := l.ProtoReflect().Descriptor().Fields()
+	req := &v1.UpdateLinkRequest{Link: l}
+	for _, k := range keys {
+		fd := fields.ByJSONName(k)
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(k))
+		}
+		if fd != nil && fd.Name() != "id" {
+			req.Fields = append(req.Fields, string(fd.Name()))
+		}
+	}
+	if len(req.Fields) == 0 {
+		return a.getLink(w, r)
+	}
+	if l, err = a.svc.UpdateLink(r.Context(), req); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, l, nil
+}
+
+func (a *apiServer) deleteLink(w http.ResponseWriter, r *http.Request) (int, proto.Message, error) {
+	resp, err := a.svc.DeleteLink(r.Context(), &v1.DeleteLinkRequest{Id: r.PathValue("id")})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, resp.Link, nil
+}
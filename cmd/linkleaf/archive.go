@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/archive"
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+func cmdArchive() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("archive", flag.ExitOnError)
+	var file, id, to, dir string
+	var all, force bool
+	var timeout time.Duration
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID of the link to archive")
+	fs.BoolVar(&all, "all", false, "archive every link that has no archive_url yet")
+	fs.StringVar(&to, "to", "wayback", "wayback (web.archive.org) or local (a snapshot in -dir)")
+	fs.StringVar(&dir, "dir", "snapshots", "-to local: directory for <id>.html snapshots")
+	fs.BoolVar(&force, "force", false, "archive again links that already have an archive_url")
+	fs.DurationVar(&timeout, "timeout", time.Minute, "per-link timeout")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || (id == "") == !all || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		if to != "wayback" && to != "local" {
+			die(invalid(fmt.Errorf("-to: want wayback or local, got %q", to)))
+		}
+
+		// Archive first, lock after: captures can take minutes, and the
+		// results are applied to a fresh load by ID.
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		var links []*v1.Link
+		if id != "" {
+			l, err := feed.FindPrefix(f, id)
+			if err != nil {
+				die(err)
+			}
+			links = append(links, l)
+		} else {
+			for _, l := range f.Links {
+				if force || l.ArchiveUrl == "" {
+					links = append(links, l)
+				}
+			}
+		}
+
+		opts := archive.Options{Timeout: timeout}
+		archived := map[string]string{}
+		failed := 0
+		for _, l := range links {
+			if l.ArchiveUrl != "" && !force {
+				msg.Infof("[%s] already archived: %s", l.Id, l.ArchiveUrl)
+				continue
+			}
+			var where string
+			var err error
+			if to == "wayback" {
+				where, err = archive.Wayback(context.Background(), l.Url, opts)
+			} else {
+				where, err = saveSnapshot(dir, l, opts)
+			}
+			if err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "warning: [%s] %v\n", l.Id, err)
+				continue
+			}
+			msg.Debugf("archived %s as %s", l.Url, where)
+			archived[l.Id] = where
+		}
+
+		if len(archived) > 0 {
+			sf.lock(file)
+			f, err := mustLoad(file)
+			if err != nil {
+				die(err)
+			}
+			sf.loaded(f)
+			for id, where := range archived {
+				if l := feed.Find(f, id); l != nil {
+					l.ArchiveUrl = where
+				}
+			}
+			f.GeneratedAt = feed.NowRFC3339()
+			if err := sf.save(file, f); err != nil {
+				die(err)
+			}
+		}
+		msg.Infof("archived %d links, %d failed", len(archived), failed)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+// saveSnapshot writes a readable copy of l's page to dir/<id>.html and
+// returns that path.
+func saveSnapshot(dir string, l *v1.Link, opts archive.Options) (string, error) {
+	path, err := linkFile(dir, l.Id, ".html")
+	if err != nil {
+		return "", err
+	}
+	b, err := archive.Snapshot(context.Background(), l.Url, opts)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := feed.WriteFileAtomic(path, b, 0o644); err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(path), nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+func cmdBackup() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	var file string
+	var keep int
+	var list, asJSON bool
+	fs.StringVar(&file, "file", "", "protobuf feed file (.pb), instead of the positional argument")
+	fs.IntVar(&keep, "keep", 0, "keep only the N newest snapshots (0: all)")
+	fs.BoolVar(&list, "list", false, "list the snapshots instead of taking one")
+	fs.BoolVar(&asJSON, "json", false, "with -list, print the snapshots as JSON")
+	return fs, func() {
+		path, ok := feedArg(fs, file)
+		if !ok || keep < 0 {
+			badUsage(fs)
+		}
+		if list {
+			listSnapshots(path, asJSON)
+			return
+		}
+
+		if _, err := feed.Lock(path); err != nil {
+			die(fmt.Errorf("lock %s: %w", path, err))
+		}
+		s, created, err := feed.Backup(path, keep)
+		if err != nil {
+			die(fmt.Errorf("backup %s: %w", path, err))
+		}
+		if !created {
+			msg.Infof("%s is unchanged since snapshot %s (%s)", path, s.Hash, s.Time.Format(time.RFC3339))
+			return
+		}
+		msg.Infof("snapshot %s of %s (%d bytes) in %s", s.Hash, path, s.Size, s.Path)
+	}
+}
+
+func cmdRestore() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	var file, hash string
+	fs.StringVar(&file, "file", "", "protobuf feed file (.pb), instead of the positional argument")
+	fs.StringVar(&hash, "snapshot", "", "hash (or unique prefix) of the snapshot to restore; omit to list them")
+	return fs, func() {
+		path, ok := feedArg(fs, file)
+		if !ok {
+			badUsage(fs)
+		}
+		if hash == "" {
+			listSnapshots(path, false)
+			return
+		}
+
+		if _, err := feed.Lock(path); err != nil {
+			die(fmt.Errorf("lock %s: %w", path, err))
+		}
+		s, err := feed.FindSnapshot(path, hash)
+		if err != nil {
+			die(err)
+		}
+		// Snapshot what is there now, so the restore itself can be undone.
+		var current string
+		if prev, _, err := feed.Backup(path, 0); err == nil {
+			current = prev.Hash
+		} else if !errors.Is(err, os.ErrNotExist) {
+			die(fmt.Errorf("snapshot %s before restoring: %w", path, err))
+		}
+		if err := feed.Restore(path, s); err != nil {
+			die(fmt.Errorf("restore %s: %w", path, err))
+		}
+		if current != "" && current != s.Hash {
+			msg.Infof("restored %s to snapshot %s from %s (was %s)", path, s.Hash, s.Time.Format(time.RFC3339), current)
+			return
+		}
+		msg.Infof("restored %s to snapshot %s from %s", path, s.Hash, s.Time.Format(time.RFC3339))
+	}
+}
+
+func listSnapshots(path string, asJSON bool) {
+	all, err := feed.Snapshots(path)
+	if err != nil {
+		die(err)
+	}
+	if asJSON {
+		if all == nil {
+			all = []feed.Snapshot{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(all); err != nil {
+			die(err)
+		}
+		return
+	}
+	if len(all) == 0 {
+		msg.Infof("no snapshots of %s", path)
+		return
+	}
+	for _, s := range all {
+		fmt.Printf("%s  %s  %8d bytes\n", s.Hash, s.Time.Format(time.RFC3339), s.Size)
+	}
+}
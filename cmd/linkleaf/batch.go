@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// readBatch parses "url<TAB>title<TAB>date<TAB>tags" lines for add -batch.
+// Tags are comma-separated and optional. Blank lines and lines starting
+// with '#' are ignored; invalid lines (see feed.ValidateLink, if validate)
+// and links breaking the policy are skipped and reported as warnings.
+func readBatch(r io.Reader, normalize, validate bool, policy feed.Policy) ([]*v1.Link, []lineWarning, error) {
+	var links []*v1.Link
+	var warnings []lineWarning
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		l, err := parseBatchLine(text, normalize)
+		if err == nil && validate {
+			err = feed.ValidateLink(l)
+		}
+		if err == nil {
+			if err = policy.CheckLink(l); err != nil {
+				err = fmt.Errorf("breaks the policy: %s", strings.ReplaceAll(err.Error(), "\n", "; "))
+			}
+		}
+		if err != nil {
+			warnings = append(warnings, lineWarning{line, err})
+			continue
+		}
+		links = append(links, l)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, fmt.Errorf("read batch: %w", err)
+	}
+	return links, warnings, nil
+}
+
+func parseBatchLine(text string, normalize bool) (*v1.Link, error) {
+	fields := strings.Split(text, "\t")
+	if len(fields) < 3 || len(fields) > 4 {
+		return nil, fmt.Errorf("want url<TAB>title<TAB>date[<TAB>tags], got %d fields", len(fields))
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	l := &v1.Link{Url: fields[0], Title: fields[1], Date: fields[2]}
+	var missing []string
+	for _, req := range [][2]string{{"url", l.Url}, {"title", l.Title}, {"date", l.Date}} {
+		if req[1] == "" {
+			missing = append(missing, req[0])
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	if _, err := feed.ParseDate(l.Date); err != nil {
+		return nil, fmt.Errorf("date %q: want YYYY-MM-DD", l.Date)
+	}
+	if len(fields) == 4 {
+		tags, err := validTags(feed.SplitTags(fields[3]))
+		if err != nil {
+			return nil, err
+		}
+		if normalize && tags != nil {
+			tags = feed.NormalizeTags(tags)
+		}
+		l.Tags = withDefaultTags(tags)
+	}
+	return l, nil
+}
+
+// addBatch adds every valid line of the batch file in one load/save cycle,
+// keeping the file's order at the top of the feed.
+func addBatch(path, batch, idScheme string, normalize, validate, force bool, policy feed.Policy, sf *saveFlags) {
+	genID, err := feed.IDScheme(idScheme)
+	if err != nil {
+		die(invalid(err))
+	}
+	var r io.Reader = os.Stdin
+	if batch != "-" {
+		file, err := os.Open(batch)
+		if err != nil {
+			die(err)
+		}
+		defer file.Close()
+		r = file
+	}
+	links, warnings, err := readBatch(r, normalize, validate, policy)
+	if err != nil {
+		die(err)
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s: %v; skipped\n", batch, w)
+	}
+
+	sf.lock(path)
+	opened, err := feed.OpenWith(path, loadOpts) // a missing file starts a new feed
+	if err != nil {
+		die(fmt.Errorf("load %s: %w", path, err))
+	}
+	f := opened.Feed
+	sf.loaded(f)
+
+	// The batch's links take their IDs one after another, so they can't
+	// collide with each other either.
+	taken := &v1.Feed{Links: slices.Clone(f.Links), Trash: f.Trash}
+	for _, l := range links {
+		if !force && feed.FindURL(taken, l.Url) != nil {
+			continue // importLinks skips it
+		}
+		assignID(taken, genID, l)
+		taken.Links = append(taken.Links, l)
+	}
+	added, dupes := importLinks(f, links, force)
+	if added > 0 {
+		if err := sf.save(path, f); err != nil {
+			die(err)
+		}
+	}
+	msg.Infof("added %d links to %s (%d invalid, %d already present)", added, path, len(warnings), dupes)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// bookmarkTag matches the tags that matter in a Netscape bookmark file.
+// The format is loose HTML (unclosed <DT>/<p>), so it is scanned rather
+// than parsed.
+var (
+	bookmarkTag  = regexp.MustCompile(`(?is)<(/?)(a|h3|dl|dd|dt)\b([^>]*)>`)
+	bookmarkAttr = regexp.MustCompile(`(?is)([a-z_]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+)
+
+// readBookmarks parses a browser bookmarks.html export. Each <A> becomes a
+// link: folder names (<H3>) become tags alongside any TAGS attribute,
+// ADD_DATE sets the date and added_at, and a following <DD> the summary.
+// Entries without an http(s) URL are skipped and reported as warnings.
+func readBookmarks(r io.Reader) ([]*v1.Link, []lineWarning, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read bookmarks: %w", err)
+	}
+	doc := string(b)
+	lineAt := func(off int) int { return 1 + strings.Count(doc[:off], "\n") }
+	// text returns the unescaped text from off up to the next tag.
+	text := func(off int) string {
+		end := strings.IndexByte(doc[off:], '<')
+		if end < 0 {
+			end = len(doc) - off
+		}
+		return strings.Join(strings.Fields(html.UnescapeString(doc[off:off+end])), " ")
+	}
+
+	var links []*v1.Link
+	var warnings []lineWarning
+	var folders []string // open <DL> folders; "" for ones that yield no tag
+	pending := ""        // last <H3>, applied by the <DL> that follows it
+	var last *v1.Link    // link a <DD> would describe
+	for _, m := range bookmarkTag.FindAllStringSubmatchIndex(doc, -1) {
+		closing := m[3] > m[2]
+		name := strings.ToLower(doc[m[4]:m[5]])
+		attrs := doc[m[6]:m[7]]
+		switch {
+		case name == "h3" && !closing:
+			pending = text(m[1])
+		case name == "dl" && !closing:
+			folders = append(folders, pending)
+			pending = ""
+		case name == "dl" && closing:
+			if len(folders) > 0 {
+				folders = folders[:len(folders)-1]
+			}
+		case name == "dd" && !closing:
+			if last != nil && last.Summary == "" {
+				last.Summary = text(m[1])
+			}
+			last = nil
+		case name == "dt":
+			last = nil
+		case name == "a" && !closing:
+			l, err := bookmarkLink(bookmarkAttrs(attrs), text(m[1]), folders)
+			if err != nil {
+				warnings = append(warnings, lineWarning{lineAt(m[0]), err})
+				continue
+			}
+			links = append(links, l)
+			last = l
+		}
+	}
+	return links, warnings, nil
+}
+
+func bookmarkAttrs(s string) map[string]string {
+	attrs := map[string]string{}
+	for _, m := range bookmarkAttr.FindAllStringSubmatch(s, -1) {
+		attrs[strings.ToLower(m[1])] = html.UnescapeString(m[2] + m[3] + m[4])
+	}
+	return attrs
+}
+
+func bookmarkLink(attrs map[string]string, title string, folders []string) (*v1.Link, error) {
+	href := strings.TrimSpace(attrs["href"])
+	if !strings.HasPrefix(href, "http://") && !strings.HasPrefix(href, "https://") {
+		return nil, fmt.Errorf("not a web URL: %q", href)
+	}
+	if title == "" {
+		title = href
+	}
+	added := time.Now().UTC()
+	if secs, err := strconv.ParseInt(attrs["add_date"], 10, 64); err == nil && secs > 0 {
+		// Some browsers write microseconds.
+		if secs > 1e12 {
+			secs /= 1e6
+		}
+		added = time.Unix(secs, 0).UTC()
+	}
+	var tags []string
+	for _, f := range folders {
+		if strings.TrimSpace(f) != "" {
+			tags = append(tags, feed.Slugify(f))
+		}
+	}
+	for _, t := range feed.SplitTags(attrs["tags"]) {
+		tags = append(tags, strings.Join(strings.Fields(t), "-"))
+	}
+	tags, err := validTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	l := &v1.Link{
+		Title:   title,
+		Url:     href,
+		Date:    added.Format(feed.DateLayout),
+		AddedAt: added.Format(time.RFC3339),
+		Tags:    tags,
+	}
+	l.Id = feed.LinkID(l.Url, l.Date)
+	return l, nil
+}
+
+// renderShaarli writes f as a Netscape bookmark file the way Shaarli
+// exports one, for Shaarli's import (and browsers'): tags comma-separated
+// in TAGS, the summary and notes as the <DD> description, and drafts and
+// scheduled links PRIVATE.
+func renderShaarli(f *v1.Feed) ([]byte, error) {
+	var b bytes.Buffer
+	title := html.EscapeString(cmp.Or(f.Title, "Bookmarks"))
+	b.WriteString("<!DOCTYPE NETSCAPE-Bookmark-file-1>\n")
+	b.WriteString("<!-- This is an automatically generated file.\n     It will be read and overwritten.\n     Do Not Edit! -->\n")
+	b.WriteString(`<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">` + "\n")
+	fmt.Fprintf(&b, "<TITLE>%s</TITLE>\n<H1>%s</H1>\n<DL><p>\n", title, title)
+	now := time.Now()
+	for _, l := range f.Links {
+		fmt.Fprintf(&b, `<DT><A HREF="%s"`, html.EscapeString(l.Url))
+		if t, ok := feed.LinkTime(l); ok {
+			fmt.Fprintf(&b, ` ADD_DATE="%d"`, t.Unix())
+		}
+		if t, err := time.Parse(time.RFC3339, l.UpdatedAt); err == nil {
+			fmt.Fprintf(&b, ` LAST_MODIFIED="%d"`, t.Unix())
+		}
+		private := 0
+		if !feed.Published(l, now) {
+			private = 1
+		}
+		fmt.Fprintf(&b, ` PRIVATE="%d" TAGS="%s">%s</A>`+"\n", private, html.EscapeString(strings.Join(l.Tags, ",")), html.EscapeString(l.Title))
+		if desc := strings.TrimSpace(l.Summary + "\n\n" + l.Notes); desc != "" {
+			fmt.Fprintf(&b, "<DD>%s\n", html.EscapeString(desc))
+		}
+	}
+	b.WriteString("</DL><p>\n")
+	return b.Bytes(), nil
+}
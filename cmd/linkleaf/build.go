@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// siteNav is the cross-page navigation handed to templates by build.
+// Hrefs are relative to the page, so the site works from any path.
+type siteNav struct {
+	Root    string            // prefix from the page to the site root ("./", "../../")
+	TagHref map[string]string // tag -> page URL, for linking #tags
+	Tags    []navItem
+	Months  []navItem
+}
+
+type navItem struct {
+	Name  string
+	Href  string
+	Count int
+}
+
+// sitePage is one generated HTML page.
+type sitePage struct {
+	dir   string // relative to -out, "" for the index
+	tmpl  string // template file name looked up in -templates
+	title string
+	links []*v1.Link
+}
+
+func cmdBuild() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	var file, out, baseURL, tmplDir, css string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&out, "out", "public", "output directory")
+	fs.StringVar(&baseURL, "base-url", "", "absolute URL the site is published at (required)")
+	fs.StringVar(&tmplDir, "templates", "", "directory with index.html.tmpl, tag.html.tmpl or archive.html.tmpl overrides")
+	fs.StringVar(&css, "css", cfg.Export.CSS, "stylesheet URL linked from every page")
+	var pageSize int
+	fs.IntVar(&pageSize, "page-size", 0, "split feed.xml, feed.atom and feed.json into pages of N links: feed-page2.xml, … (RFC 5005; 0: one document)")
+	ff := addFilterFlags(fs)
+	drafts := addDraftsFlag(fs)
+	imf := addImageFlags(fs)
+	permalinks := addPermalinksFlag(fs)
+	return fs, func() {
+		if file == "" || baseURL == "" || fs.NArg() != 0 || pageSize < 0 {
+			badUsage(fs)
+		}
+		checkPermalinks(*permalinks)
+		baseURL = strings.TrimRight(baseURL, "/")
+		if feed.Host(baseURL) == "" {
+			die(invalid(fmt.Errorf("-base-url: want an absolute URL, got %q", baseURL)))
+		}
+		flt, err := ff.filter()
+		if err != nil {
+			die(err)
+		}
+
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		f = public(flt.Select(f), *drafts)
+
+		// Images live in <out>/assets, which a later build reuses.
+		images := imf.open(filepath.Join(out, "assets"))
+		if images != nil {
+			n, err := images.Update(context.Background(), f.Links)
+			if err != nil {
+				die(err)
+			}
+			msg.Debugf("fetched images for %d links", n)
+		}
+
+		pages, nav := sitePages(f)
+		if *permalinks == "page" {
+			pages = append(pages, permalinkPages(f)...)
+		}
+		// Related links point into the index, which has every link.
+		related := relatedLinks(f)
+		written := 0
+		write := func(rel string, b []byte) {
+			if err := feed.WriteFileAtomic(filepath.Join(out, filepath.FromSlash(rel)), b, 0o644); err != nil {
+				die(err)
+			}
+			msg.Debugf("wrote %s (%d bytes)", rel, len(b))
+			written++
+		}
+
+		for _, p := range pages {
+			n := *nav
+			n.Root = "./"
+			if p.dir != "" {
+				n.Root = strings.Repeat("../", strings.Count(p.dir, "/")+1)
+			}
+			page := htmlPage{Feed: feed.Filter{}.Select(f), Stylesheet: css, Site: &n, Related: related, Permalinks: n.Root + "l/"}
+			page.Feed.Title, page.Feed.Links = p.title, p.links
+			if images != nil {
+				page.Images = pageImages(images, p.links, n.Root+"assets/")
+			}
+			for _, e := range feedEndpoints {
+				page.Alternates = append(page.Alternates, alternate{Type: mediaType(e.contentType), Title: e.title, Href: n.Root + strings.TrimPrefix(e.path, "/")})
+			}
+			b, err := renderPage(page, siteTemplate(tmplDir, p.tmpl))
+			if err != nil {
+				die(fmt.Errorf("%s: %w", p.dir, err))
+			}
+			write(pathJoin(p.dir, "index.html"), b)
+		}
+		if *permalinks == "redirect" {
+			for _, l := range f.Links {
+				if l.Slug == "" {
+					continue
+				}
+				b, err := renderRedirect(l)
+				if err != nil {
+					msg.Debugf("no permalink for [%s]: %v", l.Id, err)
+					continue
+				}
+				write("l/"+l.Slug+"/index.html", b)
+			}
+		}
+		writeFeed := func(path string, f *v1.Feed, si siteInfo, render func(*v1.Feed, siteInfo) ([]byte, error)) {
+			pages, err := renderPages(f, si, pageSize, render)
+			if err != nil {
+				die(err)
+			}
+			for i, b := range pages {
+				write(strings.TrimPrefix(pageName(path, i+1), "/"), b)
+			}
+		}
+		for _, e := range feedEndpoints {
+			writeFeed(e.path, f, siteInfo{Link: baseURL + "/", FeedURL: baseURL + e.path, Author: configAuthor()}.withFeed(f), e.render)
+		}
+		for _, lang := range feedLangs(f) {
+			lf := feed.Filter{Lang: lang}.Select(f)
+			for _, e := range feedEndpoints {
+				path := "/lang/" + lang + e.path
+				writeFeed(path, lf, siteInfo{Link: baseURL + "/", FeedURL: baseURL + path, Lang: lang, Author: configAuthor()}.withFeed(lf), e.render)
+			}
+		}
+		b, err := renderSitemap(baseURL, pages, f)
+		if err != nil {
+			die(err)
+		}
+		write("sitemap.xml", b)
+		msg.Infof("built %d files for %d links into %s", written, len(f.Links), out)
+	}
+}
+
+// sitePages lays out the index, one page per tag and one per month.
+func sitePages(f *v1.Feed) ([]sitePage, *siteNav) {
+	title := f.Title
+	if title == "" {
+		title = "Links"
+	}
+	pages := []sitePage{{tmpl: "index.html.tmpl", title: title, links: f.Links}}
+	nav := &siteNav{TagHref: map[string]string{}}
+
+	// Tag directories are slugs; tags that slugify alike get -2, -3, ….
+	used := map[string]bool{}
+	for _, tc := range feed.CountTags(f) {
+		slug := feed.Slugify(tc.Tag)
+		for i := 2; used[slug]; i++ {
+			slug = fmt.Sprintf("%s-%d", feed.Slugify(tc.Tag), i)
+		}
+		used[slug] = true
+		dir := "tags/" + slug
+		nav.TagHref[tc.Tag] = dir + "/"
+		nav.Tags = append(nav.Tags, navItem{Name: tc.Tag, Href: dir + "/", Count: tc.Count})
+		var links []*v1.Link
+		for _, l := range f.Links {
+			if slices.Contains(l.Tags, tc.Tag) {
+				links = append(links, l)
+			}
+		}
+		pages = append(pages, sitePage{dir: dir, tmpl: "tag.html.tmpl", title: title + " · #" + tc.Tag, links: links})
+	}
+
+	months := map[string][]*v1.Link{}
+	for _, l := range f.Links {
+		if t, ok := linkTime(l); ok {
+			m := t.Format("2006-01")
+			months[m] = append(months[m], l)
+		}
+	}
+	keys := make([]string, 0, len(months))
+	for m := range months {
+		keys = append(keys, m)
+	}
+	slices.Sort(keys)
+	slices.Reverse(keys)
+	for _, m := range keys {
+		dir := "archive/" + m
+		nav.Months = append(nav.Months, navItem{Name: m, Href: dir + "/", Count: len(months[m])})
+		pages = append(pages, sitePage{dir: dir, tmpl: "archive.html.tmpl", title: title + " · " + m, links: months[m]})
+	}
+	return pages, nav
+}
+
+// siteTemplate returns the override for name in dir, falling back to the
+// index override and then to the built-in page ("").
+func siteTemplate(dir, name string) string {
+	if dir == "" {
+		return ""
+	}
+	for _, n := range []string{name, "index.html.tmpl"} {
+		p := filepath.Join(dir, n)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		} else if !errors.Is(err, os.ErrNotExist) {
+			die(err)
+		}
+	}
+	return ""
+}
+
+func pathJoin(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	NS      string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+func renderSitemap(baseURL string, pages []sitePage, f *v1.Feed) ([]byte, error) {
+	set := sitemapURLSet{NS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, p := range pages {
+		u := sitemapURL{Loc: baseURL + "/"}
+		if p.dir != "" {
+			u.Loc += p.dir + "/"
+		}
+		var latest time.Time
+		for _, l := range p.links {
+			if t, ok := linkTime(l); ok && t.After(latest) {
+				latest = t
+			}
+		}
+		if p.dir == "" {
+			latest = feedTime(f)
+		}
+		if !latest.IsZero() {
+			u.LastMod = latest.Format(feed.DateLayout)
+		}
+		set.URLs = append(set.URLs, u)
+	}
+	return marshalXML(set)
+}
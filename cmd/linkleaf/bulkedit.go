@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// bulkEditErrors matches the error comments a failed round put on top of
+// the text, dropped before the next one.
+var bulkEditErrors = regexp.MustCompile(`(?m)^# ERROR: .*\n`)
+
+// cmdBulkEdit opens the links the filter flags and -match pick as one
+// textproto in the editor and saves every change made to them at once.
+func cmdBulkEdit() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("bulk-edit", flag.ExitOnError)
+	var file, match string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&match, "match", "", "search query picking the links, e.g. \"tag:go domain:github.com\"")
+	ff := addFilterFlags(fs)
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		flt, err := ff.filter()
+		if err != nil {
+			die(err)
+		}
+		q, err := feed.ParseQuery(match)
+		if err != nil {
+			die(invalid(err))
+		}
+
+		// Edit first, lock after: the feed stays writable while the editor is
+		// open, and links changed meanwhile are refused below.
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		picked := q.Apply(flt.Select(f).Links)
+		if len(picked) == 0 {
+			msg.Infof("no links match; nothing to edit")
+			return
+		}
+		before := map[string]*v1.Link{}
+		sel := &v1.Feed{}
+		for _, l := range picked {
+			before[l.Id] = l
+			sel.Links = append(sel.Links, l)
+		}
+		text, err := bulkEditText(file, sel)
+		if err != nil {
+			die(err)
+		}
+		p := newPrompter(os.Stdin, os.Stderr)
+		var changed []*v1.Link
+		for {
+			edited, err := editText(text, "bulk-edit-*.textproto")
+			if err != nil {
+				die(err)
+			}
+			edited = bulkEditErrors.ReplaceAllString(edited, "")
+			changed, err = bulkEditChanges(f, before, edited)
+			if err == nil {
+				break
+			}
+			if errors.Is(err, errAborted) {
+				msg.Infof("empty text; nothing changed")
+				return
+			}
+			fmt.Fprintln(os.Stderr, err)
+			answer, perr := p.ask("Edit again? (Y/n)", "y")
+			if a := strings.ToLower(answer); perr != nil || a != "y" && a != "yes" {
+				fmt.Fprintln(os.Stderr, "aborted; nothing written")
+				os.Exit(1)
+			}
+			var b strings.Builder
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Fprintf(&b, "# ERROR: %s\n", line)
+			}
+			text = b.String() + edited
+		}
+		if len(changed) == 0 {
+			msg.Infof("%d links unchanged", len(picked))
+			return
+		}
+
+		sf.lock(file)
+		f, err = mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		for _, l := range changed {
+			i := feed.Index(f, l.Id)
+			if i < 0 {
+				die(conflict(fmt.Errorf("[%s] was removed while you were editing; nothing written", l.Id)))
+			}
+			if !proto.Equal(f.Links[i], before[l.Id]) {
+				die(conflict(fmt.Errorf("[%s] was changed while you were editing; nothing written", l.Id)))
+			}
+			f.Links[i] = l
+		}
+		for _, l := range changed {
+			if i := feed.SlugIndex(f, l.Slug); i >= 0 && f.Links[i] != l {
+				die(conflict(fmt.Errorf("[%s]: slug %q is taken by [%s]; nothing written", l.Id, l.Slug, f.Links[i].Id)))
+			}
+		}
+		f.GeneratedAt = feed.NowRFC3339()
+
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		for _, l := range changed {
+			msg.Debugf("edited [%s] %s", l.Id, l.Title)
+		}
+		msg.Infof("edited %d of %d links", len(changed), len(picked))
+	}
+}
+
+// bulkEditText renders the picked links for the editor.
+func bulkEditText(file string, sel *v1.Feed) (string, error) {
+	b, err := prototext.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(sel)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`# %d links of %s: change any field, save and quit to save them all at once.
+# Links can't be added or removed here and ids must stay. Lines starting with
+# # are ignored; an empty file changes nothing.
+# proto-message: linkleaf.v1.Feed
+
+`, len(sel.Links), file) + string(textprotoField.ReplaceAll(b, []byte("$1: "))), nil
+}
+
+// bulkEditChanges parses the edited text and returns the links that
+// differ from before, checked as edit would check them. Every problem is
+// reported, one per line. An empty text returns errAborted.
+func bulkEditChanges(f *v1.Feed, before map[string]*v1.Link, text string) ([]*v1.Link, error) {
+	edited := &v1.Feed{}
+	if err := prototext.Unmarshal([]byte(text), edited); err != nil {
+		return nil, fmt.Errorf("parse edited links: %w", err)
+	}
+	if proto.Equal(edited, &v1.Feed{}) {
+		return nil, errAborted
+	}
+	var errs []error
+	if edited.Version != 0 || edited.Title != "" || len(edited.Trash) > 0 {
+		errs = append(errs, errors.New("only links can be edited here (see meta for the feed's fields)"))
+	}
+	seen := map[string]bool{}
+	var changed []*v1.Link
+	slugs := map[string]string{} // slug → ID, among the edited links
+	for _, l := range edited.Links {
+		fail := func(err error) { errs = append(errs, fmt.Errorf("[%s]: %w", l.Id, err)) }
+		old := before[l.Id]
+		switch {
+		case old == nil:
+			fail(errors.New("not one of the links being edited; ids can't change and links can't be added"))
+			continue
+		case seen[l.Id]:
+			fail(errors.New("appears twice"))
+			continue
+		}
+		seen[l.Id] = true
+		if err := feed.ValidateLink(l); err != nil {
+			fail(err)
+		}
+		var err error
+		if l.Tags, err = validTags(l.Tags); err != nil {
+			fail(err)
+		}
+		if l.Lang != "" {
+			if l.Lang, err = feed.NormalizeLang(l.Lang); err != nil {
+				fail(err)
+			}
+		}
+		if l.PublishAt != "" {
+			if t, err := feed.ParsePublishAt(l.PublishAt); err != nil {
+				fail(fmt.Errorf("publish_at %q: want an RFC 3339 time or YYYY-MM-DD", l.PublishAt))
+			} else {
+				l.PublishAt = t.Format(time.RFC3339)
+			}
+		}
+		for k := range l.Meta {
+			if err := feed.ValidateMetaKey(k); err != nil {
+				fail(err)
+			}
+		}
+		if l.Slug != old.Slug {
+			if err := feed.ValidateSlug(l.Slug); err != nil {
+				fail(err)
+			} else if i := feed.SlugIndex(f, l.Slug); i >= 0 && f.Links[i].Id != l.Id && before[f.Links[i].Id] == nil {
+				fail(fmt.Errorf("slug %q is taken by [%s]", l.Slug, f.Links[i].Id))
+			}
+		}
+		if other, ok := slugs[l.Slug]; ok && l.Slug != "" {
+			fail(fmt.Errorf("slug %q is also given to [%s]", l.Slug, other))
+		}
+		slugs[l.Slug] = l.Id
+		if !proto.Equal(l, old) {
+			changed = append(changed, l)
+		}
+	}
+	for id := range before {
+		if !seen[id] {
+			errs = append(errs, fmt.Errorf("[%s]: missing; bulk-edit can't remove links (see remove)", id))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return changed, nil
+}
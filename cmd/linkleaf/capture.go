@@ -0,0 +1,222 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// maxCaptureBody bounds a capture request.
+const maxCaptureBody = 64 << 10
+
+func cmdCapture() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("capture", flag.ExitOnError)
+	var file, addr, token, idScheme string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&addr, "addr", "127.0.0.1:7070", "listen address")
+	fs.StringVar(&token, "token", os.Getenv("LINKLEAF_CAPTURE_TOKEN"), "secret clients must send (default $LINKLEAF_CAPTURE_TOKEN, else random)")
+	fs.StringVar(&idScheme, "id-scheme", defaultIDScheme(), "ID generator: "+strings.Join(feed.IDSchemes(), ", "))
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		if storage.IsRemote(file) {
+			die(invalid(fmt.Errorf("capture needs a local file, got %s", file)))
+		}
+		genID, err := feed.IDScheme(idScheme)
+		if err != nil {
+			die(invalid(err))
+		}
+		if token == "" {
+			b := make([]byte, 16)
+			rand.Read(b)
+			token = hex.EncodeToString(b)
+			msg.Infof("token: %s", token)
+		}
+
+		c := &captureServer{path: file, token: token, genID: genID, sf: sf}
+		srv := &http.Server{
+			Addr:              addr,
+			Handler:           c.handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdown)
+		}()
+
+		msg.Infof("capturing into %s on %s; bookmarklet:\n\n%s\n", file, addr, bookmarklet(addr, token))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			die(err)
+		}
+	}
+}
+
+// captureServer adds the links POSTed to /capture, one locked
+// load-modify-save per request.
+type captureServer struct {
+	path  string
+	token string
+	genID feed.IDGenerator
+	sf    *saveFlags
+
+	mu sync.Mutex // one save at a time within the process
+}
+
+// captureRequest is the /capture body: the fields of "add -" JSON, plus
+// the token for clients that can't set an Authorization header.
+type captureRequest struct {
+	linkText
+	Token string `json:"token"`
+}
+
+func (c *captureServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	// Bookmarklets run in the page's origin, so allow cross-origin calls
+	// (the token is the guard) and answer Chrome's private network preflight.
+	cors := func(w http.ResponseWriter) {
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", "*")
+		h.Set("Access-Control-Allow-Methods", "POST")
+		h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		h.Set("Access-Control-Allow-Private-Network", "true")
+	}
+	mux.HandleFunc("OPTIONS /capture", func(w http.ResponseWriter, r *http.Request) {
+		cors(w)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /capture", func(w http.ResponseWriter, r *http.Request) {
+		cors(w)
+		status, body := c.capture(w, r)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	})
+	return mux
+}
+
+type captureResponse struct {
+	ID    string `json:"id,omitempty"`
+	Title string `json:"title,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func (c *captureServer) capture(w http.ResponseWriter, r *http.Request) (int, captureResponse) {
+	var req captureRequest
+	d := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCaptureBody))
+	d.DisallowUnknownFields()
+	err := d.Decode(&req)
+	token := cmp.Or(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), req.Token)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(c.token)) != 1 {
+		return http.StatusUnauthorized, captureResponse{Error: "bad or missing token"}
+	}
+	if err != nil {
+		return http.StatusBadRequest, captureResponse{Error: "parse request: " + err.Error()}
+	}
+
+	l := &v1.Link{
+		Id:      strings.TrimSpace(req.ID),
+		Title:   strings.TrimSpace(req.Title),
+		Url:     strings.TrimSpace(req.URL),
+		Date:    strings.TrimSpace(req.Date),
+		Summary: strings.TrimSpace(req.Summary),
+		Via:     strings.TrimSpace(req.Via),
+	}
+	if l.Date == "" {
+		l.Date = time.Now().Format(feed.DateLayout)
+	}
+	if err := feed.ValidateLink(l); err != nil {
+		return http.StatusBadRequest, captureResponse{Error: err.Error()}
+	}
+	if l.Tags, err = validTags(req.Tags); err != nil {
+		return http.StatusBadRequest, captureResponse{Error: err.Error()}
+	}
+	l.Tags = withDefaultTags(l.Tags)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status, err := c.add(l)
+	if err != nil {
+		if status == http.StatusInternalServerError {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		return status, captureResponse{Error: err.Error()}
+	}
+	msg.Infof("added [%s] %s", l.Id, l.Title)
+	return http.StatusCreated, captureResponse{ID: l.Id, Title: l.Title}
+}
+
+// add saves l into the feed like "add" does, refusing URLs already there.
+func (c *captureServer) add(l *v1.Link) (int, error) {
+	unlock, err := feed.Lock(c.path)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer unlock()
+	opened, err := feed.OpenWith(c.path, loadOpts) // a missing file starts a new feed
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("load %s: %w", c.path, err)
+	}
+	f := opened.Feed
+	sf := *c.sf
+	sf.loaded(f)
+	if old := feed.FindURL(f, l.Url); old != nil {
+		return http.StatusConflict, fmt.Errorf("already in the feed as [%s]", old.Id)
+	}
+	if l.Id == "" {
+		assignID(f, c.genID, l)
+	} else if feed.Find(f, l.Id) != nil {
+		return http.StatusConflict, fmt.Errorf("id %q is taken", l.Id)
+	}
+	feed.AddLink(f, l)
+	if err := sf.save(c.path, f); err != nil {
+		if errors.Is(err, errHookFailed) {
+			return http.StatusUnprocessableEntity, err
+		}
+		return http.StatusInternalServerError, err
+	}
+	return 0, nil
+}
+
+// bookmarklet is a javascript: URL that asks for tags and POSTs the
+// current page (and any selected text, as the summary) to the capture
+// server at addr.
+func bookmarklet(addr, token string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, "80"
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	endpoint, _ := json.Marshal("http://" + net.JoinHostPort(host, port) + "/capture")
+	auth, _ := json.Marshal("Bearer " + token)
+	return "javascript:(()=>{const t=prompt('linkleaf tags (comma-separated)','');if(t===null)return;" +
+		"fetch(" + string(endpoint) + ",{method:'POST',headers:{'Authorization':" + string(auth) + ",'Content-Type':'application/json'}," +
+		"body:JSON.stringify({url:location.href,title:document.title,tags:t,summary:String(getSelection()).trim()})})" +
+		".then(r=>r.json()).then(j=>alert(j.error?'linkleaf: '+j.error:'linkleaf: saved ['+j.id+']'))" +
+		".catch(e=>alert('linkleaf: '+e))})()"
+}
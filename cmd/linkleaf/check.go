@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/linkcheck"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+func cmdCheck() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	var file, report string
+	var concurrency int
+	var timeout time.Duration
+	var onlyStale ageFlag
+	var failOnError, annotate, ci bool
+	fs.StringVar(&file, "file", "", "protobuf feed file (.pb), instead of the positional argument")
+	fs.IntVar(&concurrency, "concurrency", 8, "max parallel requests")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "per-request timeout")
+	fs.BoolVar(&failOnError, "fail-on-error", false, "exit 1 if any link is broken (for CI)")
+	fs.StringVar(&report, "report", "", "also write the results as JSON to this file")
+	fs.BoolVar(&ci, "ci", false, "print GitHub Actions annotations for broken links and a JSON summary")
+	fs.BoolVar(&annotate, "annotate", false, "store each result in the link's last_check and save the feed")
+	fs.Var(&onlyStale, "only-stale", "only check links not checked within this `age` (e.g. 30d, 2w, 12h); implies -annotate")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, file)
+		if !ok {
+			badUsage(fs)
+		}
+		// Skipping recent results only works if this run's are kept.
+		annotate = annotate || onlyStale > 0
+
+		if annotate {
+			sf.lock(path)
+		}
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		links := f.Links
+		if onlyStale > 0 {
+			now := time.Now()
+			links = nil
+			for _, l := range f.Links {
+				if feed.CheckStale(l, time.Duration(onlyStale), now) {
+					links = append(links, l)
+				}
+			}
+			msg.Infof("skipping %d links checked within %s", len(f.Links)-len(links), onlyStale.String())
+		}
+		results := linkcheck.Check(context.Background(), links, linkcheck.Options{
+			Concurrency: concurrency,
+			Timeout:     timeout,
+		})
+
+		broken := 0
+		for _, r := range results {
+			if ci {
+				if !r.OK() {
+					broken++
+					ciAnnotate("error", path, fmt.Sprintf("broken link [%s]", r.Link.Id), checkProblem(r)+" "+r.Link.Url)
+				}
+				continue
+			}
+			switch {
+			case r.TimedOut():
+				broken++
+				fmt.Printf("TIME [%s] %s%s\n     %v\n", r.Link.Id, r.Link.Url, failStreak(r.Link), r.Err)
+			case r.Err != nil:
+				broken++
+				fmt.Printf("ERR  [%s] %s%s\n     %v\n", r.Link.Id, r.Link.Url, failStreak(r.Link), r.Err)
+			case !r.OK():
+				broken++
+				fmt.Printf("%d  [%s] %s  <-- BROKEN%s\n", r.Status, r.Link.Id, r.Link.Url, failStreak(r.Link))
+			default:
+				fmt.Printf("%d  [%s] %s\n", r.Status, r.Link.Id, r.Link.Url)
+			}
+			if r.FinalURL != "" {
+				fmt.Printf("     -> %s\n", r.FinalURL)
+			}
+		}
+		if ci {
+			ciSummary(checkSummary{Command: "check", File: path, OK: broken == 0, Checked: len(results), Broken: broken})
+		} else {
+			fmt.Printf("\nchecked %d links: %d ok, %d broken\n", len(results), len(results)-broken, broken)
+		}
+
+		if report != "" {
+			b, err := json.MarshalIndent(checkReport(results), "", "  ")
+			if err != nil {
+				die(err)
+			}
+			if err := feed.WriteFileAtomic(report, append(b, '\n'), 0o644); err != nil {
+				die(err)
+			}
+			msg.Infof("wrote report to %s", report)
+		}
+		if annotate && len(results) > 0 {
+			now := feed.NowRFC3339()
+			for _, r := range results {
+				c := &v1.LinkCheck{CheckedAt: now, Status: int32(r.Status), FinalUrl: r.FinalURL}
+				if r.Err != nil {
+					c.Error = r.Err.Error()
+				}
+				feed.RecordCheck(r.Link, c)
+			}
+			f.GeneratedAt = now
+			if err := sf.save(path, f); err != nil {
+				die(err)
+			}
+			msg.Infof("annotated %d links in %s", len(results), path)
+		}
+		if failOnError && broken > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+type checkSummary struct {
+	Command string `json:"command"`
+	File    string `json:"file"`
+	OK      bool   `json:"ok"`
+	Checked int    `json:"checked"`
+	Broken  int    `json:"broken"`
+}
+
+// failStreak notes how many checks in a row l has now failed, counting the
+// one that just failed, if it failed before too.
+func failStreak(l *v1.Link) string {
+	if c := l.LastCheck; c != nil && c.Failures > 0 {
+		return fmt.Sprintf(" (%d checks in a row)", c.Failures+1)
+	}
+	return ""
+}
+
+// checkOutcome describes a stored check: its status, or its error.
+func checkOutcome(c *v1.LinkCheck) string {
+	switch {
+	case c.Error != "":
+		return c.Error
+	case c.Status == 0:
+		return "failed"
+	}
+	return fmt.Sprintf("HTTP %d", c.Status)
+}
+
+// ageFlag is a duration flag that also takes days and weeks ("30d", "2w").
+type ageFlag time.Duration
+
+func (a *ageFlag) String() string {
+	d := time.Duration(*a)
+	if d > 0 && d%(24*time.Hour) == 0 {
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d"
+	}
+	return d.String()
+}
+
+func (a *ageFlag) Set(s string) error {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v <= 0 {
+				return fmt.Errorf("want a positive number of days or weeks, got %q", s)
+			}
+			*a = ageFlag(time.Duration(v) * unit)
+			return nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("want a positive duration like 30d, 2w or 12h, got %q", s)
+	}
+	*a = ageFlag(d)
+	return nil
+}
+
+// brokenFlag is list's -broken: alone it means 1, and -broken=N asks for
+// N failed checks in a row.
+type brokenFlag int
+
+func (b *brokenFlag) String() string   { return strconv.Itoa(int(*b)) }
+func (b *brokenFlag) IsBoolFlag() bool { return true }
+
+func (b *brokenFlag) Set(s string) error {
+	switch s {
+	case "true":
+		*b = 1
+		return nil
+	case "false":
+		*b = 0
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return fmt.Errorf("want a number of checks, got %q", s)
+	}
+	*b = brokenFlag(n)
+	return nil
+}
+
+// checkProblem describes why a link failed the check.
+func checkProblem(r linkcheck.Result) string {
+	switch {
+	case r.TimedOut():
+		return "timed out:"
+	case r.Err != nil:
+		return r.Err.Error() + ":"
+	}
+	return fmt.Sprintf("HTTP %d:", r.Status)
+}
+
+type checkResultJSON struct {
+	ID       string `json:"id"`
+	URL      string `json:"url"`
+	OK       bool   `json:"ok"`
+	Status   int    `json:"status"`
+	Method   string `json:"method"`
+	FinalURL string `json:"final_url,omitempty"`
+	TimedOut bool   `json:"timed_out,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func checkReport(results []linkcheck.Result) []checkResultJSON {
+	out := make([]checkResultJSON, len(results))
+	for i, r := range results {
+		out[i] = checkResultJSON{
+			ID:       r.Link.Id,
+			URL:      r.Link.Url,
+			OK:       r.OK(),
+			Status:   r.Status,
+			Method:   r.Method,
+			FinalURL: r.FinalURL,
+			TimedOut: r.TimedOut(),
+		}
+		if r.Err != nil {
+			out[i].Error = r.Err.Error()
+		}
+	}
+	return out
+}
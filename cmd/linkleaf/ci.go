@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// -ci output: GitHub Actions workflow commands, which show up as
+// annotations on the run and on pull requests, followed by one JSON
+// summary line. See
+// https://docs.github.com/actions/using-workflows/workflow-commands-for-github-actions
+
+var (
+	ciData     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	ciProperty = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// ciAnnotate prints an annotation; level is error, warning or notice.
+func ciAnnotate(level, file, title, message string) {
+	fmt.Printf("::%s file=%s,title=%s::%s\n", level, ciProperty.Replace(file), ciProperty.Replace(title), ciData.Replace(message))
+}
+
+// ciSummary prints v as a single JSON line and, when run by GitHub Actions,
+// also sets it as the step output "summary".
+func ciSummary(v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		die(err)
+	}
+	fmt.Println(string(b))
+	if out := os.Getenv("GITHUB_OUTPUT"); out != "" {
+		f, err := os.OpenFile(out, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "summary=%s\n", b)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: set step output: %v\n", err)
+		}
+	}
+}
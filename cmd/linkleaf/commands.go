@@ -0,0 +1,194 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+)
+
+// A command is a linkleaf subcommand, or one of its own (e.g. "tags
+// rename") or a format it takes as its first argument ("export rss").
+type command struct {
+	name string
+	// flags builds the command's FlagSet and returns it with the function
+	// that runs the command once the FlagSet has parsed the arguments.
+	// A sub without flags is a word the command reads itself (config set,
+	// completion bash) and only matters for completion.
+	flags func() (*flag.FlagSet, func())
+	subs  []command
+}
+
+// commands lists every subcommand in usage order; run dispatches through
+// it and the completion scripts are generated from it. It is set in init
+// because cmdCompletion reads it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "init", flags: cmdInit},
+		{name: "meta", flags: cmdMeta},
+		{name: "add", flags: cmdAdd},
+		{name: "list", flags: cmdList},
+		{name: "search", flags: cmdSearch},
+		{name: "find", flags: cmdFind},
+		{name: "print", flags: cmdPrint},
+		{name: "tui", flags: cmdTUI},
+		{name: "export", flags: bind(cmdExport, "html"), subs: append(formats(exportFormats, cmdExport),
+			command{name: "opml", flags: exportOPML},
+			command{name: "hugo", flags: bind(exportContent, "hugo")},
+			command{name: "jekyll", flags: bind(exportContent, "jekyll")},
+			command{name: "custom", flags: exportCustom},
+			command{name: "email", flags: exportEmail},
+		)},
+		{name: "import", flags: bind(cmdImport, "csv"), subs: append(formats(importFormats, cmdImport),
+			command{name: "opml", flags: importOPML},
+			command{name: "browser-history", flags: importHistory},
+		)},
+		{name: "subscribe", flags: cmdSubscribeList, subs: []command{
+			{name: "list", flags: cmdSubscribeList},
+			{name: "add", flags: cmdSubscribeAdd},
+			{name: "remove", flags: cmdSubscribeRemove},
+			{name: "pull", flags: cmdSubscribePull},
+		}},
+		{name: "build", flags: cmdBuild},
+		{name: "watch", flags: cmdWatch},
+		{name: "serve", flags: cmdServe},
+		{name: "daemon", flags: cmdDaemon},
+		{name: "capture", flags: cmdCapture},
+		{name: "publish", flags: cmdPublish},
+		{name: "webmention", flags: cmdWebmention},
+		{name: "tags", flags: cmdTagsList, subs: []command{
+			{name: "list", flags: cmdTagsList},
+			{name: "rename", flags: bind(cmdTagsRewrite, "rename")},
+			{name: "merge", flags: bind(cmdTagsRewrite, "merge")},
+			{name: "rm", flags: bind(cmdTagsRewrite, "rm")},
+		}},
+		{name: "rename-tag", flags: cmdRenameTag},
+		{name: "retag", flags: cmdRetag},
+		{name: "stats", flags: cmdStats},
+		{name: "validate", flags: cmdValidate},
+		{name: "doctor", flags: cmdDoctor},
+		{name: "check", flags: cmdCheck},
+		{name: "merge", flags: cmdMerge},
+		{name: "split", flags: cmdSplit},
+		{name: "sync", flags: cmdSync},
+		{name: "diff", flags: cmdDiff},
+		{name: "reid", flags: cmdReid},
+		{name: "bulk-edit", flags: cmdBulkEdit},
+		{name: "edit", flags: cmdEdit},
+		{name: "remove", flags: cmdRemove},
+		{name: "trash", flags: cmdTrashList, subs: []command{
+			{name: "list", flags: cmdTrashList},
+			{name: "restore", flags: cmdTrashRestore},
+			{name: "purge", flags: cmdTrashPurge},
+		}},
+		{name: "dedupe", flags: cmdDedupe},
+		{name: "mark", flags: cmdMark},
+		{name: "open", flags: cmdOpen},
+		{name: "qr", flags: cmdQR},
+		{name: "note", flags: cmdNote},
+		{name: "relate", flags: cmdRelate},
+		{name: "quote", flags: cmdQuoteList, subs: []command{
+			{name: "list", flags: cmdQuoteList},
+			{name: "add", flags: cmdQuoteAdd},
+			{name: "rm", flags: cmdQuoteRm},
+		}},
+		{name: "archive", flags: cmdArchive},
+		{name: "save", flags: cmdSave},
+		{name: "read", flags: cmdRead},
+		{name: "refresh", flags: cmdRefresh},
+		{name: "move", flags: cmdMove},
+		{name: "prune", flags: cmdPrune},
+		{name: "migrate", flags: cmdMigrate, subs: []command{
+			{name: "v1-to-v2", flags: cmdMigrateToV2},
+			{name: "v2-to-v1", flags: cmdMigrateFromV2},
+		}},
+		{name: "convert", flags: cmdConvert},
+		{name: "compact", flags: cmdCompact},
+		{name: "hash", flags: cmdHash},
+		{name: "backup", flags: cmdBackup},
+		{name: "restore", flags: cmdRestore},
+		{name: "log", flags: cmdLog},
+		{name: "undo", flags: cmdUndo},
+		{name: "history", flags: cmdHistory},
+		{name: "keygen", flags: cmdKeygen},
+		{name: "sign", flags: cmdSign},
+		{name: "verify", flags: cmdVerify},
+		{name: "config", flags: cmdConfig, subs: words("list", "path", "get", "set", "unset")},
+		{name: "feeds", flags: cmdFeeds, subs: words("list", "add", "remove")},
+		{name: "completion", flags: cmdCompletion, subs: words("bash", "zsh", "fish")},
+	}
+}
+
+// run runs the command line args, a subcommand and its arguments.
+func run(args []string) {
+	if args[0] == "__complete" {
+		cmdComplete(args[1:])
+		return
+	}
+	c := findCommand(commands, args[0])
+	if c == nil {
+		usageError(usage, fmt.Errorf("unknown command %q (see linkleaf -h)", args[0]))
+	}
+	help, ok := commandHelp[c.name]
+	args = args[1:]
+	if len(args) > 0 {
+		if sub := findCommand(c.subs, args[0]); sub != nil && sub.flags != nil {
+			c, args = sub, args[1:]
+		}
+	}
+	fs, cmd := c.flags()
+	if ok {
+		fs.Usage = commandUsage(fs, help)
+	}
+	parseArgs(fs, args)
+	cmd()
+}
+
+func findCommand(cmds []command, name string) *command {
+	i := slices.IndexFunc(cmds, func(c command) bool { return c.name == name })
+	if i < 0 {
+		return nil
+	}
+	return &cmds[i]
+}
+
+// subNames returns the names of c's subs.
+func (c command) subNames() []string {
+	names := make([]string, len(c.subs))
+	for i, s := range c.subs {
+		names[i] = s.name
+	}
+	return names
+}
+
+// flagNames returns the names of the flags c defines.
+func (c command) flagNames() []string {
+	fs, _ := c.flags()
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	return names
+}
+
+// bind fixes the argument of a command that serves several names.
+func bind(flags func(string) (*flag.FlagSet, func()), arg string) func() (*flag.FlagSet, func()) {
+	return func() (*flag.FlagSet, func()) { return flags(arg) }
+}
+
+// formats returns a sub running flags with each of names as its format.
+func formats(names []string, flags func(string) (*flag.FlagSet, func())) []command {
+	subs := make([]command, len(names))
+	for i, name := range names {
+		subs[i] = command{name: name, flags: bind(flags, name)}
+	}
+	return subs
+}
+
+// words returns subs without flags of their own.
+func words(names ...string) []command {
+	subs := make([]command, len(names))
+	for i, name := range names {
+		subs[i] = command{name: name}
+	}
+	return subs
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+func cmdCompact() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("compact", flag.ExitOnError)
+	var to, out string
+	fs.StringVar(&to, "to", "", "compression: "+strings.Join(feed.Compressions(), ", ")+" (default: by -out's extension, else the feed's own)")
+	fs.StringVar(&out, "out", "", "write the result here, leaving <file.pb> as it is")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, "")
+		if !ok || to != "" && !slices.Contains(feed.Compressions(), to) {
+			badUsage(fs)
+		}
+		dest := cmp.Or(out, path)
+		pending, err := feed.WALRecords(path)
+		if err != nil {
+			die(err)
+		}
+		// Without -to the compression changes only if -out's name asks for it;
+		// otherwise compact just folds in the write-ahead log.
+		from := feed.FileCompression(path)
+		if to == "" && out != "" {
+			to = feed.CompressionForName(dest)
+		}
+		to = cmp.Or(to, from)
+		if to != feed.CompressNone && feed.CompressionForName(dest) != to && (encrypt || feed.IsEncryptedFile(path)) {
+			// The contents of an encrypted file can't be sniffed on the next
+			// save, so only the name keeps it compressed.
+			die(invalid(fmt.Errorf("an encrypted feed stays compressed only under a .gz or .zst name; use -out")))
+		}
+		sf.compress = to
+		sf.wal = false
+
+		sf.lock(dest)
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
+		}
+		size := fileSize(path)
+		if from == to && out == "" && pending == 0 {
+			msg.Infof("%s is already %s, with no logged changes to fold in", path, compressionName(to))
+			return
+		}
+		if out == "" {
+			sf.loaded(f) // with -out the journal records a new file, as for init
+		}
+		if err := sf.save(dest, f); err != nil {
+			die(err)
+		}
+		if sf.dryRun {
+			return
+		}
+		if from == to && out == "" {
+			msg.Infof("folded %d logged changes from %s%s into %s (%s)", pending, path, feed.WALSuffix, path, fileSize(path))
+			return
+		}
+		msg.Infof("compacted %s (%s, %s) to %s (%s, %s)", path, compressionName(from), size, dest, compressionName(to), fileSize(dest))
+	}
+}
+
+func compressionName(c string) string {
+	if c == feed.CompressNone {
+		return "uncompressed"
+	}
+	return c + "-compressed"
+}
+
+// fileSize formats the size of the local file at path, or "?".
+func fileSize(path string) string {
+	path, err := feed.ExpandPath(path)
+	if err != nil {
+		return "?"
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "?"
+	}
+	return fmt.Sprintf("%d bytes", fi.Size())
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+var globalFlagNames = []string{"quiet", "verbose", "porcelain", "json", "no-migrate", "verify", "encrypt", "key-file", "feed"}
+
+// commandFlags returns the flag names of each command, keyed by its name,
+// and of those of its subs whose flags differ, keyed "name sub".
+func commandFlags() map[string][]string {
+	flags := map[string][]string{}
+	for _, c := range commands {
+		base := c.flagNames()
+		flags[c.name] = base
+		for _, sub := range c.subs {
+			if sub.flags == nil {
+				continue
+			}
+			if names := sub.flagNames(); !slices.Equal(names, base) {
+				flags[c.name+" "+sub.name] = names
+			}
+		}
+	}
+	return flags
+}
+
+// subKeys returns the "name sub" keys of flags, sorted.
+func subKeys(flags map[string][]string) []string {
+	var keys []string
+	for k := range flags {
+		if strings.Contains(k, " ") {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// dynamicFlags take a value listed by "linkleaf __complete KIND".
+var dynamicFlags = map[string]string{"id": "ids", "tag": "tags", "from": "tags", "add-tag": "tags", "remove-tag": "tags"}
+
+// pathFlags take a file name as their value.
+var pathFlags = []string{"file", "out", "in", "css", "template", "batch", "report", "templates", "key", "pub", "sig", "local", "remote", "base"}
+
+func cmdCompletion() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("completion", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: linkleaf completion bash|zsh|fish")
+	}
+	return fs, func() {
+		if fs.NArg() != 1 {
+			badUsage(fs)
+		}
+		switch fs.Arg(0) {
+		case "bash":
+			fmt.Print(bashCompletion())
+		case "zsh":
+			fmt.Print(zshCompletion())
+		case "fish":
+			fmt.Print(fishCompletion())
+		default:
+			die(invalid(fmt.Errorf("unsupported shell %q (want bash, zsh or fish)", fs.Arg(0))))
+		}
+	}
+}
+
+// cmdComplete backs the dynamic parts of the completion scripts: "ids"
+// and "tags" list those of the given feed (or the default one), "feeds"
+// the named feeds of the config. It is hidden from usage and prints
+// nothing on error so shells stay quiet.
+func cmdComplete(args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		os.Exit(2)
+	}
+	if args[0] == "feeds" {
+		for _, name := range slices.Sorted(maps.Keys(cfg.Feeds)) {
+			fmt.Println(name)
+		}
+		return
+	}
+	path := defaultFeed()
+	if len(args) == 2 && args[1] != "" {
+		path = args[1]
+	}
+	if path == "" {
+		os.Exit(1)
+	}
+	f, err := feed.LoadWith(path, loadOpts)
+	if err != nil {
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "ids":
+		for _, l := range f.Links {
+			fmt.Println(l.Id)
+		}
+	case "tags":
+		for _, tc := range feed.CountTags(f) {
+			fmt.Println(tc.Tag)
+		}
+	default:
+		os.Exit(2)
+	}
+}
+
+func commandNames() string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+	return strings.Join(names, " ")
+}
+
+// dashed renders flag names as "-a -b" (and "--a --b" when double is set).
+func dashed(names []string, double bool) string {
+	var parts []string
+	for _, n := range names {
+		parts = append(parts, "-"+n)
+		if double {
+			parts = append(parts, "--"+n)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func casePattern(names []string) string {
+	return strings.ReplaceAll(dashed(names, true), " ", "|")
+}
+
+// The scripts find the subcommand by skipping global flags (and the
+// values of -feed and -key-file), then pass those two on to __complete
+// along with the feed named by -file or a *.pb argument, if any.
+
+func bashCompletion() string {
+	var b strings.Builder
+	b.WriteString(`# bash completion for linkleaf; load with: source <(linkleaf completion bash)
+_linkleaf() {
+  local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+  local cmd="" sub="" file="" flags="" kind="" global=() i
+  COMPREPLY=()
+  for ((i = 1; i < COMP_CWORD; i++)); do
+    case "${COMP_WORDS[i]}" in
+      -feed|--feed|-key-file|--key-file) global+=("${COMP_WORDS[i]}" "${COMP_WORDS[i+1]}"); ((i++)) ;;
+      -*) ;;
+      *) cmd="${COMP_WORDS[i]}"; break ;;
+    esac
+  done
+  if [[ -z $cmd ]]; then
+    case "$prev" in
+      -feed|--feed) COMPREPLY=($(compgen -W "$(linkleaf __complete feeds 2>/dev/null)" -- "$cur")) ;;
+      -key-file|--key-file) COMPREPLY=($(compgen -f -- "$cur")) ;;
+`)
+	fmt.Fprintf(&b, "      *) [[ $cur == -* ]] && COMPREPLY=($(compgen -W %q -- \"$cur\")) || COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", dashed(globalFlagNames, false), commandNames())
+	b.WriteString(`    esac
+    return
+  fi
+  if ((COMP_CWORD == i + 1)) && [[ $cur != -* ]]; then
+    case "$cmd" in
+`)
+	for _, c := range commands {
+		if len(c.subs) > 0 {
+			fmt.Fprintf(&b, "      %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", c.name, strings.Join(c.subNames(), " "))
+		}
+	}
+	b.WriteString(`    esac
+    COMPREPLY+=($(compgen -f -- "$cur"))
+    return
+  fi
+  ((i + 1 < COMP_CWORD)) && sub="${COMP_WORDS[i+1]}"
+  case "$prev" in
+`)
+	for _, fl := range slices.Sorted(maps.Keys(dynamicFlags)) {
+		fmt.Fprintf(&b, "    -%s|--%s) kind=%s ;;\n", fl, fl, dynamicFlags[fl])
+	}
+	fmt.Fprintf(&b, "    %s)\n      COMPREPLY=($(compgen -f -- \"$cur\"))\n      return\n      ;;\n  esac\n", casePattern(pathFlags))
+	b.WriteString(`  if [[ -n $kind ]]; then
+    for ((i++; i < COMP_CWORD; i++)); do
+      case "${COMP_WORDS[i]}" in
+        -file|--file) file="${COMP_WORDS[i+1]}" ;;
+        *.pb) [[ -z $file ]] && file="${COMP_WORDS[i]}" ;;
+      esac
+    done
+    COMPREPLY=($(compgen -W "$(linkleaf "${global[@]}" __complete $kind "$file" 2>/dev/null)" -- "$cur"))
+    return
+  fi
+  case "$cmd $sub" in
+`)
+	flags := commandFlags()
+	for _, k := range subKeys(flags) {
+		fmt.Fprintf(&b, "    %q) flags=%q ;;\n", k, dashed(flags[k], false))
+	}
+	b.WriteString("    *)\n      case \"$cmd\" in\n")
+	for _, c := range commands {
+		if len(flags[c.name]) > 0 {
+			fmt.Fprintf(&b, "        %s) flags=%q ;;\n", c.name, dashed(flags[c.name], false))
+		}
+	}
+	b.WriteString(`      esac
+      ;;
+  esac
+  if [[ $cur == -* ]]; then
+    COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+  else
+    COMPREPLY=($(compgen -f -- "$cur"))
+  fi
+}
+complete -o filenames -F _linkleaf linkleaf
+`)
+	return b.String()
+}
+
+func zshCompletion() string {
+	var b strings.Builder
+	b.WriteString(`#compdef linkleaf
+# zsh completion for linkleaf; load with: source <(linkleaf completion zsh)
+_linkleaf() {
+`)
+	fmt.Fprintf(&b, "  local -a cmds gflags flags global\n  cmds=(%s)\n  gflags=(%s)\n", commandNames(), dashed(globalFlagNames, false))
+	b.WriteString(`  local cmd sub prev=$words[CURRENT-1] file kind i
+  for ((i = 2; i < CURRENT; i++)); do
+    case $words[i] in
+      -feed|--feed|-key-file|--key-file) global+=($words[i] $words[i+1]); ((i++)) ;;
+      -*) ;;
+      *) cmd=$words[i]; break ;;
+    esac
+  done
+  if [[ -z $cmd ]]; then
+    case $prev in
+      -feed|--feed) compadd -- ${(f)"$(linkleaf __complete feeds 2>/dev/null)"} ;;
+      -key-file|--key-file) _files ;;
+      *) [[ $PREFIX == -* ]] && compadd -a gflags || compadd -a cmds ;;
+    esac
+    return
+  fi
+  if ((CURRENT == i + 1)) && [[ $PREFIX != -* ]]; then
+    case $cmd in
+`)
+	for _, c := range commands {
+		if len(c.subs) > 0 {
+			fmt.Fprintf(&b, "      %s) compadd %s ;;\n", c.name, strings.Join(c.subNames(), " "))
+		}
+	}
+	b.WriteString(`    esac
+    _files
+    return
+  fi
+  ((i + 1 < CURRENT)) && sub=$words[i+1]
+  case $prev in
+`)
+	for _, fl := range slices.Sorted(maps.Keys(dynamicFlags)) {
+		fmt.Fprintf(&b, "    -%s|--%s) kind=%s ;;\n", fl, fl, dynamicFlags[fl])
+	}
+	fmt.Fprintf(&b, "    %s)\n      _files\n      return\n      ;;\n  esac\n", casePattern(pathFlags))
+	b.WriteString(`  if [[ -n $kind ]]; then
+    for ((i++; i < CURRENT; i++)); do
+      case $words[i] in
+        -file|--file) file=$words[i+1] ;;
+        *.pb) [[ -z $file ]] && file=$words[i] ;;
+      esac
+    done
+    compadd -- ${(f)"$(linkleaf $global __complete $kind "$file" 2>/dev/null)"}
+    return
+  fi
+  case "$cmd $sub" in
+`)
+	flags := commandFlags()
+	for _, k := range subKeys(flags) {
+		fmt.Fprintf(&b, "    %q) flags=(%s) ;;\n", k, dashed(flags[k], false))
+	}
+	b.WriteString("    *)\n      case $cmd in\n")
+	for _, c := range commands {
+		if len(flags[c.name]) > 0 {
+			fmt.Fprintf(&b, "        %s) flags=(%s) ;;\n", c.name, dashed(flags[c.name], false))
+		}
+	}
+	b.WriteString(`      esac
+      ;;
+  esac
+  if [[ $PREFIX == -* ]]; then
+    compadd -a flags
+  else
+    _files
+  fi
+}
+compdef _linkleaf linkleaf
+`)
+	return b.String()
+}
+
+func fishCompletion() string {
+	var b strings.Builder
+	b.WriteString(`# fish completion for linkleaf; load with: linkleaf completion fish | source
+function __linkleaf_cmd
+    set -l toks (commandline -opc)
+    set -l skip 0
+    for t in $toks[2..-1]
+        if test $skip = 1
+            set skip 0
+            continue
+        end
+        switch $t
+            case -feed --feed -key-file --key-file
+                set skip 1
+            case '-*'
+            case '*'
+                echo $t
+                return 0
+        end
+    end
+    return 1
+end
+
+function __linkleaf_needs_command
+    not __linkleaf_cmd >/dev/null
+end
+
+# __linkleaf_key prints the command, or "command sub" for a sub with
+# flags of its own.
+function __linkleaf_key
+    set -l toks (commandline -opc)
+    set -l skip 0
+    set -l cmd
+    for t in $toks[2..-1]
+        if test $skip = 1
+            set skip 0
+            continue
+        end
+        if test -n "$cmd"
+            contains -- "$cmd $t" $__linkleaf_sub_keys
+            and set cmd "$cmd $t"
+            break
+        end
+        switch $t
+            case -feed --feed -key-file --key-file
+                set skip 1
+            case '-*'
+            case '*'
+                set cmd $t
+        end
+    end
+    test -n "$cmd"
+    and echo $cmd
+end
+
+function __linkleaf_using
+    set -l key (__linkleaf_key)
+    and test "$key" = "$argv"
+end
+
+function __linkleaf_needs_sub
+    set -l toks (commandline -opc)
+    set -l cmd (__linkleaf_cmd)
+    and test "$cmd" = $argv[1]
+    and test "$toks[-1]" = $argv[1]
+end
+
+function __linkleaf_complete
+    set -l toks (commandline -opc)
+    set -l global
+    set -l file
+    for i in (seq 2 (count $toks))
+        switch $toks[$i]
+            case -feed --feed -key-file --key-file
+                set global $global $toks[$i] $toks[(math $i + 1)]
+            case -file --file
+                set file $toks[(math $i + 1)]
+            case '*.pb'
+                test -z "$file"; and set file $toks[$i]
+        end
+    end
+    linkleaf $global __complete $argv[1] $file 2>/dev/null
+end
+
+`)
+	flags := commandFlags()
+	keys := subKeys(flags)
+	for i, k := range keys {
+		keys[i] = fmt.Sprintf("%q", k)
+	}
+	fmt.Fprintf(&b, "set -g __linkleaf_sub_keys %s\n\n", strings.Join(keys, " "))
+	fmt.Fprintf(&b, "complete -c linkleaf -n __linkleaf_needs_command -f -a %q\n", commandNames())
+	for _, fl := range globalFlagNames {
+		switch fl {
+		case "feed":
+			b.WriteString("complete -c linkleaf -n __linkleaf_needs_command -o feed -x -a '(linkleaf __complete feeds 2>/dev/null)'\n")
+		case "key-file":
+			b.WriteString("complete -c linkleaf -n __linkleaf_needs_command -o key-file -r -F\n")
+		default:
+			fmt.Fprintf(&b, "complete -c linkleaf -n __linkleaf_needs_command -o %s\n", fl)
+		}
+	}
+	for _, c := range commands {
+		if len(c.subs) > 0 {
+			fmt.Fprintf(&b, "complete -c linkleaf -n '__linkleaf_needs_sub %s' -a %q\n", c.name, strings.Join(c.subNames(), " "))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(flags)) {
+		for _, fl := range flags[key] {
+			cond := fmt.Sprintf("'__linkleaf_using %s'", key)
+			switch kind, dynamic := dynamicFlags[fl]; {
+			case dynamic:
+				fmt.Fprintf(&b, "complete -c linkleaf -n %s -o %s -x -a '(__linkleaf_complete %s)'\n", cond, fl, kind)
+			case slices.Contains(pathFlags, fl):
+				fmt.Fprintf(&b, "complete -c linkleaf -n %s -o %s -r -F\n", cond, fl)
+			default:
+				fmt.Fprintf(&b, "complete -c linkleaf -n %s -o %s\n", cond, fl)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,607 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+// config is ~/.config/linkleaf/config.toml. Every field is optional and
+// only supplies defaults; flags and LINKLEAF_FEED take precedence.
+type config struct {
+	Feed     string   // default feed when a command gets none
+	Tags     []string // tags added to every new link
+	IDScheme string   // default -id-scheme
+	Policy   string   // default -policy of add and validate
+	Author   struct {
+		Name, Email, URL string
+	}
+	Export struct {
+		Link, SiteTitle, Description, FeedURL, CSS string
+	}
+	Serve struct {
+		APITokens, APIOrigins []string
+	}
+	Webhooks struct {
+		URLs   []string
+		Secret string
+	}
+	Hooks struct {
+		PreAdd, PostAdd, PreEdit, PostEdit, PreRemove, PostRemove string
+	}
+	Mastodon struct {
+		Server, Token, Visibility string
+	}
+	Bluesky struct {
+		Handle, AppPassword, Service string
+	}
+	ActivityPub struct {
+		URL, User string
+	}
+	Webmention struct {
+		Source string
+	}
+	Email struct {
+		SMTP, Username, Password, From, Subject string
+		To                                      []string
+	}
+	Trash struct {
+		Retention string
+	}
+	Feeds map[string]string // named feeds, for -feed NAME
+	Watch map[string]string // named steps of watch, shell commands
+}
+
+// cfg is the loaded config file (zero if there is none).
+var cfg config
+
+// configField maps a dotted key ("author.name") to a config field; str or
+// list is set depending on the TOML type. check, if set, validates (and
+// may normalize) a list given to "config set".
+type configField struct {
+	key   string
+	help  string
+	str   func(*config) *string
+	list  func(*config) *[]string
+	check func([]string) ([]string, error)
+}
+
+var configFields = []configField{
+	{key: "feed", help: "default feed: a file or a name from [feeds] ($LINKLEAF_FEED overrides)", str: func(c *config) *string { return &c.Feed }},
+	{key: "tags", help: "tags added to every link created by add", list: func(c *config) *[]string { return &c.Tags }, check: validTags},
+	{key: "id_scheme", help: "default -id-scheme of add, capture, serve, daemon and reid (default urlhash)", str: func(c *config) *string { return &c.IDScheme }},
+	{key: "policy", help: "policy file whose rules add and validate enforce (see validate -policy)", str: func(c *config) *string { return &c.Policy }},
+	{key: "author.name", help: "author for rss/atom/jsonfeed and of links you add", str: func(c *config) *string { return &c.Author.Name }},
+	{key: "author.email", help: "author e-mail for rss/atom", str: func(c *config) *string { return &c.Author.Email }},
+	{key: "author.url", help: "author home page for atom/jsonfeed", str: func(c *config) *string { return &c.Author.URL }},
+	{key: "export.link", help: "default for export -link", str: func(c *config) *string { return &c.Export.Link }},
+	{key: "export.site_title", help: "default for export -site-title", str: func(c *config) *string { return &c.Export.SiteTitle }},
+	{key: "export.description", help: "default for export -description", str: func(c *config) *string { return &c.Export.Description }},
+	{key: "export.feed_url", help: "default for export -feed-url", str: func(c *config) *string { return &c.Export.FeedURL }},
+	{key: "export.css", help: "default for export/build -css", str: func(c *config) *string { return &c.Export.CSS }},
+	{key: "serve.api_tokens", help: "bearer tokens for serve's /api/v1 (none: no API)", list: func(c *config) *[]string { return &c.Serve.APITokens }, check: validTokens},
+	{key: "serve.api_origins", help: "web origins allowed to call /api/v1 from a browser (\"*\": any)", list: func(c *config) *[]string { return &c.Serve.APIOrigins }, check: validOrigins},
+	{key: "webhooks.urls", help: "URLs POSTed a JSON description of every saved change", list: func(c *config) *[]string { return &c.Webhooks.URLs }, check: validURLs},
+	{key: "webhooks.secret", help: "HMAC-SHA256 key for the webhooks' X-Linkleaf-Signature-256 header", str: func(c *config) *string { return &c.Webhooks.Secret }},
+	{key: "hooks.pre-add", help: "command run before a link is added (link JSON on stdin); failing aborts", str: func(c *config) *string { return &c.Hooks.PreAdd }},
+	{key: "hooks.post-add", help: "command run after a link was added", str: func(c *config) *string { return &c.Hooks.PostAdd }},
+	{key: "hooks.pre-edit", help: "command run before a link is edited (new link JSON on stdin); failing aborts", str: func(c *config) *string { return &c.Hooks.PreEdit }},
+	{key: "hooks.post-edit", help: "command run after a link was edited", str: func(c *config) *string { return &c.Hooks.PostEdit }},
+	{key: "hooks.pre-remove", help: "command run before a link is removed (link JSON on stdin); failing aborts", str: func(c *config) *string { return &c.Hooks.PreRemove }},
+	{key: "hooks.post-remove", help: "command run after a link was removed", str: func(c *config) *string { return &c.Hooks.PostRemove }},
+	{key: "mastodon.server", help: "Mastodon instance for publish, e.g. https://mastodon.social", str: func(c *config) *string { return &c.Mastodon.Server }},
+	{key: "mastodon.token", help: "Mastodon access token with write:statuses", str: func(c *config) *string { return &c.Mastodon.Token }},
+	{key: "mastodon.visibility", help: "public, unlisted, private or direct (default: the account's)", str: func(c *config) *string { return &c.Mastodon.Visibility }},
+	{key: "bluesky.handle", help: "Bluesky account for publish, e.g. alice.bsky.social", str: func(c *config) *string { return &c.Bluesky.Handle }},
+	{key: "bluesky.app_password", help: "Bluesky app password", str: func(c *config) *string { return &c.Bluesky.AppPassword }},
+	{key: "bluesky.service", help: "Bluesky PDS (default https://bsky.social)", str: func(c *config) *string { return &c.Bluesky.Service }},
+	{key: "activitypub.url", help: "public URL of serve; makes the feed a followable ActivityPub actor", str: func(c *config) *string { return &c.ActivityPub.URL }},
+	{key: "activitypub.user", help: "ActivityPub user name, as in @user@host (default links)", str: func(c *config) *string { return &c.ActivityPub.User }},
+	{key: "webmention.source", help: "your page for a link, {id} replaced by its ID (e.g. https://links.example.com/#{id})", str: func(c *config) *string { return &c.Webmention.Source }},
+	{key: "email.smtp", help: "SMTP server for export email -send, host:port (465: TLS, else STARTTLS if offered)", str: func(c *config) *string { return &c.Email.SMTP }},
+	{key: "email.username", help: "SMTP user name (none: no authentication)", str: func(c *config) *string { return &c.Email.Username }},
+	{key: "email.password", help: "SMTP password ($LINKLEAF_SMTP_PASSWORD overrides)", str: func(c *config) *string { return &c.Email.Password }},
+	{key: "email.from", help: "default for export email -from", str: func(c *config) *string { return &c.Email.From }},
+	{key: "email.to", help: "default recipients of export email", list: func(c *config) *[]string { return &c.Email.To }},
+	{key: "email.subject", help: "default for export email -subject", str: func(c *config) *string { return &c.Email.Subject }},
+	{key: "trash.retention", help: "how long removed links stay in the trash: an age such as 30d, 2w or 3m (default 30d), or forever", str: func(c *config) *string { return &c.Trash.Retention }},
+}
+
+// validURLs checks a list of http(s) URLs, such as webhooks.urls.
+func validURLs(urls []string) ([]string, error) {
+	for _, u := range urls {
+		if err := feed.ValidateURL(u); err != nil {
+			return nil, err
+		}
+	}
+	return urls, nil
+}
+
+// validTokens checks bearer tokens: no whitespace, which the
+// Authorization header couldn't carry.
+func validTokens(tokens []string) ([]string, error) {
+	for _, t := range tokens {
+		if strings.IndexFunc(t, unicode.IsSpace) >= 0 {
+			return nil, errors.New("a token contains whitespace")
+		}
+	}
+	return tokens, nil
+}
+
+// validOrigins checks web origins, as browsers send them in the Origin
+// header: scheme://host[:port], or "*" for any.
+func validOrigins(origins []string) ([]string, error) {
+	for _, o := range origins {
+		if o == "*" {
+			continue
+		}
+		u, err := url.Parse(o)
+		if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return nil, fmt.Errorf("origin %q: want scheme://host[:port], e.g. https://app.example, or *", o)
+		}
+	}
+	return origins, nil
+}
+
+func lookupConfigField(key string) (configField, bool) {
+	i := slices.IndexFunc(configFields, func(f configField) bool { return f.key == key })
+	if i < 0 {
+		return configField{}, false
+	}
+	return configFields[i], true
+}
+
+// configPath is $LINKLEAF_CONFIG, else linkleaf/config.toml in the user
+// config directory ($XDG_CONFIG_HOME or ~/.config on Linux).
+func configPath() (string, error) {
+	if p := os.Getenv("LINKLEAF_CONFIG"); p != "" {
+		return feed.ExpandPath(p)
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "linkleaf", "config.toml"), nil
+}
+
+// loadConfig reads the config file into cfg; a missing file is fine.
+func loadConfig() error {
+	path, err := configPath()
+	if err != nil {
+		return nil // no home directory: run without a config
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c, err := parseConfig(b)
+	if err != nil {
+		return invalid(fmt.Errorf("%s:%w", path, err))
+	}
+	cfg = c
+	return nil
+}
+
+// writeConfig saves c to the config file at path. The file holds tokens
+// and passwords, so a new one is readable by its owner only; an existing
+// one keeps its mode.
+func writeConfig(path string, c config) error {
+	perm := os.FileMode(0o600)
+	if fi, err := os.Stat(path); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	return feed.WriteFileAtomic(path, c.encode(), perm)
+}
+
+// defaultFeed is the feed used when a command is given none: the one
+// selected with -feed, else $LINKLEAF_FEED, else the config's "feed",
+// which may be a path or the name of a feed in [feeds].
+func defaultFeed() string {
+	if feedName != "" {
+		return cfg.Feeds[feedName]
+	}
+	if p := os.Getenv("LINKLEAF_FEED"); p != "" {
+		return p
+	}
+	if p, ok := cfg.Feeds[cfg.Feed]; ok {
+		return p
+	}
+	return cfg.Feed
+}
+
+// feedArg resolves the feed of a command that takes it as an optional
+// positional argument or -file: the argument, else file, else the
+// default feed. ok is false when there is none or both were given.
+func feedArg(fs *flag.FlagSet, file string) (path string, ok bool) {
+	switch {
+	case fs.NArg() == 1 && file == "":
+		return fs.Arg(0), true
+	case fs.NArg() != 0:
+		return "", false
+	case file != "":
+		return file, true
+	}
+	def := defaultFeed()
+	return def, def != ""
+}
+
+// defaultIDScheme is the -id-scheme used when none is given.
+func defaultIDScheme() string {
+	if cfg.IDScheme != "" {
+		return cfg.IDScheme
+	}
+	return feed.DefaultIDScheme
+}
+
+// withDefaultTags appends the config's default tags to those of a new link.
+func withDefaultTags(tags []string) []string {
+	if len(cfg.Tags) == 0 {
+		return tags
+	}
+	return feed.UniqueTags(append(tags, cfg.Tags...))
+}
+
+// parseConfig reads the config file; see parseTOML.
+func parseConfig(b []byte) (config, error) {
+	var c config
+	err := parseTOML(b, c.set)
+	return c, err
+}
+
+// parseTOML reads the TOML subset the config and policy files use:
+// comments, [tables], and key = value with strings, booleans, integers or
+// arrays of strings. set gets each value under its dotted key.
+func parseTOML(b []byte, set func(key string, val any) error) error {
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	table := ""
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			end := strings.IndexByte(line, ']')
+			if end < 0 || strings.TrimSpace(stripComment(line[end+1:])) != "" {
+				return fmt.Errorf("%d: bad table header %q", n, line)
+			}
+			table = strings.TrimSpace(line[1:end])
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("%d: want key = value, got %q", n, line)
+		}
+		key := strings.Trim(strings.TrimSpace(k), `"`)
+		if table != "" {
+			key = table + "." + key
+		}
+		val, err := parseTOMLValue(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("%d: %s: %w", n, key, err)
+		}
+		if err := set(key, val); err != nil {
+			return fmt.Errorf("%d: %w", n, err)
+		}
+	}
+	return sc.Err()
+}
+
+// set stores a parsed value (string or []string) under key.
+func (c *config) set(key string, val any) error {
+	if name, ok := strings.CutPrefix(key, "feeds."); ok {
+		path, isStr := val.(string)
+		if !isStr {
+			return fmt.Errorf("%s: want a string", key)
+		}
+		if err := validFeedName(name); err != nil {
+			return err
+		}
+		if c.Feeds == nil {
+			c.Feeds = make(map[string]string)
+		}
+		c.Feeds[name] = path
+		return nil
+	}
+	if name, ok := strings.CutPrefix(key, "watch."); ok {
+		command, isStr := val.(string)
+		if !isStr {
+			return fmt.Errorf("%s: want a string", key)
+		}
+		if err := validKeyName("step", name); err != nil {
+			return err
+		}
+		if c.Watch == nil {
+			c.Watch = make(map[string]string)
+		}
+		c.Watch[name] = command
+		return nil
+	}
+	f, ok := lookupConfigField(key)
+	if !ok {
+		return fmt.Errorf("unknown key %q", key)
+	}
+	switch v := val.(type) {
+	case string:
+		if f.str == nil {
+			return fmt.Errorf("%s: want an array of strings", key)
+		}
+		*f.str(c) = v
+	case []string:
+		if f.list == nil {
+			return fmt.Errorf("%s: want a string", key)
+		}
+		*f.list(c) = v
+	default:
+		return fmt.Errorf("%s: unsupported value %v", key, v)
+	}
+	return nil
+}
+
+// parseTOMLValue parses a string, array of strings, boolean or integer;
+// the latter two come back as their text.
+func parseTOMLValue(s string) (any, error) {
+	switch {
+	case strings.HasPrefix(s, "["):
+		var list []string
+		rest := strings.TrimSpace(s[1:])
+		for {
+			rest = strings.TrimLeft(rest, " \t,")
+			if strings.HasPrefix(rest, "]") {
+				if strings.TrimSpace(stripComment(rest[1:])) != "" {
+					return nil, fmt.Errorf("trailing text after array")
+				}
+				return list, nil
+			}
+			if rest == "" {
+				return nil, errors.New("unterminated array (arrays must fit on one line)")
+			}
+			str, n, err := parseTOMLString(rest)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, str)
+			rest = strings.TrimSpace(rest[n:])
+		}
+	case strings.HasPrefix(s, `"`), strings.HasPrefix(s, "'"):
+		str, n, err := parseTOMLString(s)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(stripComment(s[n:])) != "" {
+			return nil, errors.New("trailing text after string")
+		}
+		return str, nil
+	}
+	s = strings.TrimSpace(stripComment(s))
+	if s == "true" || s == "false" {
+		return s, nil
+	}
+	if _, err := strconv.ParseInt(strings.ReplaceAll(s, "_", ""), 0, 64); err == nil {
+		return s, nil
+	}
+	return nil, fmt.Errorf("unsupported value %q (quote strings)", s)
+}
+
+// parseTOMLString parses the basic ("...") or literal ('...') string at
+// the start of s and returns it with the number of bytes consumed.
+func parseTOMLString(s string) (string, int, error) {
+	if s[0] == '\'' {
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", 0, errors.New("unterminated string")
+		}
+		return s[1 : end+1], end + 2, nil
+	}
+	if s[0] != '"' {
+		return "", 0, fmt.Errorf("want a quoted string, got %q", s)
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\\':
+			i++
+			if i >= len(s) {
+				return "", 0, errors.New("unterminated string")
+			}
+			switch e := s[i]; e {
+			case 'b':
+				b.WriteByte('\b')
+			case 't':
+				b.WriteByte('\t')
+			case 'n':
+				b.WriteByte('\n')
+			case 'f':
+				b.WriteByte('\f')
+			case 'r':
+				b.WriteByte('\r')
+			case '"', '\\':
+				b.WriteByte(e)
+			case 'u', 'U':
+				n := 4
+				if e == 'U' {
+					n = 8
+				}
+				if i+n >= len(s) {
+					return "", 0, errors.New("short unicode escape")
+				}
+				r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+				if err != nil {
+					return "", 0, fmt.Errorf("bad unicode escape: %w", err)
+				}
+				b.WriteRune(rune(r))
+				i += n
+			default:
+				return "", 0, fmt.Errorf("unknown escape \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+func stripComment(s string) string {
+	if i := strings.IndexByte(s, '#'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// quoteTOML renders s as a TOML basic string.
+func quoteTOML(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f || r == utf8.RuneError:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func (f configField) value(c *config) (string, bool) {
+	if f.list != nil {
+		l := *f.list(c)
+		quoted := make([]string, len(l))
+		for i, s := range l {
+			quoted[i] = quoteTOML(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]", l != nil
+	}
+	s := *f.str(c)
+	return quoteTOML(s), s != ""
+}
+
+// encode renders the set fields of c as TOML, tables in field order.
+func (c *config) encode() []byte {
+	var b bytes.Buffer
+	b.WriteString("# linkleaf configuration (see `linkleaf config`)\n")
+	table := ""
+	for _, f := range configFields {
+		v, set := f.value(c)
+		if !set {
+			continue
+		}
+		t, key, ok := strings.Cut(f.key, ".")
+		if !ok {
+			t, key = "", f.key
+		}
+		if t != table {
+			fmt.Fprintf(&b, "\n[%s]\n", t)
+			table = t
+		}
+		fmt.Fprintf(&b, "%s = %s\n", key, v)
+	}
+	if len(c.Feeds) > 0 {
+		b.WriteString("\n[feeds]\n")
+		for _, name := range slices.Sorted(maps.Keys(c.Feeds)) {
+			fmt.Fprintf(&b, "%s = %s\n", name, quoteTOML(c.Feeds[name]))
+		}
+	}
+	if len(c.Watch) > 0 {
+		b.WriteString("\n[watch]\n")
+		for _, name := range slices.Sorted(maps.Keys(c.Watch)) {
+			fmt.Fprintf(&b, "%s = %s\n", name, quoteTOML(c.Watch[name]))
+		}
+	}
+	return b.Bytes()
+}
+
+func cmdConfig() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: linkleaf config [list | path | get KEY | set KEY VALUE | unset KEY]\n\nkeys:")
+		for _, f := range configFields {
+			fmt.Fprintf(os.Stderr, "  %-20s %s\n", f.key, f.help)
+		}
+	}
+	return fs, func() {
+		sub := "list"
+		if fs.NArg() > 0 {
+			sub = fs.Arg(0)
+		}
+		path, err := configPath()
+		if err != nil {
+			die(err)
+		}
+		rest := fs.Args()[min(1, fs.NArg()):]
+
+		switch {
+		case sub == "path" && len(rest) == 0:
+			fmt.Println(path)
+		case sub == "list" && len(rest) == 0:
+			os.Stdout.Write(cfg.encode())
+			if def := os.Getenv("LINKLEAF_FEED"); def != "" {
+				fmt.Printf("# LINKLEAF_FEED=%s overrides feed\n", def)
+			}
+		case sub == "get" && len(rest) == 1:
+			f, ok := lookupConfigField(rest[0])
+			if !ok {
+				die(invalid(fmt.Errorf("unknown key %q", rest[0])))
+			}
+			if f.list != nil {
+				fmt.Println(strings.Join(*f.list(&cfg), ","))
+			} else {
+				fmt.Println(*f.str(&cfg))
+			}
+		case sub == "set" && len(rest) == 2, sub == "unset" && len(rest) == 1:
+			f, ok := lookupConfigField(rest[0])
+			if !ok {
+				die(invalid(fmt.Errorf("unknown key %q", rest[0])))
+			}
+			c := cfg
+			switch {
+			case sub == "unset" && f.list != nil:
+				*f.list(&c) = nil
+			case sub == "unset":
+				*f.str(&c) = ""
+			case f.list != nil:
+				list := feed.SplitTags(rest[1])
+				if f.check != nil {
+					var err error
+					if list, err = f.check(list); err != nil {
+						die(invalid(fmt.Errorf("%s: %w", f.key, err)))
+					}
+				}
+				*f.list(&c) = list
+			default:
+				*f.str(&c) = rest[1]
+			}
+			if err := writeConfig(path, c); err != nil {
+				die(err)
+			}
+			msg.Infof("%s %s in %s", sub, f.key, path)
+		default:
+			badUsage(fs)
+		}
+	}
+}
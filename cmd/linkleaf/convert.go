@@ -0,0 +1,56 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"slices"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+func cmdConvert() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	var to, out string
+	fs.StringVar(&to, "to", "", "storage format: "+feed.FormatProto+" (one message), "+feed.FormatStream+" (append-friendly records), "+feed.FormatSQLite+" (indexed database) or "+feed.FormatSharded+" (a directory with a file per year)")
+	fs.StringVar(&out, "out", "", "write the converted feed here, leaving <file.pb> as it is")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, "")
+		if !ok || !slices.Contains([]string{feed.FormatProto, feed.FormatStream, feed.FormatSQLite, feed.FormatSharded}, to) {
+			badUsage(fs)
+		}
+		dest := cmp.Or(out, path)
+		sf.format = to
+
+		sf.lock(dest)
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
+		}
+		from := feed.FormatProto
+		switch {
+		case feed.IsStreamFile(path):
+			from = feed.FormatStream
+		case feed.IsSQLiteFile(path):
+			from = feed.FormatSQLite
+		case feed.IsShardedFeed(path):
+			from = feed.FormatSharded
+		}
+		if from == to && out == "" {
+			msg.Infof("%s is already in %s format", path, to)
+			return
+		}
+		if (from == feed.FormatSharded) != (to == feed.FormatSharded) && out == "" {
+			// A directory can't become a file in place, nor the other way round.
+			die(invalid(errors.New("converting to or from a sharded feed needs -out")))
+		}
+		if out == "" {
+			sf.loaded(f) // with -out the journal records a new file, as for init
+		}
+		if err := sf.save(dest, f); err != nil {
+			die(err)
+		}
+		msg.Infof("converted %s (%s) to %s (%s, %d links)", path, from, dest, to, len(f.Links))
+	}
+}
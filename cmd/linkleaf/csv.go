@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// csvHeader is the column order written by CSV export. Import matches
+// columns by header name, so other orders (and extra columns) are fine.
+var csvHeader = []string{"id", "title", "url", "date", "tags", "summary", "via"}
+
+// csvTagSep joins tags within the single tags column.
+const csvTagSep = ";"
+
+func renderCSV(f *v1.Feed) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(csvHeader)
+	for _, l := range f.Links {
+		w.Write([]string{l.Id, l.Title, l.Url, l.Date, strings.Join(l.Tags, csvTagSep), l.Summary, l.Via})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// lineWarning is an input line that was skipped (import, add -batch).
+type lineWarning struct {
+	line int
+	err  error
+}
+
+func (w lineWarning) Error() string { return fmt.Sprintf("line %d: %v", w.line, w.err) }
+
+// csvOptions configure readCSV. The zero value reads comma-separated
+// input whose header row names the columns.
+type csvOptions struct {
+	comma rune
+	// columns maps link fields to 1-based column numbers or header names
+	// (import -map); nil matches columns by header name.
+	columns map[string]string
+}
+
+// parseColumnMap parses an import -map value like url=1,title=2,date=3,
+// where each column is a 1-based number or a header name.
+func parseColumnMap(s string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		field, col, ok := strings.Cut(part, "=")
+		field, col = strings.ToLower(strings.TrimSpace(field)), strings.TrimSpace(col)
+		switch {
+		case !ok || col == "":
+			return nil, fmt.Errorf("-map: want field=column, got %q", part)
+		case !slices.Contains(csvHeader, field):
+			return nil, fmt.Errorf("-map: unknown field %q (want %s)", field, strings.Join(csvHeader, ", "))
+		case m[field] != "":
+			return nil, fmt.Errorf("-map: %s given twice", field)
+		}
+		if n, err := strconv.Atoi(col); err == nil && n < 1 {
+			return nil, fmt.Errorf("-map: %s=%d: columns count from 1", field, n)
+		}
+		m[field] = col
+	}
+	for _, req := range []string{"title", "url", "date"} {
+		if m[req] == "" {
+			return nil, fmt.Errorf("-map has no %s column", req)
+		}
+	}
+	return m, nil
+}
+
+// readCSV parses links from r. Rows lacking title, url or date, or with an
+// invalid date or tags, are skipped and reported as warnings; only a
+// malformed header, a bad column mapping or unreadable input is an error.
+//
+// With opts.columns given only as numbers, the first row counts as a
+// header unless its url cell holds a URL.
+func readCSV(r io.Reader, opts csvOptions) ([]*v1.Link, []lineWarning, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	if opts.comma != 0 {
+		cr.Comma = opts.comma
+	}
+	first, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read csv header: %w", err)
+	}
+	names := map[string]int{}
+	for i, name := range first {
+		names[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	cell := func(rec []string, i int) string {
+		if i >= 0 && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	col, header := names, true
+	if opts.columns != nil {
+		col, header = map[string]int{}, false
+		for field, c := range opts.columns {
+			if n, err := strconv.Atoi(c); err == nil {
+				col[field] = n - 1
+				continue
+			}
+			i, ok := names[strings.ToLower(c)]
+			if !ok {
+				return nil, nil, fmt.Errorf("-map %s=%s: no such column in the header", field, c)
+			}
+			col[field], header = i, true
+		}
+		if !header {
+			u, err := url.Parse(cell(first, col["url"]))
+			header = err != nil || u.Scheme == "" || u.Host == ""
+		}
+	}
+	for _, req := range []string{"title", "url", "date"} {
+		if _, ok := col[req]; !ok {
+			return nil, nil, fmt.Errorf("csv header has no %q column", req)
+		}
+	}
+
+	var links []*v1.Link
+	var warnings []lineWarning
+	row := func(rec []string, line int) {
+		get := func(name string) string {
+			if i, ok := col[name]; ok {
+				return cell(rec, i)
+			}
+			return ""
+		}
+		l := &v1.Link{
+			Id:      get("id"),
+			Title:   get("title"),
+			Url:     get("url"),
+			Date:    get("date"),
+			Summary: get("summary"),
+			Via:     get("via"),
+		}
+		var missing []string
+		for _, req := range [][2]string{{"title", l.Title}, {"url", l.Url}, {"date", l.Date}} {
+			if req[1] == "" {
+				missing = append(missing, req[0])
+			}
+		}
+		if len(missing) > 0 {
+			warnings = append(warnings, lineWarning{line, fmt.Errorf("missing %s", strings.Join(missing, ", "))})
+			return
+		}
+		if _, err := feed.ParseDate(l.Date); err != nil {
+			warnings = append(warnings, lineWarning{line, fmt.Errorf("date %q: want YYYY-MM-DD", l.Date)})
+			return
+		}
+		tags := strings.FieldsFunc(get("tags"), func(r rune) bool { return r == ';' || r == ',' })
+		for i := range tags {
+			tags[i] = strings.TrimSpace(tags[i])
+		}
+		var err error
+		if l.Tags, err = validTags(slices.DeleteFunc(tags, func(t string) bool { return t == "" })); err != nil {
+			warnings = append(warnings, lineWarning{line, err})
+			return
+		}
+		if l.Id == "" {
+			l.Id = feed.LinkID(l.Url, l.Date)
+		}
+		links = append(links, l)
+	}
+
+	if !header {
+		row(first, 1)
+	}
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				warnings = append(warnings, lineWarning{perr.Line, perr.Err})
+				continue
+			}
+			return nil, nil, err
+		}
+		row(rec, line)
+	}
+	return links, warnings, nil
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// feedMetadataKey is the gRPC metadata naming the feed a daemon call is
+// for; without it the call goes to the default feed.
+const feedMetadataKey = "linkleaf-feed"
+
+// cmdDaemon keeps the default feed and the configured [feeds] loaded and
+// indexed, reloading each one in the background when its file changes,
+// and answers the FeedService (gRPC) and REST API from memory.
+func cmdDaemon() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	var addr, grpcAddr, token, idScheme, names string
+	fs.StringVar(&grpcAddr, "grpc", "localhost:9090", "gRPC listen address for linkleaf.v1.FeedService (\"\": none)")
+	fs.StringVar(&addr, "addr", "", "also serve the REST API (needs serve.api_tokens) on this HTTP address, e.g. localhost:8080")
+	fs.StringVar(&token, "grpc-token", os.Getenv("LINKLEAF_GRPC_TOKEN"), "bearer token gRPC calls must send (default $LINKLEAF_GRPC_TOKEN)")
+	var insecure bool
+	fs.BoolVar(&insecure, "grpc-insecure", false, "serve gRPC without -grpc-token on an address other machines can reach")
+	fs.StringVar(&idScheme, "id-scheme", defaultIDScheme(), "ID generator for AddLink: "+strings.Join(feed.IDSchemes(), ", "))
+	fs.StringVar(&names, "feeds", "all", "configured feeds to keep loaded besides the default one: comma-separated names, all or none")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if fs.NArg() != 0 || addr == "" && grpcAddr == "" {
+			badUsage(fs)
+		}
+		if addr != "" && len(cfg.Serve.APITokens) == 0 {
+			die(invalid(errors.New("daemon -addr serves the REST API, which needs serve.api_tokens in the config")))
+		}
+		genID, err := feed.IDScheme(idScheme)
+		if err != nil {
+			die(invalid(err))
+		}
+
+		// Feeds by name, "" being the default; names of the same file share
+		// one service.
+		var named []string
+		switch names {
+		case "all":
+			named = slices.Sorted(maps.Keys(cfg.Feeds))
+		case "none":
+		default:
+			for _, n := range strings.Split(names, ",") {
+				n = strings.TrimSpace(n)
+				if _, ok := cfg.Feeds[n]; !ok {
+					die(notFound(fmt.Errorf("no feed named %q (see linkleaf feeds list)", n)))
+				}
+				named = append(named, n)
+			}
+		}
+		paths := map[string]string{}
+		if def := defaultFeed(); def != "" {
+			paths[""] = def
+		}
+		for _, n := range named {
+			paths[n] = cfg.Feeds[n]
+		}
+		if len(paths) == 0 {
+			die(invalid(errors.New("no feed to serve: set a default feed or add some with linkleaf feeds add")))
+		}
+		svcs := map[string]*feedService{}
+		byPath := map[string]*feedService{}
+		for _, n := range slices.Sorted(maps.Keys(paths)) {
+			path := paths[n]
+			if storage.IsRemote(path) {
+				die(invalid(fmt.Errorf("daemon needs local files, got %s", path)))
+			}
+			key, err := feed.ExpandPath(path)
+			if err != nil {
+				die(err)
+			}
+			if svc := byPath[key]; svc != nil {
+				svcs[n] = svc
+				continue
+			}
+			cache := &feedCache{path: path, indexed: true}
+			start := time.Now()
+			f, err := cache.get()
+			if err != nil {
+				die(err)
+			}
+			msg.Debugf("loaded %s (%d links) in %v", path, len(f.Links), time.Since(start))
+			svcs[n] = newFeedService(cache, genID, sf)
+			byPath[key] = svcs[n]
+		}
+
+		var srv *http.Server
+		if addr != "" {
+			mux := http.NewServeMux()
+			for n, svc := range svcs {
+				api := &apiServer{svc: svc, tokens: cfg.Serve.APITokens, origins: cfg.Serve.APIOrigins}
+				if n != "" {
+					api.prefix = "/api/v1/feeds/" + n
+				}
+				api.register(mux)
+			}
+			srv = &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		}
+		var gs *grpc.Server
+		var lis net.Listener
+		router := &feedRouter{svcs: svcs}
+		if grpcAddr != "" {
+			if err := checkGRPCAuth(grpcAddr, token, insecure); err != nil {
+				die(invalid(err))
+			}
+			if lis, err = net.Listen("tcp", grpcAddr); err != nil {
+				die(err)
+			}
+			if token == "" && !isLoopback(grpcAddr) {
+				fmt.Fprintf(os.Stderr, "warning: anyone who can reach %s can change the feeds; set -grpc-token\n", grpcAddr)
+			}
+			gs = grpcServer(router, token)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		done := make(chan struct{})
+		for _, svc := range byPath {
+			go svc.cache.watch(done)
+		}
+		stopped := make(chan struct{})
+		go func() {
+			<-ctx.Done()
+			close(done)
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if srv != nil {
+				srv.Shutdown(shutdown)
+			}
+			if gs != nil {
+				for _, svc := range byPath {
+					close(svc.done)
+				}
+				stopGRPC(gs)
+			}
+			close(stopped)
+		}()
+
+		for _, n := range slices.Sorted(maps.Keys(svcs)) {
+			if n == "" {
+				msg.Infof("keeping %s loaded (default feed)", svcs[n].cache.path)
+			} else {
+				msg.Infof("keeping %s loaded (feed %s)", svcs[n].cache.path, n)
+			}
+		}
+		if gs != nil {
+			msg.Infof("serving linkleaf.v1.FeedService on %s (gRPC; %q metadata picks a feed)", grpcAddr, feedMetadataKey)
+			go func() {
+				if err := gs.Serve(lis); err != nil {
+					die(err)
+				}
+			}()
+		}
+		if srv != nil {
+			msg.Infof("serving the REST API on %s (under /api/v1, named feeds under /api/v1/feeds/NAME)", addr)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				die(err)
+			}
+		}
+		<-stopped
+	}
+}
+
+// watch reloads the feed whenever its file changes, until done is closed,
+// so requests find it decoded and indexed already.
+func (c *feedCache) watch(done <-chan struct{}) {
+	tick := time.NewTicker(watchInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-tick.C:
+		}
+		if _, err := c.get(); err != nil {
+			msg.Debugf("watch %s: %v", c.path, err) // e.g. mid-rewrite; try again next tick
+		}
+	}
+}
+
+// listIndexed is ListLinks for an indexed cache: it selects from the index
+// instead of reading the file.
+func (s *feedService) listIndexed(flt feed.Filter, query string, offset, limit int) (*v1.ListLinksResponse, error) {
+	x, err := s.cache.linkIndex()
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if query == "" {
+		links, more := page(x.Select(flt), offset, limit)
+		return &v1.ListLinksResponse{Links: links, More: more}, nil
+	}
+	q, err := feed.ParseQuery(query)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "query: %v", err)
+	}
+	// The query's tag: and domain: terms can use the index too.
+	narrow := q.Filter()
+	flt.Tags = append(flt.Tags, narrow.Tags...)
+	if flt.Domain == "" {
+		flt.Domain = narrow.Domain
+	}
+	links, more := page(q.Apply(x.Select(flt)), offset, limit)
+	return &v1.ListLinksResponse{Links: links, More: more}, nil
+}
+
+// feedRouter is the daemon's FeedService: it hands each call to the
+// service of the feed its feedMetadataKey metadata names.
+type feedRouter struct {
+	v1.UnimplementedFeedServiceServer
+
+	svcs map[string]*feedService
+}
+
+func (r *feedRouter) service(ctx context.Context) (*feedService, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	name := strings.Join(md.Get(feedMetadataKey), "")
+	svc := r.svcs[name]
+	if svc == nil {
+		if name == "" {
+			return nil, status.Errorf(codes.NotFound, "no default feed; send %q metadata", feedMetadataKey)
+		}
+		return nil, status.Errorf(codes.NotFound, "no feed named %q", name)
+	}
+	return svc, nil
+}
+
+func (r *feedRouter) ListLinks(ctx context.Context, req *v1.ListLinksRequest) (*v1.ListLinksResponse, error) {
+	svc, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.ListLinks(ctx, req)
+}
+
+func (r *feedRouter) AddLink(ctx context.Context, req *v1.AddLinkRequest) (*v1.Link, error) {
+	svc, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.AddLink(ctx, req)
+}
+
+func (r *feedRouter) UpdateLink(ctx context.Context, req *v1.UpdateLinkRequest) (*v1.Link, error) {
+	svc, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.UpdateLink(ctx, req)
+}
+
+func (r *feedRouter) DeleteLink(ctx context.Context, req *v1.DeleteLinkRequest) (*v1.DeleteLinkResponse, error) {
+	svc, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.DeleteLink(ctx, req)
+}
+
+func (r *feedRouter) WatchFeed(req *v1.WatchFeedRequest, stream grpc.ServerStreamingServer[v1.FeedEvent]) error {
+	svc, err := r.service(stream.Context())
+	if err != nil {
+		return err
+	}
+	return svc.WatchFeed(req, stream)
+}
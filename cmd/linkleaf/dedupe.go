@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+func cmdDedupe() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("dedupe", flag.ExitOnError)
+	var file, keep string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&keep, "keep", "newest", "which duplicate to keep: newest or oldest (by date, then added_at)")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		if keep != "newest" && keep != "oldest" {
+			die(invalid(fmt.Errorf("-keep: want newest or oldest, got %q", keep)))
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		groups := feed.Dedupe(f, keep == "oldest")
+		if len(groups) == 0 {
+			msg.Infof("no duplicates (%d links)", len(f.Links))
+			return
+		}
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		dropped := 0
+		for _, g := range groups {
+			ids := make([]string, len(g.Dropped))
+			for i, l := range g.Dropped {
+				ids[i] = l.Id
+			}
+			dropped += len(ids)
+			msg.Infof("kept [%s] %s; collapsed %s", g.Kept.Id, g.Kept.Url, strings.Join(ids, ", "))
+		}
+		msg.Infof("removed %d duplicates, %d links left", dropped, len(f.Links))
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+type diffLinkJSON struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+type diffChangeJSON struct {
+	diffLinkJSON
+	Changes []feed.FieldChange `json:"changes"`
+}
+
+type diffJSON struct {
+	Added    []diffLinkJSON   `json:"added"`
+	Removed  []diffLinkJSON   `json:"removed"`
+	Modified []diffChangeJSON `json:"modified"`
+}
+
+func cmdDiff() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	var format string
+	var asJSON, ci, exitCode bool
+	fs.StringVar(&format, "format", "text", "output format: text, json or ci")
+	fs.BoolVar(&asJSON, "json", false, "shorthand for -format json")
+	fs.BoolVar(&ci, "ci", false, "shorthand for -format ci (GitHub Actions annotations and a JSON summary)")
+	fs.BoolVar(&exitCode, "exit-code", false, "exit 1 if the feeds differ (for CI)")
+	return fs, func() {
+		if asJSON {
+			format = "json"
+		}
+		if ci {
+			format = "ci"
+		}
+		if fs.NArg() != 2 {
+			badUsage(fs)
+		}
+		a, err := mustLoad(fs.Arg(0))
+		if err != nil {
+			die(err)
+		}
+		b, err := mustLoad(fs.Arg(1))
+		if err != nil {
+			die(err)
+		}
+		d := feed.Compare(a, b)
+
+		switch format {
+		case "text":
+			printDiff(d)
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(diffToJSON(d)); err != nil {
+				die(err)
+			}
+		case "ci":
+			annotateDiff(fs.Arg(1), d)
+			ciSummary(diffSummary{
+				Command: "diff", Old: fs.Arg(0), New: fs.Arg(1), Same: d.Empty(),
+				Added: len(d.Added), Removed: len(d.Removed), Modified: len(d.Modified),
+			})
+		default:
+			die(invalid(fmt.Errorf("-format: want text, json or ci, got %q", format)))
+		}
+		if exitCode && !d.Empty() {
+			os.Exit(1)
+		}
+	}
+}
+
+func printDiff(d feed.Diff) {
+	for _, l := range d.Added {
+		fmt.Printf("+ [%s] %s\n", l.Id, l.Title)
+	}
+	for _, l := range d.Removed {
+		fmt.Printf("- [%s] %s\n", l.Id, l.Title)
+	}
+	for _, c := range d.Modified {
+		fmt.Printf("~ [%s] %s\n", c.New.Id, c.New.Title)
+		for _, fc := range c.Fields {
+			fmt.Printf("    %s: %q -> %q\n", fc.Field, fc.Old, fc.New)
+		}
+	}
+	fmt.Printf("%d added, %d removed, %d modified\n", len(d.Added), len(d.Removed), len(d.Modified))
+}
+
+type diffSummary struct {
+	Command  string `json:"command"`
+	Old      string `json:"old"`
+	New      string `json:"new"`
+	Same     bool   `json:"same"`
+	Added    int    `json:"added"`
+	Removed  int    `json:"removed"`
+	Modified int    `json:"modified"`
+}
+
+// annotateDiff reports changes as notices on the new file; removals are
+// warnings, since they take links out of a published feed.
+func annotateDiff(file string, d feed.Diff) {
+	for _, l := range d.Added {
+		ciAnnotate("notice", file, fmt.Sprintf("added [%s]", l.Id), l.Title+" "+l.Url)
+	}
+	for _, l := range d.Removed {
+		ciAnnotate("warning", file, fmt.Sprintf("removed [%s]", l.Id), l.Title+" "+l.Url)
+	}
+	for _, c := range d.Modified {
+		var lines []string
+		for _, fc := range c.Fields {
+			lines = append(lines, fmt.Sprintf("%s: %q -> %q", fc.Field, fc.Old, fc.New))
+		}
+		ciAnnotate("notice", file, fmt.Sprintf("modified [%s]", c.New.Id), strings.Join(lines, "\n"))
+	}
+}
+
+func diffToJSON(d feed.Diff) diffJSON {
+	link := func(l *v1.Link) diffLinkJSON { return diffLinkJSON{ID: l.Id, Title: l.Title, URL: l.Url} }
+	out := diffJSON{
+		Added:    []diffLinkJSON{},
+		Removed:  []diffLinkJSON{},
+		Modified: []diffChangeJSON{},
+	}
+	for _, l := range d.Added {
+		out.Added = append(out.Added, link(l))
+	}
+	for _, l := range d.Removed {
+		out.Removed = append(out.Removed, link(l))
+	}
+	for _, c := range d.Modified {
+		out.Modified = append(out.Modified, diffChangeJSON{diffLinkJSON: link(c.New), Changes: c.Fields})
+	}
+	return out
+}
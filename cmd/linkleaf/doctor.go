@@ -0,0 +1,472 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/fts"
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// configErr is why the config file didn't load, kept for doctor instead
+// of stopping at startup.
+var configErr error
+
+// finding is one result of doctor. fix, if set, is the safe repair -fix
+// applies; hint says what to do otherwise.
+type finding struct {
+	level string // ok, warn or error
+	msg   string
+	hint  string
+	fix   func() error
+}
+
+// sidecarSuffixes are the files linkleaf keeps next to a feed file,
+// besides rotated backups (<file>.1 …).
+var sidecarSuffixes = []string{
+	feed.LockSuffix, feed.WALSuffix, feed.ChecksumSuffix, feed.SignatureSuffix, feed.JournalSuffix,
+	fts.Suffix, syncBaseSuffix, ".bak", ".sqlite-tmp", apKeySuffix, apFollowersSuffix,
+}
+
+// disposable are the sidecars that only make sense with their feed and
+// can be rebuilt; -fix removes them once the feed is gone. Journals,
+// backups and keys may be all that's left of it and are only reported.
+var disposable = []string{feed.LockSuffix, feed.WALSuffix, feed.ChecksumSuffix, fts.Suffix, ".sqlite-tmp"}
+
+var rotatedBackup = regexp.MustCompile(`^(.+)\.[0-9]+$`)
+
+// cmdDoctor checks a feed file, the files around it and the config, and
+// with -fix applies the repairs that can't lose anything.
+func cmdDoctor() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	var file string
+	var fix bool
+	fs.StringVar(&file, "file", "", "protobuf feed file (.pb), instead of the positional argument")
+	fs.BoolVar(&fix, "fix", false, "apply the safe repairs: drop identical duplicate links, upgrade the version, remove stale sidecar and temporary files, rebuild the search index")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, file)
+		if !ok {
+			badUsage(fs)
+		}
+
+		var all []finding
+		all = append(all, doctorConfig()...)
+		if fix {
+			sf.lock(path)
+		}
+		fileFindings, f := doctorFeed(path, sf)
+		all = append(all, fileFindings...)
+		if f != nil {
+			all = append(all, doctorSidecars(path, f, sf)...)
+		}
+
+		errs, fixed := 0, 0
+		for _, d := range all {
+			level := d.level
+			if fix && d.fix != nil {
+				switch err := d.fix(); {
+				case err != nil:
+					d.hint = fmt.Sprintf("fix failed: %v", err)
+				case sf.dryRun:
+					d.hint = "-fix repairs this (not with -dry-run)"
+				default:
+					level = "fixed"
+					fixed++
+				}
+			}
+			if level == "error" {
+				errs++
+			}
+			fmt.Printf("%-6s %s\n", level, d.msg)
+			switch {
+			case level == "fixed":
+			case d.fix != nil && d.hint == "":
+				fmt.Println("       linkleaf doctor -fix repairs this")
+			case d.hint != "":
+				fmt.Printf("       %s\n", d.hint)
+			}
+		}
+		if fixed > 0 {
+			msg.Infof("repairs applied: %d", fixed)
+		}
+		if errs > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+// doctorConfig checks that the config parsed and that the feeds it names
+// exist.
+func doctorConfig() []finding {
+	path, _ := configPath()
+	if configErr != nil {
+		return []finding{{level: "error", msg: fmt.Sprintf("config: %v", configErr), hint: "fix the line, or see linkleaf config -h for the keys"}}
+	}
+	var out []finding
+	missing := func(what, p string) {
+		if storage.IsRemote(p) {
+			return
+		}
+		if ep, err := feed.ExpandPath(p); err == nil && !fileOrDirExists(ep) {
+			out = append(out, finding{level: "warn", msg: fmt.Sprintf("config: %s %s doesn't exist", what, p), hint: "linkleaf init creates it, or point the config elsewhere"})
+		}
+	}
+	if cfg.Feed != "" {
+		if _, named := cfg.Feeds[cfg.Feed]; !named {
+			missing("feed", cfg.Feed)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Feeds)) {
+		missing("feed "+name+" =", cfg.Feeds[name])
+	}
+	if cfg.Email.SMTP != "" {
+		if _, _, err := net.SplitHostPort(cfg.Email.SMTP); err != nil {
+			out = append(out, finding{level: "warn", msg: fmt.Sprintf("config: email.smtp %q: want host:port", cfg.Email.SMTP)})
+		}
+	}
+	if _, _, err := trashCutoff(time.Now()); err != nil {
+		out = append(out, finding{level: "warn", msg: "config: " + err.Error()})
+	}
+	if _, err := feed.IDScheme(defaultIDScheme()); err != nil {
+		out = append(out, finding{level: "warn", msg: "config: id_scheme: " + err.Error()})
+	}
+	if _, err := loadPolicy(cfg.Policy); err != nil {
+		out = append(out, finding{level: "warn", msg: "config: " + err.Error()})
+	}
+	if len(out) == 0 {
+		where := "no config file"
+		if path != "" && fileOrDirExists(path) {
+			where = path
+		}
+		out = append(out, finding{level: "ok", msg: "config: " + where})
+	}
+	return out
+}
+
+// doctorFeed checks that the file can be read and decoded, its version,
+// checksum and journal, and its links. It returns the feed as loaded, nil
+// if it can't be.
+func doctorFeed(path string, sf *saveFlags) ([]finding, *v1.Feed) {
+	var out []finding
+	remote := storage.IsRemote(path)
+	if !remote {
+		p, err := feed.ExpandPath(path)
+		if err != nil {
+			return []finding{{level: "error", msg: err.Error()}}, nil
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return []finding{{level: "error", msg: fmt.Sprintf("read %s: %v", path, err)}}, nil
+		}
+		if !info.IsDir() {
+			fh, err := os.Open(p)
+			if err != nil {
+				return []finding{{level: "error", msg: fmt.Sprintf("read %s: %v", path, err)}}, nil
+			}
+			fh.Close()
+			out = append(out, finding{level: "ok", msg: fmt.Sprintf("read %s (%d bytes)", path, info.Size())})
+		}
+		if tmp, err := os.CreateTemp(filepath.Dir(p), ".doctor-*"); err != nil {
+			out = append(out, finding{level: "warn", msg: fmt.Sprintf("%s isn't writable: %v", filepath.Dir(p), err), hint: "saving will fail"})
+		} else {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}
+
+	opts := loadOpts
+	opts.NoMigrate = true
+	stored, err := feed.LoadWith(path, opts)
+	if err != nil {
+		d := finding{level: "error", msg: fmt.Sprintf("decode %s: %v", path, err)}
+		switch {
+		case errors.Is(err, feed.ErrEncrypted):
+			d.hint = "set LINKLEAF_KEY or -key-file"
+		case !remote:
+			d.hint = "linkleaf restore puts back the newest snapshot; see linkleaf backup -list"
+		}
+		return append(out, d), nil
+	}
+	out = append(out, finding{level: "ok", msg: fmt.Sprintf("decode: version %d, %d links", stored.Version, len(stored.Links))})
+
+	f := stored
+	if stored.Version < feed.CurrentVersion {
+		f = proto.Clone(stored).(*v1.Feed)
+		if err := feed.Migrate(f); err != nil {
+			return append(out, finding{level: "error", msg: fmt.Sprintf("migrate from version %d: %v", stored.Version, err)}), nil
+		}
+	}
+	// Duplicate IDs: identical copies can go, different ones need a person.
+	seen := map[string]*v1.Link{}
+	var identical, conflicting []string
+	keep := f.Links[:0:0]
+	for _, l := range f.Links {
+		first := seen[l.Id]
+		switch {
+		case first == nil:
+			seen[l.Id] = l
+			keep = append(keep, l)
+		case proto.Equal(first, l):
+			identical = append(identical, l.Id)
+		default:
+			conflicting = append(conflicting, l.Id)
+			keep = append(keep, l)
+		}
+	}
+	// Both repairs are one save, made once.
+	saved := false
+	save := func() error {
+		if saved {
+			return nil
+		}
+		saved = true
+		fixed := proto.Clone(f).(*v1.Feed)
+		fixed.Links = keep
+		fixed.GeneratedAt = feed.NowRFC3339()
+		sf.loaded(stored)
+		return sf.save(path, fixed)
+	}
+
+	// Saving would write a new checksum and hide the damage, so a feed
+	// that fails its own isn't repaired.
+	if !remote {
+		p, _ := feed.ExpandPath(path)
+		if _, err := os.Stat(p + feed.ChecksumSuffix); err == nil {
+			vopts := loadOpts
+			vopts.Verify, vopts.NoMigrate = true, true
+			if _, err := feed.LoadWith(path, vopts); err != nil {
+				out = append(out, finding{level: "error", msg: fmt.Sprintf("checksum: %v", err), hint: "the file changed after it was written; compare with linkleaf backup -list before trusting it"})
+				save = nil
+			}
+		}
+	}
+
+	switch {
+	case stored.Version > feed.CurrentVersion:
+		out = append(out, finding{level: "warn", msg: fmt.Sprintf("version %d is newer than this linkleaf's (%d)", stored.Version, feed.CurrentVersion), hint: "upgrade linkleaf before saving to this feed"})
+	case stored.Version < feed.CurrentVersion:
+		out = append(out, finding{level: "warn", msg: fmt.Sprintf("stored as version %d; this linkleaf writes %d", stored.Version, feed.CurrentVersion), fix: save})
+	}
+	if n := unknownFields(stored); n > 0 {
+		out = append(out, finding{level: "warn", msg: fmt.Sprintf("messages with fields this linkleaf doesn't know: %d", n), hint: "written by a newer linkleaf? saving keeps them unless -canonical"})
+	}
+	out = append(out, doctorJournal(path, f)...)
+	if len(identical) > 0 {
+		out = append(out, finding{level: "error", msg: fmt.Sprintf("exact copies of another link: %d (%s)", len(identical), strings.Join(compactIDs(identical), ", ")), fix: save})
+	}
+	if len(conflicting) > 0 {
+		out = append(out, finding{level: "error", msg: fmt.Sprintf("IDs shared by different links: %d (%s)", len(conflicting), strings.Join(compactIDs(conflicting), ", ")), hint: "give one a new ID with linkleaf edit, or remove it"})
+	}
+
+	if n := len(feed.Lint(f)); n > 0 {
+		out = append(out, finding{level: "warn", msg: fmt.Sprintf("problems in links: %d", n), hint: "linkleaf validate lists them"})
+	}
+	if n := outOfOrder(f); n > 0 {
+		out = append(out, finding{level: "warn", msg: fmt.Sprintf("links above one added after them: %d", n), hint: "fine if they were placed with linkleaf move; -fix leaves the order alone"})
+	}
+	return out, f
+}
+
+// doctorJournal checks that the last journaled change can still be
+// undone, i.e. the feed wasn't changed around the journal since.
+func doctorJournal(path string, f *v1.Feed) []finding {
+	entries, err := feed.ReadJournal(path)
+	if err != nil {
+		return []finding{{level: "warn", msg: fmt.Sprintf("journal: %v", err), hint: "linkleaf log and undo won't work"}}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	last := entries[len(entries)-1]
+	if err := feed.Undo(proto.Clone(f).(*v1.Feed), last); errors.Is(err, feed.ErrJournalMismatch) {
+		return []finding{{level: "warn", msg: fmt.Sprintf("journal: the feed changed without it after %q (%s)", last.Op, last.Time), hint: "linkleaf undo can't revert that change"}}
+	}
+	return nil
+}
+
+// doctorSidecars looks at the files next to the feed: a stale write-ahead
+// log, an outdated search index, temporary files left by interrupted
+// saves, and sidecars of feeds that are gone.
+func doctorSidecars(path string, f *v1.Feed, sf *saveFlags) []finding {
+	if storage.IsRemote(path) {
+		return nil
+	}
+	p, err := feed.ExpandPath(path)
+	if err != nil {
+		return nil
+	}
+	var out []finding
+	if stale, err := feed.StaleWAL(p); err != nil {
+		out = append(out, finding{level: "error", msg: fmt.Sprintf("write-ahead log: %v", err), hint: "linkleaf compact folds it into the file"})
+	} else if stale {
+		wal := p + feed.WALSuffix
+		out = append(out, finding{level: "warn", msg: wal + " is stale (the file was saved in full after it)", fix: removeFix(sf, wal)})
+	}
+	if !feed.IsEncryptedFile(p) {
+		idx := p + fts.Suffix
+		update := func(x *fts.Index) func() error {
+			return func() error {
+				if sf.dryRun {
+					fmt.Printf("would update %s\n", idx)
+					return nil
+				}
+				x.Update(f.Links)
+				return x.Save(idx)
+			}
+		}
+		x, err := fts.Load(idx)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			out = append(out, finding{level: "warn", msg: fmt.Sprintf("search index: %v", err), fix: update(fts.New())})
+		case x.Update(f.Links) > 0:
+			out = append(out, finding{level: "warn", msg: idx + " is behind the feed", hint: "search -fts catches it up", fix: update(x)})
+		}
+	}
+
+	dir := filepath.Dir(p)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return append(out, finding{level: "warn", msg: fmt.Sprintf("list %s: %v", dir, err)})
+	}
+	for _, e := range entries {
+		name, full := e.Name(), filepath.Join(dir, e.Name())
+		if e.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(name, ".tmp-") {
+			// Only old ones: a save may be writing this one right now.
+			if info, err := e.Info(); err == nil && time.Since(info.ModTime()) > time.Hour {
+				out = append(out, finding{level: "warn", msg: full + " was left by an interrupted save", fix: removeFix(sf, full)})
+			}
+			continue
+		}
+		base, suffix := sidecarOf(name)
+		if base == "" || fileOrDirExists(filepath.Join(dir, base)) {
+			continue
+		}
+		d := finding{level: "warn", msg: fmt.Sprintf("%s belongs to %s, which doesn't exist", full, base)}
+		if slices.Contains(disposable, suffix) {
+			d.fix = removeFix(sf, full)
+		} else {
+			d.hint = "moved or renamed feed? move this along with it, or delete it"
+		}
+		out = append(out, d)
+	}
+	snaps := filepath.Join(dir, filepath.FromSlash(feed.SnapshotDir))
+	if entries, err := os.ReadDir(snaps); err == nil {
+		orphaned := map[string]int{}
+		for _, e := range entries {
+			// <feed file>.<time>.<hash>
+			parts := strings.Split(e.Name(), ".")
+			if len(parts) < 3 {
+				continue
+			}
+			base := strings.Join(parts[:len(parts)-2], ".")
+			if !fileOrDirExists(filepath.Join(dir, base)) {
+				orphaned[base]++
+			}
+		}
+		for _, base := range slices.Sorted(maps.Keys(orphaned)) {
+			out = append(out, finding{level: "warn", msg: fmt.Sprintf("%s holds %d snapshots of %s, which doesn't exist", snaps, orphaned[base], base), hint: "linkleaf restore -file " + filepath.Join(dir, base) + " brings it back"})
+		}
+	}
+	return out
+}
+
+// sidecarOf splits a sidecar's file name into its feed's and its suffix;
+// base is "" if name isn't one. Only feed-looking bases count, so other
+// files' .bak or .1 aren't taken for linkleaf's.
+func sidecarOf(name string) (base, suffix string) {
+	for _, s := range sidecarSuffixes {
+		if b, ok := strings.CutSuffix(name, s); ok && feedLike(b) {
+			return b, s
+		}
+	}
+	if m := rotatedBackup.FindStringSubmatch(name); m != nil && feedLike(m[1]) {
+		return m[1], ".N"
+	}
+	return "", ""
+}
+
+func feedLike(name string) bool {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".zst")
+	return strings.HasSuffix(name, ".pb") || strings.HasSuffix(name, feed.StreamExt) || strings.HasSuffix(name, feed.SQLiteExt)
+}
+
+// removeFix deletes path as a repair; with -dry-run it only says so. A
+// file already gone (a save before it dropped a stale log) is fine.
+func removeFix(sf *saveFlags, path string) func() error {
+	return func() error {
+		if sf.dryRun {
+			fmt.Printf("would remove %s\n", path)
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+}
+
+// unknownFields counts the messages of f holding fields unknown to this
+// build.
+func unknownFields(f *v1.Feed) int {
+	n := 0
+	if len(f.ProtoReflect().GetUnknown()) > 0 {
+		n++
+	}
+	for _, l := range f.Links {
+		if len(l.ProtoReflect().GetUnknown()) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// outOfOrder counts the links placed above a link added after them; feeds
+// are kept newest first (unless stored sorted by ID).
+func outOfOrder(f *v1.Feed) int {
+	if len(f.Links) > 2 && slices.IsSortedFunc(f.Links, func(a, b *v1.Link) int { return strings.Compare(a.Id, b.Id) }) {
+		return 0
+	}
+	n := 0
+	newest := ""
+	for i := len(f.Links) - 1; i >= 0; i-- {
+		added := f.Links[i].AddedAt
+		if added == "" {
+			continue
+		}
+		if added < newest {
+			n++
+		}
+		newest = max(newest, added)
+	}
+	return n
+}
+
+// compactIDs lists at most five IDs.
+func compactIDs(ids []string) []string {
+	if len(ids) <= 5 {
+		return ids
+	}
+	return append(slices.Clone(ids[:5]), fmt.Sprintf("and %d more", len(ids)-5))
+}
+
+func fileOrDirExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func cmdEdit() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	var file, id, title, url, date, summary, via, author string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID of the link to edit (required)")
+	fs.StringVar(&title, "title", "", "new title")
+	fs.StringVar(&url, "url", "", "new URL")
+	fs.StringVar(&date, "date", "", "new date (YYYY-MM-DD, optionally with a time and zone)")
+	fs.StringVar(&summary, "summary", "", "new summary (\"\" clears it)")
+	fs.StringVar(&via, "via", "", "new attribution URL (\"\" clears it)")
+	fs.StringVar(&author, "author", "", "who added the link (\"\" clears it)")
+	var slug string
+	fs.StringVar(&slug, "slug", "", "new permalink slug, /l/SLUG (changes the link's short URL)")
+	var lang string
+	fs.StringVar(&lang, "lang", "", "language of the page, e.g. en or de-AT (\"\" clears it)")
+	meta := metaFlag{}
+	fs.Var(meta, "meta", "set custom data as key=value (repeatable; key= deletes the key)")
+	ef := addEnclosureFlags(fs)
+	var draft bool
+	var publishAt string
+	fs.BoolVar(&draft, "draft", false, "make the link a draft (-draft=false: publish it, like \"linkleaf publish\")")
+	fs.StringVar(&publishAt, "publish-at", "", "schedule the link for this time (RFC 3339 or YYYY-MM-DD; \"\" clears it)")
+	tf := addTagFlags(fs)
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || id == "" {
+			badUsage(fs)
+		}
+		set := map[string]bool{}
+		fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+		for _, name := range []string{"title", "url", "date", "slug"} {
+			if set[name] && fs.Lookup(name).Value.String() == "" {
+				die(invalid(fmt.Errorf("-%s may not be empty", name)))
+			}
+		}
+		tags, err := tf.tags()
+		if err != nil {
+			die(err)
+		}
+		enclosure, err := ef.enclosure()
+		if err != nil {
+			die(err)
+		}
+		if publishAt != "" {
+			t, err := feed.ParsePublishAt(publishAt)
+			if err != nil {
+				die(invalid(fmt.Errorf("-publish-at: want an RFC 3339 time or YYYY-MM-DD, got %q", publishAt)))
+			}
+			publishAt = t.Format(time.RFC3339)
+		}
+		if lang != "" {
+			if lang, err = feed.NormalizeLang(lang); err != nil {
+				die(invalid(fmt.Errorf("-lang: %w", err)))
+			}
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		l, err := feed.FindPrefix(f, id)
+		if err != nil {
+			die(err)
+		}
+		id = l.Id
+
+		// Only supplied flags are applied; the ID and position never change.
+		old := proto.Clone(l)
+		if set["date"] {
+			if date, err = feed.NormalizeDate(date, time.Local); err != nil {
+				die(invalid(fmt.Errorf("-date: %w", err)))
+			}
+		}
+		for name, field := range map[string]*string{
+			"title": &l.Title, "url": &l.Url, "summary": &l.Summary, "via": &l.Via, "author": &l.Author,
+		} {
+			if set[name] {
+				*field = fs.Lookup(name).Value.String()
+			}
+		}
+		if set["date"] {
+			l.Date = date
+		}
+		if set["slug"] {
+			if err := feed.SetSlug(f, l, slug); err != nil {
+				die(invalid(fmt.Errorf("-slug: %w", err)))
+			}
+		}
+		if set["lang"] {
+			l.Lang = lang
+		}
+		l.Meta = applyMeta(l.Meta, meta)
+		if set["draft"] {
+			l.Draft = draft
+		}
+		if set["publish-at"] {
+			l.PublishAt = publishAt
+		}
+		switch {
+		case set["enclosure"]:
+			l.Enclosure = enclosure // nil for -enclosure ""
+		case set["enclosure-type"] || set["enclosure-length"]:
+			if l.Enclosure == nil {
+				die(invalid(fmt.Errorf("[%s] has no enclosure; pass -enclosure", id)))
+			}
+			e := proto.Clone(l.Enclosure).(*v1.Enclosure)
+			if set["enclosure-type"] {
+				e.MimeType = ef.mimeType
+			}
+			if set["enclosure-length"] {
+				e.Length = ef.length
+			}
+			if err := feed.ValidateEnclosure(e); err != nil {
+				die(invalid(fmt.Errorf("-enclosure: %w", err)))
+			}
+			l.Enclosure = e
+		}
+		if set["tags"] || set["tag"] {
+			l.Tags = tags
+		} else if tf.normalize && l.Tags != nil {
+			l.Tags = feed.NormalizeTags(l.Tags)
+		}
+		if proto.Equal(old, l) {
+			msg.Infof("[%s] unchanged", id)
+			return
+		}
+		f.GeneratedAt = feed.NowRFC3339()
+
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		msg.Infof("edited [%s] %s", l.Id, l.Title)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"flag"
+	htmltemplate "html/template"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/email"
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// emailDigest is the data handed to the e-mail templates (built-in or
+// -template and -text-template).
+type emailDigest struct {
+	Feed    *v1.Feed // the selected links only
+	Subject string
+	Intro   string
+	// Since and Until are the -since and -until days, YYYY-MM-DD, if set.
+	Since, Until string
+}
+
+// emailFuncs are available to the e-mail templates: those of -format,
+// paragraphs, and wrap TEXT WIDTH INDENT for the plain text part.
+var emailFuncs = func() template.FuncMap {
+	m := maps.Clone(formatFuncs)
+	m["paragraphs"] = paragraphs
+	m["wrap"] = wrap
+	return m
+}()
+
+// exportEmail writes a digest of links as a MIME message with an HTML and
+// a plain text part, or sends it through the config's SMTP server.
+func exportEmail() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("export email", flag.ExitOnError)
+	var file, out, subject, from, to, intro, htmlTmpl, textTmpl string
+	var send bool
+	fs.StringVar(&file, "file", "", "protobuf feed file (.pb), instead of the positional argument")
+	fs.StringVar(&out, "out", "", "write the message (.eml) here instead of stdout")
+	fs.StringVar(&subject, "subject", cfg.Email.Subject, "subject line, a text/template with the digest (default: the feed title and date)")
+	fs.StringVar(&from, "from", cfg.Email.From, "sender (default: email.from, else author.name <author.email>)")
+	fs.StringVar(&to, "to", strings.Join(cfg.Email.To, ","), "comma-separated recipients (default: email.to)")
+	fs.StringVar(&intro, "intro", "", "text above the links (@file reads it from a file)")
+	fs.StringVar(&htmlTmpl, "template", "", "html/template file overriding the built-in HTML part")
+	fs.StringVar(&textTmpl, "text-template", "", "text/template file overriding the built-in plain text part")
+	fs.BoolVar(&send, "send", false, "send the message through email.smtp instead of writing it")
+	ff := addFilterFlags(fs)
+	so := addSortFlags(fs)
+	drafts := addDraftsFlag(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, file)
+		if !ok || send && out != "" {
+			badUsage(fs)
+		}
+		flt, err := ff.filter()
+		if err != nil {
+			die(err)
+		}
+		if err := so.check(); err != nil {
+			die(err)
+		}
+		if strings.HasPrefix(intro, "@") {
+			b, err := os.ReadFile(intro[1:])
+			if err != nil {
+				die(err)
+			}
+			intro = string(b)
+		}
+		if from == "" && cfg.Author.Email != "" {
+			from = cfg.Author.Email
+			if cfg.Author.Name != "" {
+				from = cfg.Author.Name + " <" + cfg.Author.Email + ">"
+			}
+		}
+		if from == "" {
+			die(invalid(errors.New("-from is required (or set email.from or author.email)")))
+		}
+		var rcpt []string
+		for _, a := range strings.Split(to, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				rcpt = append(rcpt, a)
+			}
+		}
+		if len(rcpt) == 0 {
+			die(invalid(errors.New("-to is required (or set email.to)")))
+		}
+
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
+		}
+		f = public(flt.Select(f), *drafts)
+		if err := so.sort(f.Links); err != nil {
+			die(err)
+		}
+		if len(f.Links) == 0 {
+			msg.Infof("no links selected; nothing to send")
+			return
+		}
+
+		d := emailDigest{Feed: f, Intro: strings.TrimSpace(intro)}
+		if !flt.After.IsZero() {
+			d.Since = flt.After.Format(feed.DateLayout)
+		}
+		if !flt.Before.IsZero() {
+			d.Until = flt.Before.Format(feed.DateLayout)
+		}
+		if subject == "" {
+			subject = `{{if .Feed.Title}}{{.Feed.Title}}{{else}}Links{{end}}: {{len .Feed.Links}} links{{if .Since}} since {{.Since}}{{end}}`
+		}
+		if d.Subject, err = renderEmailText("subject", subject, "", d); err != nil {
+			die(err)
+		}
+		d.Subject = strings.Join(strings.Fields(d.Subject), " ")
+		m := email.Message{From: from, To: rcpt, Subject: d.Subject, Date: time.Now()}
+		if m.Text, err = renderEmailText("email.txt.tmpl", "", textTmpl, d); err != nil {
+			die(err)
+		}
+		if m.HTML, err = renderEmailHTML(htmlTmpl, d); err != nil {
+			die(err)
+		}
+
+		if send {
+			if cfg.Email.SMTP == "" {
+				die(invalid(errors.New("-send needs email.smtp in the config (host:port)")))
+			}
+			s := email.SMTP{
+				Addr:     cfg.Email.SMTP,
+				Username: cfg.Email.Username,
+				Password: cmp.Or(os.Getenv("LINKLEAF_SMTP_PASSWORD"), cfg.Email.Password),
+			}
+			if err := email.Send(m, s); err != nil {
+				die(err)
+			}
+			msg.Infof("sent %d links to %s", len(f.Links), strings.Join(rcpt, ", "))
+			return
+		}
+		b, err := m.Bytes()
+		if err != nil {
+			die(err)
+		}
+		if out == "" || out == "-" {
+			os.Stdout.Write(b)
+			return
+		}
+		if err := feed.WriteFileAtomic(out, b, 0o644); err != nil {
+			die(err)
+		}
+		msg.Infof("exported %d links to %s (email)", len(f.Links), out)
+	}
+}
+
+// renderEmailText runs a text/template: text if given, else the file at
+// path, else the built-in templates/<name>.
+func renderEmailText(name, text, path string, d emailDigest) (string, error) {
+	t := template.New(name).Funcs(emailFuncs)
+	var err error
+	switch {
+	case text != "":
+		t, err = t.Parse(text)
+	case path != "":
+		t, err = template.New(filepath.Base(path)).Funcs(emailFuncs).ParseFiles(path)
+	default:
+		t, err = t.ParseFS(templateFS, "templates/"+name)
+	}
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderEmailHTML runs the HTML part's template, the file at path or the
+// built-in one; html/template escapes the feed's content.
+func renderEmailHTML(path string, d emailDigest) (string, error) {
+	var t *htmltemplate.Template
+	var err error
+	if path != "" {
+		t, err = htmltemplate.New(filepath.Base(path)).Funcs(htmltemplate.FuncMap(emailFuncs)).ParseFiles(path)
+	} else {
+		t, err = htmltemplate.New("email.html.tmpl").Funcs(htmltemplate.FuncMap(emailFuncs)).ParseFS(templateFS, "templates/email.html.tmpl")
+	}
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// enclosureTypes are the media types podcast feeds carry, which the
+// system's MIME table may not know.
+var enclosureTypes = map[string]string{
+	".mp3": "audio/mpeg", ".m4a": "audio/mp4", ".aac": "audio/aac", ".ogg": "audio/ogg", ".oga": "audio/ogg",
+	".opus": "audio/opus", ".wav": "audio/wav", ".flac": "audio/flac",
+	".mp4": "video/mp4", ".m4v": "video/mp4", ".webm": "video/webm", ".mov": "video/quicktime",
+	".pdf": "application/pdf", ".epub": "application/epub+zip",
+}
+
+// enclosureFlags are the -enclosure flags of add and edit.
+type enclosureFlags struct {
+	url, mimeType string
+	length        int64
+}
+
+func addEnclosureFlags(fs *flag.FlagSet) *enclosureFlags {
+	ef := &enclosureFlags{}
+	fs.StringVar(&ef.url, "enclosure", "", "URL of a media file the link comes with (podcast episode, video)")
+	fs.StringVar(&ef.mimeType, "enclosure-type", "", "the enclosure's MIME type (default: from its extension)")
+	fs.Int64Var(&ef.length, "enclosure-length", 0, "the enclosure's size in bytes (0: unknown)")
+	return ef
+}
+
+// enclosure is the enclosure the flags describe, or nil without
+// -enclosure.
+func (ef *enclosureFlags) enclosure() (*v1.Enclosure, error) {
+	if ef.url == "" {
+		return nil, nil
+	}
+	e := &v1.Enclosure{Url: ef.url, MimeType: ef.mimeType, Length: ef.length}
+	if e.MimeType == "" {
+		e.MimeType = guessMediaType(ef.url)
+		if e.MimeType == "" {
+			return nil, fmt.Errorf("-enclosure: can't tell the type of %s; pass -enclosure-type", ef.url)
+		}
+	}
+	if err := feed.ValidateEnclosure(e); err != nil {
+		return nil, fmt.Errorf("-enclosure: %w", err)
+	}
+	return e, nil
+}
+
+// guessMediaType is the MIME type of the file rawURL names, by extension,
+// or "".
+func guessMediaType(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	if t, ok := enclosureTypes[ext]; ok {
+		return t
+	}
+	t, _, _ := strings.Cut(mime.TypeByExtension(ext), ";")
+	return t
+}
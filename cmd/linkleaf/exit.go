@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+// Exit codes of a failed command, for scripts. 1 covers checks that found
+// problems (check, validate, diff -exit-code, …) and errors of no
+// particular kind.
+const (
+	exitError    = 1
+	exitUsage    = 2 // an unknown command or flag, or missing arguments, as the flag package has it
+	exitNotFound = 3 // no link, trashed link or file by that name
+	exitInvalid  = 4 // a flag, argument, config value or edited link doesn't validate
+	exitConflict = 5 // the change clashes with the feed: a URL or ID it has, a link changed meanwhile
+	exitIO       = 6 // reading or writing a file, or the network, failed
+)
+
+// The kinds of error, matched with errors.Is. Wrap an error with
+// notFound, invalid or conflict to give it one; file and network errors
+// are told apart by their type.
+var (
+	errNotFound = errors.New("not found")
+	errInvalid  = errors.New("invalid")
+	errConflict = errors.New("conflict")
+	errUsage    = errors.New("usage")
+)
+
+// kindError gives err a kind without changing its message.
+type kindError struct {
+	err, kind error
+}
+
+func (e kindError) Error() string   { return e.err.Error() }
+func (e kindError) Unwrap() []error { return []error{e.err, e.kind} }
+
+func notFound(err error) error { return kindError{err, errNotFound} }
+func invalid(err error) error  { return kindError{err, errInvalid} }
+func conflict(err error) error { return kindError{err, errConflict} }
+
+// jsonErrors and porcelain are the global -json and -porcelain: how die
+// reports errors.
+var jsonErrors, porcelain bool
+
+// exitCode returns the exit code for err and the name of its kind.
+func exitCode(err error) (int, string) {
+	var pathErr *fs.PathError
+	var netErr net.Error
+	var urlErr *url.Error
+	switch {
+	case errors.Is(err, errUsage):
+		return exitUsage, "usage"
+	case errors.Is(err, errNotFound), errors.Is(err, feed.ErrNoLink), errors.Is(err, fs.ErrNotExist):
+		return exitNotFound, "not_found"
+	case errors.Is(err, errInvalid), errors.Is(err, feed.ErrUndefinedVar):
+		return exitInvalid, "invalid"
+	case errors.Is(err, errConflict), errors.Is(err, feed.ErrJournalMismatch):
+		return exitConflict, "conflict"
+	case errors.As(err, &pathErr), errors.As(err, &netErr), errors.As(err, &urlErr):
+		return exitIO, "io"
+	}
+	return exitError, "error"
+}
+
+// die reports err on stderr, as text, JSON (-json) or one tab-separated
+// line (-porcelain), and exits with its exit code.
+func die(err error) {
+	code, kind := exitCode(err)
+	switch {
+	case jsonErrors:
+		json.NewEncoder(os.Stderr).Encode(struct {
+			Error    string `json:"error"`
+			Kind     string `json:"kind"`
+			ExitCode int    `json:"exit_code"`
+		}{err.Error(), kind, code})
+	case porcelain:
+		fmt.Fprintf(os.Stderr, "error\t%s\t%s\n", kind, strings.ReplaceAll(err.Error(), "\n", "; "))
+	default:
+		fmt.Fprintln(os.Stderr, "error:", err)
+	}
+	os.Exit(code)
+}
+
+// usageError exits 2 for a command line that can't run: it calls usage to
+// print the help, or with -json or -porcelain reports err as die does.
+func usageError(usage func(), err error) {
+	if jsonErrors || porcelain {
+		die(kindError{err, errUsage})
+	}
+	usage()
+	os.Exit(exitUsage)
+}
+
+// badUsage is usageError for the command of fs.
+func badUsage(fs *flag.FlagSet) {
+	usageError(fs.Usage, fmt.Errorf("bad arguments (see linkleaf %s -h)", fs.Name()))
+}
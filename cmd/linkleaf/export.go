@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/assets"
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// htmlPage is the data handed to the HTML template (built-in or -template).
+type htmlPage struct {
+	Feed       *v1.Feed
+	Stylesheet string
+	Alternates []alternate // feed autodiscovery links (serve, build)
+	Site       *siteNav    // tag/archive navigation (build only)
+	// Related maps link IDs to the links they refer to (Link.related_ids);
+	// renderPage fills it from Feed unless set.
+	Related map[string][]*v1.Link
+	// Images maps link IDs to their icon and preview image hrefs (build
+	// and serve -images).
+	Images map[string]assets.Images
+	// Permalinks prefixes a link's slug to make the href of its permalink
+	// page (serve, build); empty: no permalinks.
+	Permalinks string
+}
+
+type alternate struct {
+	Type, Title, Href string
+}
+
+// exportFormats may also be given as the first argument ("export rss ...").
+var exportFormats = []string{"html", "csv", "jsonl", "rss", "atom", "jsonfeed", "markdown", "textproto", "json", "shaarli"}
+
+// dataFormats copy the feed's data rather than publish it, so they keep
+// drafts and scheduled links (and their draft and publish_at fields).
+var dataFormats = []string{"csv", "jsonl", "textproto", "json", "shaarli"}
+
+func cmdExport(format string) (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	var file, out, css, tmpl string
+	fs.StringVar(&format, "format", format, "output format: "+strings.Join(exportFormats, ", "))
+	fs.StringVar(&file, "file", "", "protobuf feed file (.pb), instead of the positional argument")
+	fs.StringVar(&out, "out", "", "output file (default: stdout)")
+	fs.StringVar(&css, "css", cfg.Export.CSS, "stylesheet path/URL linked from the HTML page")
+	fs.StringVar(&tmpl, "template", "", "html/template file overriding the built-in page")
+	var header bool
+	fs.BoolVar(&header, "header", false, "jsonl: emit feed metadata as the first line")
+	var groupBy string
+	fs.StringVar(&groupBy, "group-by", "none", "markdown: heading per "+strings.Join(markdownGroups, ", "))
+	si := siteInfo{Author: configAuthor()}
+	fs.StringVar(&si.Link, "link", "", "rss/atom/jsonfeed: site home page URL (default: the feed's home_page_url, else export.link; required for rss)")
+	fs.StringVar(&si.Title, "site-title", "", "rss/atom/jsonfeed: channel title (default: the feed's title, else export.site_title)")
+	fs.StringVar(&si.Description, "description", "", "rss/atom/jsonfeed: channel description (default: the feed's description, else export.description, else the title)")
+	fs.StringVar(&si.FeedURL, "feed-url", cfg.Export.FeedURL, "rss/atom/jsonfeed: URL the document is published at")
+	var pageSize int
+	fs.IntVar(&pageSize, "page-size", 0, "rss/atom/jsonfeed: split into pages of N links, -out and its -page2, -page3, … files (RFC 5005; 0: one document)")
+	ff := addFilterFlags(fs)
+	so := addSortFlags(fs)
+	drafts := addDraftsFlag(fs)
+	return fs, func() {
+
+		path, ok := feedArg(fs, file)
+		if !ok {
+			badUsage(fs)
+		}
+		flt, err := ff.filter()
+		if err != nil {
+			die(err)
+		}
+		if err := so.check(); err != nil {
+			die(err)
+		}
+		paged := slices.Contains(syndicationFormats, format)
+		switch {
+		case pageSize < 0:
+			die(invalid(fmt.Errorf("-page-size: want a number of links, got %d", pageSize)))
+		case pageSize > 0 && !paged:
+			die(invalid(fmt.Errorf("-page-size: %s isn't paged (only %s)", format, strings.Join(syndicationFormats, ", "))))
+		case pageSize > 0 && (out == "" || out == "-"):
+			die(invalid(errors.New("-page-size writes a file per page; pass -out")))
+		}
+
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
+		}
+		f = flt.Select(f)
+		if !slices.Contains(dataFormats, format) {
+			f = public(f, *drafts)
+		}
+		if err := so.sort(f.Links); err != nil {
+			die(err)
+		}
+		si = si.withFeed(f)
+
+		if paged {
+			exportPages(f, si, format, out, pageSize)
+			return
+		}
+		var b []byte
+		switch format {
+		case "html":
+			b, err = renderHTML(f, css, tmpl)
+		case "csv":
+			b, err = renderCSV(f)
+		case "jsonl":
+			b, err = renderJSONL(f, header)
+		case "markdown":
+			b, err = renderMarkdown(f, groupBy)
+		case "textproto":
+			b, err = renderTextproto(f)
+		case "json":
+			b, err = renderJSON(f)
+		case "shaarli":
+			b, err = renderShaarli(f)
+		default:
+			err = fmt.Errorf("unknown export format %q", format)
+		}
+		if err != nil {
+			die(err)
+		}
+
+		if out == "" || out == "-" {
+			os.Stdout.Write(b)
+			return
+		}
+		if err := feed.WriteFileAtomic(out, b, 0o644); err != nil {
+			die(err)
+		}
+		msg.Infof("exported %d links to %s (%s)", len(f.Links), out, format)
+	}
+}
+
+// syndicationFormats are the export formats that can be paged.
+var syndicationFormats = []string{"rss", "atom", "jsonfeed"}
+
+// exportPages writes f as an RSS, Atom or JSON feed to out, split into
+// pages of pageSize links if it has more: out, then out-page2 and so on,
+// dropping pages left over from a longer export.
+func exportPages(f *v1.Feed, si siteInfo, format, out string, pageSize int) {
+	var render func(*v1.Feed, siteInfo) ([]byte, error)
+	switch format {
+	case "rss":
+		if si.Link == "" {
+			die(invalid(errors.New("rss: -link is required (the channel's home page)")))
+		}
+		render = renderRSS
+	case "atom":
+		if si.Link == "" && si.FeedURL == "" {
+			die(invalid(errors.New("atom: -link or -feed-url is required (the feed's ID)")))
+		}
+		render = renderAtom
+	case "jsonfeed":
+		render = renderJSONFeed
+	}
+	pages, err := renderPages(f, si, pageSize, render)
+	if err != nil {
+		die(err)
+	}
+	if out == "" || out == "-" {
+		os.Stdout.Write(pages[0])
+		return
+	}
+	for i, b := range pages {
+		if err := feed.WriteFileAtomic(pageName(out, i+1), b, 0o644); err != nil {
+			die(err)
+		}
+	}
+	if pageSize > 0 {
+		for n := len(pages) + 1; ; n++ {
+			if err := os.Remove(pageName(out, n)); err != nil {
+				break
+			}
+			msg.Debugf("removed %s, a page no longer needed", pageName(out, n))
+		}
+	}
+	if len(pages) == 1 {
+		msg.Infof("exported %d links to %s (%s)", len(f.Links), out, format)
+		return
+	}
+	msg.Infof("exported %d links to %s … %s (%s, %d pages of %d)", len(f.Links), out, pageName(out, len(pages)), format, len(pages), pageSize)
+}
+
+// renderHTML renders feed as a standalone page. html/template escapes all
+// feed content, so titles/summaries can't inject markup or script URLs.
+func renderHTML(f *v1.Feed, css, tmplPath string) ([]byte, error) {
+	return renderPage(htmlPage{Feed: f, Stylesheet: css}, tmplPath)
+}
+
+// relatedLinks resolves the related_ids of f's links, skipping IDs f
+// doesn't have.
+func relatedLinks(f *v1.Feed) map[string][]*v1.Link {
+	byID := make(map[string]*v1.Link, len(f.Links))
+	for _, l := range f.Links {
+		byID[l.Id] = l
+	}
+	related := map[string][]*v1.Link{}
+	for _, l := range f.Links {
+		for _, id := range l.RelatedIds {
+			if r := byID[id]; r != nil {
+				related[l.Id] = append(related[l.Id], r)
+			}
+		}
+	}
+	return related
+}
+
+// templateFuncs are available to the built-in and custom page templates.
+var templateFuncs = template.FuncMap{
+	"paragraphs": paragraphs,
+}
+
+func renderPage(page htmlPage, tmplPath string) ([]byte, error) {
+	if page.Related == nil {
+		page.Related = relatedLinks(page.Feed)
+	}
+	var t *template.Template
+	var err error
+	if tmplPath != "" {
+		t, err = template.New(filepath.Base(tmplPath)).Funcs(templateFuncs).ParseFiles(tmplPath)
+	} else {
+		t, err = template.New("index.html.tmpl").Funcs(templateFuncs).ParseFS(templateFS, "templates/index.html.tmpl")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, page); err != nil {
+		return nil, fmt.Errorf("render html: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// xmlItem covers both an RSS <item> and an Atom <entry>; each format
+// fills the fields it has.
+type xmlItem struct {
+	Title       string `xml:"title"`
+	Description string `xml:"description"` // RSS
+	Summary     string `xml:"summary"`     // Atom
+	Content     string `xml:"content"`     // Atom
+	PubDate     string `xml:"pubDate"`     // RSS
+	DCDate      string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Published   string `xml:"published"` // Atom
+	Updated     string `xml:"updated"`   // Atom
+	Links       []struct {
+		Href  string `xml:"href,attr"` // Atom
+		Rel   string `xml:"rel,attr"`
+		Value string `xml:",chardata"` // RSS
+	} `xml:"link"`
+	Categories []struct {
+		Term  string `xml:"term,attr"` // Atom
+		Value string `xml:",chardata"` // RSS
+	} `xml:"category"`
+}
+
+// feedDateLayouts are the timestamp formats seen in the wild in RSS/Atom.
+var feedDateLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"2006-01-02T15:04:05",
+	feed.DateLayout,
+}
+
+var markup = regexp.MustCompile(`<[^>]*>`)
+
+// readFeedXML parses an RSS 2.0 or Atom 1.0 document into links: title,
+// link, description/summary (markup stripped), the publication date and
+// categories as tags. Items without a link are reported as warnings.
+func readFeedXML(r io.Reader) ([]*v1.Link, []lineWarning, error) {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.CharsetReader = func(_ string, in io.Reader) (io.Reader, error) { return in, nil }
+	var links []*v1.Link
+	var warnings []lineWarning
+	root := ""
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse feed: %w", err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root == "" {
+			root = se.Name.Local
+			if root != "rss" && root != "feed" && root != "RDF" {
+				return nil, nil, fmt.Errorf("not an RSS or Atom feed (root element <%s>)", root)
+			}
+		}
+		if se.Name.Local != "item" && se.Name.Local != "entry" {
+			continue
+		}
+		line, _ := d.InputPos()
+		var it xmlItem
+		if err := d.DecodeElement(&it, &se); err != nil {
+			return nil, nil, fmt.Errorf("parse feed: line %d: %w", line, err)
+		}
+		l, err := it.link()
+		if err != nil {
+			warnings = append(warnings, lineWarning{line, err})
+			continue
+		}
+		links = append(links, l)
+	}
+	if root == "" {
+		return nil, nil, errors.New("parse feed: empty document")
+	}
+	return links, warnings, nil
+}
+
+func (it xmlItem) link() (*v1.Link, error) {
+	url := ""
+	for _, l := range it.Links {
+		href := strings.TrimSpace(l.Href + l.Value)
+		if href != "" && (l.Rel == "" || l.Rel == "alternate") {
+			url = href
+			break
+		}
+	}
+	if url == "" {
+		return nil, errors.New("item has no link")
+	}
+	l := &v1.Link{
+		Title: plainText(it.Title),
+		Url:   url,
+	}
+	if l.Title == "" {
+		l.Title = url
+	}
+	for _, s := range []string{it.Description, it.Summary, it.Content} {
+		if l.Summary = plainText(s); l.Summary != "" {
+			break
+		}
+	}
+	published := time.Now().UTC()
+	for _, s := range []string{it.PubDate, it.Published, it.DCDate, it.Updated} {
+		if t, ok := parseFeedDate(s); ok {
+			published = t.UTC()
+			break
+		}
+	}
+	l.Date = published.Format(feed.DateLayout)
+	var tags []string
+	for _, c := range it.Categories {
+		if t := strings.Join(strings.Fields(c.Term+c.Value), "-"); t != "" && !strings.HasPrefix(t, "#") {
+			tags = append(tags, t)
+		}
+	}
+	l.Tags, _ = validTags(tags)
+	l.Id = feed.LinkID(l.Url, l.Date)
+	return l, nil
+}
+
+func parseFeedDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// plainText strips markup and entities (feeds often carry escaped HTML)
+// and collapses whitespace.
+func plainText(s string) string {
+	s = html.UnescapeString(markup.ReplaceAllString(html.UnescapeString(s), " "))
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// feedMetaField is a Feed field "meta" reads and writes; check, if set,
+// validates (and may normalize) a new value.
+type feedMetaField struct {
+	key, help string
+	str       func(f *v1.Feed) *string
+	check     func(v string) (string, error)
+}
+
+var feedMetaFields = []feedMetaField{
+	{key: "title", help: "the feed's name", str: func(f *v1.Feed) *string { return &f.Title }},
+	{key: "description", help: "what the feed is about (channel description in exports)", str: func(f *v1.Feed) *string { return &f.Description }},
+	{key: "author", help: "who the feed is by (instead of the config's author.name)", str: func(f *v1.Feed) *string { return &f.Author }},
+	{key: "home_page_url", help: "the site the feed belongs to (rss/atom/jsonfeed link)", str: func(f *v1.Feed) *string { return &f.HomePageUrl }, check: httpURL},
+	{key: "icon", help: "image URL standing for the feed (rss image, atom icon, favicon)", str: func(f *v1.Feed) *string { return &f.Icon }, check: httpURL},
+	{key: "lang", help: "language of the feed as a whole, e.g. en or de-AT", str: func(f *v1.Feed) *string { return &f.Lang }, check: feed.NormalizeLang},
+}
+
+func lookupFeedMetaField(key string) (feedMetaField, bool) {
+	for _, f := range feedMetaFields {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return feedMetaField{}, false
+}
+
+func httpURL(v string) (string, error) {
+	if u, err := url.Parse(v); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%q: want an http or https URL", v)
+	}
+	return v, nil
+}
+
+// cmdMeta shows and edits the feed-level metadata exports use.
+func cmdMeta() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("meta", flag.ExitOnError)
+	var file string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	sf := addSaveFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: linkleaf meta [-file <file.pb>] [show | get KEY | set KEY VALUE | unset KEY] [save flags]\n\nkeys:")
+		for _, f := range feedMetaFields {
+			fmt.Fprintf(os.Stderr, "  %-14s %s\n", f.key, f.help)
+		}
+		fmt.Fprint(os.Stderr, `
+notes:
+  • "meta set" stores the feed's own title, description, author, home_page_url, icon and lang. Exports
+    (rss, atom, jsonfeed, html, and those of build and serve) use them where no flag says otherwise, ahead of
+    the config's export settings; lang, when unset, is the language the links share.
+`)
+		fmt.Fprintln(os.Stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+	return fs, func() {
+		sub := "show"
+		if fs.NArg() > 0 {
+			sub = fs.Arg(0)
+		}
+		rest := fs.Args()[min(1, fs.NArg()):]
+		if file == "" {
+			badUsage(fs)
+		}
+
+		switch {
+		case sub == "show" && len(rest) == 0:
+			f, err := mustLoad(file)
+			if err != nil {
+				die(err)
+			}
+			for _, m := range feedMetaFields {
+				fmt.Printf("%-14s %s\n", m.key, *m.str(f))
+			}
+		case sub == "get" && len(rest) == 1:
+			m, ok := lookupFeedMetaField(rest[0])
+			if !ok {
+				die(invalid(fmt.Errorf("unknown key %q (see linkleaf meta -h)", rest[0])))
+			}
+			f, err := mustLoad(file)
+			if err != nil {
+				die(err)
+			}
+			fmt.Println(*m.str(f))
+		case sub == "set" && len(rest) == 2, sub == "unset" && len(rest) == 1:
+			m, ok := lookupFeedMetaField(rest[0])
+			if !ok {
+				die(invalid(fmt.Errorf("unknown key %q (see linkleaf meta -h)", rest[0])))
+			}
+			value := ""
+			if sub == "set" {
+				value = rest[1]
+				if m.check != nil {
+					v, err := m.check(value)
+					if err != nil {
+						die(invalid(fmt.Errorf("%s: %w", m.key, err)))
+					}
+					value = v
+				}
+			}
+			sf.lock(file)
+			f, err := mustLoad(file)
+			if err != nil {
+				die(err)
+			}
+			sf.loaded(f)
+			*m.str(f) = value
+			f.GeneratedAt = feed.NowRFC3339()
+			if err := sf.save(file, f); err != nil {
+				die(err)
+			}
+			msg.Infof("%s %s of %s", sub, m.key, file)
+		default:
+			badUsage(fs)
+		}
+	}
+}
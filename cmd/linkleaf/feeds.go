@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// feedName is the global -feed flag: a name from the config's [feeds].
+var feedName string
+
+// validFeedName accepts names that are TOML bare keys, so the config
+// file needs no quoting.
+func validFeedName(name string) error { return validKeyName("feed", name) }
+
+// validKeyName checks the name of a kind of thing kept as a key of a
+// config table.
+func validKeyName(kind, name string) error {
+	if name == "" || strings.TrimFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+	}) != "" {
+		return fmt.Errorf("bad %s name %q (use letters, digits, - and _)", kind, name)
+	}
+	return nil
+}
+
+// checkFeedName fails if -feed names a feed the config doesn't have.
+func checkFeedName() error {
+	if feedName == "" {
+		return nil
+	}
+	if _, ok := cfg.Feeds[feedName]; !ok {
+		return notFound(fmt.Errorf("no feed named %q (see linkleaf feeds list)", feedName))
+	}
+	return nil
+}
+
+func cmdFeeds() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("feeds", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: linkleaf feeds [list | add NAME FILE | remove NAME]")
+	}
+	return fs, func() {
+		sub := "list"
+		if fs.NArg() > 0 {
+			sub = fs.Arg(0)
+		}
+		rest := fs.Args()[min(1, fs.NArg()):]
+
+		c := cfg
+		c.Feeds = maps.Clone(cfg.Feeds)
+		var verb string
+		switch {
+		case sub == "list" && len(rest) == 0:
+			def := defaultFeed()
+			for _, name := range slices.Sorted(maps.Keys(c.Feeds)) {
+				mark := " "
+				if c.Feeds[name] == def {
+					mark = "*"
+				}
+				fmt.Printf("%s %-12s %s\n", mark, name, c.Feeds[name])
+			}
+			return
+		case sub == "add" && len(rest) == 2:
+			if err := validFeedName(rest[0]); err != nil {
+				die(err)
+			}
+			if c.Feeds == nil {
+				c.Feeds = make(map[string]string)
+			}
+			c.Feeds[rest[0]] = rest[1]
+			verb = "added"
+		case sub == "remove" && len(rest) == 1:
+			if _, ok := c.Feeds[rest[0]]; !ok {
+				die(notFound(fmt.Errorf("no feed named %q", rest[0])))
+			}
+			delete(c.Feeds, rest[0])
+			if c.Feed == rest[0] {
+				c.Feed = ""
+			}
+			verb = "removed"
+		default:
+			badUsage(fs)
+		}
+
+		path, err := configPath()
+		if err != nil {
+			die(err)
+		}
+		if err := writeConfig(path, c); err != nil {
+			die(err)
+		}
+		msg.Infof("%s feed %q in %s", verb, rest[0], path)
+	}
+}
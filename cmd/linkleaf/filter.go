@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// filterFlags are the link-selection flags shared by list, export and
+// the commands working on a selection of links (open, refresh).
+type filterFlags struct {
+	after, before string
+	via           string
+	noVia         bool
+	tags          stringsFlag
+	tagExpr       string
+	domain        string
+	author        string
+	lang          string
+	maxMinutes    int
+	unread        bool
+	starred       bool
+}
+
+func addFilterFlags(fs *flag.FlagSet) *filterFlags {
+	ff := &filterFlags{}
+	fs.StringVar(&ff.after, "after", "", "only links dated on/after YYYY-MM-DD, or an age such as 7d, 2w, 3m")
+	fs.StringVar(&ff.before, "before", "", "only links dated on/before YYYY-MM-DD, or an age such as 7d, 2w, 3m")
+	fs.StringVar(&ff.after, "since", "", "alias for -after")
+	fs.StringVar(&ff.before, "until", "", "alias for -before")
+	fs.StringVar(&ff.via, "via", "", "only links whose via URL is on this host (e.g. example.com)")
+	fs.BoolVar(&ff.noVia, "no-via", false, "only links without a via attribution")
+	fs.Var(&ff.tags, "tag", "only links with this tag (repeatable: all must match; lang/* matches a namespace)")
+	fs.StringVar(&ff.tagExpr, "tags", "", "only links whose tags satisfy this expression, e.g. \"lang/go AND NOT topic/orm\"")
+	fs.StringVar(&ff.domain, "domain", "", "only links whose URL is on this domain or a subdomain")
+	fs.StringVar(&ff.author, "author", "", "only links added by this author (ignoring case)")
+	fs.StringVar(&ff.lang, "lang", "", "only links in this language (de also matches de-AT)")
+	fs.IntVar(&ff.maxMinutes, "max-minutes", 0, "only links estimated to take at most this many minutes to read")
+	fs.BoolVar(&ff.unread, "unread", false, "only links not marked read")
+	fs.BoolVar(&ff.starred, "starred", false, "only starred links")
+	return ff
+}
+
+// any reports whether any filter flag was given.
+func (ff *filterFlags) any() bool {
+	return ff.after != "" || ff.before != "" || ff.via != "" || ff.noVia || len(ff.tags) > 0 ||
+		ff.tagExpr != "" || ff.domain != "" || ff.author != "" || ff.lang != "" || ff.maxMinutes > 0 || ff.unread || ff.starred
+}
+
+func (ff *filterFlags) filter() (feed.Filter, error) {
+	flt := feed.Filter{NoVia: ff.noVia, Tags: ff.tags, Author: ff.author, MaxMinutes: ff.maxMinutes, Unread: ff.unread, Starred: ff.starred}
+	var err error
+	if ff.via != "" {
+		if ff.noVia {
+			return flt, invalid(fmt.Errorf("-via and -no-via are mutually exclusive"))
+		}
+		// Accept a full URL as well as a bare host.
+		if flt.ViaHost = feed.Host(ff.via); flt.ViaHost == "" {
+			flt.ViaHost = ff.via
+		}
+	}
+	if ff.domain != "" {
+		if flt.Domain = feed.Host(ff.domain); flt.Domain == "" {
+			flt.Domain = ff.domain
+		}
+	}
+	if ff.lang != "" {
+		if flt.Lang, err = feed.NormalizeLang(ff.lang); err != nil {
+			return flt, invalid(fmt.Errorf("-lang: %w", err))
+		}
+	}
+	if ff.tagExpr != "" {
+		if flt.TagExpr, err = feed.ParseTagExpr(ff.tagExpr); err != nil {
+			return flt, invalid(fmt.Errorf("-tags: %w", err))
+		}
+	}
+	if ff.after != "" {
+		if flt.After, err = feed.ParseRelativeDate(ff.after, time.Now()); err != nil {
+			return flt, invalid(fmt.Errorf("-after: %w", err))
+		}
+	}
+	if ff.before != "" {
+		if flt.Before, err = feed.ParseRelativeDate(ff.before, time.Now()); err != nil {
+			return flt, invalid(fmt.Errorf("-before: %w", err))
+		}
+	}
+	return flt, nil
+}
+
+// sortFlags are -sort and -reverse, shared by list, search and export.
+type sortFlags struct {
+	by      string
+	reverse bool
+}
+
+func addSortFlags(fs *flag.FlagSet) *sortFlags {
+	so := &sortFlags{}
+	fs.StringVar(&so.by, "sort", "", "order by "+strings.Join(feed.SortKeys, ", ")+" (dates newest first, text A to Z, reading time quickest first; default: feed order)")
+	fs.BoolVar(&so.reverse, "reverse", false, "reverse the order")
+	return so
+}
+
+// set reports whether the order differs from the default one.
+func (so *sortFlags) set() bool { return so.by != "" || so.reverse }
+
+// check rejects an unknown -sort key before any work is done.
+func (so *sortFlags) check() error {
+	if so.by != "" && !slices.Contains(feed.SortKeys, so.by) {
+		return invalid(fmt.Errorf("-sort: want one of %s, got %q", strings.Join(feed.SortKeys, ", "), so.by))
+	}
+	return nil
+}
+
+func (so *sortFlags) sort(links []*v1.Link) error { return feed.Sort(links, so.by, so.reverse) }
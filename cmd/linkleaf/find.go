@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+func cmdFind() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("find", flag.ExitOnError)
+	var file string
+	var limit int
+	var minScore float64
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.IntVar(&limit, "limit", 10, "show at most N links (0: all)")
+	fs.Float64Var(&minScore, "min", 0.4, "lowest match score shown, from 0 to 1")
+	jf := addJSONFlags(fs)
+	format := addFormatFlag(fs)
+	return fs, func() {
+		if file == "" || fs.NArg() == 0 {
+			badUsage(fs)
+		}
+		query := strings.Join(fs.Args(), " ")
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		matches := feed.FuzzyFind(f.Links, query, minScore)
+		if limit > 0 && len(matches) > limit {
+			matches = matches[:limit]
+		}
+		links := make([]*v1.Link, len(matches))
+		for i, m := range matches {
+			links[i] = m.Link
+		}
+		if *format != "" {
+			if err := writeFormatted(os.Stdout, *format, jf, links); err != nil {
+				die(err)
+			}
+			return
+		}
+		if jf.enabled() {
+			sel := feed.Filter{}.Select(f)
+			sel.Links = links
+			if err := jf.write(sel); err != nil {
+				die(err)
+			}
+			return
+		}
+		if len(matches) == 0 {
+			die(notFound(fmt.Errorf("no link resembles %q", query)))
+		}
+		for i, m := range matches {
+			fmt.Printf("%3d) [%s] %s  (%.0f%%)\n     %s\n", i+1, m.Link.Id, m.Link.Title, 100*m.Score, m.Link.Url)
+		}
+	}
+}
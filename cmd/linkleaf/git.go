@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+// git runs git in dir and returns its trimmed stdout; stderr becomes the
+// error text.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], s)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitCommit stages the feed file (and its checksum sidecar, if any) and
+// commits just those with message. Nothing to commit is not an error.
+func gitCommit(path, message string) error {
+	path, err := feed.ExpandPath(path)
+	if err != nil {
+		return err
+	}
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	files := []string{name}
+	if _, err := os.Stat(path + feed.ChecksumSuffix); err == nil {
+		files = append(files, name+feed.ChecksumSuffix)
+	}
+	if _, err := git(dir, append([]string{"add", "--"}, files...)...); err != nil {
+		return err
+	}
+	// diff --quiet exits 1 when something is staged.
+	if _, err := git(dir, append([]string{"diff", "--cached", "--quiet", "--"}, files...)...); err == nil {
+		msg.Debugf("git: %s unchanged, nothing to commit", path)
+		return nil
+	}
+	if _, err := git(dir, append([]string{"commit", "--quiet", "-m", message, "--"}, files...)...); err != nil {
+		return err
+	}
+	msg.Debugf("git: committed %s: %s", path, message)
+	return nil
+}
+
+// commitMessage describes a saved change: "add [id] title" for a single
+// link, counts otherwise. e may be nil (nothing journaled).
+func commitMessage(op string, e *feed.JournalEntry) string {
+	if e == nil {
+		return op
+	}
+	switch n := len(e.Added) + len(e.Removed) + len(e.Modified); {
+	case n == 1 && len(e.Added) == 1:
+		return fmt.Sprintf("%s [%s] %s", op, e.Added[0].Id, e.Added[0].Title)
+	case n == 1 && len(e.Removed) == 1:
+		return fmt.Sprintf("%s [%s] %s", op, e.Removed[0].Link.Id, e.Removed[0].Link.Title)
+	case n == 1:
+		return fmt.Sprintf("%s [%s] %s", op, e.Modified[0].New.Id, e.Modified[0].New.Title)
+	case n == 0:
+		return op
+	}
+	var parts []string
+	for _, c := range []struct {
+		n    int
+		verb string
+	}{{len(e.Added), "added"}, {len(e.Removed), "removed"}, {len(e.Modified), "modified"}} {
+		if c.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", c.n, c.verb))
+		}
+	}
+	return fmt.Sprintf("%s: %s", op, strings.Join(parts, ", "))
+}
+
+func cmdHistory() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	var file string
+	var limit int
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb) in a git work tree")
+	fs.IntVar(&limit, "limit", 0, "show at most N commits (0 = all)")
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		path, err := feed.ExpandPath(file)
+		if err != nil {
+			die(err)
+		}
+		dir, name := filepath.Split(path)
+		if dir == "" {
+			dir = "."
+		}
+		gitArgs := []string{"log", "--follow", "--date=short", "--format=%h  %ad  %s"}
+		if limit > 0 {
+			gitArgs = append(gitArgs, "-n", strconv.Itoa(limit))
+		}
+		out, err := git(dir, append(gitArgs, "--", name)...)
+		if err != nil {
+			var ee *exec.Error
+			if errors.As(err, &ee) {
+				die(fmt.Errorf("history needs git: %w", err))
+			}
+			die(err)
+		}
+		if out == "" {
+			msg.Infof("no commits touch %s", file)
+			return
+		}
+		fmt.Println(out)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// listGroups are the accepted list -group-by values.
+var listGroups = []string{"tag", "domain", "day", "week", "month", "year"}
+
+// linkGroup is one heading of list -group-by and its links, in feed order.
+type linkGroup struct {
+	name  string
+	links []*v1.Link
+}
+
+// groupLinks groups links by tag (a link under each of its tags) or domain,
+// biggest group first, or by the period of their date (see
+// markdownHeading), newest first in a newest-first feed.
+func groupLinks(links []*v1.Link, by string) ([]linkGroup, error) {
+	var keys func(l *v1.Link) []string
+	bySize := true
+	rest := "" // the group of links without a key, shown last
+	switch by {
+	case "tag":
+		rest = "(untagged)"
+		keys = func(l *v1.Link) []string {
+			if len(l.Tags) == 0 {
+				return []string{rest}
+			}
+			return l.Tags
+		}
+	case "domain":
+		rest = "(no domain)"
+		keys = func(l *v1.Link) []string {
+			return []string{cmp.Or(strings.TrimPrefix(feed.Host(l.Url), "www."), rest)}
+		}
+	default:
+		heading, ok := markdownHeading(by)
+		if !ok || heading == nil {
+			return nil, fmt.Errorf("-group-by: want one of %s, got %q", strings.Join(listGroups, ", "), by)
+		}
+		keys = func(l *v1.Link) []string {
+			if t, ok := linkTime(l); ok {
+				return []string{heading(t)}
+			}
+			return []string{"Undated"}
+		}
+		bySize = false
+	}
+
+	var groups []linkGroup
+	at := map[string]int{}
+	for _, l := range links {
+		seen := map[string]bool{}
+		for _, k := range keys(l) {
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			i, ok := at[k]
+			if !ok {
+				i = len(groups)
+				at[k] = i
+				groups = append(groups, linkGroup{name: k})
+			}
+			groups[i].links = append(groups[i].links, l)
+		}
+	}
+	if bySize {
+		slices.SortStableFunc(groups, func(a, b linkGroup) int {
+			if (a.name == rest) != (b.name == rest) {
+				if a.name == rest {
+					return 1
+				}
+				return -1
+			}
+			return cmp.Or(cmp.Compare(len(b.links), len(a.links)), strings.Compare(a.name, b.name))
+		})
+	}
+	return groups, nil
+}
+
+// groupHeading is the line list prints above a group.
+func groupHeading(g linkGroup) string {
+	n := "links"
+	if len(g.links) == 1 {
+		n = "link"
+	}
+	return fmt.Sprintf("%s (%d %s)", g.name, len(g.links), n)
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// watchInterval is how often WatchFeed checks the file for changes.
+const watchInterval = 500 * time.Millisecond
+
+// feedService implements linkleaf.v1.FeedService over the feed file: reads
+// go through feed.Select like "list", and every write is one locked
+// load-modify-save, journaled under the matching command's name, so the
+// CLI and other servers can share the file.
+type feedService struct {
+	v1.UnimplementedFeedServiceServer
+
+	cache *feedCache
+	genID feed.IDGenerator
+	sf    *saveFlags
+	done  chan struct{} // closed on shutdown, ending WatchFeed streams
+
+	mu sync.Mutex // one save at a time within the process
+}
+
+func newFeedService(cache *feedCache, genID feed.IDGenerator, sf *saveFlags) *feedService {
+	return &feedService{cache: cache, genID: genID, sf: sf, done: make(chan struct{})}
+}
+
+// grpcServer returns a gRPC server offering s. With a token, every call
+// must carry "authorization: Bearer <token>" metadata.
+func grpcServer(s v1.FeedServiceServer, token string) *grpc.Server {
+	check := func(ctx context.Context) error {
+		if token == "" {
+			return nil
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		got := strings.TrimPrefix(strings.Join(md.Get("authorization"), ""), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return status.Error(codes.Unauthenticated, "bad or missing token")
+		}
+		return nil
+	}
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+			if err := check(ctx); err != nil {
+				return nil, err
+			}
+			return h(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, h grpc.StreamHandler) error {
+			if err := check(ss.Context()); err != nil {
+				return err
+			}
+			return h(srv, ss)
+		}),
+	)
+	v1.RegisterFeedServiceServer(srv, s)
+	return srv
+}
+
+// stop ends the watch streams, then stops srv (see stopGRPC).
+func (s *feedService) stop(srv *grpc.Server) {
+	close(s.done)
+	stopGRPC(srv)
+}
+
+// stopGRPC lets in-flight calls finish for up to five seconds.
+func stopGRPC(srv *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		srv.Stop()
+	}
+}
+
+// checkGRPCAuth refuses to serve the read-write FeedService on addr without
+// a token when other machines can reach it, unless insecure allows it.
+func checkGRPCAuth(addr, token string, insecure bool) error {
+	if token != "" || insecure || isLoopback(addr) {
+		return nil
+	}
+	return fmt.Errorf("anyone who can reach %s could change the feeds; set -grpc-token, or -grpc-insecure to serve it anyway", addr)
+}
+
+// isLoopback reports whether the listen address addr only accepts local
+// connections.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return host == "localhost" || ip != nil && ip.IsLoopback()
+}
+
+func (s *feedService) ListLinks(_ context.Context, req *v1.ListLinksRequest) (*v1.ListLinksResponse, error) {
+	flt := feed.Filter{Tags: req.Tags, Unread: req.Unread, Starred: req.Starred}
+	if req.Domain != "" {
+		flt.Domain = cmp.Or(feed.Host(req.Domain), req.Domain)
+	}
+	var err error
+	if req.After != "" {
+		if flt.After, err = feed.ParseDate(req.After); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "after: %v", err)
+		}
+	}
+	if req.Before != "" {
+		if flt.Before, err = feed.ParseDate(req.Before); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "before: %v", err)
+		}
+	}
+	offset, limit := int(req.Offset), int(req.Limit)
+	if s.cache.indexed {
+		return s.listIndexed(flt, req.Query, offset, limit)
+	}
+
+	if req.Query == "" {
+		// One link past the page tells whether there are more.
+		more := limit
+		if limit > 0 {
+			more++
+		}
+		f, err := mustSelect(s.cache.path, flt, offset, more)
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		links, hasMore := page(f.Links, 0, limit)
+		return &v1.ListLinksResponse{Links: links, More: hasMore}, nil
+	}
+	q, err := feed.ParseQuery(req.Query)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "query: %v", err)
+	}
+	f, err := mustSelect(s.cache.path, flt, 0, 0)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	links, hasMore := page(q.Apply(f.Links), offset, limit)
+	return &v1.ListLinksResponse{Links: links, More: hasMore}, nil
+}
+
+// page returns links[offset:offset+limit] (all from offset if limit is
+// 0) and whether links continue past it.
+func page(links []*v1.Link, offset, limit int) ([]*v1.Link, bool) {
+	links = links[min(offset, len(links)):]
+	if limit > 0 && len(links) > limit {
+		return links[:limit], true
+	}
+	return links, false
+}
+
+// update runs one locked load-modify-save of the feed, recorded in the
+// journal as op. fn returns the link to answer with and whether it
+// changed the feed; a missing file starts a new feed.
+func (s *feedService) update(op string, fn func(f *v1.Feed) (*v1.Link, bool, error)) (*v1.Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.cache.path
+	unlock, err := feed.Lock(path)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "lock %s: %v", path, err)
+	}
+	defer unlock()
+	opened, err := feed.OpenWith(path, loadOpts)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "load %s: %v", path, err)
+	}
+	f := opened.Feed
+	sf := *s.sf
+	sf.op = op
+	sf.loaded(f)
+	l, changed, err := fn(f)
+	if err != nil || !changed {
+		return l, err
+	}
+	f.GeneratedAt = feed.NowRFC3339()
+	if err := sf.save(path, f); err != nil {
+		if errors.Is(err, errHookFailed) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return l, nil
+}
+
+func (s *feedService) AddLink(_ context.Context, req *v1.AddLinkRequest) (*v1.Link, error) {
+	if req.Link == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing link")
+	}
+	l := proto.Clone(req.Link).(*v1.Link)
+	if l.Date == "" {
+		l.Date = time.Now().Format(feed.DateLayout)
+	}
+	if err := feed.ValidateLink(l); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var err error
+	if l.Tags, err = validTags(l.Tags); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	l.Tags = withDefaultTags(l.Tags)
+
+	l, err = s.update("add", func(f *v1.Feed) (*v1.Link, bool, error) {
+		if old := feed.FindURL(f, l.Url); old != nil {
+			return nil, false, status.Errorf(codes.AlreadyExists, "already in the feed as [%s]", old.Id)
+		}
+		if l.Id == "" {
+			assignID(f, s.genID, l)
+		} else if feed.Find(f, l.Id) != nil {
+			return nil, false, status.Errorf(codes.AlreadyExists, "id %q is taken", l.Id)
+		}
+		return feed.AddLink(f, l), true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	msg.Infof("added [%s] %s", l.Id, l.Title)
+	return l, nil
+}
+
+func (s *feedService) UpdateLink(_ context.Context, req *v1.UpdateLinkRequest) (*v1.Link, error) {
+	if req.Link.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing link.id")
+	}
+	src := proto.Clone(req.Link).ProtoReflect() // its values end up in the feed
+	fields := src.Descriptor().Fields()
+	var set []protoreflect.FieldDescriptor
+	if len(req.Fields) == 0 {
+		src.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+			if fd.Name() != "id" {
+				set = append(set, fd)
+			}
+			return true
+		})
+	}
+	for _, name := range req.Fields {
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown link field %q", name)
+		}
+		if fd.Name() == "id" {
+			return nil, status.Error(codes.InvalidArgument, "the id can't be changed")
+		}
+		set = append(set, fd)
+	}
+
+	l, err := s.update("edit", func(f *v1.Feed) (*v1.Link, bool, error) {
+		l := feed.Find(f, req.Link.Id)
+		if l == nil {
+			return nil, false, status.Errorf(codes.NotFound, "no link with id %q", req.Link.Id)
+		}
+		old := proto.Clone(l)
+		dst := l.ProtoReflect()
+		for _, fd := range set {
+			if src.Has(fd) {
+				dst.Set(fd, src.Get(fd))
+			} else {
+				dst.Clear(fd)
+			}
+		}
+		if err := feed.ValidateLink(l); err != nil {
+			return nil, false, status.Error(codes.InvalidArgument, err.Error())
+		}
+		var err error
+		if l.Tags, err = validTags(l.Tags); err != nil {
+			return nil, false, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return l, !proto.Equal(old, l), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	msg.Infof("edited [%s] %s", l.Id, l.Title)
+	return l, nil
+}
+
+func (s *feedService) DeleteLink(_ context.Context, req *v1.DeleteLinkRequest) (*v1.DeleteLinkResponse, error) {
+	l, err := s.update("remove", func(f *v1.Feed) (*v1.Link, bool, error) {
+		removed := feed.Trash(f, func(l *v1.Link) bool { return l.Id == req.Id }, time.Now())
+		if removed == nil {
+			return nil, false, status.Errorf(codes.NotFound, "no link with id %q", req.Id)
+		}
+		return removed[0], true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	msg.Infof("removed [%s] %s", l.Id, l.Title)
+	return &v1.DeleteLinkResponse{Link: l}, nil
+}
+
+// WatchFeed polls the file (see feedCache) and sends the links that
+// changed since the last look, so edits made by the CLI show up too.
+func (s *feedService) WatchFeed(_ *v1.WatchFeedRequest, stream grpc.ServerStreamingServer[v1.FeedEvent]) error {
+	prev, err := s.cache.get()
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	tick := time.NewTicker(watchInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.done:
+			return nil
+		case <-tick.C:
+		}
+		f, err := s.cache.get()
+		if err != nil {
+			msg.Debugf("watch: %v", err) // e.g. mid-rewrite; try again next tick
+			continue
+		}
+		if f == prev {
+			continue
+		}
+		for _, e := range feedEvents(prev, f) {
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		}
+		prev = f
+	}
+}
+
+// feedEvents lists the link changes from a to b: additions, then
+// modifications, then removals (see feed.Compare).
+func feedEvents(a, b *v1.Feed) []*v1.FeedEvent {
+	d := feed.Compare(a, b)
+	var out []*v1.FeedEvent
+	for _, l := range d.Added {
+		out = append(out, &v1.FeedEvent{Kind: v1.ChangeKind_CHANGE_KIND_ADDED, Link: l, GeneratedAt: b.GeneratedAt})
+	}
+	for _, c := range d.Modified {
+		out = append(out, &v1.FeedEvent{Kind: v1.ChangeKind_CHANGE_KIND_MODIFIED, Link: c.New, GeneratedAt: b.GeneratedAt})
+	}
+	for _, l := range d.Removed {
+		out = append(out, &v1.FeedEvent{Kind: v1.ChangeKind_CHANGE_KIND_REMOVED, Link: l, GeneratedAt: b.GeneratedAt})
+	}
+	return out
+}
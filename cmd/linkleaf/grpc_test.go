@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestCheckGRPCAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		addr     string
+		token    string
+		insecure bool
+		wantErr  bool
+	}{
+		{"loopback without token", "localhost:9090", "", false, false},
+		{"loopback IP without token", "127.0.0.1:9090", "", false, false},
+		{"IPv6 loopback without token", "[::1]:9090", "", false, false},
+		{"all interfaces without token", ":9090", "", false, true},
+		{"public address without token", "192.0.2.1:9090", "", false, true},
+		{"public address with token", "192.0.2.1:9090", "secret", false, false},
+		{"all interfaces with -grpc-insecure", ":9090", "", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkGRPCAuth(tt.addr, tt.token, tt.insecure)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkGRPCAuth(%q, %q, %v) = %v, want error: %v", tt.addr, tt.token, tt.insecure, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+type hashReport struct {
+	Feed  string     `json:"feed"`
+	Links []linkHash `json:"links"`
+}
+
+type linkHash struct {
+	ID   string `json:"id"`
+	Hash string `json:"hash"`
+}
+
+// cmdHash prints the canonical digests of a feed and its links, in
+// sha256sum style: the feed's against its file name, then one per link ID.
+func cmdHash() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("hash", flag.ExitOnError)
+	var file string
+	var asJSON bool
+	fs.StringVar(&file, "file", "", "protobuf feed file (.pb), instead of the positional argument")
+	fs.BoolVar(&asJSON, "json", false, "print the digests as JSON")
+	return fs, func() {
+		path, ok := feedArg(fs, file)
+		if !ok {
+			badUsage(fs)
+		}
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
+		}
+
+		r := hashReport{Links: []linkHash{}}
+		if r.Feed, err = feed.FeedHash(f); err != nil {
+			die(err)
+		}
+		for _, l := range f.Links {
+			h, err := feed.LinkHash(l)
+			if err != nil {
+				die(err)
+			}
+			r.Links = append(r.Links, linkHash{ID: l.Id, Hash: h})
+		}
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(r); err != nil {
+				die(err)
+			}
+			return
+		}
+		fmt.Printf("%s  %s\n", r.Feed, path)
+		for _, l := range r.Links {
+			fmt.Printf("%s  %s\n", l.Hash, l.ID)
+		}
+	}
+}
@@ -0,0 +1,701 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// commandHelp holds the usage lines and notes of each command, keyed by its
+// name; subs (tags rename, export rss) share their command's. run makes it,
+// with the flags, the command's FlagSet.Usage. meta, config, feeds and
+// completion set their own.
+var commandHelp = map[string]string{
+	"init": `usage: linkleaf init <file.pb> [-title "My Feed"] [-author NAME] [-version 1] [save flags]
+
+notes:
+  • "init" creates the file if it doesn't exist; "add" creates it on demand if needed.
+`,
+	"add": `usage: linkleaf add -file <file.pb> -title "..." -url "..." -date YYYY-MM-DD [-summary "..."] [-tags a,b,c] [-tag t]... [-normalize-tags]
+                    [-via URL] [-author NAME] [-id ID | -id-scheme urlhash|slug|uuid] [-force | -update-existing] [-no-validate]
+                    [-slug SLUG] [-lang L] [-meta key=value]... [-enclosure URL [-enclosure-type MIME] [-enclosure-length BYTES]]
+                    [-draft | -publish-at TIME] [-announce mastodon,bluesky|all] [-webmention] [-policy FILE] [save flags]
+       linkleaf add -file <file.pb> -url "..." -date YYYY-MM-DD -fetch [-timeout 10s] [-user-agent UA] [any add flag]
+       linkleaf add -file <file.pb> -interactive [any add flag to pre-fill]
+       linkleaf add -file <file.pb> -e [any add flag to pre-fill]
+       linkleaf add -file <file.pb> [any add flag] -
+       linkleaf add -file <file.pb> -batch links.txt [-id-scheme S] [-normalize-tags] [-force] [-policy FILE] [save flags]
+
+notes:
+  • "add" prepends links (newest first). If -id is empty it comes from -id-scheme (default: id_scheme in the
+    config, else urlhash):
+      urlhash (default)  sha256(url+"|"+date)[:12] — reproducible from the link itself
+      slug               slugified title — depends on existing IDs
+      uuid               random UUIDv4 — not reproducible
+    A generated ID the feed already has, on a link or in the trash, gets -2, -3, … appended (add, add -batch,
+    capture, and AddLink of serve/daemon), with a warning unless the scheme is slug; a taken -id is refused.
+  • "add" refuses a URL the feed already has, compared normalized (host case, default ports, utm_* params and
+    trailing slash ignored); -force adds it anyway, -update-existing updates the existing link instead.
+  • -fetch GETs the page and fills title/summary from og:title/og:description (or <title>/meta description),
+    and the language from the page (see "linkleaf -h" on languages). It also counts the words of the page's
+    text (its <article>, else <main>, else all of it, without navigation, headers and footers) and stores them
+    with a reading time at 230 words a minute: "list -sort reading-time" puts quick reads first and
+    -max-minutes N (filter flags) keeps those that fit N minutes. Links never fetched have no reading time.
+  • -meta key=value (add, edit; repeatable) stores custom data on a link, such as rating=5 or project=x;
+    keys can't contain whitespace or '='. "edit -meta key=" deletes a key. print and list show it and search
+    finds it with meta:key=value.
+  • "add -" reads one link from stdin, as JSON ({"title": …, "url": …, "tags": [...]}) or "key: value" lines
+    (title, url, date, tags, summary, via, id); flags win over its fields and the date defaults to today.
+    "add -e" opens the same key: value form in $VISUAL/$EDITOR, pre-filled from the flags (the URL, if not
+    given, from an http(s) URL on the clipboard); saving without a title or URL adds nothing.
+  • -batch lines are url<TAB>title<TAB>date<TAB>tags (tags optional, comma-separated); the feed is written once.
+  • -enclosure attaches a media file to a link (a podcast episode, a video) for podcast clients: RSS gets an
+    <enclosure>, Atom a rel="enclosure" link and JSON Feed an attachment. -enclosure-type defaults to the type
+    its extension implies (.mp3 is audio/mpeg); -enclosure-length is its size in bytes, 0 if unknown.
+    "edit -enclosure ''" removes it.
+  • -draft (add, edit) keeps a link out of html, rss, atom, jsonfeed, markdown, hugo, jekyll and custom exports,
+    "build", the "serve" pages and feeds and ActivityPub until "publish -id X" releases it; -publish-at TIME
+    (YYYY-MM-DD or RFC 3339) does the same until that time. "publish" then crossposts as usual, unless no
+    service is configured or -to none. The csv, jsonl, textproto and json exports and /raw.pb keep every link;
+    -drafts previews the others with drafts in. Static exports and builds need re-running after publish_at.
+  • "add" (and add -batch, capture) wants an http(s) URL with a host, a title, and a YYYY-MM-DD date from 1970
+    to a year ahead; -no-validate skips the URL and date checks. An -id may not be "." or ".." or contain a
+    slash or backslash (IDs name files), with or without -no-validate.
+  • A policy file holds a shared feed's curation rules, in the config's TOML subset: allowed_domains and
+    blocked_domains (subdomains included), required_tags, min_tags, max_title_length (in characters) and
+    summary_required_after = "YYYY-MM-DD" (links dated from then on need a summary). "add" refuses a link that
+    breaks one (add -batch skips it with a warning) and "validate -policy" reports every link that does, so CI
+    can enforce them. Both default to the config's policy; -policy '' turns it off.
+  • add/edit -date also take a time, with a zone or in local time: "2024-05-01T18:30", "2024-05-01 18:30:05",
+    "2024-05-01T18:30+02:00"; such dates are stored in RFC 3339. Filters, queries and periods compare the
+    date's day. RSS/Atom/JSON Feed publish times and "-sort date" use the time, else added_at when it falls
+    on the date (so links of the same day keep the order they were added in), else midnight UTC.
+`,
+	"list": `usage: linkleaf list <file.pb> [filter flags] [sort flags] [-offset N] [-limit N] [-broken[=N]]
+                     [-group-by tag|domain|day|week|month|year]
+                     [-json | -jsonl | -format T | -table [-columns index,id,date,title,domain,tags] [-no-color]]
+
+notes:
+  • list/print -json print the feed as protojson (camelCase fields); -jsonl prints one link per line.
+  • "list -table" prints one aligned row per link; -columns picks and orders them from index, id, date, added,
+    title, url, domain, tags, author and minutes (reading time). On a terminal (else at $COLUMNS) rows are cut to its width, the
+    title first, then url, tags, domain and author, with "…". Output is colored on a terminal unless
+    -no-color, $NO_COLOR or TERM=dumb; piped output is plain and never cut.
+  • "list -group-by" puts links under a heading with the group's count: by tag (a link under each of its
+    tags, "(untagged)" last) or domain, biggest group first, or by the day, week, month or year of their
+    date. It works with -table, not with -json, -jsonl or -format.
+`,
+	"search": `usage: linkleaf search -file <file.pb> [-fts [-no-color]] [-tags EXPR] [sort flags] [-json | -jsonl | -format T] "query"
+
+notes:
+  • "search -fts" searches the words of titles, summaries and notes instead, best matches first (BM25; title
+    words count triple), with the matching words highlighted (bold on a terminal, else *word*) and an excerpt
+    of the summary or notes. Every word must occur; "a phrase" must occur as written within one field, word*
+    matches a prefix and -word excludes. The index is kept in <file>.fts, built by the first -fts search and
+    then updated for the links each save changes. Encrypted and remote feeds are indexed in memory only.
+`,
+	"find": `usage: linkleaf find -file <file.pb> [-limit 10] [-min 0.4] [-json | -jsonl | -format T] "approximate title"
+
+notes:
+  • "find" ranks links by how closely their titles resemble the words given (shared trigrams, so typos
+    and missing words still match), or by ID prefix, and shows the score of each.
+`,
+	"print": `usage: linkleaf print <file.pb> [-json | -jsonl]
+
+notes:
+  • list/print -json print the feed as protojson (camelCase fields); -jsonl prints one link per line.
+`,
+	"tui": `usage: linkleaf tui -file <file.pb>
+
+notes:
+  • "tui" browses the feed: / searches as you type (search syntax), t filters by tag, o opens the link,
+    e edits the title, T the tags, d deletes. Each change is saved (and journaled) right away.
+`,
+	"export": `usage: linkleaf export <file.pb> [-format html|csv|jsonl] [-out FILE] [-css style.css] [-template page.tmpl]
+                       [-header] [-drafts] [filter flags] [sort flags]
+       linkleaf export rss|atom|jsonfeed -file <file.pb> [-link https://example.com] [-site-title T]
+                       [-description D] [-feed-url URL] [-out FILE [-page-size N]] [-drafts] [filter flags] [sort flags]
+       linkleaf export markdown -file <file.pb> [-group-by none|day|week|month|year] [-out FILE] [-drafts]
+                       [filter flags] [sort flags]
+       linkleaf export textproto|json -file <file.pb> [-out FILE] [filter flags] [sort flags]
+       linkleaf export opml [-base-url https://links.example.com] [-title T] [-out FILE]
+       linkleaf export hugo|jekyll -file <file.pb> [-out DIR] [-front-matter yaml|toml] [-incremental] [-drafts]
+                       [filter flags]
+       linkleaf export custom -file <file.pb> -format TEMPLATE|@file [-out FILE] [-drafts] [filter flags]
+                       [sort flags]
+       linkleaf export email -file <file.pb> [-since 7d] [-subject T] [-from ADDR] [-to ADDR,ADDR] [-intro TEXT|@file]
+                       [-template page.tmpl] [-text-template text.tmpl] [-out FILE.eml | -send] [-drafts] [filter flags]
+                       [sort flags]
+       linkleaf export shaarli -file <file.pb> [-out FILE] [filter flags] [sort flags]
+
+notes:
+  • export textproto and export json write the whole Feed message (as textproto, or as protojson like
+    print -json) for code review, hand edits and diff-able copies in git. Importing one into a new or empty
+    feed restores it exactly, byte for byte; into a feed with links, only the links are merged.
+  • shaarli reads Shaarli's export (a bookmarks.html: the description's first paragraph becomes the summary,
+    the rest the notes; private links become drafts; notes without a URL are skipped) and wallabag its JSON
+    export (archived articles are marked read; origin_url becomes via; language, reading time and starred
+    are kept; annotations become quotes and their comments notes). "export shaarli" writes the same
+    bookmarks.html back, drafts and scheduled links as private, for Shaarli's import (and browsers').
+  • "export opml" lists the feeds in the config's [feeds] for feed readers and blogrolls, each at
+    <base-url>/NAME/feed.xml (as "build -out public/NAME -base-url <base-url>/NAME" publishes it; -base-url
+    defaults to export.link). "import opml" registers a feed for each outline with an xmlUrl, named after its
+    title and stored as DIR/NAME.pb; -fetch also imports the feed's current items. Names already configured
+    are skipped.
+  • "export hugo" and "export jekyll" write one Markdown file per link (hugo: content/links/ID.md, jekyll:
+    _links/YYYY-MM-DD-ID.md) with front matter for title, date, tags, link (the URL), via, archive and id,
+    and the summary and notes as the body. Files are named by ID, so edits rewrite them; -incremental writes
+    only new or changed files. Hugo can read toml front matter too.
+  • CSV columns: id,title,url,date,tags,summary,via (tags joined by ';' or ','); import skips rows without
+    title, url or date or with a bad date or tag, and counts them as invalid. tsv is the same, tab-separated.
+  • "export email" writes a newsletter of the selected links (e.g. -since 7d for the past week) as a MIME
+    message with an HTML and a plain text part; -send hands it to the SMTP server in the config (email.smtp,
+    email.username, email.password or $LINKLEAF_SMTP_PASSWORD) instead. -from and -to default to email.from
+    (else the author) and email.to. -subject is a text/template, -template and -text-template replace the
+    built-in parts (data: .Feed, .Subject, .Intro, .Since, .Until; the -format helpers plus paragraphs and
+    wrap TEXT WIDTH INDENT). Nothing is written or sent when no link is selected.
+  • jsonl writes one protojson Link per line (-header: feed metadata first), e.g. for jq.
+  • rss needs -link (the site home page, or the feed's home_page_url); atom needs -link or -feed-url.
+  • Atom entry IDs are tag URIs built from the -link host and the link ID, so they survive regeneration.
+  • jsonfeed is JSON Feed 1.1: via becomes external_url, dates become RFC 3339 date_published.
+  • -page-size N (export rss/atom/jsonfeed, build) splits a feed of more than N links into pages, paged as in
+    RFC 5005: feed.xml holds the first N, feed-page2.xml the next N, and so on. Each page links the others with
+    rel="self", "first", "previous", "next" and "last" (<atom:link> in RSS, <link> in Atom); JSON Feed pages
+    get next_url. Page URLs derive from -feed-url (build: -base-url), which paging needs. export drops pages
+    a previous, longer export left behind.
+  • The export format may be given as the first argument ("export rss ..." = "export -format rss ...").
+`,
+	"import": `usage: linkleaf import <file.pb> [-format csv|tsv|bookmarks|rss] [-in FILE] [-map COLUMNS] [save flags]
+       linkleaf import bookmarks -file <file.pb> -in bookmarks.html [save flags]
+       linkleaf import textproto|json -file <file.pb> [-in FILE] [save flags]
+       linkleaf import rss -file <file.pb> (-url https://example.com/feed.xml | -in feed.xml) [save flags]
+       linkleaf import opml [-in subscriptions.opml] [-dir DIR] [-fetch] [save flags]
+       linkleaf import pocket|pinboard|raindrop|shaarli|wallabag -file <file.pb> -in export-file [save flags]
+       linkleaf import browser-history -file <file.pb> (-browser chrome|firefox | -in History|places.sqlite)
+                       [-after DATE] [-before DATE] [-min-visits 2] [-limit N] [-tags a,b] [-yes] [save flags]
+
+notes:
+  • import skips IDs and (normalized) URLs the feed already has.
+  • export textproto and export json write the whole Feed message (as textproto, or as protojson like
+    print -json) for code review, hand edits and diff-able copies in git. Importing one into a new or empty
+    feed restores it exactly, byte for byte; into a feed with links, only the links are merged.
+  • bookmarks reads a browser bookmarks.html export: folders become tags, ADD_DATE the date.
+  • rss reads RSS 2.0/1.0 or Atom: item title, link, description/summary, date and categories (as tags).
+  • pocket reads Pocket's ril_export.html or CSV export (archived links are marked read), pinboard its JSON
+    export (extended becomes the summary; links not "to read" are marked read) and raindrop Raindrop.io's
+    CSV (excerpt becomes the summary, note the notes, the folder a tag; favorites are starred). Tags and
+    the time each link was saved (date and added_at) are kept.
+  • shaarli reads Shaarli's export (a bookmarks.html: the description's first paragraph becomes the summary,
+    the rest the notes; private links become drafts; notes without a URL are skipped) and wallabag its JSON
+    export (archived articles are marked read; origin_url becomes via; language, reading time and starred
+    are kept; annotations become quotes and their comments notes). "export shaarli" writes the same
+    bookmarks.html back, drafts and scheduled links as private, for Shaarli's import (and browsers').
+  • "import browser-history" reads a copy of Chrome's (or another Chromium browser's) History or Firefox's
+    places.sqlite, from the default profile unless -in, so the browser may stay open. Pages visited at least
+    -min-visits times, last visited between -after and -before, are offered newest first for y/n/a(ll)/q(uit)
+    unless -yes; the last visit becomes the date. Local pages, searches and logins are left out.
+  • "export opml" lists the feeds in the config's [feeds] for feed readers and blogrolls, each at
+    <base-url>/NAME/feed.xml (as "build -out public/NAME -base-url <base-url>/NAME" publishes it; -base-url
+    defaults to export.link). "import opml" registers a feed for each outline with an xmlUrl, named after its
+    title and stored as DIR/NAME.pb; -fetch also imports the feed's current items. Names already configured
+    are skipped.
+  • CSV columns: id,title,url,date,tags,summary,via (tags joined by ';' or ','); import skips rows without
+    title, url or date or with a bad date or tag, and counts them as invalid. tsv is the same, tab-separated.
+  • -map reads other layouts, e.g. url=1,title=2,date=3,tags=4 (1-based numbers or header names). With
+    numbers only, the first row is taken as a header unless its url cell holds a URL.
+`,
+	"subscribe": `usage: linkleaf subscribe add -file <file.pb> URL [-title T] [-tags a,b,c] [-tag t]... [save flags]
+       linkleaf subscribe [list] -file <file.pb>
+       linkleaf subscribe remove -file <file.pb> URL|N [save flags]
+       linkleaf subscribe pull -file <file.pb> [-timeout 30s] [save flags]
+
+notes:
+  • "subscribe add" registers an RSS or Atom feed in the feed file, with tags for what it brings in;
+    "subscribe pull" fetches every subscription (conditionally, by ETag/Last-Modified) and adds the items it
+    hasn't seen before as links, via the subscription, skipping URLs already in the feed. It prints each link
+    added and exits 1 if any subscription failed, so it can run from cron. Items removed from the feed
+    aren't added back; "subscribe list" shows when each was last pulled or why it failed.
+`,
+	"build": `usage: linkleaf build -file <file.pb> -base-url https://links.example.com [-out public] [-templates DIR] [-css URL]
+                      [-images [-images-max-size BYTES] [-images-max-age 30d]] [-drafts] [-permalinks page|redirect] [-page-size N]
+                      [filter flags]
+
+notes:
+  • -page-size N (export rss/atom/jsonfeed, build) splits a feed of more than N links into pages, paged as in
+    RFC 5005: feed.xml holds the first N, feed-page2.xml the next N, and so on. Each page links the others with
+    rel="self", "first", "previous", "next" and "last" (<atom:link> in RSS, <link> in Atom); JSON Feed pages
+    get next_url. Page URLs derive from -feed-url (build: -base-url), which paging needs. export drops pages
+    a previous, longer export left behind.
+  • "build" writes index.html, tags/<tag>/, archive/YYYY-MM/, feed.xml, feed.atom, feed.json and sitemap.xml;
+    -templates DIR may hold index.html.tmpl, tag.html.tmpl and archive.html.tmpl (same data as export -template).
+  • -images (build, serve) shows each site's icon and each page's og:image, as cards: build caches them in
+    <out>/assets, serve in -assets DIR (served under /assets/) and fetches them in the background, so they show
+    up on a later request. Images over -images-max-size bytes (1 MiB) and SVGs aren't kept; images, and pages
+    without one, are fetched again after -images-max-age. Templates get them as .Images (link ID → .Icon, .Image).
+`,
+	"watch": `usage: linkleaf watch -file <file.pb> [-on-change STEP]... [-interval 500ms] [-initial=false]
+
+notes:
+  • "watch" runs steps at start and whenever the feed file (or its .wal) changes, once it has been the same for
+    -interval, until Ctrl-C. It watches the file's directory, so saves that rename a new file over it count,
+    and a sharded feed's directory itself.
+    Each -on-change is one step: a name from the config's [watch] table, whose values are shell commands
+    (e.g. site = "linkleaf build -out public -base-url https://links.example.com"), or a linkleaf command line
+    like "export rss -out public/feed.xml", split as a shell would (quote arguments with spaces or commas);
+    by default every step of [watch] runs.
+    Steps run in order with LINKLEAF_FEED set to the file, so those without -file use it; one that fails is
+    reported and watching goes on.
+`,
+	"serve": `usage: linkleaf serve <file.pb> | -file <file.pb> [-addr :8080] [-rate-limit N] [-permalinks page|redirect] [-grpc :9090 [-grpc-token X | -grpc-insecure] [-id-scheme S] [save flags]]
+                      [-images [-assets DIR] [-images-max-size BYTES] [-images-max-age 30d]]
+
+notes:
+  • -images (build, serve) shows each site's icon and each page's og:image, as cards: build caches them in
+    <out>/assets, serve in -assets DIR (served under /assets/) and fetches them in the background, so they show
+    up on a later request. Images over -images-max-size bytes (1 MiB) and SVGs aren't kept; images, and pages
+    without one, are fetched again after -images-max-age. Templates get them as .Images (link ID → .Icon, .Image).
+  • "serve" publishes the feed over HTTP: / (HTML), /feed.xml (RSS), /feed.atom, /feed.json, /raw.pb; edits
+    show up on the next request. These are read-only; with serve.api_tokens in the config it also answers a
+    JSON REST API for frontends that changes the file, locked, saved and journaled like add, edit and remove
+    ("Authorization: Bearer TOKEN"): GET /api/v1/feed (metadata), GET and POST /api/v1/links, and GET, PATCH
+    and DELETE /api/v1/links/{id}. Listing takes tag (repeatable), domain, after, before, unread, starred, q
+    (search syntax), offset and limit (default 50, 0: all) and sends a Link rel="next" header for the next
+    page. Bodies are protojson Links; PATCH changes only the fields it sends (null clears one). Errors are
+    {"error"} with 400, 401, 404 or 409. serve.api_origins lets browser apps on other origins call it.
+  • For monitoring, serve also answers /healthz (200 "ok" while the feed loads, else 503 with the error) and
+    /metrics in the Prometheus text format: requests and their latency by route and status, the feed's link
+    count and size, and when it last changed (as a timestamp and an age in seconds).
+  • serve renders the page and feeds once per change of the file (or scheduled link going public) and sends
+    them with an ETag and Last-Modified, so polling readers get 304 Not Modified, gzipped when the client
+    accepts it. -rate-limit N allows each client IP N requests a minute (429 with Retry-After beyond that;
+    /healthz and /metrics are exempt); behind a reverse proxy on the same machine the X-Forwarded-For
+    address counts.
+  • The same tokens open a Pinboard-compatible API for existing Pinboard clients and browser extensions (set
+    their API base to the server): /v1/posts/add (url, description, extended, tags, dt, toread, replace),
+    /v1/posts/all (tag, start, results, fromdt, todt) and /v1/posts/delete (url), with auth_token=USER:TOKEN
+    or basic auth with the token as the password. Answers are XML, or JSON with format=json.
+  • "serve -grpc :9090" also serves linkleaf.v1.FeedService (proto/linkleaf/v1/service.proto): ListLinks
+    (filter fields, a search query, offset/limit paging), AddLink, UpdateLink, DeleteLink, and WatchFeed, which
+    streams the links added, modified or removed by anyone as it polls the file twice a second. Writes lock,
+    save and journal like add, edit and remove. With -grpc-token (default $LINKLEAF_GRPC_TOKEN) calls must send
+    "authorization: Bearer X"; without one, serve and daemon refuse an address other machines can reach unless
+    -grpc-insecure is given. -addr "" serves gRPC only.
+  • With activitypub.url (the public https URL serve is reached at) in the config, "serve" makes the feed an
+    ActivityPub actor Fediverse users can follow as @links@host (activitypub.user changes the name): WebFinger,
+    /ap/actor, an outbox with a Note per link (title linking to the URL, summary, tags as hashtags) and an
+    inbox that accepts signed Follow and Undo. While serve runs, links added by any command are delivered to
+    followers (at most 20 per save), and removed ones deleted. The signing key and the followers are kept in
+    <file>.ap-key.pem and <file>.followers.json; a reverse proxy in front must pass the Host header on.
+`,
+	"daemon": `usage: linkleaf daemon [-grpc localhost:9090] [-grpc-token X | -grpc-insecure] [-addr ADDR] [-feeds all|none|NAME,...] [-id-scheme S]
+                       [save flags]
+
+notes:
+  • "serve -grpc :9090" also serves linkleaf.v1.FeedService (proto/linkleaf/v1/service.proto): ListLinks
+    (filter fields, a search query, offset/limit paging), AddLink, UpdateLink, DeleteLink, and WatchFeed, which
+    streams the links added, modified or removed by anyone as it polls the file twice a second. Writes lock,
+    save and journal like add, edit and remove. With -grpc-token (default $LINKLEAF_GRPC_TOKEN) calls must send
+    "authorization: Bearer X"; without one, serve and daemon refuse an address other machines can reach unless
+    -grpc-insecure is given. -addr "" serves gRPC only.
+  • "daemon" keeps the default feed and the configured feeds (-feeds) decoded and indexed by ID, tag and host,
+    reloading each in the background when its file changes, and answers the FeedService on -grpc (default
+    localhost:9090) from memory; the "linkleaf-feed: NAME" metadata picks a configured feed, none the default.
+    -addr adds the REST API (it needs serve.api_tokens), with named feeds under /api/v1/feeds/NAME. Writes
+    lock, save and journal as with serve, so the CLI can keep using the files.
+`,
+	"capture": `usage: linkleaf capture -file <file.pb> [-addr 127.0.0.1:7070] [-token X] [-id-scheme S] [save flags]
+
+notes:
+  • "capture" adds links POSTed as JSON to /capture ({"url", "title", "tags", "summary", "via", "date"}, like
+    "add -") with the token as "Authorization: Bearer X" or a "token" field; -token defaults to
+    $LINKLEAF_CAPTURE_TOKEN, else a random one. It prints a bookmarklet that posts the current page (selected
+    text as the summary). Answers 201 {"id"}, 401, 400 or 409 (URL already in the feed) with {"error"}.
+`,
+	"publish": `usage: linkleaf publish -file <file.pb> -id ID [-to mastodon,bluesky|all|none] [-timeout 30s] [save flags]
+
+notes:
+  • "publish" posts a link as "title, URL, #tags" to Mastodon (mastodon.server, mastodon.token with
+    write:statuses, optional mastodon.visibility) and Bluesky (bluesky.handle, bluesky.app_password, optional
+    bluesky.service) as set in the config; -to all (the default) means every configured service. Tags become
+    hashtags without punctuation; if the post is too long (500 and 300 characters) tags are dropped, then the
+    title is shortened. Bluesky posts get a link card and clickable link and tags. -dry-run prints the posts;
+    "add -announce" publishes the new link after saving it.
+`,
+	"webmention": `usage: linkleaf webmention -file <file.pb> -id ID [-source URL] [-dry-run] [-timeout 10s]
+
+notes:
+  • "webmention" tells the link's URL and its via page that -source (your page about the link, by default
+    webmention.source with {id} replaced, e.g. https://links.example.com/#{id}) links to them: it finds each
+    page's endpoint (Link header, else <link> or <a rel="webmention">) and POSTs source and target. Pages
+    without an endpoint are skipped. "add -webmention" does this after saving; the source page must already
+    show the link, so it suits serve better than a site that still has to be rebuilt.
+`,
+	"tags": `usage: linkleaf tags [list] <file.pb> [-sort count|name] [-json]
+       linkleaf tags rename -file <file.pb> OLD NEW [save flags]
+       linkleaf tags merge -file <file.pb> TAG... -into NEW [save flags]
+       linkleaf tags rm -file <file.pb> TAG... [save flags]
+
+notes:
+  • "tags rename", "tags merge" and "tags rm" rewrite every affected link in one save; a link left with a
+    tag twice keeps it once. "rename-tag" is the older spelling of rename and rm.
+`,
+	"rename-tag": `usage: linkleaf rename-tag <file.pb> -from old (-to new | -delete) [save flags]
+
+notes:
+  • "tags rename", "tags merge" and "tags rm" rewrite every affected link in one save; a link left with a
+    tag twice keeps it once. "rename-tag" is the older spelling of rename and rm.
+`,
+	"retag": `usage: linkleaf retag -file <file.pb> -match QUERY [-add-tag T]... [-remove-tag T]... [save flags]
+
+notes:
+  • "retag" adds and removes tags on every link matching -match, a query in "search" syntax, in one save;
+    -remove-tag ignores case and takes lang/* for a namespace. -dry-run previews the changed links.
+`,
+	"stats": `usage: linkleaf stats <file.pb> [-top N] [-json] [filter flags]
+
+notes:
+  • "stats" counts links, links per month (empty months included), top domains and tags, summary coverage
+    and links per week between the first and last date; -json prints the same figures for charting.
+`,
+	"validate": `usage: linkleaf validate <file.pb> [-json | -ci] [-policy policy.toml]
+
+notes:
+  • "validate" lints a whole feed (empty, malformed or duplicate IDs, empty titles, bad URLs, dates, tags
+    and timestamps) and exits 1 if it finds any problem.
+  • A policy file holds a shared feed's curation rules, in the config's TOML subset: allowed_domains and
+    blocked_domains (subdomains included), required_tags, min_tags, max_title_length (in characters) and
+    summary_required_after = "YYYY-MM-DD" (links dated from then on need a summary). "add" refuses a link that
+    breaks one (add -batch skips it with a warning) and "validate -policy" reports every link that does, so CI
+    can enforce them. Both default to the config's policy; -policy '' turns it off.
+  • -ci (validate, check, diff) prints GitHub Actions annotations on the feed file (::error for problems and
+    broken links; ::notice, or ::warning for removals, for diff changes) and then a one-line JSON summary,
+    also set as the step output "summary". Exit codes are unchanged: add -fail-on-error or -exit-code.
+`,
+	"doctor": `usage: linkleaf doctor [<file.pb> | -file <file.pb>] [-fix] [save flags]
+
+notes:
+  • "doctor" looks past the links: whether the file reads and decodes, its version, checksum and journal,
+    links that repeat an ID, links out of added_at order, a stale write-ahead log or search index, temporary
+    files of interrupted saves, sidecars of feeds that no longer exist, and the config. -fix applies only
+    repairs that lose nothing: it drops exact duplicates, upgrades the version and deletes stale or orphaned
+    lock, log, checksum and index files; journals, backups and snapshots are left for you to move or delete.
+    It exits 1 while an error remains.
+`,
+	"check": `usage: linkleaf check <file.pb> [-concurrency 8] [-timeout 10s] [-fail-on-error] [-report FILE] [-ci]
+                      [-annotate | -only-stale AGE] [save flags]
+
+notes:
+  • "check" tries HEAD, then GET, for every URL and reports redirects and timeouts. It only modifies the feed
+    with -annotate, which stores each result in the link's last_check (the 10 before it move to check_history)
+    and counts failed checks in a row; -report writes the results as JSON. -only-stale 30d (or 2w, 12h) checks
+    only links not checked that recently and implies -annotate. "list -broken" shows links whose last check
+    failed, -broken=3 those that failed the last 3 in a row.
+  • -ci (validate, check, diff) prints GitHub Actions annotations on the feed file (::error for problems and
+    broken links; ::notice, or ::warning for removals, for diff changes) and then a one-line JSON summary,
+    also set as the step output "summary". Exit codes are unchanged: add -fail-on-error or -exit-code.
+`,
+	"merge": `usage: linkleaf merge -out <merged.pb> [-interactive] [-resolve-file FILE] <a.pb> <b.pb>... [save flags]
+
+notes:
+  • "merge" unions feeds; a link present in several (same ID or normalized URL) is taken from the feed with the
+    newest generated_at, and the result is ordered newest added_at first.
+  • merge -interactive and sync -interactive show each conflict field by field (for merge, a link whose
+    copies differ between feeds) and ask which side to keep, or open it in $EDITOR to combine them. With
+    -resolve-file the decisions are recorded there as JSON and replayed on later runs without asking; each
+    applies only to the same pair of versions, so a link changed again is asked about again.
+`,
+	"split": `usage: linkleaf split -file <file.pb> -out <part.pb> [-title T] [-remove] [filter flags] [save flags]
+
+notes:
+  • "split" copies the links matching its filter flags (at least one) into -out, created with -title (by
+    default the -tag) or added to; links already there are skipped. -remove moves them: -out is saved first,
+    then they are removed from -file.
+`,
+	"sync": `usage: linkleaf sync -local <file.pb> -remote <URL|file.pb> [-strategy union|ours|theirs] [-base FILE] [-interactive] [-resolve-file FILE] [save flags]
+
+notes:
+  • "sync" three-way merges local and remote against <local>.sync-base (the last synced state) and writes
+    the result to both. Links changed differently on both sides are conflicts: union (default) reports them and
+    writes nothing; ours/theirs pick a side. A link deleted on one side but modified on the other is kept.
+  • merge -interactive and sync -interactive show each conflict field by field (for merge, a link whose
+    copies differ between feeds) and ask which side to keep, or open it in $EDITOR to combine them. With
+    -resolve-file the decisions are recorded there as JSON and replayed on later runs without asking; each
+    applies only to the same pair of versions, so a link changed again is asked about again.
+`,
+	"diff": `usage: linkleaf diff <old.pb> <new.pb> [-format text|json|ci | -json | -ci] [-exit-code]
+
+notes:
+  • "diff" matches links by ID and lists changed fields; -exit-code exits 1 when the feeds differ.
+  • -ci (validate, check, diff) prints GitHub Actions annotations on the feed file (::error for problems and
+    broken links; ::notice, or ::warning for removals, for diff changes) and then a one-line JSON summary,
+    also set as the step output "summary". Exit codes are unchanged: add -fail-on-error or -exit-code.
+`,
+	"edit": `usage: linkleaf edit -file <file.pb> -id ID [-title "..."] [-url "..."] [-date DATE] [-summary "..."] [-via URL]
+                     [-author NAME] [-slug SLUG] [-lang L] [-tags a,b,c] [-tag t]... [-normalize-tags] [-meta key=value]...
+                     [-enclosure URL] [-enclosure-type MIME] [-enclosure-length BYTES]
+                     [-draft[=false]] [-publish-at TIME] [save flags]
+
+notes:
+  • "edit" changes only the fields given (-tags/-tag replace all tags); ID and position are kept.
+  • -meta key=value (add, edit; repeatable) stores custom data on a link, such as rating=5 or project=x;
+    keys can't contain whitespace or '='. "edit -meta key=" deletes a key. print and list show it and search
+    finds it with meta:key=value.
+  • -enclosure attaches a media file to a link (a podcast episode, a video) for podcast clients: RSS gets an
+    <enclosure>, Atom a rel="enclosure" link and JSON Feed an attachment. -enclosure-type defaults to the type
+    its extension implies (.mp3 is audio/mpeg); -enclosure-length is its size in bytes, 0 if unknown.
+    "edit -enclosure ''" removes it.
+  • -draft (add, edit) keeps a link out of html, rss, atom, jsonfeed, markdown, hugo, jekyll and custom exports,
+    "build", the "serve" pages and feeds and ActivityPub until "publish -id X" releases it; -publish-at TIME
+    (YYYY-MM-DD or RFC 3339) does the same until that time. "publish" then crossposts as usual, unless no
+    service is configured or -to none. The csv, jsonl, textproto and json exports and /raw.pb keep every link;
+    -drafts previews the others with drafts in. Static exports and builds need re-running after publish_at.
+  • add/edit -date also take a time, with a zone or in local time: "2024-05-01T18:30", "2024-05-01 18:30:05",
+    "2024-05-01T18:30+02:00"; such dates are stored in RFC 3339. Filters, queries and periods compare the
+    date's day. RSS/Atom/JSON Feed publish times and "-sort date" use the time, else added_at when it falls
+    on the date (so links of the same day keep the order they were added in), else midnight UTC.
+`,
+	"bulk-edit": `usage: linkleaf bulk-edit -file <file.pb> [-match QUERY] [filter flags] [save flags]
+
+notes:
+  • "bulk-edit" opens the links matching -match and the filter flags (all of them without any) in $EDITOR
+    as one textproto and saves every change in one go. Edited links are checked as "edit" checks them; on
+    an error it offers to edit again. IDs stay and links can't be added or removed there, and a link
+    changed by something else while the editor is open stops the save.
+`,
+	"reid": `usage: linkleaf reid -file <file.pb> [-id-scheme S] [-match QUERY] [filter flags] [save flags]
+
+notes:
+  • "reid" gives the links matching -match and the filter flags (all without any) new IDs from -id-scheme and
+    rewrites the related_ids and trash entries that pointed to the old ones, printing each old -> new ID.
+    Anything outside the feed that names links by ID (permalinks, #id anchors, saved snapshot file names)
+    keeps the old ones.
+`,
+	"remove": `usage: linkleaf remove -file <file.pb> (-id ID | -url URL) [-permanent] [save flags]
+
+notes:
+  • "remove" (and deleting in tui, serve's API and gRPC) moves links to the feed's trash, kept in the file
+    with when and where they were removed; -permanent deletes them for good. "trash list" shows the trash,
+    "trash restore ID" puts a link back in its old place with its relations, and "trash purge" deletes the
+    named links, -all, or by default those older than trash.retention in the config (default 30d; forever
+    keeps them). remove and restore purge the expired links on the way.
+`,
+	"trash": `usage: linkleaf trash [list] -file <file.pb>
+       linkleaf trash restore -file <file.pb> (ID... | -all) [save flags]
+       linkleaf trash purge -file <file.pb> [ID... | -all] [save flags]
+
+notes:
+  • "remove" (and deleting in tui, serve's API and gRPC) moves links to the feed's trash, kept in the file
+    with when and where they were removed; -permanent deletes them for good. "trash list" shows the trash,
+    "trash restore ID" puts a link back in its old place with its relations, and "trash purge" deletes the
+    named links, -all, or by default those older than trash.retention in the config (default 30d; forever
+    keeps them). remove and restore purge the expired links on the way.
+`,
+	"dedupe": `usage: linkleaf dedupe -file <file.pb> [-keep newest|oldest] [save flags]
+
+notes:
+  • "dedupe" collapses links with the same normalized URL into one, merging their tags.
+`,
+	"mark": `usage: linkleaf mark -file <file.pb> -id ID [-read[=false]] [-starred[=false]] [-archived[=false]] [save flags]
+
+notes:
+  • "mark" sets a link's read, starred and archived flags (-read=false etc. clears them); -unread and -starred
+    filter on them, e.g. "list -unread" for a read-later queue.
+`,
+	"open": `usage: linkleaf open -file <file.pb> (ID | N | -random) [-mark-read] [filter flags] [save flags]
+
+notes:
+  • "open" opens a link in the default browser, by ID or by its number as "list" shows it with the same filter
+    flags; -random picks one of the selected links, and -mark-read marks it read, to work down the queue.
+`,
+	"qr": `usage: linkleaf qr -file <file.pb> -id ID [-out FILE.png|- [-scale 8]] [-level L|M|Q|H]
+
+notes:
+  • "qr" draws a link's URL as a QR code in the terminal, to scan it over to a phone, or with -out writes it
+    as a PNG (-scale pixels per module). -level trades density for error correction (default M).
+`,
+	"note": `usage: linkleaf note -file <file.pb> -id ID [-m TEXT] [save flags]
+
+notes:
+  • "note" opens the link's notes in $VISUAL/$EDITOR (-m sets them directly); blank lines separate
+    paragraphs. Notes show up in print, markdown export (as a blockquote) and HTML pages.
+`,
+	"relate": `usage: linkleaf relate -file <file.pb> -id ID -to ID... [-both] [-remove] [save flags]
+
+notes:
+  • "relate -id A -to B" records in A that B is related (a follow-up, the next part of a series); -to is
+    repeatable, -both also relates B to A and -remove drops the relations. print and HTML pages show a
+    "related" section; removing a link drops references to it, and validate reports any left dangling.
+`,
+	"quote": `usage: linkleaf quote add -file <file.pb> -id ID [TEXT | -] [save flags]
+       linkleaf quote [list] -file <file.pb> -id ID
+       linkleaf quote rm -file <file.pb> -id ID N... [save flags]
+
+notes:
+  • "quote add" keeps a passage from the linked page with the link (TEXT, - for stdin, else $EDITOR); a link
+    collects any number, in order, for a commonplace book. "quote list" numbers them for "quote rm". Quotes
+    show up in print, as blockquotes in markdown, hugo and jekyll exports, HTML pages and e-mail digests.
+`,
+	"archive": `usage: linkleaf archive -file <file.pb> (-id ID | -all) [-to wayback|local] [-dir DIR] [-force] [-timeout 1m]
+                        [save flags]
+
+notes:
+  • "archive" has web.archive.org capture the page (-to wayback) or saves a copy without scripts to
+    <dir>/<id>.html (-to local), and stores where in the link's archive_url; HTML and markdown exports link
+    to it. -all skips links already archived unless -force; it exits 1 if any link failed. Unrelated to
+    "mark -archived", which only flags a link as no longer current.
+`,
+	"save": `usage: linkleaf save -file <file.pb> (-id ID | -all) [-dir DIR] [-force] [-timeout 30s] [save flags]
+
+notes:
+  • "save" keeps a readable copy of the page for offline reading: it picks out the article (dropping
+    navigation, sidebars, comments and scripts), writes it as plain text to <dir>/<id>.txt and stores the path
+    in the link's article_path (and its word count, if it has none). -all saves the unread links that have no
+    copy yet. "read" shows the copy wrapped to the terminal (-width) and through $PAGER (default less, unless
+    -no-pager or stdout isn't a terminal); -mark-read marks the link read afterwards.
+`,
+	"read": `usage: linkleaf read -file <file.pb> -id ID [-width N] [-no-pager] [-mark-read] [save flags]
+
+notes:
+  • "save" keeps a readable copy of the page for offline reading: it picks out the article (dropping
+    navigation, sidebars, comments and scripts), writes it as plain text to <dir>/<id>.txt and stores the path
+    in the link's article_path (and its word count, if it has none). -all saves the unread links that have no
+    copy yet. "read" shows the copy wrapped to the terminal (-width) and through $PAGER (default less, unless
+    -no-pager or stdout isn't a terminal); -mark-read marks the link read afterwards.
+`,
+	"refresh": `usage: linkleaf refresh -file <file.pb> [-ids ID,ID] [-only-empty] [-concurrency 4] [-per-host 1s] [-timeout 10s]
+                        [-user-agent UA] [filter flags] [save flags]
+
+notes:
+  • "refresh" fetches the pages of the links the filter flags and -ids pick again (-concurrency at a time, one
+    request per host every -per-host) and updates titles, summaries, languages and word counts that are empty
+    or no longer match the page's, printing each change as "diff" does; -only-empty keeps those already set, -dry-run
+    only prints. It exits 1 if any page couldn't be fetched.
+`,
+	"move": `usage: linkleaf move <file.pb> -id ID -to N|top|bottom [save flags]
+`,
+	"prune": `usage: linkleaf prune <file.pb> [-keep N] [-before YYYY-MM-DD] [save flags]
+`,
+	"migrate": `usage: linkleaf migrate <file.pb> [-out FILE] [save flags]
+       linkleaf migrate v1-to-v2 <in.pb> <out.pb>
+       linkleaf migrate v2-to-v1 <in.pb> <out.pb> [save flags]
+
+notes:
+  • Older feed versions are upgraded in memory on load (-no-migrate disables); "migrate" saves the upgrade,
+    or with -out writes it to a new file and leaves the original alone. Version 4 adds updated_at, notes, read
+    and archived to links; every save stamps updated_at on the links it changed. Version 5 adds starred,
+    version 6 archive_url, version 7 author (of the feed and of each link), version 8 related_ids, version 9
+    meta, version 10 enclosure, version 11 draft and publish_at, version 12 the failures count of last_check
+    and check_history, version 13 slug (filled in from each title), version 14 lang,
+    version 15 word_count and reading_minutes, version 16 article_path,
+    version 17 the feed's subscriptions, version 18 its description, home_page_url, icon and lang,
+    version 19 its trash of removed links, version 20 quotes.
+  • "migrate v1-to-v2" writes a feed in the linkleaf.v2 schema (proto/linkleaf/v2), where every time is a
+    google.protobuf.Timestamp and a link's date may carry a time of day and UTC offset; nothing is lost, and
+    "migrate v2-to-v1" converts it back. The other commands read linkleaf.v1 only. A time that isn't RFC 3339
+    UTC (fix it with "edit" first) stops the conversion; a date it can't parse is kept as text.
+`,
+	"convert": `usage: linkleaf convert <file.pb> -to pb|stream|sqlite|sharded [-out FILE] [save flags]
+
+notes:
+  • Stream feeds (.pbs, or any file "convert -to stream" wrote) store each link as its own length-delimited
+    record after a small header, so "add" appends instead of rewriting the file and "list -limit N" without
+    filters or -sort decodes only the newest N links. Every command reads and writes both formats and keeps a
+    file's format; new .pbs files start as streams. Encrypted or compressed feeds can't be streams.
+  • Compressed feeds (gzip or zstd, told apart by their first bytes) are read like plain ones and stay
+    compressed on save; new .pb.gz and .pb.zst files start compressed. "compact -to zstd|gzip" compresses a
+    feed (as does -out with a .gz or .zst name) and -to none decompresses it; without either it keeps the
+    compression and only folds in the write-ahead log. Encrypted feeds are compressed before
+    encryption and keep their compression only under a .gz or .zst name.
+  • SQLite feeds (.db, or "convert -to sqlite") hold one indexed row per link: saves write only the links that
+    changed, and list filters and the tag:/domain:/date terms of search are answered by queries instead of
+    reading every link. They must be local files and can't be encrypted or compressed; "convert -to pb"
+    exports one back to a .pb.
+  • Sharded feeds are a directory holding index.pb and one feed file per year of the links' dates (2024.pb,
+    undated.pb for the rest), made by "convert -to sharded -out DIR" or "init DIR/". Every command takes the
+    directory as the feed; saves rewrite only the years that changed plus the index, date filters read only
+    the matching years and -verify checks each shard against the checksum the index holds. Links are kept
+    newest year first, so "move" works within a year. They can't be encrypted or compressed, and "backup"
+    and "restore" work on single files (copy the directory instead); "sign" signs the index.
+`,
+	"compact": `usage: linkleaf compact <file.pb> [-to zstd|gzip|none] [-out FILE] [save flags]
+
+notes:
+  • Compressed feeds (gzip or zstd, told apart by their first bytes) are read like plain ones and stay
+    compressed on save; new .pb.gz and .pb.zst files start compressed. "compact -to zstd|gzip" compresses a
+    feed (as does -out with a .gz or .zst name) and -to none decompresses it; without either it keeps the
+    compression and only folds in the write-ahead log. Encrypted feeds are compressed before
+    encryption and keep their compression only under a .gz or .zst name.
+`,
+	"hash": `usage: linkleaf hash [<file.pb> | -file <file.pb>] [-json]
+
+notes:
+  • For reproducible bytes (e.g. committing .pb to git) use -deterministic -sort-ids -freeze-generated-at;
+    -canonical also writes every string in Unicode NFC and drops unknown fields, so equal content gives equal
+    bytes whatever wrote it. "hash" prints SHA-256 digests of the canonical form of the feed (without
+    generated_at) and of each link, stable across saves, tools and protobuf versions.
+`,
+	"backup": `usage: linkleaf backup [<file.pb> | -file <file.pb>] [-keep N] [-list [-json]]
+
+notes:
+  • "backup" copies the feed file byte for byte into .linkleaf/backups/ next to it, named
+    <file>.<UTC time>.<content hash>; an unchanged feed isn't copied twice and -keep N deletes all but the N
+    newest. "restore -snapshot HASH" (any unique prefix) checks the copy against its hash, snapshots the
+    current file and puts the copy back; without -snapshot it lists the snapshots.
+`,
+	"restore": `usage: linkleaf restore [<file.pb> | -file <file.pb>] [-snapshot HASH]
+
+notes:
+  • "backup" copies the feed file byte for byte into .linkleaf/backups/ next to it, named
+    <file>.<UTC time>.<content hash>; an unchanged feed isn't copied twice and -keep N deletes all but the N
+    newest. "restore -snapshot HASH" (any unique prefix) checks the copy against its hash, snapshots the
+    current file and puts the copy back; without -snapshot it lists the snapshots.
+`,
+	"log": `usage: linkleaf log -file <file.pb> [-limit N] [-json]
+
+notes:
+  • Every save appends what changed (links added, removed, modified; old values included) to <file>.journal.
+    "log" shows it newest first; "undo" reverts the newest entry and drops it. Encrypted feeds aren't journaled.
+`,
+	"undo": `usage: linkleaf undo -file <file.pb> [save flags]
+
+notes:
+  • Every save appends what changed (links added, removed, modified; old values included) to <file>.journal.
+    "log" shows it newest first; "undo" reverts the newest entry and drops it. Encrypted feeds aren't journaled.
+`,
+	"history": `usage: linkleaf history -file <file.pb> [-limit N]
+
+notes:
+  • -git-commit commits the saved file (and its .sha256) in its git repository with a message like
+    "add [id] title"; "history" lists the commits that touched the file (git log --follow).
+`,
+	"keygen": `usage: linkleaf keygen [-key key.pem] [-pub pub.pem] [-force]
+
+notes:
+  • "sign" writes a detached ed25519 signature of the file's bytes to <file>.sig; publish it with pub.pem and
+    re-sign after every save. "verify" exits 1 if the file doesn't match.
+`,
+	"sign": `usage: linkleaf sign -file <file.pb> -key key.pem [-sig FILE]
+
+notes:
+  • "sign" writes a detached ed25519 signature of the file's bytes to <file>.sig; publish it with pub.pem and
+    re-sign after every save. "verify" exits 1 if the file doesn't match.
+`,
+	"verify": `usage: linkleaf verify -file <file.pb> -pub pub.pem [-sig FILE]
+
+notes:
+  • "sign" writes a detached ed25519 signature of the file's bytes to <file>.sig; publish it with pub.pem and
+    re-sign after every save. "verify" exits 1 if the file doesn't match.
+`,
+}
+
+// commandUsage returns a FlagSet.Usage that prints help and then fs's flags.
+func commandUsage(fs *flag.FlagSet, help string) func() {
+	return func() {
+		fmt.Fprint(fs.Output(), help)
+		var hasFlags bool
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(fs.Output(), "\nflags:")
+			fs.PrintDefaults()
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// historyEntry is a page of a browser's history.
+type historyEntry struct {
+	url, title string
+	visits     int
+	last       time.Time // last visit
+}
+
+// chromeEpoch is Chrome's time 0, 1601-01-01, in Unix microseconds.
+const chromeEpoch = -11644473600_000000
+
+// historyQueries read the pages visited at least ? times, most recently
+// visited first, from each browser's history database.
+var historyQueries = map[string]string{
+	"chrome": `SELECT url, title, visit_count, last_visit_time FROM urls
+		WHERE visit_count >= ? AND hidden = 0 AND last_visit_time > 0 ORDER BY last_visit_time DESC`,
+	"firefox": `SELECT url, COALESCE(title, ''), visit_count, last_visit_date FROM moz_places
+		WHERE visit_count >= ? AND hidden = 0 AND last_visit_date > 0 ORDER BY last_visit_date DESC`,
+}
+
+// importHistory runs "import browser-history": pages from a Chrome (or
+// Chromium-based) or Firefox history, confirmed one by one unless -yes.
+func importHistory() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("import browser-history", flag.ExitOnError)
+	var file, browser, in, after, before string
+	var minVisits, limit int
+	var yes bool
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&browser, "browser", "", "chrome or firefox (default: guessed from -in)")
+	fs.StringVar(&in, "in", "", "history database: Chrome's History or Firefox's places.sqlite (default: the browser's default profile)")
+	fs.StringVar(&after, "after", "", "only pages last visited on/after YYYY-MM-DD")
+	fs.StringVar(&before, "before", "", "only pages last visited on/before YYYY-MM-DD")
+	fs.IntVar(&minVisits, "min-visits", 2, "only pages visited at least N times")
+	fs.IntVar(&limit, "limit", 0, "offer at most N pages, most recently visited first (0: all)")
+	fs.BoolVar(&yes, "yes", false, "import every selected page without asking")
+	tf := addTagFlags(fs)
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 || minVisits < 1 || limit < 0 {
+			badUsage(fs)
+		}
+		if browser == "" {
+			switch {
+			case in == "":
+				die(invalid(errors.New("-browser chrome|firefox or -in is required")))
+			case filepath.Base(in) == "places.sqlite":
+				browser = "firefox"
+			default:
+				browser = "chrome"
+			}
+		}
+		if _, ok := historyQueries[browser]; !ok {
+			die(invalid(fmt.Errorf("-browser: want chrome or firefox, got %q", browser)))
+		}
+		var from, to time.Time
+		var err error
+		if after != "" {
+			if from, err = feed.ParseDate(after); err != nil {
+				die(invalid(fmt.Errorf("-after: %w", err)))
+			}
+		}
+		if before != "" {
+			if to, err = feed.ParseDate(before); err != nil {
+				die(invalid(fmt.Errorf("-before: %w", err)))
+			}
+			to = to.AddDate(0, 0, 1)
+		}
+		tags, err := tf.tags()
+		if err != nil {
+			die(err)
+		}
+		if in == "" {
+			if in, err = historyPath(browser); err != nil {
+				die(err)
+			}
+		}
+
+		entries, err := readHistory(browser, in, minVisits)
+		if err != nil {
+			die(err)
+		}
+		var links []*v1.Link
+		for _, e := range entries {
+			if !from.IsZero() && e.last.Before(from) || !to.IsZero() && !e.last.Before(to) {
+				continue
+			}
+			l, err := savedLink(e.title, e.url, e.last, tags)
+			if err != nil || historyNoise(l.Url) {
+				continue // local files, browser pages, searches and logins
+			}
+			links = append(links, l)
+			if limit > 0 && len(links) == limit {
+				break
+			}
+		}
+		msg.Debugf("%s: %d pages visited %d+ times, %d candidates", in, len(entries), minVisits, len(links))
+		if len(links) == 0 {
+			msg.Infof("no pages in %s match", in)
+			return
+		}
+		if !yes {
+			visits := map[string]int{}
+			for _, e := range entries {
+				visits[e.url] = e.visits
+			}
+			if links, err = confirmLinks(newPrompter(os.Stdin, os.Stderr), links, visits); err != nil {
+				if errors.Is(err, errAborted) {
+					fmt.Fprintln(os.Stderr, "aborted; nothing written")
+					os.Exit(1)
+				}
+				die(err)
+			}
+		}
+
+		sf.lock(file)
+		opened, err := feed.OpenWith(file, loadOpts) // a missing file starts a new feed
+		if err != nil {
+			die(fmt.Errorf("load %s: %w", file, err))
+		}
+		f := opened.Feed
+		sf.loaded(f)
+		added, dupes := importLinks(f, links, false)
+		if added > 0 {
+			if err := sf.save(file, f); err != nil {
+				die(err)
+			}
+		}
+		msg.Infof("imported %d links into %s (%d already present)", added, file, dupes)
+	}
+}
+
+// confirmLinks asks about each link on p: y takes it, n skips it, a takes
+// it and every one after, q stops and keeps those taken so far.
+func confirmLinks(p *prompter, links []*v1.Link, visits map[string]int) ([]*v1.Link, error) {
+	var keep []*v1.Link
+	for i, l := range links {
+		fmt.Fprintf(p.w, "\n%d/%d  %s\n       %s  (%d visits, last %s)\n", i+1, len(links), l.Title, l.Url, visits[l.Url], l.Date)
+		for {
+			answer, err := p.ask("Import? y/n/a/q", "n")
+			if err != nil {
+				return nil, err
+			}
+			switch strings.ToLower(answer) {
+			case "y", "yes":
+				keep = append(keep, l)
+			case "n", "no":
+			case "a", "all":
+				return append(keep, links[i:]...), nil
+			case "q", "quit":
+				return keep, nil
+			default:
+				fmt.Fprintln(p.w, "answer y (import), n (skip), a (import this and the rest) or q (stop)")
+				continue
+			}
+			break
+		}
+	}
+	return keep, nil
+}
+
+// historyNoise reports pages not worth offering: searches, logins and
+// pages on the local machine.
+func historyNoise(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return true
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	if host == "localhost" || strings.HasSuffix(host, ".local") || strings.HasPrefix(host, "127.") {
+		return true
+	}
+	path := strings.ToLower(u.Path)
+	for _, p := range []string{"/search", "/login", "/signin", "/sign-in", "/logout", "/oauth", "/auth"} {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// readHistory reads the entries of a history database visited at least
+// minVisits times. Browsers keep it locked while running, so it is read
+// from a copy (with Firefox's write-ahead log, which holds the newest
+// visits).
+func readHistory(browser, path string, minVisits int) ([]historyEntry, error) {
+	dir, err := os.MkdirTemp("", "linkleaf-history-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	cp := filepath.Join(dir, filepath.Base(path))
+	if err := copyFile(path, cp); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	if err := copyFile(path+"-wal", cp+"-wal"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", cp)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(historyQueries[browser], minVisits)
+	if err != nil {
+		return nil, fmt.Errorf("%s: not a %s history database? %w", path, browser, err)
+	}
+	defer rows.Close()
+	var out []historyEntry
+	for rows.Next() {
+		var e historyEntry
+		var micros int64
+		if err := rows.Scan(&e.url, &e.title, &e.visits, &micros); err != nil {
+			return nil, err
+		}
+		if browser == "chrome" {
+			micros += chromeEpoch
+		}
+		e.last = time.UnixMicro(micros)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// statOK reports whether path exists.
+func statOK(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// historyPath finds the history database of browser's default profile:
+// Chrome's Default/History, or the places.sqlite of the Firefox profile
+// used last.
+func historyPath(browser string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if browser == "chrome" {
+		var dirs []string
+		switch runtime.GOOS {
+		case "darwin":
+			dirs = []string{filepath.Join(home, "Library/Application Support/Google/Chrome"), filepath.Join(home, "Library/Application Support/Chromium")}
+		case "windows":
+			local := os.Getenv("LOCALAPPDATA")
+			dirs = []string{filepath.Join(local, `Google\Chrome\User Data`), filepath.Join(local, `Chromium\User Data`)}
+		default:
+			dirs = []string{filepath.Join(home, ".config/google-chrome"), filepath.Join(home, ".config/chromium")}
+		}
+		for _, d := range dirs {
+			if p := filepath.Join(d, "Default", "History"); statOK(p) {
+				return p, nil
+			}
+		}
+		return "", fmt.Errorf("no Chrome history under %s; pass -in", strings.Join(dirs, " or "))
+	}
+
+	var dir string
+	switch runtime.GOOS {
+	case "darwin":
+		dir = filepath.Join(home, "Library/Application Support/Firefox/Profiles")
+	case "windows":
+		dir = filepath.Join(os.Getenv("APPDATA"), `Mozilla\Firefox\Profiles`)
+	default:
+		dir = filepath.Join(home, ".mozilla/firefox")
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", "places.sqlite"))
+	var newest string
+	var newestMod time.Time
+	for _, m := range matches {
+		if fi, err := os.Stat(m); err == nil && fi.ModTime().After(newestMod) {
+			newest, newestMod = m, fi.ModTime()
+		}
+	}
+	if newest == "" {
+		return "", fmt.Errorf("no Firefox profile under %s; pass -in", dir)
+	}
+	return newest, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// errHookFailed marks a save a pre-* hook refused.
+var errHookFailed = errors.New("hook failed")
+
+// hookKind is the hook family a save runs, by the op that saves
+// (capture, and gRPC/REST adds, add links too); "" runs none.
+func hookKind(op string) string {
+	switch op {
+	case "add", "capture":
+		return "add"
+	case "edit", "remove":
+		return op
+	case "bulk-edit":
+		return "edit"
+	}
+	return ""
+}
+
+// hookCommand returns the config's hooks.<stage>-<kind> command.
+func hookCommand(stage, kind string) string {
+	h := cfg.Hooks
+	return map[string]string{
+		"pre-add": h.PreAdd, "post-add": h.PostAdd,
+		"pre-edit": h.PreEdit, "post-edit": h.PostEdit,
+		"pre-remove": h.PreRemove, "post-remove": h.PostRemove,
+	}[stage+"-"+kind]
+}
+
+// runHooks runs the hook for stage ("pre" or "post") once for every link
+// the save op made from before to after added, edited or removed, per
+// hookKind: the new link for add and edit, the old one for remove.
+func runHooks(stage, op, path string, before, after *v1.Feed) error {
+	kind := hookKind(op)
+	command := hookCommand(stage, kind)
+	if command == "" {
+		return nil
+	}
+	d := feed.Compare(before, after)
+	var links []*v1.Link
+	switch kind {
+	case "add":
+		links = d.Added
+	case "edit":
+		for _, c := range d.Modified {
+			links = append(links, c.New)
+		}
+	case "remove":
+		links = d.Removed
+	}
+	for _, l := range links {
+		if err := runHook(stage+"-"+kind, command, path, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runHook runs command with the shell, l as protojson on stdin and
+// LINKLEAF_HOOK, LINKLEAF_FEED and LINKLEAF_LINK_ID set. Its output goes to
+// stderr, leaving stdout to the command that saves.
+func runHook(name, command, path string, l *v1.Link) error {
+	b, err := protojson.Marshal(l)
+	if err != nil {
+		return err
+	}
+	sh, c := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		sh, c = "cmd", "/C"
+	}
+	cmd := exec.Command(sh, c, command)
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	cmd.Env = append(os.Environ(), "LINKLEAF_HOOK="+name, "LINKLEAF_FEED="+path, "LINKLEAF_LINK_ID="+l.Id)
+	msg.Debugf("hook %s [%s]: %s", name, l.Id, command)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s [%s]: %v", errHookFailed, name, l.Id, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"sync/atomic"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/assets"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// imageFlags are the -images flags of build and serve.
+type imageFlags struct {
+	enabled bool
+	maxSize int64
+	maxAge  ageFlag
+}
+
+func addImageFlags(fs *flag.FlagSet) *imageFlags {
+	imf := &imageFlags{maxAge: ageFlag(30 * 24 * time.Hour)}
+	fs.BoolVar(&imf.enabled, "images", false, "show each link's site icon and preview image (og:image), cached in an assets directory")
+	fs.Int64Var(&imf.maxSize, "images-max-size", 1<<20, "-images: largest image kept, in bytes")
+	fs.Var(&imf.maxAge, "images-max-age", "-images: fetch images, and look again for missing ones, after this `age`")
+	return imf
+}
+
+// open opens the assets cache in dir, or returns nil without -images.
+func (imf *imageFlags) open(dir string) *assets.Cache {
+	if !imf.enabled {
+		return nil
+	}
+	c, err := assets.Open(dir, assets.Options{MaxSize: imf.maxSize, MaxAge: time.Duration(imf.maxAge)})
+	if err != nil {
+		die(err)
+	}
+	return c
+}
+
+// pageImages returns the cached images of links, as hrefs under prefix,
+// for htmlPage.Images.
+func pageImages(c *assets.Cache, links []*v1.Link, prefix string) map[string]assets.Images {
+	out := map[string]assets.Images{}
+	for _, l := range links {
+		im := c.Lookup(l)
+		if im.Icon != "" {
+			im.Icon = prefix + im.Icon
+		}
+		if im.Image != "" {
+			im.Image = prefix + im.Image
+		}
+		if im != (assets.Images{}) {
+			out[l.Id] = im
+		}
+	}
+	return out
+}
+
+// imageUpdater fetches serve's images in the background, so pages render
+// with what is cached and pick up the rest on a later request.
+type imageUpdater struct {
+	cache   *assets.Cache
+	dir     string
+	running atomic.Bool
+}
+
+// update starts fetching the images of links unless it is already at it.
+func (u *imageUpdater) update(links []*v1.Link) {
+	if !u.running.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer u.running.Store(false)
+		if n, _ := u.cache.Update(context.Background(), links); n > 0 {
+			msg.Debugf("fetched images for %d links", n)
+		}
+	}()
+}
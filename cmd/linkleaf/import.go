@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// importFormats may also be given as the first argument ("import bookmarks ...").
+var importFormats = []string{"csv", "tsv", "bookmarks", "rss", "textproto", "json", "pocket", "pinboard", "raindrop", "shaarli", "wallabag"}
+
+func cmdImport(format string) (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	var file, in string
+	fs.StringVar(&format, "format", format, "input format: "+strings.Join(importFormats, ", "))
+	fs.StringVar(&file, "file", "", "protobuf feed file (.pb), instead of the positional argument")
+	fs.StringVar(&in, "in", "", "input file (default: stdin)")
+	var url, colMap string
+	fs.StringVar(&url, "url", "", "rss: fetch the feed from this URL instead of -in")
+	fs.StringVar(&colMap, "map", "", "csv/tsv: columns by number or header name, e.g. url=1,title=2,date=3,tags=4")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, file)
+		if !ok {
+			badUsage(fs)
+		}
+
+		csvOpts := csvOptions{}
+		if format == "tsv" {
+			csvOpts.comma = '\t'
+		}
+		if colMap != "" {
+			if format != "csv" && format != "tsv" {
+				die(invalid(fmt.Errorf("-map applies to csv and tsv, not %s", format)))
+			}
+			var err error
+			if csvOpts.columns, err = parseColumnMap(colMap); err != nil {
+				die(err)
+			}
+		}
+
+		var r io.Reader = os.Stdin
+		if url != "" {
+			if in != "" {
+				die(invalid(errors.New("-in and -url are mutually exclusive")))
+			}
+			body, err := fetchBody(url)
+			if err != nil {
+				die(err)
+			}
+			defer body.Close()
+			r = body
+		} else if in != "" && in != "-" {
+			file, err := os.Open(in)
+			if err != nil {
+				die(err)
+			}
+			defer file.Close()
+			r = file
+		}
+
+		var links []*v1.Link
+		var warnings []lineWarning
+		var whole *v1.Feed // textproto and json hold a whole feed
+		var err error
+		switch format {
+		case "textproto", "json":
+			if whole, err = readFeedText(r, format); err == nil {
+				links = whole.Links
+			}
+		case "csv", "tsv", "bookmarks", "rss", "pocket", "pinboard", "raindrop", "shaarli", "wallabag":
+			read := func(r io.Reader) ([]*v1.Link, []lineWarning, error) { return readCSV(r, csvOpts) }
+			switch format {
+			case "bookmarks":
+				read = readBookmarks
+			case "rss":
+				read = readFeedXML
+			case "pocket":
+				read = readPocket
+			case "pinboard":
+				read = readPinboard
+			case "raindrop":
+				read = readRaindrop
+			case "shaarli":
+				read = readShaarli
+			case "wallabag":
+				read = readWallabag
+			}
+			links, warnings, err = read(r)
+			for _, w := range warnings {
+				fmt.Fprintf(os.Stderr, "warning: %v; skipped\n", w)
+			}
+		default:
+			err = fmt.Errorf("unknown import format %q", format)
+		}
+		if err != nil {
+			die(err)
+		}
+
+		sf.lock(path)
+		opened, err := feed.OpenWith(path, loadOpts) // a missing file starts a new feed
+		if err != nil {
+			die(fmt.Errorf("load %s: %w", path, err))
+		}
+		f := opened.Feed
+		sf.loaded(f)
+
+		if whole != nil && len(f.Links) == 0 {
+			// Into an empty feed, take the whole feed as it is: the exact
+			// reverse of "export textproto" or "export json".
+			if err := sf.save(path, whole); err != nil {
+				die(err)
+			}
+			msg.Infof("imported %d links into %s (the whole feed)", len(whole.Links), path)
+			return
+		}
+		added, dupes := importLinks(f, links, false)
+		if added > 0 {
+			if err := sf.save(path, f); err != nil {
+				die(err)
+			}
+		}
+		msg.Infof("imported %d links into %s (%d already present, %d invalid)", added, path, dupes, len(warnings))
+	}
+}
+
+// fetchBody GETs url for import -url; the caller closes the body.
+func fetchBody(url string) (io.ReadCloser, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetch %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// importLinks prepends links to f as a block, keeping their input order.
+// Links whose ID is taken, or (unless force) whose normalized URL is
+// already in the feed or earlier in links, are skipped; an ID that isn't
+// valid is replaced by one from the URL and date. It returns added and
+// skipped counts.
+func importLinks(f *v1.Feed, links []*v1.Link, force bool) (added, dupes int) {
+	seen := map[string]bool{}
+	if !force {
+		for _, l := range f.Links {
+			seen[feed.NormalizeURL(l.Url)] = true
+		}
+	}
+	var fresh []*v1.Link
+	for _, l := range links {
+		if err := feed.ValidateID(l.Id); err != nil && l.Id != "" {
+			l.Id = feed.LinkID(l.Url, l.Date)
+			fmt.Fprintf(os.Stderr, "warning: %s: %v; it gets %s\n", l.Url, err, l.Id)
+		}
+		norm := feed.NormalizeURL(l.Url)
+		if seen[norm] || feed.Index(f, l.Id) >= 0 || slices.ContainsFunc(fresh, func(o *v1.Link) bool { return o.Id == l.Id }) {
+			dupes++
+			continue
+		}
+		if !force {
+			seen[norm] = true
+		}
+		fresh = append(fresh, l)
+	}
+	for i := len(fresh) - 1; i >= 0; i-- {
+		feed.AddLink(f, fresh[i])
+	}
+	return len(fresh), dupes
+}
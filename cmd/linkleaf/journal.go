@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+func cmdLog() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("log", flag.ExitOnError)
+	var file string
+	var limit int
+	var asJSON bool
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.IntVar(&limit, "limit", 0, "show at most N entries (0 = all)")
+	fs.BoolVar(&asJSON, "json", false, "print the entries as JSON")
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		entries, err := feed.ReadJournal(file)
+		if err != nil {
+			die(err)
+		}
+		slices.Reverse(entries)
+		if limit > 0 && len(entries) > limit {
+			entries = entries[:limit]
+		}
+
+		if asJSON {
+			if entries == nil {
+				entries = []*feed.JournalEntry{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(entries); err != nil {
+				die(err)
+			}
+			return
+		}
+		if len(entries) == 0 {
+			msg.Infof("no journal for %s", file)
+			return
+		}
+		for _, e := range entries {
+			fmt.Printf("%s  %-10s +%d -%d ~%d\n", e.Time, e.Op, len(e.Added), len(e.Removed), len(e.Modified))
+			for _, l := range e.Added {
+				fmt.Printf("    + [%s] %s\n", l.Id, l.Title)
+			}
+			for _, r := range e.Removed {
+				fmt.Printf("    - [%s] %s\n", r.Link.Id, r.Link.Title)
+			}
+			for _, c := range e.Modified {
+				fmt.Printf("    ~ [%s] %s\n", c.New.Id, c.New.Title)
+			}
+			if e.Order != nil {
+				fmt.Println("    (reordered)")
+			}
+			if e.TitleBefore != nil {
+				fmt.Printf("    title was %q\n", *e.TitleBefore)
+			}
+			if e.VersionBefore != nil {
+				fmt.Printf("    version was %d\n", *e.VersionBefore)
+			}
+		}
+	}
+}
+
+func cmdUndo() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("undo", flag.ExitOnError)
+	var file string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		sf.noJournal = true
+
+		sf.lock(file)
+		entries, err := feed.ReadJournal(file)
+		if err != nil {
+			die(err)
+		}
+		if len(entries) == 0 {
+			die(notFound(fmt.Errorf("nothing to undo: %s has no journal", file)))
+		}
+		last := entries[len(entries)-1]
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		if err := feed.Undo(f, last); err != nil {
+			if errors.Is(err, feed.ErrJournalMismatch) {
+				err = fmt.Errorf("%w; not undoing %q from %s", err, last.Op, last.Time)
+			}
+			die(err)
+		}
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		if !sf.dryRun {
+			if err := feed.DropLastJournalEntry(file); err != nil {
+				die(err)
+			}
+		}
+		msg.Infof("undid %q from %s (+%d -%d ~%d)", last.Op, last.Time, len(last.Added), len(last.Removed), len(last.Modified))
+	}
+}
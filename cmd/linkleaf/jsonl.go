@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// renderJSONL writes one protojson Link object per line. With header set,
+// the first line is the Feed message without its links.
+func renderJSONL(f *v1.Feed, header bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if header {
+		links := f.Links
+		f.Links = nil
+		meta := proto.Clone(f)
+		f.Links = links
+		if err := writeJSONLine(&buf, meta); err != nil {
+			return nil, err
+		}
+	}
+	for _, l := range f.Links {
+		if err := writeJSONLine(&buf, l); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSONLine appends m as one compact JSON line. protojson doesn't
+// promise stable whitespace, so the output is re-compacted.
+func writeJSONLine(buf *bytes.Buffer, m proto.Message) error {
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshal json: %w", err)
+	}
+	if err := json.Compact(buf, b); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+// jsonFlags switch list and print to machine-readable output.
+type jsonFlags struct {
+	json, jsonl bool
+}
+
+func addJSONFlags(fs *flag.FlagSet) *jsonFlags {
+	jf := &jsonFlags{}
+	fs.BoolVar(&jf.json, "json", false, "print the feed as protojson")
+	fs.BoolVar(&jf.jsonl, "jsonl", false, "print one protojson link per line")
+	return jf
+}
+
+// enabled reports whether JSON output was requested.
+func (jf *jsonFlags) enabled() bool { return jf.json || jf.jsonl }
+
+// write prints f to stdout in the requested form.
+func (jf *jsonFlags) write(f *v1.Feed) error {
+	var b []byte
+	var err error
+	switch {
+	case jf.json && jf.jsonl:
+		return errors.New("-json and -jsonl are mutually exclusive")
+	case jf.jsonl:
+		b, err = renderJSONL(f, false)
+	default:
+		b, err = renderJSON(f)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(b)
+	return err
+}
+
+// renderJSON encodes f as an indented protojson Feed.
+func renderJSON(f *v1.Feed) ([]byte, error) {
+	b, err := protojson.Marshal(f)
+	if err != nil {
+		return nil, fmt.Errorf("marshal json: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
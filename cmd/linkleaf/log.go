@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+// logger routes the CLI's non-data output: status lines ("added …") go to
+// stdout unless -quiet, debug lines go to stderr only under -verbose.
+// Command results (list, print, export to stdout, …) bypass it.
+type logger struct {
+	quiet, verbose bool
+	prefix         string // prepended to status lines, e.g. "dry run: "
+	out, err       io.Writer
+	debug          *slog.Logger
+}
+
+var msg = &logger{out: os.Stdout, err: os.Stderr, debug: feed.Logger}
+
+// Infof prints a status line; -quiet suppresses it.
+func (l *logger) Infof(format string, args ...any) {
+	if !l.quiet {
+		fmt.Fprintf(l.out, l.prefix+format+"\n", args...)
+	}
+}
+
+// Debugf logs a diagnostic line to stderr under -verbose.
+func (l *logger) Debugf(format string, args ...any) {
+	l.debug.Debug(fmt.Sprintf(format, args...))
+}
+
+// setup applies the global flags; -verbose also turns on the feed
+// library's debug events (paths, byte counts, timings).
+func (l *logger) setup() {
+	if l.verbose {
+		l.quiet = false
+		l.debug = slog.New(slog.NewTextHandler(l.err, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		feed.Logger = l.debug
+	}
+}
@@ -1,143 +1,708 @@
 package main
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/doriancodes/linkleaf-cli/pkg/crosspost"
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/pagemeta"
 	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
 	"google.golang.org/protobuf/proto"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		usage()
-		os.Exit(2)
+	gfs := flag.NewFlagSet("linkleaf", flag.ExitOnError)
+	gfs.BoolVar(&msg.quiet, "quiet", false, "suppress non-error status output")
+	gfs.BoolVar(&msg.verbose, "verbose", false, "log paths, sizes and timings to stderr")
+	gfs.BoolVar(&porcelain, "porcelain", false, "for scripts: no status output, errors as one line \"error<TAB>kind<TAB>message\"")
+	gfs.BoolVar(&jsonErrors, "json", false, "report errors on stderr as JSON: {\"error\", \"kind\", \"exit_code\"}")
+	gfs.BoolVar(&loadOpts.NoMigrate, "no-migrate", false, "don't upgrade older feed versions on load")
+	gfs.BoolVar(&loadOpts.Verify, "verify", false, "check feed files against their .sha256 sidecar on load")
+	gfs.BoolVar(&encrypt, "encrypt", false, "encrypt feed files on save (key from LINKLEAF_KEY or -key-file)")
+	gfs.StringVar(&keyFile, "key-file", "", "file holding the passphrase for encrypted feeds")
+	gfs.StringVar(&feedName, "feed", "", "use the feed with this name from the config's [feeds]")
+	gfs.Usage = usage
+	gfs.Parse(os.Args[1:])
+	msg.quiet = msg.quiet || porcelain
+	msg.setup()
+	if err := setupKey(); err != nil {
+		die(err)
+	}
+	if err := loadConfig(); err != nil {
+		if gfs.Arg(0) != "doctor" {
+			die(err)
+		}
+		configErr = err
 	}
-	switch os.Args[1] {
-	case "init":
-		cmdInit(os.Args[2:])
-	case "add":
-		cmdAdd(os.Args[2:])
-	case "list":
-		cmdList(os.Args[2:])
-	case "print":
-		cmdPrint(os.Args[2:])
-	default:
-		usage()
-		os.Exit(2)
+	if err := checkFeedName(); err != nil {
+		die(err)
+	}
+
+	args := gfs.Args()
+	if len(args) < 1 {
+		usageError(usage, errors.New("no command (see linkleaf -h)"))
 	}
+	run(args)
 }
 
 func usage() {
-	fmt.Fprintf(os.Stderr, `linkleaf – protobuf-only feed manager (linkleaf.v1)
+	fmt.Fprintf(os.Stderr, `linkleaf – link feed manager (linkleaf.v1)
 
 Usage:
-  linkleaf init  <file.pb> [-title "My Feed"] [-version 1]
-  linkleaf add   -file <file.pb> -title "..." -url "..." -date YYYY-MM-DD [-summary "..."] [-tags a,b,c] [-via URL] [-id ID]
-  linkleaf list  <file.pb>
-  linkleaf print <file.pb>
+  linkleaf [-quiet | -verbose] [-porcelain] [-json] [-no-migrate] [-verify] [-encrypt] [-key-file FILE] [-feed NAME]
+           <command> [args]
+
+  linkleaf init  <file.pb> [-title "My Feed"] [-author NAME] [-version 1] [save flags]
+  linkleaf meta  [-file <file.pb>] [show | get KEY | set KEY VALUE | unset KEY] [save flags]
+  linkleaf add   -file <file.pb> -title "..." -url "..." -date YYYY-MM-DD [-summary "..."] [-tags a,b,c] [-tag t]... [-normalize-tags]
+                 [-via URL] [-author NAME] [-id ID | -id-scheme urlhash|slug|uuid] [-force | -update-existing] [-no-validate]
+                 [-slug SLUG] [-lang L] [-meta key=value]... [-enclosure URL [-enclosure-type MIME] [-enclosure-length BYTES]]
+                 [-draft | -publish-at TIME] [-announce mastodon,bluesky|all] [-webmention] [-policy FILE] [save flags]
+  linkleaf add   -file <file.pb> -url "..." -date YYYY-MM-DD -fetch [-timeout 10s] [-user-agent UA] [any add flag]
+  linkleaf add   -file <file.pb> -interactive [any add flag to pre-fill]
+  linkleaf add   -file <file.pb> -e [any add flag to pre-fill]
+  linkleaf add   -file <file.pb> [any add flag] -
+  linkleaf add   -file <file.pb> -batch links.txt [-id-scheme S] [-normalize-tags] [-force] [-policy FILE] [save flags]
+  linkleaf list  <file.pb> [filter flags] [sort flags] [-offset N] [-limit N] [-broken[=N]]
+                 [-group-by tag|domain|day|week|month|year]
+                 [-json | -jsonl | -format T | -table [-columns index,id,date,title,domain,tags] [-no-color]]
+  linkleaf search -file <file.pb> [-fts [-no-color]] [-tags EXPR] [sort flags] [-json | -jsonl | -format T] "query"
+  linkleaf find  -file <file.pb> [-limit 10] [-min 0.4] [-json | -jsonl | -format T] "approximate title"
+  linkleaf print <file.pb> [-json | -jsonl]
+  linkleaf tui   -file <file.pb>
+  linkleaf export <file.pb> [-format html|csv|jsonl] [-out FILE] [-css style.css] [-template page.tmpl]
+                 [-header] [-drafts] [filter flags] [sort flags]
+  linkleaf export rss|atom|jsonfeed -file <file.pb> [-link https://example.com] [-site-title T]
+                 [-description D] [-feed-url URL] [-out FILE [-page-size N]] [-drafts] [filter flags] [sort flags]
+  linkleaf export markdown -file <file.pb> [-group-by none|day|week|month|year] [-out FILE] [-drafts]
+                 [filter flags] [sort flags]
+  linkleaf import <file.pb> [-format csv|tsv|bookmarks|rss] [-in FILE] [-map COLUMNS] [save flags]
+  linkleaf import bookmarks -file <file.pb> -in bookmarks.html [save flags]
+  linkleaf export textproto|json -file <file.pb> [-out FILE] [filter flags] [sort flags]
+  linkleaf import textproto|json -file <file.pb> [-in FILE] [save flags]
+  linkleaf import rss -file <file.pb> (-url https://example.com/feed.xml | -in feed.xml) [save flags]
+  linkleaf subscribe add -file <file.pb> URL [-title T] [-tags a,b,c] [-tag t]... [save flags]
+  linkleaf subscribe [list] -file <file.pb>
+  linkleaf subscribe remove -file <file.pb> URL|N [save flags]
+  linkleaf subscribe pull -file <file.pb> [-timeout 30s] [save flags]
+  linkleaf export opml [-base-url https://links.example.com] [-title T] [-out FILE]
+  linkleaf export hugo|jekyll -file <file.pb> [-out DIR] [-front-matter yaml|toml] [-incremental] [-drafts]
+                 [filter flags]
+  linkleaf export custom -file <file.pb> -format TEMPLATE|@file [-out FILE] [-drafts] [filter flags]
+                 [sort flags]
+  linkleaf export email -file <file.pb> [-since 7d] [-subject T] [-from ADDR] [-to ADDR,ADDR] [-intro TEXT|@file]
+                 [-template page.tmpl] [-text-template text.tmpl] [-out FILE.eml | -send] [-drafts] [filter flags]
+                 [sort flags]
+  linkleaf import opml [-in subscriptions.opml] [-dir DIR] [-fetch] [save flags]
+  linkleaf import pocket|pinboard|raindrop|shaarli|wallabag -file <file.pb> -in export-file [save flags]
+  linkleaf export shaarli -file <file.pb> [-out FILE] [filter flags] [sort flags]
+  linkleaf import browser-history -file <file.pb> (-browser chrome|firefox | -in History|places.sqlite)
+                 [-after DATE] [-before DATE] [-min-visits 2] [-limit N] [-tags a,b] [-yes] [save flags]
+  linkleaf build -file <file.pb> -base-url https://links.example.com [-out public] [-templates DIR] [-css URL]
+                 [-images [-images-max-size BYTES] [-images-max-age 30d]] [-drafts] [-permalinks page|redirect] [-page-size N]
+                 [filter flags]
+  linkleaf watch -file <file.pb> [-on-change STEP]... [-interval 500ms] [-initial=false]
+  linkleaf serve <file.pb> | -file <file.pb> [-addr :8080] [-rate-limit N] [-permalinks page|redirect] [-grpc :9090 [-grpc-token X | -grpc-insecure] [-id-scheme S] [save flags]]
+                 [-images [-assets DIR] [-images-max-size BYTES] [-images-max-age 30d]]
+  linkleaf daemon [-grpc localhost:9090] [-grpc-token X | -grpc-insecure] [-addr ADDR] [-feeds all|none|NAME,...] [-id-scheme S]
+                 [save flags]
+  linkleaf capture -file <file.pb> [-addr 127.0.0.1:7070] [-token X] [-id-scheme S] [save flags]
+  linkleaf publish -file <file.pb> -id ID [-to mastodon,bluesky|all|none] [-timeout 30s] [save flags]
+  linkleaf webmention -file <file.pb> -id ID [-source URL] [-dry-run] [-timeout 10s]
+  linkleaf tags  [list] <file.pb> [-sort count|name] [-json]
+  linkleaf tags  rename -file <file.pb> OLD NEW [save flags]
+  linkleaf tags  merge -file <file.pb> TAG... -into NEW [save flags]
+  linkleaf tags  rm -file <file.pb> TAG... [save flags]
+  linkleaf rename-tag <file.pb> -from old (-to new | -delete) [save flags]
+  linkleaf retag -file <file.pb> -match QUERY [-add-tag T]... [-remove-tag T]... [save flags]
+  linkleaf stats <file.pb> [-top N] [-json] [filter flags]
+  linkleaf validate <file.pb> [-json | -ci] [-policy policy.toml]
+  linkleaf doctor [<file.pb> | -file <file.pb>] [-fix] [save flags]
+  linkleaf check <file.pb> [-concurrency 8] [-timeout 10s] [-fail-on-error] [-report FILE] [-ci]
+                 [-annotate | -only-stale AGE] [save flags]
+  linkleaf merge -out <merged.pb> [-interactive] [-resolve-file FILE] <a.pb> <b.pb>... [save flags]
+  linkleaf split -file <file.pb> -out <part.pb> [-title T] [-remove] [filter flags] [save flags]
+  linkleaf sync  -local <file.pb> -remote <URL|file.pb> [-strategy union|ours|theirs] [-base FILE] [-interactive] [-resolve-file FILE] [save flags]
+  linkleaf diff  <old.pb> <new.pb> [-format text|json|ci | -json | -ci] [-exit-code]
+  linkleaf edit  -file <file.pb> -id ID [-title "..."] [-url "..."] [-date DATE] [-summary "..."] [-via URL]
+                 [-author NAME] [-slug SLUG] [-lang L] [-tags a,b,c] [-tag t]... [-normalize-tags] [-meta key=value]...
+                 [-enclosure URL] [-enclosure-type MIME] [-enclosure-length BYTES]
+                 [-draft[=false]] [-publish-at TIME] [save flags]
+  linkleaf bulk-edit -file <file.pb> [-match QUERY] [filter flags] [save flags]
+  linkleaf reid  -file <file.pb> [-id-scheme S] [-match QUERY] [filter flags] [save flags]
+  linkleaf remove -file <file.pb> (-id ID | -url URL) [-permanent] [save flags]
+  linkleaf trash [list] -file <file.pb>
+  linkleaf trash restore -file <file.pb> (ID... | -all) [save flags]
+  linkleaf trash purge -file <file.pb> [ID... | -all] [save flags]
+  linkleaf dedupe -file <file.pb> [-keep newest|oldest] [save flags]
+  linkleaf mark  -file <file.pb> -id ID [-read[=false]] [-starred[=false]] [-archived[=false]] [save flags]
+  linkleaf open  -file <file.pb> (ID | N | -random) [-mark-read] [filter flags] [save flags]
+  linkleaf qr    -file <file.pb> -id ID [-out FILE.png|- [-scale 8]] [-level L|M|Q|H]
+  linkleaf note  -file <file.pb> -id ID [-m TEXT] [save flags]
+  linkleaf relate -file <file.pb> -id ID -to ID... [-both] [-remove] [save flags]
+  linkleaf quote add -file <file.pb> -id ID [TEXT | -] [save flags]
+  linkleaf quote [list] -file <file.pb> -id ID
+  linkleaf quote rm -file <file.pb> -id ID N... [save flags]
+  linkleaf archive -file <file.pb> (-id ID | -all) [-to wayback|local] [-dir DIR] [-force] [-timeout 1m]
+                 [save flags]
+  linkleaf save  -file <file.pb> (-id ID | -all) [-dir DIR] [-force] [-timeout 30s] [save flags]
+  linkleaf read  -file <file.pb> -id ID [-width N] [-no-pager] [-mark-read] [save flags]
+  linkleaf refresh -file <file.pb> [-ids ID,ID] [-only-empty] [-concurrency 4] [-per-host 1s] [-timeout 10s]
+                 [-user-agent UA] [filter flags] [save flags]
+  linkleaf move  <file.pb> -id ID -to N|top|bottom [save flags]
+  linkleaf prune <file.pb> [-keep N] [-before YYYY-MM-DD] [save flags]
+  linkleaf migrate <file.pb> [-out FILE] [save flags]
+  linkleaf migrate v1-to-v2 <in.pb> <out.pb>
+  linkleaf migrate v2-to-v1 <in.pb> <out.pb> [save flags]
+  linkleaf convert <file.pb> -to pb|stream|sqlite|sharded [-out FILE] [save flags]
+  linkleaf compact <file.pb> [-to zstd|gzip|none] [-out FILE] [save flags]
+  linkleaf hash    [<file.pb> | -file <file.pb>] [-json]
+  linkleaf backup  [<file.pb> | -file <file.pb>] [-keep N] [-list [-json]]
+  linkleaf restore [<file.pb> | -file <file.pb>] [-snapshot HASH]
+  linkleaf log   -file <file.pb> [-limit N] [-json]
+  linkleaf undo  -file <file.pb> [save flags]
+  linkleaf history -file <file.pb> [-limit N]
+  linkleaf keygen [-key key.pem] [-pub pub.pem] [-force]
+  linkleaf sign  -file <file.pb> -key key.pem [-sig FILE]
+  linkleaf verify -file <file.pb> -pub pub.pem [-sig FILE]
+  linkleaf config [list | path | get KEY | set KEY VALUE | unset KEY]
+  linkleaf feeds [list | add NAME FILE | remove NAME]
+  linkleaf completion bash|zsh|fish
+
+"linkleaf COMMAND -h" shows a command's flags and notes.
+
+Filter flags (list, export, build, stats, open, refresh, split):
+  -after|-since DATE  -before|-until DATE  -tag T (repeatable)  -tags EXPR  -domain DOMAIN  -via HOST
+  -no-via  -author NAME  -lang L  -max-minutes N  -unread  -starred
+
+Sort flags (list, search, export):
+  -sort date|title|domain|added|reading-time  -reverse
+
+Save flags (init, meta, add, capture, serve -grpc, daemon, import, check -annotate, doctor -fix, tags rename/merge/rm, rename-tag, retag, edit, bulk-edit, reid, publish, refresh, remove, trash restore/purge, dedupe, merge, split, sync, mark, open, note, relate, quote add/rm, archive, save, read, subscribe, move, prune, migrate, convert, compact, undo):
+  -backup  -keep-backups N  -deterministic  -canonical  -sort-ids  -freeze-generated-at  -checksum  -dry-run  -git-commit  -wal
 
 Notes:
-  • Data is stored ONLY in protobuf binary files (.pb).
-  • "add" prepends links (newest first). If -id is empty: sha256(url+"|"+date)[:12].
-  • "init" creates the file if it doesn't exist; "add" creates it on demand if needed.
+  • Feeds are protobuf files (.pb). Stream (.pbs), compressed (.pb.gz, .pb.zst), encrypted, SQLite (.db)
+    and sharded (a directory) feeds, and remote ones, work with every command as well; "convert -h"
+    describes the formats and "convert" moves a feed between them.
+  • A failing command exits 3 when a link, feed or file isn't found, 4 when input doesn't validate (flags,
+    tags, queries, dates, the config), 5 on a conflict with the feed (a URL or ID it has, a link changed
+    meanwhile), 6 when reading or writing a file or the network fails, 2 on bad usage and 1 otherwise;
+    check, validate, doctor and diff -exit-code exit 1 for the problems they find. -porcelain drops status
+    output and reports errors as "error<TAB>kind<TAB>message", -json as {"error", "kind", "exit_code"}, with
+    kind one of usage, not_found, invalid, conflict, io and error; bad usage then prints no help text.
+  • Feed paths expand $VAR, ${VAR} and a leading ~; an unset variable is invalid input (exit 4).
+  • Without <file.pb> or -file, commands use $LINKLEAF_FEED, else "feed" from the config file
+    (~/.config/linkleaf/config.toml or $LINKLEAF_CONFIG), which also holds default tags for "add", the author
+    for rss/atom/jsonfeed and export defaults; "linkleaf config -h" lists the keys.
+  • "feeds add work ~/links/work.pb" names a feed in the config's [feeds]; -feed work then selects it for any
+    command (ahead of $LINKLEAF_FEED), and "feed" in the config may be such a name too.
+  • Completion scripts complete -id, -tag, rename-tag -from and retag's tags from the feed on the command line (-file or a
+    *.pb argument), else the default feed, and -feed from the config's [feeds].
+  • A feed may also be remote: s3://bucket/key (AWS_* credentials; AWS_ENDPOINT_URL for S3-compatible stores),
+    gs://bucket/object ($GOOGLE_OAUTH_ACCESS_TOKEN) or http(s)://… (GET, and PUT to save; user:pass@ in the URL
+    or $LINKLEAF_HTTP_TOKEN). Remote feeds aren't locked or journaled, and "serve" needs a local file.
+  • Tags may not contain whitespace or start with '#'; -tag is repeatable and combines with -tags.
+  • -backup copies the previous file to <file>.bak; -keep-backups N rotates <file>.1 … <file>.N.
+  • -checksum writes <file>.sha256 (kept current by every later save); -verify fails loudly on mismatch.
+  • -dry-run prints the resulting link diff and writes nothing.
+  • Every save appends what changed (links added, removed, modified; old values included) to <file>.journal.
+    "log" shows it newest first; "undo" reverts the newest entry and drops it. Encrypted feeds aren't journaled.
+  • With webhooks.urls in the config, every save that changes links, title or version POSTs a JSON
+    {"event": "feed.changed", "feed", "op", "time", "added", "removed", "modified", "change"} to each URL;
+    "change" is the journal entry as in log -json (encrypted feeds send counts only). With webhooks.secret,
+    X-Linkleaf-Signature-256 is "sha256=" plus the hex HMAC-SHA256 of the body. Network errors, 408, 429 and
+    5xx are retried twice (1s, then 2s later) before a warning; the save stands either way.
+  • hooks.pre-add, hooks.post-add, hooks.pre-edit, hooks.post-edit, hooks.pre-remove and hooks.post-remove in
+    the config are shell commands run once per link added (add, capture, serve), edited (edit, tui, serve) or
+    removed (remove, tui, serve), with the link as protojson on stdin and LINKLEAF_HOOK, LINKLEAF_FEED and
+    LINKLEAF_LINK_ID set; their output goes to stderr. A failing pre-* hook aborts the save, a failing post-*
+    hook is only a warning. Hooks run while the feed is locked, so they must not save to it; -dry-run runs none.
+  • -git-commit commits the saved file (and its .sha256) in its git repository with a message like
+    "add [id] title"; "history" lists the commits that touched the file (git log --follow).
+  • Commands that save take an advisory lock on <file>.lock first, so concurrent runs wait their turn.
+  • -wal appends the change to <file>.wal (one fsynced line) instead of rewriting the file, which keeps frequent
+    scripted adds cheap and loses nothing a crash interrupts; every load replays it. Changes it can't record
+    (reordering, feed fields, encrypted or non-.pb files) are saved in full, and any full save, or "compact"
+    without flags, folds the log into the file and removes it.
+  • For reproducible bytes (e.g. committing .pb to git) use -deterministic -sort-ids -freeze-generated-at;
+    -canonical also writes every string in Unicode NFC and drops unknown fields, so equal content gives equal
+    bytes whatever wrote it. "hash" prints SHA-256 digests of the canonical form of the feed (without
+    generated_at) and of each link, stable across saves, tools and protobuf versions.
+  • search terms are AND-ed, OR separates alternatives; quote the query. Terms: free text (title/summary),
+    "a phrase", tag:go, domain:example.com, lang:de, title:/url:/summary:/via:/author:/id:,
+    date>=YYYY-MM-DD (> < <= =), meta:key=value (meta:key alone: has the key); a leading '-' negates a term.
+  • -id (and relate -to, refresh -ids, open) takes any unique prefix of an ID; an ambiguous one lists the
+    IDs it matches.
+  • Tags may be namespaced with '/', e.g. lang/go or topic/db; "lang/*" (in -tag, -tags and tag:) matches lang
+    and every tag under it. -tags takes an expression of tags with NOT, AND, OR and parentheses, e.g.
+    "lang/go AND NOT topic/orm" or "(lang/go OR lang/rust) topic/*"; terms side by side are AND-ed.
+  • -sort orders the links list, search and export print: date and added newest first, title and domain A
+    to Z; -reverse flips it (alone, it reverses feed order, oldest entered first). Links missing the key come
+    last. With "search -fts" it replaces the ranking; "list -sort" reads the whole feed, even a stream.
+  • list/search -format runs a Go text/template (inline, or @FILE to read one) for each link; "export custom
+    -format" runs one once with the feed (.Title, .Author, .Links). Link fields: .Id .Title .Url .Date .Tags
+    .Summary .Via .Author .AddedAt .Notes .ArchiveUrl .Read .Starred .Archived .Meta (index .Meta "key").
+    Helpers: date LAYOUT VALUE (Go layout, e.g. "Jan 2, 2006"), domain URL, join SEP LIST, lower, upper and
+    json (a JSON-quoted value).
+  • Every link has a slug, a short name made from its title when it is added (-slug picks one; "edit -slug"
+    changes it, and with it the link's short URL). build writes a page per link under l/<slug>/ and serve
+    answers /l/<slug>, both linked as "permalink" from the lists, for sharing one entry; with -permalinks
+    redirect the short URL sends visitors straight on to the link instead. Slugs are unique within a feed;
+    a title already taken gets -2, -3, …. link.html.tmpl overrides build's permalink pages.
+  • A link's language (lang, a BCP 47 tag like en or de-AT) comes from -lang, else with -fetch from the page:
+    <html lang>, a Content-Language meta or header, og:locale, else a guess from the words of its title and
+    description; "refresh" fills it in for links added earlier. -lang de (filter flags) and lang:de (search)
+    select a language with its regional variants. RSS, Atom and JSON Feed exports carry it, and build and
+    serve add a feed per language: lang/<lang>/feed.xml, feed.atom and feed.json.
+  • Encrypted feeds (AES-256-GCM, passphrase from $LINKLEAF_KEY or -key-file) are decrypted on load and stay
+    encrypted on save; -encrypt encrypts a plain feed on its next save.
+  • Older feed versions are upgraded in memory on load (-no-migrate disables); "migrate" saves the upgrade.
+  • -after/-before are inclusive and take YYYY-MM-DD or an age back from today: 7d, 2w, 3m or 1y (-since 7d
+    is the past week); links with unparseable dates are skipped while they're set.
+  • -domain example.com also matches subdomains (blog.example.com); -offset/-limit page through list output.
+  • -via matches the host of the via URL (example.com matches https://www.example.com/x).
+  • Shared feeds record who added each link: "add" sets the link's author from -author, else the config's
+    author.name; "list -author NAME" (or the query term author:) selects one person's links. "init -author"
+    names the feed's author, who replaces author.name in exports; RSS (dc:creator), Atom, JSON Feed and HTML
+    credit each link's author when it isn't the feed's.
 `)
 }
 
-func cmdInit(args []string) {
+func cmdInit() (*flag.FlagSet, func()) {
 	fs := flag.NewFlagSet("init", flag.ExitOnError)
-	var title string
+	var title, author string
 	var version uint
 	fs.StringVar(&title, "title", "", "feed title")
-	fs.UintVar(&version, "version", 1, "feed version")
-	fs.Parse(args)
-
-	if fs.NArg() != 1 {
-		usage()
-		os.Exit(2)
-	}
-	path := fs.Arg(0)
+	fs.StringVar(&author, "author", "", "who the feed is by (default: the config's author.name in exports)")
+	fs.UintVar(&version, "version", feed.CurrentVersion, "feed version")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, "")
+		if !ok {
+			badUsage(fs)
+		}
 
-	feed := &v1.Feed{
-		Version:     uint32(version),
-		Title:       title,
-		GeneratedAt: nowRFC3339(),
-		Links:       nil,
-	}
-	if err := saveFeed(path, feed); err != nil {
-		die(err)
+		sf.lock(path)
+		f := feed.New(title, uint32(version))
+		f.Author = author
+		if err := sf.save(path, f); err != nil {
+			die(err)
+		}
+		msg.Infof("initialized %s (version=%d, title=%q)", path, version, title)
 	}
-	fmt.Printf("initialized %s (version=%d, title=%q)\n", path, version, title)
 }
 
-func cmdAdd(args []string) {
+func cmdAdd() (*flag.FlagSet, func()) {
 	fs := flag.NewFlagSet("add", flag.ExitOnError)
-	var file, title, url, summary, tagsCSV, via, id, date string
-	fs.StringVar(&file, "file", "", "protobuf feed file (.pb)")
+	var file, title, url, summary, via, id, date string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
 	fs.StringVar(&title, "title", "", "link title (required)")
 	fs.StringVar(&url, "url", "", "link URL (required)")
-	fs.StringVar(&date, "date", "", "YYYY-MM-DD (required)")
+	fs.StringVar(&date, "date", "", "YYYY-MM-DD, optionally with a time and zone: 2024-05-01T18:30[+02:00] (required)")
 	fs.StringVar(&summary, "summary", "", "short summary")
-	fs.StringVar(&tagsCSV, "tags", "", "comma-separated tags (e.g. a,b,c)")
+	tf := addTagFlags(fs)
 	fs.StringVar(&via, "via", "", "optional attribution URL")
-	fs.StringVar(&id, "id", "", "stable ID (default: sha256(url|date)[:12])")
-	fs.Parse(args)
+	var author string
+	fs.StringVar(&author, "author", "", "who added the link (default: the config's author.name)")
+	meta := metaFlag{}
+	fs.Var(meta, "meta", "custom data as key=value (repeatable)")
+	ef := addEnclosureFlags(fs)
+	fs.StringVar(&id, "id", "", "stable ID (default: generated by -id-scheme)")
+	var slug string
+	fs.StringVar(&slug, "slug", "", "short name of the link's permalink page, /l/SLUG (default: from the title)")
+	var lang string
+	fs.StringVar(&lang, "lang", "", "language of the page, e.g. en or de-AT (default with -fetch: the page's)")
+	var idScheme string
+	fs.StringVar(&idScheme, "id-scheme", defaultIDScheme(), "ID generator when -id is empty: "+strings.Join(feed.IDSchemes(), ", "))
+	var interactive bool
+	fs.BoolVar(&interactive, "interactive", false, "prompt for fields on stdin (flags pre-fill answers)")
+	var edit bool
+	fs.BoolVar(&edit, "e", false, "write the link in $EDITOR from a template (flags, else the clipboard URL, pre-fill it)")
+	var batch string
+	fs.StringVar(&batch, "batch", "", "add every url<TAB>title<TAB>date<TAB>tags line of this file (- for stdin)")
+	var fetch bool
+	var fo pagemeta.Options
+	fs.BoolVar(&fetch, "fetch", false, "fill in title/summary/language from the page (explicit flags win) and count its words")
+	fs.DurationVar(&fo.Timeout, "timeout", 10*time.Second, "-fetch: request timeout")
+	fs.StringVar(&fo.UserAgent, "user-agent", pagemeta.DefaultUserAgent, "-fetch: User-Agent header")
+	var force, update, noValidate bool
+	fs.BoolVar(&noValidate, "no-validate", false, "accept any URL and date string")
+	var policyFile string
+	fs.StringVar(&policyFile, "policy", cfg.Policy, "refuse links that break the rules of this policy file (see validate -policy)")
+	fs.BoolVar(&force, "force", false, "add even if the feed already has this URL")
+	fs.BoolVar(&update, "update-existing", false, "if the feed already has this URL, update that link instead")
+	var announce string
+	fs.StringVar(&announce, "announce", "", "also publish the new link to these services: mastodon, bluesky or all (see publish)")
+	var mention bool
+	fs.BoolVar(&mention, "webmention", false, "send Webmentions to the new link's URL and via from webmention.source")
+	var draft bool
+	var publishAt string
+	fs.BoolVar(&draft, "draft", false, "keep the link out of exports, build and serve until \"linkleaf publish\" releases it")
+	fs.StringVar(&publishAt, "publish-at", "", "keep the link out of exports, build and serve until this time (RFC 3339, or YYYY-MM-DD: midnight UTC)")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		// "add [flags] -" reads the link from stdin.
+		stdin := fs.NArg() == 1 && fs.Arg(0) == "-"
+		textual := stdin || edit
 
-	if file == "" || title == "" || url == "" || date == "" {
-		fs.Usage()
-		os.Exit(2)
-	}
+		if force && update {
+			die(invalid(errors.New("-force and -update-existing are mutually exclusive")))
+		}
+		var publishTime time.Time
+		if publishAt != "" {
+			var err error
+			if publishTime, err = feed.ParsePublishAt(publishAt); err != nil {
+				die(invalid(fmt.Errorf("-publish-at: want an RFC 3339 time or YYYY-MM-DD, got %q", publishAt)))
+			}
+		}
+		if lang != "" {
+			var err error
+			if lang, err = feed.NormalizeLang(lang); err != nil {
+				die(invalid(fmt.Errorf("-lang: %w", err)))
+			}
+		}
+		if (draft || publishAt != "" || slug != "") && batch != "" {
+			die(invalid(errors.New("-draft, -publish-at and -slug need a single link, not -batch")))
+		}
+		if slug != "" {
+			if err := feed.ValidateSlug(slug); err != nil {
+				die(invalid(fmt.Errorf("-slug: %w", err)))
+			}
+		}
+		if id != "" {
+			if err := feed.ValidateID(id); err != nil {
+				die(invalid(fmt.Errorf("-id: %w", err)))
+			}
+		}
+		if (draft || publishTime.After(time.Now())) && (announce != "" || mention) {
+			die(invalid(errors.New("-announce and -webmention need a link that is public now (publish -id announces a draft as it releases it)")))
+		}
+		var targets []crosspost.Target
+		if announce != "" {
+			if batch != "" {
+				die(invalid(errors.New("-announce needs a single link, not -batch")))
+			}
+			var err error
+			if targets, err = publishTargets(announce); err != nil {
+				die(invalid(fmt.Errorf("-announce: %w", err)))
+			}
+		}
+		if mention {
+			if batch != "" {
+				die(invalid(errors.New("-webmention needs a single link, not -batch")))
+			}
+			if _, err := webmentionSource(&v1.Link{}); err != nil {
+				die(invalid(fmt.Errorf("-webmention: %w", err)))
+			}
+		}
+		policy, err := loadPolicy(policyFile)
+		if err != nil {
+			die(err)
+		}
+		if batch != "" {
+			if file == "" || interactive || textual || update {
+				badUsage(fs)
+			}
+			addBatch(file, batch, idScheme, tf.normalize, !noValidate, force, policy, sf)
+			return
+		}
+		if file == "" || (fs.NArg() > 0 && !stdin) || (!interactive && !textual && ((title == "" && !fetch) || url == "" || date == "")) {
+			badUsage(fs)
+		}
+		if interactive && stdin || edit && (interactive || stdin) {
+			die(invalid(errors.New("-interactive, -e and - are mutually exclusive")))
+		}
+		tags, err := tf.tags()
+		if err != nil {
+			die(err)
+		}
+		tags = withDefaultTags(tags)
+		if author == "" {
+			author = cfg.Author.Name
+		}
+		enclosure, err := ef.enclosure()
+		if err != nil {
+			die(err)
+		}
+		genID, err := feed.IDScheme(idScheme)
+		if err != nil {
+			die(invalid(err))
+		}
+		link := &v1.Link{
+			Id:        id,
+			Title:     title,
+			Url:       url,
+			Summary:   summary,
+			Tags:      tags,
+			Date:      date,
+			Via:       via,
+			Author:    author,
+			Meta:      applyMeta(nil, meta),
+			Enclosure: enclosure,
+			Draft:     draft,
+			Lang:      lang,
+		}
+		if !publishTime.IsZero() {
+			link.PublishAt = publishTime.Format(time.RFC3339)
+		}
+		if textual {
+			if err := readLinkText(link, stdin, tf.normalize); err != nil {
+				die(err)
+			}
+		}
+		if fetch && link.Url != "" {
+			meta, err := pagemeta.Fetch(context.Background(), link.Url, fo)
+			if err != nil {
+				die(err)
+			}
+			msg.Debugf("fetched %s: title=%q description=%q lang=%q words=%d", link.Url, meta.Title, meta.Description, meta.Lang, meta.Words)
+			if link.Title == "" {
+				link.Title = meta.Title
+			}
+			if link.Summary == "" {
+				link.Summary = meta.Description
+			}
+			if link.Lang == "" {
+				link.Lang, _ = feed.NormalizeLang(meta.Lang) // pages declare all sorts; a bad one is no language
+			}
+			feed.SetWordCount(link, meta.Words)
+			if link.Title == "" && !interactive {
+				die(invalid(fmt.Errorf("%s has no title; pass -title", link.Url)))
+			}
+		}
+		if interactive {
+			if err := promptLink(newPrompter(os.Stdin, os.Stderr), link); err != nil {
+				if errors.Is(err, errAborted) {
+					fmt.Fprintln(os.Stderr, "aborted; nothing written")
+					os.Exit(1)
+				}
+				die(err)
+			}
+		}
+		if d, err := feed.NormalizeDate(link.Date, time.Local); err == nil {
+			link.Date = d
+		}
+		if !noValidate {
+			if err := feed.ValidateLink(link); err != nil {
+				die(invalid(fmt.Errorf("%w (-no-validate adds it anyway)", err)))
+			}
+		}
+		if err := policy.CheckLink(link); err != nil {
+			die(invalid(fmt.Errorf("%s breaks the feed's policy:\n%w", link.Url, err)))
+		}
 
-	feed, _ := loadFeed(file) // if not found, create a new feed
-	if feed == nil {
-		feed = &v1.Feed{}
-	}
-	feed.GeneratedAt = nowRFC3339()
+		sf.lock(file)
+		opened, err := feed.OpenWith(file, loadOpts) // a missing file starts a new feed
+		if err != nil {
+			die(fmt.Errorf("load %s: %w", file, err))
+		}
+		f := opened.Feed
+		sf.loaded(f)
 
-	if id == "" {
-		id = shortHash(url + "|" + date)
-	}
-	link := v1.Link{
-		Id:      id,
-		Title:   title,
-		Url:     url,
-		Summary: summary,
-		Tags:    splitTags(tagsCSV),
-		Date:    date,
-		Via:     via,
-	}
+		if old := feed.FindURL(f, link.Url); old != nil && !force {
+			if !update {
+				die(conflict(fmt.Errorf("%s is already in the feed as [%s] (use -force to add it again or -update-existing)", link.Url, old.Id)))
+			}
+			if !updateLink(old, link) {
+				msg.Infof("[%s] unchanged", old.Id)
+				return
+			}
+			f.GeneratedAt = feed.NowRFC3339()
+			if err := sf.save(file, f); err != nil {
+				die(err)
+			}
+			msg.Infof("updated [%s] %s", old.Id, old.Title)
+			return
+		}
 
-	// Prepend (newest first)
-	feed.Links = append([]*v1.Link{&link}, feed.Links...)
+		if link.Id == "" {
+			assignID(f, genID, link)
+			msg.Debugf("generated id %s (scheme %s)", link.Id, idScheme)
+		} else if old := feed.Find(f, link.Id); old != nil {
+			die(conflict(fmt.Errorf("id %s is taken by %q (pick another -id or leave it out)", link.Id, old.Title)))
+		}
+		if slug != "" {
+			if err := feed.SetSlug(f, link, slug); err != nil {
+				die(invalid(fmt.Errorf("-slug: %w", err)))
+			}
+		}
+		feed.AddLink(f, link)
 
-	if err := saveFeed(file, feed); err != nil {
-		die(err)
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		switch {
+		case link.Draft:
+			msg.Infof("added [%s] %s (draft)", link.Id, link.Title)
+		case !feed.Published(link, time.Now()):
+			msg.Infof("added [%s] %s (scheduled for %s)", link.Id, link.Title, link.PublishAt)
+		default:
+			msg.Infof("added [%s] %s", link.Id, link.Title)
+		}
+		if len(targets) > 0 {
+			if err := publishLink(link, targets, sf.dryRun, 0); err != nil {
+				die(fmt.Errorf("%w (the link was added; retry with linkleaf publish -id %s)", err, link.Id))
+			}
+		}
+		if mention {
+			source, _ := webmentionSource(link)
+			if err := sendWebmentions(link, source, sf.dryRun, 10*time.Second); err != nil {
+				die(fmt.Errorf("%w (the link was added; retry with linkleaf webmention -id %s)", err, link.Id))
+			}
+		}
 	}
-	fmt.Printf("added [%s] %s\n", id, title)
 }
 
-func cmdList(args []string) {
+func cmdList() (*flag.FlagSet, func()) {
 	fs := flag.NewFlagSet("list", flag.ExitOnError)
-	fs.Parse(args)
-	if fs.NArg() != 1 {
-		fs.Usage()
-		os.Exit(2)
+	ff := addFilterFlags(fs)
+	so := addSortFlags(fs)
+	var limit, offset int
+	fs.IntVar(&limit, "limit", 0, "show at most N links (0: all)")
+	fs.IntVar(&offset, "offset", 0, "skip the first N matching links")
+	var broken brokenFlag
+	fs.Var(&broken, "broken", "only links whose last check failed; -broken=N: the last N checks in a row")
+	var table, noColor bool
+	var columns, groupBy string
+	fs.StringVar(&groupBy, "group-by", "", "show the links under headings with counts: "+strings.Join(listGroups, ", "))
+	fs.BoolVar(&table, "table", false, "print aligned columns, cut to the terminal's width")
+	fs.StringVar(&columns, "columns", defaultColumns, "-table: comma-separated columns, of index, id, date, added, title, url, domain, tags, author, minutes (implies -table)")
+	fs.BoolVar(&noColor, "no-color", false, "-table: don't color the output (default: color on a terminal unless $NO_COLOR is set)")
+	jf := addJSONFlags(fs)
+	format := addFormatFlag(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, "")
+		if !ok || limit < 0 || offset < 0 {
+			badUsage(fs)
+		}
+		flt, err := ff.filter()
+		if err != nil {
+			die(err)
+		}
+		flt.Broken = int(broken)
+		if err := so.check(); err != nil {
+			die(err)
+		}
+		fs.Visit(func(fl *flag.Flag) { table = table || fl.Name == "columns" })
+		var cols []tableColumn
+		if table {
+			if *format != "" || jf.enabled() {
+				die(invalid(errors.New("-table and -json/-jsonl/-format are mutually exclusive")))
+			}
+			if cols, err = parseColumns(columns); err != nil {
+				die(err)
+			}
+		}
+		if groupBy != "" && (*format != "" || jf.enabled()) {
+			die(invalid(errors.New("-group-by and -json/-jsonl/-format are mutually exclusive")))
+		}
+
+		var f *v1.Feed
+		if !so.set() {
+			// Select reads only what it returns from SQLite and stream feeds.
+			if f, err = mustSelect(path, flt, offset, limit); err != nil {
+				die(err)
+			}
+		} else {
+			if f, err = mustLoad(path); err != nil {
+				die(err)
+			}
+			f = flt.Select(f)
+			if err := so.sort(f.Links); err != nil {
+				die(err)
+			}
+			f.Links = f.Links[min(offset, len(f.Links)):]
+			if limit > 0 && limit < len(f.Links) {
+				f.Links = f.Links[:limit]
+			}
+		}
+		if *format != "" {
+			if err := writeFormatted(os.Stdout, *format, jf, f.Links); err != nil {
+				die(err)
+			}
+			return
+		}
+		if jf.enabled() {
+			if err := jf.write(f); err != nil {
+				die(err)
+			}
+			return
+		}
+		groups := []linkGroup{{links: f.Links}}
+		if groupBy != "" {
+			if groups, err = groupLinks(f.Links, groupBy); err != nil {
+				die(err)
+			}
+		}
+		switch {
+		case table:
+			printTable(groups, cols, outputWidth(), colorOutput(noColor))
+		case groupBy != "":
+			printGroups(f, groups)
+		default:
+			printList(f, f.Links)
+		}
 	}
-	path := fs.Arg(0)
+}
 
-	feed, err := mustLoad(path)
-	if err != nil {
-		die(err)
+// updateLink copies the fields given to add onto an existing link with the
+// same URL; ID, URL, added_at and position stay. It reports whether
+// anything changed.
+func updateLink(old, l *v1.Link) bool {
+	before := proto.Clone(old)
+	old.Title, old.Date = l.Title, l.Date
+	if l.Summary != "" {
+		old.Summary = l.Summary
+	}
+	if l.Tags != nil {
+		old.Tags = l.Tags
+	}
+	if l.Via != "" {
+		old.Via = l.Via
+	}
+	if l.Author != "" {
+		old.Author = l.Author
+	}
+	if l.Lang != "" {
+		old.Lang = l.Lang
+	}
+	if l.WordCount > 0 {
+		old.WordCount, old.ReadingMinutes = l.WordCount, l.ReadingMinutes
+	}
+	old.Meta = applyMeta(old.Meta, l.Meta)
+	if l.Enclosure != nil {
+		old.Enclosure = l.Enclosure
+	}
+	return !proto.Equal(before, old)
+}
+
+// printList is the human-readable listing shared by list and search.
+func printList(f *v1.Feed, links []*v1.Link) {
+	fmt.Printf("Feed: %q  (version=%d, generated_at=%s)\n", f.Title, f.Version, f.GeneratedAt)
+	printLinks(links)
+}
+
+// printGroups is printList under list -group-by's headings.
+func printGroups(f *v1.Feed, groups []linkGroup) {
+	fmt.Printf("Feed: %q  (version=%d, generated_at=%s)\n", f.Title, f.Version, f.GeneratedAt)
+	for _, g := range groups {
+		fmt.Printf("\n%s\n", groupHeading(g))
+		printLinks(g.links)
 	}
-	fmt.Printf("Feed: %q  (version=%d, generated_at=%s)\n", feed.Title, feed.Version, feed.GeneratedAt)
-	for i, l := range feed.Links {
+}
+
+func printLinks(links []*v1.Link) {
+	for i, l := range links {
 		fmt.Printf("%3d) [%s] %s\n     %s\n     date=%s tags=%s\n",
 			i+1, l.Id, l.Title, l.Url, l.Date, strings.Join(l.Tags, ","))
 		if l.Summary != "" {
@@ -146,121 +711,266 @@ func cmdList(args []string) {
 		if l.Via != "" {
 			fmt.Printf("     via: %s\n", l.Via)
 		}
+		if l.Author != "" {
+			fmt.Printf("     by: %s\n", l.Author)
+		}
+		if l.ReadingMinutes > 0 {
+			fmt.Printf("     %d min read (%d words)\n", l.ReadingMinutes, l.WordCount)
+		}
+		if e := l.Enclosure; e != nil {
+			fmt.Printf("     enclosure: %s\n", e.Url)
+		}
+		if len(l.Meta) > 0 {
+			fmt.Printf("     meta: %s\n", formatMeta(l.Meta))
+		}
+		if len(l.RelatedIds) > 0 {
+			fmt.Printf("     related: %s\n", strings.Join(l.RelatedIds, ", "))
+		}
+		if l.Read || l.Starred || l.Archived {
+			fmt.Printf("     %s\n", markState(l.Read, l.Starred, l.Archived))
+		}
+		switch {
+		case l.Draft:
+			fmt.Println("     draft")
+		case !feed.Published(l, time.Now()):
+			fmt.Printf("     scheduled for %s\n", l.PublishAt)
+		}
+		if c := l.LastCheck; c != nil && c.Failures > 0 {
+			fmt.Printf("     broken: %s (%d checks in a row, last %s)\n", checkOutcome(c), c.Failures, c.CheckedAt)
+		}
 	}
 }
 
-func cmdPrint(args []string) {
-	// Human-friendly dump (no JSON); still loads from .pb
+func cmdPrint() (*flag.FlagSet, func()) {
+	// Human-friendly dump unless -json/-jsonl; still loads from .pb
 	fs := flag.NewFlagSet("print", flag.ExitOnError)
-	fs.Parse(args)
-	if fs.NArg() != 1 {
-		fs.Usage()
-		os.Exit(2)
-	}
-	path := fs.Arg(0)
+	jf := addJSONFlags(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, "")
+		if !ok {
+			badUsage(fs)
+		}
 
-	feed, err := mustLoad(path)
-	if err != nil {
-		die(err)
-	}
-	fmt.Printf("FEED\n----\nversion: %d\ntitle: %s\ngenerated_at: %s\nlinks: %d\n\n",
-		feed.Version, feed.Title, feed.GeneratedAt, len(feed.Links))
-	for _, l := range feed.Links {
-		fmt.Printf("- id: %s\n  title: %s\n  url: %s\n  date: %s\n",
-			l.Id, l.Title, l.Url, l.Date)
-		if len(l.Tags) > 0 {
-			fmt.Printf("  tags: %s\n", strings.Join(l.Tags, ", "))
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
 		}
-		if l.Summary != "" {
-			fmt.Printf("  summary: %s\n", l.Summary)
+		if jf.enabled() {
+			if err := jf.write(f); err != nil {
+				die(err)
+			}
+			return
 		}
-		if l.Via != "" {
-			fmt.Printf("  via: %s\n", l.Via)
+		fmt.Printf("FEED\n----\nversion: %d\ntitle: %s\n", f.Version, f.Title)
+		if f.Author != "" {
+			fmt.Printf("author: %s\n", f.Author)
+		}
+		fmt.Printf("generated_at: %s\nlinks: %d\n\n", f.GeneratedAt, len(f.Links))
+		for _, l := range f.Links {
+			fmt.Printf("- id: %s\n  title: %s\n  url: %s\n  date: %s\n",
+				l.Id, l.Title, l.Url, l.Date)
+			if l.Slug != "" {
+				fmt.Printf("  slug: %s\n", l.Slug)
+			}
+			if l.Lang != "" {
+				fmt.Printf("  lang: %s\n", l.Lang)
+			}
+			if l.WordCount > 0 {
+				fmt.Printf("  word_count: %d\n  reading_minutes: %d\n", l.WordCount, l.ReadingMinutes)
+			}
+			if l.AddedAt != "" {
+				fmt.Printf("  added_at: %s\n", l.AddedAt)
+			}
+			if l.UpdatedAt != "" {
+				fmt.Printf("  updated_at: %s\n", l.UpdatedAt)
+			}
+			if l.Read {
+				fmt.Println("  read: true")
+			}
+			if l.Starred {
+				fmt.Println("  starred: true")
+			}
+			if l.Archived {
+				fmt.Println("  archived: true")
+			}
+			if l.Draft {
+				fmt.Println("  draft: true")
+			}
+			if l.PublishAt != "" {
+				fmt.Printf("  publish_at: %s\n", l.PublishAt)
+			}
+			if len(l.Tags) > 0 {
+				fmt.Printf("  tags: %s\n", strings.Join(l.Tags, ", "))
+			}
+			if l.Summary != "" {
+				fmt.Printf("  summary: %s\n", l.Summary)
+			}
+			if l.Via != "" {
+				fmt.Printf("  via: %s\n", l.Via)
+			}
+			if l.Author != "" {
+				fmt.Printf("  author: %s\n", l.Author)
+			}
+			if l.ArchiveUrl != "" {
+				fmt.Printf("  archive_url: %s\n", l.ArchiveUrl)
+			}
+			if l.ArticlePath != "" {
+				fmt.Printf("  article_path: %s\n", l.ArticlePath)
+			}
+			if e := l.Enclosure; e != nil {
+				fmt.Printf("  enclosure: %s (%s, %d bytes)\n", e.Url, e.MimeType, e.Length)
+			}
+			if len(l.Meta) > 0 {
+				fmt.Println("  meta:")
+				for _, k := range slices.Sorted(maps.Keys(l.Meta)) {
+					fmt.Printf("    %s: %s\n", k, l.Meta[k])
+				}
+			}
+			if len(l.Quotes) > 0 {
+				fmt.Println("  quotes:")
+				for _, q := range l.Quotes {
+					for i, line := range strings.Split(q, "\n") {
+						prefix := "      "
+						if i == 0 {
+							prefix = "    - "
+						}
+						fmt.Println(strings.TrimRight(prefix+line, " "))
+					}
+				}
+			}
+			if l.Notes != "" {
+				fmt.Println("  notes: |")
+				for _, line := range strings.Split(l.Notes, "\n") {
+					fmt.Println(strings.TrimRight("    "+line, " "))
+				}
+			}
+			if len(l.RelatedIds) > 0 {
+				fmt.Println("  related:")
+				for _, id := range l.RelatedIds {
+					if r := feed.Find(f, id); r != nil {
+						fmt.Printf("    - [%s] %s\n", r.Id, r.Title)
+					} else {
+						fmt.Printf("    - [%s] (not in the feed)\n", id)
+					}
+				}
+			}
+			if c := l.LastCheck; c != nil {
+				fmt.Printf("  last_check: %s status=%d", c.CheckedAt, c.Status)
+				if c.FinalUrl != "" {
+					fmt.Printf(" final_url=%s", c.FinalUrl)
+				}
+				if c.Error != "" {
+					fmt.Printf(" error=%q", c.Error)
+				}
+				if c.Failures > 0 {
+					fmt.Printf(" failures=%d", c.Failures)
+				}
+				fmt.Println()
+				if len(l.CheckHistory) > 0 {
+					fmt.Println("  check_history:")
+					for _, h := range l.CheckHistory {
+						fmt.Printf("    - %s %s\n", h.CheckedAt, checkOutcome(h))
+					}
+				}
+			}
+			fmt.Println()
 		}
-		fmt.Println()
 	}
 }
 
-// -------- storage (protobuf only) --------
+// -------- storage --------
 
-func loadFeed(path string) (*v1.Feed, error) {
-	b, err := os.ReadFile(path)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, os.ErrNotExist
+// loadOpts holds the global load flags (-no-migrate, -verify) and the
+// encryption key.
+var loadOpts feed.LoadOptions
+
+// encrypt and keyFile are the global -encrypt and -key-file flags.
+var (
+	encrypt bool
+	keyFile string
+)
+
+// setupKey reads the passphrase for encrypted feeds from -key-file or,
+// failing that, $LINKLEAF_KEY.
+func setupKey() error {
+	if keyFile != "" {
+		path, err := feed.ExpandPath(keyFile)
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("-key-file: %w", err)
 		}
-		return nil, err
+		loadOpts.Key = []byte(strings.TrimRight(string(b), "\r\n"))
+	} else if k := os.Getenv("LINKLEAF_KEY"); k != "" {
+		loadOpts.Key = []byte(k)
 	}
-	var feed v1.Feed
-	if err := proto.Unmarshal(b, &feed); err != nil {
-		return nil, fmt.Errorf("unmarshal protobuf: %w", err)
+	if encrypt && len(loadOpts.Key) == 0 {
+		return errors.New("-encrypt needs a key: set LINKLEAF_KEY or -key-file")
 	}
-	return &feed, nil
+	return nil
 }
 
 func mustLoad(path string) (*v1.Feed, error) {
-	feed, err := loadFeed(path)
-	if err != nil {
-		return nil, fmt.Errorf("load %s: %w", path, err)
+	f, err := feed.LoadWith(path, loadOpts)
+	if errors.Is(err, feed.ErrEncrypted) {
+		err = fmt.Errorf("%w (set LINKLEAF_KEY or -key-file)", err)
 	}
-	return feed, nil
-}
-
-func saveFeed(path string, feed *v1.Feed) error {
-	b, err := proto.Marshal(feed)
 	if err != nil {
-		return fmt.Errorf("marshal protobuf: %w", err)
+		return nil, fmt.Errorf("load %s: %w", path, err)
 	}
-	return writeFileAtomic(path, b, 0o644)
+	return f, nil
 }
 
-func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return err
+// mustSelect is feed.Select with mustLoad's errors.
+func mustSelect(path string, flt feed.Filter, offset, limit int) (*v1.Feed, error) {
+	f, err := feed.Select(path, flt, offset, limit, loadOpts)
+	if errors.Is(err, feed.ErrEncrypted) {
+		err = fmt.Errorf("%w (set LINKLEAF_KEY or -key-file)", err)
 	}
-	tmp, err := os.CreateTemp(dir, ".tmp-*")
 	if err != nil {
-		return err
-	}
-	defer func() {
-		tmp.Close()
-		os.Remove(tmp.Name())
-	}()
-	if _, err := tmp.Write(data); err != nil {
-		return err
-	}
-	if err := tmp.Chmod(perm); err != nil {
-		return err
-	}
-	if err := tmp.Close(); err != nil {
-		return err
+		return nil, fmt.Errorf("load %s: %w", path, err)
 	}
-	return os.Rename(tmp.Name(), path)
+	return f, nil
 }
 
 // -------- helpers --------
 
-func nowRFC3339() string { return time.Now().UTC().Format(time.RFC3339) }
-
-func shortHash(s string) string {
-	sum := sha256.Sum256([]byte(s))
-	return hex.EncodeToString(sum[:])[:12]
-}
-
-func splitTags(csv string) []string {
-	if strings.TrimSpace(csv) == "" {
-		return nil
+// parseArgs parses fs but, unlike fs.Parse, also accepts flags after
+// positional arguments (e.g. "export feed.pb -out index.html"). Everything
+// after "--" is positional.
+func parseArgs(fs *flag.FlagSet, args []string) {
+	if jsonErrors || porcelain {
+		// Report flag errors as die does rather than as the flag package.
+		fs.Init(fs.Name(), flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
 	}
-	parts := strings.Split(csv, ",")
-	out := make([]string, 0, len(parts))
-	for _, p := range parts {
-		t := strings.TrimSpace(p)
-		if t != "" {
-			out = append(out, t)
+	parse := func(args []string) {
+		switch err := fs.Parse(args); {
+		case errors.Is(err, flag.ErrHelp):
+			fs.SetOutput(nil)
+			fs.Usage()
+			os.Exit(0)
+		case err != nil:
+			usageError(fs.Usage, err)
 		}
 	}
-	return out
+	var pos []string
+	for {
+		parse(args)
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			pos = append(pos, rest...)
+			break
+		}
+		if len(rest) == 0 {
+			break
+		}
+		pos = append(pos, rest[0])
+		args = rest[1:]
+	}
+	parse(append([]string{"--"}, pos...))
 }
 
 func wrap(s string, width int, indent string) string {
@@ -290,8 +1000,3 @@ func wrap(s string, width int, indent string) string {
 	}
 	return b.String()
 }
-
-func die(err error) {
-	fmt.Fprintln(os.Stderr, "error:", err)
-	os.Exit(1)
-}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"google.golang.org/protobuf/proto"
+)
+
+func cmdMark() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("mark", flag.ExitOnError)
+	var file, id string
+	var read, starred, archived bool
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID of the link to mark (required)")
+	fs.BoolVar(&read, "read", false, "mark read (-read=false: unread)")
+	fs.BoolVar(&starred, "starred", false, "star (-starred=false: unstar)")
+	fs.BoolVar(&archived, "archived", false, "archive (-archived=false: unarchive)")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		set := map[string]bool{}
+		fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+		if file == "" || id == "" || fs.NArg() != 0 || !(set["read"] || set["starred"] || set["archived"]) {
+			badUsage(fs)
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		l, err := feed.FindPrefix(f, id)
+		if err != nil {
+			die(err)
+		}
+		id = l.Id
+
+		old := proto.Clone(l)
+		if set["read"] {
+			l.Read = read
+		}
+		if set["starred"] {
+			l.Starred = starred
+		}
+		if set["archived"] {
+			l.Archived = archived
+		}
+		if proto.Equal(old, l) {
+			msg.Infof("[%s] unchanged", id)
+			return
+		}
+		f.GeneratedAt = feed.NowRFC3339()
+
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		msg.Infof("marked [%s] %s (%s)", l.Id, l.Title, markState(l.Read, l.Starred, l.Archived))
+	}
+}
+
+// markState describes a link's read/starred/archived flags, e.g.
+// "unread, starred".
+func markState(read, starred, archived bool) string {
+	parts := []string{"unread"}
+	if read {
+		parts[0] = "read"
+	}
+	if starred {
+		parts = append(parts, "starred")
+	}
+	if archived {
+		parts = append(parts, "archived")
+	}
+	return strings.Join(parts, ", ")
+}
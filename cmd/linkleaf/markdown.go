@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// markdownGroups are the accepted export -group-by values.
+var markdownGroups = []string{"none", "day", "week", "month", "year"}
+
+// renderMarkdown writes a link roundup: a title, then one "## heading" per
+// period (unless groupBy is "none") with a bullet per link in feed order.
+func renderMarkdown(f *v1.Feed, groupBy string) ([]byte, error) {
+	heading, ok := markdownHeading(groupBy)
+	if !ok {
+		return nil, fmt.Errorf("-group-by: want one of %s, got %q", strings.Join(markdownGroups, ", "), groupBy)
+	}
+	var buf bytes.Buffer
+	title := f.Title
+	if title == "" {
+		title = "Links"
+	}
+	fmt.Fprintf(&buf, "# %s\n", mdEscape(title))
+
+	// Links keep feed order within a group; groups appear in the order of
+	// their first link, so a newest-first feed yields newest-first groups.
+	var order []string
+	groups := map[string][]*v1.Link{}
+	for _, l := range f.Links {
+		h := ""
+		if heading != nil {
+			h = "Undated"
+			if t, ok := linkTime(l); ok {
+				h = heading(t)
+			}
+		}
+		if _, ok := groups[h]; !ok {
+			order = append(order, h)
+		}
+		groups[h] = append(groups[h], l)
+	}
+	for _, h := range order {
+		if h != "" {
+			fmt.Fprintf(&buf, "\n## %s\n", h)
+		}
+		buf.WriteByte('\n')
+		for _, l := range groups[h] {
+			writeMarkdownLink(&buf, l)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func writeMarkdownLink(buf *bytes.Buffer, l *v1.Link) {
+	fmt.Fprintf(buf, "- [%s](%s)", mdEscape(l.Title), mdURL(l.Url))
+	if l.Summary != "" {
+		fmt.Fprintf(buf, " — %s", mdEscape(l.Summary))
+	}
+	if l.Via != "" {
+		host := feed.Host(l.Via)
+		if host == "" {
+			host = l.Via
+		}
+		fmt.Fprintf(buf, " _via [%s](%s)_", mdEscape(host), mdURL(l.Via))
+	}
+	if l.ArchiveUrl != "" {
+		fmt.Fprintf(buf, " ([archived copy](%s))", mdURL(l.ArchiveUrl))
+	}
+	buf.WriteByte('\n')
+	// Quotes follow as blockquotes inside the list item, one each, then
+	// the notes as one more.
+	quoted := false
+	for _, text := range append(slices.Clone(l.Quotes), l.Notes) {
+		ps := paragraphs(text)
+		if len(ps) == 0 {
+			continue
+		}
+		buf.WriteByte('\n')
+		for i, p := range ps {
+			if i > 0 {
+				buf.WriteString("  >\n")
+			}
+			fmt.Fprintf(buf, "  > %s\n", mdEscape(p))
+		}
+		quoted = true
+	}
+	if quoted {
+		buf.WriteByte('\n')
+	}
+}
+
+// markdownHeading returns the group heading for a link date, or nil for
+// "none".
+func markdownHeading(groupBy string) (func(time.Time) string, bool) {
+	switch groupBy {
+	case "", "none":
+		return nil, true
+	case "day":
+		return func(t time.Time) string { return t.Format("Monday, January 2, 2006") }, true
+	case "week":
+		return func(t time.Time) string {
+			monday := t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+			return "Week of " + monday.Format("January 2, 2006")
+		}, true
+	case "month":
+		return func(t time.Time) string { return t.Format("January 2006") }, true
+	case "year":
+		return func(t time.Time) string { return t.Format("2006") }, true
+	}
+	return nil, false
+}
+
+var mdEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `*`, `\*`, `_`, `\_`, "`", "\\`", "<", "&lt;", "\n", " ")
+
+// mdEscape keeps feed text from being read as Markdown or HTML.
+func mdEscape(s string) string { return mdEscaper.Replace(s) }
+
+// mdURL makes a URL safe inside (...) link syntax.
+func mdURL(u string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace(u)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func cmdMerge() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	var out, resolveFile string
+	var interactive bool
+	fs.StringVar(&out, "out", "", "merged feed file to write (required; may be one of the inputs)")
+	fs.BoolVar(&interactive, "interactive", false, "ask which copy to keep of each link the feeds hold different versions of")
+	fs.StringVar(&resolveFile, "resolve-file", "", "replay the decisions recorded in this file, and record those -interactive makes")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if out == "" || fs.NArg() < 2 {
+			badUsage(fs)
+		}
+
+		var feeds []*v1.Feed
+		for _, path := range fs.Args() {
+			f, err := mustLoad(path)
+			if err != nil {
+				die(err)
+			}
+			feeds = append(feeds, f)
+		}
+		// For -dry-run's diff, compare against what -out holds now.
+		sf.lock(out)
+		before, err := feed.OpenWith(out, loadOpts)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(before.Feed)
+
+		merged, st := feed.Merge(feeds...)
+		if len(st.Conflicts) > 0 && (interactive || resolveFile != "") {
+			res, err := newResolver(resolveFile, interactive, "newer", "older")
+			if err != nil {
+				die(err)
+			}
+			for _, c := range st.Conflicts {
+				c.Ours = feed.Find(merged, c.ID) // as settled so far
+				if proto.Equal(c.Ours, c.Theirs) {
+					continue
+				}
+				l, how, ok, err := res.resolve(c)
+				if err != nil {
+					if errors.Is(err, errAborted) {
+						fmt.Fprintln(os.Stderr, "aborted; nothing written")
+						os.Exit(1)
+					}
+					die(err)
+				}
+				if ok {
+					settle(merged, c, l)
+					msg.Infof("conflict on [%s] resolved (%s)", c.ID, how)
+				}
+			}
+			if err := res.save(); err != nil {
+				die(err)
+			}
+		}
+		if err := sf.save(out, merged); err != nil {
+			die(err)
+		}
+		msg.Infof("merged %d feeds into %s: %d links (%d duplicates dropped)", len(feeds), out, st.Links, st.Duplicates)
+	}
+}
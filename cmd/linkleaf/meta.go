@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+// metaFlag collects -meta key=value pairs (repeatable; a later value for
+// the same key wins). An empty value ("-meta key=") asks edit to delete
+// the key.
+type metaFlag map[string]string
+
+func (m metaFlag) String() string { return formatMeta(m) }
+
+func (m metaFlag) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	if !ok {
+		return fmt.Errorf("want key=value, got %q", v)
+	}
+	if err := feed.ValidateMetaKey(key); err != nil {
+		return err
+	}
+	m[key] = value
+	return nil
+}
+
+// applyMeta sets the pairs of set in meta and deletes the keys set gives
+// empty values, returning meta (allocated if needed, nil once empty).
+func applyMeta(meta map[string]string, set metaFlag) map[string]string {
+	for k, v := range set {
+		if v == "" {
+			delete(meta, k)
+			continue
+		}
+		if meta == nil {
+			meta = map[string]string{}
+		}
+		meta[k] = v
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+// formatMeta lists meta as key=value pairs sorted by key.
+func formatMeta(meta map[string]string) string {
+	var pairs []string
+	for _, k := range slices.Sorted(maps.Keys(meta)) {
+		pairs = append(pairs, k+"="+meta[k])
+	}
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request
+// duration histogram (Prometheus' defaults).
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// serveMetrics counts serve's HTTP requests for /metrics, by route pattern
+// (see http.Request.Pattern) so the number of series stays bounded.
+type serveMetrics struct {
+	mu       sync.Mutex
+	requests map[routeCode]uint64
+	latency  map[string]*histogram
+}
+
+type routeCode struct {
+	route string
+	code  int
+}
+
+type histogram struct {
+	counts []uint64 // per latencyBuckets bound, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func newServeMetrics() *serveMetrics {
+	return &serveMetrics{requests: map[routeCode]uint64{}, latency: map[string]*histogram{}}
+}
+
+// statusWriter remembers the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// wrap counts the requests h serves. h is a ServeMux, which sets the
+// pattern it matched on the request.
+func (m *serveMetrics) wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		h.ServeHTTP(sw, r)
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		m.observe(route, cmp.Or(sw.code, http.StatusOK), time.Since(start))
+	})
+}
+
+func (m *serveMetrics) observe(route string, code int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[routeCode{route, code}]++
+	h := m.latency[route]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(latencyBuckets))}
+		m.latency[route] = h
+	}
+	s := d.Seconds()
+	if i, _ := slices.BinarySearch(latencyBuckets, s); i < len(latencyBuckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += s
+}
+
+// handler serves the metrics in the Prometheus text format, with the
+// feed's size and age from cache.
+func (m *serveMetrics) handler(cache *feedCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var b strings.Builder
+		metric := func(name, typ, help string) {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		}
+
+		m.mu.Lock()
+		metric("linkleaf_http_requests_total", "counter", "HTTP requests served, by route and status code.")
+		keys := make([]routeCode, 0, len(m.requests))
+		for k := range m.requests {
+			keys = append(keys, k)
+		}
+		slices.SortFunc(keys, func(a, b routeCode) int {
+			return cmp.Or(strings.Compare(a.route, b.route), cmp.Compare(a.code, b.code))
+		})
+		for _, k := range keys {
+			fmt.Fprintf(&b, "linkleaf_http_requests_total{route=%q,code=\"%d\"} %d\n", k.route, k.code, m.requests[k])
+		}
+		metric("linkleaf_http_request_duration_seconds", "histogram", "Time taken to serve HTTP requests, by route.")
+		routes := make([]string, 0, len(m.latency))
+		for route := range m.latency {
+			routes = append(routes, route)
+		}
+		slices.Sort(routes)
+		for _, route := range routes {
+			h := m.latency[route]
+			var cum uint64
+			for i, le := range latencyBuckets {
+				cum += h.counts[i]
+				fmt.Fprintf(&b, "linkleaf_http_request_duration_seconds_bucket{route=%q,le=%q} %d\n", route, strconv.FormatFloat(le, 'g', -1, 64), cum)
+			}
+			fmt.Fprintf(&b, "linkleaf_http_request_duration_seconds_bucket{route=%q,le=\"+Inf\"} %d\n", route, h.count)
+			fmt.Fprintf(&b, "linkleaf_http_request_duration_seconds_sum{route=%q} %g\n", route, h.sum)
+			fmt.Fprintf(&b, "linkleaf_http_request_duration_seconds_count{route=%q} %d\n", route, h.count)
+		}
+		m.mu.Unlock()
+
+		// The feed metrics are left out while it can't be read; /healthz
+		// says why.
+		if f, err := cache.get(); err == nil {
+			mod, size := cache.stat()
+			metric("linkleaf_feed_links", "gauge", "Links in the feed.")
+			fmt.Fprintf(&b, "linkleaf_feed_links %d\n", len(f.Links))
+			metric("linkleaf_feed_bytes", "gauge", "Size of the feed file on disk, with its write-ahead log.")
+			fmt.Fprintf(&b, "linkleaf_feed_bytes %d\n", size)
+			metric("linkleaf_feed_last_modified_timestamp_seconds", "gauge", "When the feed file last changed, in Unix time.")
+			fmt.Fprintf(&b, "linkleaf_feed_last_modified_timestamp_seconds %d\n", mod.Unix())
+			metric("linkleaf_feed_last_modified_age_seconds", "gauge", "Seconds since the feed file last changed.")
+			fmt.Fprintf(&b, "linkleaf_feed_last_modified_age_seconds %g\n", time.Since(mod).Seconds())
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		fmt.Fprint(w, b.String())
+	}
+}
+
+// healthz answers 200 while the feed can be read, 503 with the error
+// otherwise.
+func healthz(cache *feedCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := cache.get(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, "unhealthy:", err)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	}
+}
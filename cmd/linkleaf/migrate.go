@@ -0,0 +1,125 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v2 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+func cmdMigrate() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	var out string
+	fs.StringVar(&out, "out", "", "write the upgraded feed here, leaving <file.pb> as it is")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, "")
+		if !ok {
+			badUsage(fs)
+		}
+		dest := cmp.Or(out, path)
+
+		opts := loadOpts
+		opts.NoMigrate = true
+		sf.lock(dest)
+		f, err := feed.LoadWith(path, opts)
+		if err != nil {
+			die(fmt.Errorf("load %s: %w", path, err))
+		}
+		from := f.Version
+		if !feed.NeedsMigration(f) && out == "" {
+			msg.Infof("%s is already at version %d", path, from)
+			return
+		}
+		if out == "" {
+			sf.loaded(f) // with -out the journal records a new file, as for init
+		}
+		if err := feed.Migrate(f); err != nil {
+			die(err)
+		}
+		f.GeneratedAt = feed.NowRFC3339()
+		if err := sf.save(dest, f); err != nil {
+			die(err)
+		}
+		if out != "" {
+			msg.Infof("migrated %s (version %d) to %s (version %d)", path, from, out, f.Version)
+			return
+		}
+		msg.Infof("migrated %s from version %d to %d", path, from, f.Version)
+	}
+}
+
+// cmdMigrateToV2 writes the linkleaf.v1 feed in.pb, upgraded to the
+// current version, to out.pb in the linkleaf.v2 schema (see feed.ToV2).
+// Other commands read v1 only; "migrate v2-to-v1" converts it back.
+func cmdMigrateToV2() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("migrate v1-to-v2", flag.ExitOnError)
+	return fs, func() {
+		in, out, ok := schemaArgs(fs)
+		if !ok {
+			badUsage(fs)
+		}
+		f, err := mustLoad(in)
+		if err != nil {
+			die(err)
+		}
+		g, err := feed.ToV2(f)
+		if err != nil {
+			die(invalid(fmt.Errorf("%s: %w", in, err)))
+		}
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(g)
+		if err != nil {
+			die(err)
+		}
+		if err := feed.WriteFileAtomic(out, b, 0o644); err != nil {
+			die(err)
+		}
+		msg.Infof("converted %s (linkleaf.v1, %d links) to %s (linkleaf.v2)", in, len(f.Links), out)
+	}
+}
+
+// cmdMigrateFromV2 writes the linkleaf.v2 feed in.pb to out.pb as a
+// linkleaf.v1 feed, saved like any other.
+func cmdMigrateFromV2() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("migrate v2-to-v1", flag.ExitOnError)
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		in, out, ok := schemaArgs(fs)
+		if !ok {
+			badUsage(fs)
+		}
+		b, err := os.ReadFile(in)
+		if err != nil {
+			die(err)
+		}
+		var g v2.Feed
+		if err := proto.Unmarshal(b, &g); err != nil {
+			die(invalid(fmt.Errorf("%s: not a linkleaf.v2 feed: %w", in, err)))
+		}
+		sf.lock(out)
+		f := feed.FromV2(&g)
+		if err := sf.save(out, f); err != nil {
+			die(err)
+		}
+		msg.Infof("converted %s (linkleaf.v2) to %s (linkleaf.v1, %d links)", in, out, len(f.Links))
+	}
+}
+
+// schemaArgs returns the in.pb and out.pb arguments of "migrate v1-to-v2"
+// and "v2-to-v1"; the two schemas can't share a file.
+func schemaArgs(fs *flag.FlagSet) (in, out string, ok bool) {
+	if fs.NArg() != 2 {
+		return "", "", false
+	}
+	in, out = fs.Arg(0), fs.Arg(1)
+	if filepath.Clean(in) == filepath.Clean(out) {
+		die(invalid(errors.New("out.pb must be a different file from in.pb")))
+	}
+	return in, out, true
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+func cmdMove() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("move", flag.ExitOnError)
+	var id, to string
+	fs.StringVar(&id, "id", "", "ID of the link to move (required)")
+	fs.StringVar(&to, "to", "", "1-based position, top or bottom (required)")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, "")
+		if !ok || id == "" || to == "" {
+			badUsage(fs)
+		}
+
+		var pos int // 0-based; out-of-range values are clamped by feed.Move
+		switch to {
+		case "top":
+			pos = 0
+		case "bottom":
+			pos = math.MaxInt
+		default:
+			n, err := strconv.Atoi(to)
+			if err != nil {
+				die(invalid(fmt.Errorf("-to: want a position, top or bottom, got %q", to)))
+			}
+			pos = n - 1
+		}
+
+		sf.lock(path)
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		l, err := feed.FindPrefix(f, id)
+		if err != nil {
+			die(err)
+		}
+		id = l.Id
+		from := feed.Move(f, id, pos)
+		now := feed.Index(f, id)
+		if now == from {
+			msg.Infof("[%s] already at position %d", id, now+1)
+			return
+		}
+		if err := sf.save(path, f); err != nil {
+			die(err)
+		}
+		msg.Infof("moved [%s] from position %d to %d", id, from+1, now+1)
+	}
+}
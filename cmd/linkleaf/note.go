@@ -0,0 +1,130 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+func cmdNote() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("note", flag.ExitOnError)
+	var file, id, text string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID of the link to annotate (required)")
+	fs.StringVar(&text, "m", "", "set the notes to this text instead of opening $EDITOR (\"\" clears them)")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || id == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		inline := false
+		fs.Visit(func(fl *flag.Flag) { inline = inline || fl.Name == "m" })
+
+		if !inline {
+			// Edit first, lock after: the feed stays writable while the
+			// editor is open.
+			f, err := mustLoad(file)
+			if err != nil {
+				die(err)
+			}
+			l, err := feed.FindPrefix(f, id)
+			if err != nil {
+				die(err)
+			}
+			id = l.Id
+			if text, err = editText(l.Notes, "note-*.md"); err != nil {
+				die(err)
+			}
+		}
+		text = strings.TrimSpace(text)
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		l, err := feed.FindPrefix(f, id)
+		if err != nil {
+			die(err)
+		}
+		id = l.Id
+		if text == l.Notes {
+			msg.Infof("[%s] unchanged", id)
+			return
+		}
+		l.Notes = text
+		f.GeneratedAt = feed.NowRFC3339()
+
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		if text == "" {
+			msg.Infof("cleared notes of [%s] %s", l.Id, l.Title)
+			return
+		}
+		msg.Infof("noted [%s] %s", l.Id, l.Title)
+	}
+}
+
+// editText opens text in $VISUAL or $EDITOR (vi, or notepad on Windows)
+// in a temporary file named after pattern, and returns what was saved.
+// The editor variable may carry arguments, e.g. "code --wait".
+func editText(text, pattern string) (string, error) {
+	editor := cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"))
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	tmp, err := os.CreateTemp("", "linkleaf-"+pattern)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(text)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	argv := append(strings.Fields(editor), tmp.Name())
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return "", fmt.Errorf("%s exited with status %d; nothing changed", argv[0], exit.ExitCode())
+		}
+		return "", err
+	}
+	b, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+var blankLines = regexp.MustCompile(`\n[ \t]*\n\s*`)
+
+// paragraphs splits notes on blank lines, dropping empty ones.
+func paragraphs(s string) []string {
+	var out []string
+	for _, p := range blankLines.Split(strings.ReplaceAll(s, "\r\n", "\n"), -1) {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+func cmdOpen() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("open", flag.ExitOnError)
+	var file string
+	var random, markRead bool
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.BoolVar(&random, "random", false, "open a random link (of those the filter flags select)")
+	fs.BoolVar(&markRead, "mark-read", false, "mark the link read once it's opened")
+	ff := addFilterFlags(fs)
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || random != (fs.NArg() == 0) || fs.NArg() > 1 {
+			badUsage(fs)
+		}
+		flt, err := ff.filter()
+		if err != nil {
+			die(err)
+		}
+
+		if markRead {
+			sf.lock(file)
+		}
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		l, err := pickLink(flt.Select(f).Links, fs.Arg(0), random)
+		if err != nil {
+			die(err)
+		}
+
+		if err := openBrowser(l.Url); err != nil {
+			die(fmt.Errorf("open %s: %w", l.Url, err))
+		}
+		msg.Infof("opened [%s] %s", l.Id, l.Title)
+		if !markRead || l.Read {
+			return
+		}
+		l.Read = true
+		f.GeneratedAt = feed.NowRFC3339()
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		msg.Infof("marked [%s] read", l.Id)
+	}
+}
+
+// pickLink finds the link arg names among links, by ID, else by its
+// 1-based position as "list" numbers them, else by a unique ID prefix, or
+// picks one at random.
+func pickLink(links []*v1.Link, arg string, random bool) (*v1.Link, error) {
+	if random {
+		if len(links) == 0 {
+			return nil, errors.New("no links to pick from")
+		}
+		return links[rand.IntN(len(links))], nil
+	}
+	for _, l := range links {
+		if l.Id == arg {
+			return l, nil
+		}
+	}
+	if i, err := strconv.Atoi(arg); err == nil && i >= 1 && i <= len(links) {
+		return links[i-1], nil
+	}
+	l, err := feed.FindPrefix(&v1.Feed{Links: links}, arg)
+	if errors.Is(err, feed.ErrNoLink) {
+		return nil, fmt.Errorf("no link with id or number %q", arg)
+	}
+	return l, err
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"cmp"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+// opmlDoc is an OPML 2.0 subscription list, as feed readers and blogroll
+// tools exchange them.
+type opmlDoc struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title       string `xml:"title,omitempty"`
+		DateCreated string `xml:"dateCreated,omitempty"`
+	} `xml:"head"`
+	Body struct {
+		Outlines []opmlOutline `xml:"outline"`
+	} `xml:"body"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// exportOPML lists the configured feeds as OPML, each at the URLs "build
+// -out DIR/NAME" publishes it under -base-url.
+func exportOPML() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("export opml", flag.ExitOnError)
+	var base, title, out string
+	fs.StringVar(&base, "base-url", cfg.Export.Link, "where the feeds are published: NAME/feed.xml and NAME/ under it")
+	fs.StringVar(&title, "title", "linkleaf feeds", "title of the list")
+	fs.StringVar(&out, "out", "", "output file (default: stdout)")
+	return fs, func() {
+		if fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		if base == "" {
+			die(invalid(errors.New("-base-url is required (or export.link in the config)")))
+		}
+		if len(cfg.Feeds) == 0 {
+			die(invalid(errors.New("no feeds configured (see linkleaf feeds add)")))
+		}
+		base = strings.TrimSuffix(base, "/")
+
+		doc := opmlDoc{Version: "2.0"}
+		doc.Head.Title = title
+		doc.Head.DateCreated = time.Now().UTC().Format(time.RFC1123Z)
+		for _, name := range slices.Sorted(maps.Keys(cfg.Feeds)) {
+			text := name
+			if f, err := mustLoad(cfg.Feeds[name]); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %s: %v\n", name, err)
+			} else if f.Title != "" {
+				text = f.Title
+			}
+			doc.Body.Outlines = append(doc.Body.Outlines, opmlOutline{
+				Text:    text,
+				Title:   text,
+				Type:    "rss",
+				XMLURL:  base + "/" + url.PathEscape(name) + "/feed.xml",
+				HTMLURL: base + "/" + url.PathEscape(name) + "/",
+			})
+		}
+		b, err := xml.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			die(err)
+		}
+		b = append([]byte(xml.Header), append(b, '\n')...)
+		if out == "" || out == "-" {
+			os.Stdout.Write(b)
+			return
+		}
+		if err := feed.WriteFileAtomic(out, b, 0o644); err != nil {
+			die(err)
+		}
+		msg.Infof("exported %d feeds to %s (opml)", len(doc.Body.Outlines), out)
+	}
+}
+
+// importOPML registers a feed in the config for every outline with an
+// xmlUrl, stored as DIR/NAME.pb, and with -fetch fills it with the items
+// of that RSS or Atom feed.
+func importOPML() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("import opml", flag.ExitOnError)
+	var in, dir string
+	var fetch bool
+	fs.StringVar(&in, "in", "", "OPML file (default: stdin)")
+	fs.StringVar(&dir, "dir", ".", "directory for the new feed files")
+	fs.BoolVar(&fetch, "fetch", false, "import each feed's current items into its file (like import rss -url)")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		var r io.Reader = os.Stdin
+		if in != "" && in != "-" {
+			file, err := os.Open(in)
+			if err != nil {
+				die(err)
+			}
+			defer file.Close()
+			r = file
+		}
+		var doc opmlDoc
+		if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+			die(invalid(fmt.Errorf("read OPML: %w", err)))
+		}
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			die(err)
+		}
+
+		c := cfg
+		c.Feeds = maps.Clone(cfg.Feeds)
+		if c.Feeds == nil {
+			c.Feeds = make(map[string]string)
+		}
+		var added, failed int
+		seen := map[string]bool{}
+		for _, o := range opmlFeeds(doc.Body.Outlines) {
+			if seen[o.XMLURL] {
+				continue
+			}
+			seen[o.XMLURL] = true
+			title := strings.TrimSpace(cmp.Or(o.Title, o.Text))
+			name := opmlFeedName(title, o.XMLURL)
+			if _, ok := cfg.Feeds[name]; ok {
+				msg.Infof("skipped %s: a feed named %q is already configured", o.XMLURL, name)
+				continue
+			}
+			for i, base := 2, name; c.Feeds[name] != ""; i++ {
+				name = base + "-" + strconv.Itoa(i)
+			}
+			path := filepath.Join(dir, name+".pb")
+			c.Feeds[name] = path
+			added++
+			msg.Infof("feed %q: %s (%s)", name, path, o.XMLURL)
+			if fetch {
+				if err := seedFeed(path, title, o.XMLURL, sf); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %s: %v\n", o.XMLURL, err)
+					failed++
+				}
+			}
+		}
+		if added == 0 {
+			msg.Infof("no new feeds")
+			return
+		}
+		if sf.dryRun {
+			msg.Infof("would register %d feeds", added)
+			return
+		}
+		path, err := configPath()
+		if err != nil {
+			die(err)
+		}
+		if err := writeConfig(path, c); err != nil {
+			die(err)
+		}
+		msg.Infof("registered %d feeds in %s", added, path)
+		if failed > 0 {
+			die(fmt.Errorf("couldn't fetch %d of the feeds (registered anyway)", failed))
+		}
+	}
+}
+
+// opmlFeeds flattens outlines (folders included) to those with an xmlUrl.
+func opmlFeeds(outlines []opmlOutline) []opmlOutline {
+	var out []opmlOutline
+	for _, o := range outlines {
+		if o.XMLURL != "" {
+			out = append(out, o)
+		}
+		out = append(out, opmlFeeds(o.Outlines)...)
+	}
+	return out
+}
+
+// opmlFeedName makes a feed name (see validFeedName) from an outline's
+// title, else the host of its feed URL.
+func opmlFeedName(title, xmlURL string) string {
+	if title == "" {
+		if u, err := url.Parse(xmlURL); err == nil {
+			title = strings.TrimPrefix(u.Hostname(), "www.")
+		}
+	}
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "feed"
+	}
+	return b.String()
+}
+
+// seedFeed imports the items of the RSS or Atom feed at xmlURL into the
+// feed at path, which is created titled title if missing.
+func seedFeed(path, title, xmlURL string, sf *saveFlags) error {
+	body, err := fetchBody(xmlURL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	links, warnings, err := readFeedXML(body)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %v; skipped\n", w)
+	}
+	sf.lock(path)
+	opened, err := feed.OpenWith(path, loadOpts) // a missing file starts a new feed
+	if err != nil {
+		return fmt.Errorf("load %s: %w", path, err)
+	}
+	f := opened.Feed
+	sf.loaded(f)
+	if f.Title == "" {
+		f.Title = title
+	}
+	added, dupes := importLinks(f, links, false)
+	if added > 0 || f.Title != sf.before.Title {
+		f.GeneratedAt = feed.NowRFC3339()
+		if err := sf.save(path, f); err != nil {
+			return err
+		}
+	}
+	msg.Infof("imported %d links into %s (%d already present, %d invalid)", added, path, dupes, len(warnings))
+	return nil
+}
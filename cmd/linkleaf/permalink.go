@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// permalinkModes are the accepted -permalinks values: /l/<slug>/ is a page
+// showing the link, or sends the visitor on to the link's URL.
+var permalinkModes = []string{"page", "redirect"}
+
+func addPermalinksFlag(fs *flag.FlagSet) *string {
+	return fs.String("permalinks", "page", "what /l/<slug>/ shows: page (the link on its own page) or redirect (to its URL)")
+}
+
+func checkPermalinks(mode string) {
+	if !slices.Contains(permalinkModes, mode) {
+		die(invalid(fmt.Errorf("-permalinks: want one of %s, got %q", strings.Join(permalinkModes, ", "), mode)))
+	}
+}
+
+// permalinkPages are the build pages of the links of f with a slug, each
+// under l/<slug>.
+func permalinkPages(f *v1.Feed) []sitePage {
+	var pages []sitePage
+	for _, l := range f.Links {
+		if l.Slug != "" {
+			pages = append(pages, sitePage{dir: "l/" + l.Slug, tmpl: "link.html.tmpl", title: l.Title, links: []*v1.Link{l}})
+		}
+	}
+	return pages
+}
+
+var redirectTemplate = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>{{.Title}}</title>
+<link rel="canonical" href="{{.Url}}">
+<meta http-equiv="refresh" content="0; url={{.Url}}">
+</head>
+<body>
+<p>Moved to <a href="{{.Url}}">{{.Title}}</a>.</p>
+</body>
+</html>
+`))
+
+// renderRedirect is a static page sending visitors on to l's URL, for
+// build -permalinks redirect. Only http and https URLs are sent on to.
+func renderRedirect(l *v1.Link) ([]byte, error) {
+	if err := feed.ValidateURL(l.Url); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := redirectTemplate.Execute(&buf, l); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
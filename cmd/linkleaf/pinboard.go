@@ -0,0 +1,256 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// The Pinboard v1 API (https://pinboard.in/api/) subset that bookmarking
+// clients and browser extensions use, served next to /api/v1 so they can
+// write into the feed by pointing their API base at serve. Answers are
+// XML unless the request asks for format=json; failures other than
+// authentication are a result code with status 200, as Pinboard does.
+
+func (a *apiServer) registerPinboard(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/posts/add", a.pinboard(a.pinboardAdd))
+	mux.HandleFunc("/v1/posts/all", a.pinboard(a.pinboardAll))
+	mux.HandleFunc("/v1/posts/delete", a.pinboard(a.pinboardDelete))
+}
+
+// pinboardUser returns the user named by the request's credentials, if
+// they carry one of the API tokens: auth_token=USER:TOKEN or HTTP basic
+// auth with the token as the password.
+func (a *apiServer) pinboardUser(r *http.Request) (string, bool) {
+	user, token, ok := r.BasicAuth()
+	if !ok {
+		user, token, ok = strings.Cut(r.FormValue("auth_token"), ":")
+		if !ok {
+			return "", false
+		}
+	}
+	match := 0
+	for _, t := range a.tokens {
+		match |= subtle.ConstantTimeCompare([]byte(token), []byte(t))
+	}
+	return user, match == 1
+}
+
+// pinboard authenticates a Pinboard API request and writes h's answer:
+// a value to encode, or an error whose message becomes the result code.
+func (a *apiServer) pinboard(h func(r *http.Request, user string) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxAPIBody)
+		user, ok := a.pinboardUser(r)
+		if !ok {
+			http.Error(w, "401 Forbidden", http.StatusUnauthorized)
+			return
+		}
+		v, err := h(r, user)
+		if err != nil {
+			st := status.Convert(err)
+			if st.Code() == codes.Internal || st.Code() == codes.Unavailable {
+				fmt.Fprintln(os.Stderr, "error:", st.Message())
+				v = pinboardResult("something went wrong")
+			} else {
+				v = pinboardResult(st.Message())
+			}
+		}
+		writePinboard(w, r, v)
+	}
+}
+
+// pinboardResult is the answer of a write: "done" or what went wrong.
+type pinboardResult string
+
+func writePinboard(w http.ResponseWriter, r *http.Request, v any) {
+	if r.FormValue("format") == "json" {
+		if res, ok := v.(pinboardResult); ok {
+			v = struct {
+				ResultCode string `json:"result_code"`
+			}{string(res)}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+		return
+	}
+	if res, ok := v.(pinboardResult); ok {
+		v = struct {
+			XMLName xml.Name `xml:"result"`
+			Code    string   `xml:"code,attr"`
+		}{Code: string(res)}
+	}
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	w.Write(append(b, '\n'))
+}
+
+// pinboardTags splits Pinboard's tags, separated by spaces or commas.
+func pinboardTags(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// pinboardAdd adds url (description is the title, extended the summary,
+// dt the time it was saved); with replace=no a link already in the feed
+// is left alone, else its title, summary and tags are replaced.
+func (a *apiServer) pinboardAdd(r *http.Request, _ string) (any, error) {
+	rawURL, title := r.FormValue("url"), r.FormValue("description")
+	if rawURL == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing url")
+	}
+	if title == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing description")
+	}
+	l := &v1.Link{
+		Title:   title,
+		Url:     rawURL,
+		Summary: r.FormValue("extended"),
+		Tags:    pinboardTags(r.FormValue("tags")),
+		Read:    r.FormValue("toread") == "no",
+	}
+	fields := []string{"title", "summary", "tags"}
+	if toread := r.FormValue("toread"); toread != "" {
+		fields = append(fields, "read")
+	}
+	if dt := r.FormValue("dt"); dt != "" {
+		t, err := time.Parse(time.RFC3339, dt)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "bad dt %q", dt)
+		}
+		l.Date, l.AddedAt = t.UTC().Format(feed.DateLayout), t.UTC().Format(time.RFC3339)
+		fields = append(fields, "date", "added_at")
+	}
+	_, err := a.svc.AddLink(r.Context(), &v1.AddLinkRequest{Link: l})
+	if status.Code(err) != codes.AlreadyExists {
+		if err != nil {
+			return nil, err
+		}
+		return pinboardResult("done"), nil
+	}
+	if r.FormValue("replace") == "no" {
+		return nil, status.Error(codes.AlreadyExists, "item already exists")
+	}
+	f, err := a.svc.cache.get()
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	old := feed.FindURL(f, rawURL)
+	if old == nil {
+		return nil, status.Error(codes.Unavailable, "the feed changed; retry")
+	}
+	l.Id, l.Url = old.Id, old.Url
+	if _, err := a.svc.UpdateLink(r.Context(), &v1.UpdateLinkRequest{Link: l, Fields: fields}); err != nil {
+		return nil, err
+	}
+	return pinboardResult("done"), nil
+}
+
+// pinboardAll lists the feed's links, newest first: tag (up to three,
+// space-separated) must all match, start and results page the list and
+// fromdt and todt bound the dates (by day).
+func (a *apiServer) pinboardAll(r *http.Request, user string) (any, error) {
+	req := &v1.ListLinksRequest{Tags: pinboardTags(r.FormValue("tag"))}
+	for name, dst := range map[string]*uint32{"start": &req.Offset, "results": &req.Limit} {
+		if v := r.FormValue(name); v != "" {
+			n, err := strconv.ParseUint(v, 10, 31)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "bad %s %q", name, v)
+			}
+			*dst = uint32(n)
+		}
+	}
+	for name, dst := range map[string]*string{"fromdt": &req.After, "todt": &req.Before} {
+		if v := r.FormValue(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "bad %s %q", name, v)
+			}
+			*dst = t.UTC().Format(feed.DateLayout)
+		}
+	}
+	resp, err := a.svc.ListLinks(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+	posts := make([]pinboardPost, 0, len(resp.Links))
+	for _, l := range resp.Links {
+		posts = append(posts, pinboardEntry(l))
+	}
+	if r.FormValue("format") == "json" {
+		return posts, nil
+	}
+	return struct {
+		XMLName xml.Name       `xml:"posts"`
+		User    string         `xml:"user,attr"`
+		Posts   []pinboardPost `xml:"post"`
+	}{User: user, Posts: posts}, nil
+}
+
+// pinboardEntry describes l as Pinboard would: hash is the MD5 of the URL
+// and meta that of the whole link, so it changes when the link does.
+func pinboardEntry(l *v1.Link) pinboardPost {
+	hash := md5.Sum([]byte(l.Url))
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(l)
+	meta := md5.Sum(b)
+	saved := l.AddedAt
+	if _, err := time.Parse(time.RFC3339, saved); err != nil {
+		saved = l.Date + "T00:00:00Z"
+	}
+	toread := "yes"
+	if l.Read {
+		toread = "no"
+	}
+	return pinboardPost{
+		Href:        l.Url,
+		Description: l.Title,
+		Extended:    l.Summary,
+		Hash:        hex.EncodeToString(hash[:]),
+		Meta:        hex.EncodeToString(meta[:]),
+		Time:        saved,
+		Shared:      "yes", // a served feed is public
+		ToRead:      toread,
+		Tags:        strings.Join(l.Tags, " "),
+	}
+}
+
+// pinboardDelete removes the link with url.
+func (a *apiServer) pinboardDelete(r *http.Request, _ string) (any, error) {
+	rawURL := r.FormValue("url")
+	if rawURL == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing url")
+	}
+	f, err := a.svc.cache.get()
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	l := feed.FindURL(f, rawURL)
+	if l == nil {
+		return nil, status.Error(codes.NotFound, "item not found")
+	}
+	if _, err := a.svc.DeleteLink(r.Context(), &v1.DeleteLinkRequest{Id: l.Id}); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, status.Error(codes.NotFound, "item not found")
+		}
+		return nil, err
+	}
+	return pinboardResult("done"), nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+// loadPolicy reads a policy file: the config's TOML subset with the keys
+// allowed_domains, blocked_domains, required_tags, min_tags,
+// max_title_length and summary_required_after. An empty path is no policy.
+func loadPolicy(path string) (feed.Policy, error) {
+	var p feed.Policy
+	if path == "" {
+		return p, nil
+	}
+	path, err := feed.ExpandPath(path)
+	if err != nil {
+		return p, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return p, fmt.Errorf("policy: %w", err)
+	}
+	err = parseTOML(b, func(key string, val any) error {
+		list, isList := val.([]string)
+		str, _ := val.(string)
+		switch key {
+		case "allowed_domains", "blocked_domains", "required_tags":
+			if !isList {
+				return fmt.Errorf("%s: want an array of strings", key)
+			}
+		case "min_tags", "max_title_length", "summary_required_after":
+			if isList {
+				return fmt.Errorf("%s: want a single value", key)
+			}
+		default:
+			return fmt.Errorf("unknown key %q", key)
+		}
+		var err error
+		switch key {
+		case "allowed_domains":
+			p.AllowedDomains = list
+		case "blocked_domains":
+			p.BlockedDomains = list
+		case "required_tags":
+			p.RequiredTags, err = validTags(list)
+		case "min_tags":
+			p.MinTags, err = policyCount(key, str)
+		case "max_title_length":
+			p.MaxTitleLength, err = policyCount(key, str)
+		case "summary_required_after":
+			p.SummaryRequiredAfter, err = feed.ParseDate(str)
+			if err != nil {
+				err = fmt.Errorf("%s: want YYYY-MM-DD, got %q", key, str)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return p, invalid(fmt.Errorf("%s:%w", path, err))
+	}
+	return p, nil
+}
+
+func policyCount(key, s string) (int, error) {
+	n, err := strconv.Atoi(strings.ReplaceAll(s, "_", ""))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s: want a number of 0 or more, got %q", key, s)
+	}
+	return n, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// errAborted is returned when the user declines or stdin hits EOF.
+var errAborted = errors.New("aborted")
+
+// prompter asks questions on w and reads answers line by line from r.
+type prompter struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+func newPrompter(r io.Reader, w io.Writer) *prompter {
+	return &prompter{r: bufio.NewReader(r), w: w}
+}
+
+// ask prints label (with def in brackets, if any) and returns the trimmed
+// answer, or def when the answer is empty. EOF aborts.
+func (p *prompter) ask(label, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.w, "%s [%s]: ", label, def)
+	} else {
+		fmt.Fprintf(p.w, "%s: ", label)
+	}
+	line, err := p.r.ReadString('\n')
+	if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+		fmt.Fprintln(p.w)
+		return "", errAborted
+	}
+	if s := strings.TrimSpace(line); s != "" {
+		return s, nil
+	}
+	return def, nil
+}
+
+// promptLink fills l interactively; values already set (from flags) are
+// offered as defaults. Nothing is written unless the user confirms.
+func promptLink(p *prompter, l *v1.Link) error {
+	var err error
+	ask := func(dst *string, label, def string, required bool) {
+		for err == nil {
+			*dst, err = p.ask(label, def)
+			if err != nil || *dst != "" || !required {
+				return
+			}
+			fmt.Fprintf(p.w, "%s is required\n", label)
+		}
+	}
+	ask(&l.Title, "Title", l.Title, true)
+	ask(&l.Url, "URL", l.Url, true)
+	if l.Date == "" {
+		l.Date = time.Now().Format(feed.DateLayout)
+	}
+	for err == nil {
+		ask(&l.Date, "Date (YYYY-MM-DD [HH:MM])", l.Date, true)
+		d, perr := feed.NormalizeDate(l.Date, time.Local)
+		if perr == nil {
+			l.Date = d
+		}
+		if err != nil || perr == nil {
+			break
+		}
+		fmt.Fprintf(p.w, "%q is not a YYYY-MM-DD date, or a date and time\n", l.Date)
+	}
+	ask(&l.Summary, "Summary", l.Summary, false)
+	for err == nil {
+		var csv string
+		ask(&csv, "Tags (comma-separated)", strings.Join(l.Tags, ","), false)
+		if err != nil {
+			break
+		}
+		l.Tags, err = validTags(feed.SplitTags(csv))
+		if err != nil {
+			fmt.Fprintln(p.w, err)
+			err = nil
+			continue
+		}
+		break
+	}
+	ask(&l.Via, "Via URL", l.Via, false)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(p.w, "\n  title:   %s\n  url:     %s\n  date:    %s\n  summary: %s\n  tags:    %s\n  via:     %s\n\n",
+		l.Title, l.Url, l.Date, l.Summary, strings.Join(l.Tags, ", "), l.Via)
+	answer, err := p.ask("Add this link? (y/N)", "")
+	if err != nil {
+		return err
+	}
+	if a := strings.ToLower(answer); a != "y" && a != "yes" {
+		return errAborted
+	}
+	return nil
+}
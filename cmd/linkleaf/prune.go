@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+func cmdPrune() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	var keep int
+	var before string
+	fs.IntVar(&keep, "keep", -1, "keep only the first N links (newest first)")
+	fs.StringVar(&before, "before", "", "remove links dated before YYYY-MM-DD")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, "")
+		if !ok || (keep < 0 && before == "") {
+			badUsage(fs)
+		}
+
+		sf.lock(path)
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+
+		var removed []*v1.Link
+		if before != "" {
+			cutoff, err := feed.ParseDate(before)
+			if err != nil {
+				die(invalid(fmt.Errorf("-before: %w", err)))
+			}
+			// Links whose date doesn't parse are kept: we can't tell their age.
+			removed = feed.RemoveFunc(f, func(l *v1.Link) bool {
+				d, err := feed.ParseDate(l.Date)
+				return err == nil && d.Before(cutoff)
+			})
+		}
+		if keep >= 0 {
+			removed = append(removed, feed.Prune(f, keep)...)
+		}
+
+		if len(removed) == 0 {
+			msg.Infof("nothing to prune (%d links)", len(f.Links))
+			return
+		}
+		if err := sf.save(path, f); err != nil {
+			die(err)
+		}
+		msg.Infof("pruned %d links, %d left", len(removed), len(f.Links))
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/crosspost"
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+func cmdPublish() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	var file, id, to string
+	var timeout time.Duration
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID of the link to publish (required)")
+	fs.StringVar(&to, "to", "all", "services, comma-separated: mastodon, bluesky, all configured ones, or none")
+	fs.DurationVar(&timeout, "timeout", 30*time.Second, "timeout per service")
+	sf := addSaveFlags(fs) // its -dry-run also prints the posts instead of publishing them
+	return fs, func() {
+		if file == "" || id == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		toSet := false
+		fs.Visit(func(fl *flag.Flag) { toSet = toSet || fl.Name == "to" })
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		l, err := feed.FindPrefix(f, id)
+		if err != nil {
+			die(err)
+		}
+
+		// A draft or scheduled link is released first. Announcing it is then
+		// optional: without -to, only the services configured get it.
+		released := !feed.Published(l, time.Now())
+		if released {
+			l.Draft, l.PublishAt = false, ""
+			f.GeneratedAt = feed.NowRFC3339()
+			if err := sf.save(file, f); err != nil {
+				die(err)
+			}
+			msg.Infof("released [%s] %s", l.Id, l.Title)
+		}
+		if to == "none" || released && !toSet && !anyServiceConfigured() {
+			return
+		}
+		targets, err := publishTargets(to)
+		if err != nil {
+			die(err)
+		}
+		if err := publishLink(l, targets, sf.dryRun, timeout); err != nil {
+			die(err)
+		}
+	}
+}
+
+// anyServiceConfigured reports whether "-to all" has somewhere to post.
+func anyServiceConfigured() bool {
+	_, err := publishTargets("all")
+	return err == nil
+}
+
+// addDraftsFlag adds -drafts to commands that publish the feed.
+func addDraftsFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("drafts", false, "include drafts and links scheduled for later (e.g. to preview)")
+}
+
+// public leaves out the drafts and the links scheduled for later, unless
+// drafts (-drafts) keeps them.
+func public(f *v1.Feed, drafts bool) *v1.Feed {
+	if drafts {
+		return f
+	}
+	return feed.Public(f, time.Now())
+}
+
+// publishTargets resolves a -to or -announce list: "all" is every service
+// with credentials in the config; a named one must have them.
+func publishTargets(list string) ([]crosspost.Target, error) {
+	configured := map[string]crosspost.Target{}
+	if cfg.Mastodon.Server != "" && cfg.Mastodon.Token != "" {
+		configured["mastodon"] = crosspost.Mastodon{Server: cfg.Mastodon.Server, Token: cfg.Mastodon.Token, Visibility: cfg.Mastodon.Visibility}
+	}
+	if cfg.Bluesky.Handle != "" && cfg.Bluesky.AppPassword != "" {
+		configured["bluesky"] = crosspost.Bluesky{Handle: cfg.Bluesky.Handle, AppPassword: cfg.Bluesky.AppPassword, Service: cfg.Bluesky.Service}
+	}
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+		case "all":
+			if len(configured) == 0 {
+				return nil, errors.New("no service configured: set mastodon.server and mastodon.token, or bluesky.handle and bluesky.app_password")
+			}
+			for _, n := range []string{"mastodon", "bluesky"} {
+				if _, ok := configured[n]; ok {
+					names = append(names, n)
+				}
+			}
+		case "mastodon", "bluesky":
+			if _, ok := configured[name]; !ok {
+				return nil, fmt.Errorf("%s isn't configured (see linkleaf config -h)", name)
+			}
+			names = append(names, name)
+		default:
+			return nil, fmt.Errorf("unknown service %q (want mastodon, bluesky or all)", name)
+		}
+	}
+	var out []crosspost.Target
+	for i, n := range names {
+		if !slices.Contains(names[:i], n) {
+			out = append(out, configured[n])
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no service to publish to")
+	}
+	return out, nil
+}
+
+// publishLink posts l to every target, or with dryRun prints the posts.
+// A failing service doesn't stop the others; the errors are joined.
+func publishLink(l *v1.Link, targets []crosspost.Target, dryRun bool, timeout time.Duration) error {
+	p := crosspost.Post{ID: l.Id, Title: l.Title, URL: l.Url, Summary: l.Summary, Tags: l.Tags}
+	var errs []error
+	for _, t := range targets {
+		if dryRun {
+			text := t.Text(p)
+			fmt.Printf("--- %s (%d/%d characters)\n%s\n", t.Name(), utf8.RuneCountInString(text), t.Limit(), text)
+			continue
+		}
+		u, err := t.Publish(context.Background(), p, crosspost.Options{Timeout: timeout})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("publish [%s] to %s: %w", l.Id, t.Name(), err))
+			continue
+		}
+		msg.Infof("published [%s] to %s: %s", l.Id, t.Name(), u)
+	}
+	return errors.Join(errs...)
+}
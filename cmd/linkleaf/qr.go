@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image/png"
+	"os"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/qrcode"
+)
+
+// cmdQR shows a link's URL as a QR code, to open it on a phone.
+func cmdQR() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("qr", flag.ExitOnError)
+	var file, id, out, level string
+	var scale int
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID (or unique ID prefix) of the link (required)")
+	fs.StringVar(&out, "out", "", "write a PNG image here (- for stdout) instead of drawing the code in the terminal")
+	fs.IntVar(&scale, "scale", 8, "-out: pixels per module")
+	fs.StringVar(&level, "level", "M", "error correction: L, M, Q or H (more survives damage but makes a denser code)")
+	return fs, func() {
+		if file == "" || id == "" || fs.NArg() != 0 || scale < 1 {
+			badUsage(fs)
+		}
+		lvl, err := qrcode.ParseLevel(level)
+		if err != nil {
+			die(invalid(fmt.Errorf("-level: %w", err)))
+		}
+
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		l, err := feed.FindPrefix(f, id)
+		if err != nil {
+			die(err)
+		}
+		code, err := qrcode.Encode(l.Url, lvl)
+		if err != nil {
+			die(invalid(fmt.Errorf("[%s]: %w", l.Id, err)))
+		}
+		msg.Debugf("QR code version %d (%d×%d modules)", code.Version, code.Size, code.Size)
+
+		if out == "" {
+			if err := code.Terminal(os.Stdout); err != nil {
+				die(err)
+			}
+			fmt.Printf("[%s] %s\n%s\n", l.Id, l.Title, l.Url)
+			return
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, code.Image(scale)); err != nil {
+			die(err)
+		}
+		if out == "-" {
+			os.Stdout.Write(buf.Bytes())
+			return
+		}
+		if err := feed.WriteFileAtomic(out, buf.Bytes(), 0o644); err != nil {
+			die(err)
+		}
+		msg.Infof("wrote %s: QR code of [%s] %s", out, l.Id, l.Url)
+	}
+}
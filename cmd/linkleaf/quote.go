@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+// cmdQuoteAdd appends a passage from the linked page to the link's quotes:
+// the argument, "-" for stdin, or without one what is saved in the editor.
+func cmdQuoteAdd() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("quote add", flag.ExitOnError)
+	var file, id string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID of the link the passage is from (required)")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || id == "" || fs.NArg() > 1 {
+			badUsage(fs)
+		}
+		var text string
+		switch {
+		case fs.Arg(0) == "-":
+			b, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				die(err)
+			}
+			text = string(b)
+		case fs.NArg() == 1:
+			text = fs.Arg(0)
+		default:
+			var err error
+			if text, err = editText("", "quote-*.txt"); err != nil {
+				die(err)
+			}
+		}
+		text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+		if text == "" {
+			die(invalid(errors.New("empty quote; nothing added")))
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		l, err := feed.FindPrefix(f, id)
+		if err != nil {
+			die(err)
+		}
+		if slices.Contains(l.Quotes, text) {
+			msg.Infof("[%s] already has that quote", l.Id)
+			return
+		}
+		l.Quotes = append(l.Quotes, text)
+		f.GeneratedAt = feed.NowRFC3339()
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		msg.Infof("quoted [%s] %s", l.Id, l.Title)
+	}
+}
+
+// cmdQuoteList prints a link's quotes, numbered for "quote rm".
+func cmdQuoteList() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("quote list", flag.ExitOnError)
+	var file, id string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID of the link (required)")
+	return fs, func() {
+		if file == "" || id == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		l, err := feed.FindPrefix(f, id)
+		if err != nil {
+			die(err)
+		}
+		for i, q := range l.Quotes {
+			lines := strings.Split(q, "\n")
+			fmt.Printf("%2d. %s\n", i+1, lines[0])
+			for _, line := range lines[1:] {
+				fmt.Println(strings.TrimRight("    "+line, " "))
+			}
+		}
+		if len(l.Quotes) == 0 {
+			msg.Infof("[%s] has no quotes", l.Id)
+		}
+	}
+}
+
+// cmdQuoteRm removes quotes by their number in "quote list".
+func cmdQuoteRm() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("quote rm", flag.ExitOnError)
+	var file, id string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID of the link (required)")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || id == "" || fs.NArg() == 0 {
+			badUsage(fs)
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		l, err := feed.FindPrefix(f, id)
+		if err != nil {
+			die(err)
+		}
+		drop := map[int]bool{}
+		for _, a := range fs.Args() {
+			n, err := strconv.Atoi(a)
+			if err != nil || n < 1 || n > len(l.Quotes) {
+				die(notFound(fmt.Errorf("[%s] has no quote %s (see linkleaf quote list)", l.Id, a)))
+			}
+			drop[n-1] = true
+		}
+		kept := l.Quotes[:0]
+		for i, q := range l.Quotes {
+			if !drop[i] {
+				kept = append(kept, q)
+			}
+		}
+		l.Quotes = kept
+		if len(l.Quotes) == 0 {
+			l.Quotes = nil
+		}
+		f.GeneratedAt = feed.NowRFC3339()
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		msg.Infof("removed %d quotes from [%s] %s", len(drop), l.Id, l.Title)
+	}
+}
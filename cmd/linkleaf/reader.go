@@ -0,0 +1,274 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/archive"
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// cmdSave extracts the article of links' pages and keeps it as text for
+// "read", to read them offline.
+func cmdSave() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("save", flag.ExitOnError)
+	var file, id, dir string
+	var all, force bool
+	var timeout time.Duration
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID (or unique ID prefix) of the link to save")
+	fs.BoolVar(&all, "all", false, "save every unread link that has no saved article yet")
+	fs.StringVar(&dir, "dir", "articles", "directory for <id>.txt articles")
+	fs.BoolVar(&force, "force", false, "save again links that already have an article")
+	fs.DurationVar(&timeout, "timeout", 30*time.Second, "per-link timeout")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || (id == "") == !all || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+
+		// Fetch first, lock after, as archive does.
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		var links []*v1.Link
+		if id != "" {
+			l, err := feed.FindPrefix(f, id)
+			if err != nil {
+				die(err)
+			}
+			links = append(links, l)
+		} else {
+			for _, l := range f.Links {
+				if !l.Read && (force || l.ArticlePath == "") {
+					links = append(links, l)
+				}
+			}
+		}
+
+		opts := archive.Options{Timeout: timeout}
+		saved := map[string]savedArticle{}
+		failed := 0
+		for _, l := range links {
+			if l.ArticlePath != "" && !force {
+				msg.Infof("[%s] already saved: %s", l.Id, l.ArticlePath)
+				continue
+			}
+			s, err := saveArticle(dir, l, opts)
+			if err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "warning: [%s] %v\n", l.Id, err)
+				continue
+			}
+			msg.Infof("saved [%s] %s (%d words)", l.Id, s.path, s.words)
+			saved[l.Id] = s
+		}
+
+		if len(saved) > 0 {
+			sf.lock(file)
+			f, err := mustLoad(file)
+			if err != nil {
+				die(err)
+			}
+			sf.loaded(f)
+			for id, s := range saved {
+				if l := feed.Find(f, id); l != nil {
+					l.ArticlePath = s.path
+					if l.WordCount == 0 {
+						feed.SetWordCount(l, s.words)
+					}
+				}
+			}
+			f.GeneratedAt = feed.NowRFC3339()
+			if err := sf.save(file, f); err != nil {
+				die(err)
+			}
+		}
+		if all {
+			msg.Infof("saved %d articles, %d failed", len(saved), failed)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+type savedArticle struct {
+	path  string
+	words int
+}
+
+// saveArticle writes the article of l's page to dir/<id>.txt: a heading
+// with its title, URL, byline and the date, then its text.
+func saveArticle(dir string, l *v1.Link, opts archive.Options) (savedArticle, error) {
+	path, err := linkFile(dir, l.Id, ".txt")
+	if err != nil {
+		return savedArticle{}, err
+	}
+	a, err := archive.Readable(context.Background(), l.Url, opts)
+	if err != nil {
+		return savedArticle{}, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n%s\n", cmp.Or(a.Title, l.Title), l.Url)
+	if a.Byline != "" {
+		fmt.Fprintf(&b, "By %s\n", a.Byline)
+	}
+	fmt.Fprintf(&b, "Saved %s\n\n%s\n", time.Now().UTC().Format(time.DateOnly), a.Text)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return savedArticle{}, err
+	}
+	if err := feed.WriteFileAtomic(path, []byte(b.String()), 0o644); err != nil {
+		return savedArticle{}, err
+	}
+	return savedArticle{filepath.ToSlash(path), len(strings.Fields(a.Text))}, nil
+}
+
+// linkFile is dir/<id><ext>, refusing an ID that would name a file
+// outside dir (see feed.ValidateID), as IDs of hand-edited or old feeds
+// may.
+func linkFile(dir, id, ext string) (string, error) {
+	if err := feed.ValidateID(id); err != nil {
+		return "", fmt.Errorf("[%s]: no file name: %w", id, err)
+	}
+	path := filepath.Join(dir, id+ext)
+	if rel, err := filepath.Rel(dir, path); err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("[%s]: %s is outside %s", id, path, dir)
+	}
+	return path, nil
+}
+
+// cmdRead shows a link's saved article, wrapped to the terminal and paged.
+func cmdRead() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("read", flag.ExitOnError)
+	var file, id string
+	var width int
+	var noPager, markRead bool
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID (or unique ID prefix) of the link (required)")
+	fs.IntVar(&width, "width", 0, "wrap lines at this many columns (default: the terminal's, at most 80; -1 doesn't wrap)")
+	fs.BoolVar(&noPager, "no-pager", false, "write to stdout even when it's a terminal")
+	fs.BoolVar(&markRead, "mark-read", false, "mark the link read once it's been shown")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || id == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+
+		if markRead {
+			sf.lock(file)
+		}
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		l, err := feed.FindPrefix(f, id)
+		if err != nil {
+			die(err)
+		}
+		if l.ArticlePath == "" {
+			die(notFound(fmt.Errorf("[%s] has no saved article; run linkleaf save -id %s", l.Id, l.Id)))
+		}
+		b, err := os.ReadFile(filepath.FromSlash(l.ArticlePath))
+		if err != nil {
+			die(err)
+		}
+
+		if width == 0 {
+			width = min(cmp.Or(outputWidth(), 80), 80)
+		}
+		text := wrapArticle(string(b), width)
+		if err := showPaged(text, noPager); err != nil {
+			die(err)
+		}
+		if !markRead || l.Read {
+			return
+		}
+		l.Read = true
+		f.GeneratedAt = feed.NowRFC3339()
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		msg.Infof("marked [%s] read", l.Id)
+	}
+}
+
+// paraPrefix matches the markers an article paragraph starts with: quote
+// levels, then a heading, bullet or number.
+var paraPrefix = regexp.MustCompile(`^(> )*(#+ |• |\d+\. )?`)
+
+// wrapArticle wraps the paragraphs of a saved article at width columns
+// (none if width < 0), hanging lines under their paragraph's marker and
+// leaving indented (preformatted) ones alone.
+func wrapArticle(text string, width int) string {
+	if width < 0 {
+		return text
+	}
+	paras := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n")
+	for i, p := range paras {
+		if strings.HasPrefix(p, "    ") || strings.HasPrefix(p, "\t") {
+			continue
+		}
+		var lines []string
+		for _, line := range strings.Split(p, "\n") {
+			prefix := paraPrefix.FindString(line)
+			hang := strings.Repeat("> ", strings.Count(prefix, "> "))
+			hang += strings.Repeat(" ", len([]rune(prefix))-len(hang))
+			w := wrap(line[len(prefix):], max(width-len(hang), 20), hang)
+			lines = append(lines, prefix+strings.TrimPrefix(w, hang))
+		}
+		paras[i] = strings.Join(lines, "\n")
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// showPaged writes text to stdout through $PAGER (default less) when stdout is
+// a terminal, else directly.
+func showPaged(text string, noPager bool) error {
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less"
+		if runtime.GOOS == "windows" {
+			pager = "more"
+		}
+	}
+	if noPager || pager == "cat" || !term.IsTerminal(os.Stdout.Fd()) {
+		_, err := os.Stdout.WriteString(text)
+		return err
+	}
+	argv := strings.Fields(pager)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = strings.NewReader(text), os.Stdout, os.Stderr
+	if os.Getenv("LESS") == "" {
+		// Quit at once if the article fits on a screen.
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return nil // quit by the reader
+		}
+		// No pager to run.
+		_, err := os.Stdout.WriteString(text)
+		return err
+	}
+	return nil
+}
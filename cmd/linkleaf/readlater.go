@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// Readers for the exports of bookmarking and read-later services. Each
+// keeps the time a link was saved (date and added_at), its tags and what
+// the service knows about read and favorite state.
+
+// savedLink builds an imported link, or fails for a non-web URL or a tag
+// linkleaf can't store. Whitespace inside tags becomes '-'.
+func savedLink(title, href string, added time.Time, tags []string) (*v1.Link, error) {
+	href = strings.TrimSpace(href)
+	if !strings.HasPrefix(href, "http://") && !strings.HasPrefix(href, "https://") {
+		return nil, fmt.Errorf("not a web URL: %q", href)
+	}
+	if title = strings.Join(strings.Fields(title), " "); title == "" {
+		title = href
+	}
+	if added.IsZero() {
+		added = time.Now()
+	}
+	added = added.UTC()
+	var clean []string
+	for _, t := range tags {
+		if t = strings.Join(strings.Fields(t), "-"); t != "" {
+			clean = append(clean, t)
+		}
+	}
+	clean, err := validTags(clean)
+	if err != nil {
+		return nil, err
+	}
+	l := &v1.Link{
+		Title:   title,
+		Url:     href,
+		Date:    added.Format(feed.DateLayout),
+		AddedAt: added.Format(time.RFC3339),
+		Tags:    clean,
+	}
+	l.Id = feed.LinkID(l.Url, l.Date)
+	return l, nil
+}
+
+// unixTime parses seconds since the epoch; zero if s isn't one.
+func unixTime(s string) time.Time {
+	secs, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || secs <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+var pocketSection = regexp.MustCompile(`(?is)<h1[^>]*>([^<]*)</h1>|<a\b([^>]*)>([^<]*)</a>`)
+
+// readPocket parses a Pocket export: the older ril_export.html (lists
+// under "Unread" and "Read Archive" headings) or the newer CSV (title,
+// url, time_added, tags joined by '|', status unread or archive).
+// Archived links are marked read.
+func readPocket(r io.Reader) ([]*v1.Link, []lineWarning, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read pocket export: %w", err)
+	}
+	if t := bytes.TrimSpace(b); len(t) > 0 && t[0] == '<' {
+		return readPocketHTML(string(b))
+	}
+	return readServiceCSV(bytes.NewReader(b), "pocket", []string{"url"}, func(get func(string) string) (*v1.Link, error) {
+		l, err := savedLink(get("title"), get("url"), unixTime(get("time_added")), strings.Split(get("tags"), "|"))
+		if err != nil {
+			return nil, err
+		}
+		l.Read = get("status") == "archive"
+		return l, nil
+	})
+}
+
+func readPocketHTML(doc string) ([]*v1.Link, []lineWarning, error) {
+	lineAt := func(off int) int { return 1 + strings.Count(doc[:off], "\n") }
+	var links []*v1.Link
+	var warnings []lineWarning
+	archive := false
+	for _, m := range pocketSection.FindAllStringSubmatchIndex(doc, -1) {
+		if m[2] >= 0 {
+			archive = strings.Contains(strings.ToLower(doc[m[2]:m[3]]), "archive")
+			continue
+		}
+		attrs := bookmarkAttrs(doc[m[4]:m[5]])
+		title := strings.TrimSpace(html.UnescapeString(doc[m[6]:m[7]]))
+		l, err := savedLink(title, attrs["href"], unixTime(attrs["time_added"]), feed.SplitTags(attrs["tags"]))
+		if err != nil {
+			warnings = append(warnings, lineWarning{lineAt(m[0]), err})
+			continue
+		}
+		l.Read = archive
+		links = append(links, l)
+	}
+	return links, warnings, nil
+}
+
+// pinboardPost is one entry of Pinboard's JSON export
+// (https://pinboard.in/export/format:json/), also how serve's Pinboard
+// API lists links.
+type pinboardPost struct {
+	Href        string `xml:"href,attr" json:"href"`
+	Description string `xml:"description,attr" json:"description"` // the title
+	Extended    string `xml:"extended,attr" json:"extended"`
+	Hash        string `xml:"hash,attr" json:"hash"`
+	Meta        string `xml:"meta,attr" json:"meta"`
+	Time        string `xml:"time,attr" json:"time"`
+	Shared      string `xml:"shared,attr" json:"shared"`
+	ToRead      string `xml:"toread,attr" json:"toread"`
+	Tags        string `xml:"tag,attr" json:"tags"` // space-separated
+}
+
+// readPinboard parses Pinboard's JSON export: description is the title,
+// extended the summary; links not marked "to read" are marked read.
+// Warnings give the line each entry starts on.
+func readPinboard(r io.Reader) ([]*v1.Link, []lineWarning, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read pinboard export: %w", err)
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	if tok, err := d.Token(); err != nil || tok != json.Delim('[') {
+		return nil, nil, fmt.Errorf("read pinboard export: want a JSON array (export format:json)")
+	}
+	var links []*v1.Link
+	var warnings []lineWarning
+	for d.More() {
+		line := 1 + bytes.Count(b[:d.InputOffset()], []byte("\n"))
+		var p pinboardPost
+		if err := d.Decode(&p); err != nil {
+			return nil, nil, fmt.Errorf("read pinboard export: line %d: %w", line, err)
+		}
+		added, _ := time.Parse(time.RFC3339, p.Time)
+		l, err := savedLink(p.Description, p.Href, added, strings.Fields(p.Tags))
+		if err != nil {
+			warnings = append(warnings, lineWarning{line, err})
+			continue
+		}
+		l.Summary = strings.TrimSpace(p.Extended)
+		l.Read = p.ToRead != "yes"
+		links = append(links, l)
+	}
+	return links, warnings, nil
+}
+
+// readRaindrop parses Raindrop.io's CSV export (id, title, note, excerpt,
+// url, folder, tags, created, cover, highlights, favorite): the excerpt
+// becomes the summary, the note the notes, the folder a tag (except
+// Unsorted) and favorites are starred.
+func readRaindrop(r io.Reader) ([]*v1.Link, []lineWarning, error) {
+	return readServiceCSV(r, "raindrop", []string{"url", "created"}, func(get func(string) string) (*v1.Link, error) {
+		added, _ := time.Parse(time.RFC3339, get("created"))
+		tags := strings.Split(get("tags"), ",")
+		if folder := get("folder"); folder != "" && !strings.EqualFold(folder, "unsorted") {
+			tags = append([]string{feed.Slugify(folder)}, tags...)
+		}
+		l, err := savedLink(get("title"), get("url"), added, tags)
+		if err != nil {
+			return nil, err
+		}
+		l.Summary = get("excerpt")
+		l.Notes = get("note")
+		l.Starred = get("favorite") == "true"
+		return l, nil
+	})
+}
+
+// readShaarli parses Shaarli's export (Tools > Export), a Netscape
+// bookmark file: TAGS is comma-separated, the <DD> description's first
+// paragraph becomes the summary and the rest the notes, and private links
+// become drafts. Notes without a URL of their own are skipped.
+func readShaarli(r io.Reader) ([]*v1.Link, []lineWarning, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read shaarli export: %w", err)
+	}
+	doc := string(b)
+	lineAt := func(off int) int { return 1 + strings.Count(doc[:off], "\n") }
+	// text returns the unescaped text from off up to the next tag, lines
+	// kept: descriptions are Markdown.
+	text := func(off int) string {
+		end := strings.IndexByte(doc[off:], '<')
+		if end < 0 {
+			end = len(doc) - off
+		}
+		return strings.TrimSpace(strings.ReplaceAll(html.UnescapeString(doc[off:off+end]), "\r\n", "\n"))
+	}
+	var links []*v1.Link
+	var warnings []lineWarning
+	var last *v1.Link // link a <DD> would describe
+	for _, m := range bookmarkTag.FindAllStringSubmatchIndex(doc, -1) {
+		if m[3] > m[2] {
+			continue // closing tag
+		}
+		switch strings.ToLower(doc[m[4]:m[5]]) {
+		case "dd":
+			if last != nil {
+				ps := strings.SplitN(text(m[1]), "\n\n", 2)
+				last.Summary = strings.Join(strings.Fields(ps[0]), " ")
+				if len(ps) > 1 {
+					last.Notes = strings.TrimSpace(ps[1])
+				}
+			}
+			last = nil
+		case "dt":
+			last = nil
+		case "a":
+			attrs := bookmarkAttrs(doc[m[6]:m[7]])
+			l, err := savedLink(text(m[1]), attrs["href"], unixTime(attrs["add_date"]), strings.FieldsFunc(attrs["tags"], func(r rune) bool { return r == ',' || r == ' ' }))
+			if err != nil {
+				warnings = append(warnings, lineWarning{lineAt(m[0]), err})
+				last = nil
+				continue
+			}
+			l.Draft = attrs["private"] == "1"
+			links = append(links, l)
+			last = l
+		}
+	}
+	return links, warnings, nil
+}
+
+// wallabagEntry is one article of wallabag's JSON export.
+type wallabagEntry struct {
+	Title       string       `json:"title"`
+	URL         string       `json:"url"`
+	OriginURL   string       `json:"origin_url"`
+	Tags        []string     `json:"tags"`
+	IsArchived  wallabagFlag `json:"is_archived"`
+	IsStarred   wallabagFlag `json:"is_starred"`
+	CreatedAt   string       `json:"created_at"`
+	Language    string       `json:"language"`
+	ReadingTime int          `json:"reading_time"`
+	Annotations []struct {
+		Quote string `json:"quote"`
+		Text  string `json:"text"`
+	} `json:"annotations"`
+}
+
+// wallabagFlag is a flag wallabag exports as 0/1 (older versions) or as a
+// JSON boolean.
+type wallabagFlag bool
+
+func (w *wallabagFlag) UnmarshalJSON(b []byte) error {
+	switch string(b) {
+	case "1", "true":
+		*w = true
+	case "0", "false", "null":
+		*w = false
+	default:
+		return fmt.Errorf("want 0, 1, true or false, got %s", b)
+	}
+	return nil
+}
+
+// readWallabag parses wallabag's JSON export (all articles, or a tag's):
+// archived articles are marked read, starred ones starred, origin_url
+// becomes via, the language and reading time are kept, and annotations
+// become quotes (the highlighted passage) and notes (the comment, one
+// paragraph each). The saved article text isn't
+// imported; "linkleaf save" keeps a copy of its own.
+func readWallabag(r io.Reader) ([]*v1.Link, []lineWarning, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read wallabag export: %w", err)
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	if tok, err := d.Token(); err != nil || tok != json.Delim('[') {
+		return nil, nil, fmt.Errorf("read wallabag export: want a JSON array (export as json)")
+	}
+	var links []*v1.Link
+	var warnings []lineWarning
+	for d.More() {
+		line := 1 + bytes.Count(b[:d.InputOffset()], []byte("\n"))
+		var e wallabagEntry
+		if err := d.Decode(&e); err != nil {
+			return nil, nil, fmt.Errorf("read wallabag export: line %d: %w", line, err)
+		}
+		added, err := time.Parse(time.RFC3339, e.CreatedAt)
+		if err != nil {
+			added, _ = time.Parse("2006-01-02T15:04:05-0700", e.CreatedAt)
+		}
+		l, err := savedLink(e.Title, e.URL, added, e.Tags)
+		if err != nil {
+			warnings = append(warnings, lineWarning{line, err})
+			continue
+		}
+		l.Read, l.Starred = bool(e.IsArchived), bool(e.IsStarred)
+		if strings.HasPrefix(e.OriginURL, "http://") || strings.HasPrefix(e.OriginURL, "https://") {
+			l.Via = e.OriginURL
+		}
+		if lang, err := feed.NormalizeLang(e.Language); err == nil {
+			l.Lang = lang
+		}
+		if e.ReadingTime > 0 {
+			l.ReadingMinutes = uint32(e.ReadingTime)
+		}
+		var notes []string
+		for _, a := range e.Annotations {
+			if q := strings.Join(strings.Fields(a.Quote), " "); q != "" && !slices.Contains(l.Quotes, q) {
+				l.Quotes = append(l.Quotes, q)
+			}
+			if t := strings.TrimSpace(a.Text); t != "" {
+				notes = append(notes, t)
+			}
+		}
+		l.Notes = strings.Join(notes, "\n\n")
+		links = append(links, l)
+	}
+	return links, warnings, nil
+}
+
+// readServiceCSV reads a CSV export with a header row naming at least the
+// required columns, building a link per row with build.
+func readServiceCSV(r io.Reader, service string, required []string, build func(get func(string) string) (*v1.Link, error)) ([]*v1.Link, []lineWarning, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read %s export: %w", service, err)
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range required {
+		if _, ok := col[name]; !ok {
+			return nil, nil, fmt.Errorf("read %s export: no %q column in the header", service, name)
+		}
+	}
+	var links []*v1.Link
+	var warnings []lineWarning
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read %s export: %w", service, err)
+		}
+		get := func(name string) string {
+			if i, ok := col[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		l, err := build(get)
+		if err != nil {
+			warnings = append(warnings, lineWarning{line, err})
+			continue
+		}
+		links = append(links, l)
+	}
+	return links, warnings, nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/pagemeta"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+func cmdRefresh() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("refresh", flag.ExitOnError)
+	ff := addFilterFlags(fs)
+	var file, ids string
+	var concurrency int
+	var perHost time.Duration
+	var onlyEmpty bool
+	var fo pagemeta.Options
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&ids, "ids", "", "only these links, by comma-separated IDs")
+	fs.IntVar(&concurrency, "concurrency", 4, "max parallel requests")
+	fs.DurationVar(&perHost, "per-host", time.Second, "min time between requests to the same host")
+	fs.BoolVar(&onlyEmpty, "only-empty", false, "only fill in empty titles, summaries and languages; keep the others")
+	fs.DurationVar(&fo.Timeout, "timeout", 10*time.Second, "per-request timeout")
+	fs.StringVar(&fo.UserAgent, "user-agent", pagemeta.DefaultUserAgent, "User-Agent header")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || concurrency < 1 || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		flt, err := ff.filter()
+		if err != nil {
+			die(err)
+		}
+
+		// Fetch first, lock after, as archive does: a few hundred pages take
+		// a while, and the results are applied to a fresh load by ID.
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		links := flt.Apply(f.Links)
+		if ids != "" {
+			var picked []*v1.Link
+			for _, id := range strings.Split(ids, ",") {
+				l, err := feed.FindPrefix(f, strings.TrimSpace(id))
+				if err != nil {
+					die(err)
+				}
+				if flt.Match(l) {
+					picked = append(picked, l)
+				}
+			}
+			links = picked
+		}
+
+		metas, errs := fetchMetas(links, fo, concurrency, perHost)
+		changes := map[string][]feed.FieldChange{}
+		failed := 0
+		for i, l := range links {
+			if errs[i] != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "warning: [%s] %v\n", l.Id, errs[i])
+				continue
+			}
+			m := metas[i]
+			var cs []feed.FieldChange
+			if m.Title != "" && m.Title != l.Title && (l.Title == "" || !onlyEmpty) {
+				cs = append(cs, feed.FieldChange{Field: "title", Old: l.Title, New: m.Title})
+			}
+			if m.Description != "" && m.Description != l.Summary && (l.Summary == "" || !onlyEmpty) {
+				cs = append(cs, feed.FieldChange{Field: "summary", Old: l.Summary, New: m.Description})
+			}
+			if lang, _ := feed.NormalizeLang(m.Lang); lang != "" && lang != l.Lang && (l.Lang == "" || !onlyEmpty) {
+				cs = append(cs, feed.FieldChange{Field: "lang", Old: l.Lang, New: lang})
+			}
+			if m.Words > 0 && uint32(m.Words) != l.WordCount && (l.WordCount == 0 || !onlyEmpty) {
+				cs = append(cs, feed.FieldChange{Field: "word_count", Old: wordCount(l.WordCount), New: strconv.Itoa(m.Words)})
+			}
+			if len(cs) == 0 {
+				continue
+			}
+			changes[l.Id] = cs
+			if sf.dryRun {
+				continue // save prints the same changes
+			}
+			fmt.Printf("~ [%s] %s\n", l.Id, l.Title)
+			for _, c := range cs {
+				fmt.Printf("    %s: %q -> %q\n", c.Field, c.Old, c.New)
+			}
+		}
+
+		if len(changes) > 0 {
+			sf.lock(file)
+			f, err := mustLoad(file)
+			if err != nil {
+				die(err)
+			}
+			sf.loaded(f)
+			for id, cs := range changes {
+				l := feed.Find(f, id)
+				if l == nil {
+					continue
+				}
+				for _, c := range cs {
+					switch c.Field {
+					case "title":
+						l.Title = c.New
+					case "summary":
+						l.Summary = c.New
+					case "lang":
+						l.Lang = c.New
+					case "word_count":
+						n, _ := strconv.Atoi(c.New)
+						feed.SetWordCount(l, n)
+					}
+				}
+			}
+			f.GeneratedAt = feed.NowRFC3339()
+			if err := sf.save(file, f); err != nil {
+				die(err)
+			}
+		}
+		msg.Infof("refreshed %d links, %d unchanged, %d failed", len(changes), len(links)-len(changes)-failed, failed)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+// fetchMetas fetches the metadata of every link, at most concurrency at a
+// time and no more often than once per perHost for any one host. Results
+// are in link order.
+func fetchMetas(links []*v1.Link, fo pagemeta.Options, concurrency int, perHost time.Duration) ([]pagemeta.Meta, []error) {
+	metas := make([]pagemeta.Meta, len(links))
+	errs := make([]error, len(links))
+	var mu sync.Mutex
+	next := map[string]time.Time{} // host → earliest time of its next request
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, l := range links {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			host := feed.Host(l.Url)
+			mu.Lock()
+			now := time.Now()
+			at := next[host]
+			if at.Before(now) {
+				at = now
+			}
+			next[host] = at.Add(perHost)
+			mu.Unlock()
+			time.Sleep(at.Sub(now))
+			metas[i], errs[i] = pagemeta.Fetch(context.Background(), l.Url, fo)
+			msg.Debugf("fetched %s: title=%q description=%q lang=%q words=%d", l.Url, metas[i].Title, metas[i].Description, metas[i].Lang, metas[i].Words)
+		}()
+	}
+	wg.Wait()
+	return metas, errs
+}
+
+// wordCount shows a link's word count as a change's old value: "" when it
+// was never counted.
+func wordCount(n uint32) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(int(n))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// assignID gives l an ID from gen that no link in f has, warning when the
+// generated one was taken and got a suffix.
+func assignID(f *v1.Feed, gen feed.IDGenerator, l *v1.Link) {
+	id, base := feed.NewID(f, gen, l)
+	if id != base {
+		fmt.Fprintf(os.Stderr, "warning: id %s is taken; %s gets %s\n", base, l.Url, id)
+	}
+	l.Id = id
+}
+
+// cmdReid moves links to another ID scheme, keeping their relations.
+func cmdReid() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("reid", flag.ExitOnError)
+	var file, scheme, match string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&scheme, "id-scheme", defaultIDScheme(), "ID generator: "+strings.Join(feed.IDSchemes(), ", "))
+	fs.StringVar(&match, "match", "", "search query picking the links (default: all)")
+	ff := addFilterFlags(fs)
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		gen, err := feed.IDScheme(scheme)
+		if err != nil {
+			die(invalid(err))
+		}
+		flt, err := ff.filter()
+		if err != nil {
+			die(err)
+		}
+		q, err := feed.ParseQuery(match)
+		if err != nil {
+			die(invalid(err))
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		links := q.Apply(flt.Select(f).Links)
+		renamed := feed.ReID(f, links, gen)
+		if len(renamed) == 0 {
+			msg.Infof("%d links already have %s IDs", len(links), scheme)
+			return
+		}
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		was := map[string]string{}
+		for old, id := range renamed {
+			was[id] = old
+		}
+		for _, l := range links {
+			if old, ok := was[l.Id]; ok {
+				msg.Infof("[%s] -> [%s] %s", old, l.Id, l.Title)
+			}
+		}
+		msg.Infof("re-identified %d of %d links with %s IDs", len(renamed), len(links), scheme)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+func cmdRelate() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("relate", flag.ExitOnError)
+	var file, id string
+	var to stringsFlag
+	var both, remove bool
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID of the link that refers to the others (required)")
+	fs.Var(&to, "to", "ID of a related link (required, repeatable)")
+	fs.BoolVar(&both, "both", false, "also relate each -to link back to -id")
+	fs.BoolVar(&remove, "remove", false, "remove the relations instead of adding them")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || id == "" || len(to) == 0 || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		from, err := feed.FindPrefix(f, id)
+		if err != nil {
+			die(err)
+		}
+		id = from.Id
+		var targets []*v1.Link
+		for _, t := range to {
+			l, err := feed.FindPrefix(f, t)
+			switch {
+			case err != nil && remove:
+				// A dangling reference (see validate) can still be removed.
+				l = &v1.Link{Id: t}
+			case err != nil:
+				die(err)
+			case l == from:
+				die(invalid(fmt.Errorf("[%s] can't relate to itself", id)))
+			}
+			targets = append(targets, l)
+		}
+
+		changed := 0
+		for _, l := range targets {
+			pairs := [][2]*v1.Link{{from, l}}
+			if both {
+				pairs = append(pairs, [2]*v1.Link{l, from})
+			}
+			for _, p := range pairs {
+				var ok bool
+				if remove {
+					ok = feed.Unrelate(p[0], p[1].Id)
+				} else {
+					ok = feed.Relate(p[0], p[1])
+				}
+				if ok {
+					changed++
+					msg.Debugf("[%s] → [%s]", p[0].Id, p[1].Id)
+				}
+			}
+		}
+		if changed == 0 {
+			msg.Infof("[%s] unchanged", id)
+			return
+		}
+		f.GeneratedAt = feed.NowRFC3339()
+
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		verb := "added"
+		if remove {
+			verb = "removed"
+		}
+		msg.Infof("%s %d relations for [%s] %s", verb, changed, from.Id, from.Title)
+	}
+}
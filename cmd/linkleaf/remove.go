@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+func cmdRemove() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("remove", flag.ExitOnError)
+	var file, id, url string
+	var permanent bool
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID of the link to remove")
+	fs.StringVar(&url, "url", "", "remove every link with exactly this URL")
+	fs.BoolVar(&permanent, "permanent", false, "delete the links for good instead of moving them to the trash")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || (id == "") == (url == "") {
+			badUsage(fs)
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+
+		del := func(l *v1.Link) bool { return l.Url == url }
+		if id != "" {
+			l, err := feed.FindPrefix(f, id)
+			if err != nil {
+				die(err)
+			}
+			del = func(o *v1.Link) bool { return o == l }
+		}
+		var removed []*v1.Link
+		if permanent {
+			removed = feed.RemoveFunc(f, del)
+		} else {
+			now := time.Now()
+			removed = feed.Trash(f, del, now)
+			if _, err := purgeExpired(f, now); err != nil {
+				die(err)
+			}
+		}
+		if len(removed) == 0 {
+			die(notFound(fmt.Errorf("no link with url %q", url)))
+		}
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		for _, l := range removed {
+			if permanent {
+				msg.Infof("removed [%s] %s", l.Id, l.Title)
+			} else {
+				msg.Infof("removed [%s] %s; linkleaf trash restore %s brings it back", l.Id, l.Title, l.Id)
+			}
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// decision is how one conflict was settled, as -resolve-file records it.
+// It applies again only to the same pair of versions.
+type decision struct {
+	ID     string `json:"id"`
+	Ours   string `json:"ours"`   // fingerprint of our version
+	Theirs string `json:"theirs"` // fingerprint of theirs
+	// Take is ours, theirs or edited.
+	Take string `json:"take"`
+	// Link is the edited link (protojson), for edited.
+	Link json.RawMessage `json:"link,omitempty"`
+}
+
+// resolver settles the conflicts of merge and sync from the decisions in
+// a -resolve-file and, with -interactive, by asking.
+type resolver struct {
+	p          *prompter // nil: don't ask
+	file       string    // -resolve-file; "" keeps no record
+	ours       string    // what to call the sides when asking, e.g. "local"
+	theirs     string
+	decisions  []decision
+	changed    bool
+	introduced bool
+}
+
+func newResolver(file string, interactive bool, ours, theirs string) (*resolver, error) {
+	r := &resolver{file: file, ours: ours, theirs: theirs}
+	if interactive {
+		r.p = newPrompter(os.Stdin, os.Stderr)
+	}
+	if file == "" {
+		return r, nil
+	}
+	b, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &r.decisions); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return r, nil
+}
+
+// fingerprint identifies a version of a link; "deleted" for none.
+func fingerprint(l *v1.Link) string {
+	if l == nil {
+		return "deleted"
+	}
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(l)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// resolve returns the version of c's link to keep (nil: deleted), how it
+// was picked ("kept local", "edited", ...) and whether it was settled at
+// all; skipped conflicts aren't. Quitting returns errAborted.
+func (r *resolver) resolve(c feed.Conflict) (l *v1.Link, how string, ok bool, err error) {
+	d := decision{ID: c.ID, Ours: fingerprint(c.Ours), Theirs: fingerprint(c.Theirs)}
+	for _, rec := range r.decisions {
+		if rec.ID == d.ID && rec.Ours == d.Ours && rec.Theirs == d.Theirs {
+			l, err := r.apply(c, rec)
+			return l, r.describe(rec.Take) + ", as recorded", err == nil, err
+		}
+	}
+	if r.p == nil {
+		return nil, "", false, nil
+	}
+	if d.Take, l, err = r.ask(c); err != nil || d.Take == "" {
+		return nil, "", false, err
+	}
+	if d.Take == "edited" {
+		if d.Link, err = protojson.Marshal(l); err != nil {
+			return nil, "", false, err
+		}
+	}
+	r.decisions = append(r.decisions, d)
+	r.changed = true
+	return l, r.describe(d.Take), true, nil
+}
+
+func (r *resolver) apply(c feed.Conflict, d decision) (*v1.Link, error) {
+	switch d.Take {
+	case "ours":
+		return c.Ours, nil
+	case "theirs":
+		return c.Theirs, nil
+	case "edited":
+		l := &v1.Link{}
+		if err := protojson.Unmarshal(d.Link, l); err != nil {
+			return nil, fmt.Errorf("%s: [%s]: %w", r.file, d.ID, err)
+		}
+		return l, nil
+	}
+	return nil, fmt.Errorf("%s: [%s]: take must be ours, theirs or edited, got %q", r.file, d.ID, d.Take)
+}
+
+func (r *resolver) describe(take string) string {
+	switch take {
+	case "ours":
+		return "kept " + r.ours
+	case "theirs":
+		return "kept " + r.theirs
+	}
+	return take
+}
+
+// ask shows c's differences and reads a choice: o(urs), t(heirs), e(dit),
+// s(kip) or q(uit). take is "" for a skip.
+func (r *resolver) ask(c feed.Conflict) (take string, l *v1.Link, err error) {
+	w := r.p.w
+	if !r.introduced {
+		fmt.Fprintf(w, "ours is %s, theirs is %s\n", r.ours, r.theirs)
+		r.introduced = true
+	}
+	title := c.ID
+	for _, v := range []*v1.Link{c.Ours, c.Theirs} {
+		if v != nil {
+			title = v.Title
+			break
+		}
+	}
+	fmt.Fprintf(w, "\nCONFLICT [%s] %s\n", c.ID, title)
+	switch {
+	case c.Ours == nil:
+		fmt.Fprintf(w, "    deleted in %s, changed in %s\n", r.ours, r.theirs)
+	case c.Theirs == nil:
+		fmt.Fprintf(w, "    changed in %s, deleted in %s\n", r.ours, r.theirs)
+	default:
+		for _, fc := range feed.CompareLinks(c.Ours, c.Theirs) {
+			fmt.Fprintf(w, "    %s: %s %q, %s %q\n", fc.Field, r.ours, fc.Old, r.theirs, fc.New)
+		}
+	}
+	for {
+		answer, err := r.p.ask("Keep o(urs)/t(heirs)/e(dit)/s(kip)/q(uit)", "s")
+		if err != nil {
+			return "", nil, err
+		}
+		switch strings.ToLower(answer) {
+		case "o", "ours":
+			return "ours", c.Ours, nil
+		case "t", "theirs":
+			return "theirs", c.Theirs, nil
+		case "e", "edit":
+			l, err := editConflict(c, r.ours, r.theirs)
+			if err != nil {
+				fmt.Fprintf(w, "%v\n", err)
+				continue
+			}
+			return "edited", l, nil
+		case "s", "skip":
+			return "", nil, nil
+		case "q", "quit":
+			return "", nil, errAborted
+		}
+		fmt.Fprintln(w, "answer o (keep ours), t (keep theirs), e (edit a merged version), s (leave unresolved) or q (stop)")
+	}
+}
+
+// editConflict opens our version (theirs, if we deleted it) as textproto
+// in the editor, with their differing values as comments, and returns the
+// link as saved. Its ID can't change.
+func editConflict(c feed.Conflict, ours, theirs string) (*v1.Link, error) {
+	start, other, otherName := c.Ours, c.Theirs, theirs
+	if start == nil {
+		start, other, otherName = c.Theirs, c.Ours, ours
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Merge the two versions of [%s]: edit, save and quit. Lines starting\n# with # are ignored.\n", c.ID)
+	if other != nil {
+		fmt.Fprintf(&b, "# In %s:\n", otherName)
+		for _, fc := range feed.CompareLinks(start, other) {
+			fmt.Fprintf(&b, "#   %s: %q\n", fc.Field, fc.New)
+		}
+	}
+	text, err := prototext.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(start)
+	if err != nil {
+		return nil, err
+	}
+	b.Write(textprotoField.ReplaceAll(text, []byte("$1: ")))
+	edited, err := editText(b.String(), "conflict-*.textproto")
+	if err != nil {
+		return nil, err
+	}
+	l := &v1.Link{}
+	if err := prototext.Unmarshal([]byte(edited), l); err != nil {
+		return nil, fmt.Errorf("parse edited link: %w", err)
+	}
+	if l.Id != c.ID {
+		return nil, fmt.Errorf("the edited link's id must stay %s", c.ID)
+	}
+	if l.Title == "" || l.Url == "" {
+		return nil, errors.New("the edited link needs a title and a URL")
+	}
+	return l, nil
+}
+
+// save writes the decisions back to -resolve-file if any were added.
+func (r *resolver) save() error {
+	if r.file == "" || !r.changed {
+		return nil
+	}
+	b, err := json.MarshalIndent(r.decisions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return feed.WriteFileAtomic(r.file, append(b, '\n'), 0o644)
+}
+
+// settle puts l in place of the link c is about in f, or removes it when
+// l is nil.
+func settle(f *v1.Feed, c feed.Conflict, l *v1.Link) {
+	i := feed.Index(f, c.ID)
+	switch {
+	case i < 0 && l != nil:
+		f.Links = append(f.Links, proto.Clone(l).(*v1.Link))
+	case i >= 0 && l == nil:
+		f.Links = append(f.Links[:i], f.Links[i+1:]...)
+	case i >= 0:
+		f.Links[i] = proto.Clone(l).(*v1.Link)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+func cmdRetag() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("retag", flag.ExitOnError)
+	var file, match string
+	var add, remove stringsFlag
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&match, "match", "", "search query picking the links, e.g. \"domain:github.com tag:misc\" (required)")
+	fs.Var(&add, "add-tag", "tag to add (repeatable; may contain commas)")
+	fs.Var(&remove, "remove-tag", "tag to remove, ignoring case; lang/* removes a namespace (repeatable)")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || strings.TrimSpace(match) == "" || fs.NArg() != 0 || len(add)+len(remove) == 0 {
+			badUsage(fs)
+		}
+		q, err := feed.ParseQuery(match)
+		if err != nil {
+			die(invalid(err))
+		}
+		tags, err := validTags(add)
+		if err != nil {
+			die(err)
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		links := q.Apply(f.Links)
+		n := feed.Retag(links, tags, remove)
+		if n == 0 {
+			msg.Infof("%d links match %s; none changed", len(links), q)
+			return
+		}
+		f.GeneratedAt = feed.NowRFC3339()
+
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		msg.Infof("retagged %d of %d links matching %s", n, len(links), q)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// saveFlags are the write-safety flags shared by commands that rewrite the
+// feed. Commands call loaded right after reading the feed and save instead
+// of feed.SaveWith, so every flag (notably -dry-run) applies uniformly.
+type saveFlags struct {
+	backup        bool
+	keep          int
+	deterministic bool
+	canonical     bool
+	sortIDs       bool
+	freeze        bool
+	checksum      bool
+	dryRun        bool
+	gitCommit     bool
+	wal           bool
+
+	op        string   // command name, recorded in the journal
+	noJournal bool     // set by undo, which pops the journal instead
+	format    string   // set by convert (see feed.SaveOptions.Format)
+	compress  string   // set by compact (see feed.SaveOptions.Compression)
+	loadedAt  string   // generated_at as read, for -freeze-generated-at
+	before    *v1.Feed // copy of the feed as read, for the -dry-run diff and the journal
+}
+
+func addSaveFlags(fs *flag.FlagSet) *saveFlags {
+	sf := &saveFlags{op: fs.Name()}
+	fs.BoolVar(&sf.backup, "backup", false, "copy the existing file to <file>.bak before writing")
+	fs.IntVar(&sf.keep, "keep-backups", 0, "keep N rotated backups (<file>.1 … <file>.N) instead of .bak")
+	fs.BoolVar(&sf.deterministic, "deterministic", false, "byte-stable protobuf output")
+	fs.BoolVar(&sf.canonical, "canonical", false, "canonical protobuf output: NFC strings, no unknown fields (implies -deterministic)")
+	fs.BoolVar(&sf.sortIDs, "sort-ids", false, "store links sorted by ID (reproducible regardless of add order)")
+	fs.BoolVar(&sf.freeze, "freeze-generated-at", false, "keep the loaded generated_at instead of stamping now")
+	fs.BoolVar(&sf.checksum, "checksum", false, "write a <file>.sha256 sidecar (checked by the global -verify)")
+	fs.BoolVar(&sf.dryRun, "dry-run", false, "show what would change without writing the file")
+	fs.BoolVar(&sf.gitCommit, "git-commit", false, "git add + commit the file after saving (\"add [id] title\")")
+	fs.BoolVar(&sf.wal, "wal", false, "append the change to <file>.wal instead of rewriting the file (see compact)")
+	return sf
+}
+
+// lock takes the advisory lock for path (see feed.Lock); call it before
+// loading. It is held until the process exits, so die releases it too.
+// -dry-run writes nothing and takes no lock.
+func (sf *saveFlags) lock(path string) {
+	if sf.dryRun {
+		return
+	}
+	if _, err := feed.Lock(path); err != nil {
+		die(fmt.Errorf("lock %s: %w", path, err))
+	}
+}
+
+// loaded records f as it was read, before the command changes it.
+func (sf *saveFlags) loaded(f *v1.Feed) {
+	sf.loadedAt = f.GeneratedAt
+	sf.before = proto.Clone(f).(*v1.Feed)
+}
+
+func (sf *saveFlags) options() feed.SaveOptions {
+	opts := feed.SaveOptions{
+		Backup:        sf.backup,
+		KeepBackups:   sf.keep,
+		Deterministic: sf.deterministic,
+		Canonical:     sf.canonical,
+		SortByID:      sf.sortIDs,
+		Checksum:      sf.checksum,
+		DryRun:        sf.dryRun,
+		Key:           loadOpts.Key,
+		Encrypt:       encrypt,
+		Format:        sf.format,
+		Compression:   sf.compress,
+		// git commits the file itself, so it must hold the change.
+		WAL:    sf.wal && !sf.gitCommit,
+		Before: sf.before,
+	}
+	if sf.freeze {
+		opts.GeneratedAt = sf.loadedAt
+	}
+	return opts
+}
+
+// appended returns how many links were added at the front of f since
+// before, if that is all that changed; 0 otherwise. Stream files (see
+// feed.StreamMagic) then only get those links appended.
+func appended(before, f *v1.Feed) int {
+	d := feed.Compare(before, f)
+	n := len(d.Added)
+	if n == 0 || len(d.Removed) > 0 || len(d.Modified) > 0 || f.Title != before.Title || f.Version != before.Version ||
+		len(f.Links) != n+len(before.Links) {
+		return 0
+	}
+	for i, l := range before.Links {
+		if f.Links[n+i].Id != l.Id {
+			return 0
+		}
+	}
+	return n
+}
+
+// save writes f to path, records the change in the journal and runs the
+// hooks and webhooks; links it modified get a new updated_at (see
+// feed.StampUpdated) and new links a slug (see feed.AssignSlugs). A
+// failing pre-* hook (see runHooks) stops the save.
+// Under -dry-run nothing touches the disk and no hook runs: the link diff
+// against the loaded feed is printed and later status lines are marked as
+// a dry run.
+func (sf *saveFlags) save(path string, f *v1.Feed) error {
+	before := sf.before
+	if before == nil {
+		before = &v1.Feed{}
+	}
+	feed.StampUpdated(before, f, feed.NowRFC3339())
+	feed.AssignSlugs(f) // new links get theirs here, whichever command added them
+	opts := sf.options()
+	if sf.before != nil {
+		opts.Appended = appended(before, f)
+	}
+	if !sf.dryRun {
+		if err := runHooks("pre", sf.op, path, before, f); err != nil {
+			return err
+		}
+	}
+	if err := feed.SaveWith(path, f, opts); err != nil {
+		return err
+	}
+	if sf.dryRun {
+		printDiff(feed.Compare(before, f))
+		msg.prefix = "dry run: "
+		return nil
+	}
+	// The journal and git are local; remote feeds (see package storage)
+	// get neither.
+	if storage.IsRemote(path) {
+		sf.announce(path, before, f)
+		if sf.gitCommit {
+			return fmt.Errorf("%s saved, but not committed: -git-commit needs a local file", path)
+		}
+		return nil
+	}
+	// The journal (and commit message) would hold links in the clear, so
+	// encrypted feeds get neither.
+	var e *feed.JournalEntry
+	if !feed.IsEncryptedFile(path) {
+		e = feed.NewJournalEntry(sf.op, before, f)
+	}
+	if e != nil && !sf.noJournal {
+		if err := feed.AppendJournal(path, e); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s saved, but not journaled: %v\n", path, err)
+		}
+	}
+	if !feed.IsEncryptedFile(path) {
+		updateSearchIndex(path, f)
+	}
+	sf.announce(path, before, f)
+	if sf.gitCommit {
+		if err := gitCommit(path, commitMessage(sf.op, e)); err != nil {
+			return fmt.Errorf("%s saved, but not committed: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// announce runs the post-* hooks and webhooks for a completed save; the
+// feed is saved either way, so their failures are only warnings.
+func (sf *saveFlags) announce(path string, before, f *v1.Feed) {
+	if err := runHooks("post", sf.op, path, before, f); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %s saved, but %v\n", path, err)
+	}
+	notifyWebhooks(path, sf.op, before, f)
+}
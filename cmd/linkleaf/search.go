@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/fts"
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+func cmdSearch() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	var file, tagExpr string
+	var fullText, noColor bool
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&tagExpr, "tags", "", "only links whose tags satisfy this expression, e.g. \"lang/go AND NOT topic/orm\"")
+	fs.BoolVar(&fullText, "fts", false, "full-text search of titles, summaries and notes, ranked, with the index kept in <file>.fts")
+	fs.BoolVar(&noColor, "no-color", false, "-fts: mark matches with *word* even on a terminal")
+	so := addSortFlags(fs)
+	jf := addJSONFlags(fs)
+	format := addFormatFlag(fs)
+	return fs, func() {
+		if file == "" || fs.NArg() == 0 && (tagExpr == "" || fullText) {
+			badUsage(fs)
+		}
+		var tags *feed.TagExpr
+		if tagExpr != "" {
+			var err error
+			if tags, err = feed.ParseTagExpr(tagExpr); err != nil {
+				die(invalid(fmt.Errorf("-tags: %w", err)))
+			}
+		}
+		if err := so.check(); err != nil {
+			die(err)
+		}
+		if fullText {
+			searchFullText(file, strings.Join(fs.Args(), " "), tags, so, jf, *format, colorOutput(noColor))
+			return
+		}
+		q, err := feed.ParseQuery(strings.Join(fs.Args(), " "))
+		if err != nil {
+			die(invalid(err))
+		}
+
+		flt := q.Filter()
+		flt.TagExpr = tags
+		f, err := mustSelect(file, flt, 0, 0)
+		if err != nil {
+			die(err)
+		}
+		links := q.Apply(f.Links)
+		msg.Debugf("query %s: %d of %d candidate links", q, len(links), len(f.Links))
+		if err := so.sort(links); err != nil {
+			die(err)
+		}
+		if *format != "" {
+			if err := writeFormatted(os.Stdout, *format, jf, links); err != nil {
+				die(err)
+			}
+			return
+		}
+		if jf.enabled() {
+			sel := feed.Filter{}.Select(f)
+			sel.Links = links
+			if err := jf.write(sel); err != nil {
+				die(err)
+			}
+			return
+		}
+		printList(f, links)
+	}
+}
+
+// searchFullText is "search -fts": it ranks the links matching query in the
+// feed's full-text index, which it brings up to date first. -sort and
+// -reverse replace the ranking.
+func searchFullText(file, query string, tags *feed.TagExpr, so *sortFlags, jf *jsonFlags, format string, color bool) {
+	q, err := fts.ParseQuery(query)
+	if err != nil {
+		die(err)
+	}
+	f, err := mustLoad(file)
+	if err != nil {
+		die(err)
+	}
+	x := searchIndex(file, f)
+	byID := make(map[string]*v1.Link, len(f.Links))
+	for _, l := range f.Links {
+		if _, dup := byID[l.Id]; !dup {
+			byID[l.Id] = l
+		}
+	}
+	flt := feed.Filter{TagExpr: tags}
+	var hits []fts.Hit
+	var links []*v1.Link
+	for _, h := range x.Search(q) {
+		if l := byID[h.ID]; l != nil && flt.Match(l) {
+			hits = append(hits, h)
+			links = append(links, l)
+		}
+	}
+	msg.Debugf("fts %q: %d of %d links", query, len(links), x.Len())
+	if so.set() {
+		score := make(map[*v1.Link]fts.Hit, len(links))
+		for i, l := range links {
+			score[l] = hits[i]
+		}
+		if err := so.sort(links); err != nil {
+			die(err)
+		}
+		for i, l := range links {
+			hits[i] = score[l]
+		}
+	}
+	if format != "" {
+		if err := writeFormatted(os.Stdout, format, jf, links); err != nil {
+			die(err)
+		}
+		return
+	}
+	if jf.enabled() {
+		sel := feed.Filter{}.Select(f)
+		sel.Links = links
+		if err := jf.write(sel); err != nil {
+			die(err)
+		}
+		return
+	}
+	mark := func(w string) string { return "*" + w + "*" }
+	if color {
+		mark = func(w string) string { return "\x1b[1m" + w + "\x1b[0m" }
+	}
+	fmt.Printf("Feed: %q  (version=%d, generated_at=%s)\n", f.Title, f.Version, f.GeneratedAt)
+	for i, l := range links {
+		fmt.Printf("%3d) [%s] %s  (%.2f)\n     %s\n     date=%s tags=%s\n",
+			i+1, l.Id, fts.Mark(l.Title, q, mark), hits[i].Score, l.Url, l.Date, strings.Join(l.Tags, ","))
+		for _, text := range []string{l.Summary, l.Notes} {
+			if e := fts.Excerpt(text, q, 140, mark); e != "" {
+				fmt.Printf("     %s\n", e)
+				break
+			}
+		}
+	}
+}
+
+// searchIndex returns the full-text index of the feed at path, brought up
+// to date with f. It is read from and saved to <file>.fts, except for
+// remote feeds and for encrypted ones, whose text it would hold in the
+// clear; those get one built in memory.
+func searchIndex(path string, f *v1.Feed) *fts.Index {
+	if storage.IsRemote(path) || feed.IsEncryptedFile(path) {
+		x := fts.New()
+		x.Update(f.Links)
+		return x
+	}
+	p, err := feed.ExpandPath(path)
+	if err != nil {
+		die(err)
+	}
+	x, err := fts.Load(p + fts.Suffix)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "warning: %v; indexing again\n", err)
+		}
+		x = fts.New()
+	}
+	if n := x.Update(f.Links); n > 0 || err != nil {
+		msg.Debugf("indexed %d links in %s", n, p+fts.Suffix)
+		if err := x.Save(p + fts.Suffix); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: index not saved: %v\n", err)
+		}
+	}
+	return x
+}
+
+// updateSearchIndex brings the full-text index of the feed at path up to
+// date with f after a save, if the feed has one.
+func updateSearchIndex(path string, f *v1.Feed) {
+	p, err := feed.ExpandPath(path)
+	if err != nil {
+		return
+	}
+	x, err := fts.Load(p + fts.Suffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err == nil && x.Update(f.Links) > 0 {
+		err = x.Save(p + fts.Suffix)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %s saved, but its search index not updated: %v\n", path, err)
+	}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/grpc"
+)
+
+// feedCache holds the served feed and reloads it when the file's mtime or
+// size changes. The pages and feeds only read the file; the REST,
+// Pinboard and gRPC APIs save it like the commands do, and the cache picks
+// their changes up like anyone else's.
+type feedCache struct {
+	path string
+	// indexed keeps a feed.LinkIndex of the feed (see linkIndex), for the
+	// daemon's in-memory queries.
+	indexed bool
+
+	mu    sync.Mutex
+	mod   time.Time
+	size  int64
+	feed  *v1.Feed
+	index *feed.LinkIndex
+}
+
+func (c *feedCache) get() (*v1.Feed, error) {
+	mod, size, err := feedStamp(c.path)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.feed != nil && mod.Equal(c.mod) && size == c.size {
+		return c.feed, nil
+	}
+	f, err := mustLoad(c.path)
+	if err != nil {
+		return nil, err
+	}
+	msg.Debugf("reloaded %s (%d links)", c.path, len(f.Links))
+	c.feed, c.mod, c.size = f, mod, size
+	if c.indexed {
+		c.index = feed.NewLinkIndex(f)
+	}
+	return f, nil
+}
+
+// feedStamp returns the modification time and size of the feed file at
+// path, counting its write-ahead log: what changes when the feed is saved.
+func feedStamp(path string) (time.Time, int64, error) {
+	path, err := feed.ExpandPath(path)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	mod, size := fi.ModTime(), fi.Size()
+	if wal, err := os.Stat(path + feed.WALSuffix); err == nil {
+		// Changes saved with -wal leave the file itself as it was.
+		size += wal.Size()
+		if wal.ModTime().After(mod) {
+			mod = wal.ModTime()
+		}
+	}
+	return mod, size, nil
+}
+
+// stat returns the modification time and size of the feed as last loaded
+// (see get), counting its write-ahead log.
+func (c *feedCache) stat() (time.Time, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mod, c.size
+}
+
+// linkIndex returns the index of the current feed; c must be indexed.
+func (c *feedCache) linkIndex() (*feed.LinkIndex, error) {
+	if _, err := c.get(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.index, nil
+}
+
+// find returns the link with the given ID, or nil.
+func (c *feedCache) find(id string) (*v1.Link, error) {
+	if c.indexed {
+		x, err := c.linkIndex()
+		if err != nil {
+			return nil, err
+		}
+		return x.Find(id), nil
+	}
+	f, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+	return feed.Find(f, id), nil
+}
+
+func cmdServe() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var addr, grpcAddr, token, idScheme, file string
+	fs.StringVar(&addr, "addr", ":8080", "HTTP listen address (\"\" with -grpc: gRPC only)")
+	fs.StringVar(&grpcAddr, "grpc", "", "also serve linkleaf.v1.FeedService (read-write) on this address, e.g. :9090")
+	fs.StringVar(&token, "grpc-token", os.Getenv("LINKLEAF_GRPC_TOKEN"), "bearer token gRPC calls must send (default $LINKLEAF_GRPC_TOKEN)")
+	var insecure bool
+	fs.BoolVar(&insecure, "grpc-insecure", false, "serve gRPC without -grpc-token on an address other machines can reach")
+	fs.StringVar(&idScheme, "id-scheme", defaultIDScheme(), "ID generator for AddLink: "+strings.Join(feed.IDSchemes(), ", "))
+	fs.StringVar(&file, "file", "", "protobuf feed file (.pb), instead of the positional argument")
+	var assetsDir string
+	fs.StringVar(&assetsDir, "assets", "assets", "-images: directory the images are cached in, served under /assets/")
+	permalinks := addPermalinksFlag(fs)
+	var rateLimit int
+	fs.IntVar(&rateLimit, "rate-limit", 0, "allow each client IP N HTTP requests a minute, in bursts of up to N (0: no limit)")
+	imf := addImageFlags(fs)
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		file, ok := feedArg(fs, file)
+		if !ok || addr == "" && grpcAddr == "" || rateLimit < 0 {
+			badUsage(fs)
+		}
+		checkPermalinks(*permalinks)
+		if storage.IsRemote(file) {
+			die(invalid(fmt.Errorf("serve needs a local file, got %s", file)))
+		}
+		genID, err := feed.IDScheme(idScheme)
+		if err != nil {
+			die(invalid(err))
+		}
+		cache := &feedCache{path: file}
+		if _, err := cache.get(); err != nil {
+			die(err)
+		}
+
+		svc := newFeedService(cache, genID, sf)
+		var api *apiServer
+		if len(cfg.Serve.APITokens) > 0 {
+			api = &apiServer{svc: svc, tokens: cfg.Serve.APITokens, origins: cfg.Serve.APIOrigins}
+		}
+		var ap *apServer
+		if cfg.ActivityPub.URL != "" {
+			if addr == "" {
+				die(invalid(errors.New("activitypub.url is set but -addr is empty")))
+			}
+			if ap, err = newAPServer(cache, cfg.ActivityPub.URL, cfg.ActivityPub.User); err != nil {
+				die(err)
+			}
+		}
+		var images *imageUpdater
+		if c := imf.open(assetsDir); c != nil {
+			images = &imageUpdater{cache: c, dir: assetsDir}
+		}
+		var limit *rateLimiter
+		if rateLimit > 0 {
+			limit = newRateLimiter(rateLimit)
+		}
+		var srv *http.Server
+		if addr != "" {
+			srv = &http.Server{
+				Addr:              addr,
+				Handler:           newFeedServer(cache, api, ap, images, limit, *permalinks),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+		}
+		var gs *grpc.Server
+		var lis net.Listener
+		if grpcAddr != "" {
+			if err := checkGRPCAuth(grpcAddr, token, insecure); err != nil {
+				die(invalid(err))
+			}
+			if lis, err = net.Listen("tcp", grpcAddr); err != nil {
+				die(err)
+			}
+			if token == "" && !isLoopback(grpcAddr) {
+				fmt.Fprintf(os.Stderr, "warning: anyone who can reach %s can change %s; set -grpc-token\n", grpcAddr, file)
+			}
+			gs = grpcServer(svc, token)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		stopped := make(chan struct{})
+		apDone := make(chan struct{})
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if srv != nil {
+				srv.Shutdown(shutdown)
+			}
+			if gs != nil {
+				svc.stop(gs)
+			}
+			if ap != nil {
+				close(apDone)
+				ap.wait(shutdown)
+			}
+			close(stopped)
+		}()
+		if ap != nil {
+			go ap.watch(apDone)
+		}
+
+		if gs != nil {
+			msg.Infof("serving linkleaf.v1.FeedService for %s on %s (gRPC)", cache.path, grpcAddr)
+			go func() {
+				if err := gs.Serve(lis); err != nil {
+					die(err)
+				}
+			}()
+		}
+		if srv != nil {
+			if api != nil {
+				msg.Infof("serving %s on %s (REST API under /api/v1, Pinboard API under /v1)", cache.path, addr)
+			} else {
+				msg.Infof("serving %s on %s", cache.path, addr)
+			}
+			if ap != nil {
+				msg.Infof("followable as @%s@%s (%s)", ap.user, ap.host, ap.actorID())
+			}
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				die(err)
+			}
+		}
+		<-stopped
+	}
+}
+
+// feedEndpoints are the syndication documents served next to the index.
+var feedEndpoints = []struct {
+	path, contentType, title string
+	render                   func(*v1.Feed, siteInfo) ([]byte, error)
+}{
+	{"/feed.xml", "application/rss+xml; charset=utf-8", "RSS", renderRSS},
+	{"/feed.atom", "application/atom+xml; charset=utf-8", "Atom", renderAtom},
+	{"/feed.json", "application/feed+json; charset=utf-8", "JSON Feed", renderJSONFeed},
+}
+
+// newFeedServer serves the pages and feeds (also per language
+// under /lang/<lang>/), the /l/<slug>/ permalinks (see permalinkModes),
+// /metrics and /healthz, plus api's and ap's routes and images' assets
+// unless they are nil, all but the monitoring routes rate-limited by limit
+// unless it is nil.
+func newFeedServer(cache *feedCache, api *apiServer, ap *apServer, images *imageUpdater, limit *rateLimiter, permalinks string) http.Handler {
+	mux := http.NewServeMux()
+	metrics := newServeMetrics()
+	if api != nil {
+		api.register(mux)
+	}
+	if ap != nil {
+		ap.register(mux)
+	}
+	pages := &renderCache{}
+	// respond sends the response for key, rendered from the public links
+	// only when the feed changed since it was last.
+	respond := func(w http.ResponseWriter, r *http.Request, key, contentType string, render func(*v1.Feed) ([]byte, error)) {
+		f, err := cache.get()
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		mod, size := cache.stat()
+		page, err := pages.get(key, f, mod, size, render)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		page.serve(w, r, contentType)
+	}
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		key := "/"
+		if images != nil {
+			key = "" // the page changes as images are fetched
+		}
+		respond(w, r, key, "text/html; charset=utf-8", func(f *v1.Feed) ([]byte, error) {
+			page := htmlPage{Feed: f, Permalinks: "/l/"}
+			if images != nil {
+				page.Images = pageImages(images.cache, page.Feed.Links, "/assets/")
+				images.update(page.Feed.Links)
+			}
+			for _, e := range feedEndpoints {
+				page.Alternates = append(page.Alternates, alternate{Type: mediaType(e.contentType), Title: e.title, Href: e.path})
+			}
+			return renderPage(page, "")
+		})
+	})
+	permalink := func(w http.ResponseWriter, r *http.Request) {
+		slug := r.PathValue("slug")
+		f, err := cache.get()
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		public := feed.Public(f, time.Now())
+		i := feed.SlugIndex(public, slug)
+		if i < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		if permalinks == "redirect" {
+			http.Redirect(w, r, public.Links[i].Url, http.StatusFound)
+			return
+		}
+		respond(w, r, "/l/"+slug, "text/html; charset=utf-8", func(f *v1.Feed) ([]byte, error) {
+			i := feed.SlugIndex(f, slug)
+			if i < 0 {
+				return nil, fmt.Errorf("no link with slug %q", slug)
+			}
+			page := htmlPage{Feed: feed.Filter{}.Select(f), Site: &siteNav{Root: "/"}, Related: relatedLinks(f), Permalinks: "/l/"}
+			page.Feed.Title, page.Feed.Links = f.Links[i].Title, f.Links[i:i+1]
+			return renderPage(page, "")
+		})
+	}
+	mux.HandleFunc("GET /l/{slug}", permalink)
+	mux.HandleFunc("GET /l/{slug}/", permalink)
+	for _, e := range feedEndpoints {
+		mux.HandleFunc("GET "+e.path, func(w http.ResponseWriter, r *http.Request) {
+			base := requestBase(r)
+			respond(w, r, base+e.path, e.contentType, func(f *v1.Feed) ([]byte, error) {
+				return e.render(f, siteInfo{Link: base + "/", FeedURL: base + e.path, Author: configAuthor()}.withFeed(f))
+			})
+		})
+		// The same feed with only the links in one language.
+		mux.HandleFunc("GET /lang/{lang}"+e.path, func(w http.ResponseWriter, r *http.Request) {
+			lang, err := feed.NormalizeLang(r.PathValue("lang"))
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			base, path := requestBase(r), "/lang/"+lang+e.path
+			respond(w, r, base+path, e.contentType, func(f *v1.Feed) ([]byte, error) {
+				lf := feed.Filter{Lang: lang}.Select(f)
+				return e.render(lf, siteInfo{Link: base + "/", FeedURL: base + path, Lang: lang, Author: configAuthor()}.withFeed(lf))
+			})
+		})
+	}
+	if images != nil {
+		mux.Handle("GET /assets/", http.StripPrefix("/assets/", http.FileServer(http.Dir(images.dir))))
+	}
+	mux.Handle("GET /feed.rss", http.RedirectHandler("/feed.xml", http.StatusMovedPermanently))
+	// For monitoring: Prometheus metrics, and a check that the feed loads.
+	mux.HandleFunc("GET /metrics", metrics.handler(cache))
+	mux.HandleFunc("GET /healthz", healthz(cache))
+	// The file exactly as stored, for clients that speak linkleaf.v1 themselves.
+	mux.HandleFunc("GET /raw.pb", func(w http.ResponseWriter, r *http.Request) {
+		path, err := feed.ExpandPath(cache.path)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf; messageType=linkleaf.v1.Feed")
+		http.ServeFile(w, r, path)
+	})
+	var h http.Handler = mux
+	if limit != nil {
+		h = limit.wrap(mux)
+	}
+	return metrics.wrap(h)
+}
+
+// requestBase is the scheme://host the client used to reach us.
+func requestBase(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if p := r.Header.Get("X-Forwarded-Proto"); p == "http" || p == "https" {
+		scheme = p
+	}
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
+
+func mediaType(contentType string) string {
+	mt, _, _ := strings.Cut(contentType, ";")
+	return mt
+}
+
+func serverError(w http.ResponseWriter, err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	http.Error(w, "feed unavailable", http.StatusInternalServerError)
+}
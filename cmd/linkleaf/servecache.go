@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// maxRendered bounds the responses a renderCache keeps: keys include the
+// Host the client sent, which anyone can vary.
+const maxRendered = 64
+
+// renderCache keeps serve's rendered pages and feeds, each also gzipped,
+// until the feed file changes or a scheduled link is due (see
+// feed.NextPublish), so repeated requests cost no rendering.
+type renderCache struct {
+	mu      sync.Mutex
+	mod     time.Time
+	size    int64
+	until   time.Time // zero: no scheduled link
+	entries map[string]*rendered
+}
+
+// rendered is one response body, plain and gzipped, with its validators.
+type rendered struct {
+	body, gz []byte
+	etag     string
+	modified time.Time
+}
+
+// get returns the response for key as of the feed f, last modified at mod
+// with size bytes (see feedCache.stat), rendering it on a miss. An empty
+// key renders every time.
+func (c *renderCache) get(key string, f *v1.Feed, mod time.Time, size int64, render func(*v1.Feed) ([]byte, error)) (*rendered, error) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !mod.Equal(c.mod) || size != c.size || !c.until.IsZero() && !now.Before(c.until) || len(c.entries) >= maxRendered {
+		c.mod, c.size, c.until, c.entries = mod, size, feed.NextPublish(f, now), map[string]*rendered{}
+	}
+	if e := c.entries[key]; e != nil && key != "" {
+		return e, nil
+	}
+	e, err := newRendered(f, mod, now, render)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		c.entries[key] = e
+	}
+	return e, nil
+}
+
+func newRendered(f *v1.Feed, mod, now time.Time, render func(*v1.Feed) ([]byte, error)) (*rendered, error) {
+	public := feed.Public(f, now)
+	body, err := render(public)
+	if err != nil {
+		return nil, err
+	}
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(body)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+	e := &rendered{body: body, gz: gz.Bytes(), etag: hex.EncodeToString(sum[:8]), modified: mod}
+	// A scheduled link going public changes the response, not the file.
+	for _, l := range public.Links {
+		if t, err := feed.ParsePublishAt(l.PublishAt); l.PublishAt != "" && err == nil && t.After(e.modified) {
+			e.modified = t
+		}
+	}
+	return e, nil
+}
+
+// serve answers r with e as contentType, gzipped if the client takes it,
+// honoring If-None-Match and If-Modified-Since (see http.ServeContent).
+func (e *rendered) serve(w http.ResponseWriter, r *http.Request, contentType string) {
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Vary", "Accept-Encoding")
+	body, etag := e.body, e.etag
+	if acceptsGzip(r) {
+		body, etag = e.gz, etag+"-gz"
+		h.Set("Content-Encoding", "gzip")
+	}
+	h.Set("ETag", `"`+etag+`"`)
+	http.ServeContent(w, r, "", e.modified, bytes.NewReader(body))
+}
+
+// acceptsGzip reports whether r's Accept-Encoding allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.TrimSpace(coding)
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(p), "q="); ok {
+				q, _ = strconv.ParseFloat(v, 64)
+			}
+		}
+		return q > 0
+	}
+	return false
+}
+
+// rateLimiter allows each client IP perMinute requests a minute, in
+// bursts of up to as many (a token bucket per IP).
+type rateLimiter struct {
+	perMinute int
+
+	mu      sync.Mutex
+	clients map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimitExempt are the routes monitoring polls, never limited.
+var rateLimitExempt = map[string]bool{"GET /healthz": true, "GET /metrics": true}
+
+// maxClients is how many IPs a rateLimiter tracks before it forgets those
+// whose buckets have refilled.
+const maxClients = 10000
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{perMinute: perMinute, clients: map[string]*tokenBucket{}}
+}
+
+// allow takes a token for ip, or reports how long until one is available.
+func (l *rateLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	perSecond := float64(l.perMinute) / 60
+	if len(l.clients) >= maxClients {
+		for k, b := range l.clients {
+			if now.Sub(b.last) >= time.Minute {
+				delete(l.clients, k)
+			}
+		}
+	}
+	b := l.clients[ip]
+	if b == nil {
+		b = &tokenBucket{tokens: float64(l.perMinute), last: now}
+		l.clients[ip] = b
+	}
+	b.tokens = min(float64(l.perMinute), b.tokens+now.Sub(b.last).Seconds()*perSecond)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// wrap answers 429 Too Many Requests, with Retry-After, to clients over
+// their limit on any of mux's routes but rateLimitExempt.
+func (l *rateLimiter) wrap(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); !rateLimitExempt[pattern] {
+			if ok, wait := l.allow(clientIP(r), time.Now()); !ok {
+				r.Pattern = pattern // for the metrics
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// clientIP is the address r came from. Behind a reverse proxy on the same
+// machine that is the last X-Forwarded-For entry, the one the proxy added.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			return strings.TrimSpace(fwd[strings.LastIndex(fwd, ",")+1:])
+		}
+	}
+	return host
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+func cmdKeygen() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	var keyPath, pubPath string
+	var force bool
+	fs.StringVar(&keyPath, "key", "key.pem", "where to write the private key (mode 0600)")
+	fs.StringVar(&pubPath, "pub", "pub.pem", "where to write the public key")
+	fs.BoolVar(&force, "force", false, "overwrite existing key files")
+	return fs, func() {
+		if fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		if !force {
+			for _, p := range []string{keyPath, pubPath} {
+				if _, err := os.Stat(p); err == nil {
+					die(conflict(fmt.Errorf("%s already exists (use -force to overwrite)", p)))
+				} else if !errors.Is(err, os.ErrNotExist) {
+					die(err)
+				}
+			}
+		}
+		priv, pub, err := feed.GenerateKey()
+		if err != nil {
+			die(err)
+		}
+		if err := feed.WriteFileAtomic(keyPath, priv, 0o600); err != nil {
+			die(err)
+		}
+		if err := feed.WriteFileAtomic(pubPath, pub, 0o644); err != nil {
+			die(err)
+		}
+		msg.Infof("wrote private key %s and public key %s", keyPath, pubPath)
+	}
+}
+
+func cmdSign() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	var file, keyPath, sigPath string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&keyPath, "key", "", "PEM ed25519 private key (from keygen)")
+	fs.StringVar(&sigPath, "sig", "", "signature file (default <file>.sig)")
+	return fs, func() {
+		if file == "" || keyPath == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		b, err := os.ReadFile(keyPath)
+		if err != nil {
+			die(err)
+		}
+		key, err := feed.ParsePrivateKey(b)
+		if err != nil {
+			die(invalid(fmt.Errorf("%s: %w", keyPath, err)))
+		}
+		// Refuse to sign something that isn't a readable feed.
+		if _, err := mustLoad(file); err != nil {
+			die(err)
+		}
+		if err := feed.SignFile(file, sigPath, key); err != nil {
+			die(err)
+		}
+		if sigPath == "" {
+			sigPath = file + feed.SignatureSuffix
+		}
+		msg.Infof("signed %s -> %s", file, sigPath)
+	}
+}
+
+func cmdVerify() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	var file, pubPath, sigPath string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&pubPath, "pub", "", "PEM ed25519 public key of the signer")
+	fs.StringVar(&sigPath, "sig", "", "signature file (default <file>.sig)")
+	return fs, func() {
+		if file == "" || pubPath == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		b, err := os.ReadFile(pubPath)
+		if err != nil {
+			die(err)
+		}
+		pub, err := feed.ParsePublicKey(b)
+		if err != nil {
+			die(invalid(fmt.Errorf("%s: %w", pubPath, err)))
+		}
+		if err := feed.VerifyFile(file, sigPath, pub); err != nil {
+			die(err)
+		}
+		msg.Infof("%s: signature OK", file)
+	}
+}
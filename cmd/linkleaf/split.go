@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func cmdSplit() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	var file, out, title string
+	var remove bool
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb) to take the links from")
+	fs.StringVar(&out, "out", "", "feed file the links go to, created if missing (required)")
+	fs.StringVar(&title, "title", "", "title of the -out feed (default for a new one: the -tag, else the file name)")
+	fs.BoolVar(&remove, "remove", false, "move the links: also remove them from -file")
+	ff := addFilterFlags(fs)
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || out == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		if !ff.any() {
+			die(invalid(errors.New("split needs a filter flag (e.g. -tag recipes) to pick the links")))
+		}
+		if filepath.Clean(file) == filepath.Clean(out) {
+			die(invalid(errors.New("-out must differ from -file")))
+		}
+		flt, err := ff.filter()
+		if err != nil {
+			die(err)
+		}
+
+		sf.lock(file)
+		src, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		picked := flt.Apply(src.Links)
+		if len(picked) == 0 {
+			msg.Infof("no links in %s match", file)
+			return
+		}
+
+		sf.lock(out)
+		opened, err := feed.OpenWith(out, loadOpts) // a missing file starts a new feed
+		if err != nil {
+			die(fmt.Errorf("load %s: %w", out, err))
+		}
+		dst := opened.Feed
+		sf.loaded(dst)
+		switch {
+		case title != "":
+			dst.Title = title
+		case dst.Title == "" && len(dst.Links) == 0:
+			dst.Title = strings.Join(ff.tags, ", ")
+			if dst.Title == "" {
+				dst.Title = strings.TrimSuffix(filepath.Base(out), filepath.Ext(out))
+			}
+		}
+		if dst.Author == "" {
+			dst.Author = src.Author
+		}
+		copies := make([]*v1.Link, len(picked))
+		for i, l := range picked {
+			copies[i] = proto.Clone(l).(*v1.Link)
+		}
+		added, dupes := importLinks(dst, copies, false)
+		dst.GeneratedAt = feed.NowRFC3339()
+		// -out first: should removing from -file fail, the links are in both.
+		if err := sf.save(out, dst); err != nil {
+			die(err)
+		}
+		if !remove {
+			msg.Infof("copied %d links to %s (%d already there)", added, out, dupes)
+			return
+		}
+
+		sf.loaded(src)
+		ids := map[string]bool{}
+		for _, l := range picked {
+			ids[l.Id] = true
+		}
+		feed.RemoveFunc(src, func(l *v1.Link) bool { return ids[l.Id] })
+		src.GeneratedAt = feed.NowRFC3339()
+		if err := sf.save(file, src); err != nil {
+			die(fmt.Errorf("%w (the links were copied to %s)", err, out))
+		}
+		msg.Infof("moved %d links from %s to %s (%d already there)", len(picked), file, out, dupes)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// exportContent writes one Markdown file per link for a static site
+// generator: gen is "hugo" (content/links/ID.md by default) or "jekyll"
+// (a _links collection with YYYY-MM-DD-ID.md files). Files are named by
+// ID, not title, so editing a link rewrites its file rather than adding
+// another.
+func exportContent(gen string) (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("export "+gen, flag.ExitOnError)
+	var file, out, format string
+	var incremental bool
+	defOut := "content/links"
+	if gen == "jekyll" {
+		defOut = "_links"
+	}
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&out, "out", defOut, "output directory")
+	fs.StringVar(&format, "front-matter", "yaml", "front matter format: yaml or toml (hugo only)")
+	fs.BoolVar(&incremental, "incremental", false, "only write files that are new or whose content changed")
+	ff := addFilterFlags(fs)
+	drafts := addDraftsFlag(fs)
+	return fs, func() {
+		if file == "" || out == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		switch {
+		case format != "yaml" && format != "toml":
+			die(invalid(fmt.Errorf("-front-matter: want yaml or toml, got %q", format)))
+		case format == "toml" && gen == "jekyll":
+			die(invalid(errors.New("-front-matter: jekyll only reads yaml")))
+		}
+		flt, err := ff.filter()
+		if err != nil {
+			die(err)
+		}
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		f = public(flt.Select(f), *drafts)
+
+		written, unchanged := 0, 0
+		for _, l := range f.Links {
+			name := cmp.Or(feed.Slugify(l.Id), feed.LinkID(l.Url, l.Date)) + ".md"
+			if gen == "jekyll" {
+				name = l.Date + "-" + name
+			}
+			path := filepath.Join(out, name)
+			b := renderContentFile(l, format)
+			if incremental {
+				if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, b) {
+					unchanged++
+					continue
+				}
+			}
+			if err := feed.WriteFileAtomic(path, b, 0o644); err != nil {
+				die(err)
+			}
+			msg.Debugf("wrote %s", path)
+			written++
+		}
+		if incremental {
+			msg.Infof("exported %d links to %s (%s; %d unchanged)", written, out, gen, unchanged)
+			return
+		}
+		msg.Infof("exported %d links to %s (%s)", written, out, gen)
+	}
+}
+
+// renderContentFile is l as a Markdown page: front matter with the title,
+// date, tags, the link's URL (link) and via, then the summary and notes.
+func renderContentFile(l *v1.Link, format string) []byte {
+	date := l.Date
+	if t, err := time.Parse(time.RFC3339, l.AddedAt); err == nil {
+		date = t.Format(time.RFC3339)
+	}
+	delim, sep := "---", ": "
+	if format == "toml" {
+		delim, sep = "+++", " = "
+	}
+	var buf bytes.Buffer
+	field := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&buf, "%s%s%s\n", key, sep, fmQuote(value))
+		}
+	}
+	buf.WriteString(delim + "\n")
+	field("title", l.Title)
+	fmt.Fprintf(&buf, "date%s%s\n", sep, date)
+	if len(l.Tags) > 0 {
+		quoted := make([]string, len(l.Tags))
+		for i, t := range l.Tags {
+			quoted[i] = fmQuote(t)
+		}
+		fmt.Fprintf(&buf, "tags%s[%s]\n", sep, strings.Join(quoted, ", "))
+	}
+	field("link", l.Url)
+	field("via", l.Via)
+	field("archive", l.ArchiveUrl)
+	field("id", l.Id)
+	buf.WriteString(delim + "\n")
+	if l.Summary != "" {
+		fmt.Fprintf(&buf, "\n%s\n", mdEscape(l.Summary))
+	}
+	for _, q := range l.Quotes {
+		buf.WriteByte('\n')
+		for i, p := range paragraphs(q) {
+			if i > 0 {
+				buf.WriteString(">\n")
+			}
+			fmt.Fprintf(&buf, "> %s\n", mdEscape(p))
+		}
+	}
+	for _, p := range paragraphs(l.Notes) {
+		fmt.Fprintf(&buf, "\n> %s\n", mdEscape(p))
+	}
+	return buf.Bytes()
+}
+
+// fmQuote quotes s as a JSON string, which is also a valid YAML
+// double-quoted string and TOML basic string.
+func fmQuote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+func cmdStats() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	var file string
+	var top int
+	var asJSON bool
+	fs.StringVar(&file, "file", "", "protobuf feed file (.pb), instead of the positional argument")
+	fs.IntVar(&top, "top", 10, "show the N most used domains and tags (0: all)")
+	fs.BoolVar(&asJSON, "json", false, "print the figures as JSON")
+	ff := addFilterFlags(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, file)
+		if !ok || top < 0 {
+			badUsage(fs)
+		}
+		flt, err := ff.filter()
+		if err != nil {
+			die(err)
+		}
+
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
+		}
+		s := feed.ComputeStats(flt.Apply(f.Links), top)
+
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(s); err != nil {
+				die(err)
+			}
+			return
+		}
+		printStats(s)
+	}
+}
+
+// statsBarWidth is the length of the longest bar in the per-month chart.
+const statsBarWidth = 40
+
+func printStats(s feed.Stats) {
+	fmt.Printf("links         %d", s.Links)
+	if s.First != "" {
+		fmt.Printf(" (%s … %s)", s.First, s.Last)
+	}
+	fmt.Println()
+	if s.Undated > 0 {
+		fmt.Printf("undated       %d\n", s.Undated)
+	}
+	fmt.Printf("per week      %.1f\n", s.PerWeek)
+	fmt.Printf("with summary  %d (%.0f%%)\n", s.WithSummary, 100*s.SummaryCoverage)
+
+	if len(s.PerMonth) > 0 {
+		fmt.Println("\nper month")
+		most := 0
+		for _, m := range s.PerMonth {
+			most = max(most, m.Count)
+		}
+		for _, m := range s.PerMonth {
+			bar := 0
+			if most > 0 {
+				bar = (m.Count*statsBarWidth + most - 1) / most
+			}
+			fmt.Printf("  %s %5d  %s\n", m.Month, m.Count, strings.Repeat("▇", bar))
+		}
+	}
+	if len(s.TopDomains) > 0 {
+		fmt.Println("\ntop domains")
+		for _, d := range s.TopDomains {
+			fmt.Printf("%5d  %s\n", d.Count, d.Domain)
+		}
+	}
+	if len(s.TopTags) > 0 {
+		fmt.Println("\ntop tags")
+		for _, tc := range s.TopTags {
+			fmt.Printf("%5d  %s\n", tc.Count, tc.Tag)
+		}
+	}
+}
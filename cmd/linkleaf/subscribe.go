@@ -0,0 +1,272 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/pagemeta"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// maxSubscription bounds the size of a source feed.
+const maxSubscription = 10 << 20
+
+func cmdSubscribeAdd() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("subscribe add", flag.ExitOnError)
+	var file, title string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&title, "title", "", "name to list the subscription by")
+	tf := addTagFlags(fs)
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || fs.NArg() != 1 {
+			badUsage(fs)
+		}
+		src := fs.Arg(0)
+		if u, err := url.Parse(src); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			die(invalid(fmt.Errorf("%q: want an http or https URL", src)))
+		}
+		tags, err := tf.tags()
+		if err != nil {
+			die(err)
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		if _, s := findSubscription(f, src); s != nil {
+			die(conflict(fmt.Errorf("already subscribed to %s", s.Url)))
+		}
+		f.Subscriptions = append(f.Subscriptions, &v1.Subscription{Url: src, Title: title, Tags: tags, AddedAt: feed.NowRFC3339()})
+		f.GeneratedAt = feed.NowRFC3339()
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		msg.Infof("subscribed to %s; linkleaf subscribe pull imports its items", src)
+	}
+}
+
+func cmdSubscribeRemove() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("subscribe remove", flag.ExitOnError)
+	var file string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || fs.NArg() != 1 {
+			badUsage(fs)
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		i, s := findSubscription(f, fs.Arg(0))
+		if s == nil {
+			die(notFound(fmt.Errorf("no subscription %q (see linkleaf subscribe list)", fs.Arg(0))))
+		}
+		f.Subscriptions = slices.Delete(f.Subscriptions, i, i+1)
+		f.GeneratedAt = feed.NowRFC3339()
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		msg.Infof("unsubscribed from %s; links imported from it stay", s.Url)
+	}
+}
+
+func cmdSubscribeList() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("subscribe list", flag.ExitOnError)
+	var file string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		for i, s := range f.Subscriptions {
+			fmt.Printf("%2d. %s\n", i+1, cmp.Or(s.Title, s.Url))
+			if s.Title != "" {
+				fmt.Printf("    %s\n", s.Url)
+			}
+			if len(s.Tags) > 0 {
+				fmt.Printf("    tags: %s\n", strings.Join(s.Tags, ", "))
+			}
+			switch {
+			case s.Error != "":
+				fmt.Printf("    failed: %s\n", s.Error)
+			case s.PulledAt != "":
+				fmt.Printf("    pulled %s\n", s.PulledAt)
+			default:
+				fmt.Println("    not pulled yet")
+			}
+		}
+	}
+}
+
+// findSubscription finds a subscription by URL (normalized) or by its
+// 1-based position in subscribe list.
+func findSubscription(f *v1.Feed, arg string) (int, *v1.Subscription) {
+	norm := feed.NormalizeURL(arg)
+	for i, s := range f.Subscriptions {
+		if feed.NormalizeURL(s.Url) == norm {
+			return i, s
+		}
+	}
+	if i, err := strconv.Atoi(arg); err == nil && i >= 1 && i <= len(f.Subscriptions) {
+		return i - 1, f.Subscriptions[i-1]
+	}
+	return -1, nil
+}
+
+// pulled is the outcome of fetching one subscription.
+type pulled struct {
+	links         []*v1.Link // all of its items, tagged
+	notModified   bool
+	etag, lastMod string
+	err           error
+}
+
+func cmdSubscribePull() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("subscribe pull", flag.ExitOnError)
+	var file string
+	var timeout time.Duration
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.DurationVar(&timeout, "timeout", 30*time.Second, "per-feed timeout")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+
+		// Fetch first, lock after: results are applied to a fresh load by URL.
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		if len(f.Subscriptions) == 0 {
+			msg.Infof("no subscriptions (add one with linkleaf subscribe add URL)")
+			return
+		}
+		client := &http.Client{Timeout: timeout}
+		results := map[string]pulled{}
+		for _, s := range f.Subscriptions {
+			results[s.Url] = pullSubscription(client, s)
+		}
+
+		sf.lock(file)
+		f, err = mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		now := feed.NowRFC3339()
+		added, failed := 0, 0
+		for _, s := range f.Subscriptions {
+			p, ok := results[s.Url]
+			if !ok {
+				continue // subscribed since the fetch
+			}
+			name := cmp.Or(s.Title, s.Url)
+			if p.err != nil {
+				failed++
+				s.Error = p.err.Error()
+				fmt.Fprintf(os.Stderr, "warning: %s: %v\n", name, p.err)
+				continue
+			}
+			s.PulledAt, s.Error = now, ""
+			if p.notModified {
+				msg.Debugf("%s: not modified", name)
+				continue
+			}
+			s.Etag, s.LastModified = p.etag, p.lastMod
+
+			var fresh []*v1.Link
+			seen := make([]string, 0, len(p.links))
+			for _, l := range p.links {
+				norm := feed.NormalizeURL(l.Url)
+				seen = append(seen, norm)
+				if !slices.Contains(s.Seen, norm) {
+					fresh = append(fresh, l)
+				}
+			}
+			s.Seen = seen
+			n, _ := importLinks(f, fresh, false)
+			for _, l := range f.Links[:n] {
+				fmt.Printf("%s: added [%s] %s\n", name, l.Id, l.Title)
+			}
+			added += n
+		}
+		f.GeneratedAt = now
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		msg.Infof("pulled %d subscriptions: %d new links, %d failed", len(results), added, failed)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+// pullSubscription GETs s's feed, conditionally on its ETag and
+// Last-Modified, and turns its items into links with s's tags.
+func pullSubscription(client *http.Client, s *v1.Subscription) pulled {
+	req, err := http.NewRequest(http.MethodGet, s.Url, nil)
+	if err != nil {
+		return pulled{err: err}
+	}
+	req.Header.Set("User-Agent", pagemeta.DefaultUserAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.5")
+	if s.Etag != "" {
+		req.Header.Set("If-None-Match", s.Etag)
+	}
+	if s.LastModified != "" {
+		req.Header.Set("If-Modified-Since", s.LastModified)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return pulled{err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return pulled{notModified: true, etag: s.Etag, lastMod: s.LastModified}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return pulled{err: errors.New(resp.Status)}
+	}
+	links, warnings, err := readFeedXML(io.LimitReader(resp.Body, maxSubscription))
+	if err != nil {
+		return pulled{err: err}
+	}
+	for _, w := range warnings {
+		msg.Debugf("%s: %v; skipped", s.Url, w)
+	}
+	base := resp.Request.URL
+	for _, l := range links {
+		// Items may link relative to the feed.
+		if u, err := base.Parse(l.Url); err == nil && u.String() != l.Url {
+			l.Url = u.String()
+			l.Id = feed.LinkID(l.Url, l.Date)
+		}
+		l.Via = s.Url
+		l.Tags = feed.UniqueTags(withDefaultTags(append(l.Tags, s.Tags...)))
+	}
+	return pulled{links: links, etag: resp.Header.Get("ETag"), lastMod: resp.Header.Get("Last-Modified")}
+}
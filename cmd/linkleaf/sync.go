@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// syncBaseSuffix names the snapshot of the last synced state kept next to
+// the local feed; it is the common ancestor for the next three-way merge.
+const syncBaseSuffix = ".sync-base"
+
+func cmdSync() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	var local, remote, base, strategy, resolveFile string
+	var interactive bool
+	fs.StringVar(&local, "local", defaultFeed(), "local feed file (.pb)")
+	fs.StringVar(&remote, "remote", "", "remote feed: path or s3://, gs://, https:// URL")
+	fs.StringVar(&base, "base", "", "snapshot of the last sync (default <local>"+syncBaseSuffix+")")
+	fs.StringVar(&strategy, "strategy", string(feed.StrategyUnion), "links changed on both sides: "+strings.Join(feed.Strategies(), ", "))
+	fs.BoolVar(&interactive, "interactive", false, "ask how to settle each conflict the strategy leaves: keep local, remote or an edited version")
+	fs.StringVar(&resolveFile, "resolve-file", "", "replay the decisions recorded in this file, and record those -interactive makes")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if local == "" || remote == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		if base == "" {
+			base = local + syncBaseSuffix
+		}
+
+		sf.lock(local)
+		open := func(path string) *v1.Feed {
+			f, err := feed.OpenWith(path, loadOpts) // missing: nothing synced yet
+			if err != nil {
+				die(fmt.Errorf("load %s: %w", path, err))
+			}
+			return f.Feed
+		}
+		ours, theirs, ancestor := open(local), open(remote), open(base)
+		sf.loaded(ours)
+
+		merged, conflicts, err := feed.ThreeWayMerge(ancestor, ours, theirs, feed.Strategy(strategy))
+		if err != nil {
+			die(err)
+		}
+		res, err := newResolver(resolveFile, interactive, "local", "remote")
+		if err != nil {
+			die(err)
+		}
+		unresolved := 0
+		for _, c := range conflicts {
+			if c.Resolved {
+				msg.Infof("conflict on [%s] resolved (%s)", c.ID, resolution(c, merged))
+				continue
+			}
+			l, how, ok, err := res.resolve(c)
+			if err != nil {
+				if errors.Is(err, errAborted) {
+					fmt.Fprintln(os.Stderr, "aborted; nothing written")
+					os.Exit(1)
+				}
+				die(err)
+			}
+			if ok {
+				settle(merged, c, l)
+				msg.Infof("conflict on [%s] resolved (%s)", c.ID, how)
+				continue
+			}
+			unresolved++
+			fmt.Printf("CONFLICT [%s] %s\n", c.ID, c.Ours.Title)
+			for _, fc := range feed.CompareLinks(c.Ours, c.Theirs) {
+				fmt.Printf("    %s: local %q, remote %q\n", fc.Field, fc.Old, fc.New)
+			}
+		}
+		if err := res.save(); err != nil {
+			die(err)
+		}
+		if unresolved > 0 {
+			die(conflict(fmt.Errorf("%d conflicting links; nothing written (rerun with -interactive, -strategy ours or -strategy theirs)", unresolved)))
+		}
+
+		pulled, pushed := feed.Compare(ours, merged), feed.Compare(theirs, merged)
+		merged.GeneratedAt = feed.NowRFC3339()
+		if !pulled.Empty() || !feed.SameMeta(ours, merged) {
+			if err := sf.save(local, merged); err != nil {
+				die(err)
+			}
+		}
+		if !pushed.Empty() || !feed.SameMeta(theirs, merged) {
+			if sf.dryRun {
+				fmt.Printf("remote %s:\n", remote)
+				printDiff(pushed)
+			}
+			if err := feed.SaveWith(remote, merged, sf.options()); err != nil {
+				die(err)
+			}
+		}
+		if !sf.dryRun {
+			opts := feed.SaveOptions{Deterministic: true, Key: loadOpts.Key, Encrypt: encrypt || feed.IsEncryptedFile(local)}
+			if err := feed.SaveWith(base, merged, opts); err != nil {
+				die(fmt.Errorf("save sync base: %w", err))
+			}
+		}
+		msg.Infof("synced %s with %s: %d links; pulled %s, pushed %s", local, remote, len(merged.Links), diffCounts(pulled), diffCounts(pushed))
+	}
+}
+
+// resolution names the side a resolved conflict ended up with.
+func resolution(c feed.Conflict, merged *v1.Feed) string {
+	switch m := feed.Find(merged, c.ID); {
+	case m == nil:
+		return "deleted"
+	case c.Ours != nil && proto.Equal(m, c.Ours):
+		return "kept local"
+	default:
+		return "kept remote"
+	}
+}
+
+func diffCounts(d feed.Diff) string {
+	return fmt.Sprintf("+%d -%d ~%d", len(d.Added), len(d.Removed), len(d.Modified))
+}
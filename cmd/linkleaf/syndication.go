@@ -0,0 +1,556 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// siteInfo is the channel-level metadata of RSS/Atom/JSON Feed: flags
+// first, then the Feed's own (see "meta set", and withFeed), then the
+// config's.
+type siteInfo struct {
+	Title       string // defaults to Feed.Title
+	Link        string // site home page URL
+	Description string
+	FeedURL     string // URL the rendered document is published at
+	Icon        string // image URL
+	Lang        string // defaults to the language the links share
+	Author      siteAuthor
+	Page        pageLinks // set on the pages of a paged feed (see renderPages)
+}
+
+// pageLinks are the URLs of a page of a paged feed (RFC 5005) and of the
+// pages around it; Prev and Next are empty on the first and last page.
+type pageLinks struct {
+	Self, First, Last, Prev, Next string
+}
+
+// feedLinks are the atom:links of the document: self, then the paging
+// links of a paged feed.
+func (si siteInfo) feedLinks(typ string) []atomLink {
+	var links []atomLink
+	if self := cmp.Or(si.Page.Self, si.FeedURL); self != "" {
+		links = append(links, atomLink{Href: self, Rel: "self", Type: typ})
+	}
+	for _, l := range []struct{ rel, href string }{
+		{"first", si.Page.First}, {"previous", si.Page.Prev}, {"next", si.Page.Next}, {"last", si.Page.Last},
+	} {
+		if l.href != "" {
+			links = append(links, atomLink{Href: l.href, Rel: l.rel, Type: typ})
+		}
+	}
+	return links
+}
+
+// renderPages renders f with render, split into pages of size links when
+// it has more (and size > 0), linked to each other by their URLs under
+// si.FeedURL (see pageName). The first page is the newest links.
+func renderPages(f *v1.Feed, si siteInfo, size int, render func(*v1.Feed, siteInfo) ([]byte, error)) ([][]byte, error) {
+	if size <= 0 || len(f.Links) <= size {
+		b, err := render(f, si)
+		return [][]byte{b}, err
+	}
+	if si.FeedURL == "" {
+		return nil, invalid(errors.New("a paged feed needs -feed-url, the URL of its first page"))
+	}
+	si.Lang = si.lang(f) // of the whole feed, not each page
+	n := (len(f.Links) + size - 1) / size
+	pageURL := func(page int) string { return pageName(si.FeedURL, page) }
+	links := f.Links
+	f.Links = nil
+	meta := proto.Clone(f).(*v1.Feed)
+	f.Links = links
+	var out [][]byte
+	for i := range n {
+		page := proto.Clone(meta).(*v1.Feed)
+		page.Links = links[i*size : min((i+1)*size, len(links))]
+		psi := si
+		psi.Page = pageLinks{Self: pageURL(i + 1), First: pageURL(1), Last: pageURL(n)}
+		if i > 0 {
+			psi.Page.Prev = pageURL(i)
+		}
+		if i < n-1 {
+			psi.Page.Next = pageURL(i + 2)
+		}
+		b, err := render(page, psi)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// pageName is the file name or URL of page n of a paged feed whose first
+// page is name: feed.xml, feed-page2.xml, feed-page3.xml, ….
+func pageName(name string, n int) string {
+	if n == 1 {
+		return name
+	}
+	rest := ""
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+	ext := path.Ext(name)
+	if strings.Contains(ext, "/") {
+		ext = ""
+	}
+	return fmt.Sprintf("%s-page%d%s%s", strings.TrimSuffix(name, ext), n, ext, rest)
+}
+
+// withFeed fills what si leaves empty from f's metadata, then from the
+// config's export settings.
+func (si siteInfo) withFeed(f *v1.Feed) siteInfo {
+	si.Title = cmp.Or(si.Title, f.Title, cfg.Export.SiteTitle)
+	si.Link = cmp.Or(si.Link, f.HomePageUrl, cfg.Export.Link)
+	si.Description = cmp.Or(si.Description, f.Description, cfg.Export.Description)
+	si.FeedURL = cmp.Or(si.FeedURL, cfg.Export.FeedURL)
+	si.Icon = cmp.Or(si.Icon, f.Icon)
+	si.Lang = cmp.Or(si.Lang, f.Lang)
+	return si
+}
+
+// siteAuthor is the feed's author, from the config file (or Feed.author,
+// see siteInfo.author); all optional.
+type siteAuthor struct {
+	Name, Email, URL string
+}
+
+func configAuthor() siteAuthor {
+	return siteAuthor{Name: cfg.Author.Name, Email: cfg.Author.Email, URL: cfg.Author.URL}
+}
+
+// author is the feed's author: Feed.Author if set, else the config's. The
+// config's e-mail and URL only go with the config's name.
+func (si siteInfo) author(f *v1.Feed) siteAuthor {
+	if f.Author != "" && !strings.EqualFold(f.Author, si.Author.Name) {
+		return siteAuthor{Name: f.Author}
+	}
+	return si.Author
+}
+
+// linkAuthor is who added l, or "" when that's the feed's author anyway.
+func linkAuthor(l *v1.Link, feedAuthor siteAuthor) string {
+	if strings.EqualFold(l.Author, feedAuthor.Name) {
+		return ""
+	}
+	return l.Author
+}
+
+func (si siteInfo) lang(f *v1.Feed) string {
+	return cmp.Or(si.Lang, feedLang(f))
+}
+
+func (si siteInfo) title(f *v1.Feed) string {
+	switch {
+	case si.Title != "":
+		return si.Title
+	case f.Title != "":
+		return f.Title
+	}
+	return "Links"
+}
+
+// linkTime is the publication time of l (see feed.LinkTime).
+func linkTime(l *v1.Link) (time.Time, bool) {
+	return feed.LinkTime(l)
+}
+
+// feedTime is Feed.GeneratedAt, falling back to the newest link date.
+func feedTime(f *v1.Feed) time.Time {
+	if t, err := time.Parse(time.RFC3339, f.GeneratedAt); err == nil {
+		return t
+	}
+	var newest time.Time
+	for _, l := range f.Links {
+		if t, ok := linkTime(l); ok && t.After(newest) {
+			newest = t
+		}
+	}
+	return newest
+}
+
+// feedLang is the language of every link of f: their tag if they share
+// it, else their shared language subtag ("de" for de and de-AT), else "".
+func feedLang(f *v1.Feed) string {
+	lang := ""
+	for i, l := range f.Links {
+		switch {
+		case l.Lang == "":
+			return ""
+		case i == 0 || l.Lang == lang:
+			lang = l.Lang
+		case feed.BaseLang(l.Lang) == feed.BaseLang(lang):
+			lang = feed.BaseLang(lang)
+		default:
+			return ""
+		}
+	}
+	return lang
+}
+
+// feedLangs are the languages of f's links, by language subtag and
+// sorted, for the per-language feeds under lang/<lang>/ of build and serve.
+func feedLangs(f *v1.Feed) []string {
+	var langs []string
+	for _, l := range f.Links {
+		if base := feed.BaseLang(l.Lang); base != "" && !slices.Contains(langs, base) {
+			langs = append(langs, base)
+		}
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// -------- RSS 2.0 --------
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr,omitempty"`
+	DC      string     `xml:"xmlns:dc,attr,omitempty"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	Language      string     `xml:"language,omitempty"`
+	Image         *rssImage  `xml:"image,omitempty"`
+	AtomLinks     []atomLink `xml:"atom:link"`
+	Editor        string     `xml:"managingEditor,omitempty"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	Generator     string     `xml:"generator"`
+	Items         []rssItem  `xml:"item"`
+}
+
+// rssImage is the channel's logo; RSS wants it to repeat the title and
+// link.
+type rssImage struct {
+	URL   string `xml:"url"`
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description,omitempty"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Categories  []string `xml:"category"`
+	Source      *rssSrc  `xml:"source,omitempty"`
+	Enclosure   *rssEnc  `xml:"enclosure,omitempty"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// rssEnc is an RSS enclosure; length is required, 0 when unknown.
+type rssEnc struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssSrc struct {
+	URL   string `xml:"url,attr"`
+	Value string `xml:",chardata"`
+}
+
+func renderRSS(f *v1.Feed, si siteInfo) ([]byte, error) {
+	ch := rssChannel{
+		Title:       si.title(f),
+		Link:        si.Link,
+		Description: si.Description,
+		Language:    si.lang(f),
+		Generator:   "linkleaf",
+	}
+	if ch.Description == "" {
+		ch.Description = ch.Title
+	}
+	if si.Icon != "" {
+		ch.Image = &rssImage{URL: si.Icon, Title: ch.Title, Link: ch.Link}
+	}
+	author := si.author(f)
+	// RSS wants "email (name)"; a name alone isn't valid there.
+	if a := author; a.Email != "" {
+		ch.Editor = a.Email
+		if a.Name != "" {
+			ch.Editor += " (" + a.Name + ")"
+		}
+	}
+	ch.AtomLinks = si.feedLinks("application/rss+xml")
+	if t := feedTime(f); !t.IsZero() {
+		ch.LastBuildDate = t.Format(time.RFC1123Z)
+	}
+	for _, l := range f.Links {
+		it := rssItem{
+			Title:       l.Title,
+			Link:        l.Url,
+			Description: l.Summary,
+			GUID:        rssGUID{Value: l.Id},
+			Categories:  l.Tags,
+		}
+		if t, ok := linkTime(l); ok {
+			it.PubDate = t.Format(time.RFC1123Z)
+		}
+		if l.Via != "" {
+			it.Source = &rssSrc{URL: l.Via, Value: l.Via}
+		}
+		if e := l.Enclosure; e != nil {
+			it.Enclosure = &rssEnc{URL: e.Url, Length: e.Length, Type: e.MimeType}
+		}
+		// RSS's own <author> wants an e-mail address; Dublin Core takes a name.
+		it.Creator = linkAuthor(l, author)
+		ch.Items = append(ch.Items, it)
+	}
+	doc := rssDoc{Version: "2.0", Channel: ch}
+	if len(ch.AtomLinks) > 0 {
+		doc.Atom = atomNS
+	}
+	for _, it := range ch.Items {
+		if it.Creator != "" {
+			doc.DC = dcNS
+			break
+		}
+	}
+	return marshalXML(doc)
+}
+
+// -------- Atom 1.0 --------
+
+const (
+	atomNS = "http://www.w3.org/2005/Atom"
+	dcNS   = "http://purl.org/dc/elements/1.1/"
+)
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	NS       string      `xml:"xmlns,attr"`
+	Lang     string      `xml:"xml:lang,attr,omitempty"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   *atomAuthor `xml:"author,omitempty"`
+	Icon     string      `xml:"icon,omitempty"`
+	Gen      string      `xml:"generator"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+	URI   string `xml:"uri,omitempty"`
+}
+
+type atomEntry struct {
+	Lang       string         `xml:"xml:lang,attr,omitempty"`
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Summary    string         `xml:"summary,omitempty"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func renderAtom(f *v1.Feed, si siteInfo) ([]byte, error) {
+	updated := feedTime(f)
+	author := si.author(f)
+	doc := atomFeed{
+		NS:       atomNS,
+		Lang:     si.lang(f),
+		ID:       si.FeedURL,
+		Title:    si.title(f),
+		Subtitle: si.Description,
+		Updated:  updated.Format(time.RFC3339),
+		Icon:     si.Icon,
+		Gen:      "linkleaf",
+		// Atom requires an author when entries don't carry their own.
+		Author: &atomAuthor{Name: author.Name, Email: author.Email, URI: author.URL},
+	}
+	if doc.Author.Name == "" {
+		doc.Author.Name = si.title(f)
+	}
+	if doc.ID == "" {
+		doc.ID = si.Link
+	}
+	if si.Link != "" {
+		doc.Links = append(doc.Links, atomLink{Href: si.Link, Rel: "alternate", Type: "text/html"})
+	}
+	doc.Links = append(doc.Links, si.feedLinks("application/atom+xml")...)
+	for _, l := range f.Links {
+		e := atomEntry{
+			Lang:    l.Lang,
+			ID:      atomEntryID(si, l),
+			Title:   l.Title,
+			Updated: updated.Format(time.RFC3339),
+			Links:   []atomLink{{Href: l.Url, Rel: "alternate"}},
+			Summary: l.Summary,
+		}
+		if t, ok := linkTime(l); ok {
+			e.Updated = t.Format(time.RFC3339)
+			e.Published = e.Updated
+		}
+		if l.Via != "" {
+			e.Links = append(e.Links, atomLink{Href: l.Via, Rel: "via"})
+		}
+		if enc := l.Enclosure; enc != nil {
+			e.Links = append(e.Links, atomLink{Href: enc.Url, Rel: "enclosure", Type: enc.MimeType, Length: enc.Length})
+		}
+		if name := linkAuthor(l, author); name != "" {
+			e.Author = &atomAuthor{Name: name}
+		}
+		for _, t := range l.Tags {
+			e.Categories = append(e.Categories, atomCategory{Term: t})
+		}
+		doc.Entries = append(doc.Entries, e)
+	}
+	return marshalXML(doc)
+}
+
+// atomEntryID builds a tag URI (RFC 4151) from the site host (or the feed
+// URL's) and link ID, so entry IDs survive regeneration.
+func atomEntryID(si siteInfo, l *v1.Link) string {
+	host := "linkleaf"
+	for _, raw := range []string{si.Link, si.FeedURL} {
+		if u, err := url.Parse(raw); err == nil && u.Hostname() != "" {
+			host = u.Hostname()
+			break
+		}
+	}
+	date := l.Date
+	if _, ok := linkTime(l); !ok {
+		date = "2000"
+	}
+	return fmt.Sprintf("tag:%s,%s:%s", host, date, l.Id)
+}
+
+func marshalXML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("render xml: %w", err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// -------- JSON Feed 1.1 --------
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	NextURL     string         `json:"next_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Icon        string         `json:"icon,omitempty"`
+	Language    string         `json:"language,omitempty"`
+	Authors     []jsonAuthor   `json:"authors,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url"`
+	ExternalURL   string       `json:"external_url,omitempty"`
+	Title         string       `json:"title,omitempty"`
+	ContentText   string       `json:"content_text"`
+	Summary       string       `json:"summary,omitempty"`
+	DatePublished string       `json:"date_published,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+	Language      string       `json:"language,omitempty"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Attachments   []jsonAttach `json:"attachments,omitempty"`
+}
+
+type jsonAttach struct {
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size_in_bytes,omitempty"`
+}
+
+func renderJSONFeed(f *v1.Feed, si siteInfo) ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       si.title(f),
+		HomePageURL: si.Link,
+		FeedURL:     si.FeedURL,
+		NextURL:     si.Page.Next,
+		Description: si.Description,
+		Icon:        si.Icon,
+		Language:    si.lang(f),
+		Items:       []jsonFeedItem{},
+	}
+	author := si.author(f)
+	if a := author; a.Name != "" || a.URL != "" {
+		doc.Authors = []jsonAuthor{{Name: a.Name, URL: a.URL}}
+	}
+	for _, l := range f.Links {
+		it := jsonFeedItem{
+			ID:          l.Id,
+			URL:         l.Url,
+			ExternalURL: l.Via,
+			Title:       l.Title,
+			ContentText: l.Summary,
+			Summary:     l.Summary,
+			Tags:        l.Tags,
+			Language:    l.Lang,
+		}
+		if t, ok := linkTime(l); ok {
+			it.DatePublished = t.Format(time.RFC3339)
+		}
+		if name := linkAuthor(l, author); name != "" {
+			it.Authors = []jsonAuthor{{Name: name}}
+		}
+		if e := l.Enclosure; e != nil {
+			it.Attachments = []jsonAttach{{URL: e.Url, MimeType: e.MimeType, Size: e.Length}}
+		}
+		doc.Items = append(doc.Items, it)
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("render json feed: %w", err)
+	}
+	return append(b, '\n'), nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"github.com/mattn/go-runewidth"
+)
+
+// defaultColumns are list -table's columns without -columns.
+const defaultColumns = "index,id,date,title,domain,tags"
+
+// tableColumn is a column list -table can show.
+type tableColumn struct {
+	header string
+	value  func(i int, l *v1.Link) string
+	// shrink orders the columns cut to fit the terminal: 1 first, 0 never.
+	shrink int
+	color  string // ANSI SGR parameters of its cells, "" for none
+}
+
+var tableColumns = map[string]tableColumn{
+	"index":   {"#", func(i int, _ *v1.Link) string { return strconv.Itoa(i + 1) }, 0, "2"},
+	"id":      {"ID", func(_ int, l *v1.Link) string { return l.Id }, 0, "33"},
+	"date":    {"DATE", func(_ int, l *v1.Link) string { return l.Date }, 0, ""},
+	"added":   {"ADDED", func(_ int, l *v1.Link) string { return l.AddedAt }, 0, ""},
+	"title":   {"TITLE", func(_ int, l *v1.Link) string { return l.Title }, 1, ""},
+	"url":     {"URL", func(_ int, l *v1.Link) string { return l.Url }, 2, "2"},
+	"tags":    {"TAGS", func(_ int, l *v1.Link) string { return strings.Join(l.Tags, ",") }, 3, "36"},
+	"domain":  {"DOMAIN", func(_ int, l *v1.Link) string { return strings.TrimPrefix(feed.Host(l.Url), "www.") }, 4, "2"},
+	"author":  {"AUTHOR", func(_ int, l *v1.Link) string { return l.Author }, 5, ""},
+	"minutes": {"MIN", func(_ int, l *v1.Link) string { return readingMinutes(l) }, 0, ""},
+}
+
+// readingMinutes is l's estimated reading time in minutes, "" if unknown.
+func readingMinutes(l *v1.Link) string {
+	if l.ReadingMinutes == 0 {
+		return ""
+	}
+	return strconv.Itoa(int(l.ReadingMinutes))
+}
+
+// minColumnWidth is as narrow as truncation makes a column.
+const minColumnWidth = 8
+
+// parseColumns reads a -columns list.
+func parseColumns(s string) ([]tableColumn, error) {
+	var cols []tableColumn
+	for _, name := range strings.Split(s, ",") {
+		c, ok := tableColumns[strings.TrimSpace(name)]
+		if !ok {
+			names := make([]string, 0, len(tableColumns))
+			for n := range tableColumns {
+				names = append(names, n)
+			}
+			slices.Sort(names)
+			return nil, fmt.Errorf("-columns: unknown column %q (want %s)", name, strings.Join(names, ", "))
+		}
+		cols = append(cols, c)
+	}
+	return cols, nil
+}
+
+// colorOutput reports whether to color what goes to stdout: it is a
+// terminal, -no-color wasn't given and neither NO_COLOR nor TERM=dumb is
+// set.
+func colorOutput(noColor bool) bool {
+	return !noColor && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" && term.IsTerminal(os.Stdout.Fd())
+}
+
+// outputWidth is the width list -table fits in: the terminal's, else
+// $COLUMNS; 0 (no limit) when stdout is neither.
+func outputWidth() int {
+	if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
+		return w
+	}
+	w, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	return max(w, 0)
+}
+
+// printTable prints the links of groups in aligned columns, cutting the
+// widest text columns (title first) with "…" until a row fits width (0: no
+// limit). Every group but an unnamed one gets a heading; columns line up
+// across groups.
+func printTable(groups []linkGroup, cols []tableColumn, width int, color bool) {
+	rows := make([][][]string, len(groups))
+	widths := make([]int, len(cols))
+	for j, c := range cols {
+		widths[j] = runewidth.StringWidth(c.header)
+	}
+	for g, grp := range groups {
+		rows[g] = make([][]string, len(grp.links))
+		for i, l := range grp.links {
+			rows[g][i] = make([]string, len(cols))
+			for j, c := range cols {
+				v := strings.Join(strings.Fields(c.value(i, l)), " ")
+				rows[g][i][j] = v
+				widths[j] = max(widths[j], runewidth.StringWidth(v))
+			}
+		}
+	}
+	if width > 0 {
+		total := 2 * (len(cols) - 1)
+		for _, w := range widths {
+			total += w
+		}
+		order := make([]int, 0, len(cols))
+		for j, c := range cols {
+			if c.shrink > 0 {
+				order = append(order, j)
+			}
+		}
+		slices.SortStableFunc(order, func(a, b int) int { return cols[a].shrink - cols[b].shrink })
+		for _, j := range order {
+			if total <= width {
+				break
+			}
+			cut := min(total-width, widths[j]-minColumnWidth)
+			if cut > 0 {
+				widths[j] -= cut
+				total -= cut
+			}
+		}
+	}
+
+	line := func(cells []string, sgr func(j int) string) {
+		var sb strings.Builder
+		for j, v := range cells {
+			v = runewidth.Truncate(v, widths[j], "…")
+			if j < len(cells)-1 {
+				v = runewidth.FillRight(v, widths[j])
+			}
+			if s := sgr(j); color && s != "" && v != "" {
+				v = "\x1b[" + s + "m" + v + "\x1b[0m"
+			}
+			sb.WriteString(v)
+			if j < len(cells)-1 {
+				sb.WriteString("  ")
+			}
+		}
+		fmt.Println(strings.TrimRight(sb.String(), " "))
+	}
+	headers := make([]string, len(cols))
+	for j, c := range cols {
+		headers[j] = c.header
+	}
+	line(headers, func(int) string { return "1" })
+	for g, grp := range groups {
+		if grp.name != "" {
+			h := groupHeading(grp)
+			if width > 0 {
+				h = runewidth.Truncate(h, width, "…")
+			}
+			if color {
+				h = "\x1b[1;4m" + h + "\x1b[0m"
+			}
+			fmt.Printf("\n%s\n", h)
+		}
+		for _, r := range rows[g] {
+			line(r, func(j int) string { return cols[j].color })
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+// stringsFlag is a repeatable string flag: every occurrence is appended.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string     { return strings.Join(*s, ",") }
+func (s *stringsFlag) Set(v string) error { *s = append(*s, v); return nil }
+
+// tagFlags collect tags from -tags a,b,c and any number of -tag x.
+type tagFlags struct {
+	csv       string
+	each      stringsFlag
+	normalize bool
+}
+
+func addTagFlags(fs *flag.FlagSet) *tagFlags {
+	tf := &tagFlags{}
+	fs.StringVar(&tf.csv, "tags", "", "comma-separated tags (e.g. a,b,c)")
+	fs.Var(&tf.each, "tag", "add a tag (repeatable; may contain commas)")
+	fs.BoolVar(&tf.normalize, "normalize-tags", false, "lowercase tags and drop duplicates")
+	return tf
+}
+
+// tags returns the validated tag list, or nil when no tags were given.
+func (tf *tagFlags) tags() ([]string, error) {
+	tags, err := validTags(append(feed.SplitTags(tf.csv), tf.each...))
+	if err != nil || tags == nil {
+		return nil, err
+	}
+	if tf.normalize {
+		return feed.NormalizeTags(tags), nil
+	}
+	return tags, nil
+}
+
+// validTags checks every tag and drops duplicates; empty input yields nil.
+func validTags(tags []string) ([]string, error) {
+	for _, t := range tags {
+		if err := feed.ValidateTag(t); err != nil {
+			return nil, invalid(err)
+		}
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return feed.UniqueTags(tags), nil
+}
+
+func cmdTagsList() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("tags", flag.ExitOnError)
+	var file, sortBy string
+	var asJSON bool
+	fs.StringVar(&file, "file", "", "protobuf feed file (.pb), instead of the positional argument")
+	fs.StringVar(&sortBy, "sort", "count", "order by count (desc) or name")
+	fs.BoolVar(&asJSON, "json", false, "print a JSON array of {tag, count}")
+	return fs, func() {
+		path, ok := feedArg(fs, file)
+		if !ok {
+			badUsage(fs)
+		}
+
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
+		}
+		counts := feed.CountTags(f)
+		switch sortBy {
+		case "count":
+		case "name":
+			slices.SortFunc(counts, func(a, b feed.TagCount) int { return strings.Compare(a.Tag, b.Tag) })
+		default:
+			die(invalid(fmt.Errorf("-sort: want count or name, got %q", sortBy)))
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(counts); err != nil {
+				die(err)
+			}
+			return
+		}
+		for _, tc := range counts {
+			fmt.Printf("%5d  %s\n", tc.Count, tc.Tag)
+		}
+	}
+}
+
+// cmdTagsRewrite runs "tags rename OLD NEW", "tags merge TAG... -into NEW"
+// and "tags rm TAG...", each as one locked load-modify-save.
+func cmdTagsRewrite(sub string) (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("tags "+sub, flag.ExitOnError)
+	var file, into string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	if sub == "merge" {
+		fs.StringVar(&into, "into", "", "tag the merged tags become (required)")
+	}
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		tags := fs.Args()
+		switch {
+		case file == "",
+			sub == "rename" && len(tags) != 2,
+			sub == "merge" && (len(tags) == 0 || into == ""),
+			sub == "rm" && len(tags) == 0:
+			badUsage(fs)
+		}
+		if sub == "rename" {
+			tags, into = tags[:1], tags[1]
+		}
+		if into != "" {
+			if err := feed.ValidateTag(into); err != nil {
+				die(err)
+			}
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		for _, t := range tags {
+			if sub == "rm" {
+				feed.DeleteTag(f, t)
+			} else {
+				feed.RenameTag(f, t, into)
+			}
+		}
+		// A link carrying several of the tags counts once.
+		n := len(feed.Compare(sf.before, f).Modified)
+		if n == 0 {
+			msg.Infof("no links tagged %s", strings.Join(tags, ", "))
+			return
+		}
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		switch sub {
+		case "rm":
+			msg.Infof("removed %s from %d links", strings.Join(tags, ", "), n)
+		case "merge":
+			msg.Infof("merged %s into %q on %d links", strings.Join(tags, ", "), into, n)
+		default:
+			msg.Infof("renamed tag %q to %q on %d links", tags[0], into, n)
+		}
+	}
+}
+
+func cmdRenameTag() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("rename-tag", flag.ExitOnError)
+	var from, to string
+	var del bool
+	fs.StringVar(&from, "from", "", "tag to rename (required)")
+	fs.StringVar(&to, "to", "", "new tag name")
+	fs.BoolVar(&del, "delete", false, "remove -from from every link instead of renaming")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		path, ok := feedArg(fs, "")
+		if !ok || from == "" || (to == "") == !del {
+			badUsage(fs)
+		}
+		if !del {
+			if err := feed.ValidateTag(to); err != nil {
+				die(err)
+			}
+		}
+
+		sf.lock(path)
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+
+		var n int
+		if del {
+			n = feed.DeleteTag(f, from)
+		} else {
+			n = feed.RenameTag(f, from, to)
+		}
+		if n == 0 {
+			msg.Infof("no links tagged %q", from)
+			return
+		}
+		if err := sf.save(path, f); err != nil {
+			die(err)
+		}
+		if del {
+			msg.Infof("removed tag %q from %d links", from, n)
+		} else {
+			msg.Infof("renamed tag %q to %q on %d links", from, to, n)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// formatFuncs are the helpers of -format templates, next to text/template's
+// own (printf, len, index, ...).
+var formatFuncs = template.FuncMap{
+	// date reformats a YYYY-MM-DD or RFC 3339 value with a Go layout:
+	// {{date "Jan 2, 2006" .Date}}. Values that don't parse pass through.
+	"date": func(layout, s string) string {
+		for _, in := range []string{time.RFC3339, feed.DateLayout} {
+			if t, err := time.Parse(in, s); err == nil {
+				return t.Format(layout)
+			}
+		}
+		return s
+	},
+	// domain is a URL's host without "www.": {{domain .Url}}.
+	"domain": func(u string) string { return strings.TrimPrefix(feed.Host(u), "www.") },
+	"join":   func(sep string, s []string) string { return strings.Join(s, sep) },
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+	// json quotes a value as JSON, for building JSON or YAML by hand.
+	"json": func(v any) (string, error) {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		err := enc.Encode(v)
+		return strings.TrimSuffix(buf.String(), "\n"), err
+	},
+}
+
+// addFormatFlag adds -format, a template list and search run for each
+// link.
+func addFormatFlag(fs *flag.FlagSet) *string {
+	var s string
+	fs.StringVar(&s, "format", "", "text/template run for each link (inline or @file), e.g. '{{.Title}} {{.Url}}'")
+	return &s
+}
+
+// parseFormat parses a -format value: the template itself, or @FILE to
+// read it from a file.
+func parseFormat(spec string) (*template.Template, error) {
+	text := spec
+	if name, ok := strings.CutPrefix(spec, "@"); ok {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		text = string(b)
+	}
+	t, err := template.New("format").Funcs(formatFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("-format: %w", err)
+	}
+	return t, nil
+}
+
+// writeFormatted runs the -format template spec for each link, ending
+// each output with a newline unless the template does.
+func writeFormatted(w io.Writer, spec string, jf *jsonFlags, links []*v1.Link) error {
+	if jf.enabled() {
+		return errors.New("-format and -json/-jsonl are mutually exclusive")
+	}
+	t, err := parseFormat(spec)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, l := range links {
+		start := buf.Len()
+		if err := t.Execute(&buf, l); err != nil {
+			return fmt.Errorf("-format: %w", err)
+		}
+		if buf.Len() == start || buf.Bytes()[buf.Len()-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// exportCustom renders the (filtered) feed through a -format template run
+// once, with the Feed as its data.
+func exportCustom() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("export custom", flag.ExitOnError)
+	var file, spec, out string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&spec, "format", "", "text/template run once with the feed (inline or @file), e.g. '{{range .Links}}{{.Url}}\\n{{end}}' (required)")
+	fs.StringVar(&out, "out", "", "output file (default: stdout)")
+	ff := addFilterFlags(fs)
+	so := addSortFlags(fs)
+	drafts := addDraftsFlag(fs)
+	return fs, func() {
+		if file == "" || spec == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		t, err := parseFormat(spec)
+		if err != nil {
+			die(err)
+		}
+		flt, err := ff.filter()
+		if err != nil {
+			die(err)
+		}
+		if err := so.check(); err != nil {
+			die(err)
+		}
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		f = public(flt.Select(f), *drafts)
+		if err := so.sort(f.Links); err != nil {
+			die(err)
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, f); err != nil {
+			die(invalid(fmt.Errorf("-format: %w", err)))
+		}
+		if out == "" || out == "-" {
+			os.Stdout.Write(buf.Bytes())
+			return
+		}
+		if err := feed.WriteFileAtomic(out, buf.Bytes(), 0o644); err != nil {
+			die(err)
+		}
+		msg.Infof("exported %d links to %s (custom)", len(f.Links), out)
+	}
+}
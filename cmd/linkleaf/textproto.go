@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+)
+
+// textprotoHeader names the message for editors and tools that understand
+// the textproto header convention.
+const textprotoHeader = "# proto-file: linkleaf/v1/feed.proto\n# proto-message: linkleaf.v1.Feed\n\n"
+
+// textprotoField matches the "name: " that starts a field line.
+// prototext deliberately varies the spacing after the colon between
+// builds; normalizing it keeps exported files diff-able.
+var textprotoField = regexp.MustCompile(`(?m)^(\s*[A-Za-z_][\w.]*): +`)
+
+// renderTextproto encodes f as a multi-line textproto Feed.
+func renderTextproto(f *v1.Feed) ([]byte, error) {
+	b, err := prototext.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(f)
+	if err != nil {
+		return nil, fmt.Errorf("marshal textproto: %w", err)
+	}
+	return append([]byte(textprotoHeader), textprotoField.ReplaceAll(b, []byte("$1: "))...), nil
+}
+
+// readFeedText parses a whole Feed written by "export textproto" or
+// "export json". Unknown fields are errors, so nothing is silently lost.
+func readFeedText(r io.Reader, format string) (*v1.Feed, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f := &v1.Feed{}
+	switch format {
+	case "textproto":
+		err = prototext.Unmarshal(b, f)
+	case "json":
+		err = protojson.Unmarshal(b, f)
+	default:
+		err = fmt.Errorf("unknown feed format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", format, err)
+	}
+	return f, nil
+}
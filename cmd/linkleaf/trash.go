@@ -0,0 +1,221 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// defaultTrashRetention is how long removed links stay in the trash when
+// the config sets no trash.retention.
+const defaultTrashRetention = "30d"
+
+// trashCutoff returns the removal time before which trashed links are
+// purged, per trash.retention; keep is true when they are kept for ever.
+func trashCutoff(now time.Time) (cutoff time.Time, keep bool, err error) {
+	r := cmp.Or(cfg.Trash.Retention, defaultTrashRetention)
+	if r == "forever" {
+		return time.Time{}, true, nil
+	}
+	if !strings.ContainsAny(r[len(r)-1:], "dwmy") {
+		return time.Time{}, false, fmt.Errorf("trash.retention %q: want an age such as 30d, 2w, 3m or 1y, or forever", r)
+	}
+	if cutoff, err = feed.ParseRelativeDate(r, now); err != nil {
+		return time.Time{}, false, fmt.Errorf("trash.retention %q: want an age such as 30d, 2w, 3m or 1y, or forever", r)
+	}
+	return cutoff, false, nil
+}
+
+// purgeExpired drops the trashed links older than trash.retention,
+// reports them and returns how many there were.
+func purgeExpired(f *v1.Feed, now time.Time) (int, error) {
+	cutoff, keep, err := trashCutoff(now)
+	if err != nil || keep {
+		return 0, err
+	}
+	purged := feed.PurgeTrash(f, cutoff)
+	for _, t := range purged {
+		msg.Infof("purged [%s] %s from the trash (removed %s)", t.Link.GetId(), t.Link.GetTitle(), t.RemovedAt)
+	}
+	return len(purged), nil
+}
+
+func cmdTrashList() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("trash list", flag.ExitOnError)
+	var file string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		now := time.Now()
+		cutoff, keep, err := trashCutoff(now)
+		if err != nil {
+			die(err)
+		}
+		// A link is purged once its removal falls before the cutoff, so it is
+		// kept for as long as the cutoff trails today.
+		today, _ := time.Parse(feed.DateLayout, now.UTC().Format(feed.DateLayout))
+		kept := today.Sub(cutoff)
+		for _, t := range f.Trash {
+			l := t.Link
+			fmt.Printf("[%s] %s\n", l.GetId(), l.GetTitle())
+			fmt.Printf("    %s\n", l.GetUrl())
+			removed, err := time.Parse(time.RFC3339, t.RemovedAt)
+			switch {
+			case err != nil:
+				fmt.Printf("    removed at an unknown time; purged on the next change\n")
+			case keep:
+				fmt.Printf("    removed %s\n", t.RemovedAt)
+			default:
+				day, _ := time.Parse(feed.DateLayout, removed.UTC().Format(feed.DateLayout))
+				fmt.Printf("    removed %s, purged from %s\n", t.RemovedAt, day.Add(kept).AddDate(0, 0, 1).Format(feed.DateLayout))
+			}
+		}
+		if len(f.Trash) == 0 {
+			msg.Infof("the trash is empty")
+		}
+	}
+}
+
+func cmdTrashRestore() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("trash restore", flag.ExitOnError)
+	var file string
+	var all bool
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.BoolVar(&all, "all", false, "restore every trashed link")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || all == (fs.NArg() > 0) {
+			badUsage(fs)
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		// Restore in trash order, latest removal first, so every link finds
+		// the neighbours it was removed from.
+		pick := map[*v1.TrashedLink]bool{}
+		for _, id := range fs.Args() {
+			i, err := feed.FindTrashed(f, id)
+			if err != nil {
+				die(err)
+			}
+			pick[f.Trash[i]] = true
+		}
+		var restored []*v1.Link
+		for _, t := range slices.Clone(f.Trash) {
+			if !all && !pick[t] {
+				continue
+			}
+			l, err := feed.RestoreTrashed(f, slices.Index(f.Trash, t))
+			if err != nil {
+				if !all {
+					die(conflict(err))
+				}
+				fmt.Fprintf(os.Stderr, "warning: %v; left in the trash\n", err)
+				continue
+			}
+			restored = append(restored, l)
+		}
+		purged, err := purgeExpired(f, time.Now())
+		if err != nil {
+			die(err)
+		}
+		if len(restored) == 0 && purged == 0 {
+			msg.Infof("nothing to restore")
+			return
+		}
+		f.GeneratedAt = feed.NowRFC3339()
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		for _, l := range restored {
+			msg.Infof("restored [%s] %s", l.Id, l.Title)
+		}
+	}
+}
+
+func cmdTrashPurge() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("trash purge", flag.ExitOnError)
+	var file string
+	var all bool
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.BoolVar(&all, "all", false, "empty the trash")
+	sf := addSaveFlags(fs)
+	return fs, func() {
+		if file == "" || all && fs.NArg() > 0 {
+			badUsage(fs)
+		}
+
+		sf.lock(file)
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		sf.loaded(f)
+		n := len(f.Trash)
+		if n == 0 {
+			msg.Infof("the trash is empty")
+			return
+		}
+		switch {
+		case all:
+			for _, t := range f.Trash {
+				msg.Infof("purged [%s] %s", t.Link.GetId(), t.Link.GetTitle())
+			}
+			f.Trash = nil
+		case fs.NArg() > 0:
+			for _, id := range fs.Args() {
+				i, err := feed.FindTrashed(f, id)
+				if err != nil {
+					die(err)
+				}
+				t := f.Trash[i]
+				f.Trash = append(f.Trash[:i], f.Trash[i+1:]...)
+				msg.Infof("purged [%s] %s", t.Link.GetId(), t.Link.GetTitle())
+			}
+			if _, err := purgeExpired(f, time.Now()); err != nil {
+				die(err)
+			}
+		default:
+			cutoff, keep, err := trashCutoff(time.Now())
+			if err != nil {
+				die(err)
+			}
+			if keep {
+				die(invalid(errors.New("trash.retention is forever; name the links to purge, or -all")))
+			}
+			if _, err := purgeExpired(f, time.Now()); err != nil {
+				die(err)
+			}
+			if len(f.Trash) == n {
+				msg.Infof("nothing removed before %s to purge", cutoff.Format(feed.DateLayout))
+				return
+			}
+		}
+		if len(f.Trash) == 0 {
+			f.Trash = nil
+		}
+		f.GeneratedAt = feed.NowRFC3339()
+		if err := sf.save(file, f); err != nil {
+			die(err)
+		}
+		msg.Infof("purged %d links; %d left in the trash", n-len(f.Trash), len(f.Trash))
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"github.com/mattn/go-runewidth"
+)
+
+func cmdTUI() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	var file string
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		m := &tuiModel{path: file, f: f}
+		m.refilter()
+		if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+			die(err)
+		}
+	}
+}
+
+// tuiMode is what keys currently do: browse the list, or type into the
+// prompt for one of the other modes.
+type tuiMode int
+
+const (
+	modeBrowse tuiMode = iota
+	modeSearch
+	modeTag
+	modeTitle
+	modeTags
+	modeDelete
+)
+
+var tuiPrompts = map[tuiMode]string{
+	modeSearch: "/",
+	modeTag:    "tag filter: ",
+	modeTitle:  "title: ",
+	modeTags:   "tags: ",
+	modeDelete: "delete? (y/n) ",
+}
+
+const tuiHelp = "↑/↓ move  / search  t tag filter  o open  e edit title  T tags  d delete  esc clear  q quit"
+
+// detailHeight is the number of lines the detail pane below the list takes.
+const detailHeight = 7
+
+var (
+	tuiSelected = lipgloss.NewStyle().Reverse(true)
+	tuiFaint    = lipgloss.NewStyle().Faint(true)
+	tuiBold     = lipgloss.NewStyle().Bold(true)
+)
+
+type tuiModel struct {
+	path  string
+	f     *v1.Feed
+	shown []*v1.Link // links passing the search and tag filter
+
+	query     feed.Query
+	queryText string
+	tag       string
+
+	cursor, top   int
+	width, height int
+
+	mode   tuiMode
+	input  []rune
+	status string
+}
+
+func (m *tuiModel) Init() tea.Cmd { return nil }
+
+func (m *tuiModel) selected() *v1.Link {
+	if m.cursor < len(m.shown) {
+		return m.shown[m.cursor]
+	}
+	return nil
+}
+
+// refilter recomputes shown, keeping the cursor on the same link if it is
+// still there.
+func (m *tuiModel) refilter() {
+	var id string
+	if l := m.selected(); l != nil {
+		id = l.Id
+	}
+	flt := feed.Filter{}
+	if m.tag != "" {
+		flt.Tags = []string{m.tag}
+	}
+	m.shown = m.shown[:0]
+	for _, l := range m.f.Links {
+		if flt.Match(l) && m.query.Match(l) {
+			m.shown = append(m.shown, l)
+		}
+	}
+	m.cursor = min(m.cursor, max(len(m.shown)-1, 0))
+	for i, l := range m.shown {
+		if l.Id == id {
+			m.cursor = i
+		}
+	}
+}
+
+func (m *tuiModel) listHeight() int { return max(m.height-detailHeight-2, 3) }
+
+func (m *tuiModel) move(delta int) {
+	m.cursor = max(min(m.cursor+delta, len(m.shown)-1), 0)
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		if m.mode != modeBrowse {
+			return m, m.prompt(msg)
+		}
+		return m, m.browse(msg)
+	}
+	return m, nil
+}
+
+func (m *tuiModel) browse(k tea.KeyMsg) tea.Cmd {
+	m.status = ""
+	switch k.String() {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "pgup", "ctrl+b":
+		m.move(-m.listHeight())
+	case "pgdown", "ctrl+f", " ":
+		m.move(m.listHeight())
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.move(len(m.shown))
+	case "esc":
+		m.query, m.queryText, m.tag = feed.Query{}, "", ""
+		m.refilter()
+	case "/":
+		m.startPrompt(modeSearch, m.queryText)
+	case "t":
+		m.startPrompt(modeTag, m.tag)
+	}
+	l := m.selected()
+	if l == nil {
+		return nil
+	}
+	switch k.String() {
+	case "o", "enter":
+		if err := openBrowser(l.Url); err != nil {
+			m.status = err.Error()
+		}
+	case "e":
+		m.startPrompt(modeTitle, l.Title)
+	case "T":
+		m.startPrompt(modeTags, strings.Join(l.Tags, ","))
+	case "d":
+		m.startPrompt(modeDelete, "")
+	}
+	return nil
+}
+
+func (m *tuiModel) startPrompt(mode tuiMode, text string) {
+	m.mode, m.input = mode, []rune(text)
+}
+
+// prompt handles a key while the prompt is open. Search filters as the
+// query is typed; the other prompts act on enter.
+func (m *tuiModel) prompt(k tea.KeyMsg) tea.Cmd {
+	if m.mode == modeDelete {
+		if k.String() == "y" {
+			m.change("remove", nil)
+		}
+		m.mode = modeBrowse
+		return nil
+	}
+	switch k.Type {
+	case tea.KeyCtrlC:
+		return tea.Quit
+	case tea.KeyEsc:
+		if m.mode == modeSearch {
+			m.setQuery("")
+		}
+		m.mode = modeBrowse
+		return nil
+	case tea.KeyEnter:
+		m.submit(string(m.input))
+		m.mode = modeBrowse
+		return nil
+	case tea.KeyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case tea.KeyCtrlU:
+		m.input = m.input[:0]
+	case tea.KeyRunes, tea.KeySpace:
+		m.input = append(m.input, k.Runes...)
+	default:
+		return nil
+	}
+	if m.mode == modeSearch {
+		m.setQuery(string(m.input))
+	}
+	return nil
+}
+
+// setQuery filters by a search query (see feed.ParseQuery). While the
+// query doesn't parse, e.g. mid-way through a quote, the previous
+// results stay.
+func (m *tuiModel) setQuery(s string) {
+	q, err := feed.ParseQuery(s)
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.status = ""
+	m.query, m.queryText = q, s
+	m.refilter()
+}
+
+func (m *tuiModel) submit(text string) {
+	switch m.mode {
+	case modeSearch:
+		m.setQuery(text)
+	case modeTag:
+		m.tag = strings.TrimSpace(text)
+		m.refilter()
+	case modeTitle:
+		text = strings.TrimSpace(text)
+		if text == "" {
+			m.status = "title can't be empty"
+			return
+		}
+		m.change("edit", func(l *v1.Link) error {
+			l.Title = text
+			return nil
+		})
+	case modeTags:
+		m.change("edit", func(l *v1.Link) error {
+			tags, err := validTags(feed.SplitTags(text))
+			l.Tags = tags
+			return err
+		})
+	}
+}
+
+// change applies edit to the selected link, or removes it if edit is nil,
+// and saves. Like the edit and remove commands it locks the feed and works
+// on a fresh load, so changes made elsewhere since the TUI started are
+// kept; the journal records it under op.
+func (m *tuiModel) change(op string, edit func(*v1.Link) error) {
+	id := m.selected().Id
+	err := func() error {
+		unlock, err := feed.Lock(m.path)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		f, err := mustLoad(m.path)
+		if err != nil {
+			return err
+		}
+		sf := &saveFlags{op: op}
+		sf.loaded(f)
+		if edit == nil {
+			if feed.Trash(f, func(l *v1.Link) bool { return l.Id == id }, time.Now()) == nil {
+				return fmt.Errorf("no link with id %q", id)
+			}
+			m.status = "removed [" + id + "]"
+		} else {
+			l := feed.Find(f, id)
+			if l == nil {
+				return fmt.Errorf("no link with id %q", id)
+			}
+			if err := edit(l); err != nil {
+				return err
+			}
+			m.status = "saved [" + id + "]"
+		}
+		f.GeneratedAt = feed.NowRFC3339()
+		if err := sf.save(m.path, f); err != nil {
+			return err
+		}
+		m.f = f
+		return nil
+	}()
+	if err != nil {
+		m.status = err.Error()
+	}
+	m.refilter()
+}
+
+func (m *tuiModel) View() string {
+	if m.width == 0 {
+		return ""
+	}
+	var b strings.Builder
+	line := func(s string, style *lipgloss.Style) {
+		s = runewidth.Truncate(s, m.width, "…")
+		if style != nil {
+			s = style.Render(s)
+		}
+		b.WriteString(s + "\n")
+	}
+
+	head := fmt.Sprintf("linkleaf  %s  %d/%d links", m.path, len(m.shown), len(m.f.Links))
+	if m.queryText != "" {
+		head += "  /" + m.queryText
+	}
+	if m.tag != "" {
+		head += "  tag:" + m.tag
+	}
+	line(head, &tuiBold)
+
+	rows := m.listHeight()
+	m.top = min(max(m.top, m.cursor-rows+1), m.cursor)
+	for i := m.top; i < m.top+rows; i++ {
+		if i >= len(m.shown) {
+			b.WriteString("\n")
+			continue
+		}
+		l := m.shown[i]
+		row := fmt.Sprintf(" %s  %s", l.Date, l.Title)
+		if len(l.Tags) > 0 {
+			row += "  #" + strings.Join(l.Tags, " #")
+		}
+		if i == m.cursor {
+			line(runewidth.FillRight(row, m.width), &tuiSelected)
+		} else {
+			line(row, nil)
+		}
+	}
+
+	detail := make([]string, 0, detailHeight)
+	if l := m.selected(); l != nil {
+		detail = append(detail, "["+l.Id+"] "+l.Title, l.Url, "date "+l.Date+"  tags "+strings.Join(l.Tags, ","))
+		if l.Via != "" {
+			detail = append(detail, "via "+l.Via)
+		}
+		if l.Summary != "" {
+			detail = append(detail, strings.Split(wrap(l.Summary, max(m.width-1, 20), ""), "\n")...)
+		}
+	}
+	for i := range detailHeight {
+		if i < len(detail) {
+			line(detail[i], &tuiFaint)
+		} else {
+			b.WriteString("\n")
+		}
+	}
+
+	switch {
+	case m.mode != modeBrowse:
+		b.WriteString(runewidth.Truncate(tuiPrompts[m.mode]+string(m.input)+"█", m.width, "…"))
+	case m.status != "":
+		b.WriteString(runewidth.Truncate(m.status, m.width, "…"))
+	default:
+		b.WriteString(tuiFaint.Render(runewidth.Truncate(tuiHelp, m.width, "…")))
+	}
+	return b.String()
+}
+
+// openBrowser opens u with the platform's default handler.
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
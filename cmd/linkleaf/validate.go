@@ -0,0 +1,69 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+)
+
+func cmdValidate() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	var file, policyFile string
+	var asJSON, ci bool
+	fs.StringVar(&file, "file", "", "protobuf feed file (.pb), instead of the positional argument")
+	fs.StringVar(&policyFile, "policy", cfg.Policy, "also check the rules of this policy file (domains, tags, title length, summaries)")
+	fs.BoolVar(&asJSON, "json", false, "print the problems as a JSON array")
+	fs.BoolVar(&ci, "ci", false, "print GitHub Actions annotations and a JSON summary")
+	return fs, func() {
+		path, ok := feedArg(fs, file)
+		if !ok || asJSON && ci {
+			badUsage(fs)
+		}
+		policy, err := loadPolicy(policyFile)
+		if err != nil {
+			die(err)
+		}
+
+		f, err := mustLoad(path)
+		if err != nil {
+			die(err)
+		}
+		// Policy problems follow the lint ones of the same link.
+		problems := append(feed.Lint(f), policy.Check(f)...)
+		slices.SortStableFunc(problems, func(a, b feed.Problem) int { return cmp.Compare(a.Index, b.Index) })
+		switch {
+		case ci:
+			for _, p := range problems {
+				ciAnnotate("error", path, fmt.Sprintf("%s of link %d [%s]", p.Field, p.Index+1, p.ID), p.Message)
+			}
+			ciSummary(validateSummary{Command: "validate", File: path, OK: len(problems) == 0, Links: len(f.Links), Problems: len(problems)})
+		case asJSON:
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(append([]feed.Problem{}, problems...)); err != nil {
+				die(err)
+			}
+		default:
+			for _, p := range problems {
+				fmt.Println(p)
+			}
+			fmt.Printf("%s: %d links, %d problems\n", path, len(f.Links), len(problems))
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+type validateSummary struct {
+	Command  string `json:"command"`
+	File     string `json:"file"`
+	OK       bool   `json:"ok"`
+	Links    int    `json:"links"`
+	Problems int    `json:"problems"`
+}
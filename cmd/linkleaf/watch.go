@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchStep is one command watch runs when the feed changed: a shell
+// command from the config's [watch] table, or a linkleaf command line.
+type watchStep struct {
+	name  string
+	shell string   // from [watch]
+	argv  []string // or linkleaf's arguments, run by this binary
+}
+
+func cmdWatch() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	var file string
+	var onChange stringsFlag
+	var interval time.Duration
+	var initial bool
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.Var(&onChange, "on-change", "a step to run (repeatable, in order): a name from [watch] in the config, or a linkleaf command line such as \"export rss -out public/feed.xml\" (default: all of [watch])")
+	fs.DurationVar(&interval, "interval", watchInterval, "how long the file must stay unchanged before the steps run")
+	fs.BoolVar(&initial, "initial", true, "run the steps once at start too")
+	return fs, func() {
+		if file == "" || fs.NArg() != 0 || interval <= 0 {
+			badUsage(fs)
+		}
+		if storage.IsRemote(file) {
+			die(invalid(fmt.Errorf("watch needs a local file, got %s", file)))
+		}
+		steps, err := watchSteps(onChange)
+		if err != nil {
+			die(invalid(err))
+		}
+		path, err := feed.ExpandPath(file)
+		if err != nil {
+			die(invalid(err))
+		}
+		if path, err = filepath.Abs(path); err != nil {
+			die(err)
+		}
+		mod, size, err := feedStamp(path)
+		if err != nil {
+			die(err)
+		}
+		// Saves write a temporary file and rename it over the feed, which a
+		// watch on the file itself would lose track of; the directory sees
+		// the rename.
+		w, err := fsnotify.NewWatcher()
+		if err != nil {
+			die(err)
+		}
+		defer w.Close()
+		if err := w.Add(filepath.Dir(path)); err != nil {
+			die(fmt.Errorf("watch %s: %w", filepath.Dir(path), err))
+		}
+		// A sharded feed is saved inside its directory: the shards and the
+		// index are renamed into place there.
+		sharded := feed.IsShardedFeed(path)
+		if sharded {
+			if err := w.Add(path); err != nil {
+				die(fmt.Errorf("watch %s: %w", path, err))
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if initial {
+			runWatchSteps(ctx, file, steps)
+		}
+		msg.Infof("watching %s; Ctrl-C stops", file)
+		// The steps run once the file has had no events for -interval, so a
+		// burst of saves (an import, a sync) runs them once.
+		quiet := time.NewTimer(interval)
+		quiet.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-w.Errors:
+				fmt.Fprintf(os.Stderr, "warning: watch %s: %v\n", file, err)
+			case ev := <-w.Events:
+				name := filepath.Clean(ev.Name)
+				ours := name == path || name == path+feed.WALSuffix || sharded && filepath.Dir(name) == path
+				if ours && !ev.Has(fsnotify.Chmod) {
+					msg.Debugf("watch %s: %s", file, ev)
+					quiet.Reset(interval)
+				}
+			case <-quiet.C:
+				m, s, err := feedStamp(path)
+				if err != nil {
+					msg.Debugf("watch %s: %v", file, err) // removed, or mid-rename; the next event re-arms
+					continue
+				}
+				if m.Equal(mod) && s == size {
+					continue
+				}
+				mod, size = m, s
+				msg.Infof("%s changed", file)
+				runWatchSteps(ctx, file, steps)
+			}
+		}
+	}
+}
+
+// watchSteps resolves the -on-change flags: each names a step of the
+// config's [watch] table or is a linkleaf command line, split into
+// arguments like a shell would (quotes group, a backslash escapes).
+// Without flags it is every step of [watch], by name.
+func watchSteps(specs []string) ([]watchStep, error) {
+	var steps []watchStep
+	if len(specs) == 0 {
+		for _, name := range slices.Sorted(maps.Keys(cfg.Watch)) {
+			steps = append(steps, watchStep{name: name, shell: cfg.Watch[name]})
+		}
+		if len(steps) == 0 {
+			return nil, errors.New("nothing to run: pass -on-change or add steps to [watch] in the config")
+		}
+		return steps, nil
+	}
+	for _, s := range specs {
+		if command, ok := cfg.Watch[s]; ok {
+			steps = append(steps, watchStep{name: s, shell: command})
+			continue
+		}
+		argv, err := splitCommandLine(s)
+		if err != nil {
+			return nil, fmt.Errorf("-on-change %q: %w", s, err)
+		}
+		known := false
+		for _, c := range commands {
+			known = known || len(argv) > 0 && c.name == argv[0] && c.name != "watch"
+		}
+		if !known {
+			return nil, fmt.Errorf("-on-change: %q is neither a step in [watch] of the config nor a linkleaf command", s)
+		}
+		steps = append(steps, watchStep{name: s, argv: argv})
+	}
+	return steps, nil
+}
+
+// splitCommandLine splits s into arguments at unquoted whitespace. Single
+// quotes keep everything up to the next one; in double quotes and outside
+// quotes a backslash takes the next character literally.
+func splitCommandLine(s string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inArg = true, true
+		case quote == '"':
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or trailing backslash")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// runWatchSteps runs the steps in order with LINKLEAF_FEED set to the
+// watched file, so those that take the default feed use it. A failed step
+// is reported and the others still run.
+func runWatchSteps(ctx context.Context, file string, steps []watchStep) {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = os.Args[0]
+	}
+	for _, st := range steps {
+		if ctx.Err() != nil {
+			return
+		}
+		var cmd *exec.Cmd
+		switch {
+		case st.shell == "":
+			cmd = exec.CommandContext(ctx, exe, st.argv...)
+		case runtime.GOOS == "windows":
+			cmd = exec.CommandContext(ctx, "cmd", "/C", st.shell)
+		default:
+			cmd = exec.CommandContext(ctx, "sh", "-c", st.shell)
+		}
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		cmd.Env = append(os.Environ(), "LINKLEAF_FEED="+file)
+		start := time.Now()
+		if err := cmd.Run(); err != nil {
+			if ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "warning: %s: %v\n", st.name, err)
+			}
+			continue
+		}
+		msg.Infof("ran %s (%s)", st.name, time.Since(start).Round(time.Millisecond))
+	}
+}
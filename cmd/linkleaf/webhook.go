@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	"github.com/doriancodes/linkleaf-cli/pkg/webhook"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// webhookEvent is the event name of feed change notifications.
+const webhookEvent = "feed.changed"
+
+// webhookPayload is the body POSTed to the config's webhooks.urls after a
+// save. Feed is the feed's absolute path (or URL). Change is the journal
+// entry (see feed.JournalEntry); encrypted feeds, whose links the journal
+// doesn't hold either, only get the counts.
+type webhookPayload struct {
+	Event    string             `json:"event"`
+	Feed     string             `json:"feed"`
+	Op       string             `json:"op"`
+	Time     string             `json:"time"`
+	Added    int                `json:"added"`
+	Removed  int                `json:"removed"`
+	Modified int                `json:"modified"`
+	Change   *feed.JournalEntry `json:"change,omitempty"`
+}
+
+// notifyWebhooks posts the change op made from before to after to every
+// configured webhook, in parallel, and waits for them. The feed is
+// already saved, so failures are only warnings. Saves that changed
+// nothing the journal tracks notify no one.
+func notifyWebhooks(path, op string, before, after *v1.Feed) {
+	if len(cfg.Webhooks.URLs) == 0 {
+		return
+	}
+	e := feed.NewJournalEntry(op, before, after)
+	if e == nil {
+		return
+	}
+	if p, err := feed.ExpandPath(path); err == nil && !storage.IsRemote(p) {
+		path, _ = filepath.Abs(p)
+	}
+	payload := webhookPayload{
+		Event: webhookEvent, Feed: path, Op: e.Op, Time: e.Time,
+		Added: len(e.Added), Removed: len(e.Removed), Modified: len(e.Modified),
+	}
+	if !feed.IsEncryptedFile(path) {
+		payload.Change = e
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: webhooks not sent: %v\n", err)
+		return
+	}
+
+	opts := webhook.Options{Secret: cfg.Webhooks.Secret}
+	var wg sync.WaitGroup
+	for _, url := range cfg.Webhooks.URLs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := webhook.Post(context.Background(), url, webhookEvent, body, opts); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: webhook: %v\n", err)
+				return
+			}
+			msg.Debugf("webhook %s: delivered %s", url, webhookEvent)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/webmention"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+func cmdWebmention() (*flag.FlagSet, func()) {
+	fs := flag.NewFlagSet("webmention", flag.ExitOnError)
+	var file, id, source string
+	var dryRun bool
+	var timeout time.Duration
+	fs.StringVar(&file, "file", defaultFeed(), "protobuf feed file (.pb)")
+	fs.StringVar(&id, "id", "", "ID of the link to mention (required)")
+	fs.StringVar(&source, "source", "", "your page that links to it (default: webmention.source from the config)")
+	fs.BoolVar(&dryRun, "dry-run", false, "discover the endpoints but send nothing")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "timeout per request")
+	return fs, func() {
+		if file == "" || id == "" || fs.NArg() != 0 {
+			badUsage(fs)
+		}
+		f, err := mustLoad(file)
+		if err != nil {
+			die(err)
+		}
+		l, err := feed.FindPrefix(f, id)
+		if err != nil {
+			die(err)
+		}
+		if source == "" {
+			if source, err = webmentionSource(l); err != nil {
+				die(err)
+			}
+		}
+		if err := sendWebmentions(l, source, dryRun, timeout); err != nil {
+			die(err)
+		}
+	}
+}
+
+// webmentionSource is webmention.source from the config with {id}
+// replaced by l's ID.
+func webmentionSource(l *v1.Link) (string, error) {
+	if cfg.Webmention.Source == "" {
+		return "", errors.New("no -source given and no webmention.source in the config (e.g. https://links.example.com/#{id})")
+	}
+	return strings.ReplaceAll(cfg.Webmention.Source, "{id}", l.Id), nil
+}
+
+// sendWebmentions tells l's URL and via page that source links to them.
+// Pages without an endpoint are skipped; other failures are joined.
+func sendWebmentions(l *v1.Link, source string, dryRun bool, timeout time.Duration) error {
+	targets := []string{l.Url}
+	if l.Via != "" && l.Via != l.Url {
+		targets = append(targets, l.Via)
+	}
+	opts := webmention.Options{Timeout: timeout}
+	var errs []error
+	for _, target := range targets {
+		endpoint, err := webmention.Discover(context.Background(), target, opts)
+		if errors.Is(err, webmention.ErrNoEndpoint) {
+			msg.Infof("%s takes no webmentions", target)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webmention to %s: %w", target, err))
+			continue
+		}
+		if dryRun {
+			fmt.Printf("would mention %s from %s via %s\n", target, source, endpoint)
+			continue
+		}
+		status, err := webmention.Send(context.Background(), endpoint, source, target, opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webmention to %s: %w", target, err))
+			continue
+		}
+		if status != "" {
+			msg.Infof("mentioned %s from %s (status: %s)", target, source, status)
+		} else {
+			msg.Infof("mentioned %s from %s", target, source)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,258 @@
+// Package activitypub has the server-to-server parts of ActivityPub a
+// feed needs to be followed from the Fediverse: actor documents, signed
+// deliveries and signed fetches (see httpsig.go).
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent is sent when Options.UserAgent is empty.
+const DefaultUserAgent = "linkleaf-activitypub/1"
+
+// ContentType is the media type of ActivityPub documents.
+const ContentType = "application/activity+json"
+
+// JSON-LD contexts and the audience for public posts.
+const (
+	ActivityStreams = "https://www.w3.org/ns/activitystreams"
+	Security        = "https://w3id.org/security/v1"
+	Public          = ActivityStreams + "#Public"
+)
+
+// Options control deliveries and fetches.
+type Options struct {
+	// Attempts is how often Deliver tries before giving up (default 3).
+	// Only network errors, 408, 429 and 5xx answers are retried.
+	Attempts int
+	// Backoff is the wait before the second attempt, doubled after each
+	// further failure (default 5s).
+	Backoff time.Duration
+	// Timeout bounds each request (default 10s).
+	Timeout time.Duration
+	// UserAgent is sent with every request (default DefaultUserAgent).
+	UserAgent string
+	// Client is used for the requests (default http.DefaultClient).
+	Client *http.Client
+}
+
+func (o *Options) defaults() {
+	if o.Attempts <= 0 {
+		o.Attempts = 3
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = 5 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.UserAgent == "" {
+		o.UserAgent = DefaultUserAgent
+	}
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+}
+
+// Key is an actor's signing key; ID is the URL of its publicKey object,
+// usually the actor URL plus "#main-key".
+type Key struct {
+	ID      string
+	Private *rsa.PrivateKey
+}
+
+// GenerateKey returns a new 2048-bit RSA key as PKCS#8 PEM, the kind
+// Mastodon and most other servers accept.
+func GenerateKey() ([]byte, error) {
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(k)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ParseKey reads a PEM RSA private key (PKCS#8 or PKCS#1).
+func ParseKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rk, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA key (%T)", k)
+	}
+	return rk, nil
+}
+
+// PublicKeyPEM is the PKIX PEM of k's public half, for Actor.PublicKey.
+func PublicKeyPEM(k *rsa.PrivateKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// PublicKey is an actor's publicKey object.
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+// Actor is the part of an actor document servers need to talk to it.
+type Actor struct {
+	Context           any       `json:"@context,omitempty"`
+	ID                string    `json:"id"`
+	Type              string    `json:"type"`
+	PreferredUsername string    `json:"preferredUsername,omitempty"`
+	Name              string    `json:"name,omitempty"`
+	Summary           string    `json:"summary,omitempty"`
+	URL               string    `json:"url,omitempty"`
+	Inbox             string    `json:"inbox"`
+	Outbox            string    `json:"outbox,omitempty"`
+	Followers         string    `json:"followers,omitempty"`
+	PublicKey         PublicKey `json:"publicKey"`
+	Endpoints         *struct {
+		SharedInbox string `json:"sharedInbox,omitempty"`
+	} `json:"endpoints,omitempty"`
+}
+
+// SharedInbox is the actor's server-wide inbox, else its own.
+func (a *Actor) SharedInbox() string {
+	if a.Endpoints != nil && a.Endpoints.SharedInbox != "" {
+		return a.Endpoints.SharedInbox
+	}
+	return a.Inbox
+}
+
+// FetchActor GETs the actor document at url, signed with key so servers
+// that require authorized fetch answer too. The actor's id must be on the
+// host it was fetched from (after redirects), so no server can speak for
+// another's actors.
+func FetchActor(ctx context.Context, url string, key Key, opts Options) (*Actor, error) {
+	opts.defaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", ContentType+`, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`)
+	req.Header.Set("User-Agent", opts.UserAgent)
+	if key.Private != nil {
+		if err := Sign(req, key, nil); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	var a Actor
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&a); err != nil {
+		return nil, fmt.Errorf("GET %s: %w", url, err)
+	}
+	if a.ID == "" || a.Inbox == "" {
+		return nil, fmt.Errorf("GET %s: not an actor", url)
+	}
+	if !SameOrigin(a.ID, url) || !SameOrigin(a.ID, resp.Request.URL.String()) {
+		return nil, fmt.Errorf("GET %s: served actor %s of another origin", url, a.ID)
+	}
+	return &a, nil
+}
+
+// SameOrigin reports whether the absolute URLs a and b have the same
+// scheme, host and port.
+func SameOrigin(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil || ua.Host == "" {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// Deliver POSTs activity to inbox, signed with key, retrying per opts.
+// It returns the last error once every attempt failed.
+func Deliver(ctx context.Context, inbox string, activity any, key Key, opts Options) error {
+	opts.defaults()
+	body, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	wait := opts.Backoff
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		if retry, err = deliver(ctx, inbox, body, key, opts); err == nil {
+			return nil
+		}
+		if !retry || attempt >= opts.Attempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// deliver makes one attempt and reports whether a failure is worth retrying.
+func deliver(ctx context.Context, inbox string, body []byte, key Key, opts Options) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("User-Agent", opts.UserAgent)
+	if err := Sign(req, key, body); err != nil {
+		return false, err
+	}
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= 500
+	return retry, fmt.Errorf("POST %s: %s", inbox, resp.Status)
+}
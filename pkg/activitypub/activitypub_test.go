@@ -0,0 +1,84 @@
+package activitypub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchActorOrigin(t *testing.T) {
+	// other claims srv's actors, for requests redirected to it.
+	var srv *httptest.Server
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := srv.URL + r.URL.Path
+		json.NewEncoder(w).Encode(Actor{ID: id, Inbox: id + "/inbox"})
+	}))
+	defer other.Close()
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := srv.URL + r.URL.Path
+		switch r.URL.Path {
+		case "/users/spoof":
+			id = other.URL + "/users/victim"
+		case "/users/away":
+			http.Redirect(w, r, other.URL+"/users/away", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		json.NewEncoder(w).Encode(Actor{
+			ID:        id,
+			Type:      "Person",
+			Inbox:     id + "/inbox",
+			PublicKey: PublicKey{ID: id + "#main-key", Owner: id},
+		})
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"/users/alice", ""},
+		{"/users/spoof", "another origin"},
+		{"/users/away", "another origin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			a, err := FetchActor(context.Background(), srv.URL+tt.path, Key{}, Options{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("FetchActor: %v", err)
+				}
+				if a.ID != srv.URL+tt.path {
+					t.Errorf("ID = %q, want %q", a.ID, srv.URL+tt.path)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("FetchActor = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"https://a.example/users/x", "https://a.example/users/x#main-key", true},
+		{"https://a.example/users/x", "https://A.example/other", true},
+		{"https://a.example/users/x", "https://b.example/users/x", false},
+		{"https://a.example/users/x", "http://a.example/users/x", false},
+		{"https://a.example/users/x", "https://a.example:8443/users/x", false},
+		{"/users/x", "/users/x", false},
+	}
+	for _, tt := range tests {
+		if got := SameOrigin(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameOrigin(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
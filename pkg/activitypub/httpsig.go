@@ -0,0 +1,223 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTP Signatures (draft-cavage-http-signatures-12) as the Fediverse uses
+// them: rsa-sha256 over (request-target), host, date and, for requests
+// with a body, a SHA-256 Digest header.
+
+// MaxSkew is how far a signed request's Date may be from now.
+const MaxSkew = 12 * time.Hour
+
+// Sign adds Date, Digest (if body isn't nil) and Signature headers to req.
+// body must be what req will send.
+func Sign(req *http.Request, key Key, body []byte) error {
+	if key.Private == nil {
+		return errors.New("no signing key")
+	}
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		sum := sha256.Sum256(body)
+		req.Header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]))
+		headers = append(headers, "digest")
+	}
+	if req.Host == "" {
+		req.Host = req.URL.Host
+	}
+	text, err := signingString(req, headers, nil)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(text))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key.Private, crypto.SHA256, hash[:])
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		key.ID, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(sig)))
+	return nil
+}
+
+// KeyID is the keyId of r's Signature header: the URL of the signer's
+// public key, to fetch before calling Verify.
+func KeyID(r *http.Request) (string, error) {
+	params, err := signatureParams(r)
+	if err != nil {
+		return "", err
+	}
+	return params["keyId"], nil
+}
+
+// Verify checks r's Signature header against pub. The signature must
+// cover (request-target) and the date, and for a body also its Digest,
+// which must match body; the date must be within MaxSkew of now.
+func Verify(r *http.Request, body []byte, pub *rsa.PublicKey) error {
+	params, err := signatureParams(r)
+	if err != nil {
+		return err
+	}
+	switch params["algorithm"] {
+	case "", "rsa-sha256", "hs2019":
+	default:
+		return fmt.Errorf("signature algorithm %q not supported", params["algorithm"])
+	}
+	headers := strings.Fields(strings.ToLower(params["headers"]))
+	if len(headers) == 0 {
+		headers = []string{"date"}
+	}
+	if !slices.Contains(headers, "(request-target)") {
+		return errors.New("signature doesn't cover (request-target)")
+	}
+	switch {
+	case slices.Contains(headers, "date"):
+		t, err := http.ParseTime(r.Header.Get("Date"))
+		if err != nil {
+			return fmt.Errorf("bad Date header: %w", err)
+		}
+		if d := time.Since(t); d > MaxSkew || d < -MaxSkew {
+			return fmt.Errorf("Date %s is too far from now", r.Header.Get("Date"))
+		}
+	case slices.Contains(headers, "(created)"):
+		created, err := strconv.ParseInt(params["created"], 10, 64)
+		if err != nil {
+			return errors.New("bad signature created parameter")
+		}
+		if d := time.Since(time.Unix(created, 0)); d > MaxSkew || d < -MaxSkew {
+			return errors.New("signature created too far from now")
+		}
+	default:
+		return errors.New("signature doesn't cover the date")
+	}
+	if len(body) > 0 {
+		if !slices.Contains(headers, "digest") {
+			return errors.New("signature doesn't cover the Digest header")
+		}
+		if !digestMatches(r.Header.Get("Digest"), body) {
+			return errors.New("Digest doesn't match the body")
+		}
+	}
+	sig, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return fmt.Errorf("bad signature: %w", err)
+	}
+	text, err := signingString(r, headers, params)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(text))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, hash[:], sig); err != nil {
+		return errors.New("signature doesn't verify")
+	}
+	return nil
+}
+
+// ParsePublicKey reads an actor's publicKeyPem (PKIX or PKCS#1).
+func ParsePublicKey(s string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("no PEM data in public key")
+	}
+	if k, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	k, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rk, ok := k.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key isn't RSA (%T)", k)
+	}
+	return rk, nil
+}
+
+// signatureParams parses the Signature header (or "Authorization:
+// Signature ...") into its key="value" parameters.
+func signatureParams(r *http.Request) (map[string]string, error) {
+	h := r.Header.Get("Signature")
+	if h == "" {
+		h, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Signature ")
+	}
+	if h == "" {
+		return nil, errors.New("request isn't signed")
+	}
+	params := map[string]string{}
+	for h != "" {
+		k, rest, ok := strings.Cut(h, "=")
+		if !ok {
+			return nil, errors.New("malformed Signature header")
+		}
+		k = strings.TrimSpace(k)
+		var v string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, errors.New("malformed Signature header")
+			}
+			v, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			v, rest, _ = strings.Cut(rest, ",")
+			rest = "," + rest
+		}
+		params[k] = v
+		h = strings.TrimLeft(rest, ", ")
+	}
+	if params["keyId"] == "" || params["signature"] == "" {
+		return nil, errors.New("Signature header lacks keyId or signature")
+	}
+	return params, nil
+}
+
+// signingString is the text the signature covers: one "name: value" line
+// per signed header.
+func signingString(r *http.Request, headers []string, params map[string]string) (string, error) {
+	lines := make([]string, 0, len(headers))
+	for _, h := range headers {
+		var v string
+		switch h {
+		case "(request-target)":
+			v = strings.ToLower(r.Method) + " " + r.URL.RequestURI()
+		case "(created)", "(expires)":
+			v = params[strings.Trim(h, "()")]
+		case "host":
+			v = r.Host
+		default:
+			vs := r.Header.Values(h)
+			if len(vs) == 0 {
+				return "", fmt.Errorf("signed header %s is missing", h)
+			}
+			v = strings.Join(vs, ", ")
+		}
+		lines = append(lines, h+": "+v)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// digestMatches reports whether the Digest header has a SHA-256 of body.
+func digestMatches(header string, body []byte) bool {
+	sum := sha256.Sum256(body)
+	want := base64.StdEncoding.EncodeToString(sum[:])
+	for _, d := range strings.Split(header, ",") {
+		alg, v, _ := strings.Cut(strings.TrimSpace(d), "=")
+		if strings.EqualFold(alg, "SHA-256") && v == want {
+			return true
+		}
+	}
+	return false
+}
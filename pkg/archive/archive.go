@@ -0,0 +1,139 @@
+// Package archive preserves linked pages against link rot, either by
+// asking the Wayback Machine to capture them or by saving a local copy,
+// whole or as the article's text.
+package archive
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent is sent when Options.UserAgent is empty.
+const DefaultUserAgent = "linkleaf-archive/1"
+
+// DefaultEndpoint is the Wayback Machine.
+const DefaultEndpoint = "https://web.archive.org"
+
+// maxSnapshot bounds the size of a saved page.
+const maxSnapshot = 20 << 20
+
+// Options control a Wayback or Snapshot request.
+type Options struct {
+	// Timeout bounds the whole request (default 60s; captures are slow).
+	Timeout time.Duration
+	// UserAgent is sent with the request (default DefaultUserAgent).
+	UserAgent string
+	// Client is used for the request (default http.DefaultClient).
+	Client *http.Client
+	// Endpoint is the Wayback Machine base URL (default DefaultEndpoint).
+	Endpoint string
+}
+
+func (o *Options) defaults() {
+	if o.Timeout <= 0 {
+		o.Timeout = 60 * time.Second
+	}
+	if o.UserAgent == "" {
+		o.UserAgent = DefaultUserAgent
+	}
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+	if o.Endpoint == "" {
+		o.Endpoint = DefaultEndpoint
+	}
+	o.Endpoint = strings.TrimSuffix(o.Endpoint, "/")
+}
+
+func get(ctx context.Context, url string, opts Options) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", opts.UserAgent)
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// Wayback asks the Wayback Machine to capture url ("Save Page Now") and
+// returns the address of the capture. When the response doesn't name the
+// capture it returns the /web/<url> address, which resolves to the newest
+// one.
+func Wayback(ctx context.Context, url string, opts Options) (string, error) {
+	opts.defaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	resp, err := get(ctx, opts.Endpoint+"/save/"+url, opts)
+	if err != nil {
+		return "", fmt.Errorf("wayback: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxSnapshot))
+	if loc := resp.Header.Get("Content-Location"); strings.HasPrefix(loc, "/web/") {
+		return opts.Endpoint + loc, nil
+	}
+	if u := resp.Request.URL; strings.HasPrefix(u.Path, "/web/") {
+		return u.String(), nil
+	}
+	return opts.Endpoint + "/web/" + url, nil
+}
+
+var (
+	scriptRE = regexp.MustCompile(`(?is)<script\b.*?</script\s*>`)
+	headRE   = regexp.MustCompile(`(?i)<head\b[^>]*>`)
+)
+
+// Snapshot GETs url and returns the page prepared for reading offline:
+// scripts are dropped and a <base> element points relative links and
+// images back at the original site. Non-2xx responses and non-HTML
+// content are errors.
+func Snapshot(ctx context.Context, url string, opts Options) ([]byte, error) {
+	opts.defaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	b, final, err := getHTML(ctx, url, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	b = scriptRE.ReplaceAll(b, nil)
+	base := []byte(`<base href="` + html.EscapeString(final) + `">`)
+	if loc := headRE.FindIndex(b); loc != nil {
+		return append(b[:loc[1]:loc[1]], append(base, b[loc[1]:]...)...), nil
+	}
+	return append(base, b...), nil
+}
+
+// getHTML GETs the HTML page at url and returns it with its address after
+// redirects.
+func getHTML(ctx context.Context, url string, opts Options) ([]byte, string, error) {
+	resp, err := get(ctx, url, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		if mt, _, _ := mime.ParseMediaType(ct); mt != "text/html" && mt != "application/xhtml+xml" {
+			return nil, "", fmt.Errorf("%s: not an HTML page (%s)", url, mt)
+		}
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxSnapshot))
+	if err != nil {
+		return nil, "", err
+	}
+	return b, resp.Request.URL.String(), nil
+}
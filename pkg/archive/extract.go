@@ -0,0 +1,418 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Article is the readable content of a page, as Extract finds it.
+type Article struct {
+	Title  string // og:title, else <title>
+	Byline string // <meta name="author">, if any
+	// Text is the article as plain text: paragraphs separated by blank
+	// lines and not wrapped, headings starting with '#'s, list items with
+	// "• " (or "1. "), quotes with "> " and preformatted lines indented by
+	// four spaces.
+	Text string
+}
+
+// Readable GETs url and extracts its article (see Extract). Non-2xx
+// responses and non-HTML content are errors, as for Snapshot.
+func Readable(ctx context.Context, url string, opts Options) (Article, error) {
+	opts.defaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	b, _, err := getHTML(ctx, url, opts)
+	if err != nil {
+		return Article{}, err
+	}
+	a, err := Extract(b)
+	if err != nil {
+		return Article{}, fmt.Errorf("%s: %w", url, err)
+	}
+	return a, nil
+}
+
+// ErrNoArticle is returned by Extract for pages without readable text.
+var ErrNoArticle = errors.New("no article text found")
+
+var (
+	// unlikely class and id words mark page furniture; likely ones
+	// outweigh them (e.g. "article-sidebar" isn't dropped).
+	unlikelyRE = regexp.MustCompile(`(?i)ad-|banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|gdpr|header|legends|menu|modal|nav|newsletter|pager|popup|promo|related|remark|replies|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|tweet|widget`)
+	likelyRE   = regexp.MustCompile(`(?i)and|article|body|column|content|main|post|shadow|story|text|entry`)
+	positiveRE = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeRE = regexp.MustCompile(`(?i)-ad-|hidden|^hid$|banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// dropped are elements never part of an article's text.
+var dropped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true, atom.Iframe: true,
+	atom.Form: true, atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Svg: true,
+	atom.Canvas: true, atom.Object: true, atom.Embed: true, atom.Dialog: true,
+}
+
+// Extract finds the article in an HTML page the way Readability does:
+// it drops scripts, navigation and elements whose class or id suggests
+// page furniture, scores each container by the paragraphs in it (their
+// length and commas, less the share of link text) and keeps the best
+// one, with the siblings that look like part of it.
+func Extract(page []byte) (Article, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return Article{}, err
+	}
+	var a Article
+	var ogTitle, title string
+	for n := range doc.Descendants() {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch n.DataAtom {
+		case atom.Title:
+			if title == "" {
+				title = textOf(n)
+			}
+		case atom.Meta:
+			switch strings.ToLower(attr(n, "property") + attr(n, "name")) {
+			case "og:title":
+				ogTitle = attr(n, "content")
+			case "author":
+				a.Byline = collapse(attr(n, "content"))
+			}
+		}
+	}
+	a.Title = collapse(ogTitle)
+	if a.Title == "" {
+		a.Title = collapse(title)
+	}
+
+	body := find(doc, atom.Body)
+	if body == nil {
+		return a, ErrNoArticle
+	}
+	prune(body)
+	top := bestCandidate(body)
+	if top == nil {
+		top = body
+	}
+	var w textWriter
+	for _, n := range withSiblings(top) {
+		w.node(n)
+	}
+	a.Text = w.String()
+	// The article's own heading repeats the title.
+	if h := "# " + a.Title; strings.HasPrefix(a.Text, h+"\n") || a.Text == h {
+		a.Text = strings.TrimSpace(a.Text[len(h):])
+	}
+	if a.Text == "" {
+		return a, ErrNoArticle
+	}
+	return a, nil
+}
+
+// prune removes the elements of n that can't be article text.
+func prune(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || c.Type == html.ElementNode && unlikely(c) {
+			n.RemoveChild(c)
+		} else {
+			prune(c)
+		}
+		c = next
+	}
+}
+
+func unlikely(n *html.Node) bool {
+	if dropped[n.DataAtom] || attr(n, "hidden") != "" || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	switch n.DataAtom {
+	case atom.Article, atom.Main, atom.Body, atom.A, atom.Table, atom.Tbody, atom.Tr, atom.Td:
+		return false
+	}
+	if role := attr(n, "role"); role == "navigation" || role == "complementary" || role == "dialog" {
+		return true
+	}
+	id := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyRE.MatchString(id) && !likelyRE.MatchString(id)
+}
+
+// bestCandidate scores the parents of paragraphs and returns the best.
+func bestCandidate(body *html.Node) *html.Node {
+	scores := map[*html.Node]float64{}
+	var order []*html.Node
+	add := func(n *html.Node, s float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			order = append(order, n)
+		}
+		scores[n] += s
+	}
+	for n := range body.Descendants() {
+		if n.Type != html.ElementNode || (n.DataAtom != atom.P && n.DataAtom != atom.Pre && n.DataAtom != atom.Td && n.DataAtom != atom.Blockquote) {
+			continue
+		}
+		text := textOf(n)
+		if len(text) < 25 {
+			continue
+		}
+		s := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+		add(n.Parent, s)
+		if n.Parent != nil {
+			add(n.Parent.Parent, s/2)
+		}
+	}
+	var top *html.Node
+	best := 0.0
+	for _, n := range order {
+		s := scores[n] * (1 - linkDensity(n))
+		if s > best {
+			top, best = n, s
+		}
+	}
+	return top
+}
+
+func initialScore(n *html.Node) float64 {
+	s := 0.0
+	switch n.DataAtom {
+	case atom.Article:
+		s = 10
+	case atom.Div, atom.Main, atom.Section:
+		s = 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		s = 3
+	case atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li:
+		s = -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		s = -5
+	}
+	for _, v := range []string{attr(n, "class"), attr(n, "id")} {
+		if v == "" {
+			continue
+		}
+		if positiveRE.MatchString(v) {
+			s += 25
+		}
+		if negativeRE.MatchString(v) {
+			s -= 25
+		}
+	}
+	return s
+}
+
+// linkDensity is the share of n's text inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(textOf(n))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	for d := range n.Descendants() {
+		if d.DataAtom == atom.A && d.Type == html.ElementNode {
+			links += len(textOf(d))
+		}
+	}
+	return float64(links) / float64(total)
+}
+
+// withSiblings is top, with its siblings that read like more of the
+// article: paragraphs of some length with few links.
+func withSiblings(top *html.Node) []*html.Node {
+	if top.Parent == nil || top.DataAtom == atom.Body {
+		return []*html.Node{top}
+	}
+	var out []*html.Node
+	for s := range top.Parent.ChildNodes() {
+		if s == top {
+			out = append(out, s)
+			continue
+		}
+		if s.Type != html.ElementNode || s.DataAtom != atom.P {
+			continue
+		}
+		text := textOf(s)
+		if len(text) > 80 && linkDensity(s) < 0.25 || len(text) > 0 && linkDensity(s) == 0 && strings.ContainsAny(text, ".!?") {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// textWriter renders nodes as Article.Text.
+type textWriter struct {
+	b       strings.Builder
+	line    strings.Builder // the paragraph being written
+	prefix  []string        // of the paragraph: "> " per quote level, "• "
+	ordinal []int           // per open <ol>, the next item number; 0 in a <ul>
+}
+
+func (w *textWriter) String() string {
+	w.flush()
+	return strings.TrimSpace(w.b.String())
+}
+
+// flush ends the paragraph being written.
+func (w *textWriter) flush() {
+	text := collapse(w.line.String())
+	w.line.Reset()
+	if text == "" {
+		return
+	}
+	if w.b.Len() > 0 {
+		w.b.WriteString("\n\n")
+	}
+	w.b.WriteString(strings.Join(w.prefix, ""))
+	w.b.WriteString(text)
+	// A list item's prefix goes with its first paragraph only.
+	for i, p := range w.prefix {
+		if p != "> " {
+			w.prefix[i] = ""
+		}
+	}
+}
+
+func (w *textWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.line.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	switch n.DataAtom {
+	case atom.Br:
+		w.line.WriteByte(' ')
+		return
+	case atom.Img:
+		if alt := collapse(attr(n, "alt")); alt != "" {
+			w.line.WriteString(" [image: " + alt + "] ")
+		}
+		return
+	case atom.Hr:
+		w.flush()
+		return
+	case atom.Pre:
+		w.flush()
+		text := strings.Trim(textOf(n), "\n")
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		if w.b.Len() > 0 {
+			w.b.WriteString("\n\n")
+		}
+		lines := strings.Split(strings.ReplaceAll(text, "\t", "    "), "\n")
+		for i, l := range lines {
+			lines[i] = "    " + strings.TrimRight(l, " \r")
+		}
+		w.b.WriteString(strings.Join(lines, "\n"))
+		return
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.flush()
+		w.line.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		w.children(n)
+		w.flush()
+		return
+	case atom.Blockquote:
+		w.flush()
+		w.prefix = append(w.prefix, "> ")
+		w.children(n)
+		w.flush()
+		w.prefix = w.prefix[:len(w.prefix)-1]
+		return
+	case atom.Ul, atom.Ol:
+		w.flush()
+		start := 0
+		if n.DataAtom == atom.Ol {
+			start = 1
+		}
+		w.ordinal = append(w.ordinal, start)
+		w.children(n)
+		w.flush()
+		w.ordinal = w.ordinal[:len(w.ordinal)-1]
+		return
+	case atom.Li:
+		w.flush()
+		bullet := "• "
+		if k := len(w.ordinal); k > 0 && w.ordinal[k-1] > 0 {
+			bullet = fmt.Sprintf("%d. ", w.ordinal[k-1])
+			w.ordinal[k-1]++
+		}
+		w.prefix = append(w.prefix, bullet)
+		w.children(n)
+		w.flush()
+		w.prefix = w.prefix[:len(w.prefix)-1]
+		return
+	}
+	block := isBlock(n.DataAtom)
+	if block {
+		w.flush()
+	}
+	w.children(n)
+	if block {
+		w.flush()
+	}
+}
+
+func (w *textWriter) children(n *html.Node) {
+	for c := range n.ChildNodes() {
+		w.node(c)
+	}
+}
+
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Figure, atom.Figcaption,
+		atom.Table, atom.Tr, atom.Dl, atom.Dt, atom.Dd, atom.Details, atom.Summary, atom.Address:
+		return true
+	}
+	return false
+}
+
+func find(n *html.Node, a atom.Atom) *html.Node {
+	for d := range n.Descendants() {
+		if d.Type == html.ElementNode && d.DataAtom == a {
+			return d
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textOf is the text of n and its descendants.
+func textOf(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for d := range n.Descendants() {
+		if d.Type == html.TextNode {
+			b.WriteString(d.Data)
+		}
+	}
+	return b.String()
+}
+
+func collapse(s string) string { return strings.Join(strings.Fields(s), " ") }
@@ -0,0 +1,321 @@
+// Package assets keeps a directory of the images HTML pages show next to
+// links: each site's favicon and each page's og:image. Images are fetched
+// once and again when older than a maximum age; pages without an image are
+// remembered as such for as long, so they aren't asked on every build.
+//
+// The directory holds icons/<host>.<ext> and images/<link id>.<ext>, with
+// an empty <name>.missing file standing for "looked, found nothing".
+package assets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	"github.com/doriancodes/linkleaf-cli/pkg/pagemeta"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// Options control a Cache.
+type Options struct {
+	// MaxSize is the largest image kept, in bytes (default 1 MiB).
+	MaxSize int64
+	// MaxAge is how long an image, or its absence, is trusted before it is
+	// fetched again (default 30 days).
+	MaxAge time.Duration
+	// Timeout applies to each request (default 10s).
+	Timeout time.Duration
+	// Concurrency bounds the links fetched at once (default 8).
+	Concurrency int
+	// UserAgent is sent with every request (default pagemeta.DefaultUserAgent).
+	UserAgent string
+	// Client is used for requests (default http.DefaultClient).
+	Client *http.Client
+}
+
+// Images are a link's cached images as slash-separated paths relative to
+// the cache directory; "" when there is none.
+type Images struct {
+	Icon  string
+	Image string
+}
+
+// imageExts are the image types kept, by media type. SVG isn't one: served
+// from the site's own origin, it could run script.
+var imageExts = map[string]string{
+	"image/png":                ".png",
+	"image/jpeg":               ".jpg",
+	"image/gif":                ".gif",
+	"image/webp":               ".webp",
+	"image/avif":               ".avif",
+	"image/x-icon":             ".ico",
+	"image/vnd.microsoft.icon": ".ico",
+}
+
+const missingExt = ".missing"
+
+// Cache is an assets directory. It is safe for concurrent use.
+type Cache struct {
+	dir  string
+	opts Options
+
+	mu      sync.Mutex
+	entries map[string]entry // "icons/<host>" or "images/<id>"
+	busy    map[string]bool  // keys being fetched
+}
+
+type entry struct {
+	file    string // path relative to dir; "" if missing
+	fetched time.Time
+}
+
+// Open reads the cache in dir, creating the directory if needed.
+func Open(dir string, opts Options) (*Cache, error) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 1 << 20
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = 30 * 24 * time.Hour
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = pagemeta.DefaultUserAgent
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	c := &Cache{dir: dir, opts: opts, entries: map[string]entry{}, busy: map[string]bool{}}
+	for _, sub := range []string{"icons", "images"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+		des, err := os.ReadDir(filepath.Join(dir, sub))
+		if err != nil {
+			return nil, err
+		}
+		for _, de := range des {
+			info, err := de.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			ext := path.Ext(de.Name())
+			key := sub + "/" + strings.TrimSuffix(de.Name(), ext)
+			e := entry{file: sub + "/" + de.Name(), fetched: info.ModTime()}
+			if ext == missingExt {
+				e.file = ""
+			}
+			if old, ok := c.entries[key]; !ok || e.fetched.After(old.fetched) {
+				c.entries[key] = e
+			}
+		}
+	}
+	return c, nil
+}
+
+// Lookup returns the images cached for l, without fetching anything.
+func (c *Cache) Lookup(l *v1.Link) Images {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Images{Icon: c.entries[iconKey(l)].file, Image: c.entries[imageKey(l)].file}
+}
+
+// Update fetches the images of links that aren't cached or are older than
+// MaxAge, and returns how many links it fetched for. A link whose page
+// can't be fetched is remembered as having no image; the error is logged,
+// not returned. Update returns early with ctx's error when ctx is done.
+func (c *Cache) Update(ctx context.Context, links []*v1.Link) (int, error) {
+	var stale []*v1.Link
+	for _, l := range links {
+		if c.stale(imageKey(l)) || c.stale(iconKey(l)) {
+			stale = append(stale, l)
+		}
+	}
+	sem := make(chan struct{}, c.opts.Concurrency)
+	var wg sync.WaitGroup
+	for _, l := range stale {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			c.update(ctx, l)
+		}()
+	}
+	wg.Wait()
+	return len(stale), ctx.Err()
+}
+
+func (c *Cache) update(ctx context.Context, l *v1.Link) {
+	imgKey, icoKey := imageKey(l), iconKey(l)
+	// Another link on the same site (or a concurrent Update) may be on it.
+	fetchImage, fetchIcon := c.claim(imgKey), c.claim(icoKey)
+	defer c.release(imgKey, fetchImage)
+	defer c.release(icoKey, fetchIcon)
+	if !fetchImage && !fetchIcon {
+		return
+	}
+
+	meta, err := pagemeta.Fetch(ctx, l.Url, pagemeta.Options{Timeout: c.opts.Timeout, UserAgent: c.opts.UserAgent, Client: c.opts.Client})
+	if err != nil {
+		feed.Logger.Debug("assets: page", "url", l.Url, "err", err)
+	}
+	if fetchImage {
+		c.store(ctx, imgKey, meta.Image)
+	}
+	if fetchIcon {
+		icon := meta.Icon
+		if icon == "" {
+			if u, err := url.Parse(l.Url); err == nil && u.Host != "" {
+				icon = (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/favicon.ico"}).String()
+			}
+		}
+		c.store(ctx, icoKey, icon)
+	}
+}
+
+// claim reports whether key is stale and not being fetched, marking it
+// busy if so.
+func (c *Cache) claim(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if c.busy[key] || ok && time.Since(e.fetched) < c.opts.MaxAge {
+		return false
+	}
+	c.busy[key] = true
+	return true
+}
+
+func (c *Cache) release(key string, claimed bool) {
+	if claimed {
+		c.mu.Lock()
+		delete(c.busy, key)
+		c.mu.Unlock()
+	}
+}
+
+func (c *Cache) stale(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	return !ok || time.Since(e.fetched) >= c.opts.MaxAge
+}
+
+// store downloads src as key's image, or records key as missing when src
+// is "" or can't be used. A failure caused by ctx ending records nothing.
+func (c *Cache) store(ctx context.Context, key, src string) {
+	file := ""
+	if src != "" {
+		b, ext, err := c.download(ctx, src)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			feed.Logger.Debug("assets: image", "url", src, "err", err)
+		} else {
+			file = key + ext
+			if err := feed.WriteFileAtomic(filepath.Join(c.dir, filepath.FromSlash(file)), b, 0o644); err != nil {
+				feed.Logger.Debug("assets: write", "file", file, "err", err)
+				return
+			}
+		}
+	}
+	if file == "" {
+		if err := os.WriteFile(filepath.Join(c.dir, filepath.FromSlash(key+missingExt)), nil, 0o644); err != nil {
+			feed.Logger.Debug("assets: write", "file", key+missingExt, "err", err)
+			return
+		}
+	}
+	// Drop what key was before (another type, or the missing marker).
+	c.mu.Lock()
+	old := c.entries[key].file
+	c.entries[key] = entry{file: file, fetched: time.Now()}
+	c.mu.Unlock()
+	if old != file {
+		if old == "" {
+			old = key + missingExt
+		}
+		os.Remove(filepath.Join(c.dir, filepath.FromSlash(old)))
+	}
+}
+
+// download GETs an image of a kept type no larger than MaxSize and returns
+// it with the file extension for its type.
+func (c *Cache) download(ctx context.Context, src string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	req.Header.Set("Accept", "image/*")
+	resp, err := c.opts.Client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, "", fmt.Errorf("%s", resp.Status)
+	}
+	if resp.ContentLength > c.opts.MaxSize {
+		return nil, "", fmt.Errorf("%d bytes, over the %d limit", resp.ContentLength, c.opts.MaxSize)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, c.opts.MaxSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(b)) > c.opts.MaxSize {
+		return nil, "", fmt.Errorf("over the %d byte limit", c.opts.MaxSize)
+	}
+	mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if _, ok := imageExts[mt]; !ok {
+		// Servers often send icons as application/octet-stream.
+		mt, _, _ = mime.ParseMediaType(http.DetectContentType(b))
+	}
+	ext, ok := imageExts[mt]
+	if !ok {
+		return nil, "", errors.New("not a supported image type")
+	}
+	return b, ext, nil
+}
+
+func iconKey(l *v1.Link) string  { return "icons/" + fileName(feed.Host(l.Url)) }
+func imageKey(l *v1.Link) string { return "images/" + fileName(l.Id) }
+
+// fileName is s if it is safe as a file name on every platform, else a
+// hash of it.
+func fileName(s string) string {
+	ok := s != "" && !strings.HasPrefix(s, ".") && len(s) <= 100
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			ok = false
+			break
+		}
+	}
+	if ok {
+		return s
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,139 @@
+package crosspost
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultBlueskyService is the PDS most accounts live on.
+const DefaultBlueskyService = "https://bsky.social"
+
+// Bluesky posts to a Bluesky account through its PDS (AT Protocol).
+type Bluesky struct {
+	// Handle is the account, e.g. alice.bsky.social.
+	Handle string
+	// AppPassword is an app password (Settings → App passwords), not the
+	// account password.
+	AppPassword string
+	// Service is the PDS base URL (default DefaultBlueskyService).
+	Service string
+}
+
+// blueskyURLLen is how much of a URL a post shows; the link facet still
+// points at the whole URL.
+const blueskyURLLen = 40
+
+func (b Bluesky) Name() string { return "bluesky" }
+
+func (b Bluesky) Limit() int { return 300 }
+
+func (b Bluesky) Text(p Post) string {
+	shown := shortURL(p.URL)
+	return compose(p, shown, utf8.RuneCountInString(shown), b.Limit())
+}
+
+// shortURL drops the scheme and cuts u to blueskyURLLen characters, as
+// the Bluesky apps display links.
+func shortURL(u string) string {
+	s := strings.TrimPrefix(strings.TrimPrefix(u, "https://"), "http://")
+	if r := []rune(s); len(r) > blueskyURLLen {
+		s = string(r[:blueskyURLLen-1]) + "…"
+	}
+	return s
+}
+
+// blueskyFacet marks a byte range of a post's text as a link or hashtag.
+type blueskyFacet struct {
+	Index struct {
+		ByteStart int `json:"byteStart"`
+		ByteEnd   int `json:"byteEnd"`
+	} `json:"index"`
+	Features []map[string]string `json:"features"`
+}
+
+func facet(start, end int, feature map[string]string) blueskyFacet {
+	var f blueskyFacet
+	f.Index.ByteStart, f.Index.ByteEnd = start, end
+	f.Features = []map[string]string{feature}
+	return f
+}
+
+// facets marks the URL and the hashtags of text (see compose), which
+// Bluesky doesn't detect on its own.
+func facets(text, url string) []blueskyFacet {
+	var out []blueskyFacet
+	shown := shortURL(url)
+	i := strings.LastIndex(text, "\n\n"+shown) + 2
+	if i < 2 {
+		return nil
+	}
+	out = append(out, facet(i, i+len(shown), map[string]string{"$type": "app.bsky.richtext.facet#link", "uri": url}))
+	if tags, ok := strings.CutPrefix(text[i+len(shown):], "\n\n"); ok {
+		pos := len(text) - len(tags)
+		for _, t := range strings.Split(tags, " ") {
+			out = append(out, facet(pos, pos+len(t), map[string]string{"$type": "app.bsky.richtext.facet#tag", "tag": t[1:]}))
+			pos += len(t) + 1
+		}
+	}
+	return out
+}
+
+func (b Bluesky) Publish(ctx context.Context, p Post, opts Options) (string, error) {
+	if b.Handle == "" || b.AppPassword == "" {
+		return "", errors.New("bluesky: handle and app password are required")
+	}
+	opts.defaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	service := strings.TrimSuffix(b.Service, "/")
+	if service == "" {
+		service = DefaultBlueskyService
+	}
+
+	req, err := postJSON(service+"/xrpc/com.atproto.server.createSession", map[string]string{
+		"identifier": b.Handle, "password": b.AppPassword,
+	})
+	if err != nil {
+		return "", err
+	}
+	var session struct {
+		AccessJwt string `json:"accessJwt"`
+		DID       string `json:"did"`
+		Handle    string `json:"handle"`
+	}
+	if err := call(ctx, req, opts, &session); err != nil {
+		return "", err
+	}
+
+	text := b.Text(p)
+	record := map[string]any{
+		"$type":     "app.bsky.feed.post",
+		"text":      text,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+		"facets":    facets(text, p.URL),
+		"embed": map[string]any{
+			"$type": "app.bsky.embed.external",
+			"external": map[string]string{
+				"uri": p.URL, "title": p.Title, "description": p.Summary,
+			},
+		},
+	}
+	req, err = postJSON(service+"/xrpc/com.atproto.repo.createRecord", map[string]any{
+		"repo": session.DID, "collection": "app.bsky.feed.post", "record": record,
+	})
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+session.AccessJwt)
+	var created struct {
+		URI string `json:"uri"` // at://<did>/app.bsky.feed.post/<rkey>
+	}
+	if err := call(ctx, req, opts, &created); err != nil {
+		return "", err
+	}
+	rkey := created.URI[strings.LastIndexByte(created.URI, '/')+1:]
+	return "https://bsky.app/profile/" + session.Handle + "/post/" + rkey, nil
+}
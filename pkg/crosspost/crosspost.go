@@ -0,0 +1,161 @@
+// Package crosspost publishes links as posts on Mastodon and Bluesky.
+package crosspost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultUserAgent is sent when Options.UserAgent is empty.
+const DefaultUserAgent = "linkleaf-crosspost/1"
+
+// Post is the link to publish.
+type Post struct {
+	// ID makes repeated Mastodon posts of the same link idempotent for a
+	// while (the server keeps Idempotency-Key for an hour).
+	ID      string
+	Title   string
+	URL     string
+	Summary string // shown in the Bluesky link card
+	Tags    []string
+}
+
+// Options control a Publish call.
+type Options struct {
+	// Timeout bounds the whole call (default 30s).
+	Timeout time.Duration
+	// UserAgent is sent with every request (default DefaultUserAgent).
+	UserAgent string
+	// Client is used for the requests (default http.DefaultClient).
+	Client *http.Client
+}
+
+func (o *Options) defaults() {
+	if o.Timeout <= 0 {
+		o.Timeout = 30 * time.Second
+	}
+	if o.UserAgent == "" {
+		o.UserAgent = DefaultUserAgent
+	}
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+}
+
+// Target is a service to publish to.
+type Target interface {
+	// Name is the service name, e.g. "mastodon".
+	Name() string
+	// Text is the status Publish would post for p.
+	Text(p Post) string
+	// Limit is the service's post length in characters.
+	Limit() int
+	// Publish posts p and returns the web address of the new post.
+	Publish(ctx context.Context, p Post, opts Options) (string, error)
+}
+
+// Hashtag turns a feed tag into a hashtag without the '#': letters,
+// digits and underscores only, since services end a hashtag at anything
+// else ("machine-learning" becomes "machinelearning"). It returns "" if
+// nothing is left or the result is all digits.
+func Hashtag(tag string) string {
+	var b strings.Builder
+	digits := true
+	for _, r := range tag {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			b.WriteRune(r)
+			digits = digits && unicode.IsDigit(r)
+		}
+	}
+	if digits {
+		return ""
+	}
+	return b.String()
+}
+
+// compose lays out "title\n\nurl\n\n#tag1 #tag2". If that is longer than
+// limit characters, with the URL counted as urlCost, tags are dropped
+// from the end and then the title is shortened with an ellipsis.
+func compose(p Post, shownURL string, urlCost, limit int) string {
+	var tags []string
+	for _, t := range p.Tags {
+		if h := Hashtag(t); h != "" {
+			tags = append(tags, "#"+h)
+		}
+	}
+	title := strings.Join(strings.Fields(p.Title), " ")
+	for {
+		text := title + "\n\n" + shownURL
+		if len(tags) > 0 {
+			text += "\n\n" + strings.Join(tags, " ")
+		}
+		n := utf8.RuneCountInString(text) - utf8.RuneCountInString(shownURL) + urlCost
+		switch {
+		case n <= limit:
+			return text
+		case len(tags) > 0:
+			tags = tags[:len(tags)-1]
+		default:
+			keep := utf8.RuneCountInString(title) - (n - limit) - 1
+			if keep <= 0 {
+				return text // the URL alone is too long; let the service refuse it
+			}
+			title = strings.TrimSpace(string([]rune(title)[:keep])) + "…"
+		}
+	}
+}
+
+// call sends a JSON (or, for Mastodon, form) request and decodes the JSON
+// answer into out. Errors carry the service's message.
+func call(ctx context.Context, req *http.Request, opts Options, out any) error {
+	req.Header.Set("User-Agent", opts.UserAgent)
+	req.Header.Set("Accept", "application/json")
+	resp, err := opts.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(b, &e)
+		detail := strings.TrimSpace(e.Error + ": " + e.Message)
+		detail = strings.Trim(detail, ": ")
+		if detail == "" {
+			return fmt.Errorf("%s %s: %s", req.Method, req.URL.Redacted(), resp.Status)
+		}
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, detail)
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("%s %s: bad answer: %w", req.Method, req.URL.Redacted(), err)
+	}
+	return nil
+}
+
+// postJSON makes a POST request with v as the JSON body.
+func postJSON(url string, v any) (*http.Request, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
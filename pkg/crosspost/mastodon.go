@@ -0,0 +1,60 @@
+package crosspost
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mastodon posts statuses to a Mastodon (or compatible) server.
+type Mastodon struct {
+	// Server is the instance base URL, e.g. https://mastodon.social.
+	Server string
+	// Token is an access token with the write:statuses scope (Preferences
+	// → Development → New application).
+	Token string
+	// Visibility is public, unlisted, private or direct; empty uses the
+	// account's default.
+	Visibility string
+}
+
+// mastodonURLCost is how many characters Mastodon counts for any URL.
+const mastodonURLCost = 23
+
+func (m Mastodon) Name() string { return "mastodon" }
+
+func (m Mastodon) Limit() int { return 500 }
+
+func (m Mastodon) Text(p Post) string { return compose(p, p.URL, mastodonURLCost, m.Limit()) }
+
+func (m Mastodon) Publish(ctx context.Context, p Post, opts Options) (string, error) {
+	if m.Server == "" || m.Token == "" {
+		return "", errors.New("mastodon: server and token are required")
+	}
+	opts.defaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	form := url.Values{"status": {m.Text(p)}}
+	if m.Visibility != "" {
+		form.Set("visibility", m.Visibility)
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(m.Server, "/")+"/api/v1/statuses", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+m.Token)
+	if p.ID != "" {
+		req.Header.Set("Idempotency-Key", "linkleaf-"+p.ID)
+	}
+	var status struct {
+		URL string `json:"url"`
+	}
+	if err := call(ctx, req, opts, &status); err != nil {
+		return "", err
+	}
+	return status.URL, nil
+}
@@ -0,0 +1,202 @@
+// Package email composes multipart e-mail messages with an HTML and a
+// plain text version and sends them over SMTP.
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message is an e-mail with a plain text and, optionally, an HTML body;
+// mail clients show the HTML one when they can.
+type Message struct {
+	From    string   // "Name <addr>" or a bare address
+	To      []string // the same forms
+	Subject string
+	Date    time.Time // default now
+	Text    string
+	HTML    string
+}
+
+// addresses parses the From and To headers, which must hold one address
+// each.
+func (m Message) addresses() (from *mail.Address, to []*mail.Address, err error) {
+	if from, err = mail.ParseAddress(m.From); err != nil {
+		return nil, nil, fmt.Errorf("from %q: %w", m.From, err)
+	}
+	if len(m.To) == 0 {
+		return nil, nil, errors.New("no recipients")
+	}
+	for _, s := range m.To {
+		a, err := mail.ParseAddress(s)
+		if err != nil {
+			return nil, nil, fmt.Errorf("to %q: %w", s, err)
+		}
+		to = append(to, a)
+	}
+	return from, to, nil
+}
+
+// Bytes renders m as an RFC 5322 message: multipart/alternative when it
+// has an HTML body, with both parts quoted-printable UTF-8.
+func (m Message) Bytes() ([]byte, error) {
+	from, to, err := m.addresses()
+	if err != nil {
+		return nil, err
+	}
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	var rcpt []string
+	for _, a := range to {
+		rcpt = append(rcpt, a.String())
+	}
+	var b bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&b, "%s: %s\r\n", k, v) }
+	header("From", from.String())
+	header("To", strings.Join(rcpt, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("Message-ID", messageID(from.Address))
+	header("MIME-Version", "1.0")
+
+	if m.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		b.WriteString("\r\n")
+		if err := writeQP(&b, m.Text); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+	w := multipart.NewWriter(&b)
+	header("Content-Type", `multipart/alternative; boundary="`+w.Boundary()+`"`)
+	b.WriteString("\r\n")
+	for _, part := range []struct{ typ, body string }{{"text/plain", m.Text}, {"text/html", m.HTML}} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.typ + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQP(pw, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeQP(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func messageID(from string) string {
+	var r [12]byte
+	rand.Read(r[:])
+	_, host, _ := strings.Cut(from, "@")
+	if host == "" {
+		host = "linkleaf.invalid"
+	}
+	return "<" + hex.EncodeToString(r[:]) + "@" + host + ">"
+}
+
+// SMTP is the server Send hands messages to.
+type SMTP struct {
+	// Addr is host:port. Port 465 speaks TLS from the start; others are
+	// upgraded with STARTTLS when the server offers it.
+	Addr string
+	// Username and Password authenticate with PLAIN when set, which
+	// net/smtp only allows over TLS or to localhost.
+	Username, Password string
+	// Timeout bounds connecting and the whole exchange (default 30s).
+	Timeout time.Duration
+}
+
+// Send delivers m through s.
+func Send(m Message, s SMTP) error {
+	from, to, err := m.addresses()
+	if err != nil {
+		return err
+	}
+	body, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+	host, port, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return fmt.Errorf("smtp address %q: %w", s.Addr, err)
+	}
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	d := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	if port == "465" {
+		conn, err = tls.DialWithDialer(d, "tcp", s.Addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = d.Dial("tcp", s.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok && port != "465" {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, a := range to {
+		if err := c.Rcpt(a.Address); err != nil {
+			return fmt.Errorf("%s: %w", a.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,90 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"golang.org/x/text/unicode/norm"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Canonical form: every string in Unicode NFC, unknown fields dropped,
+// then marshaled deterministically. protobuf-go writes known fields in
+// field-number order, so equal content gives equal bytes regardless of
+// how the message was built, what order map entries were inserted in, or
+// which protobuf version wrote the file it came from. Repeated fields keep
+// their order: link and tag order are content.
+
+// Canonicalize returns a copy of m in canonical form.
+func Canonicalize[M proto.Message](m M) M {
+	c := proto.Clone(m).(M)
+	canonicalize(c.ProtoReflect())
+	return c
+}
+
+func canonicalize(m protoreflect.Message) {
+	m.SetUnknown(nil)
+	var strs []protoreflect.FieldDescriptor // set after Range, which mustn't see m change
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList():
+			l := v.List()
+			for i := 0; i < l.Len(); i++ {
+				if fd.Message() != nil {
+					canonicalize(l.Get(i).Message())
+				} else if fd.Kind() == protoreflect.StringKind {
+					l.Set(i, protoreflect.ValueOfString(norm.NFC.String(l.Get(i).String())))
+				}
+			}
+		case fd.IsMap():
+			mp := v.Map()
+			mp.Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				if fd.MapValue().Message() != nil {
+					canonicalize(mv.Message())
+				} else if fd.MapValue().Kind() == protoreflect.StringKind {
+					mp.Set(k, protoreflect.ValueOfString(norm.NFC.String(mv.String())))
+				}
+				return true
+			})
+		case fd.Message() != nil:
+			canonicalize(v.Message())
+		case fd.Kind() == protoreflect.StringKind:
+			strs = append(strs, fd)
+		}
+		return true
+	})
+	for _, fd := range strs {
+		m.Set(fd, protoreflect.ValueOfString(norm.NFC.String(m.Get(fd).String())))
+	}
+}
+
+// MarshalCanonical encodes the canonical form of m (see Canonicalize).
+func MarshalCanonical(m proto.Message) ([]byte, error) {
+	return proto.MarshalOptions{Deterministic: true}.Marshal(Canonicalize(m))
+}
+
+// LinkHash is the hex SHA-256 of l's canonical encoding.
+func LinkHash(l *v1.Link) (string, error) {
+	b, err := MarshalCanonical(l)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// FeedHash is the hex SHA-256 of f's canonical encoding without
+// generated_at, which every save restamps: it changes only when the
+// version, title or links do.
+func FeedHash(f *v1.Feed) (string, error) {
+	c := Canonicalize(f)
+	c.GeneratedAt = ""
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
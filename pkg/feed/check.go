@@ -0,0 +1,47 @@
+package feed
+
+import (
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// CheckHistoryLen is how many earlier checks a link keeps in
+// check_history.
+const CheckHistoryLen = 10
+
+// CheckFailed reports whether c found the link broken: a request error or
+// an HTTP error status.
+func CheckFailed(c *v1.LinkCheck) bool {
+	return c.Error != "" || c.Status == 0 || c.Status >= 400
+}
+
+// RecordCheck makes c l's last_check, moving the previous one to the end of
+// check_history (dropping the oldest beyond CheckHistoryLen), and sets
+// c.Failures from the previous count.
+func RecordCheck(l *v1.Link, c *v1.LinkCheck) {
+	c.Failures = 0
+	if CheckFailed(c) {
+		c.Failures = 1
+		if prev := l.LastCheck; prev != nil {
+			c.Failures = prev.Failures + 1
+		}
+	}
+	if prev := l.LastCheck; prev != nil {
+		l.CheckHistory = append(l.CheckHistory, prev)
+		if n := len(l.CheckHistory) - CheckHistoryLen; n > 0 {
+			l.CheckHistory = append([]*v1.LinkCheck(nil), l.CheckHistory[n:]...)
+		}
+	}
+	l.LastCheck = c
+}
+
+// CheckStale reports whether l's last check is older than maxAge at now.
+// Links never checked, or whose checked_at doesn't parse, are stale.
+func CheckStale(l *v1.Link, maxAge time.Duration, now time.Time) bool {
+	if l.LastCheck == nil {
+		return true
+	}
+	t, err := time.Parse(time.RFC3339, l.LastCheck.CheckedAt)
+	return err != nil || now.Sub(t) > maxAge
+}
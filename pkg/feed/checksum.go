@@ -0,0 +1,52 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+)
+
+// ChecksumSuffix names the sidecar that holds a feed file's SHA-256, in
+// sha256sum format ("<hex>  <name>"), so `sha256sum -c` works on it too.
+const ChecksumSuffix = ".sha256"
+
+// ErrChecksumMismatch means the feed file doesn't match its sidecar,
+// e.g. because it was truncated or corrupted after it was written.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+func writeChecksum(path string, data []byte) error {
+	sum := sha256.Sum256(data)
+	line := fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), filepath.Base(path))
+	return writeFile(path+ChecksumSuffix, []byte(line))
+}
+
+func verifyChecksum(path string, data []byte) error {
+	b, err := readFile(path + ChecksumSuffix)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("verify %s: no %s sidecar (save with -checksum first)", path, ChecksumSuffix)
+		}
+		return err
+	}
+	want, _, _ := strings.Cut(strings.TrimSpace(string(b)), " ")
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, want) {
+		return fmt.Errorf("verify %s: %w (file %s…, sidecar %s…)", path, ErrChecksumMismatch, got[:12], want[:min(12, len(want))])
+	}
+	return nil
+}
+
+func hasChecksum(path string) bool {
+	if storage.IsRemote(path) {
+		_, err := readFile(path + ChecksumSuffix)
+		return err == nil
+	}
+	_, err := os.Stat(path + ChecksumSuffix)
+	return err == nil
+}
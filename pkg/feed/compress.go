@@ -0,0 +1,181 @@
+package feed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression values for SaveOptions.Compression. A compressed feed file
+// holds the gzip or zstd compressed protobuf (or stream) bytes; encryption,
+// if any, is applied on top. Load recognizes compressed data by its magic
+// bytes, so the file name doesn't matter when reading.
+const (
+	CompressNone = "none"
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// maxDecompressed bounds a decompressed feed, so a corrupt or hostile file
+// can't exhaust memory.
+const maxDecompressed = 1 << 30
+
+// Compressions lists the accepted Compression values.
+func Compressions() []string { return []string{CompressNone, CompressGzip, CompressZstd} }
+
+// DetectCompression returns the compression of b by its magic bytes:
+// CompressGzip, CompressZstd or CompressNone.
+func DetectCompression(b []byte) string {
+	switch {
+	case bytes.HasPrefix(b, gzipMagic):
+		return CompressGzip
+	case bytes.HasPrefix(b, zstdMagic):
+		return CompressZstd
+	}
+	return CompressNone
+}
+
+// CompressionForName returns the compression a file name asks for: gzip
+// for .gz, zstd for .zst, "" for anything else.
+func CompressionForName(path string) string {
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		return CompressGzip
+	case strings.HasSuffix(path, ".zst"):
+		return CompressZstd
+	}
+	return ""
+}
+
+// FileCompression returns the compression of the file at path (see
+// ExpandPath). Encrypted files report the compression their name asks
+// for, since their contents can't be inspected without the key.
+func FileCompression(path string) string {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return CompressNone
+	}
+	if c := fileCompression(path); c != "" {
+		return c
+	}
+	if c := CompressionForName(path); c != "" {
+		return c
+	}
+	return CompressNone
+}
+
+// fileCompression sniffs the existing plain file at path; "" if it is
+// missing, unreadable or encrypted.
+func fileCompression(path string) string {
+	var head []byte
+	if storage.IsRemote(path) {
+		b, err := readFile(path)
+		if err != nil {
+			return ""
+		}
+		head = b
+	} else {
+		fh, err := os.Open(path)
+		if err != nil {
+			return ""
+		}
+		defer fh.Close()
+		head = make([]byte, len(EncryptedMagic))
+		n, _ := io.ReadFull(fh, head)
+		head = head[:n]
+	}
+	if len(head) == 0 || IsEncrypted(head) {
+		return ""
+	}
+	return DetectCompression(head)
+}
+
+// compressionFormat decides how SaveWith compresses path: as
+// opts.Compression says, else like the existing file, else by name.
+func compressionFormat(path string, opts SaveOptions) (string, error) {
+	switch opts.Compression {
+	case CompressNone, CompressGzip, CompressZstd:
+		return opts.Compression, nil
+	case "":
+		if c := fileCompression(path); c != "" {
+			return c, nil
+		}
+		if c := CompressionForName(path); c != "" {
+			return c, nil
+		}
+		return CompressNone, nil
+	}
+	return "", fmt.Errorf("unknown compression %q (want %s)", opts.Compression, strings.Join(Compressions(), ", "))
+}
+
+// compress encodes b with c.
+func compress(b []byte, c string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch c {
+	case CompressNone:
+		return b, nil
+	case CompressGzip:
+		w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(b); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case CompressZstd:
+		w, err := zstd.NewWriter(&buf, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+		if err != nil {
+			return nil, err
+		}
+		return w.EncodeAll(b, nil), w.Close()
+	default:
+		return nil, fmt.Errorf("unknown compression %q", c)
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress undoes compress, telling the compression from b's magic
+// bytes; uncompressed data is returned as is.
+func decompress(b []byte) ([]byte, error) {
+	var r io.Reader
+	switch DetectCompression(b) {
+	case CompressGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	case CompressZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(b), zstd.WithDecoderMaxMemory(maxDecompressed))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return b, nil
+	}
+	out, err := io.ReadAll(io.LimitReader(r, maxDecompressed+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > maxDecompressed {
+		return nil, fmt.Errorf("decompressed feed exceeds %d bytes", maxDecompressed)
+	}
+	return out, nil
+}
@@ -0,0 +1,126 @@
+package feed
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+)
+
+// EncryptedMagic starts every encrypted feed file. A protobuf Feed can't
+// begin with these bytes ('L' would be an end-group tag), so plain and
+// encrypted files are told apart by their first bytes alone.
+//
+// Layout: magic | iterations (uint32 BE) | salt (16) | nonce (12) |
+// AES-256-GCM ciphertext of the protobuf bytes, with the header as
+// additional data. The key is PBKDF2-HMAC-SHA256 of the passphrase.
+const EncryptedMagic = "LINKLEAF-ENC1\n"
+
+const (
+	kdfIterations = 600_000
+	saltSize      = 16
+	headerSize    = len(EncryptedMagic) + 4 + saltSize
+)
+
+var (
+	// ErrEncrypted means the feed file is encrypted and no key was given.
+	ErrEncrypted = errors.New("feed is encrypted and no key was given")
+	// ErrBadKey means decryption failed: wrong passphrase or a damaged file.
+	ErrBadKey = errors.New("cannot decrypt feed: wrong key or corrupted file")
+)
+
+// IsEncrypted reports whether data is an encrypted feed file.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(EncryptedMagic))
+}
+
+// IsEncryptedFile reports whether the file at path (see ExpandPath) is an
+// encrypted feed. Unreadable and missing files are not.
+func IsEncryptedFile(path string) bool {
+	path, err := ExpandPath(path)
+	return err == nil && fileEncrypted(path)
+}
+
+func fileEncrypted(path string) bool {
+	if storage.IsRemote(path) {
+		b, err := readFile(path)
+		return err == nil && IsEncrypted(b)
+	}
+	fh, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer fh.Close()
+	head := make([]byte, len(EncryptedMagic))
+	_, err = io.ReadFull(fh, head)
+	return err == nil && IsEncrypted(head)
+}
+
+// Encrypt seals plain with a key derived from passphrase.
+func Encrypt(plain, passphrase []byte) ([]byte, error) {
+	header := make([]byte, headerSize)
+	copy(header, EncryptedMagic)
+	binary.BigEndian.PutUint32(header[len(EncryptedMagic):], kdfIterations)
+	if _, err := rand.Read(header[headerSize-saltSize:]); err != nil {
+		return nil, err
+	}
+	aead, err := feedCipher(header, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(header, nonce...)
+	return aead.Seal(out, nonce, plain, header), nil
+}
+
+// Decrypt opens data produced by Encrypt.
+func Decrypt(data, passphrase []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, errors.New("not an encrypted feed")
+	}
+	if len(passphrase) == 0 {
+		return nil, ErrEncrypted
+	}
+	if len(data) < headerSize+12 {
+		return nil, ErrBadKey
+	}
+	header := data[:headerSize]
+	aead, err := feedCipher(header, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	rest := data[headerSize:]
+	plain, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], header)
+	if err != nil {
+		return nil, ErrBadKey
+	}
+	return plain, nil
+}
+
+func feedCipher(header, passphrase []byte) (cipher.AEAD, error) {
+	iter := binary.BigEndian.Uint32(header[len(EncryptedMagic):])
+	if iter == 0 || iter > 10*kdfIterations {
+		return nil, fmt.Errorf("encrypted feed: bad iteration count %d", iter)
+	}
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), header[headerSize-saltSize:], int(iter), 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,100 @@
+package feed
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// DateLayout is the format of a Link.Date without a time (YYYY-MM-DD).
+const DateLayout = "2006-01-02"
+
+// ParseDate parses a Link.Date, YYYY-MM-DD or an RFC 3339 time, to its
+// day: midnight UTC of the date as written, whatever the zone. Filters,
+// queries and periods compare days.
+func ParseDate(s string) (time.Time, error) {
+	if len(s) > len(DateLayout) {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return time.Time{}, err
+		}
+		s = t.Format(DateLayout)
+	}
+	return time.Parse(DateLayout, s)
+}
+
+// ParseRelativeDate parses a filter bound: a date as ParseDate reads it,
+// or an age counted back from now's day (UTC) in days, weeks, months or
+// years, such as "7d", "2w", "3m" or "1y".
+func ParseRelativeDate(s string, now time.Time) (time.Time, error) {
+	if n, err := strconv.Atoi(s[:max(len(s)-1, 0)]); err == nil && n >= 0 && len(s) > 1 {
+		day, _ := time.Parse(DateLayout, now.UTC().Format(DateLayout))
+		switch s[len(s)-1] {
+		case 'd':
+			return day.AddDate(0, 0, -n), nil
+		case 'w':
+			return day.AddDate(0, 0, -7*n), nil
+		case 'm':
+			return day.AddDate(0, -n, 0), nil
+		case 'y':
+			return day.AddDate(-n, 0, 0), nil
+		}
+	}
+	t, err := ParseDate(s)
+	if err != nil {
+		return time.Time{}, errors.New("want YYYY-MM-DD or an age such as 7d, 2w, 3m or 1y")
+	}
+	return t, nil
+}
+
+// NormalizeDate reads a date as typed, e.g. to add -date: YYYY-MM-DD,
+// returned as is, or a date and time ("2024-05-01T18:30", or with a
+// space) with optional seconds and zone (Z, +02:00), returned in RFC 3339.
+// A time without a zone is in loc.
+func NormalizeDate(s string, loc *time.Location) (string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) <= len(DateLayout) {
+		_, err := time.Parse(DateLayout, s)
+		return s, err
+	}
+	day, clock, ok := strings.Cut(s, "T")
+	if !ok {
+		day, clock, ok = strings.Cut(s, " ")
+	}
+	if !ok {
+		return "", errors.New("want YYYY-MM-DD or YYYY-MM-DDTHH:MM[:SS][Z|±HH:MM]")
+	}
+	s = day + "T" + strings.TrimSpace(clock)
+	for _, layout := range []string{"2006-01-02T15:04:05Z07:00", "2006-01-02T15:04Z07:00"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(time.RFC3339), nil
+		}
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.Format(time.RFC3339), nil
+		}
+	}
+	return "", errors.New("want YYYY-MM-DD or YYYY-MM-DDTHH:MM[:SS][Z|±HH:MM]")
+}
+
+// LinkTime is when l was posted, as precisely as the feed knows: the time
+// of Link.Date when it has one, else added_at if it falls on that day
+// (UTC), else midnight UTC of the date. ok is false without a valid date.
+func LinkTime(l *v1.Link) (t time.Time, ok bool) {
+	if len(l.Date) > len(DateLayout) {
+		t, err := time.Parse(time.RFC3339, l.Date)
+		return t, err == nil
+	}
+	d, err := time.Parse(DateLayout, l.Date)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if a, err := time.Parse(time.RFC3339, l.AddedAt); err == nil && a.UTC().Format(DateLayout) == l.Date {
+		return a.UTC(), true
+	}
+	return d, true
+}
@@ -0,0 +1,70 @@
+package feed
+
+import (
+	"cmp"
+	"slices"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// DupGroup is a set of links sharing a normalized URL (see NormalizeURL).
+type DupGroup struct {
+	Kept    *v1.Link
+	Dropped []*v1.Link
+}
+
+// Dedupe collapses links with the same normalized URL into one, keeping
+// the newest by Date, then AddedAt (the oldest with keepOldest). The kept
+// link stays where it was and gains the tags of the dropped ones. Groups
+// are returned in feed order of their kept link.
+func Dedupe(f *v1.Feed, keepOldest bool) []DupGroup {
+	groups := map[string][]*v1.Link{}
+	var order []string
+	for _, l := range f.Links {
+		key := NormalizeURL(l.Url)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], l)
+	}
+
+	byKept := map[*v1.Link]DupGroup{}
+	drop := map[*v1.Link]bool{}
+	for _, key := range order {
+		links := groups[key]
+		if len(links) < 2 {
+			continue
+		}
+		kept := links[0]
+		for _, l := range links[1:] {
+			c := cmp.Or(cmp.Compare(l.Date, kept.Date), cmp.Compare(l.AddedAt, kept.AddedAt))
+			if (!keepOldest && c > 0) || (keepOldest && c < 0) {
+				kept = l
+			}
+		}
+		g := DupGroup{Kept: kept}
+		tags := slices.Clone(kept.Tags)
+		for _, l := range links {
+			if l != kept {
+				g.Dropped = append(g.Dropped, l)
+				tags = append(tags, l.Tags...)
+				drop[l] = true
+			}
+		}
+		if len(tags) > 0 {
+			kept.Tags = UniqueTags(tags)
+		}
+		byKept[kept] = g
+	}
+	if len(byKept) == 0 {
+		return nil
+	}
+	RemoveFunc(f, func(l *v1.Link) bool { return drop[l] })
+	var out []DupGroup
+	for _, l := range f.Links {
+		if g, ok := byKept[l]; ok {
+			out = append(out, g)
+		}
+	}
+	return out
+}
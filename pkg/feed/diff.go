@@ -0,0 +1,112 @@
+package feed
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Diff is the link-level difference between two feeds, keyed by link ID.
+type Diff struct {
+	Added    []*v1.Link
+	Removed  []*v1.Link
+	Modified []LinkChange
+}
+
+// LinkChange lists the fields that differ for a link present in both feeds.
+type LinkChange struct {
+	Old, New *v1.Link
+	Fields   []FieldChange
+}
+
+// FieldChange is one differing Link field, rendered as text.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Empty reports whether the feeds hold the same links.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// Compare diffs the links of a (old) and b (new). Added and Modified follow
+// b's order, Removed follows a's.
+func Compare(a, b *v1.Feed) Diff {
+	var d Diff
+	old := make(map[string]*v1.Link, len(a.Links))
+	for _, l := range a.Links {
+		old[l.Id] = l
+	}
+	seen := make(map[string]bool, len(b.Links))
+	for _, l := range b.Links {
+		seen[l.Id] = true
+		prev, ok := old[l.Id]
+		if !ok {
+			d.Added = append(d.Added, l)
+			continue
+		}
+		if fields := CompareLinks(prev, l); len(fields) > 0 {
+			d.Modified = append(d.Modified, LinkChange{Old: prev, New: l, Fields: fields})
+		}
+	}
+	for _, l := range a.Links {
+		if !seen[l.Id] {
+			d.Removed = append(d.Removed, l)
+		}
+	}
+	return d
+}
+
+// CompareLinks returns the fields that differ between a and b, in schema
+// order. It walks the message descriptor, so new Link fields are covered
+// without changes here.
+func CompareLinks(a, b *v1.Link) []FieldChange {
+	var out []FieldChange
+	ra, rb := a.ProtoReflect(), b.ProtoReflect()
+	fields := ra.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		va, vb := fieldText(ra, fd), fieldText(rb, fd)
+		if va != vb {
+			out = append(out, FieldChange{Field: string(fd.Name()), Old: va, New: vb})
+		}
+	}
+	return out
+}
+
+func fieldText(m protoreflect.Message, fd protoreflect.FieldDescriptor) string {
+	if !m.Has(fd) {
+		return ""
+	}
+	v := m.Get(fd)
+	switch {
+	case fd.IsList():
+		l := v.List()
+		parts := make([]string, l.Len())
+		for i := range parts {
+			parts[i] = valueText(l.Get(i))
+		}
+		return strings.Join(parts, ",")
+	case fd.IsMap():
+		var parts []string
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			parts = append(parts, k.String()+"="+valueText(mv))
+			return true
+		})
+		slices.Sort(parts)
+		return strings.Join(parts, ",")
+	}
+	return valueText(v)
+}
+
+func valueText(v protoreflect.Value) string {
+	if m, ok := v.Interface().(protoreflect.Message); ok {
+		return fmt.Sprint(m.Interface())
+	}
+	return v.String()
+}
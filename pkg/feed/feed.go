@@ -0,0 +1,202 @@
+// Package feed loads, saves and manipulates linkleaf.v1 feeds stored as
+// binary protobuf files. It is the library behind the linkleaf CLI.
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// New returns an empty feed stamped with the current time.
+func New(title string, version uint32) *v1.Feed {
+	return &v1.Feed{
+		Version:     version,
+		Title:       title,
+		GeneratedAt: NowRFC3339(),
+	}
+}
+
+// AddLink prepends l to f (newest first) and refreshes f.GeneratedAt.
+// If l.Id is empty it is derived from the URL and date (see LinkID); an
+// empty l.AddedAt is set to now.
+func AddLink(f *v1.Feed, l *v1.Link) *v1.Link {
+	if l.Id == "" {
+		l.Id = LinkID(l.Url, l.Date)
+	}
+	if l.AddedAt == "" {
+		l.AddedAt = NowRFC3339()
+	}
+	f.Links = append([]*v1.Link{l}, f.Links...)
+	f.GeneratedAt = NowRFC3339()
+	return l
+}
+
+// StampUpdated sets UpdatedAt to now on every link of after whose content
+// differs from the same link (by ID) in before. A new last_check (and
+// check_history) alone isn't an edit, and links whose UpdatedAt already changed (e.g. taken
+// from another copy of the feed) keep it. It returns the number stamped.
+func StampUpdated(before, after *v1.Feed, now string) int {
+	old := make(map[string]*v1.Link, len(before.Links))
+	for _, l := range before.Links {
+		old[l.Id] = l
+	}
+	n := 0
+	for _, l := range after.Links {
+		prev, ok := old[l.Id]
+		if !ok || prev.UpdatedAt != l.UpdatedAt {
+			continue
+		}
+		if slices.ContainsFunc(CompareLinks(prev, l), func(c FieldChange) bool {
+			return c.Field != "last_check" && c.Field != "check_history"
+		}) {
+			l.UpdatedAt = now
+			n++
+		}
+	}
+	return n
+}
+
+// Find returns the link with the given ID, or nil.
+func Find(f *v1.Feed, id string) *v1.Link {
+	if i := Index(f, id); i >= 0 {
+		return f.Links[i]
+	}
+	return nil
+}
+
+// ErrNoLink means no link has the ID asked for.
+var ErrNoLink = errors.New("no link with id")
+
+// FindPrefix returns the link with the given ID or, failing that, the only
+// link whose ID starts with it, so commands taking an ID accept any unique
+// prefix. It fails if no link or several match.
+func FindPrefix(f *v1.Feed, id string) (*v1.Link, error) {
+	if l := Find(f, id); l != nil {
+		return l, nil
+	}
+	var match []string
+	for _, l := range f.Links {
+		if id != "" && strings.HasPrefix(l.Id, id) && !slices.Contains(match, l.Id) {
+			match = append(match, l.Id)
+		}
+	}
+	switch len(match) {
+	case 0:
+		return nil, fmt.Errorf("%w %q", ErrNoLink, id)
+	case 1:
+		return Find(f, match[0]), nil
+	}
+	if len(match) > 5 {
+		match = append(match[:5], "...")
+	}
+	return nil, fmt.Errorf("id %q is ambiguous: %s", id, strings.Join(match, ", "))
+}
+
+// Index returns the position of the link with the given ID, or -1.
+func Index(f *v1.Feed, id string) int {
+	for i, l := range f.Links {
+		if l.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// -------- helpers --------
+
+// NowRFC3339 is the timestamp format used for Feed.GeneratedAt.
+func NowRFC3339() string { return time.Now().UTC().Format(time.RFC3339) }
+
+// LinkID is the default stable ID: sha256(url+"|"+date)[:12].
+func LinkID(url, date string) string { return ShortHash(url + "|" + date) }
+
+// ShortHash returns the first 12 hex characters of sha256(s).
+func ShortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// SplitTags splits a comma-separated tag list, trimming blanks.
+func SplitTags(csv string) []string {
+	if strings.TrimSpace(csv) == "" {
+		return nil
+	}
+	parts := strings.Split(csv, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		t := strings.TrimSpace(p)
+		if t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Move relocates the link with the given ID to index to (0-based),
+// clamping to the ends of the feed. It returns the link's previous index,
+// or -1 if no link has that ID.
+func Move(f *v1.Feed, id string, to int) int {
+	from := Index(f, id)
+	if from < 0 {
+		return -1
+	}
+	to = max(0, min(to, len(f.Links)-1))
+	if from != to {
+		l := f.Links[from]
+		f.Links = slices.Insert(slices.Delete(f.Links, from, from+1), to, l)
+		f.GeneratedAt = NowRFC3339()
+	}
+	return from
+}
+
+// Remove deletes the link with the given ID and returns it, or nil if no
+// link has that ID. Other links' RelatedIds drop the removed ID.
+func Remove(f *v1.Feed, id string) *v1.Link {
+	i := Index(f, id)
+	if i < 0 {
+		return nil
+	}
+	l := f.Links[i]
+	f.Links = slices.Delete(f.Links, i, i+1)
+	unrelateRemoved(f, []*v1.Link{l})
+	f.GeneratedAt = NowRFC3339()
+	return l
+}
+
+// RemoveFunc deletes every link for which del returns true and returns the
+// removed links in feed order. Like Remove, it drops references to them.
+func RemoveFunc(f *v1.Feed, del func(*v1.Link) bool) []*v1.Link {
+	var removed []*v1.Link
+	kept := f.Links[:0]
+	for _, l := range f.Links {
+		if del(l) {
+			removed = append(removed, l)
+		} else {
+			kept = append(kept, l)
+		}
+	}
+	clear(f.Links[len(kept):])
+	f.Links = kept
+	if len(removed) > 0 {
+		unrelateRemoved(f, removed)
+		f.GeneratedAt = NowRFC3339()
+	}
+	return removed
+}
+
+// Prune keeps the first keep links (the newest, in feed order) and returns
+// the ones dropped.
+func Prune(f *v1.Feed, keep int) []*v1.Link {
+	if keep < 0 || keep >= len(f.Links) {
+		return nil
+	}
+	i := 0
+	return RemoveFunc(f, func(*v1.Link) bool { i++; return i > keep })
+}
@@ -0,0 +1,144 @@
+package feed
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Filter selects links. The zero Filter matches every link.
+type Filter struct {
+	// After and Before bound Link.Date, both inclusive; zero means unbounded.
+	// While either is set, links whose date doesn't parse never match.
+	After, Before time.Time
+	// ViaHost keeps links whose Via URL is on this host (see SameHost).
+	ViaHost string
+	// NoVia keeps only links without a Via attribution.
+	NoVia bool
+	// Tags keeps links carrying every one of these tags (case-insensitive;
+	// "lang/*" matches a tag namespace, see TagMatches).
+	Tags []string
+	// TagExpr, if set, keeps links whose tags satisfy it.
+	TagExpr *TagExpr
+	// Domain keeps links whose URL is on this domain (see InDomain).
+	Domain string
+	// Author keeps links whose Author is this, ignoring case.
+	Author string
+	// Lang keeps links in this language or a regional variant of it (see
+	// LangMatches).
+	Lang string
+	// MaxMinutes keeps links estimated to take at most this many minutes
+	// to read (see Link.reading_minutes); links without an estimate don't
+	// match. 0 disables it.
+	MaxMinutes int
+	// Unread keeps links not marked read; Starred keeps starred links.
+	Unread, Starred bool
+	// Broken keeps links whose last Broken checks or more failed in a row
+	// (see LinkCheck.failures); 0 disables it.
+	Broken int
+}
+
+// zero reports whether flt is the zero Filter, which matches every link.
+func (flt Filter) zero() bool {
+	return flt.After.IsZero() && flt.Before.IsZero() && flt.ViaHost == "" && !flt.NoVia && len(flt.Tags) == 0 &&
+		flt.TagExpr == nil && flt.Domain == "" && flt.Author == "" && flt.Lang == "" && flt.MaxMinutes == 0 && !flt.Unread && !flt.Starred && flt.Broken == 0
+}
+
+// Match reports whether l passes every condition of flt.
+func (flt Filter) Match(l *v1.Link) bool {
+	if !flt.After.IsZero() || !flt.Before.IsZero() {
+		d, err := ParseDate(l.Date)
+		if err != nil {
+			return false
+		}
+		if !flt.After.IsZero() && d.Before(flt.After) {
+			return false
+		}
+		if !flt.Before.IsZero() && d.After(flt.Before) {
+			return false
+		}
+	}
+	if flt.ViaHost != "" && (l.Via == "" || !SameHost(Host(l.Via), flt.ViaHost)) {
+		return false
+	}
+	if flt.NoVia && l.Via != "" {
+		return false
+	}
+	for _, t := range flt.Tags {
+		if !slices.ContainsFunc(l.Tags, func(lt string) bool { return TagMatches(t, lt) }) {
+			return false
+		}
+	}
+	if flt.TagExpr != nil && !flt.TagExpr.Match(l.Tags) {
+		return false
+	}
+	if flt.Domain != "" && !InDomain(Host(l.Url), flt.Domain) {
+		return false
+	}
+	if flt.Author != "" && !strings.EqualFold(l.Author, flt.Author) {
+		return false
+	}
+	if flt.Lang != "" && !LangMatches(flt.Lang, l.Lang) {
+		return false
+	}
+	if flt.MaxMinutes > 0 && (l.ReadingMinutes == 0 || int(l.ReadingMinutes) > flt.MaxMinutes) {
+		return false
+	}
+	if (flt.Unread && l.Read) || (flt.Starred && !l.Starred) {
+		return false
+	}
+	if flt.Broken > 0 && (l.LastCheck == nil || int(l.LastCheck.Failures) < flt.Broken) {
+		return false
+	}
+	return true
+}
+
+// Host returns the lowercased host of rawURL without port, or "" if it
+// doesn't parse as an absolute URL.
+func Host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// SameHost compares hosts case-insensitively, ignoring a leading "www.".
+func SameHost(a, b string) bool {
+	trim := func(h string) string { return strings.TrimPrefix(strings.ToLower(h), "www.") }
+	return a != "" && trim(a) == trim(b)
+}
+
+// InDomain reports whether host is domain or one of its subdomains,
+// ignoring case and a leading "www." on either.
+func InDomain(host, domain string) bool {
+	trim := func(h string) string { return strings.TrimPrefix(strings.ToLower(h), "www.") }
+	host, domain = trim(host), trim(domain)
+	return host != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
+
+// Apply returns the links matching flt, preserving their order.
+func (flt Filter) Apply(links []*v1.Link) []*v1.Link {
+	out := make([]*v1.Link, 0, len(links))
+	for _, l := range links {
+		if flt.Match(l) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// Select returns a copy of f holding only the links matching flt. Feed
+// metadata is copied; the selected links are shared with f, not cloned.
+func (flt Filter) Select(f *v1.Feed) *v1.Feed {
+	links := f.Links
+	f.Links = nil
+	out := proto.Clone(f).(*v1.Feed)
+	f.Links = links
+	out.Links = flt.Apply(links)
+	return out
+}
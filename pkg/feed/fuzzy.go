@@ -0,0 +1,69 @@
+package feed
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// FuzzyMatch is a link FuzzyFind found and how well it matched, from 0
+// (nothing in common) to 1.
+type FuzzyMatch struct {
+	Link  *v1.Link
+	Score float64
+}
+
+// FuzzyFind ranks links by how closely their titles resemble query,
+// tolerating typos and missing words: the score is the share of the
+// query's trigrams the title has, lowered slightly for titles much longer
+// than the query. A link whose ID starts with query scores 1. Matches
+// scoring below minScore are dropped; the rest come best first, in feed
+// order among equals.
+func FuzzyFind(links []*v1.Link, query string, minScore float64) []FuzzyMatch {
+	q := trigrams(query)
+	id := strings.ToLower(strings.TrimSpace(query))
+	var out []FuzzyMatch
+	for _, l := range links {
+		score := 0.0
+		if id != "" && strings.HasPrefix(l.Id, id) {
+			score = 1
+		} else if len(q) > 0 {
+			t := trigrams(l.Title)
+			shared := 0
+			for g := range q {
+				if t[g] {
+					shared++
+				}
+			}
+			// Containment, with a tenth of the weight on the Jaccard
+			// index so that, of two titles holding the query, the
+			// shorter wins.
+			contain := float64(shared) / float64(len(q))
+			jaccard := float64(shared) / float64(len(q)+len(t)-shared)
+			score = 0.9*contain + 0.1*jaccard
+		}
+		if score > 0 && score >= minScore {
+			out = append(out, FuzzyMatch{Link: l, Score: score})
+		}
+	}
+	slices.SortStableFunc(out, func(a, b FuzzyMatch) int { return cmp.Compare(b.Score, a.Score) })
+	return out
+}
+
+// trigrams returns the set of three-letter sequences of s's words, lower
+// cased and padded as "  word " so that word starts weigh more.
+func trigrams(s string) map[string]bool {
+	out := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		r := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(r); i++ {
+			out[string(r[i:i+3])] = true
+		}
+	}
+	return out
+}
@@ -0,0 +1,158 @@
+package feed
+
+import (
+	"crypto/rand"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// IDGenerator derives an ID for l, which is about to be added to f.
+type IDGenerator func(f *v1.Feed, l *v1.Link) string
+
+// DefaultIDScheme is used when no scheme is requested.
+const DefaultIDScheme = "urlhash"
+
+var (
+	idMu      sync.RWMutex
+	idSchemes = map[string]IDGenerator{
+		// urlhash: sha256(url+"|"+date)[:12]; reproducible from the link alone.
+		"urlhash": func(_ *v1.Feed, l *v1.Link) string { return LinkID(l.Url, l.Date) },
+		// slug: slugified title, "-2", "-3", … appended on collision; depends
+		// on the IDs already in the feed.
+		"slug": func(f *v1.Feed, l *v1.Link) string { return uniqueID(f, Slugify(l.Title)) },
+		// uuid: random RFC 4122 version 4 UUID; not reproducible.
+		"uuid": func(*v1.Feed, *v1.Link) string { return newUUID() },
+	}
+)
+
+// RegisterIDScheme makes gen available under name, replacing any existing
+// scheme of that name.
+func RegisterIDScheme(name string, gen IDGenerator) {
+	idMu.Lock()
+	defer idMu.Unlock()
+	idSchemes[name] = gen
+}
+
+// IDScheme returns the generator registered under name.
+func IDScheme(name string) (IDGenerator, error) {
+	idMu.RLock()
+	defer idMu.RUnlock()
+	gen, ok := idSchemes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown id scheme %q (have %s)", name, strings.Join(idSchemeNames(), ", "))
+	}
+	return gen, nil
+}
+
+// IDSchemes lists the registered scheme names, sorted.
+func IDSchemes() []string {
+	idMu.RLock()
+	defer idMu.RUnlock()
+	return idSchemeNames()
+}
+
+func idSchemeNames() []string {
+	names := make([]string, 0, len(idSchemes))
+	for n := range idSchemes {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Slugify lowercases s and joins its letters/digits runs with '-',
+// capped at 60 bytes. An empty result becomes "link".
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		} else {
+			dash = true
+		}
+		if b.Len() >= 60 {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "link"
+	}
+	return b.String()
+}
+
+// NewID generates an ID for l with gen and makes it unique in f: an ID
+// already taken by a link or one in the trash gets "-2", "-3", … appended.
+// base is what gen returned, which differs from id on a collision.
+func NewID(f *v1.Feed, gen IDGenerator, l *v1.Link) (id, base string) {
+	base = gen(f, l)
+	return uniqueID(f, base), base
+}
+
+// ReID gives every link in links (all in f) a new ID from gen, in feed
+// order, and rewrites the related_ids and trash entries that referred to
+// the old ones. It returns the old IDs mapped to the new ones, for the
+// links whose ID changed.
+func ReID(f *v1.Feed, links []*v1.Link, gen IDGenerator) map[string]string {
+	renamed := map[string]string{}
+	for _, l := range links {
+		old := l.Id
+		l.Id = "" // its own ID doesn't count as taken
+		l.Id, _ = NewID(f, gen, l)
+		if l.Id != old {
+			renamed[old] = l.Id
+		}
+	}
+	if len(renamed) == 0 {
+		return nil
+	}
+	rewrite := func(ids []string) {
+		for i, id := range ids {
+			if n, ok := renamed[id]; ok {
+				ids[i] = n
+			}
+		}
+	}
+	for _, l := range f.Links {
+		rewrite(l.RelatedIds)
+	}
+	for _, t := range f.Trash {
+		rewrite(t.ReferencedBy)
+		if t.Link != nil {
+			rewrite(t.Link.RelatedIds)
+		}
+	}
+	f.GeneratedAt = NowRFC3339()
+	return renamed
+}
+
+// uniqueID returns base, or base-2, base-3, … if base is taken in f, by a
+// link or a trashed one.
+func uniqueID(f *v1.Feed, base string) string {
+	id := base
+	for n := 2; idTaken(f, id); n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	return id
+}
+
+func idTaken(f *v1.Feed, id string) bool {
+	return Index(f, id) >= 0 || slices.ContainsFunc(f.Trash, func(t *v1.TrashedLink) bool { return t.Link.GetId() == id })
+}
+
+func newUUID() string {
+	var u [16]byte
+	rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40 // version 4
+	u[8] = u[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
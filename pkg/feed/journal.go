@@ -0,0 +1,282 @@
+package feed
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// JournalSuffix names the sidecar that records every change saved to a
+// feed file, one JSON entry per line, oldest first.
+const JournalSuffix = ".journal"
+
+// ErrJournalMismatch means the feed no longer matches the state the last
+// journal entry left it in (it was changed without the journal), so that
+// entry can't be undone safely.
+var ErrJournalMismatch = errors.New("feed changed since the last journaled operation")
+
+// JournalEntry is one saved change: the links it added, removed and
+// modified, with enough of the old state to revert it (see Undo).
+type JournalEntry struct {
+	Time     string
+	Op       string // command that made the change, e.g. "add"
+	Added    []*v1.Link
+	Removed  []RemovedLink
+	Modified []LinkChange // Fields is not stored
+
+	// Order holds the link IDs as they were before the change; it is set
+	// only when the change reordered links that it kept.
+	Order []string
+	// TitleBefore and VersionBefore are set when the change altered them.
+	TitleBefore   *string
+	VersionBefore *uint32
+}
+
+// RemovedLink is a removed link and its position before removal.
+type RemovedLink struct {
+	Index int
+	Link  *v1.Link
+}
+
+// NewJournalEntry records the change from before to after. It returns nil
+// if nothing a journal tracks changed (e.g. only generated_at).
+func NewJournalEntry(op string, before, after *v1.Feed) *JournalEntry {
+	d := Compare(before, after)
+	e := &JournalEntry{Time: NowRFC3339(), Op: op, Added: d.Added, Modified: d.Modified}
+	for _, l := range d.Removed {
+		e.Removed = append(e.Removed, RemovedLink{Index: Index(before, l.Id), Link: l})
+	}
+	if !slices.Equal(keptIDs(before, after), keptIDs(after, before)) {
+		for _, l := range before.Links {
+			e.Order = append(e.Order, l.Id)
+		}
+	}
+	if before.Title != after.Title {
+		e.TitleBefore = &before.Title
+	}
+	if before.Version != after.Version {
+		e.VersionBefore = &before.Version
+	}
+	if d.Empty() && e.Order == nil && e.TitleBefore == nil && e.VersionBefore == nil {
+		return nil
+	}
+	return e
+}
+
+// keptIDs lists the IDs of a's links that b also has, in a's order.
+func keptIDs(a, b *v1.Feed) []string {
+	in := make(map[string]bool, len(b.Links))
+	for _, l := range b.Links {
+		in[l.Id] = true
+	}
+	var ids []string
+	for _, l := range a.Links {
+		if in[l.Id] {
+			ids = append(ids, l.Id)
+		}
+	}
+	return ids
+}
+
+// Undo reverts e on f, which must be in the state e left it in; otherwise
+// it returns ErrJournalMismatch and leaves f alone.
+func Undo(f *v1.Feed, e *JournalEntry) error {
+	for _, l := range e.Added {
+		if cur := Find(f, l.Id); cur == nil || !proto.Equal(cur, l) {
+			return fmt.Errorf("%w: link [%s]", ErrJournalMismatch, l.Id)
+		}
+	}
+	for _, c := range e.Modified {
+		if cur := Find(f, c.New.Id); cur == nil || !proto.Equal(cur, c.New) {
+			return fmt.Errorf("%w: link [%s]", ErrJournalMismatch, c.New.Id)
+		}
+	}
+	for _, r := range e.Removed {
+		if Find(f, r.Link.Id) != nil {
+			return fmt.Errorf("%w: link [%s]", ErrJournalMismatch, r.Link.Id)
+		}
+	}
+
+	for _, l := range e.Added {
+		Remove(f, l.Id)
+	}
+	for _, c := range e.Modified {
+		f.Links[Index(f, c.New.Id)] = proto.Clone(c.Old).(*v1.Link)
+	}
+	removed := slices.Clone(e.Removed)
+	slices.SortFunc(removed, func(a, b RemovedLink) int { return a.Index - b.Index })
+	for _, r := range removed {
+		i := min(max(r.Index, 0), len(f.Links))
+		f.Links = slices.Insert(f.Links, i, proto.Clone(r.Link).(*v1.Link))
+	}
+	if e.Order != nil {
+		pos := make(map[string]int, len(e.Order))
+		for i, id := range e.Order {
+			pos[id] = i
+		}
+		slices.SortStableFunc(f.Links, func(a, b *v1.Link) int { return pos[a.Id] - pos[b.Id] })
+	}
+	if e.TitleBefore != nil {
+		f.Title = *e.TitleBefore
+	}
+	if e.VersionBefore != nil {
+		f.Version = *e.VersionBefore
+	}
+	f.GeneratedAt = NowRFC3339()
+	return nil
+}
+
+// AppendJournal adds e to the journal of the feed at path (see ExpandPath).
+func AppendJournal(path string, e *JournalEntry) error {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	fh, err := os.OpenFile(path+JournalSuffix, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := fh.Write(append(b, '\n')); err != nil {
+		fh.Close()
+		return err
+	}
+	return fh.Close()
+}
+
+// ReadJournal returns the journal of the feed at path, oldest first. A
+// missing journal is empty.
+func ReadJournal(path string) ([]*JournalEntry, error) {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path + JournalSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []*JournalEntry
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(nil, len(b)+1)
+	for n := 1; sc.Scan(); n++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var e JournalEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s%s:%d: %w", path, JournalSuffix, n, err)
+		}
+		entries = append(entries, &e)
+	}
+	return entries, nil
+}
+
+// DropLastJournalEntry removes the newest entry, after it was undone.
+func DropLastJournalEntry(path string) error {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(path + JournalSuffix)
+	if err != nil {
+		return err
+	}
+	b = bytes.TrimRight(b, "\n")
+	if i := bytes.LastIndexByte(b, '\n'); i >= 0 {
+		b = b[:i+1]
+	} else {
+		b = nil
+	}
+	return WriteFileAtomic(path+JournalSuffix, b, 0o644)
+}
+
+// journalJSON is the on-disk form of JournalEntry; links are protojson.
+type journalJSON struct {
+	Time          string            `json:"time"`
+	Op            string            `json:"op"`
+	Added         []json.RawMessage `json:"added,omitempty"`
+	Removed       []removedJSON     `json:"removed,omitempty"`
+	Modified      []modifiedJSON    `json:"modified,omitempty"`
+	Order         []string          `json:"order,omitempty"`
+	TitleBefore   *string           `json:"title_before,omitempty"`
+	VersionBefore *uint32           `json:"version_before,omitempty"`
+}
+
+type removedJSON struct {
+	Index int             `json:"index"`
+	Link  json.RawMessage `json:"link"`
+}
+
+type modifiedJSON struct {
+	Old json.RawMessage `json:"old"`
+	New json.RawMessage `json:"new"`
+}
+
+func (e *JournalEntry) MarshalJSON() ([]byte, error) {
+	j := journalJSON{Time: e.Time, Op: e.Op, Order: e.Order, TitleBefore: e.TitleBefore, VersionBefore: e.VersionBefore}
+	var err error
+	for _, l := range e.Added {
+		j.Added = append(j.Added, linkJSON(l, &err))
+	}
+	for _, r := range e.Removed {
+		j.Removed = append(j.Removed, removedJSON{Index: r.Index, Link: linkJSON(r.Link, &err)})
+	}
+	for _, c := range e.Modified {
+		j.Modified = append(j.Modified, modifiedJSON{Old: linkJSON(c.Old, &err), New: linkJSON(c.New, &err)})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(j)
+}
+
+func (e *JournalEntry) UnmarshalJSON(b []byte) error {
+	var j journalJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*e = JournalEntry{Time: j.Time, Op: j.Op, Order: j.Order, TitleBefore: j.TitleBefore, VersionBefore: j.VersionBefore}
+	var err error
+	for _, raw := range j.Added {
+		e.Added = append(e.Added, parseLinkJSON(raw, &err))
+	}
+	for _, r := range j.Removed {
+		e.Removed = append(e.Removed, RemovedLink{Index: r.Index, Link: parseLinkJSON(r.Link, &err)})
+	}
+	for _, m := range j.Modified {
+		e.Modified = append(e.Modified, LinkChange{Old: parseLinkJSON(m.Old, &err), New: parseLinkJSON(m.New, &err)})
+	}
+	return err
+}
+
+// linkJSON and parseLinkJSON keep the first error in *errp, so the
+// (un)marshalers above can check once at the end.
+func linkJSON(l *v1.Link, errp *error) json.RawMessage {
+	b, err := protojson.Marshal(l)
+	if err != nil && *errp == nil {
+		*errp = err
+	}
+	return b
+}
+
+func parseLinkJSON(raw json.RawMessage, errp *error) *v1.Link {
+	l := &v1.Link{}
+	if err := protojson.Unmarshal(raw, l); err != nil && *errp == nil {
+		*errp = err
+	}
+	return l
+}
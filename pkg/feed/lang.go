@@ -0,0 +1,66 @@
+package feed
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeLang brings a language tag into the form Link.lang stores: the
+// BCP 47 subtags joined by '-', the language lowercase, a region uppercase
+// and a script titlecase ("de_at" becomes "de-AT", "zh-hant" "zh-Hant").
+// Tags that aren't of that shape are an error.
+func NormalizeLang(s string) (string, error) {
+	parts := strings.FieldsFunc(strings.TrimSpace(s), func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) == 0 || len(parts[0]) < 2 || len(parts[0]) > 3 || !isAlpha(parts[0]) {
+		return "", fmt.Errorf("language %q: want a tag such as en, de or pt-BR", s)
+	}
+	for i, p := range parts {
+		if len(p) > 8 || !isAlnum(p) {
+			return "", fmt.Errorf("language %q: want a tag such as en, de or pt-BR", s)
+		}
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(p)
+		case len(p) == 2 && isAlpha(p):
+			parts[i] = strings.ToUpper(p)
+		case len(p) == 4 && isAlpha(p):
+			parts[i] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		default:
+			parts[i] = strings.ToLower(p)
+		}
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+// LangMatches reports whether the language tag lang is want or more
+// specific than it, ignoring case: "de" matches "de" and "de-AT", but
+// "de-AT" doesn't match "de".
+func LangMatches(want, lang string) bool {
+	want = strings.ReplaceAll(want, "_", "-")
+	return len(lang) >= len(want) && strings.EqualFold(lang[:len(want)], want) &&
+		(len(lang) == len(want) || lang[len(want)] == '-')
+}
+
+// BaseLang is the language subtag of a tag: "de" for "de-AT".
+func BaseLang(lang string) string {
+	base, _, _ := strings.Cut(lang, "-")
+	return base
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package feed
+
+import (
+	"slices"
+	"strings"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// LinkIndex answers lookups by ID and Filter selections on a feed held in
+// memory without scanning every link: links are indexed by ID, tag and
+// host. It must be rebuilt when the feed changes.
+type LinkIndex struct {
+	feed  *v1.Feed
+	ids   map[string]int   // ID → position
+	tags  map[string][]int // lowercased tag → positions, ascending
+	hosts map[string][]int // host without "www." → positions, ascending
+}
+
+// NewLinkIndex indexes f's links. f must not change while the index is
+// in use.
+func NewLinkIndex(f *v1.Feed) *LinkIndex {
+	x := &LinkIndex{
+		feed:  f,
+		ids:   make(map[string]int, len(f.Links)),
+		tags:  map[string][]int{},
+		hosts: map[string][]int{},
+	}
+	for i, l := range f.Links {
+		if _, dup := x.ids[l.Id]; !dup {
+			x.ids[l.Id] = i // Find returns the first, as Index does
+		}
+		for _, t := range l.Tags {
+			t = strings.ToLower(t)
+			if ps := x.tags[t]; len(ps) == 0 || ps[len(ps)-1] != i {
+				x.tags[t] = append(ps, i)
+			}
+		}
+		h := strings.TrimPrefix(Host(l.Url), "www.")
+		x.hosts[h] = append(x.hosts[h], i)
+	}
+	return x
+}
+
+// Feed returns the indexed feed.
+func (x *LinkIndex) Feed() *v1.Feed { return x.feed }
+
+// Find returns the link with the given ID, or nil.
+func (x *LinkIndex) Find(id string) *v1.Link {
+	if i, ok := x.ids[id]; ok {
+		return x.feed.Links[i]
+	}
+	return nil
+}
+
+// Select returns the links matching flt in feed order, as flt.Apply does.
+// A plain tag (not a "ns/*" pattern) or a domain narrows the links it
+// checks to those the index lists for it.
+func (x *LinkIndex) Select(flt Filter) []*v1.Link {
+	var cand []int
+	narrowed := false
+	narrow := func(ps []int) {
+		if !narrowed || len(ps) < len(cand) {
+			cand, narrowed = ps, true
+		}
+	}
+	for _, t := range flt.Tags {
+		if !strings.HasSuffix(t, "/*") {
+			narrow(x.tags[strings.ToLower(t)])
+		}
+	}
+	if flt.Domain != "" {
+		var ps []int
+		for h, hps := range x.hosts {
+			if InDomain(h, flt.Domain) {
+				ps = append(ps, hps...)
+			}
+		}
+		slices.Sort(ps)
+		narrow(ps)
+	}
+	if !narrowed {
+		return flt.Apply(x.feed.Links)
+	}
+	out := make([]*v1.Link, 0, len(cand))
+	for _, i := range cand {
+		if l := x.feed.Links[i]; flt.Match(l) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
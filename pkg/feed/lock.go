@@ -0,0 +1,61 @@
+package feed
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+)
+
+// LockSuffix names the lock file taken next to a feed file. The feed file
+// itself can't carry the lock: Save replaces it by rename.
+const LockSuffix = ".lock"
+
+// errLocked is returned by tryLockFile when another process holds the lock.
+var errLocked = errors.New("locked")
+
+// Lock takes an exclusive advisory lock for the feed at path (see
+// ExpandPath), waiting while another process holds it. Take it before
+// loading and release it after saving, so concurrent load-modify-save
+// cycles run one after the other instead of overwriting each other.
+//
+// The lock is flock(2) on Unix and LockFileEx on Windows; it is released
+// by unlock or when the process exits. Elsewhere, and for remote files
+// (see package storage), Lock is a no-op.
+func Lock(path string) (unlock func() error, err error) {
+	path, err = ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if storage.IsRemote(path) {
+		return func() error { return nil }, nil
+	}
+	lockPath := path + LockSuffix
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o755); err != nil {
+		return nil, err
+	}
+	fh, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	err = tryLockFile(fh)
+	if errors.Is(err, errLocked) {
+		Logger.Debug("waiting for lock", "path", lockPath)
+		err = lockFile(fh)
+	}
+	if err != nil {
+		fh.Close()
+		return nil, err
+	}
+	Logger.Debug("lock", "path", lockPath, "elapsed", time.Since(start))
+	return func() error {
+		uerr := unlockFile(fh)
+		if err := fh.Close(); uerr == nil {
+			uerr = err
+		}
+		return uerr
+	}, nil
+}
@@ -0,0 +1,9 @@
+//go:build !(unix && !aix && !solaris) && !windows
+
+package feed
+
+import "os"
+
+func lockFile(*os.File) error    { return nil }
+func tryLockFile(*os.File) error { return nil }
+func unlockFile(*os.File) error  { return nil }
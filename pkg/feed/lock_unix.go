@@ -0,0 +1,30 @@
+//go:build unix && !aix && !solaris
+
+package feed
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func lockFile(fh *os.File) error { return flock(fh, syscall.LOCK_EX) }
+
+func tryLockFile(fh *os.File) error {
+	err := flock(fh, syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errLocked
+	}
+	return err
+}
+
+func unlockFile(fh *os.File) error { return flock(fh, syscall.LOCK_UN) }
+
+func flock(fh *os.File, how int) error {
+	for {
+		err := syscall.Flock(int(fh.Fd()), how)
+		if !errors.Is(err, syscall.EINTR) {
+			return err
+		}
+	}
+}
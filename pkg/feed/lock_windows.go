@@ -0,0 +1,52 @@
+//go:build windows
+
+package feed
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+	errorLockViolation      = syscall.Errno(33)
+)
+
+func lockFile(fh *os.File) error { return lockFileEx(fh, lockfileExclusiveLock) }
+
+func tryLockFile(fh *os.File) error {
+	err := lockFileEx(fh, lockfileExclusiveLock|lockfileFailImmediately)
+	if errors.Is(err, errorLockViolation) {
+		return errLocked
+	}
+	return err
+}
+
+// lockFileEx locks the first byte of the file, which is all any linkleaf
+// process asks for.
+func lockFileEx(fh *os.File, flags uintptr) error {
+	var ol syscall.Overlapped
+	r, _, err := procLockFileEx.Call(fh.Fd(), flags, 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func unlockFile(fh *os.File) error {
+	var ol syscall.Overlapped
+	r, _, err := procUnlockFileEx.Call(fh.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package feed
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// MergeStats summarizes a Merge.
+type MergeStats struct {
+	Links      int // links in the result
+	Duplicates int // links dropped because a newer feed had the same ID or URL
+	// Conflicts are the dropped links whose ID was taken by a different
+	// copy: Ours is the copy kept, Theirs the dropped one. Each is
+	// Resolved, in favor of the newer feed.
+	Conflicts []Conflict
+}
+
+// Merge unions the links of feeds into a new CurrentVersion feed. When two
+// feeds hold the same link (same ID or normalized URL, see NormalizeURL),
+// the copy from the feed with the newest GeneratedAt wins. The result is
+// ordered newest added_at first (see SortByAdded). Its title, author,
+// description, home page, icon and language come from the newest feed that
+// sets each; its subscriptions (by URL) and trash are the union of the
+// feeds', again preferring the newest copy. Inputs are not modified.
+func Merge(feeds ...*v1.Feed) (*v1.Feed, MergeStats) {
+	byAge := slices.Clone(feeds)
+	slices.SortStableFunc(byAge, func(a, b *v1.Feed) int {
+		ta, _ := time.Parse(time.RFC3339, a.GeneratedAt)
+		tb, _ := time.Parse(time.RFC3339, b.GeneratedAt)
+		return tb.Compare(ta)
+	})
+
+	out := New("", CurrentVersion)
+	var st MergeStats
+	ids, urls := map[string]*v1.Link{}, map[string]bool{}
+	subs, trashed := map[string]bool{}, map[string]bool{}
+	for _, f := range byAge {
+		for _, p := range []struct {
+			dst *string
+			src string
+		}{
+			{&out.Title, f.Title},
+			{&out.Author, f.Author},
+			{&out.Description, f.Description},
+			{&out.HomePageUrl, f.HomePageUrl},
+			{&out.Icon, f.Icon},
+			{&out.Lang, f.Lang},
+		} {
+			if *p.dst == "" {
+				*p.dst = p.src
+			}
+		}
+		for _, s := range f.Subscriptions {
+			if !subs[s.Url] {
+				subs[s.Url] = true
+				out.Subscriptions = append(out.Subscriptions, proto.Clone(s).(*v1.Subscription))
+			}
+		}
+		for _, t := range f.Trash {
+			if k := trashKey(t); !trashed[k] {
+				trashed[k] = true
+				out.Trash = append(out.Trash, proto.Clone(t).(*v1.TrashedLink))
+			}
+		}
+		for _, l := range f.Links {
+			norm := NormalizeURL(l.Url)
+			if kept := ids[l.Id]; kept != nil || urls[norm] {
+				st.Duplicates++
+				if kept != nil && !proto.Equal(kept, l) {
+					st.Conflicts = append(st.Conflicts, Conflict{ID: l.Id, Ours: kept, Theirs: l, Resolved: true})
+				}
+				continue
+			}
+			c := proto.Clone(l).(*v1.Link)
+			ids[l.Id], urls[norm] = c, true
+			out.Links = append(out.Links, c)
+		}
+	}
+	SortByAdded(out.Links)
+	slices.SortStableFunc(out.Trash, func(a, b *v1.TrashedLink) int { return strings.Compare(b.RemovedAt, a.RemovedAt) })
+	st.Links = len(out.Links)
+	return out, st
+}
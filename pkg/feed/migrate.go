@@ -0,0 +1,129 @@
+package feed
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// CurrentVersion is the Feed.Version written by this version of linkleaf.
+const CurrentVersion = 20
+
+// Migration upgrades a feed from one version to the next. Migrate bumps
+// Feed.Version afterwards, so a Migration only transforms content.
+type Migration func(f *v1.Feed) error
+
+var (
+	migrateMu  sync.RWMutex
+	migrations = map[uint32]Migration{
+		// 0 → 1: feeds created implicitly by "add" carried no version.
+		0: func(*v1.Feed) error { return nil },
+		// 1 → 2: Link.added_at introduced.
+		1: backfillAddedAt,
+		// 2 → 3: Link.last_check introduced; unset means never checked.
+		2: func(*v1.Feed) error { return nil },
+		// 3 → 4: Link.updated_at, notes, read and archived introduced;
+		// their zero values (never edited, no notes, unread, current) fit.
+		3: func(*v1.Feed) error { return nil },
+		// 4 → 5: Link.starred introduced.
+		4: func(*v1.Feed) error { return nil },
+		// 5 → 6: Link.archive_url introduced; unset means not archived.
+		5: func(*v1.Feed) error { return nil },
+		// 6 → 7: Feed.author and Link.author introduced; unset means the
+		// config's author.
+		6: func(*v1.Feed) error { return nil },
+		// 7 → 8: Link.related_ids introduced.
+		7: func(*v1.Feed) error { return nil },
+		// 8 → 9: Link.meta introduced.
+		8: func(*v1.Feed) error { return nil },
+		// 9 → 10: Link.enclosure introduced.
+		9: func(*v1.Feed) error { return nil },
+		// 10 → 11: Link.draft and publish_at introduced; their zero values
+		// (published, right away) fit.
+		10: func(*v1.Feed) error { return nil },
+		// 11 → 12: LinkCheck.failures and Link.check_history introduced.
+		11: backfillFailures,
+		// 12 → 13: Link.slug introduced; every link gets one.
+		12: func(f *v1.Feed) error { AssignSlugs(f); return nil },
+		// 13 → 14: Link.lang introduced; unset means unknown.
+		13: func(*v1.Feed) error { return nil },
+		// 14 → 15: Link.word_count and reading_minutes introduced; pages
+		// are only counted when fetched.
+		14: func(*v1.Feed) error { return nil },
+		// 15 → 16: Link.article_path introduced; nothing is saved yet.
+		15: func(*v1.Feed) error { return nil },
+		// 16 → 17: Feed.subscriptions introduced.
+		16: func(*v1.Feed) error { return nil },
+		// 17 → 18: Feed.description, home_page_url, icon and lang
+		// introduced; unset means the config's export settings.
+		17: func(*v1.Feed) error { return nil },
+		// 18 → 19: Feed.trash introduced; links removed before stay gone.
+		18: func(*v1.Feed) error { return nil },
+		// 19 → 20: Link.quotes introduced.
+		19: func(*v1.Feed) error { return nil },
+	}
+)
+
+// RegisterMigration installs m as the upgrade from version from to from+1.
+func RegisterMigration(from uint32, m Migration) {
+	migrateMu.Lock()
+	defer migrateMu.Unlock()
+	migrations[from] = m
+}
+
+// NeedsMigration reports whether f is older than CurrentVersion.
+func NeedsMigration(f *v1.Feed) bool { return f.Version < CurrentVersion }
+
+// Migrate runs the registered migrations one version at a time until f is
+// at CurrentVersion. Feeds at or above CurrentVersion are left alone
+// (unknown fields from newer schemas survive a round-trip anyway).
+func Migrate(f *v1.Feed) error {
+	migrateMu.RLock()
+	defer migrateMu.RUnlock()
+	for f.Version < CurrentVersion {
+		m, ok := migrations[f.Version]
+		if !ok {
+			return fmt.Errorf("no migration from feed version %d", f.Version)
+		}
+		if err := m(f); err != nil {
+			return fmt.Errorf("migrate version %d to %d: %w", f.Version, f.Version+1, err)
+		}
+		Logger.Debug("migrate", "from", f.Version, "to", f.Version+1)
+		f.Version++
+	}
+	return nil
+}
+
+// backfillAddedAt gives links without added_at a timestamp that preserves
+// their feed order: walking oldest (bottom) to newest, each link gets the
+// later of its date's midnight and one second after the link below it.
+func backfillAddedAt(f *v1.Feed) error {
+	var prev time.Time
+	for i := len(f.Links) - 1; i >= 0; i-- {
+		l := f.Links[i]
+		if t, err := time.Parse(time.RFC3339, l.AddedAt); err == nil {
+			prev = t
+			continue
+		}
+		t := prev.Add(time.Second)
+		if d, err := ParseDate(l.Date); err == nil && d.After(t) {
+			t = d
+		}
+		l.AddedAt = t.UTC().Format(time.RFC3339)
+		prev = t
+	}
+	return nil
+}
+
+// backfillFailures counts a failed last_check as the first failure in a
+// row; the checks before it weren't kept.
+func backfillFailures(f *v1.Feed) error {
+	for _, l := range f.Links {
+		if c := l.LastCheck; c != nil && c.Failures == 0 && CheckFailed(c) {
+			c.Failures = 1
+		}
+	}
+	return nil
+}
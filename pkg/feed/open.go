@@ -0,0 +1,48 @@
+package feed
+
+import (
+	"errors"
+	"os"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// Feed is a feed bound to the file it was opened from. It embeds the
+// protobuf message, so f.Title, f.Links etc. are available directly; the
+// methods are thin wrappers over the package functions.
+type Feed struct {
+	*v1.Feed
+	// Path is the file Save writes to, as given to Open.
+	Path string
+}
+
+// Open loads the feed at path (see Load). A missing file is not an error:
+// Open returns an empty CurrentVersion feed that Save will create.
+func Open(path string) (*Feed, error) { return OpenWith(path, LoadOptions{}) }
+
+// OpenWith is Open with load options.
+func OpenWith(path string, opts LoadOptions) (*Feed, error) {
+	f, err := LoadWith(path, opts)
+	if errors.Is(err, os.ErrNotExist) {
+		f, err = New("", CurrentVersion), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Feed{Feed: f, Path: path}, nil
+}
+
+// Add prepends l (see AddLink) and returns it.
+func (f *Feed) Add(l *v1.Link) *v1.Link { return AddLink(f.Feed, l) }
+
+// Find returns the link with the given ID, or nil.
+func (f *Feed) Find(id string) *v1.Link { return Find(f.Feed, id) }
+
+// Remove deletes the link with the given ID and returns it, or nil.
+func (f *Feed) Remove(id string) *v1.Link { return Remove(f.Feed, id) }
+
+// Save atomically writes the feed back to f.Path.
+func (f *Feed) Save() error { return Save(f.Path, f.Feed) }
+
+// SaveWith is Save with options.
+func (f *Feed) SaveWith(opts SaveOptions) error { return SaveWith(f.Path, f.Feed, opts) }
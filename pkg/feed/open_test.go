@@ -0,0 +1,150 @@
+package feed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt.pb")
+	if err := os.WriteFile(corrupt, []byte("\xff\xff\xff not a feed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		path      string
+		wantErr   bool
+		wantLinks int
+	}{
+		{"missing file starts a new feed", filepath.Join(dir, "missing.pb"), false, 0},
+		{"corrupt file", corrupt, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Open(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Open(%s) = %d links, want an error", tt.path, len(f.Links))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open(%s): %v", tt.path, err)
+			}
+			if f.Path != tt.path || f.Version != CurrentVersion || len(f.Links) != tt.wantLinks {
+				t.Errorf("Open(%s) = path %q, version %d, %d links; want %q, %d, %d",
+					tt.path, f.Path, f.Version, len(f.Links), tt.path, CurrentVersion, tt.wantLinks)
+			}
+			if _, err := os.Stat(tt.path); !os.IsNotExist(err) {
+				t.Errorf("Open created %s before Save", tt.path)
+			}
+		})
+	}
+}
+
+func TestFeedAddFindRemove(t *testing.T) {
+	link := func(id, title string) *v1.Link {
+		return &v1.Link{Id: id, Title: title, Url: "https://example.com/" + id, Date: "2024-05-01"}
+	}
+	tests := []struct {
+		name      string
+		add       []*v1.Link
+		remove    string
+		wantFound bool // whether remove found a link
+		wantIDs   []string
+		find      string
+		wantTitle string // of the link Find(find) returns, "" for none
+	}{
+		{
+			name:      "remove a link",
+			add:       []*v1.Link{link("a", "A"), link("b", "B")},
+			remove:    "a",
+			wantFound: true,
+			wantIDs:   []string{"b"},
+			find:      "a",
+		},
+		{
+			name:      "remove an unknown ID",
+			add:       []*v1.Link{link("a", "A")},
+			remove:    "nope",
+			wantIDs:   []string{"a"},
+			find:      "a",
+			wantTitle: "A",
+		},
+		{
+			// Add doesn't check for duplicates (add and import do); the
+			// newer link shadows the older one and Lint reports it.
+			name:      "duplicate add",
+			add:       []*v1.Link{link("a", "old"), link("a", "new")},
+			wantIDs:   []string{"a", "a"},
+			find:      "a",
+			wantTitle: "new",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Open(filepath.Join(t.TempDir(), "f.pb"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, l := range tt.add {
+				if got := f.Add(l); got != l {
+					t.Fatalf("Add returned %v, want the added link", got)
+				}
+			}
+			if tt.remove != "" {
+				if got := f.Remove(tt.remove); (got != nil) != tt.wantFound {
+					t.Errorf("Remove(%q) = %v, want found %v", tt.remove, got, tt.wantFound)
+				}
+			}
+			var ids []string
+			for _, l := range f.Links {
+				ids = append(ids, l.Id)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("links = %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Fatalf("links = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+			got := f.Find(tt.find)
+			switch {
+			case tt.wantTitle == "" && got != nil:
+				t.Errorf("Find(%q) = %q, want none", tt.find, got.Title)
+			case tt.wantTitle != "" && (got == nil || got.Title != tt.wantTitle):
+				t.Errorf("Find(%q) = %v, want %q", tt.find, got, tt.wantTitle)
+			}
+			if dup := len(ids) > 1 && ids[0] == ids[1]; dup && len(Lint(f.Feed)) == 0 {
+				t.Error("Lint found no problem with a duplicate ID")
+			}
+		})
+	}
+}
+
+func TestFeedSaveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.pb")
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Title = "Links"
+	f.Add(&v1.Link{Id: "a", Title: "A", Url: "https://example.com/a", Date: "2024-05-01", Tags: []string{"go"}})
+	f.Add(&v1.Link{Id: "b", Title: "B", Url: "https://example.com/b", Date: "2024-05-02", Summary: "two"})
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	g, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open after Save: %v", err)
+	}
+	if !proto.Equal(f.Feed, g.Feed) {
+		t.Errorf("reopened feed differs:\ngot  %v\nwant %v", g.Feed, f.Feed)
+	}
+}
@@ -0,0 +1,46 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUndefinedVar means a path references an environment variable that
+// isn't set.
+var ErrUndefinedVar = errors.New("undefined environment variable")
+
+// ExpandPath expands $VAR / ${VAR} references and a leading "~" in path.
+// Referencing an unset variable is an error rather than an empty string,
+// so a typo can't silently turn "$FEEDS/links.pb" into "/links.pb".
+func ExpandPath(path string) (string, error) {
+	var missing []string
+	expanded := os.Expand(path, func(name string) string {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("path %q: %w(s): %s", path, ErrUndefinedVar, strings.Join(missing, ", "))
+	}
+	path = expanded
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("expand ~: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	// "feed/" and "feed" are the same (sharded) feed, with the same lock
+	// and journal.
+	if !strings.Contains(path, "://") && len(path) > 1 {
+		if trimmed := strings.TrimRight(path, "/"+string(filepath.Separator)); trimmed != "" {
+			path = trimmed
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,99 @@
+package feed
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// Policy holds the curation rules of a shared feed, checked on top of
+// Lint. The zero Policy allows every link.
+type Policy struct {
+	// AllowedDomains, if set, are the only domains (and their
+	// subdomains) links may point to; BlockedDomains are never allowed.
+	AllowedDomains, BlockedDomains []string
+	// RequiredTags must all be on every link; a link needs at least
+	// MinTags tags.
+	RequiredTags []string
+	MinTags      int
+	// MaxTitleLength is the longest title allowed, in characters (0: any).
+	MaxTitleLength int
+	// SummaryRequiredAfter makes a summary mandatory for links dated on
+	// or after it (zero: never).
+	SummaryRequiredAfter time.Time
+}
+
+// Check returns the links of f that break p's rules, in link order.
+func (p Policy) Check(f *v1.Feed) []Problem {
+	var problems []Problem
+	for i, l := range f.Links {
+		for _, v := range p.violations(l) {
+			v.Index, v.ID = i, l.Id
+			problems = append(problems, v)
+		}
+	}
+	return problems
+}
+
+// CheckLink returns an error listing every rule of p that l breaks, one
+// per line, or nil.
+func (p Policy) CheckLink(l *v1.Link) error {
+	var errs []error
+	for _, v := range p.violations(l) {
+		errs = append(errs, fmt.Errorf("%s: %s", v.Field, v.Message))
+	}
+	return errors.Join(errs...)
+}
+
+// violations returns the rules l breaks as Problems with only Field and
+// Message set.
+func (p Policy) violations(l *v1.Link) []Problem {
+	var out []Problem
+	add := func(field, format string, args ...any) {
+		out = append(out, Problem{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	host := Host(l.Url)
+	inAny := func(domains []string) string {
+		i := slices.IndexFunc(domains, func(d string) bool { return InDomain(host, d) })
+		if i < 0 {
+			return ""
+		}
+		return domains[i]
+	}
+	if d := inAny(p.BlockedDomains); d != "" {
+		add("url", "%s is a blocked domain", d)
+	} else if len(p.AllowedDomains) > 0 && inAny(p.AllowedDomains) == "" {
+		add("url", "%s isn't one of the allowed domains (%s)", cmp.Or(host, l.Url), strings.Join(p.AllowedDomains, ", "))
+	}
+	var missing []string
+	for _, t := range p.RequiredTags {
+		if !slices.Contains(l.Tags, t) {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) > 0 {
+		noun := "tag"
+		if len(missing) > 1 {
+			noun = "tags"
+		}
+		add("tags", "missing the required %s %s", noun, strings.Join(missing, ", "))
+	}
+	if len(l.Tags) < p.MinTags {
+		add("tags", "has %d tags, want at least %d", len(l.Tags), p.MinTags)
+	}
+	if n := utf8.RuneCountInString(l.Title); p.MaxTitleLength > 0 && n > p.MaxTitleLength {
+		add("title", "%d characters long, want at most %d", n, p.MaxTitleLength)
+	}
+	if !p.SummaryRequiredAfter.IsZero() && strings.TrimSpace(l.Summary) == "" {
+		if d, err := ParseDate(l.Date); err == nil && !d.Before(p.SummaryRequiredAfter) {
+			add("summary", "required for links from %s on", p.SummaryRequiredAfter.Format(time.DateOnly))
+		}
+	}
+	return out
+}
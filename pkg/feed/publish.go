@@ -0,0 +1,75 @@
+package feed
+
+import (
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// ParsePublishAt reads a -publish-at value: an RFC 3339 time, or a
+// YYYY-MM-DD date meaning midnight UTC. The result is in UTC.
+func ParsePublishAt(s string) (time.Time, error) {
+	if t, err := time.Parse(DateLayout, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	return t.UTC(), err
+}
+
+// Published reports whether l is public at now: not a draft, and not
+// scheduled for later. A publish_at that doesn't parse counts as later,
+// so a typo can't release a link early.
+func Published(l *v1.Link, now time.Time) bool {
+	if l.Draft {
+		return false
+	}
+	if l.PublishAt == "" {
+		return true
+	}
+	t, err := time.Parse(time.RFC3339, l.PublishAt)
+	return err == nil && !t.After(now)
+}
+
+// Public returns a copy of f with only the links published at now (see
+// Published); the links themselves are shared, not copied. It returns f
+// itself when every link is published.
+func Public(f *v1.Feed, now time.Time) *v1.Feed {
+	var keep []*v1.Link
+	for i, l := range f.Links {
+		if !Published(l, now) {
+			if keep == nil {
+				keep = append(make([]*v1.Link, 0, len(f.Links)), f.Links[:i]...)
+			}
+			continue
+		}
+		if keep != nil {
+			keep = append(keep, l)
+		}
+	}
+	if keep == nil {
+		return f
+	}
+	links := f.Links
+	f.Links = nil
+	out := proto.Clone(f).(*v1.Feed)
+	f.Links = links
+	out.Links = keep
+	return out
+}
+
+// NextPublish returns the earliest publish_at after now among f's links
+// that aren't drafts, or the zero time if none is scheduled.
+func NextPublish(f *v1.Feed, now time.Time) time.Time {
+	var next time.Time
+	for _, l := range f.Links {
+		if l.Draft || l.PublishAt == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, l.PublishAt)
+		if err == nil && t.After(now) && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	return next
+}
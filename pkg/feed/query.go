@@ -0,0 +1,297 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// Query is a parsed search expression (see ParseQuery). The zero Query
+// matches every link.
+type Query struct {
+	// any holds the OR-ed alternatives; each is a list of AND-ed terms.
+	any [][]queryTerm
+}
+
+type queryTerm struct {
+	text   string // original token, for errors and String
+	match  func(*v1.Link) bool
+	narrow func(*Filter) // for Filter; nil if the term has no Filter equivalent
+}
+
+// ParseQuery parses a search expression. Terms are separated by spaces and
+// AND-ed; the keyword OR (upper case) separates alternatives, and AND may
+// be written explicitly. A term is one of:
+//
+//	word            substring of title or summary (case-insensitive)
+//	"two words"     the same, as a phrase
+//	tag:go          has the tag (case-insensitive; tag:lang/* any in lang/)
+//	domain:x.com    URL host is x.com or a subdomain ("www." ignored)
+//	title:, url:, summary:, via:, author:   substring of that field
+//	lang:de         language is de or a variant of it, like de-AT
+//	id:abc          ID starts with abc
+//	meta:rating=5   meta value (case-insensitive); meta:rating has the key
+//	date>=2024-01-01 (also >, <, <=, =)
+//
+// A leading '-' negates a term.
+func ParseQuery(s string) (Query, error) {
+	toks, err := tokenizeQuery(s)
+	if err != nil {
+		return Query{}, err
+	}
+	var q Query
+	var cur []queryTerm
+	for _, tok := range toks {
+		switch tok {
+		case "AND":
+			continue
+		case "OR":
+			if len(cur) == 0 {
+				return Query{}, errors.New("query: OR needs a term on both sides")
+			}
+			q.any = append(q.any, cur)
+			cur = nil
+			continue
+		}
+		t, err := parseTerm(tok)
+		if err != nil {
+			return Query{}, err
+		}
+		cur = append(cur, t)
+	}
+	if len(cur) == 0 && len(q.any) > 0 {
+		return Query{}, errors.New("query: OR needs a term on both sides")
+	}
+	if len(cur) > 0 {
+		q.any = append(q.any, cur)
+	}
+	return q, nil
+}
+
+// Match reports whether l satisfies q.
+func (q Query) Match(l *v1.Link) bool {
+	if len(q.any) == 0 {
+		return true
+	}
+	for _, all := range q.any {
+		if !slices.ContainsFunc(all, func(t queryTerm) bool { return !t.match(l) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the links matching q, preserving their order.
+func (q Query) Apply(links []*v1.Link) []*v1.Link {
+	out := make([]*v1.Link, 0, len(links))
+	for _, l := range links {
+		if q.Match(l) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// Filter returns a Filter that every link matching q also passes, built
+// from the tag:, domain:, lang: and date terms of a query without OR. Select
+// answers such filters from indexes, so applying q to its result is
+// cheaper than to the whole feed.
+func (q Query) Filter() Filter {
+	var flt Filter
+	if len(q.any) != 1 {
+		return flt
+	}
+	for _, t := range q.any[0] {
+		if t.narrow != nil {
+			t.narrow(&flt)
+		}
+	}
+	return flt
+}
+
+func (q Query) String() string {
+	alts := make([]string, len(q.any))
+	for i, all := range q.any {
+		terms := make([]string, len(all))
+		for j, t := range all {
+			terms[j] = t.text
+		}
+		alts[i] = strings.Join(terms, " ")
+	}
+	return strings.Join(alts, " OR ")
+}
+
+// tokenizeQuery splits on whitespace, keeping "quoted phrases" (also after
+// a field prefix, as in title:"a b") together with the quotes removed.
+func tokenizeQuery(s string) ([]string, error) {
+	var toks []string
+	var b strings.Builder
+	inQuote, started := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			started = true
+		case unicode.IsSpace(r) && !inQuote:
+			if started {
+				toks = append(toks, b.String())
+				b.Reset()
+				started = false
+			}
+		default:
+			b.WriteRune(r)
+			started = true
+		}
+	}
+	if inQuote {
+		return nil, errors.New("query: unterminated quote")
+	}
+	if started {
+		toks = append(toks, b.String())
+	}
+	return toks, nil
+}
+
+func parseTerm(tok string) (queryTerm, error) {
+	neg := strings.HasPrefix(tok, "-") && len(tok) > 1
+	body := tok
+	if neg {
+		body = tok[1:]
+	}
+	match, err := termMatcher(body)
+	if err != nil {
+		return queryTerm{}, err
+	}
+	if neg {
+		m := match
+		match = func(l *v1.Link) bool { return !m(l) }
+		return queryTerm{text: tok, match: match}, nil
+	}
+	return queryTerm{text: tok, match: match, narrow: termNarrower(body)}, nil
+}
+
+// termNarrower returns how a (non-negated) term restricts a Filter, or
+// nil. It runs after termMatcher has accepted tok.
+func termNarrower(tok string) func(*Filter) {
+	if rest, ok := strings.CutPrefix(tok, "date"); ok && rest != "" && strings.ContainsRune("<>=", rune(rest[0])) {
+		op := strings.TrimRight(rest[:min(2, len(rest))], "0123456789")
+		d, _ := ParseDate(rest[len(op):])
+		return func(flt *Filter) {
+			after, before := d, d
+			switch op {
+			case ">":
+				after = d.AddDate(0, 0, 1)
+			case "<":
+				before = d.AddDate(0, 0, -1)
+			}
+			if op != "<" && op != "<=" && after.After(flt.After) {
+				flt.After = after
+			}
+			if op != ">" && op != ">=" && (flt.Before.IsZero() || before.Before(flt.Before)) {
+				flt.Before = before
+			}
+		}
+	}
+	field, value, ok := strings.Cut(tok, ":")
+	if !ok {
+		return nil
+	}
+	switch strings.ToLower(field) {
+	case "tag":
+		return func(flt *Filter) { flt.Tags = append(flt.Tags, value) }
+	case "domain":
+		return func(flt *Filter) {
+			if flt.Domain == "" {
+				flt.Domain = value
+			}
+		}
+	case "lang":
+		return func(flt *Filter) {
+			if flt.Lang == "" {
+				flt.Lang = value
+			}
+		}
+	}
+	return nil
+}
+
+func termMatcher(tok string) (func(*v1.Link) bool, error) {
+	if rest, ok := strings.CutPrefix(tok, "date"); ok && rest != "" && strings.ContainsRune("<>=", rune(rest[0])) {
+		return dateMatcher(tok, rest)
+	}
+	field, value, ok := strings.Cut(tok, ":")
+	if !ok {
+		return func(l *v1.Link) bool { return containsFold(l.Title, tok) || containsFold(l.Summary, tok) }, nil
+	}
+	if value == "" {
+		return nil, fmt.Errorf("query: %q has no value", tok)
+	}
+	switch strings.ToLower(field) {
+	case "tag":
+		return func(l *v1.Link) bool {
+			return slices.ContainsFunc(l.Tags, func(t string) bool { return TagMatches(value, t) })
+		}, nil
+	case "domain":
+		return func(l *v1.Link) bool { return InDomain(Host(l.Url), value) }, nil
+	case "title":
+		return func(l *v1.Link) bool { return containsFold(l.Title, value) }, nil
+	case "url":
+		return func(l *v1.Link) bool { return containsFold(l.Url, value) }, nil
+	case "summary":
+		return func(l *v1.Link) bool { return containsFold(l.Summary, value) }, nil
+	case "via":
+		return func(l *v1.Link) bool { return containsFold(l.Via, value) }, nil
+	case "author":
+		return func(l *v1.Link) bool { return containsFold(l.Author, value) }, nil
+	case "lang":
+		return func(l *v1.Link) bool { return LangMatches(value, l.Lang) }, nil
+	case "id":
+		return func(l *v1.Link) bool { return strings.HasPrefix(l.Id, value) }, nil
+	case "meta":
+		key, want, hasValue := strings.Cut(value, "=")
+		return func(l *v1.Link) bool {
+			v, ok := l.Meta[key]
+			return ok && (!hasValue || strings.EqualFold(v, want))
+		}, nil
+	}
+	// Unknown prefixes (e.g. "c++:" or a pasted URL) are plain text.
+	return func(l *v1.Link) bool { return containsFold(l.Title, tok) || containsFold(l.Summary, tok) }, nil
+}
+
+func dateMatcher(tok, rest string) (func(*v1.Link) bool, error) {
+	op := rest[:1]
+	if len(rest) > 1 && rest[1] == '=' {
+		op = rest[:2]
+	}
+	want, err := ParseDate(rest[len(op):])
+	if err != nil {
+		return nil, fmt.Errorf("query: %q: want date%sYYYY-MM-DD", tok, op)
+	}
+	var cmp func(c int) bool
+	switch op {
+	case ">":
+		cmp = func(c int) bool { return c > 0 }
+	case ">=":
+		cmp = func(c int) bool { return c >= 0 }
+	case "<":
+		cmp = func(c int) bool { return c < 0 }
+	case "<=":
+		cmp = func(c int) bool { return c <= 0 }
+	case "=":
+		cmp = func(c int) bool { return c == 0 }
+	default:
+		return nil, fmt.Errorf("query: %q: unknown operator %q", tok, op)
+	}
+	return func(l *v1.Link) bool {
+		d, err := ParseDate(l.Date)
+		return err == nil && cmp(d.Compare(want))
+	}, nil
+}
+
+func containsFold(s, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
@@ -0,0 +1,104 @@
+package feed
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+func TestParseQuery(t *testing.T) {
+	links := []*v1.Link{
+		{Id: "a1", Title: "Go generics", Url: "https://go.dev/blog/generics", Date: "2024-03-01", Tags: []string{"lang/go"}, Lang: "en"},
+		{Id: "b2", Title: "Rust async", Summary: "The Book", Url: "https://www.rust-lang.org/async", Date: "2024-05-01", Tags: []string{"lang/rust"}, Lang: "de-AT", Meta: map[string]string{"rating": "5"}},
+		{Id: "c3", Title: "Postgres tips", Url: "https://blog.example.com/pg", Date: "2023-12-31", Tags: []string{"db"}, Via: "https://news.example", Author: "Ann"},
+	}
+	tests := []struct {
+		query   string
+		want    []string // IDs of the matching links
+		wantErr bool
+	}{
+		{"", []string{"a1", "b2", "c3"}, false},
+		{"generics", []string{"a1"}, false},
+		{"book", []string{"b2"}, false},
+		{`"go generics"`, []string{"a1"}, false},
+		{`title:"rust async"`, []string{"b2"}, false},
+		{"tag:lang/go", []string{"a1"}, false},
+		{"tag:LANG/*", []string{"a1", "b2"}, false},
+		{"domain:rust-lang.org", []string{"b2"}, false},
+		{"domain:example.com", []string{"c3"}, false},
+		{"url:/pg", []string{"c3"}, false},
+		{"via:news author:ann", []string{"c3"}, false},
+		{"lang:de", []string{"b2"}, false},
+		{"id:a", []string{"a1"}, false},
+		{"meta:rating", []string{"b2"}, false},
+		{"meta:rating=4", nil, false},
+		{"date>=2024-01-01", []string{"a1", "b2"}, false},
+		{"date<2024-03-01", []string{"c3"}, false},
+		{"date=2024-05-01", []string{"b2"}, false},
+		{"-tag:db", []string{"a1", "b2"}, false},
+		{"tag:lang/* AND -rust", []string{"a1"}, false},
+		{"tag:db OR generics", []string{"a1", "c3"}, false},
+		{"c++:templates", nil, false},
+		{"OR go", nil, true},
+		{"go OR", nil, true},
+		{`"go`, nil, true},
+		{"tag:", nil, true},
+		{"date>=yesterday", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseQuery(%q) = %v, want an error", tt.query, q)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuery(%q): %v", tt.query, err)
+			}
+			var got []string
+			for _, l := range q.Apply(links) {
+				got = append(got, l.Id)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseQuery(%q) matches %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryFilter(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := ParseDate(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		query string
+		want  Filter
+	}{
+		{"go tag:a tag:b", Filter{Tags: []string{"a", "b"}}},
+		{"domain:x.com lang:de", Filter{Domain: "x.com", Lang: "de"}},
+		{"date>2024-01-01 date<=2024-02-01", Filter{After: day("2024-01-02"), Before: day("2024-02-01")}},
+		{"-tag:a", Filter{}},
+		{"tag:a OR tag:b", Filter{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := q.Filter()
+			if !slices.Equal(got.Tags, tt.want.Tags) || got.Domain != tt.want.Domain || got.Lang != tt.want.Lang ||
+				!got.After.Equal(tt.want.After) || !got.Before.Equal(tt.want.Before) {
+				t.Errorf("Filter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
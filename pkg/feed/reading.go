@@ -0,0 +1,27 @@
+package feed
+
+import v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+
+// WordsPerMinute is the reading speed ReadingMinutes assumes, an adult's
+// average for non-fiction on a screen.
+const WordsPerMinute = 230
+
+// ReadingMinutes estimates the minutes words take to read, rounded to the
+// nearest minute but at least 1; 0 words take 0 (unknown).
+func ReadingMinutes(words int) uint32 {
+	if words <= 0 {
+		return 0
+	}
+	return uint32(max(1, (words+WordsPerMinute/2)/WordsPerMinute))
+}
+
+// SetWordCount stores a page's word count in l along with its reading
+// time, and reports whether either changed.
+func SetWordCount(l *v1.Link, words int) bool {
+	n, m := uint32(max(words, 0)), ReadingMinutes(words)
+	if l.WordCount == n && l.ReadingMinutes == m {
+		return false
+	}
+	l.WordCount, l.ReadingMinutes = n, m
+	return true
+}
@@ -0,0 +1,62 @@
+package feed
+
+import (
+	"slices"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// Relate adds to's ID to from.RelatedIds and reports whether it wasn't
+// there yet. A link can't relate to itself.
+func Relate(from, to *v1.Link) bool {
+	if from.Id == to.Id || slices.Contains(from.RelatedIds, to.Id) {
+		return false
+	}
+	from.RelatedIds = append(from.RelatedIds, to.Id)
+	return true
+}
+
+// Unrelate removes id from l.RelatedIds and reports whether it was there.
+func Unrelate(l *v1.Link, id string) bool {
+	n := len(l.RelatedIds)
+	if n == 0 {
+		return false
+	}
+	l.RelatedIds = slices.DeleteFunc(l.RelatedIds, func(r string) bool { return r == id })
+	if len(l.RelatedIds) == 0 {
+		l.RelatedIds = nil
+	}
+	return len(l.RelatedIds) != n
+}
+
+// Related returns the links of f that l refers to, in RelatedIds order;
+// IDs f doesn't have are skipped.
+func Related(f *v1.Feed, l *v1.Link) []*v1.Link {
+	var out []*v1.Link
+	for _, id := range l.RelatedIds {
+		if r := Find(f, id); r != nil {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// unrelateRemoved drops references to the removed links from the links
+// left in f (unless another link still has the removed one's ID).
+func unrelateRemoved(f *v1.Feed, removed []*v1.Link) {
+	gone := map[string]bool{}
+	for _, r := range removed {
+		gone[r.Id] = true
+	}
+	for _, l := range f.Links {
+		delete(gone, l.Id)
+	}
+	for _, l := range f.Links {
+		if len(l.RelatedIds) > 0 {
+			l.RelatedIds = slices.DeleteFunc(l.RelatedIds, func(id string) bool { return gone[id] })
+			if len(l.RelatedIds) == 0 {
+				l.RelatedIds = nil
+			}
+		}
+	}
+}
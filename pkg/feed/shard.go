@@ -0,0 +1,242 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// FormatSharded is the SaveOptions.Format of a sharded feed: a directory
+// with an index (ShardIndexName, a v1.ShardIndex) and one Feed file per
+// year of Link.date, so a save rewrites only the years that changed.
+// Feed order across shards is newest year first.
+const FormatSharded = "sharded"
+
+// ShardIndexName is the index file in a sharded feed's directory.
+const ShardIndexName = "index.pb"
+
+// undatedShard holds the links whose date doesn't parse.
+const undatedShard = "undated"
+
+// IsShardedFeed reports whether path (see ExpandPath) is a sharded feed's
+// directory.
+func IsShardedFeed(path string) bool {
+	path, err := ExpandPath(path)
+	return err == nil && fileSharded(path)
+}
+
+func fileSharded(path string) bool {
+	fi, err := os.Stat(filepath.Join(path, ShardIndexName))
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// errShardedBytes is returned for a sharded feed by the functions that
+// work on a feed file's bytes rather than its content.
+var errShardedBytes = errors.New("a sharded feed is a directory, not one file (copy the directory instead)")
+
+// shardedBytes returns the index of the sharded feed in dir after checking
+// that every shard still is the file the index lists, so signing the index
+// covers the whole feed.
+func shardedBytes(dir string) ([]byte, error) {
+	b, err := os.ReadFile(filepath.Join(dir, ShardIndexName))
+	if err != nil {
+		return nil, err
+	}
+	idx, err := unmarshalShardIndex(b)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range idx.Shards {
+		path := filepath.Join(dir, filepath.Base(s.Name))
+		sb, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if hexSHA256(sb) != s.Sha256 {
+			return nil, fmt.Errorf("%s: %w (not the file %s lists)", path, ErrChecksumMismatch, ShardIndexName)
+		}
+	}
+	return b, nil
+}
+
+// shardKey is the shard l belongs in: its year, or undatedShard.
+func shardKey(l *v1.Link) string {
+	if d, err := ParseDate(l.Date); err == nil {
+		return d.Format("2006")
+	}
+	return undatedShard
+}
+
+func readShardIndex(dir string) (*v1.ShardIndex, error) {
+	b, err := os.ReadFile(filepath.Join(dir, ShardIndexName))
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalShardIndex(b)
+}
+
+func unmarshalShardIndex(b []byte) (*v1.ShardIndex, error) {
+	idx := &v1.ShardIndex{}
+	if err := proto.Unmarshal(b, idx); err != nil {
+		return nil, fmt.Errorf("%s: unmarshal protobuf: %w", ShardIndexName, err)
+	}
+	return idx, nil
+}
+
+// loadSharded reads the shards keep accepts (all if nil) into one feed.
+func loadSharded(dir string, opts LoadOptions, keep func(key string) bool) (*v1.Feed, error) {
+	start := time.Now()
+	idx, err := readShardIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	f := idx.Feed
+	if f == nil {
+		f = &v1.Feed{}
+	}
+	read := 0
+	for _, s := range idx.Shards {
+		if keep != nil && !keep(strings.TrimSuffix(s.Name, ".pb")) {
+			continue
+		}
+		path := filepath.Join(dir, filepath.Base(s.Name))
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Verify && hexSHA256(b) != s.Sha256 {
+			return nil, fmt.Errorf("verify %s: %w (not the file %s lists)", path, ErrChecksumMismatch, ShardIndexName)
+		}
+		var shard v1.Feed
+		if err := proto.Unmarshal(b, &shard); err != nil {
+			return nil, fmt.Errorf("%s: unmarshal protobuf: %w", path, err)
+		}
+		f.Links = append(f.Links, shard.Links...)
+		read++
+	}
+	Logger.Debug("load", "path", dir, "shards", read, "links", len(f.Links), "elapsed", time.Since(start))
+	return migrated(f, opts)
+}
+
+// selectSharded reads only the years flt's date bounds allow, or nil, false
+// if flt has none.
+func selectSharded(dir string, flt Filter, opts LoadOptions) (*v1.Feed, bool, error) {
+	if flt.After.IsZero() && flt.Before.IsZero() {
+		return nil, false, nil
+	}
+	f, err := loadSharded(dir, opts, func(key string) bool {
+		// Undated links never match a date filter.
+		return key != undatedShard &&
+			(flt.After.IsZero() || key >= flt.After.Format("2006")) &&
+			(flt.Before.IsZero() || key <= flt.Before.Format("2006"))
+	})
+	return f, true, err
+}
+
+// saveSharded writes f as a sharded feed in dir: the shards whose content
+// changed, then the index. Shards left empty are removed.
+func saveSharded(dir string, f *v1.Feed, opts SaveOptions, encrypt bool, compression string) error {
+	if encrypt || compression != CompressNone {
+		return fmt.Errorf("save %s: sharded feeds can't be encrypted or compressed", dir)
+	}
+	old := map[string]*v1.Shard{}
+	if idx, err := readShardIndex(dir); err == nil {
+		for _, s := range idx.Shards {
+			old[s.Name] = s
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	groups := map[string][]*v1.Link{}
+	for _, l := range f.Links {
+		k := shardKey(l)
+		groups[k] = append(groups[k], l)
+	}
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	// Newest year first; "undated" sorts after every year.
+	slices.SortFunc(keys, func(a, b string) int {
+		if a == undatedShard || b == undatedShard {
+			return strings.Compare(a, b)
+		}
+		return strings.Compare(b, a)
+	})
+
+	start := time.Now()
+	idx := &v1.ShardIndex{}
+	writes := map[string][]byte{}
+	for _, k := range keys {
+		b, err := marshal(&v1.Feed{Version: f.Version, Links: groups[k]}, false, opts)
+		if err != nil {
+			return fmt.Errorf("marshal protobuf: %w", err)
+		}
+		s := &v1.Shard{Name: k + ".pb", Links: int32(len(groups[k])), Sha256: hexSHA256(b)}
+		idx.Shards = append(idx.Shards, s)
+		if o := old[s.Name]; o == nil || o.Sha256 != s.Sha256 || !fileExists(filepath.Join(dir, s.Name)) {
+			writes[s.Name] = b
+		}
+		delete(old, s.Name)
+	}
+	links := f.Links
+	f.Links = nil
+	meta := proto.Clone(f).(*v1.Feed)
+	f.Links = links
+	if opts.GeneratedAt != "" {
+		meta.GeneratedAt = opts.GeneratedAt
+		f.GeneratedAt = opts.GeneratedAt
+	}
+	if opts.Canonical {
+		meta = Canonicalize(meta)
+	}
+	idx.Feed = meta
+	ib, err := proto.MarshalOptions{Deterministic: true}.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("marshal protobuf: %w", err)
+	}
+	Logger.Debug("marshal", "shards", len(idx.Shards), "changed", len(writes), "links", len(f.Links), "elapsed", time.Since(start))
+	if opts.DryRun {
+		Logger.Debug("dry run; not saving", "path", dir)
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Shards first and the index last, so the index never lists a shard
+	// that isn't there yet.
+	for _, name := range slices.Sorted(maps.Keys(writes)) {
+		if err := writeFeedFile(filepath.Join(dir, name), writes[name], opts); err != nil {
+			return err
+		}
+	}
+	if err := writeFeedFile(filepath.Join(dir, ShardIndexName), ib, opts); err != nil {
+		return err
+	}
+	for name := range old {
+		path := filepath.Join(dir, filepath.Base(name))
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		os.Remove(path + ChecksumSuffix)
+		Logger.Debug("remove empty shard", "path", path)
+	}
+	return nil
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,145 @@
+package feed
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SignatureSuffix names the detached signature written next to a feed
+// file: the base64 ed25519 signature of the file's exact bytes.
+const SignatureSuffix = ".sig"
+
+// ErrBadSignature means the signature doesn't match the file and key:
+// the file changed after signing, or it was signed with another key.
+var ErrBadSignature = errors.New("signature does not match")
+
+// GenerateKey returns a new ed25519 key pair as PEM (PKCS#8 private key,
+// PKIX public key), the same encoding `openssl genpkey -algorithm ed25519`
+// uses.
+func GenerateKey() (privPEM, pubPEM []byte, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	privPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})
+	pubPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	return privPEM, pubPEM, nil
+}
+
+// ParsePrivateKey reads a PEM-encoded ed25519 private key.
+func ParsePrivateKey(b []byte) (ed25519.PrivateKey, error) {
+	der, err := pemBlock(b, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	k, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	priv, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("parse private key: want ed25519, got %T", k)
+	}
+	return priv, nil
+}
+
+// ParsePublicKey reads a PEM-encoded ed25519 public key.
+func ParsePublicKey(b []byte) (ed25519.PublicKey, error) {
+	der, err := pemBlock(b, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	k, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+	pub, ok := k.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("parse public key: want ed25519, got %T", k)
+	}
+	return pub, nil
+}
+
+func pemBlock(b []byte, typ string) ([]byte, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data (want %q)", typ)
+	}
+	if block.Type != typ {
+		return nil, fmt.Errorf("PEM block is %q, want %q", block.Type, typ)
+	}
+	return block.Bytes, nil
+}
+
+// SignFile signs the file at path (see ExpandPath) and writes the
+// signature to sigPath (path+SignatureSuffix if empty). For a sharded feed
+// it signs the index, which holds the checksum of every shard.
+func SignFile(path, sigPath string, key ed25519.PrivateKey) error {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return err
+	}
+	data, err := signedBytes(path)
+	if err != nil {
+		return err
+	}
+	if sigPath == "" {
+		sigPath = path + SignatureSuffix
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))
+	return writeFile(sigPath, []byte(sig+"\n"))
+}
+
+// VerifyFile checks the file at path (see ExpandPath) against the
+// signature in sigPath (path+SignatureSuffix if empty).
+func VerifyFile(path, sigPath string, pub ed25519.PublicKey) error {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return err
+	}
+	data, err := signedBytes(path)
+	if err != nil {
+		return err
+	}
+	if sigPath == "" {
+		sigPath = path + SignatureSuffix
+	}
+	b, err := readFile(sigPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("verify %s: no signature at %s", path, sigPath)
+		}
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return fmt.Errorf("verify %s: %s: %w", path, sigPath, err)
+	}
+	if !ed25519.Verify(pub, data, sig) {
+		return fmt.Errorf("verify %s: %w", path, ErrBadSignature)
+	}
+	return nil
+}
+
+// signedBytes is what SignFile signs for the feed at path.
+func signedBytes(path string) ([]byte, error) {
+	if fileSharded(path) {
+		return shardedBytes(path)
+	}
+	return readFile(path)
+}
@@ -0,0 +1,96 @@
+package feed
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// maxSlugLen caps the slugs LinkSlug makes, cut at a dash.
+const maxSlugLen = 40
+
+// LinkSlug is a short slug for l: its title (else its URL's host and path)
+// slugified (see Slugify) and cut to whole words within 40 bytes.
+func LinkSlug(l *v1.Link) string {
+	s := l.Title
+	if s == "" {
+		s = strings.TrimPrefix(Host(l.Url), "www.") + " " + urlPath(l.Url)
+	}
+	slug := Slugify(s)
+	if len(slug) > maxSlugLen {
+		cut := slug[:maxSlugLen+1]
+		if i := strings.LastIndexByte(cut, '-'); i > 0 {
+			slug = cut[:i]
+		} else {
+			slug = strings.ToValidUTF8(slug[:maxSlugLen], "")
+		}
+	}
+	return slug
+}
+
+func urlPath(s string) string {
+	_, rest, _ := strings.Cut(s, "://")
+	_, path, _ := strings.Cut(rest, "/")
+	return path
+}
+
+// ValidateSlug checks that s is a slug: lowercase letters and digits in
+// runs joined by single dashes, as Slugify makes.
+func ValidateSlug(s string) error {
+	if s == "" || Slugify(s) != s {
+		return fmt.Errorf("slug %q: want lowercase letters and digits joined by dashes, e.g. go-1-24", s)
+	}
+	return nil
+}
+
+// SlugIndex returns the position of the link with slug in f, or -1.
+func SlugIndex(f *v1.Feed, slug string) int {
+	for i, l := range f.Links {
+		if l.Slug == slug {
+			return i
+		}
+	}
+	return -1
+}
+
+// AssignSlugs gives each link of f without a slug its LinkSlug, with -2,
+// -3, … if another link has it. Older links (further down) go first, so
+// the first link with a title keeps the plain slug. It returns how many
+// links got one.
+func AssignSlugs(f *v1.Feed) int {
+	used := map[string]bool{}
+	for _, l := range f.Links {
+		if l.Slug != "" {
+			used[l.Slug] = true
+		}
+	}
+	n := 0
+	for i := len(f.Links) - 1; i >= 0; i-- {
+		l := f.Links[i]
+		if l.Slug != "" {
+			continue
+		}
+		base := LinkSlug(l)
+		l.Slug = base
+		for k := 2; used[l.Slug]; k++ {
+			l.Slug = fmt.Sprintf("%s-%d", base, k)
+		}
+		used[l.Slug] = true
+		n++
+	}
+	return n
+}
+
+// SetSlug gives l, a link of f (or about to be added to it), the slug s
+// after checking it (see ValidateSlug) and that no other link has it.
+func SetSlug(f *v1.Feed, l *v1.Link, s string) error {
+	if err := ValidateSlug(s); err != nil {
+		return err
+	}
+	if i := SlugIndex(f, s); i >= 0 && f.Links[i] != l {
+		return fmt.Errorf("slug %q is taken by [%s]", s, f.Links[i].Id)
+	}
+	l.Slug = s
+	return nil
+}
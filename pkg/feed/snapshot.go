@@ -0,0 +1,183 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+)
+
+// SnapshotDir is where Backup keeps snapshots, relative to the feed's
+// directory.
+const SnapshotDir = ".linkleaf/backups"
+
+// snapshotTime is the timestamp in snapshot names; it sorts by time.
+const snapshotTime = "20060102T150405Z"
+
+// Snapshot is a byte-for-byte copy of a feed file, named
+// <feed name>.<UTC time>.<SHA-256 of the bytes, 16 hex digits>, so equal
+// contents are stored once and a damaged copy is detected on restore.
+type Snapshot struct {
+	Path string    `json:"path"`
+	Time time.Time `json:"time"`
+	Hash string    `json:"hash"`
+	Size int64     `json:"size"`
+}
+
+// snapshotDir returns the snapshot directory of the local feed at path
+// (already expanded).
+func snapshotDir(path string) (string, error) {
+	if storage.IsRemote(path) {
+		return "", fmt.Errorf("snapshots need a local file, got %s", path)
+	}
+	return filepath.Join(filepath.Dir(path), filepath.FromSlash(SnapshotDir)), nil
+}
+
+func contentHash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Snapshots lists the snapshots of the feed at path (see ExpandPath),
+// newest first.
+func Snapshots(path string) ([]Snapshot, error) {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := snapshotDir(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(path) + "."
+	var out []Snapshot
+	for _, e := range entries {
+		rest, ok := strings.CutPrefix(e.Name(), prefix)
+		if !ok || e.IsDir() {
+			continue
+		}
+		ts, hash, ok := strings.Cut(rest, ".")
+		t, err := time.Parse(snapshotTime, ts)
+		if !ok || err != nil || len(hash) != 16 {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Snapshot{Path: filepath.Join(dir, e.Name()), Time: t, Hash: hash, Size: info.Size()})
+	}
+	slices.SortFunc(out, func(a, b Snapshot) int { return b.Time.Compare(a.Time) })
+	return out, nil
+}
+
+// Backup snapshots the feed file at path (see ExpandPath) as it is on
+// disk. If the newest snapshot already holds these bytes, it is returned
+// with created false instead of writing a duplicate. With keep > 0 only
+// the keep newest snapshots are kept.
+func Backup(path string, keep int) (s Snapshot, created bool, err error) {
+	path, err = ExpandPath(path)
+	if err != nil {
+		return s, false, err
+	}
+	if fileSharded(path) {
+		return s, false, errShardedBytes
+	}
+	dir, err := snapshotDir(path)
+	if err != nil {
+		return s, false, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return s, false, err
+	}
+	all, err := Snapshots(path)
+	if err != nil {
+		return s, false, err
+	}
+	hash := contentHash(b)
+	if len(all) > 0 && all[0].Hash == hash {
+		s = all[0]
+	} else {
+		now := time.Now().UTC()
+		s = Snapshot{
+			Path: filepath.Join(dir, fmt.Sprintf("%s.%s.%s", filepath.Base(path), now.Format(snapshotTime), hash)),
+			Time: now.Truncate(time.Second),
+			Hash: hash,
+			Size: int64(len(b)),
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return s, false, err
+		}
+		if err := WriteFileAtomic(s.Path, b, 0o644); err != nil {
+			return s, false, err
+		}
+		all = append([]Snapshot{s}, all...)
+		created = true
+	}
+	if keep > 0 && len(all) > keep {
+		for _, old := range all[keep:] {
+			if err := os.Remove(old.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return s, created, fmt.Errorf("rotate: %w", err)
+			}
+		}
+	}
+	return s, created, nil
+}
+
+// FindSnapshot returns the snapshot of the feed at path whose hash starts
+// with prefix, newest first if equal contents were saved more than once.
+func FindSnapshot(path, prefix string) (Snapshot, error) {
+	all, err := Snapshots(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	prefix = strings.ToLower(prefix)
+	var match []Snapshot
+	for _, s := range all {
+		if prefix != "" && strings.HasPrefix(s.Hash, prefix) && !slices.ContainsFunc(match, func(m Snapshot) bool { return m.Hash == s.Hash }) {
+			match = append(match, s)
+		}
+	}
+	switch len(match) {
+	case 0:
+		return Snapshot{}, fmt.Errorf("no snapshot %q of %s", prefix, path)
+	case 1:
+		return match[0], nil
+	}
+	return Snapshot{}, fmt.Errorf("snapshot %q is ambiguous: %d snapshots match", prefix, len(match))
+}
+
+// Restore replaces the feed file at path with snapshot s after checking
+// that s still holds the bytes its name promises.
+func Restore(path string, s Snapshot) error {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return err
+	}
+	if fileSharded(path) {
+		return errShardedBytes
+	}
+	b, err := os.ReadFile(s.Path)
+	if err != nil {
+		return err
+	}
+	if got := contentHash(b); got != s.Hash {
+		return fmt.Errorf("snapshot %s is damaged (content hash %s)", filepath.Base(s.Path), got)
+	}
+	return WriteFileAtomic(path, b, 0o644)
+}
@@ -0,0 +1,83 @@
+package feed
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// SortKeys are the orders Sort knows.
+var SortKeys = []string{"date", "title", "domain", "added", "reading-time"}
+
+// SortByAdded orders links newest added_at first. The sort is stable, and
+// links without a valid added_at keep their relative order after the rest.
+func SortByAdded(links []*v1.Link) { _ = Sort(links, "added", false) }
+
+// Sort orders links by one of SortKeys: date (see LinkTime) and added
+// newest first, title and domain (without www.) A to Z, ignoring case,
+// reading-time (Link.reading_minutes) quickest first; reverse flips that.
+// An empty key keeps feed order, or reverses it. The sort is stable, and
+// links missing the key (no valid date, an empty title, no reading time)
+// come last either way.
+func Sort(links []*v1.Link, key string, reverse bool) error {
+	text := func(s string) (string, bool) { return strings.ToLower(s), s != "" }
+	switch key {
+	case "":
+		if reverse {
+			slices.Reverse(links)
+		}
+	case "date":
+		sortBy(links, LinkTime, func(a, b time.Time) int { return b.Compare(a) }, reverse)
+	case "added":
+		sortBy(links, func(l *v1.Link) (time.Time, bool) {
+			t, err := time.Parse(time.RFC3339, l.AddedAt)
+			return t, err == nil
+		}, func(a, b time.Time) int { return b.Compare(a) }, reverse)
+	case "title":
+		sortBy(links, func(l *v1.Link) (string, bool) { return text(l.Title) }, strings.Compare, reverse)
+	case "domain":
+		sortBy(links, func(l *v1.Link) (string, bool) {
+			return text(strings.TrimPrefix(Host(l.Url), "www."))
+		}, strings.Compare, reverse)
+	case "reading-time":
+		sortBy(links, func(l *v1.Link) (uint32, bool) { return l.ReadingMinutes, l.ReadingMinutes > 0 }, cmp.Compare[uint32], reverse)
+	default:
+		return fmt.Errorf("-sort: want one of %s, got %q", strings.Join(SortKeys, ", "), key)
+	}
+	return nil
+}
+
+// sortBy stably sorts links by the key of each, in compare's order or its
+// reverse; links without a key (ok false) go last.
+func sortBy[K any](links []*v1.Link, key func(*v1.Link) (K, bool), compare func(a, b K) int, reverse bool) {
+	type keyed struct {
+		l  *v1.Link
+		k  K
+		ok bool
+	}
+	ks := make([]keyed, len(links))
+	for i, l := range links {
+		k, ok := key(l)
+		ks[i] = keyed{l, k, ok}
+	}
+	slices.SortStableFunc(ks, func(a, b keyed) int {
+		switch {
+		case !a.ok && !b.ok:
+			return 0
+		case !a.ok:
+			return 1
+		case !b.ok:
+			return -1
+		case reverse:
+			return compare(b.k, a.k)
+		}
+		return compare(a.k, b.k)
+	})
+	for i, k := range ks {
+		links[i] = k.l
+	}
+}
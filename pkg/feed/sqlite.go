@@ -0,0 +1,435 @@
+package feed
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+	_ "modernc.org/sqlite" // registers the "sqlite" driver, in pure Go
+)
+
+// SQLite feeds keep every link in its own row, so large feeds are saved by
+// writing only the rows that changed and filtered (see Select) with
+// indexed queries instead of decoding every link.
+//
+// Each row holds the Link message as stored in a .pb file plus copies of
+// the fields filters use (date, hosts, read/starred; tags in their own
+// table), which are indexed. pos orders the links: feed order is
+// descending pos. The feed's other fields are a Feed message without
+// links in meta under "feed".
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value BLOB NOT NULL
+);
+CREATE TABLE IF NOT EXISTS links (
+	id       TEXT PRIMARY KEY,
+	pos      INTEGER NOT NULL,
+	date     TEXT NOT NULL,    -- Link.date if it parses, else ''
+	rhost    TEXT NOT NULL,    -- URL host without www., labels reversed: com.example.blog
+	via      TEXT NOT NULL,
+	via_host TEXT NOT NULL,    -- via host without www.
+	read     INTEGER NOT NULL,
+	starred  INTEGER NOT NULL,
+	link     BLOB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS links_pos ON links (pos);
+CREATE INDEX IF NOT EXISTS links_date ON links (date);
+CREATE INDEX IF NOT EXISTS links_rhost ON links (rhost);
+CREATE INDEX IF NOT EXISTS links_via_host ON links (via_host);
+CREATE TABLE IF NOT EXISTS tags (
+	tag     TEXT NOT NULL COLLATE NOCASE,
+	link_id TEXT NOT NULL,
+	PRIMARY KEY (tag, link_id)
+);
+CREATE INDEX IF NOT EXISTS tags_link_id ON tags (link_id);
+`
+
+// SQLiteMagic starts every SQLite database file.
+const SQLiteMagic = "SQLite format 3\x00"
+
+// SQLiteExt marks a path that should be created as a SQLite feed.
+const SQLiteExt = ".db"
+
+// IsSQLiteFile reports whether the file at path (see ExpandPath) is a
+// SQLite feed.
+func IsSQLiteFile(path string) bool {
+	path, err := ExpandPath(path)
+	return err == nil && fileSQLite(path)
+}
+
+// fileSQLite sniffs the local file at path; remote URLs are never SQLite
+// feeds, since the database needs random access.
+func fileSQLite(path string) bool {
+	if storage.IsRemote(path) {
+		return false
+	}
+	fh, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer fh.Close()
+	head := make([]byte, len(SQLiteMagic))
+	_, err = io.ReadFull(fh, head)
+	return err == nil && string(head) == SQLiteMagic
+}
+
+func openSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite schema: %w", err)
+	}
+	return db, nil
+}
+
+// loadSQLite reads the whole SQLite feed at path.
+func loadSQLite(path string, opts LoadOptions) (*v1.Feed, error) {
+	if opts.Verify {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyChecksum(path, b); err != nil {
+			return nil, err
+		}
+	}
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	f, err := sqliteMeta(db)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT link FROM links ORDER BY pos DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		f.Links = append(f.Links, l)
+	}
+	return f, rows.Err()
+}
+
+func sqliteMeta(db *sql.DB) (*v1.Feed, error) {
+	var b []byte
+	err := db.QueryRow(`SELECT value FROM meta WHERE key = 'feed'`).Scan(&b)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	f := &v1.Feed{}
+	if err := proto.Unmarshal(b, f); err != nil {
+		return nil, fmt.Errorf("unmarshal protobuf: %w", err)
+	}
+	return f, nil
+}
+
+func scanLink(rows *sql.Rows) (*v1.Link, error) {
+	var b []byte
+	if err := rows.Scan(&b); err != nil {
+		return nil, err
+	}
+	l := &v1.Link{}
+	if err := proto.Unmarshal(b, l); err != nil {
+		return nil, fmt.Errorf("unmarshal protobuf: %w", err)
+	}
+	return l, nil
+}
+
+// saveSQLite writes f to the SQLite feed at path (creating it if needed) in
+// one transaction, touching only the rows of links that were added,
+// changed or removed.
+func saveSQLite(path string, f *v1.Feed, opts SaveOptions, encrypt bool, compression string) error {
+	switch {
+	case storage.IsRemote(path):
+		return fmt.Errorf("save %s: SQLite feeds must be local files", path)
+	case encrypt:
+		return fmt.Errorf("save %s: SQLite feeds can't be encrypted", path)
+	case compression != CompressNone:
+		return fmt.Errorf("save %s: SQLite feeds can't be compressed", path)
+	}
+	if opts.GeneratedAt != "" {
+		f.GeneratedAt = opts.GeneratedAt
+	}
+	seen := make(map[string]bool, len(f.Links))
+	for _, l := range f.Links {
+		if seen[l.Id] {
+			return fmt.Errorf("save %s: duplicate link ID %q (SQLite feeds need unique IDs)", path, l.Id)
+		}
+		seen[l.Id] = true
+	}
+	if opts.DryRun {
+		Logger.Debug("dry run; not saving", "path", path)
+		return nil
+	}
+	if opts.Backup || opts.KeepBackups > 0 {
+		if err := backup(path, opts.KeepBackups); err != nil {
+			return fmt.Errorf("backup %s: %w", path, err)
+		}
+	}
+	start := time.Now()
+	target := path
+	if fileExists(path) && !fileSQLite(path) {
+		// Replacing a feed in another format: build the database beside
+		// it and rename it over the old file once complete.
+		target = path + ".sqlite-tmp"
+		os.Remove(target)
+		defer os.Remove(target)
+	}
+	n, err := writeSQLiteFile(target, f)
+	if err != nil {
+		return fmt.Errorf("save %s: %w", path, err)
+	}
+	if target != path {
+		if err := os.Rename(target, path); err != nil {
+			return err
+		}
+	}
+	Logger.Debug("save", "path", path, "links", len(f.Links), "rows", n, "elapsed", time.Since(start))
+	if opts.Checksum || hasChecksum(path) {
+		b, err := os.ReadFile(path)
+		if err == nil {
+			err = writeChecksum(path, b)
+		}
+		if err != nil {
+			return fmt.Errorf("write checksum: %w", err)
+		}
+	}
+	return nil
+}
+
+func writeSQLiteFile(path string, f *v1.Feed) (int, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	n, err := writeSQLite(tx, f, proto.MarshalOptions{Deterministic: true})
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+type sqliteRow struct {
+	pos  int64
+	link []byte
+}
+
+// writeSQLite brings the database in line with f and returns the number
+// of link rows written or deleted.
+func writeSQLite(tx *sql.Tx, f *v1.Feed, mo proto.MarshalOptions) (int, error) {
+	meta, err := mo.Marshal(&v1.Feed{Version: f.Version, Title: f.Title, Author: f.Author, GeneratedAt: f.GeneratedAt, Subscriptions: f.Subscriptions,
+		Description: f.Description, HomePageUrl: f.HomePageUrl, Icon: f.Icon, Lang: f.Lang, Trash: f.Trash})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO meta (key, value) VALUES ('feed', ?)`, meta); err != nil {
+		return 0, err
+	}
+
+	old := map[string]sqliteRow{}
+	rows, err := tx.Query(`SELECT id, pos, link FROM links`)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var id string
+		var r sqliteRow
+		if err := rows.Scan(&id, &r.pos, &r.link); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		old[id] = r
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	written := 0
+	// Walk from the oldest link, keeping each stored pos while it still
+	// increases, so prepending links doesn't renumber the others.
+	pos := int64(-1)
+	for _, l := range slices.Backward(f.Links) {
+		b, err := mo.Marshal(l)
+		if err != nil {
+			return 0, err
+		}
+		r, ok := old[l.Id]
+		delete(old, l.Id)
+		if ok && r.pos > pos {
+			pos = r.pos
+		} else {
+			pos++
+		}
+		if ok && r.pos == pos && bytes.Equal(r.link, b) {
+			continue
+		}
+		if err := writeLinkRow(tx, l, pos, b); err != nil {
+			return 0, err
+		}
+		written++
+	}
+	for id := range old {
+		if _, err := tx.Exec(`DELETE FROM tags WHERE link_id = ?`, id); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(`DELETE FROM links WHERE id = ?`, id); err != nil {
+			return 0, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+func writeLinkRow(tx *sql.Tx, l *v1.Link, pos int64, b []byte) error {
+	// The column holds the day, which filters compare.
+	date := ""
+	if d, err := ParseDate(l.Date); err == nil {
+		date = d.Format(DateLayout)
+	}
+	_, err := tx.Exec(`INSERT OR REPLACE INTO links (id, pos, date, rhost, via, via_host, read, starred, link)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		l.Id, pos, date, reverseHost(Host(l.Url)), l.Via, trimWWW(Host(l.Via)), l.Read, l.Starred, b)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM tags WHERE link_id = ?`, l.Id); err != nil {
+		return err
+	}
+	for _, t := range l.Tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO tags (tag, link_id) VALUES (?, ?)`, t, l.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func trimWWW(host string) string { return strings.TrimPrefix(strings.ToLower(host), "www.") }
+
+// reverseHost turns blog.example.com into com.example.blog, so a domain
+// and its subdomains form one range of the rhost index.
+func reverseHost(host string) string {
+	labels := strings.Split(trimWWW(host), ".")
+	slices.Reverse(labels)
+	return strings.Join(labels, ".")
+}
+
+// errMigrate tells Select to load the whole feed, which Migrate needs.
+var errMigrate = errors.New("feed needs migrating")
+
+// selectSQLite is Select for a SQLite feed: the indexed columns narrow
+// the rows, and flt.Match has the final say on each decoded link.
+func selectSQLite(path string, flt Filter, offset, limit int, opts LoadOptions) (*v1.Feed, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	f, err := sqliteMeta(db)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.NoMigrate && NeedsMigration(f) {
+		return nil, errMigrate
+	}
+
+	var where []string
+	var args []any
+	cond := func(c string, a ...any) {
+		where = append(where, c)
+		args = append(args, a...)
+	}
+	if !flt.After.IsZero() || !flt.Before.IsZero() {
+		cond(`date <> ''`)
+	}
+	if !flt.After.IsZero() {
+		cond(`date >= ?`, flt.After.Format(DateLayout))
+	}
+	if !flt.Before.IsZero() {
+		cond(`date <= ?`, flt.Before.Format(DateLayout))
+	}
+	if flt.ViaHost != "" {
+		cond(`via_host = ?`, trimWWW(flt.ViaHost))
+	}
+	if flt.NoVia {
+		cond(`via = ''`)
+	}
+	for _, t := range flt.Tags {
+		if ns, ok := strings.CutSuffix(t, "/*"); ok {
+			cond(`id IN (SELECT link_id FROM tags WHERE tag = ? OR substr(tag, 1, ?) = ?)`, ns, utf8.RuneCountInString(ns)+1, ns+"/")
+			continue
+		}
+		cond(`id IN (SELECT link_id FROM tags WHERE tag = ?)`, t)
+	}
+	if flt.Domain != "" {
+		d := reverseHost(flt.Domain)
+		// "." < "/": the range holds d's subdomains.
+		cond(`(rhost = ? OR (rhost > ? AND rhost < ?))`, d, d+".", d+"/")
+	}
+	if flt.Unread {
+		cond(`read = 0`)
+	}
+	if flt.Starred {
+		cond(`starred <> 0`)
+	}
+	q := `SELECT link FROM links`
+	if len(where) > 0 {
+		q += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	q += ` ORDER BY pos DESC`
+
+	start := time.Now()
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	scanned := 0
+	for rows.Next() && (limit <= 0 || len(f.Links) < limit) {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		scanned++
+		if !flt.Match(l) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		f.Links = append(f.Links, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	Logger.Debug("select", "path", path, "where", strings.Join(where, " AND "), "scanned", scanned, "links", len(f.Links), "elapsed", time.Since(start))
+	return f, nil
+}
@@ -0,0 +1,98 @@
+package feed
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// Stats summarizes a feed's links.
+type Stats struct {
+	Links int `json:"links"`
+	// First and Last are the oldest and newest link dates; Undated counts
+	// links whose date doesn't parse, which the date figures leave out.
+	First   string `json:"first,omitempty"`
+	Last    string `json:"last,omitempty"`
+	Undated int    `json:"undated,omitempty"`
+	// PerWeek averages dated links over the weeks from First to Last
+	// (at least one).
+	PerWeek float64 `json:"per_week"`
+	// WithSummary counts links with a non-empty summary; SummaryCoverage
+	// is that as a fraction of Links.
+	WithSummary     int     `json:"with_summary"`
+	SummaryCoverage float64 `json:"summary_coverage"`
+	// PerMonth covers every month from First to Last, oldest first, empty
+	// months included.
+	PerMonth   []MonthCount  `json:"per_month"`
+	TopDomains []DomainCount `json:"top_domains"`
+	TopTags    []TagCount    `json:"top_tags"`
+}
+
+// MonthCount is the number of links dated in a month (YYYY-MM).
+type MonthCount struct {
+	Month string `json:"month"`
+	Count int    `json:"count"`
+}
+
+// DomainCount is the number of links on a host, "www." dropped.
+type DomainCount struct {
+	Domain string `json:"domain"`
+	Count  int    `json:"count"`
+}
+
+// ComputeStats summarizes links, keeping the top most used domains and
+// tags (all of them if top <= 0).
+func ComputeStats(links []*v1.Link, top int) Stats {
+	s := Stats{Links: len(links), PerMonth: []MonthCount{}, TopDomains: []DomainCount{}}
+	var first, last time.Time
+	months := map[string]int{}
+	domains := map[string]int{}
+	for _, l := range links {
+		if strings.TrimSpace(l.Summary) != "" {
+			s.WithSummary++
+		}
+		if h := strings.TrimPrefix(Host(l.Url), "www."); h != "" {
+			domains[h]++
+		}
+		d, err := ParseDate(l.Date)
+		if err != nil {
+			s.Undated++
+			continue
+		}
+		if first.IsZero() || d.Before(first) {
+			first = d
+		}
+		if d.After(last) {
+			last = d
+		}
+		months[d.Format("2006-01")]++
+	}
+	if s.Links > 0 {
+		s.SummaryCoverage = float64(s.WithSummary) / float64(s.Links)
+	}
+
+	if !first.IsZero() {
+		s.First, s.Last = first.Format(DateLayout), last.Format(DateLayout)
+		weeks := max(last.Sub(first).Hours()/24/7, 1)
+		s.PerWeek = float64(s.Links-s.Undated) / weeks
+		for m := first.AddDate(0, 0, 1-first.Day()); !m.After(last); m = m.AddDate(0, 1, 0) {
+			key := m.Format("2006-01")
+			s.PerMonth = append(s.PerMonth, MonthCount{Month: key, Count: months[key]})
+		}
+	}
+
+	for _, d := range slices.Sorted(maps.Keys(domains)) {
+		s.TopDomains = append(s.TopDomains, DomainCount{Domain: d, Count: domains[d]})
+	}
+	slices.SortStableFunc(s.TopDomains, func(a, b DomainCount) int { return cmp.Compare(b.Count, a.Count) })
+	s.TopTags = CountTags(&v1.Feed{Links: links})
+	if top > 0 {
+		s.TopDomains = s.TopDomains[:min(top, len(s.TopDomains))]
+		s.TopTags = s.TopTags[:min(top, len(s.TopTags))]
+	}
+	return s
+}
@@ -0,0 +1,405 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// -------- storage (.pb, .pbs, compressed, encrypted, SQLite, sharded, remote) --------
+
+// Logger receives debug events from Load and Save (paths, byte counts,
+// timings). It discards everything unless replaced.
+var Logger = slog.New(slog.DiscardHandler)
+
+// LoadOptions tune how Load reads a feed file.
+type LoadOptions struct {
+	// NoMigrate returns the feed as stored instead of upgrading it to
+	// CurrentVersion (see Migrate).
+	NoMigrate bool
+	// Verify checks the file against its ChecksumSuffix sidecar and fails
+	// with ErrChecksumMismatch if they differ (or if there's no sidecar).
+	Verify bool
+	// Key is the passphrase for encrypted feed files (see Encrypt). Loading
+	// an encrypted file without it fails with ErrEncrypted.
+	Key []byte
+}
+
+// Load reads a binary protobuf feed from path (see ExpandPath), which may
+// also be a remote URL (see package storage), and migrates
+// it to CurrentVersion. A missing file is reported as os.ErrNotExist so
+// callers can decide whether to start a new feed.
+func Load(path string) (*v1.Feed, error) { return LoadWith(path, LoadOptions{}) }
+
+// LoadWith is Load with options.
+func LoadWith(path string, opts LoadOptions) (*v1.Feed, error) {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	if fileSharded(path) {
+		return loadSharded(path, opts, nil)
+	}
+	if fileSQLite(path) {
+		f, err := loadSQLite(path, opts)
+		if err != nil {
+			return nil, err
+		}
+		Logger.Debug("load", "path", path, "links", len(f.Links), "elapsed", time.Since(start))
+		return migrated(f, opts)
+	}
+	b, err := readFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, os.ErrNotExist
+		}
+		return nil, err
+	}
+	if opts.Verify {
+		if err := verifyChecksum(path, b); err != nil {
+			return nil, err
+		}
+	}
+	raw, encrypted := b, IsEncrypted(b)
+	if encrypted {
+		if b, err = Decrypt(b, opts.Key); err != nil {
+			return nil, err
+		}
+	}
+	if b, err = decompress(b); err != nil {
+		return nil, fmt.Errorf("decompress: %w", err)
+	}
+	f := &v1.Feed{}
+	if IsStream(b) {
+		f, err = unmarshalStream(b)
+	} else {
+		err = proto.Unmarshal(b, f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal protobuf: %w", err)
+	}
+	if !encrypted && !IsStream(b) {
+		if err := replayWAL(path, raw, f); err != nil {
+			return nil, err
+		}
+	}
+	Logger.Debug("load", "path", path, "bytes", len(b), "links", len(f.Links), "elapsed", time.Since(start))
+	return migrated(f, opts)
+}
+
+func migrated(f *v1.Feed, opts LoadOptions) (*v1.Feed, error) {
+	if !opts.NoMigrate {
+		if err := Migrate(f); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Select returns the feed at path (see LoadWith) holding only the links
+// matching flt, after skipping offset of them and keeping at most limit
+// (limit <= 0: all). SQLite feeds answer with an indexed query, sharded
+// feeds read only the years flt's dates allow, and stream feeds read only
+// the newest links when flt is the zero Filter (see Tail); other files are
+// loaded whole.
+func Select(path string, flt Filter, offset, limit int, opts LoadOptions) (*v1.Feed, error) {
+	expanded, err := ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.Verify && fileSQLite(expanded) {
+		f, err := selectSQLite(expanded, flt, offset, limit, opts)
+		if !errors.Is(err, errMigrate) {
+			return f, err
+		}
+	}
+	f, ok, err := (*v1.Feed)(nil), false, error(nil)
+	if fileSharded(expanded) {
+		f, ok, err = selectSharded(expanded, flt, opts)
+	}
+	if !ok && flt.zero() {
+		return Tail(path, offset, limit, opts)
+	}
+	if !ok {
+		f, err = LoadWith(path, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.Links = flt.Apply(f.Links)
+	f.Links = f.Links[min(offset, len(f.Links)):]
+	if limit > 0 && limit < len(f.Links) {
+		f.Links = f.Links[:limit]
+	}
+	return f, nil
+}
+
+// SaveOptions tune how Save replaces an existing feed file.
+type SaveOptions struct {
+	// Backup copies the current file to path+".bak" before it is replaced.
+	Backup bool
+	// KeepBackups > 0 keeps that many rotated copies instead
+	// (path.1 newest … path.N oldest); it implies Backup.
+	KeepBackups int
+
+	// Deterministic marshals with stable map ordering so equal feeds
+	// produce equal bytes (see proto.MarshalOptions.Deterministic).
+	Deterministic bool
+	// Canonical marshals the canonical form of f (see Canonicalize); it
+	// implies Deterministic.
+	Canonical bool
+	// SortByID writes links ordered by ID instead of feed order, making
+	// the output independent of the order links were added.
+	SortByID bool
+	// GeneratedAt, if set, is stored as Feed.GeneratedAt instead of the
+	// feed's own value, e.g. to keep the timestamp of the loaded file.
+	GeneratedAt string
+
+	// Checksum writes a ChecksumSuffix sidecar next to the file. A sidecar
+	// that already exists is always kept up to date, even without it.
+	Checksum bool
+
+	// Key is the passphrase used to encrypt the file. With Encrypt the
+	// file is always written encrypted; otherwise only when the file being
+	// replaced already is, so encrypted feeds stay encrypted.
+	Key     []byte
+	Encrypt bool
+
+	// DryRun marshals f (so encoding errors still surface) but writes
+	// nothing: no backup, no feed file.
+	DryRun bool
+
+	// Format is FormatProto (one Feed message), FormatStream (see
+	// StreamMagic), FormatSQLite (see IsSQLiteFile) or FormatSharded.
+	// Empty keeps the format of the file being replaced; a new file is a
+	// stream if its name ends in StreamExt, a SQLite database if it ends
+	// in SQLiteExt, and a sharded feed if it ends in a slash.
+	Format string
+	// Appended says the first Appended links of f are new since the file
+	// was read and nothing else but GeneratedAt changed. A local stream
+	// file then gets just those links appended instead of a rewrite.
+	Appended int
+	// Compression is CompressNone, CompressGzip or CompressZstd. Empty
+	// keeps the compression of the file being replaced; a new file is
+	// compressed if its name ends in .gz or .zst.
+	Compression string
+	// WAL appends the change from Before to f to the file's write-ahead
+	// log (see WALSuffix) instead of rewriting it, when the file is a
+	// local, unencrypted protobuf file, no other option needs a rewrite
+	// and the change only adds links at the front, edits or removes
+	// links. Otherwise the file is saved in full, folding in the log.
+	WAL    bool
+	Before *v1.Feed
+}
+
+// Save marshals f and atomically replaces the file at path (see ExpandPath).
+func Save(path string, f *v1.Feed) error { return SaveWith(path, f, SaveOptions{}) }
+
+// SaveWith is Save with options. Backups are taken only after f has been
+// marshaled, so a failed marshal never clobbers an earlier backup.
+func SaveWith(path string, f *v1.Feed, opts SaveOptions) error {
+	dir := strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator))
+	path, err := ExpandPath(path)
+	if err != nil {
+		return err
+	}
+	if opts.Format == "" && dir && !fileExists(path) {
+		opts.Format = FormatSharded
+	}
+	format, err := saveFormat(path, opts)
+	if err != nil {
+		return err
+	}
+	stream := format == FormatStream
+	compression, err := compressionFormat(path, opts)
+	if err != nil {
+		return err
+	}
+	encrypt := opts.Encrypt || fileEncrypted(path)
+	if format == FormatSQLite {
+		return saveSQLite(path, f, opts, encrypt, compression)
+	}
+	if format == FormatSharded {
+		return saveSharded(path, f, opts, encrypt, compression)
+	}
+	if stream && encrypt {
+		return fmt.Errorf("save %s: stream files can't be encrypted", path)
+	}
+	if stream && compression != CompressNone {
+		return fmt.Errorf("save %s: stream files can't be compressed", path)
+	}
+	if opts.WAL && !stream {
+		if ok, err := appendWAL(path, f, opts); ok || err != nil {
+			return err
+		}
+	}
+	if stream && canAppend(path, opts) {
+		if opts.GeneratedAt != "" {
+			f.GeneratedAt = opts.GeneratedAt
+		}
+		if opts.DryRun {
+			Logger.Debug("dry run; not saving", "path", path)
+			return nil
+		}
+		return appendStream(path, f, opts.Appended, proto.MarshalOptions{Deterministic: opts.Deterministic})
+	}
+	start := time.Now()
+	b, err := marshal(f, stream, opts)
+	if err != nil {
+		return fmt.Errorf("marshal protobuf: %w", err)
+	}
+	Logger.Debug("marshal", "bytes", len(b), "links", len(f.Links), "elapsed", time.Since(start))
+	if compression != CompressNone {
+		if b, err = compress(b, compression); err != nil {
+			return fmt.Errorf("compress: %w", err)
+		}
+		Logger.Debug("compress", "compression", compression, "bytes", len(b))
+	}
+	if encrypt {
+		if len(opts.Key) == 0 {
+			return fmt.Errorf("save %s: %w", path, ErrEncrypted)
+		}
+		if b, err = Encrypt(b, opts.Key); err != nil {
+			return fmt.Errorf("encrypt: %w", err)
+		}
+	}
+	if opts.DryRun {
+		Logger.Debug("dry run; not saving", "path", path)
+		return nil
+	}
+	if err := writeFeedFile(path, b, opts); err != nil {
+		return err
+	}
+	return dropWAL(path)
+}
+
+// writeFeedFile replaces the file at path with the marshaled feed b,
+// taking the backups and writing the checksum opts ask for.
+func writeFeedFile(path string, b []byte, opts SaveOptions) error {
+	if opts.Backup || opts.KeepBackups > 0 {
+		if err := backup(path, opts.KeepBackups); err != nil {
+			return fmt.Errorf("backup %s: %w", path, err)
+		}
+	}
+	start := time.Now()
+	if err := writeFile(path, b); err != nil {
+		return err
+	}
+	Logger.Debug("save", "path", path, "bytes", len(b), "elapsed", time.Since(start))
+	if opts.Checksum || hasChecksum(path) {
+		if err := writeChecksum(path, b); err != nil {
+			return fmt.Errorf("write checksum: %w", err)
+		}
+	}
+	return nil
+}
+
+// saveFormat decides how SaveWith writes path: as opts.Format says, else
+// like the existing file, else by extension.
+func saveFormat(path string, opts SaveOptions) (string, error) {
+	switch opts.Format {
+	case FormatProto, FormatStream, FormatSQLite, FormatSharded:
+		return opts.Format, nil
+	case "":
+		switch {
+		case fileSharded(path):
+			return FormatSharded, nil
+		case fileStream(path):
+			return FormatStream, nil
+		case fileSQLite(path):
+			return FormatSQLite, nil
+		case fileExists(path):
+			return FormatProto, nil
+		case strings.HasSuffix(path, StreamExt):
+			return FormatStream, nil
+		case strings.HasSuffix(path, SQLiteExt):
+			return FormatSQLite, nil
+		}
+		return FormatProto, nil
+	}
+	return "", fmt.Errorf("unknown feed format %q (want %s, %s, %s or %s)", opts.Format, FormatProto, FormatStream, FormatSQLite, FormatSharded)
+}
+
+func marshal(f *v1.Feed, stream bool, opts SaveOptions) ([]byte, error) {
+	if opts.GeneratedAt != "" {
+		f.GeneratedAt = opts.GeneratedAt
+	}
+	if opts.SortByID {
+		links := f.Links
+		sorted := slices.Clone(links)
+		slices.SortStableFunc(sorted, func(a, b *v1.Link) int { return strings.Compare(a.Id, b.Id) })
+		f.Links = sorted
+		defer func() { f.Links = links }()
+	}
+	mo := proto.MarshalOptions{Deterministic: opts.Deterministic || opts.Canonical}
+	if opts.Canonical {
+		f = Canonicalize(f)
+	}
+	if stream {
+		return marshalStream(f, mo)
+	}
+	return mo.Marshal(f)
+}
+
+// backup copies path to path.bak (keep == 0) or rotates path.1 … path.keep
+// and copies path to path.1. A missing path is not an error. Remote files
+// are rotated by copying, since object stores have no rename.
+func backup(path string, keep int) error {
+	b, err := readFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if keep <= 0 {
+		return writeFile(path+".bak", b)
+	}
+	rename := os.Rename
+	if storage.IsRemote(path) {
+		rename = copyFile
+	}
+	for i := keep - 1; i >= 1; i-- {
+		err := rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return writeFile(path+".1", b)
+}
+
+// WriteFileAtomic writes data to a temp file in the target directory and
+// renames it over path, so readers never observe a partially written file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return storage.WriteFileAtomic(path, data, perm)
+}
+
+// readFile and writeFile reach local paths and remote URLs alike (see
+// package storage).
+func readFile(path string) ([]byte, error) {
+	return storage.Get(context.Background(), path)
+}
+
+func writeFile(path string, data []byte) error {
+	return storage.Put(context.Background(), path, data, 0o644)
+}
+
+// copyFile copies src to dst; a missing src is reported as os.ErrNotExist.
+func copyFile(src, dst string) error {
+	b, err := readFile(src)
+	if err != nil {
+		return err
+	}
+	return writeFile(dst, b)
+}
@@ -0,0 +1,250 @@
+package feed
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// StreamMagic starts a stream feed file: an append-friendly alternative
+// to a single Feed message for large feeds.
+//
+// Layout: magic | records. Each record is a Feed message prefixed with its
+// length as a uvarint (the protodelim framing). The first record holds
+// the feed's version, title and generated_at; every later record either
+// holds exactly one link or only a new generated_at. Links are stored
+// oldest first, so adding one appends a record instead of rewriting the
+// file. Reading merges the records in order (as protobuf does for
+// concatenated messages) and reverses the links into feed order.
+const StreamMagic = "LINKLEAF-STREAM1\n"
+
+// StreamExt marks a path that should be written as a stream file.
+const StreamExt = ".pbs"
+
+// Format values for SaveOptions.Format.
+const (
+	FormatProto  = "pb"
+	FormatStream = "stream"
+	FormatSQLite = "sqlite"
+)
+
+// maxRecord bounds one stream record, so a corrupt length can't make the
+// reader allocate gigabytes.
+const maxRecord = 64 << 20
+
+// IsStream reports whether b is a stream file.
+func IsStream(b []byte) bool { return bytes.HasPrefix(b, []byte(StreamMagic)) }
+
+// IsStreamFile reports whether the file at path is a stream file.
+func IsStreamFile(path string) bool {
+	path, err := ExpandPath(path)
+	return err == nil && fileStream(path)
+}
+
+func fileStream(path string) bool {
+	if storage.IsRemote(path) {
+		b, err := readFile(path)
+		return err == nil && IsStream(b)
+	}
+	fh, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer fh.Close()
+	head := make([]byte, len(StreamMagic))
+	_, err = io.ReadFull(fh, head)
+	return err == nil && IsStream(head)
+}
+
+// canAppend reports whether SaveWith may append to the stream file at
+// path instead of rewriting it: a local stream, no backups or checksum to
+// keep current, and links written as they are, in feed order.
+func canAppend(path string, opts SaveOptions) bool {
+	return opts.Appended > 0 && opts.Format != FormatProto && !opts.Backup && opts.KeepBackups == 0 &&
+		!opts.SortByID && !opts.Canonical && !opts.Checksum && !storage.IsRemote(path) && fileStream(path) && !hasChecksum(path)
+}
+
+func fileExists(path string) bool {
+	if storage.IsRemote(path) {
+		_, err := readFile(path)
+		return err == nil
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func appendRecord(b []byte, m *v1.Feed, opts proto.MarshalOptions) ([]byte, error) {
+	rec, err := opts.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	b = protowire.AppendVarint(b, uint64(len(rec)))
+	return append(b, rec...), nil
+}
+
+// marshalStream encodes f as a stream file (see StreamMagic).
+func marshalStream(f *v1.Feed, opts proto.MarshalOptions) ([]byte, error) {
+	b, err := appendRecord([]byte(StreamMagic), &v1.Feed{Version: f.Version, Title: f.Title, Author: f.Author, GeneratedAt: f.GeneratedAt, Subscriptions: f.Subscriptions,
+		Description: f.Description, HomePageUrl: f.HomePageUrl, Icon: f.Icon, Lang: f.Lang, Trash: f.Trash}, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range slices.Backward(f.Links) {
+		if b, err = appendRecord(b, &v1.Feed{Links: []*v1.Link{l}}, opts); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// readRecords calls fn with every record of the stream in r, which
+// starts after the magic.
+func readRecords(r *bufio.Reader, fn func(rec []byte) error) error {
+	for {
+		n, err := binary.ReadUvarint(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("stream record length: %w", err)
+		}
+		if n > maxRecord {
+			return fmt.Errorf("stream record of %d bytes: corrupt file?", n)
+		}
+		rec := make([]byte, n)
+		if _, err := io.ReadFull(r, rec); err != nil {
+			return fmt.Errorf("truncated stream record: %w", err)
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// unmarshalStream decodes a stream file (see StreamMagic).
+func unmarshalStream(b []byte) (*v1.Feed, error) {
+	var f v1.Feed
+	r := bufio.NewReader(bytes.NewReader(b[len(StreamMagic):]))
+	err := readRecords(r, func(rec []byte) error {
+		return proto.UnmarshalOptions{Merge: true}.Unmarshal(rec, &f)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(f.Links)
+	return &f, nil
+}
+
+// appendStream adds f.Links[:n], the links new since the file was read,
+// and f's generated_at to the local stream file at path with a single
+// write.
+func appendStream(path string, f *v1.Feed, n int, opts proto.MarshalOptions) error {
+	b, err := appendRecord(nil, &v1.Feed{GeneratedAt: f.GeneratedAt}, opts)
+	if err != nil {
+		return fmt.Errorf("marshal protobuf: %w", err)
+	}
+	for _, l := range slices.Backward(f.Links[:n]) {
+		if b, err = appendRecord(b, &v1.Feed{Links: []*v1.Link{l}}, opts); err != nil {
+			return fmt.Errorf("marshal protobuf: %w", err)
+		}
+	}
+	fh, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	_, err = fh.Write(b)
+	if err == nil {
+		err = fh.Sync()
+	}
+	if cerr := fh.Close(); err == nil {
+		err = cerr
+	}
+	Logger.Debug("append", "path", path, "bytes", len(b), "links", n)
+	return err
+}
+
+// Tail returns the feed at path with only the newest links: limit links
+// after skipping offset (limit <= 0: all). A local stream file is read
+// record by record, decoding only the links returned; anything else, or a
+// stream that needs migrating or verifying, is loaded whole.
+func Tail(path string, offset, limit int, opts LoadOptions) (*v1.Feed, error) {
+	full := func() (*v1.Feed, error) {
+		f, err := LoadWith(path, opts)
+		if err != nil {
+			return nil, err
+		}
+		f.Links = f.Links[min(offset, len(f.Links)):]
+		if limit > 0 && limit < len(f.Links) {
+			f.Links = f.Links[:limit]
+		}
+		return f, nil
+	}
+	expanded, err := ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || opts.Verify || storage.IsRemote(expanded) || !fileStream(expanded) {
+		return full()
+	}
+	fh, err := os.Open(expanded)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	r := bufio.NewReader(fh)
+	if _, err := r.Discard(len(StreamMagic)); err != nil {
+		return nil, err
+	}
+
+	// Keep the raw records of the newest offset+limit links in a ring.
+	var meta v1.Feed
+	keep := offset + limit
+	ring := make([][]byte, 0, keep)
+	next, total := 0, 0
+	err = readRecords(r, func(rec []byte) error {
+		if !linkRecord(rec) {
+			return proto.UnmarshalOptions{Merge: true}.Unmarshal(rec, &meta)
+		}
+		total++
+		if len(ring) < keep {
+			ring = append(ring, rec)
+		} else {
+			ring[next] = rec
+			next = (next + 1) % keep
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !opts.NoMigrate && NeedsMigration(&meta) {
+		return full()
+	}
+	ordered := append(ring[next:len(ring):len(ring)], ring[:next]...)
+	slices.Reverse(ordered) // newest first
+	ordered = ordered[min(offset, len(ordered)):]
+	for _, rec := range ordered {
+		if err := (proto.UnmarshalOptions{Merge: true}).Unmarshal(rec, &meta); err != nil {
+			return nil, fmt.Errorf("unmarshal protobuf: %w", err)
+		}
+	}
+	Logger.Debug("tail", "path", expanded, "links", total, "decoded", len(meta.Links))
+	return &meta, nil
+}
+
+// linkRecord reports whether a stream record carries a link (Feed field
+// 4) rather than feed metadata.
+func linkRecord(rec []byte) bool {
+	num, _, n := protowire.ConsumeTag(rec)
+	return n > 0 && num == 4
+}
@@ -0,0 +1,123 @@
+package feed
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func streamFeed(n int) *v1.Feed {
+	f := New("Links", CurrentVersion)
+	f.GeneratedAt = "2024-05-01T00:00:00Z"
+	for i := n; i > 0; i-- {
+		f.Links = append(f.Links, &v1.Link{Id: fmt.Sprint(i), Url: fmt.Sprintf("https://example.com/%d", i), Date: "2024-05-01"})
+	}
+	return f
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	full := streamFeed(3)
+	full.Author, full.Description, full.HomePageUrl, full.Icon, full.Lang = "Ann", "About", "https://example.com", "https://example.com/i.png", "en"
+	full.Subscriptions = []*v1.Subscription{{Url: "https://example.org/feed.xml", Seen: []string{"a"}}}
+	full.Trash = []*v1.TrashedLink{{Link: &v1.Link{Id: "x"}, RemovedAt: "2024-05-02T00:00:00Z", Index: 1}}
+	tests := []struct {
+		name string
+		f    *v1.Feed
+	}{
+		{"empty", New("", CurrentVersion)},
+		{"links", streamFeed(5)},
+		{"every feed field", full},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := marshalStream(tt.f, proto.MarshalOptions{Deterministic: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !IsStream(b) {
+				t.Fatalf("marshalStream output lacks the magic: %q", b[:min(len(b), 20)])
+			}
+			got, err := unmarshalStream(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(got, tt.f) {
+				t.Errorf("unmarshalStream(marshalStream(f)) =\n%v\nwant\n%v", got, tt.f)
+			}
+		})
+	}
+}
+
+func TestStreamAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed"+StreamExt)
+	f := streamFeed(3)
+	if err := Save(path, f); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Links = append([]*v1.Link{{Id: "5", Url: "https://example.com/5"}, {Id: "4", Url: "https://example.com/4"}}, f.Links...)
+	f.GeneratedAt = "2024-05-02T00:00:00Z"
+	if err := SaveWith(path, f, SaveOptions{Appended: 2}); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(after, before) {
+		t.Errorf("saving two new links rewrote the stream instead of appending")
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(got, f) {
+		t.Errorf("Load after appending =\n%v\nwant\n%v", got, f)
+	}
+
+	for _, tt := range []struct{ offset, limit int }{{0, 1}, {0, 2}, {1, 2}, {3, 10}, {6, 1}} {
+		t.Run(fmt.Sprintf("Tail %d,%d", tt.offset, tt.limit), func(t *testing.T) {
+			got, err := Tail(path, tt.offset, tt.limit, LoadOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := proto.Clone(f).(*v1.Feed)
+			want.Links = want.Links[min(tt.offset, len(want.Links)):]
+			want.Links = want.Links[:min(tt.limit, len(want.Links))]
+			if !proto.Equal(got, want) {
+				t.Errorf("Tail =\n%v\nwant\n%v", got, want)
+			}
+		})
+	}
+}
+
+func TestStreamCorrupt(t *testing.T) {
+	good, err := marshalStream(streamFeed(2), proto.MarshalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"truncated record", good[:len(good)-1]},
+		{"oversized record", protowire.AppendVarint([]byte(StreamMagic), maxRecord+1)},
+		{"bad record", append(protowire.AppendVarint([]byte(StreamMagic), 2), 0xff, 0xff)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if f, err := unmarshalStream(tt.b); err == nil {
+				t.Errorf("unmarshalStream = %v, want an error", f)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package feed
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Strategy decides what ThreeWayMerge does with a link both sides changed.
+type Strategy string
+
+const (
+	// StrategyUnion applies every non-conflicting change from both sides
+	// and keeps a link one side deleted if the other modified it; links
+	// modified differently on both sides are left unresolved.
+	StrategyUnion Strategy = "union"
+	// StrategyOurs resolves conflicts with our side.
+	StrategyOurs Strategy = "ours"
+	// StrategyTheirs resolves conflicts with their side.
+	StrategyTheirs Strategy = "theirs"
+)
+
+// Strategies lists the valid strategies.
+func Strategies() []string {
+	return []string{string(StrategyUnion), string(StrategyOurs), string(StrategyTheirs)}
+}
+
+// Conflict is a link both sides changed since the base, differently. A
+// nil side means that side deleted it.
+type Conflict struct {
+	ID                 string
+	Base, Ours, Theirs *v1.Link
+	// Resolved is true when the strategy picked a side.
+	Resolved bool
+}
+
+// ThreeWayMerge combines ours and theirs, two descendants of base, link by
+// link (keyed by ID): a change made on one side only is taken as is, and
+// conflicting changes are settled by s or reported unresolved. The result
+// keeps our order, with links only they added first (in their order);
+// unresolved conflicts keep our version. The feed's own fields, its
+// subscriptions and its trash are merged the same way (see mergeField and
+// mergeKeyed). Inputs are not modified.
+func ThreeWayMerge(base, ours, theirs *v1.Feed, s Strategy) (*v1.Feed, []Conflict, error) {
+	if !slices.Contains(Strategies(), string(s)) {
+		return nil, nil, fmt.Errorf("unknown merge strategy %q (want one of %v)", s, Strategies())
+	}
+	index := func(f *v1.Feed) map[string]*v1.Link {
+		m := make(map[string]*v1.Link, len(f.Links))
+		for _, l := range f.Links {
+			m[l.Id] = l
+		}
+		return m
+	}
+	b, o, t := index(base), index(ours), index(theirs)
+
+	var conflicts []Conflict
+	// pick returns the merged link for id, or nil if it ends up deleted.
+	pick := func(id string) *v1.Link {
+		bl, ol, tl := b[id], o[id], t[id]
+		switch {
+		case sameLink(ol, tl), sameLink(bl, tl):
+			return ol
+		case sameLink(bl, ol):
+			return tl
+		}
+		c := Conflict{ID: id, Base: bl, Ours: ol, Theirs: tl, Resolved: s != StrategyUnion}
+		switch {
+		case s == StrategyOurs:
+			conflicts = append(conflicts, c)
+			return ol
+		case s == StrategyTheirs:
+			conflicts = append(conflicts, c)
+			return tl
+		case ol == nil || tl == nil:
+			// Deleted on one side, modified on the other: keep it.
+			c.Resolved = true
+			conflicts = append(conflicts, c)
+			if ol == nil {
+				return tl
+			}
+			return ol
+		}
+		conflicts = append(conflicts, c)
+		return ol
+	}
+
+	// Feed-level fields take the change of whichever side made one, and
+	// ours (theirs with StrategyTheirs) when both did.
+	out := &v1.Feed{
+		Version:       max(ours.Version, theirs.Version),
+		GeneratedAt:   ours.GeneratedAt,
+		Title:         mergeField(base.Title, ours.Title, theirs.Title, s),
+		Author:        mergeField(base.Author, ours.Author, theirs.Author, s),
+		Description:   mergeField(base.Description, ours.Description, theirs.Description, s),
+		HomePageUrl:   mergeField(base.HomePageUrl, ours.HomePageUrl, theirs.HomePageUrl, s),
+		Icon:          mergeField(base.Icon, ours.Icon, theirs.Icon, s),
+		Lang:          mergeField(base.Lang, ours.Lang, theirs.Lang, s),
+		Subscriptions: mergeKeyed(base.Subscriptions, ours.Subscriptions, theirs.Subscriptions, (*v1.Subscription).GetUrl, s),
+		Trash:         mergeKeyed(base.Trash, ours.Trash, theirs.Trash, trashKey, s),
+	}
+	// Newest first, as Trash keeps it.
+	slices.SortStableFunc(out.Trash, func(a, b *v1.TrashedLink) int { return strings.Compare(b.RemovedAt, a.RemovedAt) })
+	for _, l := range theirs.Links {
+		if o[l.Id] == nil && b[l.Id] == nil {
+			if m := pick(l.Id); m != nil {
+				out.Links = append(out.Links, proto.Clone(m).(*v1.Link))
+			}
+		}
+	}
+	for _, l := range ours.Links {
+		if m := pick(l.Id); m != nil {
+			out.Links = append(out.Links, proto.Clone(m).(*v1.Link))
+		}
+	}
+	// Links we deleted that they modified (and union keeps) come back at
+	// the end, since our order has no place for them.
+	for _, l := range theirs.Links {
+		if o[l.Id] == nil && b[l.Id] != nil {
+			if m := pick(l.Id); m != nil {
+				out.Links = append(out.Links, proto.Clone(m).(*v1.Link))
+			}
+		}
+	}
+	return out, conflicts, nil
+}
+
+// mergeField returns the value of a feed-level field after ours and
+// theirs each changed it from base or not.
+func mergeField[T comparable](base, ours, theirs T, s Strategy) T {
+	if ours == base || s == StrategyTheirs && theirs != base {
+		return theirs
+	}
+	return ours
+}
+
+// mergeKeyed merges lists of messages identified by key the way
+// ThreeWayMerge merges links, without reporting conflicts: one side's
+// change (an addition, a removal, an edit) is taken, and when both
+// changed an entry differently ours wins, or theirs with StrategyTheirs;
+// an entry one side removed and the other edited stays, unless the
+// strategy picks the side that removed it. The result keeps our order,
+// then theirs for entries we lack.
+func mergeKeyed[M proto.Message](base, ours, theirs []M, key func(M) string, s Strategy) []M {
+	index := func(ms []M) map[string]M {
+		m := make(map[string]M, len(ms))
+		for _, x := range ms {
+			m[key(x)] = x
+		}
+		return m
+	}
+	b, o, t := index(base), index(ours), index(theirs)
+	same := func(x, y M, xok, yok bool) bool {
+		return xok == yok && (!xok || proto.Equal(x, y))
+	}
+	pick := func(k string) (M, bool) {
+		bm, bok := b[k]
+		om, ook := o[k]
+		tm, tok := t[k]
+		switch {
+		case same(om, tm, ook, tok), same(bm, tm, bok, tok):
+			return om, ook
+		case same(bm, om, bok, ook):
+			return tm, tok
+		case s == StrategyOurs:
+			return om, ook
+		case s == StrategyTheirs, !ook:
+			return tm, tok
+		}
+		return om, ook
+	}
+	var out []M
+	for _, x := range ours {
+		if m, ok := pick(key(x)); ok {
+			out = append(out, proto.Clone(m).(M))
+		}
+	}
+	for _, x := range theirs {
+		if _, ok := o[key(x)]; !ok {
+			if m, ok := pick(key(x)); ok {
+				out = append(out, proto.Clone(m).(M))
+			}
+		}
+	}
+	return out
+}
+
+// trashKey tells trash entries apart: the same link may be removed, restored
+// and removed again.
+func trashKey(t *v1.TrashedLink) string {
+	return t.GetLink().GetId() + "\x00" + t.RemovedAt
+}
+
+// SameMeta reports whether a and b agree on everything but their links
+// and generated_at.
+func SameMeta(a, b *v1.Feed) bool {
+	strip := func(f *v1.Feed) *v1.Feed {
+		links := f.Links
+		f.Links = nil
+		c := proto.Clone(f).(*v1.Feed)
+		f.Links = links
+		c.GeneratedAt = ""
+		return c
+	}
+	return proto.Equal(strip(a), strip(b))
+}
+
+func sameLink(a, b *v1.Link) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return proto.Equal(a, b)
+}
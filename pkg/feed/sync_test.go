@@ -0,0 +1,97 @@
+package feed
+
+import (
+	"testing"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestThreeWayMergeFeedFields(t *testing.T) {
+	link := func(id string) *v1.Link {
+		return &v1.Link{Id: id, Url: "https://example.com/" + id, Date: "2024-05-01"}
+	}
+	sub := func(url, pulled string) *v1.Subscription {
+		return &v1.Subscription{Url: url, AddedAt: "2024-05-01T00:00:00Z", PulledAt: pulled}
+	}
+	trashed := func(id, at string) *v1.TrashedLink { return &v1.TrashedLink{Link: link(id), RemovedAt: at} }
+	base := &v1.Feed{
+		Title:         "Links",
+		Author:        "Ann",
+		Description:   "old",
+		HomePageUrl:   "https://example.com",
+		Icon:          "https://example.com/icon.png",
+		Lang:          "en",
+		Links:         []*v1.Link{link("a")},
+		Subscriptions: []*v1.Subscription{sub("https://a.example/feed", ""), sub("https://gone.example/feed", "")},
+		Trash:         []*v1.TrashedLink{trashed("t", "2024-05-01T00:00:00Z")},
+	}
+
+	tests := []struct {
+		name         string
+		ours, theirs func(f *v1.Feed)
+		s            Strategy
+		want         func(f *v1.Feed)
+	}{
+		{
+			name: "unchanged",
+			s:    StrategyUnion,
+		},
+		{
+			name: "each side changes its own fields",
+			ours: func(f *v1.Feed) {
+				f.Description, f.Icon = "new", ""
+				f.Subscriptions = f.Subscriptions[:1] // unsubscribed
+				f.Trash = append([]*v1.TrashedLink{trashed("a", "2024-05-03T00:00:00Z")}, f.Trash...)
+				f.Links = nil
+			},
+			theirs: func(f *v1.Feed) {
+				f.Author, f.Lang, f.HomePageUrl = "Bob", "de", "https://example.org"
+				f.Subscriptions[0].PulledAt = "2024-05-02T00:00:00Z"
+				f.Subscriptions = append(f.Subscriptions, sub("https://b.example/feed", ""))
+				f.Trash = append([]*v1.TrashedLink{trashed("b", "2024-05-02T00:00:00Z")}, f.Trash...)
+			},
+			s: StrategyUnion,
+			want: func(f *v1.Feed) {
+				f.Description, f.Icon = "new", ""
+				f.Author, f.Lang, f.HomePageUrl = "Bob", "de", "https://example.org"
+				f.Subscriptions = []*v1.Subscription{sub("https://a.example/feed", "2024-05-02T00:00:00Z"), sub("https://b.example/feed", "")}
+				f.Trash = []*v1.TrashedLink{trashed("a", "2024-05-03T00:00:00Z"), trashed("b", "2024-05-02T00:00:00Z"), trashed("t", "2024-05-01T00:00:00Z")}
+				f.Links = nil
+			},
+		},
+		{
+			name:   "both change a field, union keeps ours",
+			ours:   func(f *v1.Feed) { f.Description = "ours" },
+			theirs: func(f *v1.Feed) { f.Description = "theirs"; f.Trash = nil },
+			s:      StrategyUnion,
+			want:   func(f *v1.Feed) { f.Description = "ours"; f.Trash = nil },
+		},
+		{
+			name:   "both change a field, theirs wins with StrategyTheirs",
+			ours:   func(f *v1.Feed) { f.Description = "ours"; f.Subscriptions[0].PulledAt = "2024-05-02T00:00:00Z" },
+			theirs: func(f *v1.Feed) { f.Description = "theirs"; f.Subscriptions[0].PulledAt = "2024-05-03T00:00:00Z" },
+			s:      StrategyTheirs,
+			want:   func(f *v1.Feed) { f.Description = "theirs"; f.Subscriptions[0].PulledAt = "2024-05-03T00:00:00Z" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			side := func(change func(*v1.Feed)) *v1.Feed {
+				f := proto.Clone(base).(*v1.Feed)
+				if change != nil {
+					change(f)
+				}
+				return f
+			}
+			ours, theirs, want := side(tt.ours), side(tt.theirs), side(tt.want)
+			got, _, err := ThreeWayMerge(base, ours, theirs, tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !SameMeta(got, want) {
+				t.Errorf("merged feed fields differ:\ngot  %v\nwant %v", got, want)
+			}
+		})
+	}
+}
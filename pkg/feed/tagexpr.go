@@ -0,0 +1,149 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TagMatches reports whether tag matches pattern, ignoring case. Tags may
+// be namespaced with '/' ("lang/go"); a pattern ending in "/*" matches
+// that namespace and everything below it ("lang/*" matches "lang",
+// "lang/go" and "lang/go/generics").
+func TagMatches(pattern, tag string) bool {
+	if ns, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.EqualFold(tag, ns) ||
+			len(tag) > len(ns) && tag[len(ns)] == '/' && strings.EqualFold(tag[:len(ns)], ns)
+	}
+	return strings.EqualFold(tag, pattern)
+}
+
+// TagExpr is a boolean expression over a link's tags (see ParseTagExpr).
+type TagExpr struct {
+	op   string // "tag", "AND", "OR" or "NOT"
+	tag  string // pattern, for "tag"
+	args []*TagExpr
+}
+
+// ParseTagExpr parses expressions like "lang/go AND NOT topic/orm" or
+// "(go OR rust) AND lang/*". Operators are NOT, AND and OR (upper case, in
+// that order of precedence) and parentheses; terms next to each other are
+// AND-ed. Terms are tag patterns as TagMatches takes them.
+func ParseTagExpr(s string) (*TagExpr, error) {
+	p := &tagParser{toks: strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s))}
+	if len(p.toks) == 0 {
+		return nil, errors.New("tag expression: empty")
+	}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("tag expression: unexpected %q", p.toks[p.pos])
+	}
+	return e, nil
+}
+
+type tagParser struct {
+	toks []string
+	pos  int
+}
+
+func (p *tagParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *tagParser) or() (*TagExpr, error) {
+	e, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "OR" {
+		p.pos++
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		e = &TagExpr{op: "OR", args: []*TagExpr{e, r}}
+	}
+	return e, nil
+}
+
+func (p *tagParser) and() (*TagExpr, error) {
+	e, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek() {
+		case "", "OR", ")":
+			return e, nil
+		case "AND":
+			p.pos++
+		}
+		r, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		e = &TagExpr{op: "AND", args: []*TagExpr{e, r}}
+	}
+}
+
+func (p *tagParser) not() (*TagExpr, error) {
+	tok := p.peek()
+	p.pos++
+	switch tok {
+	case "":
+		return nil, errors.New("tag expression: ends early")
+	case "NOT":
+		e, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &TagExpr{op: "NOT", args: []*TagExpr{e}}, nil
+	case "(":
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("tag expression: missing )")
+		}
+		p.pos++
+		return e, nil
+	case ")", "AND", "OR":
+		return nil, fmt.Errorf("tag expression: unexpected %q", tok)
+	}
+	if err := ValidateTag(strings.TrimSuffix(tok, "/*")); err != nil {
+		return nil, fmt.Errorf("tag expression: %w", err)
+	}
+	return &TagExpr{op: "tag", tag: tok}, nil
+}
+
+// Match reports whether tags satisfy e.
+func (e *TagExpr) Match(tags []string) bool {
+	switch e.op {
+	case "AND":
+		return e.args[0].Match(tags) && e.args[1].Match(tags)
+	case "OR":
+		return e.args[0].Match(tags) || e.args[1].Match(tags)
+	case "NOT":
+		return !e.args[0].Match(tags)
+	}
+	return slices.ContainsFunc(tags, func(t string) bool { return TagMatches(e.tag, t) })
+}
+
+// String returns e fully parenthesized.
+func (e *TagExpr) String() string {
+	switch e.op {
+	case "AND", "OR":
+		return "(" + e.args[0].String() + " " + e.op + " " + e.args[1].String() + ")"
+	case "NOT":
+		return "NOT " + e.args[0].String()
+	}
+	return e.tag
+}
@@ -0,0 +1,102 @@
+package feed
+
+import "testing"
+
+func TestParseTagExpr(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    string // String() of the parsed expression
+		wantErr bool
+	}{
+		{"go", "go", false},
+		{"go rust", "(go AND rust)", false},
+		{"go AND rust OR db", "((go AND rust) OR db)", false},
+		{"go OR rust AND db", "(go OR (rust AND db))", false},
+		{"NOT go AND rust", "(NOT go AND rust)", false},
+		{"NOT NOT go", "NOT NOT go", false},
+		{"(go OR rust) AND lang/*", "((go OR rust) AND lang/*)", false},
+		{"lang/go AND NOT topic/orm", "(lang/go AND NOT topic/orm)", false},
+		{"(go)", "go", false},
+		{"", "", true},
+		{"   ", "", true},
+		{"go AND", "", true},
+		{"NOT", "", true},
+		{"(go OR rust", "", true},
+		{"go)", "", true},
+		{"OR go", "", true},
+		{"go AND OR rust", "", true},
+		{"#go", "", true},
+		{"lang//go", "", true},
+		{"/*", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := ParseTagExpr(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseTagExpr(%q) = %v, want an error", tt.expr, e)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTagExpr(%q): %v", tt.expr, err)
+			}
+			if got := e.String(); got != tt.want {
+				t.Errorf("ParseTagExpr(%q) = %s, want %s", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagExprMatch(t *testing.T) {
+	tests := []struct {
+		expr string
+		tags []string
+		want bool
+	}{
+		{"go", []string{"go"}, true},
+		{"go", []string{"Go"}, true},
+		{"go", []string{"lang/go"}, false},
+		{"go", nil, false},
+		{"go rust", []string{"go"}, false},
+		{"go rust", []string{"rust", "go"}, true},
+		{"go OR rust", []string{"rust"}, true},
+		{"NOT go", nil, true},
+		{"NOT go", []string{"go"}, false},
+		{"lang/* AND NOT topic/orm", []string{"lang/go", "topic/web"}, true},
+		{"lang/* AND NOT topic/orm", []string{"lang/go", "topic/orm"}, false},
+		{"(go OR rust) AND db", []string{"rust", "db"}, true},
+		{"go OR rust AND db", []string{"go"}, true},
+	}
+	for _, tt := range tests {
+		e, err := ParseTagExpr(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseTagExpr(%q): %v", tt.expr, err)
+		}
+		if got := e.Match(tt.tags); got != tt.want {
+			t.Errorf("ParseTagExpr(%q).Match(%q) = %v, want %v", tt.expr, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestTagMatches(t *testing.T) {
+	tests := []struct {
+		pattern, tag string
+		want         bool
+	}{
+		{"go", "go", true},
+		{"go", "GO", true},
+		{"go", "golang", false},
+		{"lang/*", "lang", true},
+		{"lang/*", "lang/go", true},
+		{"lang/*", "Lang/Go/generics", true},
+		{"lang/*", "language", false},
+		{"lang/*", "la", false},
+		{"lang/go", "lang/go/generics", false},
+	}
+	for _, tt := range tests {
+		if got := TagMatches(tt.pattern, tt.tag); got != tt.want {
+			t.Errorf("TagMatches(%q, %q) = %v, want %v", tt.pattern, tt.tag, got, tt.want)
+		}
+	}
+}
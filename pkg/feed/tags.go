@@ -0,0 +1,132 @@
+package feed
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// ValidateTag rejects empty tags, tags containing whitespace, tags
+// written with a leading '#' and namespaced tags ("lang/go") with an
+// empty part.
+func ValidateTag(t string) error {
+	switch {
+	case t == "":
+		return fmt.Errorf("empty tag")
+	case strings.HasPrefix(t, "#"):
+		return fmt.Errorf("tag %q: drop the leading '#'", t)
+	case strings.IndexFunc(t, unicode.IsSpace) >= 0:
+		return fmt.Errorf("tag %q: contains whitespace", t)
+	case slices.Contains(strings.Split(t, "/"), ""):
+		return fmt.Errorf("tag %q: empty part between '/'", t)
+	}
+	return nil
+}
+
+// NormalizeTags lowercases tags and drops duplicates, keeping first
+// occurrence order.
+func NormalizeTags(tags []string) []string {
+	for i, t := range tags {
+		tags[i] = strings.ToLower(t)
+	}
+	return UniqueTags(tags)
+}
+
+// UniqueTags drops repeated tags, keeping first occurrence order.
+func UniqueTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := tags[:0]
+	for _, t := range tags {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// TagCount is a distinct tag and the number of links carrying it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// CountTags tallies tags across f, most used first, ties by name.
+func CountTags(f *v1.Feed) []TagCount {
+	counts := map[string]int{}
+	for _, l := range f.Links {
+		for _, t := range UniqueTags(slices.Clone(l.Tags)) {
+			counts[t]++
+		}
+	}
+	out := make([]TagCount, 0, len(counts))
+	for t, n := range counts {
+		out = append(out, TagCount{Tag: t, Count: n})
+	}
+	slices.SortFunc(out, func(a, b TagCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return out
+}
+
+// RenameTag replaces tag from with to on every link, dropping duplicates
+// where a link already had both. It returns the number of links changed.
+func RenameTag(f *v1.Feed, from, to string) int {
+	return rewriteTags(f, from, func(tags []string, i int) []string {
+		tags[i] = to
+		return UniqueTags(tags)
+	})
+}
+
+// DeleteTag removes tag from every link and returns the number changed.
+func DeleteTag(f *v1.Feed, tag string) int {
+	return rewriteTags(f, tag, func(tags []string, i int) []string {
+		return slices.Delete(tags, i, i+1)
+	})
+}
+
+// Retag removes the tags matching any of remove (patterns as TagMatches
+// takes them) from each link, then appends those of add it lacks. It
+// returns the number of links changed.
+func Retag(links []*v1.Link, add, remove []string) int {
+	n := 0
+	for _, l := range links {
+		tags := slices.DeleteFunc(slices.Clone(l.Tags), func(t string) bool {
+			return slices.ContainsFunc(remove, func(p string) bool { return TagMatches(p, t) })
+		})
+		for _, t := range add {
+			if !slices.Contains(tags, t) {
+				tags = append(tags, t)
+			}
+		}
+		if !slices.Equal(tags, l.Tags) {
+			l.Tags = tags
+			n++
+		}
+	}
+	return n
+}
+
+func rewriteTags(f *v1.Feed, tag string, fn func(tags []string, i int) []string) int {
+	n := 0
+	for _, l := range f.Links {
+		changed := false
+		for i := slices.Index(l.Tags, tag); i >= 0; i = slices.Index(l.Tags, tag) {
+			l.Tags = fn(l.Tags, i)
+			changed = true
+		}
+		if changed {
+			n++
+		}
+	}
+	if n > 0 {
+		f.GeneratedAt = NowRFC3339()
+	}
+	return n
+}
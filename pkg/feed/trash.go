@@ -0,0 +1,109 @@
+package feed
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// Trash removes every link for which del returns true, like RemoveFunc,
+// but keeps them in f.Trash (newest first) with what RestoreTrashed needs to put
+// them back. It returns the removed links in feed order.
+func Trash(f *v1.Feed, del func(*v1.Link) bool, now time.Time) []*v1.Link {
+	var trashed []*v1.TrashedLink
+	for i, l := range f.Links {
+		if !del(l) {
+			continue
+		}
+		t := &v1.TrashedLink{Link: l, RemovedAt: now.UTC().Format(time.RFC3339), Index: int32(i)}
+		for _, o := range f.Links {
+			if slices.Contains(o.RelatedIds, l.Id) {
+				t.ReferencedBy = append(t.ReferencedBy, o.Id)
+			}
+		}
+		trashed = append(trashed, t)
+	}
+	if len(trashed) == 0 {
+		return nil
+	}
+	gone := map[*v1.Link]bool{}
+	for _, t := range trashed {
+		gone[t.Link] = true
+	}
+	removed := RemoveFunc(f, func(l *v1.Link) bool { return gone[l] })
+	// Restoring in trash order undoes the latest removal first and, within
+	// one, refills the lower positions before the higher ones.
+	f.Trash = append(trashed, f.Trash...)
+	return removed
+}
+
+// FindTrashed returns the position in f.Trash of the newest entry whose
+// link has the given ID, or a unique prefix of it.
+func FindTrashed(f *v1.Feed, id string) (int, error) {
+	var match []string
+	for _, t := range f.Trash {
+		if t.Link.GetId() == id {
+			match = []string{id}
+			break
+		}
+		if id != "" && strings.HasPrefix(t.Link.GetId(), id) && !slices.Contains(match, t.Link.GetId()) {
+			match = append(match, t.Link.GetId())
+		}
+	}
+	switch len(match) {
+	case 0:
+		return -1, fmt.Errorf("%w %q in the trash", ErrNoLink, id)
+	case 1:
+		return slices.IndexFunc(f.Trash, func(t *v1.TrashedLink) bool { return t.Link.GetId() == match[0] }), nil
+	}
+	if len(match) > 5 {
+		match = append(match[:5], "...")
+	}
+	return -1, fmt.Errorf("id %q is ambiguous: %s", id, strings.Join(match, ", "))
+}
+
+// RestoreTrashed moves f.Trash[i] back into f.Links, at the position it was
+// removed from (or the end, if the feed has fewer links now), and relates
+// the links that referred to it again. It fails if the feed has a link
+// with the same ID by now.
+func RestoreTrashed(f *v1.Feed, i int) (*v1.Link, error) {
+	t := f.Trash[i]
+	l := t.Link
+	if l == nil {
+		return nil, fmt.Errorf("trash entry %d has no link", i+1)
+	}
+	if Find(f, l.Id) != nil {
+		return nil, fmt.Errorf("the feed has a link with id %s again", l.Id)
+	}
+	at := min(max(int(t.Index), 0), len(f.Links))
+	f.Links = slices.Insert(f.Links, at, l)
+	for _, id := range t.ReferencedBy {
+		if o := Find(f, id); o != nil {
+			Relate(o, l)
+		}
+	}
+	f.Trash = slices.Delete(f.Trash, i, i+1)
+	f.GeneratedAt = NowRFC3339()
+	return l, nil
+}
+
+// PurgeTrash deletes the trashed links removed before cutoff for good and
+// returns them. Entries without a valid removed_at are purged too.
+func PurgeTrash(f *v1.Feed, cutoff time.Time) []*v1.TrashedLink {
+	var purged []*v1.TrashedLink
+	f.Trash = slices.DeleteFunc(f.Trash, func(t *v1.TrashedLink) bool {
+		at, err := time.Parse(time.RFC3339, t.RemovedAt)
+		if err != nil || at.Before(cutoff) {
+			purged = append(purged, t)
+			return true
+		}
+		return false
+	})
+	if len(f.Trash) == 0 {
+		f.Trash = nil
+	}
+	return purged
+}
@@ -0,0 +1,59 @@
+package feed
+
+import (
+	"net/url"
+	"strings"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// NormalizeURL returns the form of rawURL used to detect duplicates: scheme
+// and host lowercased, default ports (:80, :443) dropped, utm_* query
+// parameters removed and a trailing slash trimmed from the path. The
+// fragment is kept. Input that doesn't parse is returned trimmed.
+func NormalizeURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = host
+	if strings.Contains(host, ":") { // IPv6 literal
+		u.Host = "[" + host + "]"
+	}
+	if port != "" {
+		u.Host += ":" + port
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	if u.RawQuery != "" {
+		// Filter the raw pairs rather than url.Values so the remaining
+		// parameters keep their order and encoding.
+		var kept []string
+		for _, kv := range strings.Split(u.RawQuery, "&") {
+			key, _, _ := strings.Cut(kv, "=")
+			if kv != "" && !strings.HasPrefix(strings.ToLower(key), "utm_") {
+				kept = append(kept, kv)
+			}
+		}
+		u.RawQuery = strings.Join(kept, "&")
+	}
+	return u.String()
+}
+
+// FindURL returns the first link whose URL normalizes to the same value as
+// rawURL (see NormalizeURL), or nil.
+func FindURL(f *v1.Feed, rawURL string) *v1.Link {
+	want := NormalizeURL(rawURL)
+	for _, l := range f.Links {
+		if NormalizeURL(l.Url) == want {
+			return l
+		}
+	}
+	return nil
+}
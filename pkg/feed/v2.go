@@ -0,0 +1,263 @@
+package feed
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	v2 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v2"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ToV2 converts f to the linkleaf.v2 schema, whose times are
+// google.protobuf.Timestamps. FromV2 gives f back unchanged. A time field
+// that isn't an RFC 3339 UTC time, as linkleaf writes them, is an error
+// rather than something to lose; Link.date keeps any value it can't
+// represent as text.
+func ToV2(f *v1.Feed) (*v2.Feed, error) {
+	var c v2Converter
+	out := &v2.Feed{
+		Version:     f.Version,
+		Title:       f.Title,
+		GeneratedAt: c.timestamp("generated_at", f.GeneratedAt),
+		Author:      f.Author,
+		Description: f.Description,
+		HomePageUrl: f.HomePageUrl,
+		Icon:        f.Icon,
+		Lang:        f.Lang,
+	}
+	for _, l := range f.Links {
+		out.Links = append(out.Links, c.link(l))
+	}
+	for _, s := range f.Subscriptions {
+		out.Subscriptions = append(out.Subscriptions, &v2.Subscription{
+			Url:          s.Url,
+			Title:        s.Title,
+			Tags:         s.Tags,
+			AddedAt:      c.timestamp("subscription "+s.Url+": added_at", s.AddedAt),
+			PulledAt:     c.timestamp("subscription "+s.Url+": pulled_at", s.PulledAt),
+			Error:        s.Error,
+			Etag:         s.Etag,
+			LastModified: s.LastModified,
+			Seen:         s.Seen,
+		})
+	}
+	for _, t := range f.Trash {
+		out.Trash = append(out.Trash, &v2.TrashedLink{
+			Link:         c.link(t.Link),
+			RemovedAt:    c.timestamp("trash "+t.GetLink().GetId()+": removed_at", t.RemovedAt),
+			Index:        t.Index,
+			ReferencedBy: t.ReferencedBy,
+		})
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return out, nil
+}
+
+// FromV2 converts f back to the linkleaf.v1 schema.
+func FromV2(f *v2.Feed) *v1.Feed {
+	out := &v1.Feed{
+		Version:     f.Version,
+		Title:       f.Title,
+		GeneratedAt: formatTimestamp(f.GeneratedAt),
+		Author:      f.Author,
+		Description: f.Description,
+		HomePageUrl: f.HomePageUrl,
+		Icon:        f.Icon,
+		Lang:        f.Lang,
+	}
+	for _, l := range f.Links {
+		out.Links = append(out.Links, linkFromV2(l))
+	}
+	for _, s := range f.Subscriptions {
+		out.Subscriptions = append(out.Subscriptions, &v1.Subscription{
+			Url:          s.Url,
+			Title:        s.Title,
+			Tags:         s.Tags,
+			AddedAt:      formatTimestamp(s.AddedAt),
+			PulledAt:     formatTimestamp(s.PulledAt),
+			Error:        s.Error,
+			Etag:         s.Etag,
+			LastModified: s.LastModified,
+			Seen:         s.Seen,
+		})
+	}
+	for _, t := range f.Trash {
+		out.Trash = append(out.Trash, &v1.TrashedLink{
+			Link:         linkFromV2(t.Link),
+			RemovedAt:    formatTimestamp(t.RemovedAt),
+			Index:        t.Index,
+			ReferencedBy: t.ReferencedBy,
+		})
+	}
+	return out
+}
+
+// v2Converter keeps the first error of a ToV2 conversion.
+type v2Converter struct{ err error }
+
+// timestamp parses s, an RFC 3339 UTC time; "" is unset.
+func (c *v2Converter) timestamp(field, s string) *timestamppb.Timestamp {
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil || t.UTC().Format(time.RFC3339Nano) != s {
+		if c.err == nil {
+			c.err = fmt.Errorf("%s: %q isn't an RFC 3339 UTC time", field, s)
+		}
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func (c *v2Converter) check(field string, k *v1.LinkCheck) *v2.LinkCheck {
+	if k == nil {
+		return nil
+	}
+	return &v2.LinkCheck{
+		CheckedAt: c.timestamp(field+": checked_at", k.CheckedAt),
+		Status:    k.Status,
+		Error:     k.Error,
+		FinalUrl:  k.FinalUrl,
+		Failures:  k.Failures,
+	}
+}
+
+func (c *v2Converter) link(l *v1.Link) *v2.Link {
+	if l == nil {
+		return nil
+	}
+	field := "link " + l.Id
+	out := &v2.Link{
+		Id:             l.Id,
+		Title:          l.Title,
+		Url:            l.Url,
+		Summary:        l.Summary,
+		Tags:           l.Tags,
+		Date:           linkDateToV2(l.Date),
+		Via:            l.Via,
+		CreatedAt:      c.timestamp(field+": added_at", l.AddedAt),
+		LastCheck:      c.check(field+": last_check", l.LastCheck),
+		UpdatedAt:      c.timestamp(field+": updated_at", l.UpdatedAt),
+		Notes:          l.Notes,
+		Read:           l.Read,
+		Archived:       l.Archived,
+		Starred:        l.Starred,
+		ArchiveUrl:     l.ArchiveUrl,
+		Author:         l.Author,
+		RelatedIds:     l.RelatedIds,
+		Meta:           l.Meta,
+		Draft:          l.Draft,
+		PublishAt:      c.timestamp(field+": publish_at", l.PublishAt),
+		Slug:           l.Slug,
+		Lang:           l.Lang,
+		WordCount:      l.WordCount,
+		ReadingMinutes: l.ReadingMinutes,
+		ArticlePath:    l.ArticlePath,
+		Quotes:         l.Quotes,
+	}
+	if e := l.Enclosure; e != nil {
+		out.Enclosure = &v2.Enclosure{Url: e.Url, MimeType: e.MimeType, Length: e.Length}
+	}
+	for _, k := range l.CheckHistory {
+		out.CheckHistory = append(out.CheckHistory, c.check(field+": check_history", k))
+	}
+	return out
+}
+
+func linkFromV2(l *v2.Link) *v1.Link {
+	if l == nil {
+		return nil
+	}
+	out := &v1.Link{
+		Id:             l.Id,
+		Title:          l.Title,
+		Url:            l.Url,
+		Summary:        l.Summary,
+		Tags:           l.Tags,
+		Date:           linkDateFromV2(l.Date),
+		Via:            l.Via,
+		AddedAt:        formatTimestamp(l.CreatedAt),
+		LastCheck:      checkFromV2(l.LastCheck),
+		UpdatedAt:      formatTimestamp(l.UpdatedAt),
+		Notes:          l.Notes,
+		Read:           l.Read,
+		Archived:       l.Archived,
+		Starred:        l.Starred,
+		ArchiveUrl:     l.ArchiveUrl,
+		Author:         l.Author,
+		RelatedIds:     l.RelatedIds,
+		Meta:           l.Meta,
+		Draft:          l.Draft,
+		PublishAt:      formatTimestamp(l.PublishAt),
+		Slug:           l.Slug,
+		Lang:           l.Lang,
+		WordCount:      l.WordCount,
+		ReadingMinutes: l.ReadingMinutes,
+		ArticlePath:    l.ArticlePath,
+		Quotes:         l.Quotes,
+	}
+	if e := l.Enclosure; e != nil {
+		out.Enclosure = &v1.Enclosure{Url: e.Url, MimeType: e.MimeType, Length: e.Length}
+	}
+	for _, k := range l.CheckHistory {
+		out.CheckHistory = append(out.CheckHistory, checkFromV2(k))
+	}
+	return out
+}
+
+func checkFromV2(k *v2.LinkCheck) *v1.LinkCheck {
+	if k == nil {
+		return nil
+	}
+	return &v1.LinkCheck{
+		CheckedAt: formatTimestamp(k.CheckedAt),
+		Status:    k.Status,
+		Error:     k.Error,
+		FinalUrl:  k.FinalUrl,
+		Failures:  k.Failures,
+	}
+}
+
+func formatTimestamp(t *timestamppb.Timestamp) string {
+	if t == nil {
+		return ""
+	}
+	return t.AsTime().UTC().Format(time.RFC3339Nano)
+}
+
+// linkDateToV2 converts a Link.date: a day, an RFC 3339 time, or anything
+// else kept as text.
+func linkDateToV2(s string) *v2.LinkDate {
+	if s == "" {
+		return nil
+	}
+	d := &v2.LinkDate{}
+	if t, err := time.Parse(DateLayout, s); err == nil {
+		d.Time, d.AllDay = timestamppb.New(t), true
+	} else if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		_, offset := t.Zone()
+		d.Time, d.UtcOffset = timestamppb.New(t), int32(offset)
+	}
+	if linkDateFromV2(d) != s {
+		d.Text = s
+	}
+	return d
+}
+
+func linkDateFromV2(d *v2.LinkDate) string {
+	switch {
+	case d == nil:
+		return ""
+	case d.Text != "":
+		return d.Text
+	case d.Time == nil:
+		return ""
+	case d.AllDay:
+		return d.Time.AsTime().UTC().Format(DateLayout)
+	}
+	return d.Time.AsTime().In(time.FixedZone("", int(d.UtcOffset))).Format(time.RFC3339Nano)
+}
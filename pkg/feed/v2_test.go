@@ -0,0 +1,83 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestV2RoundTrip(t *testing.T) {
+	check := &v1.LinkCheck{CheckedAt: "2024-05-03T08:00:00Z", Status: 200}
+	link := &v1.Link{
+		Id: "a", Title: "A", Url: "https://example.com/a", Tags: []string{"go"},
+		Date:         "2024-05-01",
+		AddedAt:      "2024-05-01T10:20:30Z",
+		UpdatedAt:    "2024-05-02T11:00:00.5Z",
+		Read:         true,
+		Archived:     true,
+		Meta:         map[string]string{"k": "v"},
+		Enclosure:    &v1.Enclosure{Url: "https://example.com/a.mp3", MimeType: "audio/mpeg", Length: 42},
+		PublishAt:    "2024-06-01T00:00:00Z",
+		LastCheck:    check,
+		CheckHistory: []*v1.LinkCheck{{CheckedAt: "2024-05-02T08:00:00Z", Error: "timeout", Failures: 1}},
+		Quotes:       []string{"q"},
+	}
+	f := &v1.Feed{
+		Version:     CurrentVersion,
+		Title:       "Links",
+		GeneratedAt: "2024-05-03T09:00:00Z",
+		Links: []*v1.Link{
+			link,
+			{Id: "b", Url: "https://example.com/b", Date: "2024-05-01T10:00:00+02:00"},
+			{Id: "c", Url: "https://example.com/c", Date: "2024-05-01T10:00:00+00:00"},
+			{Id: "d", Url: "https://example.com/d", Date: "May 1st"},
+		},
+		Subscriptions: []*v1.Subscription{{Url: "https://example.com/feed.xml", AddedAt: "2024-04-01T00:00:00Z"}},
+		Trash:         []*v1.TrashedLink{{Link: &v1.Link{Id: "e", Date: "2024-04-30"}, RemovedAt: "2024-05-02T00:00:00Z", Index: 3}},
+	}
+	g, err := ToV2(f)
+	if err != nil {
+		t.Fatalf("ToV2: %v", err)
+	}
+	if d := g.Links[0].Date; !d.AllDay || d.Text != "" {
+		t.Errorf("date %q = %v, want a day without text", f.Links[0].Date, d)
+	}
+	if d := g.Links[1].Date; d.UtcOffset != 2*3600 || d.Text != "" {
+		t.Errorf("date %q = %v, want offset 7200 without text", f.Links[1].Date, d)
+	}
+	if d := g.Links[3].Date; d.Time != nil || d.Text != "May 1st" {
+		t.Errorf("date %q = %v, want only text", f.Links[3].Date, d)
+	}
+	b, err := proto.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Reset()
+	if err := proto.Unmarshal(b, g); err != nil {
+		t.Fatal(err)
+	}
+	if back := FromV2(g); !proto.Equal(back, f) {
+		t.Errorf("FromV2(ToV2(f)) differs:\ngot  %v\nwant %v", back, f)
+	}
+}
+
+func TestToV2BadTime(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *v1.Feed
+		want string
+	}{
+		{"generated_at", &v1.Feed{GeneratedAt: "yesterday"}, "generated_at"},
+		{"offset", &v1.Feed{Links: []*v1.Link{{Id: "a", AddedAt: "2024-05-01T10:00:00+02:00"}}}, "link a: added_at"},
+		{"check", &v1.Feed{Links: []*v1.Link{{Id: "a", LastCheck: &v1.LinkCheck{CheckedAt: "2024-05-01"}}}}, "link a: last_check: checked_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ToV2(tt.f); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ToV2 = %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+}
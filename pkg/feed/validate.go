@@ -0,0 +1,222 @@
+package feed
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// Link dates outside [minDate, now+maxDateAhead] are rejected as typos.
+var minDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const maxDateAhead = 366 * 24 * time.Hour
+
+// ValidateURL accepts absolute http and https URLs with a host.
+func ValidateURL(s string) error {
+	u, err := url.Parse(s)
+	switch {
+	case s == "":
+		return fmt.Errorf("empty URL")
+	case err != nil:
+		return fmt.Errorf("URL %q: %v", s, err)
+	case u.Scheme != "http" && u.Scheme != "https":
+		return fmt.Errorf("URL %q: want an http or https URL", s)
+	case u.Host == "":
+		return fmt.Errorf("URL %q: no host", s)
+	}
+	return nil
+}
+
+// ValidateDate accepts YYYY-MM-DD dates, or RFC 3339 times, from 1970 to a
+// year from now.
+func ValidateDate(s string) error {
+	d, err := ParseDate(s)
+	switch {
+	case err != nil:
+		return fmt.Errorf("date %q: want YYYY-MM-DD or an RFC 3339 time", s)
+	case d.Before(minDate):
+		return fmt.Errorf("date %q: before 1970", s)
+	case d.After(time.Now().Add(maxDateAhead)):
+		return fmt.Errorf("date %q: more than a year ahead", s)
+	}
+	return nil
+}
+
+// ValidateID rejects IDs that are empty or could leave a directory when
+// used in a file name (reader and archive files are named after the ID):
+// "." and "..", and IDs containing a slash or backslash.
+func ValidateID(id string) error {
+	switch {
+	case id == "":
+		return fmt.Errorf("empty ID")
+	case id == "." || id == "..":
+		return fmt.Errorf("ID %q: not a file name", id)
+	case strings.ContainsAny(id, `/\`):
+		return fmt.Errorf("ID %q: contains a slash", id)
+	}
+	return nil
+}
+
+// ValidateLink checks what "add" requires of a new link: a valid ID if
+// set, a title, a valid URL and date, and a valid via URL and enclosure if
+// set.
+func ValidateLink(l *v1.Link) error {
+	if l.Id != "" {
+		if err := ValidateID(l.Id); err != nil {
+			return err
+		}
+	}
+	if l.Title == "" {
+		return fmt.Errorf("empty title")
+	}
+	if err := ValidateURL(l.Url); err != nil {
+		return err
+	}
+	if err := ValidateDate(l.Date); err != nil {
+		return err
+	}
+	if l.Via != "" {
+		if err := ValidateURL(l.Via); err != nil {
+			return fmt.Errorf("via: %w", err)
+		}
+	}
+	if l.Enclosure != nil {
+		if err := ValidateEnclosure(l.Enclosure); err != nil {
+			return fmt.Errorf("enclosure: %w", err)
+		}
+	}
+	return nil
+}
+
+// ValidateEnclosure wants a valid URL, a type/subtype MIME type and a
+// length that isn't negative.
+func ValidateEnclosure(e *v1.Enclosure) error {
+	if err := ValidateURL(e.Url); err != nil {
+		return err
+	}
+	if typ, sub, ok := strings.Cut(e.MimeType, "/"); !ok || typ == "" || sub == "" {
+		return fmt.Errorf("MIME type %q: want type/subtype, e.g. audio/mpeg", e.MimeType)
+	}
+	if e.Length < 0 {
+		return fmt.Errorf("negative length %d", e.Length)
+	}
+	return nil
+}
+
+// ValidateMetaKey rejects empty Link.meta keys and keys containing
+// whitespace or '=' (which "-meta key=value" and meta:key=value split on).
+func ValidateMetaKey(k string) error {
+	switch {
+	case k == "":
+		return fmt.Errorf("empty meta key")
+	case strings.ContainsRune(k, '='):
+		return fmt.Errorf("meta key %q: contains '='", k)
+	case strings.IndexFunc(k, unicode.IsSpace) >= 0:
+		return fmt.Errorf("meta key %q: contains whitespace", k)
+	}
+	return nil
+}
+
+// Problem is one issue Lint found in a feed.
+type Problem struct {
+	// Index is the link's position in Feed.Links.
+	Index   int    `json:"index"`
+	ID      string `json:"id"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("link %d [%s] %s: %s", p.Index+1, p.ID, p.Field, p.Message)
+}
+
+// Lint checks every link of f: IDs valid and unique, titles non-empty,
+// slugs well-formed and unique, URLs, via URLs, enclosures, dates, tags,
+// languages, meta keys and timestamps well-formed, related IDs pointing at
+// other links of f. Problems come in link order.
+func Lint(f *v1.Feed) []Problem {
+	var out []Problem
+	seen := map[string]int{}
+	slugs := map[string]int{}
+	ids := map[string]bool{}
+	for _, l := range f.Links {
+		ids[l.Id] = true
+	}
+	for i, l := range f.Links {
+		add := func(field string, format string, args ...any) {
+			out = append(out, Problem{Index: i, ID: l.Id, Field: field, Message: fmt.Sprintf(format, args...)})
+		}
+		if err := ValidateID(l.Id); err != nil {
+			add("id", "%v", err)
+		} else if j, dup := seen[l.Id]; dup {
+			add("id", "duplicate ID (also link %d)", j+1)
+		} else {
+			seen[l.Id] = i
+		}
+		if l.Title == "" {
+			add("title", "empty title")
+		}
+		if l.Slug != "" {
+			if err := ValidateSlug(l.Slug); err != nil {
+				add("slug", "%v", err)
+			} else if j, dup := slugs[l.Slug]; dup {
+				add("slug", "duplicate slug (also link %d)", j+1)
+			} else {
+				slugs[l.Slug] = i
+			}
+		}
+		if err := ValidateURL(l.Url); err != nil {
+			add("url", "%v", err)
+		}
+		if l.Via != "" {
+			if err := ValidateURL(l.Via); err != nil {
+				add("via", "%v", err)
+			}
+		}
+		if l.Enclosure != nil {
+			if err := ValidateEnclosure(l.Enclosure); err != nil {
+				add("enclosure", "%v", err)
+			}
+		}
+		if err := ValidateDate(l.Date); err != nil {
+			add("date", "%v", err)
+		}
+		for _, t := range l.Tags {
+			if err := ValidateTag(t); err != nil {
+				add("tags", "%v", err)
+			}
+		}
+		if l.Lang != "" {
+			if norm, err := NormalizeLang(l.Lang); err != nil {
+				add("lang", "%v", err)
+			} else if norm != l.Lang {
+				add("lang", "language %q: write it as %q", l.Lang, norm)
+			}
+		}
+		for _, ts := range [][2]string{{"added_at", l.AddedAt}, {"updated_at", l.UpdatedAt}, {"publish_at", l.PublishAt}} {
+			if _, err := time.Parse(time.RFC3339, ts[1]); ts[1] != "" && err != nil {
+				add(ts[0], "%q is not an RFC 3339 time", ts[1])
+			}
+		}
+		for _, k := range slices.Sorted(maps.Keys(l.Meta)) {
+			if err := ValidateMetaKey(k); err != nil {
+				add("meta", "%v", err)
+			}
+		}
+		for _, id := range l.RelatedIds {
+			switch {
+			case id == l.Id:
+				add("related_ids", "relates to itself")
+			case !ids[id]:
+				add("related_ids", "no link with id %q", id)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,262 @@
+package feed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/storage"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// WALSuffix names a feed file's write-ahead log: the changes saved with
+// SaveOptions.WAL, appended one JSON record per line instead of rewriting
+// the file. Load replays them; the next full save folds them into the
+// file and removes the log.
+//
+// The first line holds the SHA-256 of the feed file the records apply
+// to. A crash after a full save but before the log was removed leaves a
+// log whose hash no longer matches, which Load then ignores; a crash
+// while appending leaves a last line without its newline, which is
+// dropped too.
+const WALSuffix = ".wal"
+
+// walHeader is the first line of a write-ahead log.
+type walHeader struct {
+	Base string `json:"base"` // hex SHA-256 of the feed file
+}
+
+// walRecord is one saved change: links to put (replacing the link with
+// the same ID in place, else prepended, in order) and IDs to delete.
+type walRecord struct {
+	Time        string            `json:"time"`
+	GeneratedAt string            `json:"generated_at"`
+	Put         []json.RawMessage `json:"put,omitempty"`
+	Delete      []string          `json:"delete,omitempty"`
+}
+
+// walChange turns the change from before to after into a record, or
+// reports false if a record can't express it: links reordered, or the
+// feed's own fields changed.
+func walChange(before, after *v1.Feed) (*walRecord, bool, error) {
+	b, a := proto.Clone(before).(*v1.Feed), proto.Clone(after).(*v1.Feed)
+	b.Links, a.Links, b.GeneratedAt, a.GeneratedAt = nil, nil, "", ""
+	if !proto.Equal(b, a) || !slices.Equal(keptIDs(before, after), keptIDs(after, before)) {
+		return nil, false, nil
+	}
+	d := Compare(before, after)
+	for i, l := range d.Added {
+		if i >= len(after.Links) || after.Links[i].Id != l.Id {
+			return nil, false, nil // added somewhere else than the top
+		}
+	}
+	r := &walRecord{Time: NowRFC3339(), GeneratedAt: after.GeneratedAt}
+	put := slices.Clone(d.Added)
+	for _, c := range d.Modified {
+		put = append(put, c.New)
+	}
+	for _, l := range put {
+		j, err := protojson.Marshal(l)
+		if err != nil {
+			return nil, false, err
+		}
+		r.Put = append(r.Put, j)
+	}
+	for _, l := range d.Removed {
+		r.Delete = append(r.Delete, l.Id)
+	}
+	return r, true, nil
+}
+
+// apply replays r on f.
+func (r *walRecord) apply(f *v1.Feed) error {
+	var fresh []*v1.Link
+	for _, j := range r.Put {
+		l := &v1.Link{}
+		if err := protojson.Unmarshal(j, l); err != nil {
+			return err
+		}
+		if i := Index(f, l.Id); i >= 0 {
+			f.Links[i] = l
+		} else {
+			fresh = append(fresh, l)
+		}
+	}
+	f.Links = append(fresh, f.Links...)
+	if len(r.Delete) > 0 {
+		RemoveFunc(f, func(l *v1.Link) bool { return slices.Contains(r.Delete, l.Id) })
+	}
+	f.GeneratedAt = r.GeneratedAt
+	return nil
+}
+
+// appendWAL writes the change from opts.Before to f as a record of the
+// log of the (expanded) path, if the file can take one: a local, plain
+// or compressed protobuf file, not encrypted, saved without options that
+// rewrite its bytes. It reports whether it did.
+func appendWAL(path string, f *v1.Feed, opts SaveOptions) (bool, error) {
+	if opts.Before == nil || storage.IsRemote(path) || !fileExists(path) ||
+		opts.Format != "" && opts.Format != FormatProto || fileSharded(path) || fileSQLite(path) || fileStream(path) ||
+		opts.Encrypt || fileEncrypted(path) || opts.Compression != "" && opts.Compression != fileCompression(path) ||
+		opts.Backup || opts.KeepBackups > 0 || opts.Canonical || opts.SortByID {
+		return false, nil
+	}
+	if opts.GeneratedAt != "" {
+		f.GeneratedAt = opts.GeneratedAt
+	}
+	r, ok, err := walChange(opts.Before, f)
+	if !ok || err != nil {
+		return false, err
+	}
+	if opts.DryRun {
+		return true, nil
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return false, err
+	}
+	base, err := fileSHA256(path)
+	if err != nil {
+		return false, err
+	}
+	var head []byte
+	if records, err := readWAL(path, base); err != nil || records == nil {
+		// No log yet, or a stale one: start over on the current file.
+		h, _ := json.Marshal(walHeader{Base: base})
+		head = append(h, '\n')
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if head != nil {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	fh, err := os.OpenFile(path+WALSuffix, flags, 0o644)
+	if err != nil {
+		return false, err
+	}
+	if _, err := fh.Write(append(append(head, line...), '\n')); err != nil {
+		fh.Close()
+		return false, err
+	}
+	if err := fh.Sync(); err != nil {
+		fh.Close()
+		return false, err
+	}
+	Logger.Debug("wal append", "path", path+WALSuffix, "put", len(r.Put), "delete", len(r.Delete))
+	return true, fh.Close()
+}
+
+// readWAL returns the complete records of path's log if it applies to the
+// file whose hash is base; nil if there is no log or it is stale. A log
+// holding only its header yields an empty, non-nil slice.
+func readWAL(path, base string) ([]*walRecord, error) {
+	b, err := os.ReadFile(path + WALSuffix)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	lines := bytes.SplitAfter(b, []byte("\n"))
+	var h walHeader
+	if len(lines) == 0 || !bytes.HasSuffix(lines[0], []byte("\n")) || json.Unmarshal(lines[0], &h) != nil {
+		return nil, fmt.Errorf("%s%s: no header", path, WALSuffix)
+	}
+	if h.Base != base {
+		Logger.Debug("wal stale", "path", path+WALSuffix)
+		return nil, nil
+	}
+	records := []*walRecord{}
+	for n, line := range lines[1:] {
+		if !bytes.HasSuffix(line, []byte("\n")) {
+			break // torn by a crash mid-append
+		}
+		r := &walRecord{}
+		if err := json.Unmarshal(line, r); err != nil {
+			return nil, fmt.Errorf("%s%s:%d: %w", path, WALSuffix, n+2, err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// replayWAL applies the log of path to f, loaded from the file bytes raw.
+func replayWAL(path string, raw []byte, f *v1.Feed) error {
+	if storage.IsRemote(path) {
+		return nil
+	}
+	sum := sha256.Sum256(raw)
+	records, err := readWAL(path, hex.EncodeToString(sum[:]))
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := r.apply(f); err != nil {
+			return fmt.Errorf("replay %s%s: %w", path, WALSuffix, err)
+		}
+	}
+	if len(records) > 0 {
+		Logger.Debug("wal replay", "path", path+WALSuffix, "records", len(records))
+	}
+	return nil
+}
+
+// dropWAL removes path's log once a full save has folded it in.
+func dropWAL(path string) error {
+	if storage.IsRemote(path) {
+		return nil
+	}
+	if err := os.Remove(path + WALSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// WALRecords returns how many changes the write-ahead log of the feed at
+// path holds that the file doesn't (see WALSuffix).
+func WALRecords(path string) (int, error) {
+	path, err := ExpandPath(path)
+	if err != nil || storage.IsRemote(path) {
+		return 0, err
+	}
+	if !fileExists(path + WALSuffix) {
+		return 0, nil
+	}
+	base, err := fileSHA256(path)
+	if err != nil {
+		return 0, err
+	}
+	records, err := readWAL(path, base)
+	return len(records), err
+}
+
+// StaleWAL reports whether the feed at path has a write-ahead log that no
+// longer applies to the file, left by a crash between a full save and the
+// log's removal. Load ignores such a log; it is safe to delete.
+func StaleWAL(path string) (bool, error) {
+	path, err := ExpandPath(path)
+	if err != nil || storage.IsRemote(path) || !fileExists(path+WALSuffix) {
+		return false, err
+	}
+	base, err := fileSHA256(path)
+	if err != nil {
+		return false, err
+	}
+	records, err := readWAL(path, base)
+	return records == nil && err == nil, err
+}
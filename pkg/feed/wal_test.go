@@ -0,0 +1,157 @@
+package feed
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func walBase() *v1.Feed {
+	f := New("Links", CurrentVersion)
+	f.GeneratedAt = "2024-05-01T00:00:00Z"
+	for _, id := range []string{"c", "b", "a"} {
+		f.Links = append(f.Links, &v1.Link{Id: id, Title: id, Url: "https://example.com/" + id, Date: "2024-05-01"})
+	}
+	return f
+}
+
+func TestWALRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(f *v1.Feed)
+		logged bool // whether the change fits a record rather than a full save
+	}{
+		{"add", func(f *v1.Feed) {
+			f.Links = append([]*v1.Link{{Id: "d", Url: "https://example.com/d", Date: "2024-05-02"}}, f.Links...)
+		}, true},
+		{"edit", func(f *v1.Feed) { f.Links[1].Title, f.Links[1].Tags = "B", []string{"x"} }, true},
+		{"remove", func(f *v1.Feed) { f.Links = append(f.Links[:1], f.Links[2:]...) }, true},
+		{"add, edit and remove", func(f *v1.Feed) {
+			f.Links[0].Read = true
+			f.Links = append([]*v1.Link{{Id: "d", Url: "https://example.com/d"}}, f.Links[:2]...)
+		}, true},
+		{"reorder", func(f *v1.Feed) { f.Links[0], f.Links[2] = f.Links[2], f.Links[0] }, false},
+		{"feed field", func(f *v1.Feed) { f.Title = "More links" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "feed.pb")
+			before := walBase()
+			if err := Save(path, before); err != nil {
+				t.Fatal(err)
+			}
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			after := proto.Clone(before).(*v1.Feed)
+			tt.change(after)
+			after.GeneratedAt = "2024-05-02T00:00:00Z"
+			if err := SaveWith(path, proto.Clone(after).(*v1.Feed), SaveOptions{WAL: true, Before: before}); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(got, after) {
+				t.Errorf("Load after the save =\n%v\nwant\n%v", got, after)
+			}
+			now, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rewritten := !bytes.Equal(now, raw); rewritten == tt.logged {
+				t.Errorf("file rewritten: %v, want %v", rewritten, !tt.logged)
+			}
+			want := 0
+			if tt.logged {
+				want = 1
+			}
+			if n, err := WALRecords(path); n != want || err != nil {
+				t.Errorf("WALRecords = %d, %v; want %d", n, err, want)
+			}
+
+			// A full save folds the log into the file.
+			if err := Save(path, got); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(path + WALSuffix); !os.IsNotExist(err) {
+				t.Errorf("log left after a full save: %v", err)
+			}
+			if folded, err := Load(path); err != nil || !proto.Equal(folded, after) {
+				t.Errorf("Load after folding = %v, %v; want %v", folded, err, after)
+			}
+		})
+	}
+}
+
+func TestWALRecovery(t *testing.T) {
+	tests := []struct {
+		name  string
+		crash func(t *testing.T, path string)
+		want  int // records replayed
+	}{
+		{"intact", func(*testing.T, string) {}, 2},
+		{"torn last record", func(t *testing.T, path string) {
+			b, err := os.ReadFile(path + WALSuffix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path+WALSuffix, b[:len(b)-1], 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}, 1},
+		{"stale log", func(t *testing.T, path string) {
+			// The file was rewritten but the log not yet removed.
+			f := walBase()
+			f.Title = "Rewritten"
+			b, err := proto.Marshal(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, b, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "feed.pb")
+			states := []*v1.Feed{walBase()}
+			if err := Save(path, states[0]); err != nil {
+				t.Fatal(err)
+			}
+			for i, title := range []string{"first", "second"} {
+				next := proto.Clone(states[i]).(*v1.Feed)
+				next.Links[0].Title = title
+				if err := SaveWith(path, proto.Clone(next).(*v1.Feed), SaveOptions{WAL: true, Before: states[i]}); err != nil {
+					t.Fatal(err)
+				}
+				states = append(states, next)
+			}
+			tt.crash(t, path)
+
+			got, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := states[tt.want]
+			if tt.want == 0 {
+				want = walBase()
+				want.Title = "Rewritten"
+			}
+			if !proto.Equal(got, want) {
+				t.Errorf("Load =\n%v\nwant\n%v", got, want)
+			}
+			if stale, err := StaleWAL(path); stale != (tt.want == 0) || err != nil {
+				t.Errorf("StaleWAL = %v, %v; want %v", stale, err, tt.want == 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,470 @@
+// Package fts is the full-text index behind "search -fts": an inverted
+// index of the words in links' titles, summaries and notes, kept in a
+// sidecar file next to the feed and brought up to date link by link.
+// Results are ranked with BM25, with title words counting triple.
+package fts
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/doriancodes/linkleaf-cli/pkg/feed"
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Suffix names the index file kept next to a feed file.
+const Suffix = ".fts"
+
+const (
+	// titleWeight is how many times a word in a title counts.
+	titleWeight = 3
+	// BM25 parameters.
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Index is a full-text index of links (a v1.SearchIndex in memory).
+type Index struct {
+	docs  map[string]*v1.SearchDoc
+	terms map[string]*v1.SearchPostings
+}
+
+// New returns an empty index.
+func New() *Index {
+	return &Index{docs: map[string]*v1.SearchDoc{}, terms: map[string]*v1.SearchPostings{}}
+}
+
+// Load reads the index file at path.
+func Load(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var si v1.SearchIndex
+	if err := proto.Unmarshal(data, &si); err != nil {
+		return nil, fmt.Errorf("%s: unmarshal protobuf: %w", path, err)
+	}
+	x := New()
+	for _, d := range si.Docs {
+		x.docs[d.Id] = d
+	}
+	if si.Terms != nil {
+		x.terms = si.Terms
+	}
+	return x, nil
+}
+
+// Save writes the index to path, replacing it atomically.
+func (x *Index) Save(path string) error {
+	si := &v1.SearchIndex{Terms: x.terms}
+	for _, id := range slices.Sorted(maps.Keys(x.docs)) {
+		si.Docs = append(si.Docs, x.docs[id])
+	}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(si)
+	if err != nil {
+		return fmt.Errorf("marshal protobuf: %w", err)
+	}
+	return feed.WriteFileAtomic(path, data, 0o644)
+}
+
+// Len returns the number of indexed links.
+func (x *Index) Len() int { return len(x.docs) }
+
+// Update makes the index hold exactly links, indexing those that are new
+// or whose text changed since it last saw them, and returns how many
+// links it added, indexed again or dropped.
+func (x *Index) Update(links []*v1.Link) int {
+	seen := map[string]bool{}
+	stale := map[string]bool{}
+	var add []*v1.Link
+	var hashes []uint64
+	for _, l := range links {
+		if seen[l.Id] {
+			continue
+		}
+		seen[l.Id] = true
+		h := textHash(l)
+		d := x.docs[l.Id]
+		if d != nil && d.Hash == h {
+			continue
+		}
+		if d != nil {
+			stale[l.Id] = true
+		}
+		add = append(add, l)
+		hashes = append(hashes, h)
+	}
+	dropped := 0
+	for id := range x.docs {
+		if !seen[id] {
+			stale[id] = true
+			dropped++
+		}
+	}
+	x.drop(stale)
+	for i, l := range add {
+		x.add(l, hashes[i])
+	}
+	return len(add) + dropped
+}
+
+// drop removes the links with the given IDs.
+func (x *Index) drop(ids map[string]bool) {
+	if len(ids) == 0 {
+		return
+	}
+	for w, p := range x.terms {
+		p.Postings = slices.DeleteFunc(p.Postings, func(ps *v1.SearchPosting) bool { return ids[ps.Id] })
+		if len(p.Postings) == 0 {
+			delete(x.terms, w)
+		}
+	}
+	for id := range ids {
+		delete(x.docs, id)
+	}
+}
+
+func (x *Index) add(l *v1.Link, hash uint64) {
+	d := &v1.SearchDoc{Id: l.Id, Hash: hash}
+	positions := map[string][]uint32{}
+	var pos uint32
+	for i, text := range []string{l.Title, l.Summary, l.Notes} {
+		toks := tokenize(text)
+		for _, t := range toks {
+			positions[t.word] = append(positions[t.word], pos)
+			pos++
+		}
+		switch i {
+		case 0:
+			d.TitleWords = uint32(len(toks))
+		case 1:
+			d.SummaryWords = uint32(len(toks))
+		case 2:
+			d.NotesWords = uint32(len(toks))
+		}
+	}
+	x.docs[l.Id] = d
+	for w, ps := range positions {
+		p := x.terms[w]
+		if p == nil {
+			p = &v1.SearchPostings{}
+			x.terms[w] = p
+		}
+		p.Postings = append(p.Postings, &v1.SearchPosting{Id: l.Id, Positions: ps})
+	}
+}
+
+// textHash is the FNV-1a hash of the text Update indexes for l.
+func textHash(l *v1.Link) uint64 {
+	h := fnv.New64a()
+	for _, s := range []string{l.Title, l.Summary, l.Notes} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// token is a word of a text and its byte offsets.
+type token struct {
+	word       string // lower case
+	start, end int
+}
+
+// tokenize splits s into words: runs of letters and digits.
+func tokenize(s string) []token {
+	var toks []token
+	start := -1
+	for i, r := range s {
+		word := unicode.IsLetter(r) || unicode.IsNumber(r)
+		switch {
+		case word && start < 0:
+			start = i
+		case !word && start >= 0:
+			toks = append(toks, token{strings.ToLower(s[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		toks = append(toks, token{strings.ToLower(s[start:]), start, len(s)})
+	}
+	return toks
+}
+
+// Query is a parsed full-text query (see ParseQuery).
+type Query struct {
+	terms []term
+}
+
+type term struct {
+	words  []string // one word, or a phrase
+	prefix bool     // words[0] is a prefix (word*)
+	not    bool
+}
+
+// ParseQuery parses a full-text query. Terms are separated by spaces and
+// must all occur in a link's title, summary or notes, ignoring case:
+//
+//	word         the word
+//	"two words"  the words in a row, within one field
+//	sum*         a word starting with "sum"
+//	-word        excludes links with the word (or phrase)
+//
+// A term that holds punctuation, like "full-text", is a phrase of its words.
+func ParseQuery(s string) (Query, error) {
+	var q Query
+	for len(s) > 0 {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			break
+		}
+		var t term
+		if s[0] == '-' {
+			t.not = true
+			s = s[1:]
+		}
+		var text string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return Query{}, errors.New("fts: unterminated phrase")
+			}
+			text, s = s[1:1+end], s[2+end:]
+		} else {
+			end := strings.IndexFunc(s, unicode.IsSpace)
+			if end < 0 {
+				end = len(s)
+			}
+			text, s = s[:end], s[end:]
+			text, t.prefix = strings.CutSuffix(text, "*")
+		}
+		for _, tok := range tokenize(text) {
+			t.words = append(t.words, tok.word)
+		}
+		if len(t.words) == 0 {
+			continue
+		}
+		if t.prefix && len(t.words) > 1 {
+			return Query{}, fmt.Errorf("fts: %s*: a prefix must be a single word", text)
+		}
+		q.terms = append(q.terms, t)
+	}
+	if !slices.ContainsFunc(q.terms, func(t term) bool { return !t.not }) {
+		return Query{}, errors.New("fts: the query needs a word that isn't excluded")
+	}
+	return q, nil
+}
+
+// Hit is a link matching a query and its BM25 score.
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+// Search returns the links matching q, best first (by ID among equals).
+func (x *Index) Search(q Query) []Hit {
+	var cand map[string][]uint32
+	matches := make([]map[string][]uint32, len(q.terms))
+	for i, t := range q.terms {
+		matches[i] = x.match(t)
+		if t.not {
+			continue
+		}
+		if cand == nil || len(matches[i]) < len(cand) {
+			cand = matches[i]
+		}
+	}
+
+	var total float64
+	for _, d := range x.docs {
+		total += float64(docLen(d))
+	}
+	n := float64(len(x.docs))
+	avg := max(total/n, 1)
+	var hits []Hit
+	for id := range cand {
+		d := x.docs[id]
+		score := 0.0
+		ok := true
+		for i, t := range q.terms {
+			pos, found := matches[i][id]
+			if found == t.not {
+				ok = false
+				break
+			}
+			if t.not {
+				continue
+			}
+			df := float64(len(matches[i]))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			tf := 0.0
+			for _, p := range pos {
+				if p < d.TitleWords {
+					tf += titleWeight
+				} else {
+					tf++
+				}
+			}
+			score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(docLen(d))/avg))
+		}
+		if ok {
+			hits = append(hits, Hit{ID: id, Score: score})
+		}
+	}
+	slices.SortFunc(hits, func(a, b Hit) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), strings.Compare(a.ID, b.ID))
+	})
+	return hits
+}
+
+// docLen is d's length for BM25, weighted as its term frequencies are.
+func docLen(d *v1.SearchDoc) uint32 {
+	return d.TitleWords*titleWeight + d.SummaryWords + d.NotesWords
+}
+
+// match returns the positions where t starts in each link it occurs in.
+func (x *Index) match(t term) map[string][]uint32 {
+	if t.prefix {
+		out := map[string][]uint32{}
+		for w, p := range x.terms {
+			if strings.HasPrefix(w, t.words[0]) {
+				for _, ps := range p.Postings {
+					out[ps.Id] = append(out[ps.Id], ps.Positions...)
+				}
+			}
+		}
+		return out
+	}
+	out := map[string][]uint32{}
+	first := x.terms[t.words[0]]
+	if first == nil {
+		return out
+	}
+	if len(t.words) == 1 {
+		for _, ps := range first.Postings {
+			out[ps.Id] = ps.Positions
+		}
+		return out
+	}
+	// A phrase: every next word one position further, all in one field.
+	rest := make([]map[string][]uint32, len(t.words)-1)
+	for i, w := range t.words[1:] {
+		rest[i] = map[string][]uint32{}
+		if p := x.terms[w]; p != nil {
+			for _, ps := range p.Postings {
+				rest[i][ps.Id] = ps.Positions
+			}
+		}
+	}
+	for _, ps := range first.Postings {
+		d := x.docs[ps.Id]
+		for _, p := range ps.Positions {
+			last := p + uint32(len(rest))
+			if field(d, p) != field(d, last) {
+				continue
+			}
+			ok := true
+			for i, m := range rest {
+				if _, found := slices.BinarySearch(m[ps.Id], p+uint32(i)+1); !found {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				out[ps.Id] = append(out[ps.Id], p)
+			}
+		}
+	}
+	return out
+}
+
+// field tells which of d's fields the word at pos is in: 0 title,
+// 1 summary, 2 notes.
+func field(d *v1.SearchDoc, pos uint32) int {
+	switch {
+	case pos < d.TitleWords:
+		return 0
+	case pos < d.TitleWords+d.SummaryWords:
+		return 1
+	}
+	return 2
+}
+
+// matches reports whether word matches one of q's terms that aren't
+// excluded, for highlighting.
+func (q Query) matches(word string) bool {
+	for _, t := range q.terms {
+		if t.not {
+			continue
+		}
+		if t.prefix && strings.HasPrefix(word, t.words[0]) || !t.prefix && slices.Contains(t.words, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mark returns text with every word matching q passed through mark.
+func Mark(text string, q Query, mark func(string) string) string {
+	return markRange(text, tokenize(text), 0, len(text), q, mark)
+}
+
+// Excerpt returns about width characters of text around the first word
+// matching q, with the matching words passed through mark and "…" where
+// text was cut; "" if no word matches. Line breaks become spaces.
+func Excerpt(text string, q Query, width int, mark func(string) string) string {
+	toks := tokenize(text)
+	first := slices.IndexFunc(toks, func(t token) bool { return q.matches(t.word) })
+	if first < 0 {
+		return ""
+	}
+	// Start a third of the width before the match, at a word.
+	from := first
+	for from > 0 && utf8.RuneCountInString(text[toks[from-1].start:toks[first].start]) < width/3 {
+		from--
+	}
+	start := toks[from].start
+	if from == 0 {
+		start = 0
+	}
+	end := toks[first].end
+	for i := first + 1; i < len(toks) && utf8.RuneCountInString(text[start:toks[i].end]) <= width; i++ {
+		end = toks[i].end
+	}
+	if last := toks[len(toks)-1]; end == last.end {
+		end = len(text)
+	}
+	out := strings.Join(strings.Fields(markRange(text, toks, start, end, q, mark)), " ")
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(text) {
+		out += "…"
+	}
+	return out
+}
+
+// markRange is text[start:end] with the words of toks matching q marked.
+func markRange(text string, toks []token, start, end int, q Query, mark func(string) string) string {
+	var sb strings.Builder
+	at := start
+	for _, t := range toks {
+		if t.start < start || t.end > end || !q.matches(t.word) {
+			continue
+		}
+		sb.WriteString(text[at:t.start])
+		sb.WriteString(mark(text[t.start:t.end]))
+		at = t.end
+	}
+	sb.WriteString(text[at:end])
+	return sb.String()
+}
@@ -0,0 +1,103 @@
+// Package linkcheck probes link URLs to detect link rot.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	v1 "github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1"
+)
+
+// Options control a Check run.
+type Options struct {
+	// Concurrency bounds in-flight requests (default 8).
+	Concurrency int
+	// Timeout applies to each request, including the GET fallback (default 10s).
+	Timeout time.Duration
+	// Client is used for requests (default http.DefaultClient).
+	Client *http.Client
+}
+
+// Result is the outcome for one link.
+type Result struct {
+	Link   *v1.Link
+	Status int    // final HTTP status; 0 when the request failed
+	Method string // method that produced Status (HEAD or GET)
+	Err    error  // connection/timeout error, if any
+	// FinalURL is where redirects ended up; empty if there were none.
+	FinalURL string
+}
+
+// OK reports whether the link answered with a non-error status.
+func (r Result) OK() bool { return r.Err == nil && r.Status < 400 }
+
+// TimedOut reports whether the request failed by running out of time.
+func (r Result) TimedOut() bool {
+	var ne net.Error
+	return errors.Is(r.Err, context.DeadlineExceeded) || (errors.As(r.Err, &ne) && ne.Timeout())
+}
+
+// Check requests every link's URL and returns results in link order. It
+// tries HEAD first and falls back to GET when HEAD fails or is refused,
+// since plenty of servers mishandle HEAD.
+func Check(ctx context.Context, links []*v1.Link, opts Options) []Result {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	results := make([]Result, len(links))
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for i, l := range links {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			results[i] = checkOne(ctx, l, opts)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func checkOne(ctx context.Context, l *v1.Link, opts Options) Result {
+	r := Result{Link: l, Method: http.MethodHead}
+	r.Status, r.FinalURL, r.Err = probe(ctx, http.MethodHead, l.Url, opts)
+	if r.Err != nil || r.Status >= 400 {
+		r.Method = http.MethodGet
+		r.Status, r.FinalURL, r.Err = probe(ctx, http.MethodGet, l.Url, opts)
+	}
+	return r
+}
+
+// probe returns the final status and, if redirects were followed, the URL
+// they led to.
+func probe(ctx context.Context, method, url string, opts Options) (int, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("User-Agent", "linkleaf-check/1")
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	resp.Body.Close()
+	final := ""
+	if u := resp.Request.URL.String(); u != req.URL.String() {
+		final = u
+	}
+	return resp.StatusCode, final, nil
+}
@@ -0,0 +1,72 @@
+package pagemeta
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords are frequent short words that tell a language apart from the
+// others listed; words several of them share are left out.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "for", "with", "that", "this", "how", "you", "are", "from", "your", "what", "why", "about", "an", "it", "on"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "für", "ein", "eine", "auf", "den", "dem", "wie", "zu", "von", "ich", "sie", "wir", "auch"},
+	"fr": {"le", "la", "les", "et", "des", "est", "pour", "dans", "une", "du", "que", "qui", "sur", "pas", "avec", "au", "vous", "nous", "ce", "comment"},
+	"es": {"el", "los", "las", "y", "es", "del", "por", "para", "una", "con", "que", "se", "como", "su", "lo", "más", "qué", "cómo", "al", "sus"},
+	"it": {"il", "di", "che", "è", "per", "gli", "della", "delle", "con", "non", "sono", "una", "come", "anche", "nel", "alla", "dei", "più", "questo", "perché"},
+	"nl": {"de", "het", "een", "van", "en", "is", "niet", "met", "voor", "op", "zijn", "ook", "wat", "hoe", "je", "dat", "maar", "bij", "naar", "wordt"},
+	"pt": {"o", "os", "e", "do", "da", "dos", "das", "em", "não", "uma", "com", "para", "que", "como", "mais", "é", "por", "seu", "sua", "também"},
+}
+
+// DetectLang guesses the language of text, a page's title and description
+// say: ja, ko or zh from their scripts, else the language of stopwords
+// whose words occur in it most, if at least two do and no other language
+// comes close. It returns "" when it can't tell.
+func DetectLang(text string) string {
+	var kana, hangul, han, letters int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.IsLetter(r):
+			letters++
+		}
+	}
+	switch {
+	case kana > 0 && kana+han > letters:
+		return "ja"
+	case hangul > letters:
+		return "ko"
+	case han > letters:
+		return "zh"
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) })
+	scores := map[string]int{}
+	for lang, sw := range stopwords {
+		for _, w := range words {
+			for _, s := range sw {
+				if w == s {
+					scores[lang]++
+					break
+				}
+			}
+		}
+	}
+	best, first, second := "", 0, 0
+	for lang, n := range scores {
+		switch {
+		case n > first:
+			best, first, second = lang, n, first
+		case n > second:
+			second = n
+		}
+	}
+	if first < 2 || first < second*3/2+1 {
+		return ""
+	}
+	return best
+}
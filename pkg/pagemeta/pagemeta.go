@@ -0,0 +1,232 @@
+// Package pagemeta fetches a web page and extracts the metadata linkleaf
+// uses to pre-fill links: title and description, from OpenGraph tags or
+// the plain HTML equivalents, the page's preview image and icon, its
+// language and how many words it has.
+package pagemeta
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent is sent when Options.UserAgent is empty.
+const DefaultUserAgent = "linkleaf-fetch/1"
+
+// maxPage bounds how much of a page is read for its metadata and words.
+const maxPage = 1 << 20
+
+// Options control a Fetch.
+type Options struct {
+	// Timeout bounds the whole request (default 10s).
+	Timeout time.Duration
+	// UserAgent is sent with the request (default DefaultUserAgent).
+	UserAgent string
+	// Client is used for the request (default http.DefaultClient).
+	Client *http.Client
+}
+
+// Meta is what a page says about itself. Fields are empty when absent.
+type Meta struct {
+	Title       string // og:title, else <title>
+	Description string // og:description, else <meta name="description">
+	SiteName    string // og:site_name
+	Image       string // og:image, else twitter:image
+	Icon        string // <link rel="icon">, else rel="apple-touch-icon"
+	// Lang is the language the page declares (<html lang>, a
+	// Content-Language meta or header, og:locale), else the one DetectLang
+	// guesses from the title and description; "" if neither tells.
+	Lang string
+	// Words is how many words the page's text has (see CountWords).
+	Words int
+}
+
+// Fetch GETs url, parses its metadata (see Parse) and counts its words
+// (see CountWords). Non-2xx responses and non-HTML content are errors.
+// Image and Icon are resolved against the URL the page ended up at.
+func Fetch(ctx context.Context, url string, opts Options) (Meta, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Meta{}, err
+	}
+	req.Header.Set("User-Agent", opts.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return Meta{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Meta{}, fmt.Errorf("fetch %s: %s", url, resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		if mt, _, _ := mime.ParseMediaType(ct); mt != "text/html" && mt != "application/xhtml+xml" {
+			return Meta{}, fmt.Errorf("fetch %s: not an HTML page (%s)", url, mt)
+		}
+	}
+	page, err := io.ReadAll(io.LimitReader(resp.Body, maxPage))
+	if err != nil {
+		return Meta{}, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	m := Parse(bytes.NewReader(page))
+	m.Words = CountWords(page)
+	if cl := resp.Header.Get("Content-Language"); m.Lang == "" && !strings.Contains(cl, ",") {
+		m.Lang = strings.TrimSpace(cl) // a list names several audiences, not the page's language
+	}
+	if m.Lang == "" {
+		m.Lang = DetectLang(m.Title + "\n" + m.Description)
+	}
+	m.Image = resolve(resp.Request.URL, m.Image)
+	m.Icon = resolve(resp.Request.URL, m.Icon)
+	return m, nil
+}
+
+// resolve makes ref absolute against base; refs that don't parse or
+// aren't http(s) become "".
+func resolve(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
+
+// Parse extracts metadata from an HTML document, stopping at <body>. It
+// is lenient: malformed markup ends the scan and returns what was found.
+// Lang is only what the markup declares; Parse doesn't guess.
+func Parse(r io.Reader) Meta {
+	// encoding/xml in non-strict mode copes with the head of most real
+	// pages, which is all we need.
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+	d.CharsetReader = func(_ string, in io.Reader) (io.Reader, error) { return in, nil }
+
+	var m, og Meta
+	var touchIcon, httpLang, locale string
+	var inTitle bool
+	var title strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil { // EOF or markup we can't follow
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch strings.ToLower(t.Name.Local) {
+			case "body":
+				return pick(og, m, title.String(), touchIcon, httpLang, locale)
+			case "html":
+				for _, a := range t.Attr {
+					if strings.EqualFold(a.Name.Local, "lang") && a.Value != "" {
+						m.Lang = a.Value
+					}
+				}
+			case "title":
+				inTitle = title.Len() == 0
+			case "meta":
+				name, content, equiv := "", "", ""
+				for _, a := range t.Attr {
+					switch strings.ToLower(a.Name.Local) {
+					case "name", "property":
+						name = strings.ToLower(a.Value)
+					case "content":
+						content = a.Value
+					case "http-equiv":
+						equiv = strings.ToLower(a.Value)
+					}
+				}
+				if equiv == "content-language" && !strings.Contains(content, ",") {
+					httpLang = content
+				}
+				switch name {
+				case "description":
+					m.Description = content
+				case "og:title":
+					og.Title = content
+				case "og:description":
+					og.Description = content
+				case "og:site_name":
+					og.SiteName = content
+				case "og:locale":
+					locale = content
+				case "og:image", "og:image:url":
+					if og.Image == "" {
+						og.Image = content
+					}
+				case "twitter:image":
+					m.Image = content
+				}
+			case "link":
+				rel, href := "", ""
+				for _, a := range t.Attr {
+					switch strings.ToLower(a.Name.Local) {
+					case "rel":
+						rel = strings.ToLower(a.Value)
+					case "href":
+						href = a.Value
+					}
+				}
+				switch {
+				case rel == "apple-touch-icon":
+					touchIcon = href
+				case slices.Contains(strings.Fields(rel), "icon") && m.Icon == "":
+					m.Icon = href
+				}
+			}
+		case xml.EndElement:
+			if strings.EqualFold(t.Name.Local, "title") {
+				inTitle = false
+			}
+		case xml.CharData:
+			if inTitle {
+				title.Write(t)
+			}
+		}
+	}
+	return pick(og, m, title.String(), touchIcon, httpLang, locale)
+}
+
+// pick prefers OpenGraph values and normalizes whitespace. The language
+// comes from <html lang>, else the Content-Language meta, else og:locale.
+func pick(og, m Meta, title, touchIcon, httpLang, locale string) Meta {
+	first := func(vals ...string) string {
+		for _, v := range vals {
+			if v = strings.Join(strings.Fields(v), " "); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	return Meta{
+		Title:       first(og.Title, title),
+		Description: first(og.Description, m.Description),
+		SiteName:    first(og.SiteName),
+		Image:       strings.TrimSpace(first(og.Image, m.Image)),
+		Icon:        strings.TrimSpace(first(m.Icon, touchIcon)),
+		Lang:        strings.TrimSpace(first(m.Lang, httpLang, locale)),
+	}
+}
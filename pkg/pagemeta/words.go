@@ -0,0 +1,144 @@
+package pagemeta
+
+import (
+	"bytes"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// rawText elements hold text that isn't markup, which CountWords skips to
+// their end tag.
+var rawText = []string{"script", "style", "textarea", "title"}
+
+// chrome are the elements around a page's content rather than in it:
+// their text isn't counted.
+var chrome = map[string]bool{
+	"head": true, "nav": true, "header": true, "footer": true, "aside": true, "form": true,
+	"button": true, "select": true, "svg": true, "noscript": true, "template": true, "figcaption": true,
+}
+
+// CountWords counts the words of an HTML page's text: those in its
+// <article> elements if it has any, else in <main>, else in the whole
+// page, leaving out scripts, styles, navigation, headers, footers, asides
+// and forms. A word is a run of non-space characters with a letter or
+// digit in it. Malformed markup is skipped as well as can be; the count is
+// an estimate.
+func CountWords(page []byte) int {
+	var all, article, main int
+	var inArticle, inMain, skip int // nesting depths
+	p := page
+	for len(p) > 0 {
+		lt := bytes.IndexByte(p, '<')
+		if lt < 0 {
+			lt = len(p)
+		}
+		if skip == 0 {
+			n := countText(p[:lt])
+			all += n
+			if inArticle > 0 {
+				article += n
+			}
+			if inMain > 0 {
+				main += n
+			}
+		}
+		p = p[lt:]
+		if len(p) == 0 {
+			break
+		}
+		if bytes.HasPrefix(p, []byte("<!--")) {
+			p = after(p, "-->")
+			continue
+		}
+		name, closing, end := scanTag(p)
+		selfClosing := end >= 2 && p[end-2] == '/' && p[end-1] == '>'
+		p = p[end:]
+		switch {
+		case name == "" || selfClosing && !closing && chrome[name]:
+			// "<" that opens no tag, "<!DOCTYPE", "<?xml" or an empty <svg/>
+		case !closing && slices.Contains(rawText, name):
+			p = after(p, "</"+name)
+		case name == "article":
+			inArticle = depth(inArticle, closing)
+		case name == "main":
+			inMain = depth(inMain, closing)
+		case chrome[name]:
+			skip = depth(skip, closing)
+		}
+	}
+	switch {
+	case article > 0:
+		return article
+	case main > 0:
+		return main
+	}
+	return all
+}
+
+func depth(d int, closing bool) int {
+	if closing {
+		return max(d-1, 0)
+	}
+	return d + 1
+}
+
+// scanTag reads the tag p starts with: its lowercased name ("" if p
+// doesn't start a tag), whether it's an end tag, and where it ends. Quoted
+// attribute values may hold '>'.
+func scanTag(p []byte) (name string, closing bool, end int) {
+	i := 1
+	if i < len(p) && p[i] == '/' {
+		closing = true
+		i++
+	}
+	start := i
+	for i < len(p) && (p[i] >= 'a' && p[i] <= 'z' || p[i] >= 'A' && p[i] <= 'Z' || p[i] >= '0' && p[i] <= '9' || p[i] == '-') {
+		i++
+	}
+	if i == start {
+		if i < len(p) && (p[i] == '!' || p[i] == '?') {
+			return "", false, len(p) - len(after(p, ">"))
+		}
+		return "", false, 1
+	}
+	name = strings.ToLower(string(p[start:i]))
+	var quote byte
+	for ; i < len(p); i++ {
+		switch c := p[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return name, closing, i + 1
+		}
+	}
+	return name, closing, len(p)
+}
+
+// after returns what follows the first sep in p, matched ignoring ASCII
+// case, or nothing if p has no sep.
+func after(p []byte, sep string) []byte {
+	lower := bytes.ToLower(p)
+	if i := bytes.Index(lower, []byte(sep)); i >= 0 {
+		return p[i+len(sep):]
+	}
+	return nil
+}
+
+func countText(b []byte) int {
+	if len(b) == 0 {
+		return 0
+	}
+	n := 0
+	for _, w := range strings.Fields(html.UnescapeString(string(b))) {
+		if strings.IndexFunc(w, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,166 @@
+package qrcode
+
+// interleave splits data into version's blocks at level, appends each
+// block's Reed-Solomon error correction and interleaves the blocks.
+func interleave(data []byte, version int, level Level) []byte {
+	numBlocks := eccBlocks[level][version]
+	eccLen := eccPerBlock[level][version]
+	raw := rawDataModules(version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		dat := data[k : k+n : k+n]
+		k += n
+		ecc := rsRemainder(dat, divisor)
+		if i < numShort {
+			dat = append(dat, 0) // padding, skipped when interleaving
+		}
+		blocks[i] = append(dat, ecc...)
+	}
+
+	out := make([]byte, 0, raw)
+	for i := range blocks[0] {
+		for j, b := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				out = append(out, b[i])
+			}
+		}
+	}
+	return out
+}
+
+// rsDivisor is the generator polynomial of the given degree, highest
+// coefficient (always 1) dropped.
+func rsDivisor(degree int) []byte {
+	d := make([]byte, degree)
+	d[degree-1] = 1
+	root := byte(1)
+	for range degree {
+		for j := range d {
+			d[j] = gfMul(d[j], root)
+			if j+1 < degree {
+				d[j] ^= d[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return d
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	r := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ r[0]
+		copy(r, r[1:])
+		r[len(r)-1] = 0
+		for i, d := range divisor {
+			r[i] ^= gfMul(d, factor)
+		}
+	}
+	return r
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+// Penalty weights of the mask evaluation.
+const (
+	penaltyRun    = 3  // a run of 5 same-colored modules, +1 per extra module
+	penaltyBox    = 3  // a 2×2 block of one color
+	penaltyFinder = 40 // a finder-like 1:1:3:1:1 pattern
+	penaltyDark   = 10 // per 5% the dark share is off 50%
+)
+
+// penalty scores the code as masked: lower is easier to scan.
+func (c *Code) penalty() int {
+	score := 0
+	for _, vertical := range []bool{false, true} {
+		for a := range c.Size {
+			color, run := false, 0
+			var history [7]int
+			for b := range c.Size {
+				m := c.modules[a][b]
+				if vertical {
+					m = c.modules[b][a]
+				}
+				if m == color {
+					run++
+					if run == 5 {
+						score += penaltyRun
+					} else if run > 5 {
+						score++
+					}
+					continue
+				}
+				c.addHistory(run, &history)
+				if !color {
+					score += finderPatterns(&history) * penaltyFinder
+				}
+				color, run = m, 1
+			}
+			if color {
+				c.addHistory(run, &history)
+				run = 0
+			}
+			c.addHistory(run+c.Size, &history) // the light quiet zone
+			score += finderPatterns(&history) * penaltyFinder
+		}
+	}
+	dark := 0
+	for y := range c.Size {
+		for x := range c.Size {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				m := c.modules[y][x]
+				if m == c.modules[y][x+1] && m == c.modules[y+1][x] && m == c.modules[y+1][x+1] {
+					score += penaltyBox
+				}
+			}
+		}
+	}
+	total := c.Size * c.Size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return score + k*penaltyDark
+}
+
+// addHistory records a finished run; the first, light, run of a line
+// counts the quiet zone before it.
+func (c *Code) addHistory(run int, h *[7]int) {
+	if h[0] == 0 {
+		run += c.Size
+	}
+	copy(h[1:], h[:6])
+	h[0] = run
+}
+
+// finderPatterns counts the 1:1:3:1:1 patterns with light space on one
+// side that the last runs in h end.
+func finderPatterns(h *[7]int) int {
+	n := h[1]
+	core := n > 0 && h[2] == n && h[3] == n*3 && h[4] == n && h[5] == n
+	count := 0
+	if core && h[0] >= n*4 && h[6] >= n {
+		count++
+	}
+	if core && h[6] >= n*4 && h[0] >= n {
+		count++
+	}
+	return count
+}
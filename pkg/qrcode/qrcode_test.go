@@ -0,0 +1,183 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	tests := []struct {
+		name       string
+		data, want []byte
+	}{
+		// Version 1-M "HELLO WORLD" in alphanumeric mode, as worked through
+		// in the usual tutorials of the standard.
+		{
+			"1-M",
+			[]byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17},
+			[]byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23},
+		},
+		// Annex I of ISO/IEC 18004: "01234567" in numeric mode, 1-M.
+		{
+			"annex I",
+			[]byte{16, 32, 12, 86, 97, 128, 236, 17, 236, 17, 236, 17, 236, 17, 236, 17},
+			[]byte{165, 36, 212, 193, 237, 54, 199, 135, 44, 85},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rsRemainder(tt.data, rsDivisor(len(tt.want))); !bytes.Equal(got, tt.want) {
+				t.Errorf("rsRemainder = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	// The format information of the standard's table, most significant
+	// bit first.
+	tests := []struct {
+		level Level
+		mask  int
+		want  string
+	}{
+		{Low, 0, "111011111000100"},
+		{Low, 4, "110011000101111"},
+		{Medium, 0, "101010000010010"},
+		{Quartile, 0, "011010101011111"},
+		{High, 0, "001011010001001"},
+		{High, 7, "000100000111011"},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(int(tt.level))+"/"+strconv.Itoa(tt.mask), func(t *testing.T) {
+			c := newCode(1)
+			c.drawFormatBits(tt.level, tt.mask)
+			// The copy next to the top-left finder, bit 0 at the top.
+			var got int
+			for i, at := range [15][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+				if c.modules[at[1]][at[0]] {
+					got |= 1 << i
+				}
+			}
+			if want, _ := strconv.ParseInt(tt.want, 2, 0); got != int(want) {
+				t.Errorf("format bits = %015b, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	tests := []struct {
+		version int
+		want    string
+	}{
+		{7, "000111110010010100"},
+		{8, "001000010110111100"},
+		{40, "101000110001101001"},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.version), func(t *testing.T) {
+			c := newCode(tt.version)
+			c.drawVersion()
+			var got int
+			for i := range 18 {
+				if c.modules[i/3][c.Size-11+i%3] {
+					got |= 1 << i
+				}
+			}
+			if want, _ := strconv.ParseInt(tt.want, 2, 0); got != int(want) {
+				t.Errorf("version bits = %018b, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeVersion(t *testing.T) {
+	// Byte mode capacities of the standard's table.
+	tests := []struct {
+		name    string
+		n       int
+		level   Level
+		want    int
+		wantErr error
+	}{
+		{"1-L full", 17, Low, 1, nil},
+		{"1-L overflow", 18, Low, 2, nil},
+		{"1-M full", 14, Medium, 1, nil},
+		{"1-Q full", 11, Quartile, 1, nil},
+		{"1-H full", 7, High, 1, nil},
+		{"1-H overflow", 8, High, 2, nil},
+		{"10-L full", 271, Low, 10, nil},
+		{"40-L full", 2953, Low, 40, nil},
+		{"40-H full", 1273, High, 40, nil},
+		{"too long", 2954, Low, 0, ErrTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Encode(strings.Repeat("a", tt.n), tt.level)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Encode(%d bytes) error = %v, want %v", tt.n, err, tt.wantErr)
+			}
+			if err == nil && (c.Version != tt.want || c.Size != 4*tt.want+17) {
+				t.Errorf("Encode(%d bytes) = version %d, size %d; want %d", tt.n, c.Version, c.Size, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	// Checked with an independent decoder.
+	want := []string{
+		"#######.#####.#######",
+		"#.....#.##.##.#.....#",
+		"#.###.#..###..#.###.#",
+		"#.###.#..#.##.#.###.#",
+		"#.###.#.#..##.#.###.#",
+		"#.....#.#.#...#.....#",
+		"#######.#.#.#.#######",
+		"........###.#........",
+		"###..##.###.#####..##",
+		".##..#.#...####.##.##",
+		".#.##.##....#..####.#",
+		".##.##.....#.#.###...",
+		"#...#.###...##.....#.",
+		"........#.#.##..##..#",
+		"#######..#..#..####.#",
+		"#.....#.###..#..##..#",
+		"#.###.#...#.##.#.....",
+		"#.###.#..##.#####....",
+		"#.###.#.###.#..######",
+		"#.....#.##..#..###...",
+		"#######.#....#.##...#",
+	}
+	c, err := Encode("https://go.dev", Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := -1; y <= c.Size; y++ {
+		var row strings.Builder
+		for x := -1; x <= c.Size; x++ {
+			if c.Dark(x, y) {
+				row.WriteByte('#')
+			} else {
+				row.WriteByte('.')
+			}
+		}
+		got := row.String()
+		if y < 0 || y == c.Size {
+			if strings.Contains(got, "#") {
+				t.Errorf("quiet zone row %d = %s, want all light", y, got)
+			}
+			continue
+		}
+		if got[0] == '#' || got[len(got)-1] == '#' {
+			t.Errorf("quiet zone of row %d is dark", y)
+		}
+		if got = got[1 : len(got)-1]; got != want[y] {
+			t.Errorf("row %d = %s, want %s", y, got, want[y])
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package qrcode
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// QuietZone is the light border, in modules, scanners need around a code.
+const QuietZone = 4
+
+// Terminal renders c as text for a terminal, two rows of modules per line
+// in half blocks, with ANSI colors forcing dark on light so the code
+// scans on dark terminal themes too.
+func (c *Code) Terminal(w io.Writer) error {
+	var b strings.Builder
+	for y := -QuietZone; y < c.Size+QuietZone; y += 2 {
+		b.WriteString("\x1b[30;47m") // black on white
+		for x := -QuietZone; x < c.Size+QuietZone; x++ {
+			top, bottom := c.Dark(x, y), c.Dark(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Image renders c with scale pixels per module, quiet zone included.
+func (c *Code) Image(scale int) image.Image {
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for py := range side {
+		for px := range side {
+			if c.Dark(px/scale-QuietZone, py/scale-QuietZone) {
+				img.SetColorIndex(px, py, 1)
+			}
+		}
+	}
+	return img
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// gsBackend reads and writes gs://bucket/object through the Cloud Storage
+// XML API with an OAuth access token from $GOOGLE_OAUTH_ACCESS_TOKEN
+// (e.g. `gcloud auth print-access-token`).
+type gsBackend struct{}
+
+func (gsBackend) Get(ctx context.Context, loc string) ([]byte, error) {
+	req, err := gsRequest(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return nil, err
+	}
+	return do(req)
+}
+
+func (gsBackend) Put(ctx context.Context, loc string, data []byte) error {
+	req, err := gsRequest(ctx, http.MethodPut, loc, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	_, err = do(req)
+	return err
+}
+
+func gsRequest(ctx context.Context, method, loc string, body []byte) (*http.Request, error) {
+	bucket, object, err := splitBucketURL(loc)
+	if err != nil {
+		return nil, err
+	}
+	tok := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if tok == "" {
+		return nil, errors.New("gs: no credentials (set GOOGLE_OAUTH_ACCESS_TOKEN)")
+	}
+	target := "https://storage.googleapis.com/" + bucket + "/" + escapePath(object)
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+	return req, nil
+}
+
+// splitBucketURL splits scheme://bucket/key/with/slashes.
+func splitBucketURL(loc string) (bucket, key string, err error) {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return "", "", err
+	}
+	key = strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return "", "", errors.New(u.Scheme + ": want " + u.Scheme + "://bucket/key, got " + loc)
+	}
+	return u.Host, key, nil
+}
+
+// escapePath percent-encodes every byte of key except unreserved
+// characters and '/', as both S3 signing and GCS object names expect.
+func escapePath(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			b.WriteString("%" + strings.ToUpper(hexByte(c)))
+		}
+	}
+	return b.String()
+}
+
+func hexByte(c byte) string {
+	const digits = "0123456789abcdef"
+	return string([]byte{digits[c>>4], digits[c&0xf]})
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// httpBackend GETs and PUTs the file at an http(s) URL. Credentials come
+// from user:password in the URL (Basic) or $LINKLEAF_HTTP_TOKEN (Bearer).
+type httpBackend struct{}
+
+func (httpBackend) Get(ctx context.Context, loc string) ([]byte, error) {
+	req, err := httpRequest(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return nil, err
+	}
+	return do(req)
+}
+
+func (httpBackend) Put(ctx context.Context, loc string, data []byte) error {
+	req, err := httpRequest(ctx, http.MethodPut, loc, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	_, err = do(req)
+	return err
+}
+
+func httpRequest(ctx context.Context, method, loc string, body []byte) (*http.Request, error) {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return nil, err
+	}
+	user := u.User
+	u.User = nil
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		pw, _ := user.Password()
+		req.SetBasicAuth(user.Username(), pw)
+	} else if tok := os.Getenv("LINKLEAF_HTTP_TOKEN"); tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	return req, nil
+}
@@ -0,0 +1,137 @@
+// Package storage reads and writes whole feed files at local paths or
+// remote URLs (s3://, gs://, http(s)://). Callers pass locations as given
+// by the user; Get and Put pick the backend from the URL scheme.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend stores whole files at locations of one URL scheme.
+type Backend interface {
+	// Get returns the file at loc, or an error wrapping os.ErrNotExist
+	// if there is none.
+	Get(ctx context.Context, loc string) ([]byte, error)
+	// Put replaces the file at loc with data.
+	Put(ctx context.Context, loc string, data []byte) error
+}
+
+// Timeout bounds each remote request made by the built-in backends.
+var Timeout = 60 * time.Second
+
+var (
+	mu       sync.RWMutex
+	backends = map[string]Backend{
+		"http":  httpBackend{},
+		"https": httpBackend{},
+		"s3":    s3Backend{},
+		"gs":    gsBackend{},
+	}
+)
+
+// Register makes b handle locations starting with scheme+"://",
+// replacing any built-in backend for it.
+func Register(scheme string, b Backend) {
+	mu.Lock()
+	defer mu.Unlock()
+	backends[strings.ToLower(scheme)] = b
+}
+
+func backend(loc string) Backend {
+	scheme, _, ok := strings.Cut(loc, "://")
+	if !ok {
+		return nil
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	return backends[strings.ToLower(scheme)]
+}
+
+// IsRemote reports whether loc is a URL handled by a registered backend
+// rather than a local path.
+func IsRemote(loc string) bool { return backend(loc) != nil }
+
+// Get reads the file at loc: a local path or a remote URL.
+func Get(ctx context.Context, loc string) ([]byte, error) {
+	if b := backend(loc); b != nil {
+		return b.Get(ctx, loc)
+	}
+	return os.ReadFile(loc)
+}
+
+// Put writes data to loc; local files are replaced atomically (see
+// WriteFileAtomic) with mode perm.
+func Put(ctx context.Context, loc string, data []byte, perm os.FileMode) error {
+	if b := backend(loc); b != nil {
+		return b.Put(ctx, loc, data)
+	}
+	return WriteFileAtomic(loc, data, perm)
+}
+
+// WriteFileAtomic writes data to a temp file in the target directory and
+// renames it over path, so readers never observe a partially written file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// do sends req and returns the body of a 2xx response. 404 is reported
+// as os.ErrNotExist, other statuses as errors quoting the response.
+func do(req *http.Request) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), Timeout)
+	defer cancel()
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%s %s: %w", req.Method, redact(req), os.ErrNotExist)
+	case resp.StatusCode/100 != 2:
+		detail := strings.TrimSpace(string(body))
+		if len(detail) > 200 {
+			detail = detail[:200] + "…"
+		}
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, redact(req), resp.Status, detail)
+	}
+	return body, nil
+}
+
+func redact(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	return u.String()
+}
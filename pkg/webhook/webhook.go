@@ -0,0 +1,136 @@
+// Package webhook delivers signed JSON notifications over HTTP POST, with
+// retries.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultUserAgent is sent when Options.UserAgent is empty.
+const DefaultUserAgent = "linkleaf-webhook/1"
+
+// Headers set on every delivery besides Content-Type and User-Agent.
+const (
+	// EventHeader names the event, e.g. "feed.changed".
+	EventHeader = "X-Linkleaf-Event"
+	// DeliveryHeader is a random ID, the same on every attempt, so
+	// receivers can drop a retried delivery they already handled.
+	DeliveryHeader = "X-Linkleaf-Delivery"
+	// SignatureHeader is "sha256=" and the hex HMAC-SHA256 of the body
+	// keyed with the secret (see Sign); set only with a secret.
+	SignatureHeader = "X-Linkleaf-Signature-256"
+)
+
+// Options control a delivery.
+type Options struct {
+	// Secret keys the body signature; empty sends none.
+	Secret string
+	// Attempts is how often to try before giving up (default 3). Only
+	// network errors, 408, 429 and 5xx answers are retried.
+	Attempts int
+	// Backoff is the wait before the second attempt, doubled after each
+	// further failure (default 1s).
+	Backoff time.Duration
+	// Timeout bounds each attempt (default 10s).
+	Timeout time.Duration
+	// UserAgent is sent with the request (default DefaultUserAgent).
+	UserAgent string
+	// Client is used for the requests (default http.DefaultClient).
+	Client *http.Client
+}
+
+func (o *Options) defaults() {
+	if o.Attempts <= 0 {
+		o.Attempts = 3
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.UserAgent == "" {
+		o.UserAgent = DefaultUserAgent
+	}
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+}
+
+// Sign returns the SignatureHeader value for body: "sha256=" and the hex
+// HMAC-SHA256 of body keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is Sign(secret, body), in constant time.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, body)))
+}
+
+// Post delivers the JSON body to url as event, retrying per opts. It
+// returns the last error once every attempt failed.
+func Post(ctx context.Context, url, event string, body []byte, opts Options) error {
+	opts.defaults()
+	id := make([]byte, 16)
+	rand.Read(id)
+	delivery := hex.EncodeToString(id)
+
+	wait := opts.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		if retry, err = post(ctx, url, event, delivery, body, opts); err == nil {
+			return nil
+		}
+		if !retry || attempt >= opts.Attempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// post makes one attempt and reports whether a failure is worth retrying.
+func post(ctx context.Context, url, event, delivery string, body []byte, opts Options) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", opts.UserAgent)
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, delivery)
+	if opts.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(opts.Secret, body))
+	}
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= 500
+	return retry, fmt.Errorf("POST %s: %s", url, resp.Status)
+}
@@ -0,0 +1,208 @@
+// Package webmention sends Webmentions (https://www.w3.org/TR/webmention/):
+// it discovers a page's endpoint and notifies it that a source page links
+// to the page.
+package webmention
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent is sent when Options.UserAgent is empty.
+const DefaultUserAgent = "linkleaf-webmention/1"
+
+// maxPage bounds how much of a target page is read looking for the endpoint.
+const maxPage = 1 << 20
+
+// ErrNoEndpoint is returned by Discover (and Mention) for pages that don't
+// accept Webmentions.
+var ErrNoEndpoint = errors.New("no webmention endpoint")
+
+// Options control Discover and Send.
+type Options struct {
+	// Timeout bounds each request (default 10s).
+	Timeout time.Duration
+	// UserAgent is sent with every request (default DefaultUserAgent).
+	UserAgent string
+	// Client is used for the requests (default http.DefaultClient).
+	Client *http.Client
+}
+
+func (o *Options) defaults() {
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.UserAgent == "" {
+		o.UserAgent = DefaultUserAgent
+	}
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+}
+
+// Discover GETs target and returns its Webmention endpoint: the first
+// rel="webmention" Link header, else the first <link> or <a> with that
+// rel, resolved against the final URL after redirects.
+func Discover(ctx context.Context, target string, opts Options) (string, error) {
+	opts.defaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", opts.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+	base := resp.Request.URL
+	if href, ok := linkHeader(resp.Header.Values("Link")); ok {
+		return resolve(base, href)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		if mt, _, _ := mime.ParseMediaType(ct); mt != "text/html" && mt != "application/xhtml+xml" {
+			return "", ErrNoEndpoint
+		}
+	}
+	if href, ok := htmlLink(io.LimitReader(resp.Body, maxPage)); ok {
+		return resolve(base, href)
+	}
+	return "", ErrNoEndpoint
+}
+
+// Send notifies endpoint that source links to target. It returns the
+// status URL the endpoint gave (a 201's Location), if any.
+func Send(ctx context.Context, endpoint, source, target string, opts Options) (string, error) {
+	opts.defaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	form := url.Values{"source": {source}, "target": {target}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", opts.UserAgent)
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if detail := strings.Join(strings.Fields(string(b)), " "); detail != "" && len(detail) < 200 {
+			return "", fmt.Errorf("POST %s: %s: %s", endpoint, resp.Status, detail)
+		}
+		return "", fmt.Errorf("POST %s: %s", endpoint, resp.Status)
+	}
+	if loc, err := resp.Location(); err == nil {
+		return loc.String(), nil
+	}
+	return "", nil
+}
+
+// Mention discovers target's endpoint and sends it a mention from source.
+func Mention(ctx context.Context, source, target string, opts Options) (string, error) {
+	endpoint, err := Discover(ctx, target, opts)
+	if err != nil {
+		return "", err
+	}
+	return Send(ctx, endpoint, source, target, opts)
+}
+
+func resolve(base *url.URL, href string) (string, error) {
+	u, err := base.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", fmt.Errorf("bad webmention endpoint %q: %w", href, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("bad webmention endpoint %q", href)
+	}
+	return u.String(), nil
+}
+
+// isWebmention reports whether a rel attribute or parameter lists
+// "webmention" among its space-separated values.
+func isWebmention(rel string) bool {
+	for _, r := range strings.Fields(rel) {
+		if strings.EqualFold(r, "webmention") {
+			return true
+		}
+	}
+	return false
+}
+
+// linkHeader finds a rel="webmention" target in Link headers, e.g.
+// `<https://example.com/wm>; rel="webmention"`.
+func linkHeader(values []string) (string, bool) {
+	for _, v := range values {
+		for v != "" {
+			start := strings.IndexByte(v, '<')
+			end := strings.IndexByte(v, '>')
+			if start < 0 || end < start {
+				break
+			}
+			href := v[start+1 : end]
+			params, rest, _ := strings.Cut(v[end+1:], ",")
+			for _, p := range strings.Split(params, ";") {
+				k, val, ok := strings.Cut(strings.TrimSpace(p), "=")
+				if ok && strings.EqualFold(strings.TrimSpace(k), "rel") && isWebmention(strings.Trim(strings.TrimSpace(val), `"`)) {
+					return href, true
+				}
+			}
+			v = rest
+		}
+	}
+	return "", false
+}
+
+// htmlLink finds the first <link> or <a> with rel="webmention" and an
+// href (which may be empty: the page itself). Like pagemeta.Parse it is
+// lenient and stops at markup it can't follow.
+func htmlLink(r io.Reader) (string, bool) {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+	d.CharsetReader = func(_ string, in io.Reader) (io.Reader, error) { return in, nil }
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", false
+		}
+		t, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if name := strings.ToLower(t.Name.Local); name != "link" && name != "a" {
+			continue
+		}
+		var rel, href string
+		var hasHref bool
+		for _, a := range t.Attr {
+			switch strings.ToLower(a.Name.Local) {
+			case "rel":
+				rel = a.Value
+			case "href":
+				href, hasHref = a.Value, true
+			}
+		}
+		if hasHref && isWebmention(rel) {
+			return href, true
+		}
+	}
+}
@@ -26,8 +26,26 @@ type Feed struct {
 	Version uint32                 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
 	Title   string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
 	// RFC3339 UTC (e.g., 2025-08-18T12:34:56Z), set when you publish.
-	GeneratedAt   string  `protobuf:"bytes,3,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
-	Links         []*Link `protobuf:"bytes,4,rep,name=links,proto3" json:"links,omitempty"`
+	GeneratedAt string  `protobuf:"bytes,3,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	Links       []*Link `protobuf:"bytes,4,rep,name=links,proto3" json:"links,omitempty"`
+	// Who the feed is by (a person or a team); in exports it takes the place
+	// of the config's author.name.
+	Author string `protobuf:"bytes,5,opt,name=author,proto3" json:"author,omitempty"`
+	// RSS and Atom feeds "linkleaf subscribe pull" imports new items from.
+	Subscriptions []*Subscription `protobuf:"bytes,6,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	// What the feed is about; the channel description of its exports.
+	Description string `protobuf:"bytes,7,opt,name=description,proto3" json:"description,omitempty"`
+	// The site the feed belongs to, its exports' home page link.
+	HomePageUrl string `protobuf:"bytes,8,opt,name=home_page_url,json=homePageUrl,proto3" json:"home_page_url,omitempty"`
+	// URL of an image standing for the feed (RSS image, Atom icon, JSON Feed
+	// icon, the HTML page's favicon).
+	Icon string `protobuf:"bytes,9,opt,name=icon,proto3" json:"icon,omitempty"`
+	// BCP 47 language tag of the feed as a whole (e.g., en, de-AT); exports
+	// fall back to the language its links share.
+	Lang string `protobuf:"bytes,10,opt,name=lang,proto3" json:"lang,omitempty"`
+	// Links "linkleaf remove" took out, newest first; "linkleaf trash" lists,
+	// restores and purges them.
+	Trash         []*TrashedLink `protobuf:"bytes,11,rep,name=trash,proto3" json:"trash,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -90,6 +108,55 @@ func (x *Feed) GetLinks() []*Link {
 	return nil
 }
 
+func (x *Feed) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Feed) GetSubscriptions() []*Subscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+func (x *Feed) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Feed) GetHomePageUrl() string {
+	if x != nil {
+		return x.HomePageUrl
+	}
+	return ""
+}
+
+func (x *Feed) GetIcon() string {
+	if x != nil {
+		return x.Icon
+	}
+	return ""
+}
+
+func (x *Feed) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *Feed) GetTrash() []*TrashedLink {
+	if x != nil {
+		return x.Trash
+	}
+	return nil
+}
+
 type Link struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Stable short ID (e.g., hash(url + "|" + date)).
@@ -101,7 +168,62 @@ type Link struct {
 	// YYYY-MM-DD
 	Date string `protobuf:"bytes,6,opt,name=date,proto3" json:"date,omitempty"`
 	// Optional attribution ("via" URL).
-	Via           string `protobuf:"bytes,7,opt,name=via,proto3" json:"via,omitempty"`
+	Via string `protobuf:"bytes,7,opt,name=via,proto3" json:"via,omitempty"`
+	// RFC3339 UTC time the link was added (finer than date; used for ordering).
+	AddedAt string `protobuf:"bytes,8,opt,name=added_at,json=addedAt,proto3" json:"added_at,omitempty"`
+	// Outcome of the most recent "linkleaf check -annotate"; unset if never checked.
+	LastCheck *LinkCheck `protobuf:"bytes,9,opt,name=last_check,json=lastCheck,proto3" json:"last_check,omitempty"`
+	// RFC3339 UTC time of the last change to the link; unset until it is edited.
+	UpdatedAt string `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Long-form commentary beyond the one-line summary; blank lines separate paragraphs.
+	Notes string `protobuf:"bytes,11,opt,name=notes,proto3" json:"notes,omitempty"`
+	// Read-later state: set once the link has been read.
+	Read bool `protobuf:"varint,12,opt,name=read,proto3" json:"read,omitempty"`
+	// Set once the link is archived: kept for reference, no longer current.
+	Archived bool `protobuf:"varint,13,opt,name=archived,proto3" json:"archived,omitempty"`
+	// Favorite, for picking links out of a long feed.
+	Starred bool `protobuf:"varint,14,opt,name=starred,proto3" json:"starred,omitempty"`
+	// Preserved copy of the page made by "linkleaf archive": a Wayback Machine
+	// URL, or the path of a local snapshot.
+	ArchiveUrl string `protobuf:"bytes,15,opt,name=archive_url,json=archiveUrl,proto3" json:"archive_url,omitempty"`
+	// Who added the link, in a feed several people write to; unset means the
+	// feed's author.
+	Author string `protobuf:"bytes,16,opt,name=author,proto3" json:"author,omitempty"`
+	// IDs of other links in the feed this one refers to (follow-ups, parts of
+	// a series), in the order they were related; see "linkleaf relate".
+	RelatedIds []string `protobuf:"bytes,17,rep,name=related_ids,json=relatedIds,proto3" json:"related_ids,omitempty"`
+	// Free-form data the schema has no field for (rating, source, project,
+	// ...); keys contain no whitespace or '='.
+	Meta map[string]string `protobuf:"bytes,18,rep,name=meta,proto3" json:"meta,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Media file the link comes with (a podcast episode, a video); exported as
+	// RSS <enclosure> and JSON Feed attachments.
+	Enclosure *Enclosure `protobuf:"bytes,19,opt,name=enclosure,proto3" json:"enclosure,omitempty"`
+	// Drafts stay out of exports, "build" and "serve" until "linkleaf
+	// publish" releases them.
+	Draft bool `protobuf:"varint,20,opt,name=draft,proto3" json:"draft,omitempty"`
+	// RFC3339 UTC time before which the link stays out of exports, "build"
+	// and "serve"; unset means right away.
+	PublishAt string `protobuf:"bytes,21,opt,name=publish_at,json=publishAt,proto3" json:"publish_at,omitempty"`
+	// The checks before last_check, oldest first, at most 10.
+	CheckHistory []*LinkCheck `protobuf:"bytes,22,rep,name=check_history,json=checkHistory,proto3" json:"check_history,omitempty"`
+	// Short name of the link's permalink page (/l/<slug> in "serve" and
+	// "build"), unique within the feed; made from the title when added.
+	Slug string `protobuf:"bytes,23,opt,name=slug,proto3" json:"slug,omitempty"`
+	// Language of the page, a BCP 47 tag such as "en" or "de-AT"; from the
+	// page when fetched, else -lang. Unset means unknown.
+	Lang string `protobuf:"bytes,24,opt,name=lang,proto3" json:"lang,omitempty"`
+	// Words in the page's text, counted when it is fetched; 0 means not
+	// counted.
+	WordCount uint32 `protobuf:"varint,25,opt,name=word_count,json=wordCount,proto3" json:"word_count,omitempty"`
+	// Estimated minutes to read the page, from word_count (see
+	// feed.ReadingMinutes); 0 means unknown.
+	ReadingMinutes uint32 `protobuf:"varint,26,opt,name=reading_minutes,json=readingMinutes,proto3" json:"reading_minutes,omitempty"`
+	// Readable copy of the page's article saved by "linkleaf save" for
+	// "linkleaf read": the path of a plain text file.
+	ArticlePath string `protobuf:"bytes,27,opt,name=article_path,json=articlePath,proto3" json:"article_path,omitempty"`
+	// Passages quoted from the page, in the order they were added; see
+	// "linkleaf quote".
+	Quotes        []string `protobuf:"bytes,28,rep,name=quotes,proto3" json:"quotes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -185,24 +307,954 @@ func (x *Link) GetVia() string {
 	return ""
 }
 
-var File_linkleaf_v1_feed_proto protoreflect.FileDescriptor
+func (x *Link) GetAddedAt() string {
+	if x != nil {
+		return x.AddedAt
+	}
+	return ""
+}
 
-const file_linkleaf_v1_feed_proto_rawDesc = "" +
-	"\n" +
-	"\x16linkleaf/v1/feed.proto\x12\vlinkleaf.v1\"\x82\x01\n" +
-	"\x04Feed\x12\x18\n" +
-	"\aversion\x18\x01 \x01(\rR\aversion\x12\x14\n" +
-	"\x05title\x18\x02 \x01(\tR\x05title\x12!\n" +
-	"\fgenerated_at\x18\x03 \x01(\tR\vgeneratedAt\x12'\n" +
-	"\x05links\x18\x04 \x03(\v2\x11.linkleaf.v1.LinkR\x05links\"\x92\x01\n" +
-	"\x04Link\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
-	"\x05title\x18\x02 \x01(\tR\x05title\x12\x10\n" +
-	"\x03url\x18\x03 \x01(\tR\x03url\x12\x18\n" +
-	"\asummary\x18\x04 \x01(\tR\asummary\x12\x12\n" +
-	"\x04tags\x18\x05 \x03(\tR\x04tags\x12\x12\n" +
-	"\x04date\x18\x06 \x01(\tR\x04date\x12\x10\n" +
-	"\x03via\x18\a \x01(\tR\x03viaB:Z8github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1;v1b\x06proto3"
+func (x *Link) GetLastCheck() *LinkCheck {
+	if x != nil {
+		return x.LastCheck
+	}
+	return nil
+}
+
+func (x *Link) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+func (x *Link) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *Link) GetRead() bool {
+	if x != nil {
+		return x.Read
+	}
+	return false
+}
+
+func (x *Link) GetArchived() bool {
+	if x != nil {
+		return x.Archived
+	}
+	return false
+}
+
+func (x *Link) GetStarred() bool {
+	if x != nil {
+		return x.Starred
+	}
+	return false
+}
+
+func (x *Link) GetArchiveUrl() string {
+	if x != nil {
+		return x.ArchiveUrl
+	}
+	return ""
+}
+
+func (x *Link) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Link) GetRelatedIds() []string {
+	if x != nil {
+		return x.RelatedIds
+	}
+	return nil
+}
+
+func (x *Link) GetMeta() map[string]string {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+func (x *Link) GetEnclosure() *Enclosure {
+	if x != nil {
+		return x.Enclosure
+	}
+	return nil
+}
+
+func (x *Link) GetDraft() bool {
+	if x != nil {
+		return x.Draft
+	}
+	return false
+}
+
+func (x *Link) GetPublishAt() string {
+	if x != nil {
+		return x.PublishAt
+	}
+	return ""
+}
+
+func (x *Link) GetCheckHistory() []*LinkCheck {
+	if x != nil {
+		return x.CheckHistory
+	}
+	return nil
+}
+
+func (x *Link) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *Link) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *Link) GetWordCount() uint32 {
+	if x != nil {
+		return x.WordCount
+	}
+	return 0
+}
+
+func (x *Link) GetReadingMinutes() uint32 {
+	if x != nil {
+		return x.ReadingMinutes
+	}
+	return 0
+}
+
+func (x *Link) GetArticlePath() string {
+	if x != nil {
+		return x.ArticlePath
+	}
+	return ""
+}
+
+func (x *Link) GetQuotes() []string {
+	if x != nil {
+		return x.Quotes
+	}
+	return nil
+}
+
+type Enclosure struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// MIME type, e.g. "audio/mpeg".
+	MimeType string `protobuf:"bytes,2,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	// Size in bytes; 0 when unknown.
+	Length        int64 `protobuf:"varint,3,opt,name=length,proto3" json:"length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Enclosure) Reset() {
+	*x = Enclosure{}
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Enclosure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Enclosure) ProtoMessage() {}
+
+func (x *Enclosure) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Enclosure.ProtoReflect.Descriptor instead.
+func (*Enclosure) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_feed_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Enclosure) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Enclosure) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *Enclosure) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+// Subscription is a source feed registered with "linkleaf subscribe add".
+type Subscription struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// URL of the RSS or Atom document.
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Name to show instead of the URL; optional.
+	Title string `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	// Tags every link imported from it gets, besides the item's categories.
+	Tags []string `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	// RFC3339 UTC time it was added.
+	AddedAt string `protobuf:"bytes,4,opt,name=added_at,json=addedAt,proto3" json:"added_at,omitempty"`
+	// RFC3339 UTC time of the last pull that reached it.
+	PulledAt string `protobuf:"bytes,5,opt,name=pulled_at,json=pulledAt,proto3" json:"pulled_at,omitempty"`
+	// Why the last pull failed; unset when it succeeded.
+	Error string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	// ETag and Last-Modified of the last response, sent back so an unchanged
+	// feed isn't downloaded again.
+	Etag         string `protobuf:"bytes,7,opt,name=etag,proto3" json:"etag,omitempty"`
+	LastModified string `protobuf:"bytes,8,opt,name=last_modified,json=lastModified,proto3" json:"last_modified,omitempty"`
+	// Normalized URLs of the items the feed held at the last pull: those
+	// aren't imported again, even once removed from the feed.
+	Seen          []string `protobuf:"bytes,9,rep,name=seen,proto3" json:"seen,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subscription) Reset() {
+	*x = Subscription{}
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscription) ProtoMessage() {}
+
+func (x *Subscription) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
+func (*Subscription) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_feed_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Subscription) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Subscription) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Subscription) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Subscription) GetAddedAt() string {
+	if x != nil {
+		return x.AddedAt
+	}
+	return ""
+}
+
+func (x *Subscription) GetPulledAt() string {
+	if x != nil {
+		return x.PulledAt
+	}
+	return ""
+}
+
+func (x *Subscription) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Subscription) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *Subscription) GetLastModified() string {
+	if x != nil {
+		return x.LastModified
+	}
+	return ""
+}
+
+func (x *Subscription) GetSeen() []string {
+	if x != nil {
+		return x.Seen
+	}
+	return nil
+}
+
+// TrashedLink is a removed link kept for "linkleaf trash restore" until it
+// is purged.
+type TrashedLink struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Link  *Link                  `protobuf:"bytes,1,opt,name=link,proto3" json:"link,omitempty"`
+	// RFC3339 UTC time it was removed.
+	RemovedAt string `protobuf:"bytes,2,opt,name=removed_at,json=removedAt,proto3" json:"removed_at,omitempty"`
+	// Its position in the feed's links when removed, where restore puts it
+	// back.
+	Index int32 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	// IDs of the links whose related_ids held it; restore relates them again.
+	ReferencedBy  []string `protobuf:"bytes,4,rep,name=referenced_by,json=referencedBy,proto3" json:"referenced_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrashedLink) Reset() {
+	*x = TrashedLink{}
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrashedLink) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrashedLink) ProtoMessage() {}
+
+func (x *TrashedLink) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrashedLink.ProtoReflect.Descriptor instead.
+func (*TrashedLink) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_feed_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TrashedLink) GetLink() *Link {
+	if x != nil {
+		return x.Link
+	}
+	return nil
+}
+
+func (x *TrashedLink) GetRemovedAt() string {
+	if x != nil {
+		return x.RemovedAt
+	}
+	return ""
+}
+
+func (x *TrashedLink) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *TrashedLink) GetReferencedBy() []string {
+	if x != nil {
+		return x.ReferencedBy
+	}
+	return nil
+}
+
+// LinkCheck records one probe of a link's URL.
+type LinkCheck struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// RFC3339 UTC time of the check.
+	CheckedAt string `protobuf:"bytes,1,opt,name=checked_at,json=checkedAt,proto3" json:"checked_at,omitempty"`
+	// Final HTTP status; 0 when the request failed.
+	Status int32 `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	// Connection/timeout error, if any.
+	Error string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	// URL after redirects, if it differs from Link.url.
+	FinalUrl string `protobuf:"bytes,4,opt,name=final_url,json=finalUrl,proto3" json:"final_url,omitempty"`
+	// Failed checks in a row, up to and including this one; 0 when it
+	// succeeded.
+	Failures      int32 `protobuf:"varint,5,opt,name=failures,proto3" json:"failures,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkCheck) Reset() {
+	*x = LinkCheck{}
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkCheck) ProtoMessage() {}
+
+func (x *LinkCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkCheck.ProtoReflect.Descriptor instead.
+func (*LinkCheck) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_feed_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LinkCheck) GetCheckedAt() string {
+	if x != nil {
+		return x.CheckedAt
+	}
+	return ""
+}
+
+func (x *LinkCheck) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *LinkCheck) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *LinkCheck) GetFinalUrl() string {
+	if x != nil {
+		return x.FinalUrl
+	}
+	return ""
+}
+
+func (x *LinkCheck) GetFailures() int32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+// ShardIndex is the index.pb of a sharded feed: a directory holding the
+// feed's links in one Feed file per year (2024.pb, 2023.pb, ...; undated.pb
+// for links without a valid date).
+type ShardIndex struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The feed's metadata; its links live in the shards.
+	Feed *Feed `protobuf:"bytes,1,opt,name=feed,proto3" json:"feed,omitempty"`
+	// Newest year first, the order the links are read in.
+	Shards        []*Shard `protobuf:"bytes,2,rep,name=shards,proto3" json:"shards,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardIndex) Reset() {
+	*x = ShardIndex{}
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardIndex) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardIndex) ProtoMessage() {}
+
+func (x *ShardIndex) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardIndex.ProtoReflect.Descriptor instead.
+func (*ShardIndex) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_feed_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ShardIndex) GetFeed() *Feed {
+	if x != nil {
+		return x.Feed
+	}
+	return nil
+}
+
+func (x *ShardIndex) GetShards() []*Shard {
+	if x != nil {
+		return x.Shards
+	}
+	return nil
+}
+
+type Shard struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// File name in the feed's directory, e.g. "2024.pb".
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Links int32  `protobuf:"varint,2,opt,name=links,proto3" json:"links,omitempty"`
+	// Hex SHA-256 of the file, so saving rewrites only the shards that
+	// changed and -verify can check them.
+	Sha256        string `protobuf:"bytes,3,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Shard) Reset() {
+	*x = Shard{}
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Shard) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shard) ProtoMessage() {}
+
+func (x *Shard) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shard.ProtoReflect.Descriptor instead.
+func (*Shard) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_feed_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Shard) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Shard) GetLinks() int32 {
+	if x != nil {
+		return x.Links
+	}
+	return 0
+}
+
+func (x *Shard) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+// SearchIndex is the full-text index "search -fts" keeps next to a feed
+// (<file>.fts): the words of each link's title, summary and notes.
+type SearchIndex struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Docs  []*SearchDoc           `protobuf:"bytes,1,rep,name=docs,proto3" json:"docs,omitempty"`
+	// Word (lower case) → the links it occurs in.
+	Terms         map[string]*SearchPostings `protobuf:"bytes,2,rep,name=terms,proto3" json:"terms,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchIndex) Reset() {
+	*x = SearchIndex{}
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchIndex) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchIndex) ProtoMessage() {}
+
+func (x *SearchIndex) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchIndex.ProtoReflect.Descriptor instead.
+func (*SearchIndex) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_feed_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SearchIndex) GetDocs() []*SearchDoc {
+	if x != nil {
+		return x.Docs
+	}
+	return nil
+}
+
+func (x *SearchIndex) GetTerms() map[string]*SearchPostings {
+	if x != nil {
+		return x.Terms
+	}
+	return nil
+}
+
+// SearchDoc is one indexed link. Positions in its postings count words
+// across the title, summary and notes, in that order.
+type SearchDoc struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// FNV-1a hash of the indexed text, to tell when the link needs indexing
+	// again.
+	Hash          uint64 `protobuf:"fixed64,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	TitleWords    uint32 `protobuf:"varint,3,opt,name=title_words,json=titleWords,proto3" json:"title_words,omitempty"`
+	SummaryWords  uint32 `protobuf:"varint,4,opt,name=summary_words,json=summaryWords,proto3" json:"summary_words,omitempty"`
+	NotesWords    uint32 `protobuf:"varint,5,opt,name=notes_words,json=notesWords,proto3" json:"notes_words,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchDoc) Reset() {
+	*x = SearchDoc{}
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchDoc) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchDoc) ProtoMessage() {}
+
+func (x *SearchDoc) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchDoc.ProtoReflect.Descriptor instead.
+func (*SearchDoc) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_feed_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SearchDoc) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SearchDoc) GetHash() uint64 {
+	if x != nil {
+		return x.Hash
+	}
+	return 0
+}
+
+func (x *SearchDoc) GetTitleWords() uint32 {
+	if x != nil {
+		return x.TitleWords
+	}
+	return 0
+}
+
+func (x *SearchDoc) GetSummaryWords() uint32 {
+	if x != nil {
+		return x.SummaryWords
+	}
+	return 0
+}
+
+func (x *SearchDoc) GetNotesWords() uint32 {
+	if x != nil {
+		return x.NotesWords
+	}
+	return 0
+}
+
+type SearchPostings struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Postings      []*SearchPosting       `protobuf:"bytes,1,rep,name=postings,proto3" json:"postings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchPostings) Reset() {
+	*x = SearchPostings{}
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchPostings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchPostings) ProtoMessage() {}
+
+func (x *SearchPostings) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchPostings.ProtoReflect.Descriptor instead.
+func (*SearchPostings) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_feed_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SearchPostings) GetPostings() []*SearchPosting {
+	if x != nil {
+		return x.Postings
+	}
+	return nil
+}
+
+type SearchPosting struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Positions     []uint32               `protobuf:"varint,2,rep,packed,name=positions,proto3" json:"positions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchPosting) Reset() {
+	*x = SearchPosting{}
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchPosting) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchPosting) ProtoMessage() {}
+
+func (x *SearchPosting) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_feed_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchPosting.ProtoReflect.Descriptor instead.
+func (*SearchPosting) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_feed_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SearchPosting) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SearchPosting) GetPositions() []uint32 {
+	if x != nil {
+		return x.Positions
+	}
+	return nil
+}
+
+var File_linkleaf_v1_feed_proto protoreflect.FileDescriptor
+
+const file_linkleaf_v1_feed_proto_rawDesc = "" +
+	"\n" +
+	"\x16linkleaf/v1/feed.proto\x12\vlinkleaf.v1\"\xf9\x02\n" +
+	"\x04Feed\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\rR\aversion\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12!\n" +
+	"\fgenerated_at\x18\x03 \x01(\tR\vgeneratedAt\x12'\n" +
+	"\x05links\x18\x04 \x03(\v2\x11.linkleaf.v1.LinkR\x05links\x12\x16\n" +
+	"\x06author\x18\x05 \x01(\tR\x06author\x12?\n" +
+	"\rsubscriptions\x18\x06 \x03(\v2\x19.linkleaf.v1.SubscriptionR\rsubscriptions\x12 \n" +
+	"\vdescription\x18\a \x01(\tR\vdescription\x12\"\n" +
+	"\rhome_page_url\x18\b \x01(\tR\vhomePageUrl\x12\x12\n" +
+	"\x04icon\x18\t \x01(\tR\x04icon\x12\x12\n" +
+	"\x04lang\x18\n" +
+	" \x01(\tR\x04lang\x12.\n" +
+	"\x05trash\x18\v \x03(\v2\x18.linkleaf.v1.TrashedLinkR\x05trash\"\xfa\x06\n" +
+	"\x04Link\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12\x18\n" +
+	"\asummary\x18\x04 \x01(\tR\asummary\x12\x12\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\x12\x12\n" +
+	"\x04date\x18\x06 \x01(\tR\x04date\x12\x10\n" +
+	"\x03via\x18\a \x01(\tR\x03via\x12\x19\n" +
+	"\badded_at\x18\b \x01(\tR\aaddedAt\x125\n" +
+	"\n" +
+	"last_check\x18\t \x01(\v2\x16.linkleaf.v1.LinkCheckR\tlastCheck\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\tR\tupdatedAt\x12\x14\n" +
+	"\x05notes\x18\v \x01(\tR\x05notes\x12\x12\n" +
+	"\x04read\x18\f \x01(\bR\x04read\x12\x1a\n" +
+	"\barchived\x18\r \x01(\bR\barchived\x12\x18\n" +
+	"\astarred\x18\x0e \x01(\bR\astarred\x12\x1f\n" +
+	"\varchive_url\x18\x0f \x01(\tR\n" +
+	"archiveUrl\x12\x16\n" +
+	"\x06author\x18\x10 \x01(\tR\x06author\x12\x1f\n" +
+	"\vrelated_ids\x18\x11 \x03(\tR\n" +
+	"relatedIds\x12/\n" +
+	"\x04meta\x18\x12 \x03(\v2\x1b.linkleaf.v1.Link.MetaEntryR\x04meta\x124\n" +
+	"\tenclosure\x18\x13 \x01(\v2\x16.linkleaf.v1.EnclosureR\tenclosure\x12\x14\n" +
+	"\x05draft\x18\x14 \x01(\bR\x05draft\x12\x1d\n" +
+	"\n" +
+	"publish_at\x18\x15 \x01(\tR\tpublishAt\x12;\n" +
+	"\rcheck_history\x18\x16 \x03(\v2\x16.linkleaf.v1.LinkCheckR\fcheckHistory\x12\x12\n" +
+	"\x04slug\x18\x17 \x01(\tR\x04slug\x12\x12\n" +
+	"\x04lang\x18\x18 \x01(\tR\x04lang\x12\x1d\n" +
+	"\n" +
+	"word_count\x18\x19 \x01(\rR\twordCount\x12'\n" +
+	"\x0freading_minutes\x18\x1a \x01(\rR\x0ereadingMinutes\x12!\n" +
+	"\farticle_path\x18\x1b \x01(\tR\varticlePath\x12\x16\n" +
+	"\x06quotes\x18\x1c \x03(\tR\x06quotes\x1a7\n" +
+	"\tMetaEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"R\n" +
+	"\tEnclosure\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x1b\n" +
+	"\tmime_type\x18\x02 \x01(\tR\bmimeType\x12\x16\n" +
+	"\x06length\x18\x03 \x01(\x03R\x06length\"\xe5\x01\n" +
+	"\fSubscription\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12\x19\n" +
+	"\badded_at\x18\x04 \x01(\tR\aaddedAt\x12\x1b\n" +
+	"\tpulled_at\x18\x05 \x01(\tR\bpulledAt\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x12\x12\n" +
+	"\x04etag\x18\a \x01(\tR\x04etag\x12#\n" +
+	"\rlast_modified\x18\b \x01(\tR\flastModified\x12\x12\n" +
+	"\x04seen\x18\t \x03(\tR\x04seen\"\x8e\x01\n" +
+	"\vTrashedLink\x12%\n" +
+	"\x04link\x18\x01 \x01(\v2\x11.linkleaf.v1.LinkR\x04link\x12\x1d\n" +
+	"\n" +
+	"removed_at\x18\x02 \x01(\tR\tremovedAt\x12\x14\n" +
+	"\x05index\x18\x03 \x01(\x05R\x05index\x12#\n" +
+	"\rreferenced_by\x18\x04 \x03(\tR\freferencedBy\"\x91\x01\n" +
+	"\tLinkCheck\x12\x1d\n" +
+	"\n" +
+	"checked_at\x18\x01 \x01(\tR\tcheckedAt\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\x05R\x06status\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x1b\n" +
+	"\tfinal_url\x18\x04 \x01(\tR\bfinalUrl\x12\x1a\n" +
+	"\bfailures\x18\x05 \x01(\x05R\bfailures\"_\n" +
+	"\n" +
+	"ShardIndex\x12%\n" +
+	"\x04feed\x18\x01 \x01(\v2\x11.linkleaf.v1.FeedR\x04feed\x12*\n" +
+	"\x06shards\x18\x02 \x03(\v2\x12.linkleaf.v1.ShardR\x06shards\"I\n" +
+	"\x05Shard\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05links\x18\x02 \x01(\x05R\x05links\x12\x16\n" +
+	"\x06sha256\x18\x03 \x01(\tR\x06sha256\"\xcb\x01\n" +
+	"\vSearchIndex\x12*\n" +
+	"\x04docs\x18\x01 \x03(\v2\x16.linkleaf.v1.SearchDocR\x04docs\x129\n" +
+	"\x05terms\x18\x02 \x03(\v2#.linkleaf.v1.SearchIndex.TermsEntryR\x05terms\x1aU\n" +
+	"\n" +
+	"TermsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x121\n" +
+	"\x05value\x18\x02 \x01(\v2\x1b.linkleaf.v1.SearchPostingsR\x05value:\x028\x01\"\x96\x01\n" +
+	"\tSearchDoc\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\x06R\x04hash\x12\x1f\n" +
+	"\vtitle_words\x18\x03 \x01(\rR\n" +
+	"titleWords\x12#\n" +
+	"\rsummary_words\x18\x04 \x01(\rR\fsummaryWords\x12\x1f\n" +
+	"\vnotes_words\x18\x05 \x01(\rR\n" +
+	"notesWords\"H\n" +
+	"\x0eSearchPostings\x126\n" +
+	"\bpostings\x18\x01 \x03(\v2\x1a.linkleaf.v1.SearchPostingR\bpostings\"=\n" +
+	"\rSearchPosting\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
+	"\tpositions\x18\x02 \x03(\rR\tpositionsB:Z8github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1;v1b\x06proto3"
 
 var (
 	file_linkleaf_v1_feed_proto_rawDescOnce sync.Once
@@ -216,18 +1268,43 @@ func file_linkleaf_v1_feed_proto_rawDescGZIP() []byte {
 	return file_linkleaf_v1_feed_proto_rawDescData
 }
 
-var file_linkleaf_v1_feed_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_linkleaf_v1_feed_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_linkleaf_v1_feed_proto_goTypes = []any{
-	(*Feed)(nil), // 0: linkleaf.v1.Feed
-	(*Link)(nil), // 1: linkleaf.v1.Link
+	(*Feed)(nil),           // 0: linkleaf.v1.Feed
+	(*Link)(nil),           // 1: linkleaf.v1.Link
+	(*Enclosure)(nil),      // 2: linkleaf.v1.Enclosure
+	(*Subscription)(nil),   // 3: linkleaf.v1.Subscription
+	(*TrashedLink)(nil),    // 4: linkleaf.v1.TrashedLink
+	(*LinkCheck)(nil),      // 5: linkleaf.v1.LinkCheck
+	(*ShardIndex)(nil),     // 6: linkleaf.v1.ShardIndex
+	(*Shard)(nil),          // 7: linkleaf.v1.Shard
+	(*SearchIndex)(nil),    // 8: linkleaf.v1.SearchIndex
+	(*SearchDoc)(nil),      // 9: linkleaf.v1.SearchDoc
+	(*SearchPostings)(nil), // 10: linkleaf.v1.SearchPostings
+	(*SearchPosting)(nil),  // 11: linkleaf.v1.SearchPosting
+	nil,                    // 12: linkleaf.v1.Link.MetaEntry
+	nil,                    // 13: linkleaf.v1.SearchIndex.TermsEntry
 }
 var file_linkleaf_v1_feed_proto_depIdxs = []int32{
-	1, // 0: linkleaf.v1.Feed.links:type_name -> linkleaf.v1.Link
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	1,  // 0: linkleaf.v1.Feed.links:type_name -> linkleaf.v1.Link
+	3,  // 1: linkleaf.v1.Feed.subscriptions:type_name -> linkleaf.v1.Subscription
+	4,  // 2: linkleaf.v1.Feed.trash:type_name -> linkleaf.v1.TrashedLink
+	5,  // 3: linkleaf.v1.Link.last_check:type_name -> linkleaf.v1.LinkCheck
+	12, // 4: linkleaf.v1.Link.meta:type_name -> linkleaf.v1.Link.MetaEntry
+	2,  // 5: linkleaf.v1.Link.enclosure:type_name -> linkleaf.v1.Enclosure
+	5,  // 6: linkleaf.v1.Link.check_history:type_name -> linkleaf.v1.LinkCheck
+	1,  // 7: linkleaf.v1.TrashedLink.link:type_name -> linkleaf.v1.Link
+	0,  // 8: linkleaf.v1.ShardIndex.feed:type_name -> linkleaf.v1.Feed
+	7,  // 9: linkleaf.v1.ShardIndex.shards:type_name -> linkleaf.v1.Shard
+	9,  // 10: linkleaf.v1.SearchIndex.docs:type_name -> linkleaf.v1.SearchDoc
+	13, // 11: linkleaf.v1.SearchIndex.terms:type_name -> linkleaf.v1.SearchIndex.TermsEntry
+	11, // 12: linkleaf.v1.SearchPostings.postings:type_name -> linkleaf.v1.SearchPosting
+	10, // 13: linkleaf.v1.SearchIndex.TermsEntry.value:type_name -> linkleaf.v1.SearchPostings
+	14, // [14:14] is the sub-list for method output_type
+	14, // [14:14] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_linkleaf_v1_feed_proto_init() }
@@ -241,7 +1318,7 @@ func file_linkleaf_v1_feed_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_linkleaf_v1_feed_proto_rawDesc), len(file_linkleaf_v1_feed_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
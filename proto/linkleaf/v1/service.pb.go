@@ -0,0 +1,647 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v3.19.6
+// source: linkleaf/v1/service.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ChangeKind int32
+
+const (
+	ChangeKind_CHANGE_KIND_UNSPECIFIED ChangeKind = 0
+	ChangeKind_CHANGE_KIND_ADDED       ChangeKind = 1
+	ChangeKind_CHANGE_KIND_MODIFIED    ChangeKind = 2
+	ChangeKind_CHANGE_KIND_REMOVED     ChangeKind = 3
+)
+
+// Enum value maps for ChangeKind.
+var (
+	ChangeKind_name = map[int32]string{
+		0: "CHANGE_KIND_UNSPECIFIED",
+		1: "CHANGE_KIND_ADDED",
+		2: "CHANGE_KIND_MODIFIED",
+		3: "CHANGE_KIND_REMOVED",
+	}
+	ChangeKind_value = map[string]int32{
+		"CHANGE_KIND_UNSPECIFIED": 0,
+		"CHANGE_KIND_ADDED":       1,
+		"CHANGE_KIND_MODIFIED":    2,
+		"CHANGE_KIND_REMOVED":     3,
+	}
+)
+
+func (x ChangeKind) Enum() *ChangeKind {
+	p := new(ChangeKind)
+	*p = x
+	return p
+}
+
+func (x ChangeKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChangeKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_linkleaf_v1_service_proto_enumTypes[0].Descriptor()
+}
+
+func (ChangeKind) Type() protoreflect.EnumType {
+	return &file_linkleaf_v1_service_proto_enumTypes[0]
+}
+
+func (x ChangeKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChangeKind.Descriptor instead.
+func (ChangeKind) EnumDescriptor() ([]byte, []int) {
+	return file_linkleaf_v1_service_proto_rawDescGZIP(), []int{0}
+}
+
+type ListLinksRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Links must carry every one of these tags.
+	Tags []string `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	// URL host is this domain or a subdomain.
+	Domain string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Inclusive YYYY-MM-DD bounds on the link date.
+	After  string `protobuf:"bytes,3,opt,name=after,proto3" json:"after,omitempty"`
+	Before string `protobuf:"bytes,4,opt,name=before,proto3" json:"before,omitempty"`
+	// Search expression, as for "linkleaf search".
+	Query   string `protobuf:"bytes,5,opt,name=query,proto3" json:"query,omitempty"`
+	Unread  bool   `protobuf:"varint,6,opt,name=unread,proto3" json:"unread,omitempty"`
+	Starred bool   `protobuf:"varint,7,opt,name=starred,proto3" json:"starred,omitempty"`
+	// Paging over the matching links; limit 0 returns all.
+	Offset        uint32 `protobuf:"varint,8,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit         uint32 `protobuf:"varint,9,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLinksRequest) Reset() {
+	*x = ListLinksRequest{}
+	mi := &file_linkleaf_v1_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLinksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLinksRequest) ProtoMessage() {}
+
+func (x *ListLinksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLinksRequest.ProtoReflect.Descriptor instead.
+func (*ListLinksRequest) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListLinksRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ListLinksRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ListLinksRequest) GetAfter() string {
+	if x != nil {
+		return x.After
+	}
+	return ""
+}
+
+func (x *ListLinksRequest) GetBefore() string {
+	if x != nil {
+		return x.Before
+	}
+	return ""
+}
+
+func (x *ListLinksRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *ListLinksRequest) GetUnread() bool {
+	if x != nil {
+		return x.Unread
+	}
+	return false
+}
+
+func (x *ListLinksRequest) GetStarred() bool {
+	if x != nil {
+		return x.Starred
+	}
+	return false
+}
+
+func (x *ListLinksRequest) GetOffset() uint32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListLinksRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListLinksResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Links []*Link                `protobuf:"bytes,1,rep,name=links,proto3" json:"links,omitempty"`
+	// Set when more links match after this page.
+	More          bool `protobuf:"varint,2,opt,name=more,proto3" json:"more,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLinksResponse) Reset() {
+	*x = ListLinksResponse{}
+	mi := &file_linkleaf_v1_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLinksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLinksResponse) ProtoMessage() {}
+
+func (x *ListLinksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLinksResponse.ProtoReflect.Descriptor instead.
+func (*ListLinksResponse) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListLinksResponse) GetLinks() []*Link {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *ListLinksResponse) GetMore() bool {
+	if x != nil {
+		return x.More
+	}
+	return false
+}
+
+type AddLinkRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Link          *Link                  `protobuf:"bytes,1,opt,name=link,proto3" json:"link,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddLinkRequest) Reset() {
+	*x = AddLinkRequest{}
+	mi := &file_linkleaf_v1_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddLinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddLinkRequest) ProtoMessage() {}
+
+func (x *AddLinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddLinkRequest.ProtoReflect.Descriptor instead.
+func (*AddLinkRequest) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AddLinkRequest) GetLink() *Link {
+	if x != nil {
+		return x.Link
+	}
+	return nil
+}
+
+type UpdateLinkRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Link  *Link                  `protobuf:"bytes,1,opt,name=link,proto3" json:"link,omitempty"`
+	// Link fields to copy from link, by proto name (e.g. "title", "tags").
+	// Empty copies every field set in link.
+	Fields        []string `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateLinkRequest) Reset() {
+	*x = UpdateLinkRequest{}
+	mi := &file_linkleaf_v1_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateLinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateLinkRequest) ProtoMessage() {}
+
+func (x *UpdateLinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateLinkRequest.ProtoReflect.Descriptor instead.
+func (*UpdateLinkRequest) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UpdateLinkRequest) GetLink() *Link {
+	if x != nil {
+		return x.Link
+	}
+	return nil
+}
+
+func (x *UpdateLinkRequest) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type DeleteLinkRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteLinkRequest) Reset() {
+	*x = DeleteLinkRequest{}
+	mi := &file_linkleaf_v1_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteLinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteLinkRequest) ProtoMessage() {}
+
+func (x *DeleteLinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteLinkRequest.ProtoReflect.Descriptor instead.
+func (*DeleteLinkRequest) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeleteLinkRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteLinkResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Link          *Link                  `protobuf:"bytes,1,opt,name=link,proto3" json:"link,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteLinkResponse) Reset() {
+	*x = DeleteLinkResponse{}
+	mi := &file_linkleaf_v1_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteLinkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteLinkResponse) ProtoMessage() {}
+
+func (x *DeleteLinkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteLinkResponse.ProtoReflect.Descriptor instead.
+func (*DeleteLinkResponse) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeleteLinkResponse) GetLink() *Link {
+	if x != nil {
+		return x.Link
+	}
+	return nil
+}
+
+type WatchFeedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchFeedRequest) Reset() {
+	*x = WatchFeedRequest{}
+	mi := &file_linkleaf_v1_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchFeedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchFeedRequest) ProtoMessage() {}
+
+func (x *WatchFeedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchFeedRequest.ProtoReflect.Descriptor instead.
+func (*WatchFeedRequest) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_service_proto_rawDescGZIP(), []int{6}
+}
+
+type FeedEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Kind  ChangeKind             `protobuf:"varint,1,opt,name=kind,proto3,enum=linkleaf.v1.ChangeKind" json:"kind,omitempty"`
+	// The link after the change (before it, for CHANGE_KIND_REMOVED).
+	Link *Link `protobuf:"bytes,2,opt,name=link,proto3" json:"link,omitempty"`
+	// The feed's generated_at after the change.
+	GeneratedAt   string `protobuf:"bytes,3,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FeedEvent) Reset() {
+	*x = FeedEvent{}
+	mi := &file_linkleaf_v1_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FeedEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FeedEvent) ProtoMessage() {}
+
+func (x *FeedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v1_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FeedEvent.ProtoReflect.Descriptor instead.
+func (*FeedEvent) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v1_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FeedEvent) GetKind() ChangeKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ChangeKind_CHANGE_KIND_UNSPECIFIED
+}
+
+func (x *FeedEvent) GetLink() *Link {
+	if x != nil {
+		return x.Link
+	}
+	return nil
+}
+
+func (x *FeedEvent) GetGeneratedAt() string {
+	if x != nil {
+		return x.GeneratedAt
+	}
+	return ""
+}
+
+var File_linkleaf_v1_service_proto protoreflect.FileDescriptor
+
+const file_linkleaf_v1_service_proto_rawDesc = "" +
+	"\n" +
+	"\x19linkleaf/v1/service.proto\x12\vlinkleaf.v1\x1a\x16linkleaf/v1/feed.proto\"\xe2\x01\n" +
+	"\x10ListLinksRequest\x12\x12\n" +
+	"\x04tags\x18\x01 \x03(\tR\x04tags\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x14\n" +
+	"\x05after\x18\x03 \x01(\tR\x05after\x12\x16\n" +
+	"\x06before\x18\x04 \x01(\tR\x06before\x12\x14\n" +
+	"\x05query\x18\x05 \x01(\tR\x05query\x12\x16\n" +
+	"\x06unread\x18\x06 \x01(\bR\x06unread\x12\x18\n" +
+	"\astarred\x18\a \x01(\bR\astarred\x12\x16\n" +
+	"\x06offset\x18\b \x01(\rR\x06offset\x12\x14\n" +
+	"\x05limit\x18\t \x01(\rR\x05limit\"P\n" +
+	"\x11ListLinksResponse\x12'\n" +
+	"\x05links\x18\x01 \x03(\v2\x11.linkleaf.v1.LinkR\x05links\x12\x12\n" +
+	"\x04more\x18\x02 \x01(\bR\x04more\"7\n" +
+	"\x0eAddLinkRequest\x12%\n" +
+	"\x04link\x18\x01 \x01(\v2\x11.linkleaf.v1.LinkR\x04link\"R\n" +
+	"\x11UpdateLinkRequest\x12%\n" +
+	"\x04link\x18\x01 \x01(\v2\x11.linkleaf.v1.LinkR\x04link\x12\x16\n" +
+	"\x06fields\x18\x02 \x03(\tR\x06fields\"#\n" +
+	"\x11DeleteLinkRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\";\n" +
+	"\x12DeleteLinkResponse\x12%\n" +
+	"\x04link\x18\x01 \x01(\v2\x11.linkleaf.v1.LinkR\x04link\"\x12\n" +
+	"\x10WatchFeedRequest\"\x82\x01\n" +
+	"\tFeedEvent\x12+\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x17.linkleaf.v1.ChangeKindR\x04kind\x12%\n" +
+	"\x04link\x18\x02 \x01(\v2\x11.linkleaf.v1.LinkR\x04link\x12!\n" +
+	"\fgenerated_at\x18\x03 \x01(\tR\vgeneratedAt*s\n" +
+	"\n" +
+	"ChangeKind\x12\x1b\n" +
+	"\x17CHANGE_KIND_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11CHANGE_KIND_ADDED\x10\x01\x12\x18\n" +
+	"\x14CHANGE_KIND_MODIFIED\x10\x02\x12\x17\n" +
+	"\x13CHANGE_KIND_REMOVED\x10\x032\xea\x02\n" +
+	"\vFeedService\x12J\n" +
+	"\tListLinks\x12\x1d.linkleaf.v1.ListLinksRequest\x1a\x1e.linkleaf.v1.ListLinksResponse\x129\n" +
+	"\aAddLink\x12\x1b.linkleaf.v1.AddLinkRequest\x1a\x11.linkleaf.v1.Link\x12?\n" +
+	"\n" +
+	"UpdateLink\x12\x1e.linkleaf.v1.UpdateLinkRequest\x1a\x11.linkleaf.v1.Link\x12M\n" +
+	"\n" +
+	"DeleteLink\x12\x1e.linkleaf.v1.DeleteLinkRequest\x1a\x1f.linkleaf.v1.DeleteLinkResponse\x12D\n" +
+	"\tWatchFeed\x12\x1d.linkleaf.v1.WatchFeedRequest\x1a\x16.linkleaf.v1.FeedEvent0\x01B:Z8github.com/doriancodes/linkleaf-cli/proto/linkleaf/v1;v1b\x06proto3"
+
+var (
+	file_linkleaf_v1_service_proto_rawDescOnce sync.Once
+	file_linkleaf_v1_service_proto_rawDescData []byte
+)
+
+func file_linkleaf_v1_service_proto_rawDescGZIP() []byte {
+	file_linkleaf_v1_service_proto_rawDescOnce.Do(func() {
+		file_linkleaf_v1_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_linkleaf_v1_service_proto_rawDesc), len(file_linkleaf_v1_service_proto_rawDesc)))
+	})
+	return file_linkleaf_v1_service_proto_rawDescData
+}
+
+var file_linkleaf_v1_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_linkleaf_v1_service_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_linkleaf_v1_service_proto_goTypes = []any{
+	(ChangeKind)(0),            // 0: linkleaf.v1.ChangeKind
+	(*ListLinksRequest)(nil),   // 1: linkleaf.v1.ListLinksRequest
+	(*ListLinksResponse)(nil),  // 2: linkleaf.v1.ListLinksResponse
+	(*AddLinkRequest)(nil),     // 3: linkleaf.v1.AddLinkRequest
+	(*UpdateLinkRequest)(nil),  // 4: linkleaf.v1.UpdateLinkRequest
+	(*DeleteLinkRequest)(nil),  // 5: linkleaf.v1.DeleteLinkRequest
+	(*DeleteLinkResponse)(nil), // 6: linkleaf.v1.DeleteLinkResponse
+	(*WatchFeedRequest)(nil),   // 7: linkleaf.v1.WatchFeedRequest
+	(*FeedEvent)(nil),          // 8: linkleaf.v1.FeedEvent
+	(*Link)(nil),               // 9: linkleaf.v1.Link
+}
+var file_linkleaf_v1_service_proto_depIdxs = []int32{
+	9,  // 0: linkleaf.v1.ListLinksResponse.links:type_name -> linkleaf.v1.Link
+	9,  // 1: linkleaf.v1.AddLinkRequest.link:type_name -> linkleaf.v1.Link
+	9,  // 2: linkleaf.v1.UpdateLinkRequest.link:type_name -> linkleaf.v1.Link
+	9,  // 3: linkleaf.v1.DeleteLinkResponse.link:type_name -> linkleaf.v1.Link
+	0,  // 4: linkleaf.v1.FeedEvent.kind:type_name -> linkleaf.v1.ChangeKind
+	9,  // 5: linkleaf.v1.FeedEvent.link:type_name -> linkleaf.v1.Link
+	1,  // 6: linkleaf.v1.FeedService.ListLinks:input_type -> linkleaf.v1.ListLinksRequest
+	3,  // 7: linkleaf.v1.FeedService.AddLink:input_type -> linkleaf.v1.AddLinkRequest
+	4,  // 8: linkleaf.v1.FeedService.UpdateLink:input_type -> linkleaf.v1.UpdateLinkRequest
+	5,  // 9: linkleaf.v1.FeedService.DeleteLink:input_type -> linkleaf.v1.DeleteLinkRequest
+	7,  // 10: linkleaf.v1.FeedService.WatchFeed:input_type -> linkleaf.v1.WatchFeedRequest
+	2,  // 11: linkleaf.v1.FeedService.ListLinks:output_type -> linkleaf.v1.ListLinksResponse
+	9,  // 12: linkleaf.v1.FeedService.AddLink:output_type -> linkleaf.v1.Link
+	9,  // 13: linkleaf.v1.FeedService.UpdateLink:output_type -> linkleaf.v1.Link
+	6,  // 14: linkleaf.v1.FeedService.DeleteLink:output_type -> linkleaf.v1.DeleteLinkResponse
+	8,  // 15: linkleaf.v1.FeedService.WatchFeed:output_type -> linkleaf.v1.FeedEvent
+	11, // [11:16] is the sub-list for method output_type
+	6,  // [6:11] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_linkleaf_v1_service_proto_init() }
+func file_linkleaf_v1_service_proto_init() {
+	if File_linkleaf_v1_service_proto != nil {
+		return
+	}
+	file_linkleaf_v1_feed_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_linkleaf_v1_service_proto_rawDesc), len(file_linkleaf_v1_service_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_linkleaf_v1_service_proto_goTypes,
+		DependencyIndexes: file_linkleaf_v1_service_proto_depIdxs,
+		EnumInfos:         file_linkleaf_v1_service_proto_enumTypes,
+		MessageInfos:      file_linkleaf_v1_service_proto_msgTypes,
+	}.Build()
+	File_linkleaf_v1_service_proto = out.File
+	file_linkleaf_v1_service_proto_goTypes = nil
+	file_linkleaf_v1_service_proto_depIdxs = nil
+}
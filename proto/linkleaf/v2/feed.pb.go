@@ -0,0 +1,1015 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v3.19.6
+// source: linkleaf/v2/feed.proto
+
+package v2
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Feed is linkleaf.v1.Feed with its times as timestamps. "linkleaf migrate
+// v1-to-v2" writes it from a v1 feed and "migrate v2-to-v1" reads it back,
+// losing nothing either way.
+type Feed struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The linkleaf.v1 Feed.version the feed was migrated at.
+	Version     uint32                 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Title       string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	GeneratedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	Links       []*Link                `protobuf:"bytes,4,rep,name=links,proto3" json:"links,omitempty"`
+	// Who the feed is by (a person or a team).
+	Author string `protobuf:"bytes,5,opt,name=author,proto3" json:"author,omitempty"`
+	// RSS and Atom feeds "linkleaf subscribe pull" imports new items from.
+	Subscriptions []*Subscription `protobuf:"bytes,6,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	// What the feed is about.
+	Description string `protobuf:"bytes,7,opt,name=description,proto3" json:"description,omitempty"`
+	// The site the feed belongs to.
+	HomePageUrl string `protobuf:"bytes,8,opt,name=home_page_url,json=homePageUrl,proto3" json:"home_page_url,omitempty"`
+	// URL of an image standing for the feed.
+	Icon string `protobuf:"bytes,9,opt,name=icon,proto3" json:"icon,omitempty"`
+	// BCP 47 language tag of the feed as a whole (e.g., en, de-AT).
+	Lang string `protobuf:"bytes,10,opt,name=lang,proto3" json:"lang,omitempty"`
+	// Removed links, newest first.
+	Trash         []*TrashedLink `protobuf:"bytes,11,rep,name=trash,proto3" json:"trash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Feed) Reset() {
+	*x = Feed{}
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Feed) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Feed) ProtoMessage() {}
+
+func (x *Feed) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Feed.ProtoReflect.Descriptor instead.
+func (*Feed) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v2_feed_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Feed) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Feed) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Feed) GetGeneratedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.GeneratedAt
+	}
+	return nil
+}
+
+func (x *Feed) GetLinks() []*Link {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *Feed) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Feed) GetSubscriptions() []*Subscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+func (x *Feed) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Feed) GetHomePageUrl() string {
+	if x != nil {
+		return x.HomePageUrl
+	}
+	return ""
+}
+
+func (x *Feed) GetIcon() string {
+	if x != nil {
+		return x.Icon
+	}
+	return ""
+}
+
+func (x *Feed) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *Feed) GetTrash() []*TrashedLink {
+	if x != nil {
+		return x.Trash
+	}
+	return nil
+}
+
+type Link struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Stable short ID; never empty, ".", ".." or containing a slash.
+	Id      string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title   string   `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Url     string   `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	Summary string   `protobuf:"bytes,4,opt,name=summary,proto3" json:"summary,omitempty"`
+	Tags    []string `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	// When the link is from: a day, or a time of day in a zone.
+	Date *LinkDate `protobuf:"bytes,6,opt,name=date,proto3" json:"date,omitempty"`
+	// Optional attribution ("via" URL).
+	Via string `protobuf:"bytes,7,opt,name=via,proto3" json:"via,omitempty"`
+	// When the link was added to the feed.
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Outcome of the most recent check; unset if never checked.
+	LastCheck *LinkCheck `protobuf:"bytes,9,opt,name=last_check,json=lastCheck,proto3" json:"last_check,omitempty"`
+	// When the link was last changed; unset until it is edited.
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Long-form commentary beyond the one-line summary; blank lines separate
+	// paragraphs.
+	Notes string `protobuf:"bytes,11,opt,name=notes,proto3" json:"notes,omitempty"`
+	// Read-later state: set once the link has been read.
+	Read bool `protobuf:"varint,12,opt,name=read,proto3" json:"read,omitempty"`
+	// Set once the link is archived: kept for reference, no longer current.
+	Archived bool `protobuf:"varint,13,opt,name=archived,proto3" json:"archived,omitempty"`
+	// Favorite, for picking links out of a long feed.
+	Starred bool `protobuf:"varint,14,opt,name=starred,proto3" json:"starred,omitempty"`
+	// Preserved copy of the page: a Wayback Machine URL, or the path of a
+	// local snapshot.
+	ArchiveUrl string `protobuf:"bytes,15,opt,name=archive_url,json=archiveUrl,proto3" json:"archive_url,omitempty"`
+	// Who added the link; unset means the feed's author.
+	Author string `protobuf:"bytes,16,opt,name=author,proto3" json:"author,omitempty"`
+	// IDs of other links in the feed this one refers to.
+	RelatedIds []string `protobuf:"bytes,17,rep,name=related_ids,json=relatedIds,proto3" json:"related_ids,omitempty"`
+	// Free-form data the schema has no field for.
+	Meta map[string]string `protobuf:"bytes,18,rep,name=meta,proto3" json:"meta,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Media file the link comes with (a podcast episode, a video).
+	Enclosure *Enclosure `protobuf:"bytes,19,opt,name=enclosure,proto3" json:"enclosure,omitempty"`
+	// Drafts stay out of exports until they are published.
+	Draft bool `protobuf:"varint,20,opt,name=draft,proto3" json:"draft,omitempty"`
+	// Before this time the link stays out of exports; unset means right away.
+	PublishAt *timestamppb.Timestamp `protobuf:"bytes,21,opt,name=publish_at,json=publishAt,proto3" json:"publish_at,omitempty"`
+	// The checks before last_check, oldest first.
+	CheckHistory []*LinkCheck `protobuf:"bytes,22,rep,name=check_history,json=checkHistory,proto3" json:"check_history,omitempty"`
+	// Short name of the link's permalink page, unique within the feed.
+	Slug string `protobuf:"bytes,23,opt,name=slug,proto3" json:"slug,omitempty"`
+	// Language of the page, a BCP 47 tag; unset means unknown.
+	Lang string `protobuf:"bytes,24,opt,name=lang,proto3" json:"lang,omitempty"`
+	// Words in the page's text; 0 means not counted.
+	WordCount uint32 `protobuf:"varint,25,opt,name=word_count,json=wordCount,proto3" json:"word_count,omitempty"`
+	// Estimated minutes to read the page; 0 means unknown.
+	ReadingMinutes uint32 `protobuf:"varint,26,opt,name=reading_minutes,json=readingMinutes,proto3" json:"reading_minutes,omitempty"`
+	// Path of the readable copy of the page's article.
+	ArticlePath string `protobuf:"bytes,27,opt,name=article_path,json=articlePath,proto3" json:"article_path,omitempty"`
+	// Passages quoted from the page, in the order they were added.
+	Quotes        []string `protobuf:"bytes,28,rep,name=quotes,proto3" json:"quotes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Link) Reset() {
+	*x = Link{}
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Link) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Link) ProtoMessage() {}
+
+func (x *Link) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Link.ProtoReflect.Descriptor instead.
+func (*Link) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v2_feed_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Link) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Link) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Link) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Link) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *Link) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Link) GetDate() *LinkDate {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *Link) GetVia() string {
+	if x != nil {
+		return x.Via
+	}
+	return ""
+}
+
+func (x *Link) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Link) GetLastCheck() *LinkCheck {
+	if x != nil {
+		return x.LastCheck
+	}
+	return nil
+}
+
+func (x *Link) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Link) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *Link) GetRead() bool {
+	if x != nil {
+		return x.Read
+	}
+	return false
+}
+
+func (x *Link) GetArchived() bool {
+	if x != nil {
+		return x.Archived
+	}
+	return false
+}
+
+func (x *Link) GetStarred() bool {
+	if x != nil {
+		return x.Starred
+	}
+	return false
+}
+
+func (x *Link) GetArchiveUrl() string {
+	if x != nil {
+		return x.ArchiveUrl
+	}
+	return ""
+}
+
+func (x *Link) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Link) GetRelatedIds() []string {
+	if x != nil {
+		return x.RelatedIds
+	}
+	return nil
+}
+
+func (x *Link) GetMeta() map[string]string {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+func (x *Link) GetEnclosure() *Enclosure {
+	if x != nil {
+		return x.Enclosure
+	}
+	return nil
+}
+
+func (x *Link) GetDraft() bool {
+	if x != nil {
+		return x.Draft
+	}
+	return false
+}
+
+func (x *Link) GetPublishAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PublishAt
+	}
+	return nil
+}
+
+func (x *Link) GetCheckHistory() []*LinkCheck {
+	if x != nil {
+		return x.CheckHistory
+	}
+	return nil
+}
+
+func (x *Link) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *Link) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *Link) GetWordCount() uint32 {
+	if x != nil {
+		return x.WordCount
+	}
+	return 0
+}
+
+func (x *Link) GetReadingMinutes() uint32 {
+	if x != nil {
+		return x.ReadingMinutes
+	}
+	return 0
+}
+
+func (x *Link) GetArticlePath() string {
+	if x != nil {
+		return x.ArticlePath
+	}
+	return ""
+}
+
+func (x *Link) GetQuotes() []string {
+	if x != nil {
+		return x.Quotes
+	}
+	return nil
+}
+
+// LinkDate is when a link is from. A day (linkleaf.v1's YYYY-MM-DD) is its
+// midnight UTC with all_day set; a time keeps the UTC offset it was given
+// in.
+type LinkDate struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Time  *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	// Seconds east of UTC of the zone the time was given in.
+	UtcOffset int32 `protobuf:"varint,2,opt,name=utc_offset,json=utcOffset,proto3" json:"utc_offset,omitempty"`
+	AllDay    bool  `protobuf:"varint,3,opt,name=all_day,json=allDay,proto3" json:"all_day,omitempty"`
+	// The v1 date as written when time doesn't render it back the same (a
+	// malformed date, or seconds spelled out); unset otherwise.
+	Text          string `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkDate) Reset() {
+	*x = LinkDate{}
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkDate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkDate) ProtoMessage() {}
+
+func (x *LinkDate) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkDate.ProtoReflect.Descriptor instead.
+func (*LinkDate) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v2_feed_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LinkDate) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *LinkDate) GetUtcOffset() int32 {
+	if x != nil {
+		return x.UtcOffset
+	}
+	return 0
+}
+
+func (x *LinkDate) GetAllDay() bool {
+	if x != nil {
+		return x.AllDay
+	}
+	return false
+}
+
+func (x *LinkDate) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type Enclosure struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// MIME type, e.g. "audio/mpeg".
+	MimeType string `protobuf:"bytes,2,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	// Size in bytes; 0 when unknown.
+	Length        int64 `protobuf:"varint,3,opt,name=length,proto3" json:"length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Enclosure) Reset() {
+	*x = Enclosure{}
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Enclosure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Enclosure) ProtoMessage() {}
+
+func (x *Enclosure) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Enclosure.ProtoReflect.Descriptor instead.
+func (*Enclosure) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v2_feed_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Enclosure) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Enclosure) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *Enclosure) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+// Subscription is a source feed registered with "linkleaf subscribe add".
+type Subscription struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// URL of the RSS or Atom document.
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Name to show instead of the URL; optional.
+	Title string `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	// Tags every link imported from it gets.
+	Tags    []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	AddedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=added_at,json=addedAt,proto3" json:"added_at,omitempty"`
+	// When the last pull reached it.
+	PulledAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=pulled_at,json=pulledAt,proto3" json:"pulled_at,omitempty"`
+	// Why the last pull failed; unset when it succeeded.
+	Error string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	// ETag and Last-Modified of the last response.
+	Etag         string `protobuf:"bytes,7,opt,name=etag,proto3" json:"etag,omitempty"`
+	LastModified string `protobuf:"bytes,8,opt,name=last_modified,json=lastModified,proto3" json:"last_modified,omitempty"`
+	// Normalized URLs of the items the feed held at the last pull.
+	Seen          []string `protobuf:"bytes,9,rep,name=seen,proto3" json:"seen,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subscription) Reset() {
+	*x = Subscription{}
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscription) ProtoMessage() {}
+
+func (x *Subscription) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
+func (*Subscription) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v2_feed_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Subscription) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Subscription) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Subscription) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Subscription) GetAddedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AddedAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetPulledAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PulledAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Subscription) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *Subscription) GetLastModified() string {
+	if x != nil {
+		return x.LastModified
+	}
+	return ""
+}
+
+func (x *Subscription) GetSeen() []string {
+	if x != nil {
+		return x.Seen
+	}
+	return nil
+}
+
+// TrashedLink is a removed link kept until it is purged.
+type TrashedLink struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Link      *Link                  `protobuf:"bytes,1,opt,name=link,proto3" json:"link,omitempty"`
+	RemovedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=removed_at,json=removedAt,proto3" json:"removed_at,omitempty"`
+	// Its position in the feed's links when removed.
+	Index int32 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	// IDs of the links whose related_ids held it.
+	ReferencedBy  []string `protobuf:"bytes,4,rep,name=referenced_by,json=referencedBy,proto3" json:"referenced_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrashedLink) Reset() {
+	*x = TrashedLink{}
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrashedLink) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrashedLink) ProtoMessage() {}
+
+func (x *TrashedLink) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrashedLink.ProtoReflect.Descriptor instead.
+func (*TrashedLink) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v2_feed_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TrashedLink) GetLink() *Link {
+	if x != nil {
+		return x.Link
+	}
+	return nil
+}
+
+func (x *TrashedLink) GetRemovedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RemovedAt
+	}
+	return nil
+}
+
+func (x *TrashedLink) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *TrashedLink) GetReferencedBy() []string {
+	if x != nil {
+		return x.ReferencedBy
+	}
+	return nil
+}
+
+// LinkCheck records one probe of a link's URL.
+type LinkCheck struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	CheckedAt *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=checked_at,json=checkedAt,proto3" json:"checked_at,omitempty"`
+	// Final HTTP status; 0 when the request failed.
+	Status int32 `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	// Connection/timeout error, if any.
+	Error string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	// URL after redirects, if it differs from Link.url.
+	FinalUrl string `protobuf:"bytes,4,opt,name=final_url,json=finalUrl,proto3" json:"final_url,omitempty"`
+	// Failed checks in a row, up to and including this one; 0 when it
+	// succeeded.
+	Failures      int32 `protobuf:"varint,5,opt,name=failures,proto3" json:"failures,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkCheck) Reset() {
+	*x = LinkCheck{}
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkCheck) ProtoMessage() {}
+
+func (x *LinkCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_linkleaf_v2_feed_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkCheck.ProtoReflect.Descriptor instead.
+func (*LinkCheck) Descriptor() ([]byte, []int) {
+	return file_linkleaf_v2_feed_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LinkCheck) GetCheckedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CheckedAt
+	}
+	return nil
+}
+
+func (x *LinkCheck) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *LinkCheck) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *LinkCheck) GetFinalUrl() string {
+	if x != nil {
+		return x.FinalUrl
+	}
+	return ""
+}
+
+func (x *LinkCheck) GetFailures() int32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+var File_linkleaf_v2_feed_proto protoreflect.FileDescriptor
+
+const file_linkleaf_v2_feed_proto_rawDesc = "" +
+	"\n" +
+	"\x16linkleaf/v2/feed.proto\x12\vlinkleaf.v2\x1a\x1fgoogle/protobuf/timestamp.proto\"\x95\x03\n" +
+	"\x04Feed\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\rR\aversion\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12=\n" +
+	"\fgenerated_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\vgeneratedAt\x12'\n" +
+	"\x05links\x18\x04 \x03(\v2\x11.linkleaf.v2.LinkR\x05links\x12\x16\n" +
+	"\x06author\x18\x05 \x01(\tR\x06author\x12?\n" +
+	"\rsubscriptions\x18\x06 \x03(\v2\x19.linkleaf.v2.SubscriptionR\rsubscriptions\x12 \n" +
+	"\vdescription\x18\a \x01(\tR\vdescription\x12\"\n" +
+	"\rhome_page_url\x18\b \x01(\tR\vhomePageUrl\x12\x12\n" +
+	"\x04icon\x18\t \x01(\tR\x04icon\x12\x12\n" +
+	"\x04lang\x18\n" +
+	" \x01(\tR\x04lang\x12.\n" +
+	"\x05trash\x18\v \x03(\v2\x18.linkleaf.v2.TrashedLinkR\x05trash\"\xe9\a\n" +
+	"\x04Link\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12\x18\n" +
+	"\asummary\x18\x04 \x01(\tR\asummary\x12\x12\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\x12)\n" +
+	"\x04date\x18\x06 \x01(\v2\x15.linkleaf.v2.LinkDateR\x04date\x12\x10\n" +
+	"\x03via\x18\a \x01(\tR\x03via\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x125\n" +
+	"\n" +
+	"last_check\x18\t \x01(\v2\x16.linkleaf.v2.LinkCheckR\tlastCheck\x129\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x14\n" +
+	"\x05notes\x18\v \x01(\tR\x05notes\x12\x12\n" +
+	"\x04read\x18\f \x01(\bR\x04read\x12\x1a\n" +
+	"\barchived\x18\r \x01(\bR\barchived\x12\x18\n" +
+	"\astarred\x18\x0e \x01(\bR\astarred\x12\x1f\n" +
+	"\varchive_url\x18\x0f \x01(\tR\n" +
+	"archiveUrl\x12\x16\n" +
+	"\x06author\x18\x10 \x01(\tR\x06author\x12\x1f\n" +
+	"\vrelated_ids\x18\x11 \x03(\tR\n" +
+	"relatedIds\x12/\n" +
+	"\x04meta\x18\x12 \x03(\v2\x1b.linkleaf.v2.Link.MetaEntryR\x04meta\x124\n" +
+	"\tenclosure\x18\x13 \x01(\v2\x16.linkleaf.v2.EnclosureR\tenclosure\x12\x14\n" +
+	"\x05draft\x18\x14 \x01(\bR\x05draft\x129\n" +
+	"\n" +
+	"publish_at\x18\x15 \x01(\v2\x1a.google.protobuf.TimestampR\tpublishAt\x12;\n" +
+	"\rcheck_history\x18\x16 \x03(\v2\x16.linkleaf.v2.LinkCheckR\fcheckHistory\x12\x12\n" +
+	"\x04slug\x18\x17 \x01(\tR\x04slug\x12\x12\n" +
+	"\x04lang\x18\x18 \x01(\tR\x04lang\x12\x1d\n" +
+	"\n" +
+	"word_count\x18\x19 \x01(\rR\twordCount\x12'\n" +
+	"\x0freading_minutes\x18\x1a \x01(\rR\x0ereadingMinutes\x12!\n" +
+	"\farticle_path\x18\x1b \x01(\tR\varticlePath\x12\x16\n" +
+	"\x06quotes\x18\x1c \x03(\tR\x06quotes\x1a7\n" +
+	"\tMetaEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x86\x01\n" +
+	"\bLinkDate\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x1d\n" +
+	"\n" +
+	"utc_offset\x18\x02 \x01(\x05R\tutcOffset\x12\x17\n" +
+	"\aall_day\x18\x03 \x01(\bR\x06allDay\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\"R\n" +
+	"\tEnclosure\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x1b\n" +
+	"\tmime_type\x18\x02 \x01(\tR\bmimeType\x12\x16\n" +
+	"\x06length\x18\x03 \x01(\x03R\x06length\"\x9d\x02\n" +
+	"\fSubscription\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x125\n" +
+	"\badded_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\aaddedAt\x127\n" +
+	"\tpulled_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bpulledAt\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x12\x12\n" +
+	"\x04etag\x18\a \x01(\tR\x04etag\x12#\n" +
+	"\rlast_modified\x18\b \x01(\tR\flastModified\x12\x12\n" +
+	"\x04seen\x18\t \x03(\tR\x04seen\"\xaa\x01\n" +
+	"\vTrashedLink\x12%\n" +
+	"\x04link\x18\x01 \x01(\v2\x11.linkleaf.v2.LinkR\x04link\x129\n" +
+	"\n" +
+	"removed_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tremovedAt\x12\x14\n" +
+	"\x05index\x18\x03 \x01(\x05R\x05index\x12#\n" +
+	"\rreferenced_by\x18\x04 \x03(\tR\freferencedBy\"\xad\x01\n" +
+	"\tLinkCheck\x129\n" +
+	"\n" +
+	"checked_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\tcheckedAt\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\x05R\x06status\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x1b\n" +
+	"\tfinal_url\x18\x04 \x01(\tR\bfinalUrl\x12\x1a\n" +
+	"\bfailures\x18\x05 \x01(\x05R\bfailuresB:Z8github.com/doriancodes/linkleaf-cli/proto/linkleaf/v2;v2b\x06proto3"
+
+var (
+	file_linkleaf_v2_feed_proto_rawDescOnce sync.Once
+	file_linkleaf_v2_feed_proto_rawDescData []byte
+)
+
+func file_linkleaf_v2_feed_proto_rawDescGZIP() []byte {
+	file_linkleaf_v2_feed_proto_rawDescOnce.Do(func() {
+		file_linkleaf_v2_feed_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_linkleaf_v2_feed_proto_rawDesc), len(file_linkleaf_v2_feed_proto_rawDesc)))
+	})
+	return file_linkleaf_v2_feed_proto_rawDescData
+}
+
+var file_linkleaf_v2_feed_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_linkleaf_v2_feed_proto_goTypes = []any{
+	(*Feed)(nil),                  // 0: linkleaf.v2.Feed
+	(*Link)(nil),                  // 1: linkleaf.v2.Link
+	(*LinkDate)(nil),              // 2: linkleaf.v2.LinkDate
+	(*Enclosure)(nil),             // 3: linkleaf.v2.Enclosure
+	(*Subscription)(nil),          // 4: linkleaf.v2.Subscription
+	(*TrashedLink)(nil),           // 5: linkleaf.v2.TrashedLink
+	(*LinkCheck)(nil),             // 6: linkleaf.v2.LinkCheck
+	nil,                           // 7: linkleaf.v2.Link.MetaEntry
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+}
+var file_linkleaf_v2_feed_proto_depIdxs = []int32{
+	8,  // 0: linkleaf.v2.Feed.generated_at:type_name -> google.protobuf.Timestamp
+	1,  // 1: linkleaf.v2.Feed.links:type_name -> linkleaf.v2.Link
+	4,  // 2: linkleaf.v2.Feed.subscriptions:type_name -> linkleaf.v2.Subscription
+	5,  // 3: linkleaf.v2.Feed.trash:type_name -> linkleaf.v2.TrashedLink
+	2,  // 4: linkleaf.v2.Link.date:type_name -> linkleaf.v2.LinkDate
+	8,  // 5: linkleaf.v2.Link.created_at:type_name -> google.protobuf.Timestamp
+	6,  // 6: linkleaf.v2.Link.last_check:type_name -> linkleaf.v2.LinkCheck
+	8,  // 7: linkleaf.v2.Link.updated_at:type_name -> google.protobuf.Timestamp
+	7,  // 8: linkleaf.v2.Link.meta:type_name -> linkleaf.v2.Link.MetaEntry
+	3,  // 9: linkleaf.v2.Link.enclosure:type_name -> linkleaf.v2.Enclosure
+	8,  // 10: linkleaf.v2.Link.publish_at:type_name -> google.protobuf.Timestamp
+	6,  // 11: linkleaf.v2.Link.check_history:type_name -> linkleaf.v2.LinkCheck
+	8,  // 12: linkleaf.v2.LinkDate.time:type_name -> google.protobuf.Timestamp
+	8,  // 13: linkleaf.v2.Subscription.added_at:type_name -> google.protobuf.Timestamp
+	8,  // 14: linkleaf.v2.Subscription.pulled_at:type_name -> google.protobuf.Timestamp
+	1,  // 15: linkleaf.v2.TrashedLink.link:type_name -> linkleaf.v2.Link
+	8,  // 16: linkleaf.v2.TrashedLink.removed_at:type_name -> google.protobuf.Timestamp
+	8,  // 17: linkleaf.v2.LinkCheck.checked_at:type_name -> google.protobuf.Timestamp
+	18, // [18:18] is the sub-list for method output_type
+	18, // [18:18] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_linkleaf_v2_feed_proto_init() }
+func file_linkleaf_v2_feed_proto_init() {
+	if File_linkleaf_v2_feed_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_linkleaf_v2_feed_proto_rawDesc), len(file_linkleaf_v2_feed_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_linkleaf_v2_feed_proto_goTypes,
+		DependencyIndexes: file_linkleaf_v2_feed_proto_depIdxs,
+		MessageInfos:      file_linkleaf_v2_feed_proto_msgTypes,
+	}.Build()
+	File_linkleaf_v2_feed_proto = out.File
+	file_linkleaf_v2_feed_proto_goTypes = nil
+	file_linkleaf_v2_feed_proto_depIdxs = nil
+}